@@ -4,10 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"epistemic-me-core/svc/models"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	openai "github.com/sashabaranov/go-openai"
 )
@@ -66,37 +72,136 @@ const (
 	GPT_LATEST LLMModel = openai.GPT4oMini
 )
 
+// ChatCompletionClient is the part of the OpenAI client used by AIHelper.
+type ChatCompletionClient interface {
+	CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+}
+
 type AIHelper struct {
-	client *openai.Client
+	client ChatCompletionClient
+	// cache, when enabled, serves repeated identical prompts without a client call
+	cache *responseCache
+	// prompts are the templates of the prompts that can be overridden
+	prompts PromptSet
+	// sampling is applied to every chat completion request
+	sampling SamplingConfig
+	// promptTokenBudget bounds the estimated size of prompts carrying a belief system
+	promptTokenBudget int
+	// questionHistoryWindow is how many recent interactions questions are generated from verbatim
+	questionHistoryWindow int
+	// ctx, when set by WithContext, bounds every request the helper makes
+	ctx context.Context
+}
+
+// modelLister is implemented by clients that can list the provider's models, such as *openai.Client
+type modelLister interface {
+	ListModels(ctx context.Context) (openai.ModelsList, error)
+}
+
+// Ping checks that the AI provider is reachable with a lightweight models-list call.
+// Clients that cannot list models are assumed reachable.
+func (aih *AIHelper) Ping(ctx context.Context) error {
+	lister, ok := aih.client.(modelLister)
+	if !ok {
+		return nil
+	}
+	_, err := lister.ListModels(ctx)
+	return err
 }
 
 type InteractionEvent struct {
 	Question string `json:"question"`
 	Answer   string `json:"answer"`
+	// ExtractedBeliefs are the beliefs extracted from the answer, when known
+	ExtractedBeliefs []string `json:"extracted_beliefs,omitempty"`
 }
 
 // Constructor for AIHelper
-func NewAIHelper(apiKey string) *AIHelper {
+func NewAIHelper(apiKey string, opts ...Option) *AIHelper {
 	client := openai.NewClient(apiKey)
-	return &AIHelper{
-		client: client,
+	return NewAIHelperWithClient(client, opts...)
+}
+
+// NewAIHelperWithClient creates an AIHelper that sends its requests through the given client
+func NewAIHelperWithClient(client ChatCompletionClient, opts ...Option) *AIHelper {
+	aih := &AIHelper{
+		client:                client,
+		prompts:               DefaultPromptSet(),
+		questionHistoryWindow: DefaultQuestionHistoryWindow,
 	}
+	for _, opt := range opts {
+		opt(aih)
+	}
+	return aih
 }
 
 func (aih *AIHelper) GenerateQuestion(beliefSystem string, previousEvents []InteractionEvent) (string, error) {
-	systemContext := fmt.Sprintf("Given these definitions %s. Generate a single question to further understand the user's belief system.", DIALECTICAL_STRATEGY)
-	if len(beliefSystem) > 0 {
-		systemContext += fmt.Sprintf(" The user's current belief system is %s", beliefSystem)
+	events, err := aih.questionEvents(previousEvents)
+	if err != nil {
+		return "", err
 	}
-	if len(previousEvents) > 0 {
-		events, err := json.Marshal(previousEvents)
-		if err != nil {
+	systemContext, err := aih.questionPrompt(beliefSystem, events)
+	if err != nil {
+		return "", err
+	}
+	// A belief system given as text can only be cut short to fit the budget
+	if budget := aih.tokenBudget(); estimateTokens(systemContext) > budget {
+		room := budget - (estimateTokens(systemContext) - estimateTokens(beliefSystem))
+		log.Printf("The question_generation prompt is about %d tokens, over the budget of %d: cutting the belief system to %d tokens",
+			estimateTokens(systemContext), budget, max(room, 0))
+		if systemContext, err = aih.questionPrompt(fitText(beliefSystem, room), events); err != nil {
 			return "", err
 		}
-		systemContext += fmt.Sprintf(" Ask a single novel question given the existing questions asked: %s", events)
 	}
+	return aih.generateQuestion(systemContext)
+}
 
-	response, err := aih.client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+// GenerateQuestionForBeliefSystem generates a question like GenerateQuestion, listing the active
+// beliefs of bs. When the prompt is over the token budget, the lowest-confidence beliefs are left
+// out until it fits.
+func (aih *AIHelper) GenerateQuestionForBeliefSystem(bs *models.BeliefSystem, previousEvents []InteractionEvent) (string, error) {
+	events, err := aih.questionEvents(previousEvents)
+	if err != nil {
+		return "", err
+	}
+	systemContext, err := aih.fitBeliefSystemPrompt("question_generation", bs, func(beliefSystem string) (string, error) {
+		return aih.questionPrompt(beliefSystem, events)
+	})
+	if err != nil {
+		return "", err
+	}
+	return aih.generateQuestion(systemContext)
+}
+
+// questionEvents encodes the interactions a question follows for the question generation prompt,
+// with the ones before the question history window condensed into a summary.
+func (aih *AIHelper) questionEvents(previousEvents []InteractionEvent) (string, error) {
+	if len(previousEvents) == 0 {
+		return "", nil
+	}
+	previousEvents, err := aih.CondenseInteractionEvents(previousEvents)
+	if err != nil {
+		return "", err
+	}
+	events, err := json.Marshal(previousEvents)
+	if err != nil {
+		return "", err
+	}
+	return string(events), nil
+}
+
+// questionPrompt renders the question generation prompt.
+func (aih *AIHelper) questionPrompt(beliefSystem, events string) (string, error) {
+	return renderPrompt("question_generation", aih.prompts.QuestionGeneration, QuestionPromptData{
+		Strategy:       DIALECTICAL_STRATEGY,
+		BeliefSystem:   beliefSystem,
+		PreviousEvents: events,
+	})
+}
+
+// generateQuestion asks for a question with the rendered question generation prompt.
+func (aih *AIHelper) generateQuestion(systemContext string) (string, error) {
+	response, err := aih.createChatCompletion(aih.context(), openai.ChatCompletionRequest{
 		Model: string(GPT_LATEST),
 		Messages: []openai.ChatCompletionMessage{
 			{Role: "system", Content: systemContext},
@@ -116,7 +221,7 @@ func (aih *AIHelper) GenerateBeliefSystem(activeBeliefs []string) (string, error
 		return "", err
 	}
 
-	response, err := aih.client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+	response, err := aih.createChatCompletion(aih.context(), openai.ChatCompletionRequest{
 		Model: string(GPT_LATEST),
 		Messages: []openai.ChatCompletionMessage{
 			{Role: "system", Content: fmt.Sprintf("Given these definitions %s. Construct a belief system based on these events", DIALECTICAL_STRATEGY)},
@@ -131,53 +236,221 @@ func (aih *AIHelper) GenerateBeliefSystem(activeBeliefs []string) (string, error
 }
 
 func (aih *AIHelper) GetInteractionEventAsBelief(event InteractionEvent) ([]string, error) {
+	return aih.GetInteractionEventAsBeliefWithHistory(event, nil)
+}
+
+// GetInteractionEventAsBeliefWithHistory extracts the beliefs of an interaction like
+// GetInteractionEventAsBelief, showing the model the interactions that preceded it, oldest first,
+// so an answer referring back to them ("it", "that") is extracted with what it refers to.
+func (aih *AIHelper) GetInteractionEventAsBeliefWithHistory(event InteractionEvent, history []InteractionEvent) ([]string, error) {
+	userMessage, err := extractionMessage(event, history)
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt, err := renderPrompt("belief_extraction", aih.prompts.BeliefExtraction, BeliefExtractionPromptData{Strategy: DIALECTICAL_STRATEGY})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Beliefs []string `json:"beliefs"`
+	}
+	if err := aih.CompletePromptJSON(aih.context(), systemPrompt, userMessage, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse belief response: %w", err)
+	}
+	return response.Beliefs, nil
+}
+
+// extractionMessage asks for the beliefs of event. Without history it is the single-interaction
+// message; with history the earlier interactions are listed first, for context only.
+func extractionMessage(event InteractionEvent, history []InteractionEvent) (string, error) {
 	eventJson, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	if len(history) == 0 {
+		return fmt.Sprintf("Extract beliefs from this interaction: %s", eventJson), nil
+	}
+	historyJson, err := json.Marshal(history)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Earlier in this conversation: %s\n\n"+
+		"Extract beliefs from this interaction only, using the earlier conversation to resolve what its answer refers to, "+
+		"and state each belief without pronouns that depend on that context: %s", historyJson, eventJson), nil
+}
+
+// ClassifiedBelief is an extracted belief statement together with its kind.
+type ClassifiedBelief struct {
+	Content string
+	Type    models.BeliefType
+}
+
+// GetInteractionEventAsClassifiedBeliefs extracts the beliefs of an interaction and, in the same
+// prompt, classifies each as a statement, a falsifiable belief or a causal belief.
+func (aih *AIHelper) GetInteractionEventAsClassifiedBeliefs(event InteractionEvent) ([]ClassifiedBelief, error) {
+	return aih.GetInteractionEventAsClassifiedBeliefsWithHistory(event, nil)
+}
+
+// GetInteractionEventAsClassifiedBeliefsWithHistory is GetInteractionEventAsClassifiedBeliefs with
+// the interactions that preceded event, oldest first, shown to the model to resolve references.
+func (aih *AIHelper) GetInteractionEventAsClassifiedBeliefsWithHistory(event InteractionEvent, history []InteractionEvent) ([]ClassifiedBelief, error) {
+	userMessage, err := extractionMessage(event, history)
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt, err := renderPrompt("classified_belief_extraction", aih.prompts.ClassifiedBeliefExtraction, BeliefExtractionPromptData{Strategy: DIALECTICAL_STRATEGY})
 	if err != nil {
 		return nil, err
 	}
 
-	response, err := aih.client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+	request := openai.ChatCompletionRequest{
 		Model: string(GPT_LATEST),
 		Messages: []openai.ChatCompletionMessage{
-			{Role: "system", Content: fmt.Sprintf(`Given these definitions %s. 
-				Extract all beliefs from the user's response. 
-				Return ONLY a JSON object with a "beliefs" array containing all belief statements.
-				Example: {"beliefs": [
-					"I believe that quality sleep is essential for energy",
-					"I believe that maintaining a consistent sleep schedule improves sleep quality"
-				]}`, DIALECTICAL_STRATEGY)},
-			{Role: "user", Content: fmt.Sprintf("Extract beliefs from this interaction: %s", eventJson)},
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userMessage},
 		},
+	}
+	var beliefs []ClassifiedBelief
+	err = aih.completeJSON(request, func(content string) error {
+		var err error
+		beliefs, err = parseClassifiedBeliefs(content)
+		return err
 	})
 	if err != nil {
-		log.Printf("Error from AI: %v", err)
+		return nil, fmt.Errorf("failed to parse belief response: %w", err)
+	}
+	return beliefs, nil
+}
+
+// parseClassifiedBeliefs reads the "beliefs" array of a classifying extraction response. Plain
+// strings are accepted as statements, as are beliefs of an unknown type.
+func parseClassifiedBeliefs(content string) ([]ClassifiedBelief, error) {
+	object, err := parseJSONObject(content)
+	if err != nil {
 		return nil, err
 	}
+	raw, ok := object["beliefs"]
+	if !ok || string(raw) == "null" {
+		return nil, fmt.Errorf("%w: missing required field %q", ErrMalformedResponse, "beliefs")
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("%w: field %q is not an array", ErrMalformedResponse, "beliefs")
+	}
 
-	// Log the AI response
-	log.Printf("AI response: %s", response.Choices[0].Message.Content)
+	beliefs := make([]ClassifiedBelief, 0, len(items))
+	for _, item := range items {
+		var statement string
+		if err := json.Unmarshal(item, &statement); err == nil {
+			if statement = models.NormalizeBeliefContent(statement); statement != "" {
+				beliefs = append(beliefs, ClassifiedBelief{Content: statement, Type: models.Statement})
+			}
+			continue
+		}
 
-	// Parse the JSON response
-	var beliefResponse struct {
-		Beliefs []string `json:"beliefs"`
+		var belief struct {
+			Content string `json:"content"`
+			Type    string `json:"type"`
+		}
+		err := json.Unmarshal(item, &belief)
+		content := models.NormalizeBeliefContent(belief.Content)
+		if err != nil || content == "" {
+			return nil, fmt.Errorf("%w: every belief needs a %q", ErrMalformedResponse, "content")
+		}
+		beliefs = append(beliefs, ClassifiedBelief{Content: content, Type: beliefTypeFromName(belief.Type)})
 	}
-	if err := json.Unmarshal([]byte(response.Choices[0].Message.Content), &beliefResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse belief response: %w", err)
+	return beliefs, nil
+}
+
+func beliefTypeFromName(name string) models.BeliefType {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "causal":
+		return models.Causal
+	case "falsifiable":
+		return models.Falsifiable
+	default:
+		return models.Statement
 	}
+}
 
-	return beliefResponse.Beliefs, nil
+// beliefTypeName is the name of t used in prompts, the inverse of beliefTypeFromName.
+func beliefTypeName(t models.BeliefType) string {
+	switch t {
+	case models.Causal:
+		return "causal"
+	case models.Falsifiable:
+		return "falsifiable"
+	case models.Clarification:
+		return "clarification"
+	default:
+		return "statement"
+	}
 }
 
-func (aih *AIHelper) ExtractBeliefsFromResource(resource models.Resource) ([]string, error) {
+// parseBeliefs reads the "beliefs" array of a belief extraction response.
+func parseBeliefs(content string) ([]string, error) {
+	object, err := parseJSONObject(content)
+	if err != nil {
+		return nil, err
+	}
+	beliefs, err := object.stringArray("beliefs")
+	if err != nil {
+		return nil, err
+	}
+	return normalizeBeliefs(beliefs), nil
+}
+
+// normalizeBeliefs puts extracted beliefs in their normalized form, dropping any left empty.
+func normalizeBeliefs(beliefs []string) []string {
+	normalized := make([]string, 0, len(beliefs))
+	for _, belief := range beliefs {
+		if belief = models.NormalizeBeliefContent(belief); belief != "" {
+			normalized = append(normalized, belief)
+		}
+	}
+	return normalized
+}
+
+// GetInteractionEventsAsBeliefs extracts the beliefs of several interactions with a single AI call.
+// Results are indexed like events. If the batch response cannot be parsed, each event is extracted
+// on its own with GetInteractionEventAsBelief.
+func (aih *AIHelper) GetInteractionEventsAsBeliefs(events []InteractionEvent) ([][]string, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	if len(events) == 1 {
+		beliefs, err := aih.GetInteractionEventAsBelief(events[0])
+		if err != nil {
+			return nil, err
+		}
+		return [][]string{beliefs}, nil
+	}
+
+	type indexedEvent struct {
+		Index int `json:"index"`
+		InteractionEvent
+	}
+	indexed := make([]indexedEvent, len(events))
+	for i, event := range events {
+		indexed[i] = indexedEvent{Index: i, InteractionEvent: event}
+	}
+	eventsJson, err := json.Marshal(indexed)
+	if err != nil {
+		return nil, err
+	}
 
-	response, err := aih.client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+	response, err := aih.createChatCompletion(aih.context(), openai.ChatCompletionRequest{
 		Model: string(GPT_LATEST),
 		Messages: []openai.ChatCompletionMessage{
 			{Role: "system", Content: fmt.Sprintf(`Given these definitions %s. 
-				Extract a series of beleifs from this document. 
-				Return ONLY a JSON object with a "beliefs" field containing the array of belief statement.
-				Example: {"beliefs": ["Quality sleep is essential for energy", "HRV is a biomarker for good health"]}`, DIALECTICAL_STRATEGY)},
-			{Role: "user", Content: fmt.Sprintf("Extract a belief from this document: %s", resource.Content)},
+				Extract all beliefs from the user's response in each of the numbered interactions. 
+				Return ONLY a JSON object with an "interactions" array holding, for every interaction, its "index" and a "beliefs" array of belief statements.
+				Example: {"interactions": [
+					{"index": 0, "beliefs": ["I believe that quality sleep is essential for energy"]},
+					{"index": 1, "beliefs": []}
+				]}`, DIALECTICAL_STRATEGY)},
+			{Role: "user", Content: fmt.Sprintf("Extract beliefs from these interactions: %s", eventsJson)},
 		},
 	})
 	if err != nil {
@@ -185,50 +458,139 @@ func (aih *AIHelper) ExtractBeliefsFromResource(resource models.Resource) ([]str
 		return nil, err
 	}
 
-	// Log the AI response
-	log.Printf("AI response: %s", response.Choices[0].Message.Content)
+	beliefs, err := parseBatchBeliefs(response.Choices[0].Message.Content, len(events))
+	if err != nil {
+		log.Printf("Falling back to extracting beliefs per interaction: %v", err)
+		beliefs = make([][]string, len(events))
+		for i, event := range events {
+			if beliefs[i], err = aih.GetInteractionEventAsBelief(event); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return beliefs, nil
+}
 
-	// Extract JSON from the response
-	jsonStr := extractJSON(response.Choices[0].Message.Content)
-	if jsonStr == "" {
-		return nil, fmt.Errorf("failed to extract JSON from response")
+// parseBatchBeliefs maps a batch extraction response back to its count interactions.
+func parseBatchBeliefs(content string, count int) ([][]string, error) {
+	object, err := parseJSONObject(content)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := object["interactions"]
+	if !ok || string(raw) == "null" {
+		return nil, fmt.Errorf("%w: missing required field %q", ErrMalformedResponse, "interactions")
 	}
 
-	// Parse the JSON response
-	var beliefResponse struct {
+	var interactions []struct {
+		Index   *int     `json:"index"`
 		Beliefs []string `json:"beliefs"`
 	}
-	if err := json.Unmarshal([]byte(jsonStr), &beliefResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse belief response: %w", err)
+	if err := json.Unmarshal(raw, &interactions); err != nil {
+		return nil, fmt.Errorf("%w: field %q is not an array of interactions", ErrMalformedResponse, "interactions")
 	}
 
-	return beliefResponse.Beliefs, nil
+	beliefs := make([][]string, count)
+	for _, interaction := range interactions {
+		if interaction.Index == nil || *interaction.Index < 0 || *interaction.Index >= count {
+			return nil, fmt.Errorf("%w: invalid interaction index", ErrMalformedResponse)
+		}
+		beliefs[*interaction.Index] = append(beliefs[*interaction.Index], normalizeBeliefs(interaction.Beliefs)...)
+	}
+	return beliefs, nil
 }
 
-func (aih *AIHelper) DetermineBeliefValidity(oldBeliefs []*models.Belief, newBeliefs []*models.Belief) ([]string, []string, error) {
-	// STEP 1: Prepare the system instruction.
-	// We now request two arrays of IDs—kept vs. deleted—in a clearly specified JSON structure.
-	systemInstruction := `
-You are given two lists:
-1. Old beliefs (each with an ID and content).
-2. New beliefs.
+// ExtractBeliefsFromResource extracts the beliefs a document holds. Documents longer than
+// ResourceChunkSize are extracted chunk by chunk.
+func (aih *AIHelper) ExtractBeliefsFromResource(resource models.Resource) ([]string, error) {
+	var beliefs []string
+	for _, chunk := range chunkText(resource.Content, ResourceChunkSize) {
+		request := openai.ChatCompletionRequest{
+			Model: string(GPT_LATEST),
+			Messages: []openai.ChatCompletionMessage{
+				{Role: "system", Content: fmt.Sprintf(`Given these definitions %s. 
+				Extract a series of beleifs from this document. 
+				Return ONLY a JSON object with a "beliefs" field containing the array of belief statement.
+				Example: {"beliefs": ["Quality sleep is essential for energy", "HRV is a biomarker for good health"]}`, DIALECTICAL_STRATEGY)},
+				{Role: "user", Content: fmt.Sprintf("Extract a belief from this document: %s", chunk)},
+			},
+		}
+		var chunkBeliefs []string
+		err := aih.completeJSON(request, func(content string) error {
+			var err error
+			chunkBeliefs, err = parseBeliefs(content)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse belief response: %w", err)
+		}
+		beliefs = append(beliefs, chunkBeliefs...)
+	}
+	return beliefs, nil
+}
 
-Task: Determine which old beliefs are invalidated by the new beliefs and which remain valid.
+// MaxInferredStates bounds how many possible states InferPossibleStates returns for a context.
+const MaxInferredStates = 5
 
-Return ONLY a JSON object with two arrays of belief IDs:
-{
-  "kept_belief_ids": ["...","..."],
-  "deleted_belief_ids": ["...","..."]
+// InferPossibleStates asks for a small set of discrete states an observation context could be
+// observed in, judged from the beliefs held about it. The states are normalized like extracted
+// questions and at most MaxInferredStates are returned.
+func (aih *AIHelper) InferPossibleStates(contextName string, beliefs []string) ([]string, error) {
+	beliefsJSON, err := json.Marshal(beliefs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal beliefs: %w", err)
+	}
+
+	request := openai.ChatCompletionRequest{
+		Model: string(GPT_LATEST),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: fmt.Sprintf(`An observation context is a situation a person can observe, such as their sleep or their energy in the morning.
+Given an observation context and the beliefs a person holds about it, list between 2 and %d mutually exclusive states the context could be observed in.
+Each state is a short label of one to three words.
+Return ONLY a JSON object with a "states" array.
+Example: {"states": ["Well rested", "Tired", "Exhausted"]}`, MaxInferredStates)},
+			{Role: "user", Content: fmt.Sprintf("Observation context: %s\nBeliefs (JSON): %s", contextName, beliefsJSON)},
+		},
+	}
+	var states []string
+	err = aih.completeJSON(request, func(content string) error {
+		object, err := parseJSONObject(content)
+		if err != nil {
+			return err
+		}
+		states, err = object.stringArray("states")
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to infer possible states: %w", err)
+	}
+
+	states = uniqueStates(states)
+	if len(states) > MaxInferredStates {
+		states = states[:MaxInferredStates]
+	}
+	return states, nil
 }
 
-Important:
- - "kept_belief_ids" should be the IDs of old beliefs that remain valid.
- - "deleted_belief_ids" should be the IDs of old beliefs that are no longer valid.
- - Do NOT include the new beliefs in the returned IDs.
- - Do NOT include any markdown formatting or backticks in your response.
-`
+// uniqueStates trims the states and drops blank ones and case-insensitive repeats, keeping the
+// first spelling.
+func uniqueStates(states []string) []string {
+	seen := make(map[string]bool, len(states))
+	unique := make([]string, 0, len(states))
+	for _, state := range states {
+		state = strings.Join(strings.Fields(state), " ")
+		key := strings.ToLower(state)
+		if state == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, state)
+	}
+	return unique
+}
 
-	// STEP 2: Marshal both old and new beliefs for the AI.
+func (aih *AIHelper) DetermineBeliefValidity(oldBeliefs []*models.Belief, newBeliefs []*models.Belief) ([]string, []string, error) {
+	// STEP 1: Marshal both old and new beliefs for the AI.
 	oldBeliefsJSON, err := json.Marshal(oldBeliefs)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to marshal oldBeliefs: %w", err)
@@ -239,6 +601,16 @@ Important:
 		return nil, nil, fmt.Errorf("failed to marshal newBeliefs: %w", err)
 	}
 
+	// STEP 2: Prepare the system instruction.
+	// It requests two arrays of IDs—kept vs. deleted—in a clearly specified JSON structure.
+	systemInstruction, err := renderPrompt("belief_validity", aih.prompts.BeliefValidity, BeliefValidityPromptData{
+		OldBeliefs: string(oldBeliefsJSON),
+		NewBeliefs: string(newBeliefsJSON),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// STEP 3: Prompt: Provide old and new beliefs, request two ID lists.
 	prompt := fmt.Sprintf(`
 Old Beliefs (JSON):
@@ -250,41 +622,18 @@ New Beliefs (JSON):
 Please return ONLY a JSON object with kept_belief_ids and deleted_belief_ids arrays.
 `, string(oldBeliefsJSON), string(newBeliefsJSON))
 
-	// STEP 4: Call OpenAI
-	response, err := aih.client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
-		Model: string(GPT_LATEST),
-		Messages: []openai.ChatCompletionMessage{
-			{Role: "system", Content: systemInstruction},
-			{Role: "user", Content: prompt},
-		},
-	})
-	if err != nil {
-		log.Printf("Error from AI: %v", err)
-		return nil, nil, err
-	}
-
-	// STEP 5: Extract the raw text returned by ChatGPT.
-	aiContent := response.Choices[0].Message.Content
+	// STEP 4: Call OpenAI, retrying once if the response lacks either list.
 	log.Printf("Old Beliefs: %s", oldBeliefsJSON)
-	log.Printf("AI response: %s", aiContent)
-
-	// Extract JSON from the response if needed
-	jsonStr := extractJSON(aiContent)
-	if jsonStr == "" {
-		return nil, nil, fmt.Errorf("no valid JSON found in response")
-	}
-
-	// STEP 6: Unmarshal the JSON that ChatGPT returns.
-	var parsedResponse struct {
+	var response struct {
 		KeptBeliefIDs    []string `json:"kept_belief_ids"`
 		DeletedBeliefIDs []string `json:"deleted_belief_ids"`
 	}
-	if err := json.Unmarshal([]byte(jsonStr), &parsedResponse); err != nil {
+	if err := aih.CompletePromptJSON(aih.context(), systemInstruction, prompt, &response); err != nil {
 		return nil, nil, fmt.Errorf("failed to parse JSON from AI: %w", err)
 	}
 
 	// Return the two lists: kept and deleted.
-	return parsedResponse.KeptBeliefIDs, parsedResponse.DeletedBeliefIDs, nil
+	return response.KeptBeliefIDs, response.DeletedBeliefIDs, nil
 }
 
 // ProvidePerspectiveOnQuestionAndAnswer generates
@@ -301,7 +650,7 @@ func (aih *AIHelper) ProvidePerspectiveOnQuestionAndAnswer(
 	)
 
 	// Make a single API call to retrieve the perspective
-	perspectiveResponse, err := aih.client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+	perspectiveResponse, err := aih.createChatCompletion(aih.context(), openai.ChatCompletionRequest{
 		Model: string(GPT_LATEST),
 		Messages: []openai.ChatCompletionMessage{
 			{
@@ -327,7 +676,7 @@ func (aih *AIHelper) UpdateBeliefWithInteractionEvent(event InteractionEvent, ex
 		return false, "", err
 	}
 
-	response, err := aih.client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+	response, err := aih.createChatCompletion(aih.context(), openai.ChatCompletionRequest{
 		Model: string(GPT_LATEST),
 		Messages: []openai.ChatCompletionMessage{
 			{Role: "system", Content: "Determine whether a user interaction and an existing belief have any relevance to each other or not."},
@@ -342,7 +691,7 @@ func (aih *AIHelper) UpdateBeliefWithInteractionEvent(event InteractionEvent, ex
 		return false, "", nil
 	}
 
-	response, err = aih.client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+	response, err = aih.createChatCompletion(aih.context(), openai.ChatCompletionRequest{
 		Model: string(GPT_LATEST),
 		Messages: []openai.ChatCompletionMessage{
 			{Role: "system", Content: fmt.Sprintf("Given these definitions %s. Construct a belief that underlies the information present in the user event", DIALECTICAL_STRATEGY)},
@@ -364,39 +713,51 @@ const (
 	// Add more strategies as needed
 )
 
+// GenerateAnalysisForStrategy analyzes the belief system following strategy. The analysis is never
+// nil without an error, and its scores are kept between 0 and 1.
 func (h *AIHelper) GenerateAnalysisForStrategy(strategy DialecticStrategy, beliefSystem *models.BeliefSystem, userInteractions []models.DialecticalInteraction, interactionEvent InteractionEvent) (*models.BeliefAnalysis, error) {
+	var analysis *models.BeliefAnalysis
+	var err error
 	switch strategy {
 	case StrategySleepDietExercise:
-		return h.generateSleepDietExerciseAnalysis(beliefSystem, userInteractions, interactionEvent)
+		analysis, err = h.generateSleepDietExerciseAnalysis(beliefSystem, userInteractions, interactionEvent)
 	default:
-		return h.generateDefaultAnalysis(beliefSystem, userInteractions, interactionEvent)
+		analysis, err = h.generateDefaultAnalysis(beliefSystem, userInteractions, interactionEvent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if analysis == nil {
+		return nil, fmt.Errorf("no analysis generated for strategy %d", strategy)
 	}
+	clampAnalysisScores(analysis)
+	return analysis, nil
 }
 
-func (h *AIHelper) generateSleepDietExerciseAnalysis(beliefSystem *models.BeliefSystem, userInteractions []models.DialecticalInteraction, interactionEvent InteractionEvent) (*models.BeliefAnalysis, error) {
-	systemPrompt := fmt.Sprintf(`Analyze the following belief system related to sleep, diet, and exercise:
-%s
-
-Consider the latest interaction:
-Question: %s
-Answer: %s
+// clampAnalysisScores keeps the scores of an analysis between 0 and 1, whatever the model returned.
+func clampAnalysisScores(analysis *models.BeliefAnalysis) {
+	for _, score := range []*float32{&analysis.Coherence, &analysis.Consistency, &analysis.Falsifiability, &analysis.OverallScore} {
+		*score = float32(math.Max(0, math.Min(1, float64(*score))))
+	}
+}
 
-Provide an analysis focusing on:
-1. Coherence of beliefs related to sleep, diet, and exercise
-2. Consistency of beliefs with established health principles
-3. Falsifiability of the beliefs
-4. Overall understanding of the relationship between sleep, diet, exercise, metabolism, and energy
+func (h *AIHelper) generateSleepDietExerciseAnalysis(beliefSystem *models.BeliefSystem, userInteractions []models.DialecticalInteraction, interactionEvent InteractionEvent) (*models.BeliefAnalysis, error) {
+	return h.generateAnalysis("analysis", h.prompts.Analysis, beliefSystem, interactionEvent)
+}
 
-Respond ONLY with a JSON object in the following structure:
-{
-  "coherence": float,
-  "consistency": float,
-  "falsifiability": float,
-  "overallScore": float,
-  "feedback": string,
-  "recommendations": [string],
-  "verifiedBeliefs": [string]
-}`, beliefSystemToString(beliefSystem), interactionEvent.Question, interactionEvent.Answer)
+// generateAnalysis scores the belief system with the analysis prompt text, in the light of
+// interactionEvent when it holds a question.
+func (h *AIHelper) generateAnalysis(name, text string, beliefSystem *models.BeliefSystem, interactionEvent InteractionEvent) (*models.BeliefAnalysis, error) {
+	systemPrompt, err := h.fitBeliefSystemPrompt(name, beliefSystem, func(listing string) (string, error) {
+		return renderPrompt(name, text, AnalysisPromptData{
+			BeliefSystem: listing,
+			Question:     interactionEvent.Question,
+			Answer:       interactionEvent.Answer,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	response, err := h.getCompletionFromAI(systemPrompt)
 	if err != nil {
@@ -421,35 +782,69 @@ Respond ONLY with a JSON object in the following structure:
 	return &analysis, nil
 }
 
-func extractJSON(s string) string {
-	// Find the first occurrence of '{' and the last occurrence of '}'
-	start := strings.Index(s, "{")
-	end := strings.LastIndex(s, "}")
+// codeFence matches a markdown code block, optionally tagged as json, capturing its body.
+var codeFence = regexp.MustCompile("(?s)```(?:json|JSON)?[ \t]*\n?(.*?)```")
 
-	if start == -1 || end == -1 || end <= start {
-		return ""
+// extractJSON returns the first complete JSON object in a model response. A JSON object inside a
+// markdown code fence is preferred over one in the surrounding prose.
+func extractJSON(s string) string {
+	for _, match := range codeFence.FindAllStringSubmatch(s, -1) {
+		if object := firstJSONObject(match[1]); object != "" {
+			return object
+		}
 	}
+	return firstJSONObject(s)
+}
 
-	// Extract the substring between '{' and '}'
-	jsonCandidate := s[start : end+1]
-
-	// Use a regular expression to validate the JSON structure
-	re := regexp.MustCompile(`^\s*\{[\s\S]*\}\s*$`)
-	if !re.MatchString(jsonCandidate) {
-		return ""
+// firstJSONObject scans s for balanced braces, ignoring those inside string literals, and returns
+// the first balanced span that is valid JSON.
+func firstJSONObject(s string) string {
+	for start := strings.IndexByte(s, '{'); start != -1; {
+		if end := matchingBrace(s, start); end != -1 && json.Valid([]byte(s[start:end+1])) {
+			return s[start : end+1]
+		}
+		next := strings.IndexByte(s[start+1:], '{')
+		if next == -1 {
+			break
+		}
+		start += next + 1
 	}
+	return ""
+}
 
-	return jsonCandidate
+// matchingBrace returns the index of the brace closing the one at start, or -1 if it is unclosed.
+func matchingBrace(s string, start int) int {
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
 }
 
 func (h *AIHelper) generateDefaultAnalysis(beliefSystem *models.BeliefSystem, userInteractions []models.DialecticalInteraction, interactionEvent InteractionEvent) (*models.BeliefAnalysis, error) {
-	// Implementation similar to generateSleepDietExerciseAnalysis, but with a more general focus
-	// ... (implement this method)
-	return nil, nil
+	return h.generateAnalysis("general_analysis", h.prompts.GeneralAnalysis, beliefSystem, interactionEvent)
 }
 
 func (h *AIHelper) getCompletionFromAI(systemPrompt string) (string, error) {
-	response, err := h.client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+	response, err := h.createChatCompletion(h.context(), openai.ChatCompletionRequest{
 		Model: string(GPT_LATEST),
 		Messages: []openai.ChatCompletionMessage{
 			{Role: "system", Content: systemPrompt},
@@ -463,10 +858,50 @@ func (h *AIHelper) getCompletionFromAI(systemPrompt string) (string, error) {
 	return response.Choices[0].Message.Content, nil
 }
 
+// beliefSystemToString lists the active beliefs of bs, one per line, with each belief's type, its
+// confidence once evidence has been weighed, and the observation contexts it is held in.
 func beliefSystemToString(bs *models.BeliefSystem) string {
-	// Convert BeliefSystem to a string representation
-	// ... (implement this method)
-	return ""
+	if bs == nil {
+		return ""
+	}
+	contextNames := make(map[string]string)
+	var ppcs []*models.PredictiveProcessingContext
+	for _, ec := range bs.EpistemicContexts {
+		if ec == nil || ec.PredictiveProcessingContext == nil {
+			continue
+		}
+		ppcs = append(ppcs, ec.PredictiveProcessingContext)
+		for _, oc := range ec.PredictiveProcessingContext.ObservationContexts {
+			if oc != nil {
+				contextNames[oc.ID] = oc.Name
+			}
+		}
+	}
+	beliefContexts := make(map[string][]string)
+	for _, link := range models.BeliefContextLinks(ppcs) {
+		for _, id := range link.ObservationContextIDs {
+			if name := contextNames[id]; name != "" {
+				beliefContexts[link.BeliefID] = append(beliefContexts[link.BeliefID], name)
+			}
+		}
+	}
+
+	var lines []string
+	for _, belief := range bs.Beliefs {
+		if belief == nil || !belief.Active {
+			continue
+		}
+		details := beliefTypeName(belief.Type)
+		if belief.Confidence > 0 {
+			details += fmt.Sprintf(", confidence %.2f", belief.Confidence)
+		}
+		line := fmt.Sprintf("- [%s] %s", details, belief.GetContentAsString())
+		if names := beliefContexts[belief.ID]; len(names) > 0 {
+			line += " (contexts: " + strings.Join(names, ", ") + ")"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
 }
 
 func (h *AIHelper) PredictAnswer(question string) (string, error) {
@@ -478,14 +913,83 @@ func (h *AIHelper) PredictAnswer(question string) (string, error) {
 	return h.CompletePrompt(prompt)
 }
 
+// GetAnswerStateDistributions places each answer to question on the given states, returning for
+// every answer a probability per state that sums to 1. Results are indexed like answers.
+func (h *AIHelper) GetAnswerStateDistributions(question string, answers []string, states []string) ([]map[string]float32, error) {
+	answersJson, err := json.Marshal(answers)
+	if err != nil {
+		return nil, err
+	}
+	request := openai.ChatCompletionRequest{
+		Model: string(GPT_LATEST),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: fmt.Sprintf(`For each answer to the question, estimate how likely the answer's stance toward the question's subject is to be each of these states: %s.
+				Return ONLY a JSON object with a "distributions" array holding, for every answer in the order given, an object mapping each state to a probability between 0 and 1.
+				Example: {"distributions": [{"Positive": 0.7, "Negative": 0.1, "Neutral": 0.2}]}`, strings.Join(states, ", "))},
+			{Role: "user", Content: fmt.Sprintf("Question: %s\nAnswers: %s", question, answersJson)},
+		},
+	}
+	var distributions []map[string]float32
+	err = h.completeJSON(request, func(content string) error {
+		var err error
+		distributions, err = parseStateDistributions(content, states, len(answers))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse state distributions: %w", err)
+	}
+	return distributions, nil
+}
+
+// parseStateDistributions reads count distributions over states, normalizing each to sum to 1.
+func parseStateDistributions(content string, states []string, count int) ([]map[string]float32, error) {
+	object, err := parseJSONObject(content)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := object["distributions"]
+	if !ok || string(raw) == "null" {
+		return nil, fmt.Errorf("%w: missing required field %q", ErrMalformedResponse, "distributions")
+	}
+	var parsed []map[string]float32
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("%w: field %q is not an array of state probabilities", ErrMalformedResponse, "distributions")
+	}
+	if len(parsed) != count {
+		return nil, fmt.Errorf("%w: expected %d distributions, got %d", ErrMalformedResponse, count, len(parsed))
+	}
+
+	distributions := make([]map[string]float32, count)
+	for i, probabilities := range parsed {
+		var total float32
+		for _, state := range states {
+			if probabilities[state] > 0 {
+				total += probabilities[state]
+			}
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("%w: distribution %d gives no probability to any state", ErrMalformedResponse, i)
+		}
+		distributions[i] = make(map[string]float32, len(states))
+		for _, state := range states {
+			if probabilities[state] > 0 {
+				distributions[i][state] = probabilities[state] / total
+			} else {
+				distributions[i][state] = 0
+			}
+		}
+	}
+	return distributions, nil
+}
+
 // CompletePrompt sends a prompt to the AI model and returns the completion
 func (h *AIHelper) CompletePrompt(prompt string) (string, error) {
 	if h.client == nil {
 		return "", fmt.Errorf("AI client is not initialized")
 	}
 
-	resp, err := h.client.CreateChatCompletion(
-		context.Background(),
+	resp, err := h.createChatCompletion(
+		h.context(),
 		openai.ChatCompletionRequest{
 			Model: string(GPT_LATEST),
 			Messages: []openai.ChatCompletionMessage{
@@ -504,10 +1008,6 @@ func (h *AIHelper) CompletePrompt(prompt string) (string, error) {
 		return "", fmt.Errorf("failed to complete prompt: %w", err)
 	}
 
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no completion choices returned")
-	}
-
 	return resp.Choices[0].Message.Content, nil
 }
 
@@ -527,6 +1027,65 @@ Reply with only "true" if it answers the question, or "false" if it does not.`,
 	return strings.TrimSpace(strings.ToLower(response)) == "true", nil
 }
 
+// AreAnswersToQuestions checks with a single AI call whether each event's answer answers its
+// question. Results are indexed like events. If the batch response cannot be used, each event is
+// checked on its own with IsAnswerToQuestion.
+func (h *AIHelper) AreAnswersToQuestions(events []InteractionEvent) ([]bool, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	if len(events) == 1 {
+		relevant, err := h.IsAnswerToQuestion(events[0].Question, events[0].Answer)
+		if err != nil {
+			return nil, err
+		}
+		return []bool{relevant}, nil
+	}
+
+	eventsJson, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+	request := openai.ChatCompletionRequest{
+		Model: string(GPT_LATEST),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: `For each question and answer pair, decide whether the answer is a direct answer to the question.
+				Return ONLY a JSON object with a "relevant" array holding true or false for every pair, in the order given.
+				Example: {"relevant": [true, false]}`},
+			{Role: "user", Content: fmt.Sprintf("Check these question and answer pairs: %s", eventsJson)},
+		},
+	}
+	var relevant []bool
+	err = h.completeJSON(request, func(content string) error {
+		object, err := parseJSONObject(content)
+		if err != nil {
+			return err
+		}
+		if relevant, err = object.boolArray("relevant"); err != nil {
+			return err
+		}
+		if len(relevant) != len(events) {
+			return fmt.Errorf("%w: expected %d relevance results, got %d", ErrMalformedResponse, len(events), len(relevant))
+		}
+		return nil
+	})
+	if err == nil {
+		return relevant, nil
+	}
+	if !errors.Is(err, ErrMalformedResponse) {
+		return nil, err
+	}
+
+	log.Printf("Falling back to checking answers one at a time: %v", err)
+	relevant = make([]bool, len(events))
+	for i, event := range events {
+		if relevant[i], err = h.IsAnswerToQuestion(event.Question, event.Answer); err != nil {
+			return nil, err
+		}
+	}
+	return relevant, nil
+}
+
 func (h *AIHelper) ExtractQuestionsFromText(text string) ([]string, error) {
 	prompt := fmt.Sprintf(`Extract all distinct questions from this text. Return only the questions, one per line, without any numbering or bullets:
 
@@ -541,11 +1100,12 @@ Text: %s`, text)
 	var questions []string
 	for _, line := range strings.Split(strings.TrimSpace(response), "\n") {
 		line = strings.TrimSpace(line)
-		// Only include non-empty lines that end with a question mark
-		if line != "" && strings.HasSuffix(line, "?") {
+		// Only include lines that end with a question mark
+		if strings.HasSuffix(line, "?") {
 			questions = append(questions, line)
 		}
 	}
+	questions = NormalizeQuestions(questions)
 
 	if len(questions) == 0 {
 		return nil, fmt.Errorf("no valid questions found in text")
@@ -554,7 +1114,32 @@ Text: %s`, text)
 	return questions, nil
 }
 
+// NoAnswerProvided is what MatchAnswersToQuestions returns for a question the text does not answer.
+const NoAnswerProvided = "No answer provided"
+
+// AnswerMatch is the answer matched to a question, with how confident the AI is that the answer
+// addresses the question, from 0 to 1.
+type AnswerMatch struct {
+	Answer     string
+	Confidence float32
+}
+
 func (h *AIHelper) MatchAnswersToQuestions(answerBlob string, questions []string) ([]string, error) {
+	matches, err := h.MatchAnswersToQuestionsWithConfidence(answerBlob, questions)
+	if err != nil {
+		return nil, err
+	}
+	answers := make([]string, len(matches))
+	for i, match := range matches {
+		answers[i] = match.Answer
+	}
+	return answers, nil
+}
+
+// MatchAnswersToQuestionsWithConfidence matches answers to questions like MatchAnswersToQuestions,
+// along with the confidence of each match. An answer the AI gives no confidence for is taken as
+// certain, and a question without an answer has confidence 0.
+func (h *AIHelper) MatchAnswersToQuestionsWithConfidence(answerBlob string, questions []string) ([]AnswerMatch, error) {
 	prompt := fmt.Sprintf(`Given this text containing answers:
 "%s"
 
@@ -572,13 +1157,16 @@ Rules for Semantic Matching:
 6. Each answer should be self-contained
 7. Do not include answers to other questions
 8. If no relevant answer exists, return "No answer provided"
+9. After each answer, rate from 0 to 1 how confident you are that it answers the question
 
 Example Output Format:
 Q1: "How do you make your food choices?"
 A1: "My food choices are based on nutrition, hunger and enjoyment of food. I manage energy, and biomarkers in my blood to make healthy food choices."
+C1: 0.9
 
 Q2: "What do you eat for breakfast?"
 A2: "I start my day with a shake that contains MCT oil powder for energy."
+C2: 0.8
 
 Begin extracting answers, one per question:`,
 		answerBlob, strings.Join(questions, "\n"))
@@ -589,7 +1177,7 @@ Begin extracting answers, one per question:`,
 	}
 
 	// Parse Q&A format response
-	var answers []string
+	var matches []AnswerMatch
 	lines := strings.Split(strings.TrimSpace(response), "\n")
 	for i := 0; i < len(lines); i++ {
 		line := strings.TrimSpace(lines[i])
@@ -597,25 +1185,39 @@ Begin extracting answers, one per question:`,
 		if strings.HasPrefix(line, "Q") {
 			continue
 		}
+		// Extract just the part after the colon
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		switch {
 		// Process answer lines (starting with A)
-		if strings.HasPrefix(line, "A") {
-			// Extract just the answer part (after the colon)
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) == 2 {
-				answer := strings.TrimSpace(parts[1])
-				// Remove surrounding quotes if present
-				answer = strings.Trim(answer, `"`)
-				answers = append(answers, answer)
+		case strings.HasPrefix(line, "A"):
+			// Remove surrounding quotes if present
+			answer := strings.Trim(value, `"`)
+			matches = append(matches, AnswerMatch{Answer: answer, Confidence: 1})
+		// Process confidence lines (starting with C), which rate the answer before them
+		case strings.HasPrefix(line, "C") && len(matches) > 0:
+			confidence, err := strconv.ParseFloat(value, 32)
+			if err != nil {
+				continue
 			}
+			matches[len(matches)-1].Confidence = float32(math.Max(0, math.Min(1, confidence)))
 		}
 	}
 
 	// Ensure we have an answer for each question
-	for len(answers) < len(questions) {
-		answers = append(answers, "No answer provided")
+	for len(matches) < len(questions) {
+		matches = append(matches, AnswerMatch{Answer: NoAnswerProvided})
+	}
+	for i := range matches {
+		if matches[i].Answer == NoAnswerProvided || matches[i].Answer == "" {
+			matches[i].Confidence = 0
+		}
 	}
 
-	return answers[:len(questions)], nil
+	return matches[:len(questions)], nil
 }
 
 func (h *AIHelper) ExtractAndCleanQuestions(content string) []string {
@@ -635,15 +1237,37 @@ func (h *AIHelper) ExtractAndCleanQuestions(content string) []string {
 			line = strings.TrimSpace(line)
 			// Extract the question part if it's embedded in other text
 			if idx := strings.Index(line, "?"); idx >= 0 {
-				question := strings.TrimSpace(line[:idx+1])
-				if question != "" {
-					questions = append(questions, question)
-				}
+				questions = append(questions, line[:idx+1])
 			}
 		}
 	}
 
-	return questions
+	return NormalizeQuestions(questions)
+}
+
+// MinQuestionLength is the length, in characters, below which an extracted question is dropped as
+// too short to ask, such as a stray "?".
+const MinQuestionLength = 5
+
+// NormalizeQuestions trims each question and collapses its inner whitespace, then drops questions
+// shorter than MinQuestionLength and repeats of an earlier question, ignoring case. The order of the
+// kept questions is preserved.
+func NormalizeQuestions(questions []string) []string {
+	normalized := make([]string, 0, len(questions))
+	seen := make(map[string]bool, len(questions))
+	for _, question := range questions {
+		question = strings.Join(strings.Fields(question), " ")
+		if utf8.RuneCountInString(question) < MinQuestionLength {
+			continue
+		}
+		key := strings.ToLower(question)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, question)
+	}
+	return normalized
 }
 
 func (h *AIHelper) CleanAnswerText(content string) string {
@@ -660,9 +1284,27 @@ func (h *AIHelper) CleanAnswerText(content string) string {
 	return content
 }
 
-func (h *AIHelper) GenerateQuestionForLearningObjective(objective *models.LearningObjective, interactions []models.DialecticalInteraction) (string, error) {
-	// For the initial question (no interactions yet), start with a general question about all topics
-	if len(interactions) == 0 {
+// GenerateQuestionForLearningObjective generates the next question for a learning objective. The
+// topic it focuses on follows the objective's topic strategy: by default the least covered topic,
+// known once the progress computed by CheckLearningObjectiveCompletion is given, or with round-robin
+// the next topic in turn after the opening question.
+func (h *AIHelper) GenerateQuestionForLearningObjective(objective *models.LearningObjective, interactions []models.DialecticalInteraction, progress *models.LearningObjectiveProgress) (string, error) {
+	var topic string
+	var coverage *models.TopicCoverage
+	if len(interactions) > 0 {
+		switch objective.TopicStrategy {
+		case models.TopicStrategyRoundRobin:
+			topic = roundRobinTopic(objective.Topics, interactions)
+			if progress != nil {
+				coverage = progress.TopicCoverage[topic]
+			}
+		default:
+			topic, coverage = lowestCoverageTopic(objective.Topics, progress)
+		}
+	}
+
+	// For the initial question (or when there is no topic to focus on yet), start with a general question about all topics
+	if topic == "" {
 		prompt := fmt.Sprintf(`Given a learning objective to understand beliefs about: %s
 Generate an initial question that covers multiple topics (%s).
 The question should encourage detailed responses about personal beliefs and experiences.
@@ -673,66 +1315,18 @@ Return only the question, with no additional text.`,
 		return h.CompletePrompt(prompt)
 	}
 
-	// For subsequent questions, analyze topic coverage
-	var result struct {
-		TopicCoverage map[string]struct {
-			Percentage float32 `json:"percentage"`
-		} `json:"topic_coverage"`
-	}
-
-	// Get current completion analysis to determine which topic needs attention
-	completion, err := h.client.CreateChatCompletion(
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: openai.GPT4,
-			Messages: []openai.ChatCompletionMessage{
-				{Role: "system", Content: `You are a JSON-only response bot. Return EXACTLY this JSON structure with no other text:
-{
-    "topic_coverage": {
-        "topic1": {"percentage": 50.0},
-        "topic2": {"percentage": 75.0}
-    }
-}
-Replace topic1, topic2 with the actual topics from the input, and calculate real coverage percentages based on the beliefs.`},
-				{Role: "user", Content: fmt.Sprintf(`Analyze these topics: %v
-
-For each topic, calculate a coverage percentage (0-100) based on these beliefs:
-%s
-
-Return the coverage percentages in the specified JSON format.`,
-					strings.Join(objective.Topics, ", "),
-					formatInteractionBeliefs(interactions))},
-			},
-		},
-	)
-	if err != nil {
-		return "", fmt.Errorf("failed to analyze topic coverage: %w", err)
-	}
-
-	// Extract JSON from response if needed
-	responseContent := completion.Choices[0].Message.Content
-	jsonStr := extractJSON(responseContent)
-	if jsonStr == "" {
-		return "", fmt.Errorf("no valid JSON found in response: %s", responseContent)
-	}
-
-	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
-		return "", fmt.Errorf("failed to parse topic coverage: %w", err)
-	}
-
-	// Find the topic with lowest coverage
-	var lowestTopic string
-	var lowestCoverage float32 = 100.0
-	for topic, coverage := range result.TopicCoverage {
-		if coverage.Percentage < lowestCoverage {
-			lowestTopic = topic
-			lowestCoverage = coverage.Percentage
+	// Point the question at the categories that are still missing for that topic
+	var coverageNote, focus string
+	if coverage != nil {
+		coverageNote = fmt.Sprintf(" (current coverage: %.1f%%)", coverage.Percentage)
+		if len(coverage.MissingCategories) > 0 {
+			focus = fmt.Sprintf("\nBeliefs that are still missing for this topic: %s", strings.Join(coverage.MissingCategories, ", "))
 		}
 	}
 
-	// Generate a focused question for the topic with lowest coverage
+	// Generate a focused question for the chosen topic
 	prompt := fmt.Sprintf(`Given a learning objective to understand beliefs about: %s
-We are currently focusing on the topic: %s (current coverage: %.1f%%)
+We are currently focusing on the topic: %s%s%s
 
 Generate a focused question to gather more detailed beliefs about %s.
 If previous questions were general, ask about specific aspects or habits.
@@ -741,13 +1335,65 @@ If previous questions covered basics, ask about influences and experiences.
 The question should encourage detailed responses about personal beliefs and experiences.
 Return only the question, with no additional text.`,
 		objective.Description,
-		lowestTopic,
-		lowestCoverage,
-		lowestTopic)
+		topic,
+		coverageNote,
+		focus,
+		topic)
 
 	return h.CompletePrompt(prompt)
 }
 
+// lowestCoverageTopic returns the topic of progress with the lowest coverage, or no topic when no
+// coverage has been computed yet. Ties go to the topic listed first in topics, then to topics
+// outside it in alphabetical order, so the same progress always picks the same topic.
+func lowestCoverageTopic(topics []string, progress *models.LearningObjectiveProgress) (string, *models.TopicCoverage) {
+	if progress == nil {
+		return "", nil
+	}
+	ordered := make([]string, 0, len(progress.TopicCoverage))
+	listed := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		if _, ok := progress.TopicCoverage[topic]; ok && !listed[topic] {
+			ordered = append(ordered, topic)
+		}
+		listed[topic] = true
+	}
+	var unlisted []string
+	for topic := range progress.TopicCoverage {
+		if !listed[topic] {
+			unlisted = append(unlisted, topic)
+		}
+	}
+	sort.Strings(unlisted)
+	ordered = append(ordered, unlisted...)
+
+	var lowestTopic string
+	var lowestCoverage *models.TopicCoverage
+	for _, topic := range ordered {
+		coverage := progress.TopicCoverage[topic]
+		if coverage != nil && (lowestCoverage == nil || coverage.Percentage < lowestCoverage.Percentage) {
+			lowestTopic = topic
+			lowestCoverage = coverage
+		}
+	}
+	return lowestTopic, lowestCoverage
+}
+
+// roundRobinTopic returns the topic whose turn it is: the opening question covers every topic, and
+// each answered question after it moves on to the next topic.
+func roundRobinTopic(topics []string, interactions []models.DialecticalInteraction) string {
+	if len(topics) == 0 {
+		return ""
+	}
+	answered := 0
+	for _, interaction := range interactions {
+		if interaction.Status == models.StatusAnswered {
+			answered++
+		}
+	}
+	return topics[max(answered-1, 0)%len(topics)]
+}
+
 // Helper function to format beliefs from interactions
 func formatInteractionBeliefs(interactions []models.DialecticalInteraction) string {
 	var beliefs []string
@@ -762,8 +1408,9 @@ func formatInteractionBeliefs(interactions []models.DialecticalInteraction) stri
 	return strings.Join(beliefs, "\n")
 }
 
-// CheckLearningObjectiveCompletion determines how complete our learning objective is based on collected beliefs
-func (h *AIHelper) CheckLearningObjectiveCompletion(lo *models.LearningObjective, selfModel *models.SelfModel) (float32, error) {
+// CheckLearningObjectiveCompletion determines how complete our learning objective is based on collected beliefs,
+// including the per-topic coverage breakdown
+func (h *AIHelper) CheckLearningObjectiveCompletion(lo *models.LearningObjective, selfModel *models.SelfModel) (*models.LearningObjectiveProgress, error) {
 	// Extract all beliefs from the current belief system
 	var beliefs []string
 	for _, belief := range selfModel.BeliefSystem.Beliefs {
@@ -835,8 +1482,8 @@ Current Belief System:
 %s`, lo.Description, lo.Topics, strings.Join(beliefs, "\n"))
 
 	// Get completion analysis from OpenAI
-	completion, err := h.client.CreateChatCompletion(
-		context.Background(),
+	completion, err := h.createChatCompletion(
+		h.context(),
 		openai.ChatCompletionRequest{
 			Model: openai.GPT4,
 			Messages: []openai.ChatCompletionMessage{
@@ -846,24 +1493,15 @@ Current Belief System:
 		},
 	)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get completion analysis: %w", err)
+		return nil, fmt.Errorf("failed to get completion analysis: %w", err)
 	}
 
 	// Parse the response
-	var result struct {
-		CompletionPercentage float32 `json:"completion_percentage"`
-		TopicCoverage        map[string]struct {
-			Percentage        float32            `json:"percentage"`
-			CoveredCategories []string           `json:"covered_categories"`
-			MissingCategories []string           `json:"missing_categories"`
-			BeliefQuality     map[string]float32 `json:"belief_quality"`
-		} `json:"topic_coverage"`
-		Explanation string `json:"explanation"`
-	}
-
+	var result models.LearningObjectiveProgress
 	if err := json.Unmarshal([]byte(completion.Choices[0].Message.Content), &result); err != nil {
-		return 0, fmt.Errorf("failed to parse completion analysis: %w", err)
+		return nil, fmt.Errorf("failed to parse completion analysis: %w", err)
 	}
+	result.UpdatedAtMillisUTC = time.Now().UnixMilli()
 
 	// Log detailed analysis for each topic
 	log.Printf("\n=== Learning Objective Completion Analysis ===")
@@ -882,7 +1520,35 @@ Current Belief System:
 	}
 	log.Printf("\n=== End Analysis ===\n")
 
-	return result.CompletionPercentage, nil
+	return &result, nil
+}
+
+// MergeBeliefs synthesizes a single consolidated belief statement from several overlapping beliefs
+func (h *AIHelper) MergeBeliefs(beliefs []string) (string, error) {
+	prompt := fmt.Sprintf(`Given these definitions %s.
+The following beliefs overlap or restate each other:
+- %s
+
+Consolidate them into a single belief statement that preserves every distinct idea they express.
+Return only the consolidated belief, with no additional text.`,
+		DIALECTICAL_STRATEGY,
+		strings.Join(beliefs, "\n- "))
+
+	merged, err := h.CompletePrompt(prompt)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(merged), nil
+}
+
+// CheckLearningObjectiveCompletionPercentage is a convenience wrapper around CheckLearningObjectiveCompletion
+// that returns only the overall completion percentage.
+func (h *AIHelper) CheckLearningObjectiveCompletionPercentage(lo *models.LearningObjective, selfModel *models.SelfModel) (float32, error) {
+	progress, err := h.CheckLearningObjectiveCompletion(lo, selfModel)
+	if err != nil {
+		return 0, err
+	}
+	return progress.CompletionPercentage, nil
 }
 
 // GenerateAnswerFromBeliefSystem generates an answer to a question based on the user's belief system and philosophy
@@ -908,7 +1574,7 @@ Keep responses concise but informative, focusing on your personal beliefs and ex
 		strings.Join(beliefStrings, "\n"),
 		strings.Join(philosophies, "\n"))
 
-	response, err := aih.client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+	response, err := aih.createChatCompletion(aih.context(), openai.ChatCompletionRequest{
 		Model: string(GPT_LATEST),
 		Messages: []openai.ChatCompletionMessage{
 			{Role: "system", Content: systemPrompt},
@@ -0,0 +1,56 @@
+package ai_helper
+
+import (
+	"epistemic-me-core/svc/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeliefSystemToString(t *testing.T) {
+	bs := &models.BeliefSystem{
+		Beliefs: []*models.Belief{
+			{
+				ID:         "b1",
+				Type:       models.Causal,
+				Content:    []models.Content{{RawStr: "I believe that quality sleep gives me energy"}},
+				Active:     true,
+				Confidence: 0.75,
+			},
+			{
+				ID:      "b2",
+				Type:    models.Statement,
+				Content: []models.Content{{RawStr: "I believe that health matters"}},
+				Active:  true,
+			},
+			{
+				ID:      "b3",
+				Content: []models.Content{{RawStr: "I believe that coffee is harmless"}},
+				Active:  false,
+			},
+		},
+		EpistemicContexts: []*models.EpistemicContext{{
+			PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{
+					{ID: "oc1", Name: "Sleep"},
+					{ID: "oc2", Name: "Energy"},
+				},
+				BeliefContexts: []*models.BeliefContext{
+					{BeliefID: "b1", ObservationContextID: "oc1"},
+					{BeliefID: "b1", ObservationContextID: "oc2"},
+				},
+			},
+		}},
+	}
+
+	rendered := beliefSystemToString(bs)
+
+	lines := strings.Split(rendered, "\n")
+	assert.Equal(t, []string{
+		"- [causal, confidence 0.75] I believe that quality sleep gives me energy (contexts: Sleep, Energy)",
+		"- [statement] I believe that health matters",
+	}, lines)
+	assert.NotContains(t, rendered, "coffee")
+	assert.Empty(t, beliefSystemToString(nil))
+}
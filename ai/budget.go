@@ -0,0 +1,42 @@
+package ai_helper
+
+import (
+	"context"
+	"time"
+)
+
+// callBudgetShare is the share of the time left before a context's deadline that a single chat
+// completion may use, so a call that hangs still leaves time for the calls after it to fail fast
+// rather than the whole request running into its deadline.
+const callBudgetShare = 0.5
+
+// WithContext returns a helper sharing aih's client and cache whose requests are cancelled with
+// ctx and bounded by its deadline. When ctx carries a helper bound to the requesting developer's
+// OpenAI key (see ContextWithHelper), that helper's client and cache are used instead.
+func (aih *AIHelper) WithContext(ctx context.Context) *AIHelper {
+	base := aih
+	if bound := helperFromContext(ctx); bound != nil {
+		base = bound
+	}
+	scoped := *base
+	scoped.ctx = ctx
+	return &scoped
+}
+
+func (aih *AIHelper) context() context.Context {
+	if aih.ctx == nil {
+		return context.Background()
+	}
+	return aih.ctx
+}
+
+// callContext bounds a single chat completion to its share of the time left before ctx's deadline.
+// Without a deadline ctx is used as is.
+func callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx, func() {}
+	}
+	remaining := time.Until(deadline)
+	return context.WithTimeout(ctx, time.Duration(float64(remaining)*callBudgetShare))
+}
@@ -0,0 +1,54 @@
+package ai_helper
+
+import (
+	"context"
+	"testing"
+
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletePromptJSON(t *testing.T) {
+	var out struct {
+		Beliefs []string `json:"beliefs"`
+		Note    string   `json:"note,omitempty"`
+	}
+
+	t.Run("structured output", func(t *testing.T) {
+		client := fakeai.New(`{"beliefs": ["I believe sleep matters"]}`)
+		extracted := testutil.ToFloat64(aiJSONDecodes.WithLabelValues("extracted"))
+
+		err := NewAIHelperWithClient(client).CompletePromptJSON(context.Background(), "Reply in JSON.", "Extract beliefs.", &out)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"I believe sleep matters"}, out.Beliefs)
+
+		requests := client.Requests()
+		require.Len(t, requests, 1)
+		require.NotNil(t, requests[0].ResponseFormat)
+		assert.Equal(t, openai.ChatCompletionResponseFormatTypeJSONObject, requests[0].ResponseFormat.Type)
+		assert.Equal(t, extracted, testutil.ToFloat64(aiJSONDecodes.WithLabelValues("extracted")), "structured output is decoded without extraction")
+	})
+
+	t.Run("fenced output falls back to extraction", func(t *testing.T) {
+		client := fakeai.New("Sure:\n```json\n{\"beliefs\": [\"I believe diet matters\"]}\n```")
+		extracted := testutil.ToFloat64(aiJSONDecodes.WithLabelValues("extracted"))
+
+		err := NewAIHelperWithClient(client).CompletePromptJSON(context.Background(), "Reply in JSON.", "Extract beliefs.", &out)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"I believe diet matters"}, out.Beliefs)
+		assert.Equal(t, extracted+1, testutil.ToFloat64(aiJSONDecodes.WithLabelValues("extracted")))
+	})
+
+	t.Run("missing required field", func(t *testing.T) {
+		client := fakeai.New(`{"note": "none"}`)
+
+		err := NewAIHelperWithClient(client).CompletePromptJSON(context.Background(), "Reply in JSON.", "Extract beliefs.", &out)
+		assert.ErrorIs(t, err, ErrMalformedResponse)
+		assert.Contains(t, err.Error(), `missing required field "beliefs"`)
+		assert.Len(t, client.Requests(), 2, "a malformed response is retried once")
+	})
+}
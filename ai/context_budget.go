@@ -0,0 +1,119 @@
+package ai_helper
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"epistemic-me-core/svc/models"
+)
+
+// DefaultPromptTokenBudget is the estimated size, in tokens, that prompts carrying a belief system
+// are kept within unless configured otherwise: well inside the context window of GPT_LATEST, with
+// room left for the response.
+const DefaultPromptTokenBudget = 32000
+
+// charsPerToken is the rough number of characters per token of English text.
+const charsPerToken = 4
+
+// estimateTokens estimates the number of tokens text takes up. It errs on the high side for English
+// text, which is the safe side for a guard.
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// WithPromptTokenBudget keeps the prompts that carry a belief system within an estimated tokens,
+// trimming the belief system to fit. Zero or less keeps DefaultPromptTokenBudget.
+func WithPromptTokenBudget(tokens int) Option {
+	return func(aih *AIHelper) {
+		aih.promptTokenBudget = tokens
+	}
+}
+
+func (aih *AIHelper) tokenBudget() int {
+	if aih.promptTokenBudget <= 0 {
+		return DefaultPromptTokenBudget
+	}
+	return aih.promptTokenBudget
+}
+
+// fitBeliefSystemPrompt renders the prompt name with render, passing it the listing of bs. When the
+// prompt is over the token budget, the lowest-confidence beliefs are left out of the listing until
+// the prompt fits.
+func (aih *AIHelper) fitBeliefSystemPrompt(name string, bs *models.BeliefSystem, render func(beliefSystem string) (string, error)) (string, error) {
+	listing := beliefSystemToString(bs)
+	prompt, err := render(listing)
+	budget := aih.tokenBudget()
+	if err != nil || estimateTokens(prompt) <= budget {
+		return prompt, err
+	}
+
+	room := budget - (estimateTokens(prompt) - estimateTokens(listing))
+	trimmed, dropped := fitBeliefSystem(bs, room)
+	log.Printf("The %s prompt is about %d tokens, over the budget of %d: left out the %d lowest-confidence beliefs",
+		name, estimateTokens(prompt), budget, dropped)
+	return render(beliefSystemToString(trimmed))
+}
+
+// fitBeliefSystem returns a copy of bs without as few of its active beliefs as it takes for its
+// listing to fit within maxTokens, and how many were left out. The beliefs with the lowest
+// confidence go first and, among equally confident ones, the oldest.
+func fitBeliefSystem(bs *models.BeliefSystem, maxTokens int) (*models.BeliefSystem, int) {
+	var candidates []int
+	for i, belief := range bs.Beliefs {
+		if belief != nil && belief.Active {
+			candidates = append(candidates, i)
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return bs.Beliefs[candidates[a]].Confidence < bs.Beliefs[candidates[b]].Confidence
+	})
+
+	without := func(dropped int) *models.BeliefSystem {
+		drop := make(map[int]bool, dropped)
+		for _, i := range candidates[:dropped] {
+			drop[i] = true
+		}
+		trimmed := *bs
+		trimmed.Beliefs = make([]*models.Belief, 0, len(bs.Beliefs)-dropped)
+		for i, belief := range bs.Beliefs {
+			if !drop[i] {
+				trimmed.Beliefs = append(trimmed.Beliefs, belief)
+			}
+		}
+		return &trimmed
+	}
+	// The listing only shrinks as beliefs are dropped, so the fewest to drop is found by bisection
+	dropped := sort.Search(len(candidates), func(n int) bool {
+		return estimateTokens(beliefSystemToString(without(n))) <= maxTokens
+	})
+	return without(dropped), dropped
+}
+
+// fitBeliefList returns the most recent of beliefs, last in the list, whose JSON encoding fits
+// within maxTokens.
+func fitBeliefList(beliefs []string, maxTokens int) []string {
+	dropped := sort.Search(len(beliefs), func(n int) bool {
+		encoded, _ := json.Marshal(beliefs[n:])
+		return estimateTokens(string(encoded)) <= maxTokens
+	})
+	return beliefs[dropped:]
+}
+
+// fitText cuts text at a word boundary to fit within maxTokens.
+func fitText(text string, maxTokens int) string {
+	if estimateTokens(text) <= maxTokens {
+		return text
+	}
+	if maxTokens <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	cut := string(runes[:min(len(runes), maxTokens*charsPerToken)])
+	if i := strings.LastIndexAny(cut, " \n"); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut)
+}
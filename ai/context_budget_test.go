@@ -0,0 +1,60 @@
+package ai_helper
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOversizedBeliefSystemIsTrimmedToBudget(t *testing.T) {
+	bs := &models.BeliefSystem{}
+	for i := 0; i < 200; i++ {
+		bs.Beliefs = append(bs.Beliefs, &models.Belief{
+			ID:         fmt.Sprintf("b%d", i),
+			Content:    []models.Content{{RawStr: fmt.Sprintf("I believe that routine number %d keeps my days steady", i)}},
+			Active:     true,
+			Confidence: float64(i) / 200,
+		})
+	}
+
+	const budget = 1500
+	client := fakeai.New("How do you sleep?")
+	aih := NewAIHelperWithClient(client, WithPromptTokenBudget(budget))
+	require.Greater(t, estimateTokens(beliefSystemToString(bs)), budget)
+
+	question, err := aih.GenerateQuestionForBeliefSystem(bs, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "How do you sleep?", question)
+
+	require.Len(t, client.SystemPrompts(), 1)
+	prompt := client.SystemPrompts()[0]
+	assert.LessOrEqual(t, estimateTokens(prompt), budget)
+	// The most confident beliefs are kept and the least confident left out
+	assert.Contains(t, prompt, "routine number 199 ")
+	assert.NotContains(t, prompt, "routine number 0 ")
+	assert.Len(t, bs.Beliefs, 200, "the belief system itself is left as it was")
+
+	// A belief system given as text is cut short instead
+	_, err = aih.GenerateQuestion(strings.Repeat("I believe sleep matters. ", 1000), nil)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, estimateTokens(client.SystemPrompts()[1]), budget)
+}
+
+func TestFitBeliefSystemDropsOldestOfEquallyConfident(t *testing.T) {
+	bs := &models.BeliefSystem{Beliefs: []*models.Belief{
+		{ID: "old", Content: []models.Content{{RawStr: "I believe naps help"}}, Active: true, Confidence: 0.5},
+		{ID: "new", Content: []models.Content{{RawStr: "I believe walks help"}}, Active: true, Confidence: 0.5},
+		{ID: "inactive", Content: []models.Content{{RawStr: "I believe coffee helps"}}, Confidence: 0.1},
+	}}
+	trimmed, dropped := fitBeliefSystem(bs, estimateTokens(beliefSystemToString(bs))-1)
+	assert.Equal(t, 1, dropped)
+	require.Len(t, trimmed.Beliefs, 2)
+	assert.Equal(t, "new", trimmed.Beliefs[0].ID)
+	assert.Equal(t, "inactive", trimmed.Beliefs[1].ID)
+}
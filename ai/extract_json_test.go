@@ -0,0 +1,77 @@
+package ai_helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "bare object",
+			input: `{"beliefs": ["a"]}`,
+			want:  `{"beliefs": ["a"]}`,
+		},
+		{
+			name:  "fenced json",
+			input: "Here you go:\n```json\n{\"kept\": [\"1\"], \"deleted\": []}\n```\nLet me know if you need more.",
+			want:  `{"kept": ["1"], "deleted": []}`,
+		},
+		{
+			name:  "untagged fence",
+			input: "```\n{\"beliefs\": []}\n```",
+			want:  `{"beliefs": []}`,
+		},
+		{
+			name:  "prose with braces around a fence",
+			input: "Use the {kept} and {deleted} fields:\n```json\n{\"kept\": [], \"deleted\": [\"2\"]}\n```\nThe {deleted} belief conflicts.",
+			want:  `{"kept": [], "deleted": ["2"]}`,
+		},
+		{
+			name:  "trailing prose with braces",
+			input: `{"beliefs": ["a"]} Note: {this} is not part of the answer}`,
+			want:  `{"beliefs": ["a"]}`,
+		},
+		{
+			name:  "multiple objects",
+			input: `{"first": 1} {"second": 2}`,
+			want:  `{"first": 1}`,
+		},
+		{
+			name:  "braces in strings",
+			input: `Result: {"beliefs": ["I believe {sleep} matters", "a \"quoted\" } brace"]} done`,
+			want:  `{"beliefs": ["I believe {sleep} matters", "a \"quoted\" } brace"]}`,
+		},
+		{
+			name:  "nested objects",
+			input: `{"analysis": {"coherence": 0.5, "topics": {"sleep": 1}}} trailing`,
+			want:  `{"analysis": {"coherence": 0.5, "topics": {"sleep": 1}}}`,
+		},
+		{
+			name:  "skips invalid braces before the object",
+			input: `Consider {not json} then {"beliefs": []}`,
+			want:  `{"beliefs": []}`,
+		},
+		{
+			name:  "no object",
+			input: "I could not find any beliefs.",
+			want:  "",
+		},
+		{
+			name:  "unclosed object",
+			input: `{"beliefs": ["a"`,
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, extractJSON(tt.input))
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package ai_helper
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// helperKey is the context key of the helper bound to the OpenAI key of a request's developer.
+type helperKey struct{}
+
+// ContextWithHelper makes aih the helper WithContext hands out for requests within ctx, so the AI
+// calls made on behalf of a developer with their own OpenAI key are made with that key.
+func ContextWithHelper(ctx context.Context, aih *AIHelper) context.Context {
+	return context.WithValue(ctx, helperKey{}, aih)
+}
+
+func helperFromContext(ctx context.Context) *AIHelper {
+	if ctx == nil {
+		return nil
+	}
+	aih, _ := ctx.Value(helperKey{}).(*AIHelper)
+	return aih
+}
+
+// DefaultHelperPoolSize is how many developers' helpers a pool keeps before evicting the least
+// recently used one.
+const DefaultHelperPoolSize = 256
+
+// HelperPool caches one AIHelper per OpenAI API key, so requests sharing a key share its client and
+// response cache. It is an LRU holding at most maxHelpers helpers; an evicted key gets a new helper,
+// with an empty response cache, on its next request.
+type HelperPool struct {
+	newHelper  func(apiKey string) *AIHelper
+	maxHelpers int
+
+	mu      sync.Mutex
+	order   *list.List // front is most recently used
+	helpers map[string]*list.Element
+}
+
+type poolEntry struct {
+	apiKey string
+	helper *AIHelper
+}
+
+// NewHelperPool creates a pool of at most maxHelpers helpers that builds the helper of a key it
+// does not hold with newHelper.
+func NewHelperPool(maxHelpers int, newHelper func(apiKey string) *AIHelper) *HelperPool {
+	return &HelperPool{
+		newHelper:  newHelper,
+		maxHelpers: max(maxHelpers, 1),
+		order:      list.New(),
+		helpers:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the helper bound to apiKey, creating it on first use.
+func (p *HelperPool) Get(apiKey string) *AIHelper {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if element, ok := p.helpers[apiKey]; ok {
+		p.order.MoveToFront(element)
+		return element.Value.(*poolEntry).helper
+	}
+	aih := p.newHelper(apiKey)
+	p.helpers[apiKey] = p.order.PushFront(&poolEntry{apiKey: apiKey, helper: aih})
+	for p.order.Len() > p.maxHelpers {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		delete(p.helpers, oldest.Value.(*poolEntry).apiKey)
+	}
+	return aih
+}
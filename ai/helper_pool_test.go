@@ -0,0 +1,26 @@
+package ai_helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHelperPoolEvictsLeastRecentlyUsed(t *testing.T) {
+	built := make(map[string]int)
+	pool := NewHelperPool(2, func(apiKey string) *AIHelper {
+		built[apiKey]++
+		return NewAIHelperWithClient(emptyClient{})
+	})
+
+	first := pool.Get("sk-1")
+	pool.Get("sk-2")
+	assert.Same(t, first, pool.Get("sk-1"), "a held key reuses its helper")
+	pool.Get("sk-3")
+
+	assert.Len(t, pool.helpers, 2)
+	pool.Get("sk-1")
+	assert.Equal(t, 1, built["sk-1"], "the most recently used key is kept")
+	pool.Get("sk-2")
+	assert.Equal(t, 2, built["sk-2"], "the least recently used key is evicted and rebuilt")
+}
@@ -0,0 +1,127 @@
+package ai_helper
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"epistemic-me-core/svc/models"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// DefaultQuestionHistoryWindow is how many of the most recent interactions questions are generated
+// from verbatim unless configured otherwise. Older interactions are condensed into a summary.
+const DefaultQuestionHistoryWindow = 10
+
+// SummaryQuestion is the question of the event that stands in for summarized interactions.
+const SummaryQuestion = "Summary of the earlier interactions"
+
+// WithQuestionHistoryWindow generates questions from the given number of most recent interactions
+// verbatim, condensing the ones before them into a single summary. Zero or less sends every
+// interaction verbatim.
+func WithQuestionHistoryWindow(window int) Option {
+	return func(aih *AIHelper) {
+		aih.questionHistoryWindow = window
+	}
+}
+
+// summaryBeliefShare is the fraction of the prompt token budget the beliefs kept on a summary take
+// up at most, as one over it.
+const summaryBeliefShare = 4
+
+// CondenseInteractionEvents returns events with all but the most recent of them, as many as the
+// question history window, replaced by a single summary event. When events start with a summary
+// event, as returned by an earlier call, only the events after it that have aged out of the window
+// are folded into it, so each interaction is summarized once.
+func (aih *AIHelper) CondenseInteractionEvents(events []InteractionEvent) ([]InteractionEvent, error) {
+	var previous *InteractionEvent
+	rest := events
+	if len(rest) > 0 && rest[0].Question == SummaryQuestion {
+		previous, rest = &rest[0], rest[1:]
+	}
+	window := aih.questionHistoryWindow
+	if window <= 0 || len(rest) <= window {
+		return events, nil
+	}
+	older, recent := rest[:len(rest)-window], rest[len(rest)-window:]
+	summary, err := aih.SummarizeInteractionEvents(previous, older)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Summarized %d interactions before the %d most recent", len(older), len(recent))
+	return append([]InteractionEvent{summary}, recent...), nil
+}
+
+// SummarizeInteractionEvents condenses events, following the earlier summary previous if there is
+// one, into a single event answering SummaryQuestion. The beliefs extracted from the events are
+// kept on the summary as they were, so none are lost to the wording of the model. When they or the
+// events are over the prompt token budget, the oldest beliefs are left out and the events cut short.
+func (aih *AIHelper) SummarizeInteractionEvents(previous *InteractionEvent, events []InteractionEvent) (InteractionEvent, error) {
+	conversation := events
+	if previous != nil {
+		// The earlier summary's beliefs are listed with the new ones rather than repeated
+		conversation = append([]InteractionEvent{{Question: previous.Question, Answer: previous.Answer}}, events...)
+		events = append([]InteractionEvent{*previous}, events...)
+	}
+	seen := make(map[string]bool)
+	var beliefs []string
+	for _, event := range events {
+		for _, belief := range event.ExtractedBeliefs {
+			if key := models.BeliefContentKey(belief); key != "" && !seen[key] {
+				seen[key] = true
+				beliefs = append(beliefs, belief)
+			}
+		}
+	}
+
+	budget := aih.tokenBudget()
+	kept := fitBeliefList(beliefs, budget/summaryBeliefShare)
+	if len(kept) < len(beliefs) {
+		log.Printf("The beliefs of the summarized interactions are over %d tokens: left out the %d oldest",
+			budget/summaryBeliefShare, len(beliefs)-len(kept))
+	}
+	beliefs = kept
+
+	var extractedBeliefs string
+	if len(beliefs) > 0 {
+		encoded, err := json.Marshal(beliefs)
+		if err != nil {
+			return InteractionEvent{}, err
+		}
+		extractedBeliefs = string(encoded)
+	}
+	systemContext, err := renderPrompt("interaction_summary", aih.prompts.InteractionSummary, InteractionSummaryPromptData{
+		ExtractedBeliefs: extractedBeliefs,
+	})
+	if err != nil {
+		return InteractionEvent{}, err
+	}
+	encoded, err := json.Marshal(conversation)
+	if err != nil {
+		return InteractionEvent{}, err
+	}
+	transcript := string(encoded)
+	if room := budget - estimateTokens(systemContext); estimateTokens(transcript) > room {
+		log.Printf("The interactions to summarize are about %d tokens, over the budget of %d: cutting them to %d tokens",
+			estimateTokens(transcript), budget, max(room, 0))
+		transcript = fitText(transcript, room)
+	}
+
+	response, err := aih.createChatCompletion(aih.context(), openai.ChatCompletionRequest{
+		Model: string(GPT_LATEST),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: systemContext},
+			{Role: "user", Content: transcript},
+		},
+	})
+	if err != nil {
+		return InteractionEvent{}, err
+	}
+
+	return InteractionEvent{
+		Question:         SummaryQuestion,
+		Answer:           strings.TrimSpace(response.Choices[0].Message.Content),
+		ExtractedBeliefs: beliefs,
+	}, nil
+}
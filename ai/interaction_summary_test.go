@@ -0,0 +1,109 @@
+package ai_helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// summaryPrompt starts the prompts asking for a summary of interactions.
+const summaryPrompt = "Summarize the questions and answers"
+
+// summarizingClient answers summary prompts with a fixed summary and every other prompt with a
+// question.
+func summarizingClient() *fakeai.Client {
+	return fakeai.New("How do you sleep?").On(summaryPrompt, "The user keeps a steady routine.")
+}
+
+// questionPrompts returns the system prompts of the requests client received other than summaries.
+func questionPrompts(client *fakeai.Client) []string {
+	var prompts []string
+	for _, prompt := range client.SystemPrompts() {
+		if !strings.HasPrefix(prompt, summaryPrompt) {
+			prompts = append(prompts, prompt)
+		}
+	}
+	return prompts
+}
+
+func TestGenerateQuestionSummarizesOlderInteractions(t *testing.T) {
+	var events []InteractionEvent
+	for i := 0; i < 30; i++ {
+		events = append(events, InteractionEvent{
+			Question:         fmt.Sprintf("Question %d?", i),
+			Answer:           fmt.Sprintf("Answer %d.", i),
+			ExtractedBeliefs: []string{fmt.Sprintf("I believe routine %d matters", i)},
+		})
+	}
+
+	client := summarizingClient()
+	aih := NewAIHelperWithClient(client, WithQuestionHistoryWindow(5))
+	_, err := aih.GenerateQuestion("I believe sleep matters", events)
+	require.NoError(t, err)
+
+	// The 25 older interactions are summarized in one call that is asked to keep their beliefs
+	require.Len(t, client.RequestsMatching(summaryPrompt), 1)
+	summaryRequest := client.RequestsMatching(summaryPrompt)[0]
+	assert.Contains(t, summaryRequest.Messages[0].Content, "I believe routine 0 matters")
+	assert.Contains(t, summaryRequest.Messages[1].Content, "Question 24?")
+	assert.NotContains(t, summaryRequest.Messages[1].Content, "Question 25?")
+
+	require.Len(t, questionPrompts(client), 1)
+	prompt := questionPrompts(client)[0]
+	for i := 0; i < 25; i++ {
+		assert.NotContains(t, prompt, fmt.Sprintf("Question %d?", i))
+	}
+	for i := 25; i < 30; i++ {
+		assert.Contains(t, prompt, fmt.Sprintf("Question %d?", i))
+	}
+
+	const marker = "given the existing questions asked: "
+	start := strings.Index(prompt, marker)
+	require.GreaterOrEqual(t, start, 0)
+	var sent []InteractionEvent
+	require.NoError(t, json.Unmarshal([]byte(prompt[start+len(marker):]), &sent))
+	require.Len(t, sent, 6)
+	assert.Equal(t, SummaryQuestion, sent[0].Question)
+	assert.Equal(t, "The user keeps a steady routine.", sent[0].Answer)
+	assert.Len(t, sent[0].ExtractedBeliefs, 25, "the summary keeps the beliefs of every summarized interaction")
+	assert.Equal(t, events[25:], sent[1:])
+
+	// Dialectics within the window are sent verbatim without a summary
+	_, err = aih.GenerateQuestion("", events[:5])
+	require.NoError(t, err)
+	assert.Len(t, client.RequestsMatching(summaryPrompt), 1)
+}
+
+func TestSummarizeInteractionEventsKeepsToBudget(t *testing.T) {
+	var events []InteractionEvent
+	for i := 0; i < 200; i++ {
+		events = append(events, InteractionEvent{
+			Question:         fmt.Sprintf("Question %d?", i),
+			Answer:           strings.Repeat(fmt.Sprintf("Answer %d. ", i), 20),
+			ExtractedBeliefs: []string{fmt.Sprintf("I believe that routine number %d keeps my days steady", i)},
+		})
+	}
+
+	const budget = 2000
+	client := summarizingClient()
+	aih := NewAIHelperWithClient(client, WithPromptTokenBudget(budget))
+	previous := &InteractionEvent{Question: SummaryQuestion, Answer: "The user naps.", ExtractedBeliefs: []string{"I believe naps help"}}
+	summary, err := aih.SummarizeInteractionEvents(previous, events)
+	require.NoError(t, err)
+
+	require.Len(t, client.RequestsMatching(summaryPrompt), 1)
+	request := client.RequestsMatching(summaryPrompt)[0]
+	assert.LessOrEqual(t, estimateTokens(request.Messages[0].Content)+estimateTokens(request.Messages[1].Content), budget)
+	// The earlier summary leads the conversation and the most recent beliefs are the ones kept
+	assert.True(t, strings.HasPrefix(request.Messages[1].Content, `[{"question":"`+SummaryQuestion+`","answer":"The user naps."}`))
+	assert.Contains(t, request.Messages[0].Content, "routine number 199 ")
+	assert.NotContains(t, request.Messages[0].Content, "I believe naps help")
+	assert.Less(t, len(summary.ExtractedBeliefs), 201)
+	assert.Equal(t, "I believe that routine number 199 keeps my days steady", summary.ExtractedBeliefs[len(summary.ExtractedBeliefs)-1])
+}
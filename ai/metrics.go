@@ -0,0 +1,44 @@
+package ai_helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrNoChoices is wrapped by errors for completions the AI provider returned without any choices.
+var ErrNoChoices = errors.New("AI response has no choices")
+
+var (
+	aiRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "epistemic_me_ai_requests_total",
+		Help: "Chat completions requested from the AI provider, by model and outcome (ok, error, empty or cached).",
+	}, []string{"model", "outcome"})
+	aiTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "epistemic_me_ai_tokens_total",
+		Help: "Tokens used by chat completions, by model and kind (prompt or completion).",
+	}, []string{"model", "kind"})
+)
+
+// callClient sends request to the AI provider and records the call and its token usage. A
+// response without choices, as a throttled or filtered request may get, is an ErrNoChoices error
+// so that callers can always read the first choice.
+func (aih *AIHelper) callClient(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	response, err := aih.client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		aiRequests.WithLabelValues(request.Model, "error").Inc()
+		return response, err
+	}
+	if len(response.Choices) == 0 {
+		aiRequests.WithLabelValues(request.Model, "empty").Inc()
+		return response, fmt.Errorf("%w from model %s", ErrNoChoices, request.Model)
+	}
+	aiRequests.WithLabelValues(request.Model, "ok").Inc()
+	aiTokens.WithLabelValues(request.Model, "prompt").Add(float64(response.Usage.PromptTokens))
+	aiTokens.WithLabelValues(request.Model, "completion").Add(float64(response.Usage.CompletionTokens))
+	return response, nil
+}
@@ -0,0 +1,55 @@
+package ai_helper
+
+import (
+	"context"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+// emptyClient answers every request without any choices, as a throttled or filtered request may.
+type emptyClient struct{}
+
+func (emptyClient) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	return openai.ChatCompletionResponse{}, nil
+}
+
+func TestNoChoicesIsAnError(t *testing.T) {
+	aih := NewAIHelperWithClient(emptyClient{})
+	aih.EnableResponseCache(10)
+	event := InteractionEvent{Question: "How do you sleep?", Answer: "I sleep well"}
+
+	calls := map[string]func() error{
+		"CompletePrompt": func() error {
+			_, err := aih.CompletePrompt("hello")
+			return err
+		},
+		"GenerateQuestion": func() error {
+			_, err := aih.GenerateQuestion("", nil)
+			return err
+		},
+		"GetInteractionEventAsBelief": func() error {
+			_, err := aih.GetInteractionEventAsBelief(event)
+			return err
+		},
+		"UpdateBeliefWithInteractionEvent": func() error {
+			_, _, err := aih.UpdateBeliefWithInteractionEvent(event, "I believe sleep matters")
+			return err
+		},
+		"CompletePromptJSON": func() error {
+			var out struct {
+				Beliefs []string `json:"beliefs"`
+			}
+			return aih.CompletePromptJSON(context.Background(), "Reply in JSON.", "Extract beliefs.", &out)
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			var err error
+			assert.NotPanics(t, func() { err = call() })
+			assert.ErrorIs(t, err, ErrNoChoices)
+		})
+	}
+	assert.Zero(t, aih.CacheStats().Entries, "empty responses are not cached")
+}
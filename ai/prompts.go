@@ -0,0 +1,248 @@
+package ai_helper
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// PromptSet holds the prompt templates sent by the AIHelper, so prompts can be tuned and compared
+// without a rebuild. Each template is a text/template executed with the data type named in its
+// comment; an empty template falls back to the default from DefaultPromptSet.
+type PromptSet struct {
+	// QuestionGeneration is the system prompt of GenerateQuestion, executed with QuestionPromptData
+	QuestionGeneration string `json:"question_generation,omitempty"`
+	// BeliefExtraction is the system prompt of GetInteractionEventAsBelief, executed with
+	// BeliefExtractionPromptData
+	BeliefExtraction string `json:"belief_extraction,omitempty"`
+	// ClassifiedBeliefExtraction is the system prompt of GetInteractionEventAsClassifiedBeliefs,
+	// executed with BeliefExtractionPromptData
+	ClassifiedBeliefExtraction string `json:"classified_belief_extraction,omitempty"`
+	// BeliefValidity is the system prompt of DetermineBeliefValidity, executed with
+	// BeliefValidityPromptData
+	BeliefValidity string `json:"belief_validity,omitempty"`
+	// Analysis is the system prompt of the sleep, diet and exercise analysis, executed with
+	// AnalysisPromptData
+	Analysis string `json:"analysis,omitempty"`
+	// GeneralAnalysis is the system prompt of the analysis of the default strategy, executed with
+	// AnalysisPromptData
+	GeneralAnalysis string `json:"general_analysis,omitempty"`
+	// InteractionSummary is the system prompt of SummarizeInteractionEvents, executed with
+	// InteractionSummaryPromptData
+	InteractionSummary string `json:"interaction_summary,omitempty"`
+}
+
+// QuestionPromptData is the data of the QuestionGeneration template. BeliefSystem and
+// PreviousEvents are empty at the start of a dialectic.
+type QuestionPromptData struct {
+	Strategy       string
+	BeliefSystem   string
+	PreviousEvents string
+}
+
+// BeliefExtractionPromptData is the data of the belief extraction templates.
+type BeliefExtractionPromptData struct {
+	Strategy string
+}
+
+// BeliefValidityPromptData is the data of the BeliefValidity template, holding both belief lists
+// as JSON.
+type BeliefValidityPromptData struct {
+	OldBeliefs string
+	NewBeliefs string
+}
+
+// InteractionSummaryPromptData is the data of the InteractionSummary template, holding the beliefs
+// extracted from the summarized interactions as JSON.
+type InteractionSummaryPromptData struct {
+	ExtractedBeliefs string
+}
+
+// AnalysisPromptData is the data of the analysis templates. Question and Answer are empty when the
+// belief system is analyzed outside of a dialectic turn.
+type AnalysisPromptData struct {
+	BeliefSystem string
+	Question     string
+	Answer       string
+}
+
+const defaultQuestionGenerationPrompt = `Given these definitions {{.Strategy}}. Generate a single question to further understand the user's belief system.` +
+	`{{if .BeliefSystem}} The user's current belief system is {{.BeliefSystem}}{{end}}` +
+	`{{if .PreviousEvents}} Ask a single novel question given the existing questions asked: {{.PreviousEvents}}{{end}}`
+
+const defaultBeliefExtractionPrompt = `Given these definitions {{.Strategy}}.
+Extract all beliefs from the user's response.
+Return ONLY a JSON object with a "beliefs" array containing all belief statements.
+Example: {"beliefs": [
+	"I believe that quality sleep is essential for energy",
+	"I believe that maintaining a consistent sleep schedule improves sleep quality"
+]}`
+
+const defaultClassifiedBeliefExtractionPrompt = `Given these definitions {{.Strategy}}.
+Extract all beliefs from the user's response and classify each one:
+- "causal": the belief states that an action or condition leads to an outcome
+- "falsifiable": the belief makes a claim that observation could prove wrong, without a cause and effect
+- "statement": any other belief
+Return ONLY a JSON object with a "beliefs" array holding each belief's "content" and "type".
+Example: {"beliefs": [
+	{"content": "I believe that quality sleep gives me energy the next day", "type": "causal"},
+	{"content": "I believe that I sleep seven hours a night", "type": "falsifiable"},
+	{"content": "I believe that health matters", "type": "statement"}
+]}`
+
+const defaultBeliefValidityPrompt = `
+You are given two lists:
+1. Old beliefs (each with an ID and content).
+2. New beliefs.
+
+Task: Determine which old beliefs are invalidated by the new beliefs and which remain valid.
+
+Return ONLY a JSON object with two arrays of belief IDs:
+{
+  "kept_belief_ids": ["...","..."],
+  "deleted_belief_ids": ["...","..."]
+}
+
+Important:
+ - "kept_belief_ids" should be the IDs of old beliefs that remain valid.
+ - "deleted_belief_ids" should be the IDs of old beliefs that are no longer valid.
+ - Do NOT include the new beliefs in the returned IDs.
+ - Do NOT include any markdown formatting or backticks in your response.
+`
+
+const defaultAnalysisPrompt = `Analyze the following belief system related to sleep, diet, and exercise:
+{{.BeliefSystem}}
+{{if .Question}}
+Consider the latest interaction:
+Question: {{.Question}}
+Answer: {{.Answer}}
+{{end}}
+Provide an analysis focusing on:
+1. Coherence of beliefs related to sleep, diet, and exercise
+2. Consistency of beliefs with established health principles
+3. Falsifiability of the beliefs
+4. Overall understanding of the relationship between sleep, diet, exercise, metabolism, and energy
+
+Respond ONLY with a JSON object in the following structure:
+{
+  "coherence": float,
+  "consistency": float,
+  "falsifiability": float,
+  "overall_score": float,
+  "feedback": string,
+  "recommendations": [string],
+  "verified_beliefs": [string]
+}`
+
+const defaultGeneralAnalysisPrompt = `Analyze the following belief system:
+{{.BeliefSystem}}
+{{if .Question}}
+Consider the latest interaction:
+Question: {{.Question}}
+Answer: {{.Answer}}
+{{end}}
+Provide an analysis focusing on:
+1. Coherence: how well the beliefs fit together
+2. Consistency: whether any beliefs contradict each other
+3. Falsifiability: whether observation could prove the beliefs wrong
+4. Overall: how well the beliefs would predict the user's experience
+
+Score each of coherence, consistency, falsifiability and overall from 0 to 1.
+Respond ONLY with a JSON object in the following structure:
+{
+  "coherence": float,
+  "consistency": float,
+  "falsifiability": float,
+  "overall_score": float,
+  "feedback": string,
+  "recommendations": [string],
+  "verified_beliefs": [string]
+}`
+
+const defaultInteractionSummaryPrompt = `Summarize the questions and answers of this conversation with the user in a short paragraph.` +
+	` Keep what the user said about themselves and leave out small talk.` +
+	` When the conversation starts with a summary of earlier interactions, fold it into yours.` +
+	`{{if .ExtractedBeliefs}} The summary must keep every one of these beliefs extracted from the answers: {{.ExtractedBeliefs}}{{end}}` +
+	` Respond with the summary only.`
+
+// DefaultPromptSet returns the prompts the AIHelper uses unless they are overridden.
+func DefaultPromptSet() PromptSet {
+	return PromptSet{
+		QuestionGeneration:         defaultQuestionGenerationPrompt,
+		BeliefExtraction:           defaultBeliefExtractionPrompt,
+		ClassifiedBeliefExtraction: defaultClassifiedBeliefExtractionPrompt,
+		BeliefValidity:             defaultBeliefValidityPrompt,
+		Analysis:                   defaultAnalysisPrompt,
+		GeneralAnalysis:            defaultGeneralAnalysisPrompt,
+		InteractionSummary:         defaultInteractionSummaryPrompt,
+	}
+}
+
+// withDefaults fills the empty templates of ps from DefaultPromptSet.
+func (ps PromptSet) withDefaults() PromptSet {
+	defaults := DefaultPromptSet()
+	if ps.QuestionGeneration == "" {
+		ps.QuestionGeneration = defaults.QuestionGeneration
+	}
+	if ps.BeliefExtraction == "" {
+		ps.BeliefExtraction = defaults.BeliefExtraction
+	}
+	if ps.ClassifiedBeliefExtraction == "" {
+		ps.ClassifiedBeliefExtraction = defaults.ClassifiedBeliefExtraction
+	}
+	if ps.BeliefValidity == "" {
+		ps.BeliefValidity = defaults.BeliefValidity
+	}
+	if ps.Analysis == "" {
+		ps.Analysis = defaults.Analysis
+	}
+	if ps.GeneralAnalysis == "" {
+		ps.GeneralAnalysis = defaults.GeneralAnalysis
+	}
+	if ps.InteractionSummary == "" {
+		ps.InteractionSummary = defaults.InteractionSummary
+	}
+	return ps
+}
+
+// Validate checks that every template of the set parses.
+func (ps PromptSet) Validate() error {
+	ps = ps.withDefaults()
+	for name, text := range map[string]string{
+		"question_generation":          ps.QuestionGeneration,
+		"belief_extraction":            ps.BeliefExtraction,
+		"classified_belief_extraction": ps.ClassifiedBeliefExtraction,
+		"belief_validity":              ps.BeliefValidity,
+		"analysis":                     ps.Analysis,
+		"general_analysis":             ps.GeneralAnalysis,
+		"interaction_summary":          ps.InteractionSummary,
+	} {
+		if _, err := template.New(name).Parse(text); err != nil {
+			return fmt.Errorf("invalid %s prompt: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// renderPrompt executes a prompt template with data.
+func renderPrompt(name, text string, data interface{}) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s prompt: %w", name, err)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", name, err)
+	}
+	return rendered.String(), nil
+}
+
+// Option configures an AIHelper when it is created.
+type Option func(*AIHelper)
+
+// WithPromptSet makes the AIHelper use the given prompts; empty templates keep their defaults.
+func WithPromptSet(prompts PromptSet) Option {
+	return func(aih *AIHelper) {
+		aih.prompts = prompts.withDefaults()
+	}
+}
@@ -0,0 +1,97 @@
+package ai_helper
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"epistemic-me-core/svc/models"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ResourceChunkSize is the most characters of a resource sent to the model in one extraction
+// request. Longer resources are extracted chunk by chunk.
+const ResourceChunkSize = 6000
+
+// chunkText splits content into chunks of at most maxChars characters, breaking between paragraphs
+// where it can and between words otherwise. Blank content has no chunks.
+func chunkText(content string, maxChars int) []string {
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+	}
+
+	for _, paragraph := range strings.Split(content, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+2+utf8.RuneCountInString(paragraph) > maxChars {
+			flush()
+		}
+		for utf8.RuneCountInString(paragraph) > maxChars {
+			flush()
+			head, tail := splitAtWord(paragraph, maxChars)
+			chunks = append(chunks, head)
+			paragraph = tail
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(paragraph)
+	}
+	flush()
+	return chunks
+}
+
+// splitAtWord splits text after at most maxChars characters, at the last space before the limit
+// if there is one.
+func splitAtWord(text string, maxChars int) (string, string) {
+	prefix := string([]rune(text)[:maxChars])
+	cut := len(prefix)
+	if i := strings.LastIndex(prefix, " "); i > 0 {
+		cut = i
+	}
+	return strings.TrimSpace(text[:cut]), strings.TrimSpace(text[cut:])
+}
+
+// ExtractClassifiedBeliefsFromResource extracts the beliefs a document holds, chunk by chunk, and
+// classifies each as a statement, a falsifiable belief or a causal belief.
+func (aih *AIHelper) ExtractClassifiedBeliefsFromResource(resource models.Resource) ([]ClassifiedBelief, error) {
+	var beliefs []ClassifiedBelief
+	for _, chunk := range chunkText(resource.Content, ResourceChunkSize) {
+		// Stop between chunks once the helper's context is done, so long documents end promptly
+		if err := aih.context().Err(); err != nil {
+			return nil, err
+		}
+		request := openai.ChatCompletionRequest{
+			Model: string(GPT_LATEST),
+			Messages: []openai.ChatCompletionMessage{
+				{Role: "system", Content: fmt.Sprintf(`Given these definitions %s.
+Extract the beliefs held in this document and classify each one:
+- "causal": the belief states that an action or condition leads to an outcome
+- "falsifiable": the belief makes a claim that observation could prove wrong, without a cause and effect
+- "statement": any other belief
+Return ONLY a JSON object with a "beliefs" array holding each belief's "content" and "type".
+Example: {"beliefs": [{"content": "Quality sleep gives energy the next day", "type": "causal"}]}`, DIALECTICAL_STRATEGY)},
+				{Role: "user", Content: fmt.Sprintf("Extract the beliefs from this document: %s", chunk)},
+			},
+		}
+		var chunkBeliefs []ClassifiedBelief
+		err := aih.completeJSON(request, func(content string) error {
+			var err error
+			chunkBeliefs, err = parseClassifiedBeliefs(content)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse belief response: %w", err)
+		}
+		beliefs = append(beliefs, chunkBeliefs...)
+	}
+	return beliefs, nil
+}
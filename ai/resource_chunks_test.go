@@ -0,0 +1,25 @@
+package ai_helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkText(t *testing.T) {
+	assert.Empty(t, chunkText(" \n\n ", 20))
+	assert.Equal(t, []string{"Sleep matters."}, chunkText("Sleep matters.", 20))
+
+	// Paragraphs are packed together up to the limit
+	assert.Equal(t, []string{"Sleep matters.\n\nDiet too.", "Exercise daily."},
+		chunkText("Sleep matters.\n\nDiet too.\n\nExercise daily.", 30))
+
+	// A paragraph over the limit is split between words
+	chunks := chunkText(strings.Repeat("sleep ", 10), 20)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk), 20)
+		assert.False(t, strings.HasPrefix(chunk, "leep"), "words are not split")
+	}
+	assert.Equal(t, strings.Repeat("sleep ", 10), strings.Join(chunks, " ")+" ")
+}
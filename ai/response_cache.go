@@ -0,0 +1,150 @@
+package ai_helper
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// CacheStats counts how chat completions were served by the response cache.
+type CacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+}
+
+// responseCache is an LRU of chat completions keyed by a hash of the model and prompt messages.
+type responseCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+	hits    int64
+	misses  int64
+}
+
+type cacheEntry struct {
+	key      string
+	response openai.ChatCompletionResponse
+}
+
+func newResponseCache(maxEntries int) *responseCache {
+	return &responseCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// cacheKey hashes the model, the sampling parameters and the role and content of every message, so
+// the same system and user prompts sent to the same model with the same sampling share an entry.
+func cacheKey(request openai.ChatCompletionRequest) string {
+	hash := sha256.New()
+	hash.Write([]byte(request.Model))
+	seed := "none"
+	if request.Seed != nil {
+		seed = strconv.Itoa(*request.Seed)
+	}
+	fmt.Fprintf(hash, "\x00%g\x00%g\x00%s", request.Temperature, request.TopP, seed)
+	if request.ResponseFormat != nil {
+		fmt.Fprintf(hash, "\x00%s", request.ResponseFormat.Type)
+	}
+	for _, message := range request.Messages {
+		hash.Write([]byte{0})
+		hash.Write([]byte(message.Role))
+		hash.Write([]byte{0})
+		hash.Write([]byte(message.Content))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *responseCache) get(key string) (openai.ChatCompletionResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return openai.ChatCompletionResponse{}, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	return element.Value.(*cacheEntry).response, true
+}
+
+func (c *responseCache) put(key string, response openai.ChatCompletionResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*cacheEntry).response = response
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, response: response})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *responseCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: c.order.Len()}
+}
+
+// EnableResponseCache serves repeated identical prompts from an in-memory LRU holding at most
+// maxEntries responses. Only successful completions are cached.
+func (aih *AIHelper) EnableResponseCache(maxEntries int) {
+	if maxEntries <= 0 {
+		aih.cache = nil
+		return
+	}
+	aih.cache = newResponseCache(maxEntries)
+}
+
+// WithoutCache returns a helper sharing aih's client that always asks the provider, for callers
+// that want a fresh generation.
+func (aih *AIHelper) WithoutCache() *AIHelper {
+	fresh := *aih
+	fresh.cache = nil
+	return &fresh
+}
+
+// CacheStats reports the response cache's hits and misses, which are zero when it is disabled.
+func (aih *AIHelper) CacheStats() CacheStats {
+	if aih.cache == nil {
+		return CacheStats{}
+	}
+	return aih.cache.stats()
+}
+
+// createChatCompletion applies the sampling configuration to request and sends it to the client,
+// within its share of ctx's remaining budget, unless an identical prompt is cached.
+func (aih *AIHelper) createChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+	request = aih.sampling.apply(request)
+	if aih.cache == nil {
+		return aih.callClient(ctx, request)
+	}
+
+	key := cacheKey(request)
+	if response, ok := aih.cache.get(key); ok {
+		aiRequests.WithLabelValues(request.Model, "cached").Inc()
+		return response, nil
+	}
+	response, err := aih.callClient(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	aih.cache.put(key, response)
+	return response, nil
+}
@@ -0,0 +1,202 @@
+package ai_helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrMalformedResponse is wrapped by errors for AI responses that are not JSON of the expected shape.
+var ErrMalformedResponse = errors.New("malformed AI response")
+
+// aiJSONDecodes counts JSON responses decoded by CompletePromptJSON, by whether the response was
+// JSON as a whole (direct) or the object had to be extracted from surrounding text (extracted).
+var aiJSONDecodes = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "epistemic_me_ai_json_decodes_total",
+	Help: "JSON responses decoded from the AI provider, by method (direct or extracted).",
+}, []string{"method"})
+
+// jsonModeModels are the models that accept a JSON object response format.
+var jsonModeModels = map[string]bool{
+	openai.GPT4oMini:     true,
+	openai.GPT4o:         true,
+	openai.GPT4Turbo:     true,
+	openai.GPT3Dot5Turbo: true,
+}
+
+// jsonObject is an AI response decoded just far enough to check its fields.
+type jsonObject map[string]json.RawMessage
+
+// parseJSONObject extracts the JSON object from a model response.
+func parseJSONObject(content string) (jsonObject, error) {
+	jsonStr := extractJSON(content)
+	if jsonStr == "" {
+		return nil, fmt.Errorf("%w: no JSON object found", ErrMalformedResponse)
+	}
+	var object jsonObject
+	if err := json.Unmarshal([]byte(jsonStr), &object); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+	}
+	return object, nil
+}
+
+// stringArray returns field as a list of strings, failing if it is missing, null or of another type.
+func (o jsonObject) stringArray(field string) ([]string, error) {
+	raw, ok := o[field]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing required field %q", ErrMalformedResponse, field)
+	}
+	if string(raw) == "null" {
+		return nil, fmt.Errorf("%w: field %q is null, expected an array of strings", ErrMalformedResponse, field)
+	}
+	var values []string
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, fmt.Errorf("%w: field %q is not an array of strings", ErrMalformedResponse, field)
+	}
+	return values, nil
+}
+
+// boolArray returns field as a list of booleans, failing if it is missing, null or of another type.
+func (o jsonObject) boolArray(field string) ([]bool, error) {
+	raw, ok := o[field]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing required field %q", ErrMalformedResponse, field)
+	}
+	if string(raw) == "null" {
+		return nil, fmt.Errorf("%w: field %q is null, expected an array of booleans", ErrMalformedResponse, field)
+	}
+	var values []bool
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, fmt.Errorf("%w: field %q is not an array of booleans", ErrMalformedResponse, field)
+	}
+	return values, nil
+}
+
+// CompletePromptJSON sends systemPrompt and userPrompt and unmarshals the model's JSON object answer
+// into out, which must be a pointer. Models that support it are asked for a JSON object response;
+// otherwise, or if the answer still holds other text, the object is extracted from the answer.
+// Struct fields of out without omitempty are required, and a response missing one of them is
+// retried once like any other malformed response.
+func (aih *AIHelper) CompletePromptJSON(ctx context.Context, systemPrompt, userPrompt string, out any) error {
+	request := openai.ChatCompletionRequest{
+		Model: string(GPT_LATEST),
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+	}
+	if jsonModeModels[request.Model] {
+		request.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
+	return aih.completeJSONWithContext(ctx, request, func(content string) error {
+		return decodeJSONResponse(content, out)
+	})
+}
+
+// decodeJSONResponse unmarshals the JSON object of a model response into out, checking first that
+// every required field of out is present and not null.
+func decodeJSONResponse(content string, out any) error {
+	jsonStr := strings.TrimSpace(content)
+	if strings.HasPrefix(jsonStr, "{") && json.Valid([]byte(jsonStr)) {
+		aiJSONDecodes.WithLabelValues("direct").Inc()
+	} else {
+		if jsonStr = extractJSON(content); jsonStr == "" {
+			return fmt.Errorf("%w: no JSON object found", ErrMalformedResponse)
+		}
+		aiJSONDecodes.WithLabelValues("extracted").Inc()
+	}
+
+	var object jsonObject
+	if err := json.Unmarshal([]byte(jsonStr), &object); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+	}
+	if err := object.requireFields(out); err != nil {
+		return err
+	}
+	// clear what an earlier, malformed response may have left in out
+	target := reflect.ValueOf(out).Elem()
+	target.Set(reflect.Zero(target.Type()))
+	if err := json.Unmarshal([]byte(jsonStr), out); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fmt.Errorf("%w: field %q is not of type %s", ErrMalformedResponse, typeErr.Field, typeErr.Type)
+		}
+		return fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+	}
+	return nil
+}
+
+// requireFields checks that the object has every field of the struct out points to whose JSON tag
+// does not allow it to be omitted. Other kinds of out have no required fields.
+func (o jsonObject) requireFields(out any) error {
+	t := reflect.TypeOf(out)
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	t = t.Elem()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" || strings.Contains(options, "omitempty") {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		raw, ok := o[name]
+		if !ok {
+			return fmt.Errorf("%w: missing required field %q", ErrMalformedResponse, name)
+		}
+		if string(raw) == "null" {
+			return fmt.Errorf("%w: field %q is null", ErrMalformedResponse, name)
+		}
+	}
+	return nil
+}
+
+// completeJSON sends request and hands the response to parse. If parse reports a malformed response,
+// the request is retried once with the model's answer and the problem appended, asking for the
+// expected JSON only.
+func (aih *AIHelper) completeJSON(request openai.ChatCompletionRequest, parse func(content string) error) error {
+	return aih.completeJSONWithContext(aih.context(), request, parse)
+}
+
+// completeJSONWithContext is completeJSON bounded by ctx rather than the helper's own context.
+func (aih *AIHelper) completeJSONWithContext(ctx context.Context, request openai.ChatCompletionRequest, parse func(content string) error) error {
+	response, err := aih.createChatCompletion(ctx, request)
+	if err != nil {
+		log.Printf("Error from AI: %v", err)
+		return err
+	}
+	content := response.Choices[0].Message.Content
+	log.Printf("AI response: %s", content)
+
+	err = parse(content)
+	if !errors.Is(err, ErrMalformedResponse) {
+		return err
+	}
+
+	log.Printf("Retrying malformed AI response: %v", err)
+	retry := request
+	retry.Messages = append(append([]openai.ChatCompletionMessage{}, request.Messages...),
+		openai.ChatCompletionMessage{Role: "assistant", Content: content},
+		openai.ChatCompletionMessage{Role: "user", Content: fmt.Sprintf(
+			"That response could not be used (%v). Reply again with ONLY the JSON object in exactly the format described, with every field present, no markdown and no other text.", err)},
+	)
+	response, err = aih.createChatCompletion(ctx, retry)
+	if err != nil {
+		log.Printf("Error from AI: %v", err)
+		return err
+	}
+	content = response.Choices[0].Message.Content
+	log.Printf("AI response: %s", content)
+	return parse(content)
+}
@@ -0,0 +1,71 @@
+package ai_helper
+
+import (
+	"math"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// SamplingConfig pins the sampling parameters of every chat completion the AIHelper requests, so
+// evaluations can be reproduced. Nil fields are left unset and the provider's defaults apply.
+//
+// Seed support depends on the model: OpenAI only makes a best effort to return the same output for
+// the same seed, and models that do not support seeds ignore it.
+type SamplingConfig struct {
+	Temperature *float32
+	TopP        *float32
+	Seed        *int
+}
+
+// merge returns c with the fields set in override replacing its own.
+func (c SamplingConfig) merge(override SamplingConfig) SamplingConfig {
+	if override.Temperature != nil {
+		c.Temperature = override.Temperature
+	}
+	if override.TopP != nil {
+		c.TopP = override.TopP
+	}
+	if override.Seed != nil {
+		c.Seed = override.Seed
+	}
+	return c
+}
+
+// apply sets the configured parameters on request. The client drops a zero temperature or top-p
+// from the request, so zero is sent as the smallest positive float, which samples the same way.
+func (c SamplingConfig) apply(request openai.ChatCompletionRequest) openai.ChatCompletionRequest {
+	if c.Temperature != nil {
+		request.Temperature = nonZero(*c.Temperature)
+	}
+	if c.TopP != nil {
+		request.TopP = nonZero(*c.TopP)
+	}
+	if c.Seed != nil {
+		seed := *c.Seed
+		request.Seed = &seed
+	}
+	return request
+}
+
+func nonZero(value float32) float32 {
+	if value == 0 {
+		return math.SmallestNonzeroFloat32
+	}
+	return value
+}
+
+// WithSampling makes the AIHelper send the given sampling parameters with every request.
+func WithSampling(sampling SamplingConfig) Option {
+	return func(aih *AIHelper) {
+		aih.sampling = sampling
+	}
+}
+
+// WithSamplingOverride returns a helper sharing aih's client and cache whose requests use the
+// fields set in override in place of the configured ones, for calls such as creative question
+// generation that want a different temperature.
+func (aih *AIHelper) WithSamplingOverride(override SamplingConfig) *AIHelper {
+	overridden := *aih
+	overridden.sampling = aih.sampling.merge(override)
+	return &overridden
+}
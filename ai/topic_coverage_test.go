@@ -0,0 +1,35 @@
+package ai_helper
+
+import (
+	"testing"
+
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLowestCoverageTopicBreaksTiesByTopicOrder(t *testing.T) {
+	progress := &models.LearningObjectiveProgress{
+		TopicCoverage: map[string]*models.TopicCoverage{
+			"sleep":    {Percentage: 80},
+			"diet":     {Percentage: 40},
+			"exercise": {Percentage: 40},
+			"stress":   {Percentage: 40},
+		},
+	}
+
+	// The same progress picks the same topic every time, whatever the map's iteration order
+	for i := 0; i < 20; i++ {
+		topic, coverage := lowestCoverageTopic([]string{"sleep", "exercise", "diet"}, progress)
+		assert.Equal(t, "exercise", topic)
+		assert.Equal(t, float32(40), coverage.Percentage)
+	}
+
+	// Topics the objective does not list come after the listed ones
+	topic, _ := lowestCoverageTopic([]string{"sleep"}, progress)
+	assert.Equal(t, "diet", topic)
+
+	topic, coverage := lowestCoverageTopic([]string{"sleep"}, nil)
+	assert.Empty(t, topic)
+	assert.Nil(t, coverage)
+}
@@ -1,8 +1,20 @@
 package db
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrNotFound is returned when a key is not found in the store
 	ErrNotFound = errors.New("not found")
+
+	// ErrDeveloperNotFound is returned when nothing is stored for a developer; it wraps ErrNotFound
+	ErrDeveloperNotFound = fmt.Errorf("developer %w", ErrNotFound)
+
+	// ErrKeyNotFound is returned when a developer has nothing stored under a key; it wraps ErrNotFound
+	ErrKeyNotFound = fmt.Errorf("key %w", ErrNotFound)
+
+	// ErrVersionConflict is returned when a write's version is not greater than the stored version
+	ErrVersionConflict = errors.New("version conflict")
 )
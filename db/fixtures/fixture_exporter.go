@@ -0,0 +1,144 @@
+package fixture_models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportFixtures serializes a self model's belief system into the YAML fixture format read by
+// ImportFixtures, so live sessions can be captured as regression fixtures. Each epistemic context
+// becomes one example; beliefs that are not tied to any observation context are written to a
+// final example of their own.
+func ExportFixtures(kvStore db.KeyValueStore, selfModelID string) ([]byte, error) {
+	value, err := kvStore.Retrieve(selfModelID, "BeliefSystem")
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving belief system: %v", err)
+	}
+	beliefSystem, ok := value.(*models.BeliefSystem)
+	if !ok {
+		return nil, fmt.Errorf("invalid belief system data type: %T", value)
+	}
+
+	// Fixtures may reuse a belief name across examples, so beliefs sharing an ID are
+	// matched to their belief contexts in the order they were added
+	pending := make(map[string][]*models.Belief, len(beliefSystem.Beliefs))
+	for _, belief := range beliefSystem.Beliefs {
+		pending[belief.ID] = append(pending[belief.ID], belief)
+	}
+
+	// The importer refers to contexts by name, and a belief context may point at a context
+	// defined in an earlier example, so translate context IDs back to names up front
+	contextNames := make(map[string]string)
+	statesByContext := make(map[string][]string)
+	for _, ec := range beliefSystem.EpistemicContexts {
+		if ec == nil || ec.PredictiveProcessingContext == nil {
+			continue
+		}
+		for _, oc := range ec.PredictiveProcessingContext.ObservationContexts {
+			contextNames[oc.ID] = oc.Name
+			statesByContext[oc.ID] = oc.PossibleStates
+		}
+	}
+
+	var fixture BeliefSystemFixture
+	exported := make(map[*models.Belief]bool)
+	for i, ec := range beliefSystem.EpistemicContexts {
+		if ec == nil || ec.PredictiveProcessingContext == nil {
+			continue
+		}
+		ppc := ec.PredictiveProcessingContext
+
+		example := FixtureExample{Name: fmt.Sprintf("Example %d", i+1)}
+		for _, oc := range ppc.ObservationContexts {
+			nestedWithin := oc.ParentID
+			if name, ok := contextNames[oc.ParentID]; ok {
+				nestedWithin = name
+			}
+			example.ObservationContext = append(example.ObservationContext, FixtureObservationContext{
+				ContextName:  oc.Name,
+				NestedWithin: nestedWithin,
+			})
+		}
+
+		for _, bc := range ppc.BeliefContexts {
+			candidates := pending[bc.BeliefID]
+			if len(candidates) == 0 {
+				continue
+			}
+			belief := candidates[0]
+			pending[bc.BeliefID] = candidates[1:]
+			exported[belief] = true
+
+			predicted, counterfactual := fixtureOutcomes(bc.ConditionalProbs, statesByContext[bc.ObservationContextID])
+			example.Beliefs = append(example.Beliefs, FixtureBelief{
+				BeliefName:            fixtureBeliefName(belief.ID),
+				Context:               contextNames[bc.ObservationContextID],
+				Description:           belief.GetContentAsString(),
+				PredictedOutcome:      predicted,
+				CounterfactualOutcome: counterfactual,
+			})
+		}
+		fixture.BeliefSystem.Examples = append(fixture.BeliefSystem.Examples, example)
+	}
+
+	var unattached []FixtureBelief
+	for _, belief := range beliefSystem.Beliefs {
+		if exported[belief] {
+			continue
+		}
+		unattached = append(unattached, FixtureBelief{
+			BeliefName:  fixtureBeliefName(belief.ID),
+			Description: belief.GetContentAsString(),
+		})
+	}
+	if len(unattached) > 0 {
+		fixture.BeliefSystem.Examples = append(fixture.BeliefSystem.Examples, FixtureExample{
+			Name:    "Beliefs without observation contexts",
+			Beliefs: unattached,
+		})
+	}
+
+	data, err := yaml.Marshal(&fixture)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding YAML: %v", err)
+	}
+	return data, nil
+}
+
+// fixtureBeliefName reverses the "belief-<name>" IDs assigned by the importer
+func fixtureBeliefName(beliefID string) string {
+	return strings.TrimPrefix(beliefID, "belief-")
+}
+
+// fixtureOutcomes picks the most and least likely states of a belief context as its predicted and
+// counterfactual outcomes, falling back to the context's possible states in order.
+func fixtureOutcomes(conditionalProbs map[string]float32, possibleStates []string) (string, string) {
+	if len(conditionalProbs) > 0 {
+		states := make([]string, 0, len(conditionalProbs))
+		for state := range conditionalProbs {
+			states = append(states, state)
+		}
+		sort.Slice(states, func(i, j int) bool {
+			if conditionalProbs[states[i]] != conditionalProbs[states[j]] {
+				return conditionalProbs[states[i]] > conditionalProbs[states[j]]
+			}
+			return states[i] < states[j]
+		})
+		return states[0], states[len(states)-1]
+	}
+
+	var predicted, counterfactual string
+	if len(possibleStates) > 0 {
+		predicted = possibleStates[0]
+	}
+	if len(possibleStates) > 1 {
+		counterfactual = possibleStates[1]
+	}
+	return predicted, counterfactual
+}
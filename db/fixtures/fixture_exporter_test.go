@@ -0,0 +1,56 @@
+package fixture_models
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExportFixturesRoundTrip checks that exported fixtures import back into an equivalent belief system.
+func TestExportFixturesRoundTrip(t *testing.T) {
+	const userID = "fixture-user"
+
+	original, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	require.NoError(t, ImportFixtures(original, userID))
+
+	exported, err := ExportFixtures(original, userID)
+	require.NoError(t, err)
+	require.NotEmpty(t, exported)
+
+	roundTripped, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	require.NoError(t, ImportFixturesFromYAML(roundTripped, userID, exported))
+
+	want := retrieveBeliefSystem(t, original, userID)
+	got := retrieveBeliefSystem(t, roundTripped, userID)
+
+	require.Len(t, got.Beliefs, len(want.Beliefs))
+	for i := range want.Beliefs {
+		assert.Equal(t, want.Beliefs[i].ID, got.Beliefs[i].ID)
+		assert.Equal(t, want.Beliefs[i].GetContentAsString(), got.Beliefs[i].GetContentAsString())
+	}
+
+	require.Len(t, got.EpistemicContexts, len(want.EpistemicContexts))
+	for i := range want.EpistemicContexts {
+		wantPPC := want.EpistemicContexts[i].PredictiveProcessingContext
+		gotPPC := got.EpistemicContexts[i].PredictiveProcessingContext
+		assert.Len(t, gotPPC.ObservationContexts, len(wantPPC.ObservationContexts))
+		assert.Len(t, gotPPC.BeliefContexts, len(wantPPC.BeliefContexts))
+		for j := range wantPPC.ObservationContexts {
+			assert.Equal(t, wantPPC.ObservationContexts[j].Name, gotPPC.ObservationContexts[j].Name)
+			assert.Equal(t, wantPPC.ObservationContexts[j].ParentID, gotPPC.ObservationContexts[j].ParentID)
+		}
+	}
+}
+
+func retrieveBeliefSystem(t *testing.T, store db.KeyValueStore, userID string) *models.BeliefSystem {
+	value, err := store.Retrieve(userID, "BeliefSystem")
+	require.NoError(t, err)
+	require.IsType(t, &models.BeliefSystem{}, value)
+	return value.(*models.BeliefSystem)
+}
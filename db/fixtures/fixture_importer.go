@@ -13,7 +13,7 @@ import (
 )
 
 // ImportFixtures imports the belief system fixtures into the given KeyValueStore
-func ImportFixtures(kvStore *db.KeyValueStore, userID string) error {
+func ImportFixtures(kvStore db.KeyValueStore, userID string) error {
 	_, filename, _, ok := runtime.Caller(0)
 	if !ok {
 		return fmt.Errorf("failed to get current file path")
@@ -26,8 +26,13 @@ func ImportFixtures(kvStore *db.KeyValueStore, userID string) error {
 		return fmt.Errorf("error reading YAML file: %v", err)
 	}
 
+	return ImportFixturesFromYAML(kvStore, userID, yamlFile)
+}
+
+// ImportFixturesFromYAML imports a belief system fixture document into the given KeyValueStore
+func ImportFixturesFromYAML(kvStore db.KeyValueStore, userID string, yamlFile []byte) error {
 	var fixture BeliefSystemFixture
-	err = yaml.Unmarshal(yamlFile, &fixture)
+	err := yaml.Unmarshal(yamlFile, &fixture)
 	if err != nil {
 		return fmt.Errorf("error parsing YAML: %v", err)
 	}
@@ -110,18 +115,18 @@ func ImportFixtures(kvStore *db.KeyValueStore, userID string) error {
 
 	// First store each individual belief
 	for _, belief := range beliefSystem.Beliefs {
-		err = kvStore.Store(userID, belief.ID, *belief, int(belief.Version))
+		err = kvStore.ForceStore(userID, belief.ID, *belief, int(belief.Version))
 		if err != nil {
 			return fmt.Errorf("error storing belief %s: %v", belief.ID, err)
 		}
 	}
 
 	// Store the belief system with both keys for compatibility
-	err = kvStore.Store(userID, "BeliefSystem", *beliefSystem, 1)
+	err = kvStore.ForceStore(userID, "BeliefSystem", *beliefSystem, 1)
 	if err != nil {
 		return fmt.Errorf("error storing belief system: %v", err)
 	}
-	err = kvStore.Store(userID, "BeliefSystemId", *beliefSystem, 1)
+	err = kvStore.ForceStore(userID, "BeliefSystemId", *beliefSystem, 1)
 	if err != nil {
 		return fmt.Errorf("error storing belief system with ID key: %v", err)
 	}
@@ -0,0 +1,65 @@
+package fixture_models
+
+import (
+	"sort"
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportFixturesBackendParity imports the fixtures into every backend
+// and checks that they all return the same data.
+func TestImportFixturesBackendParity(t *testing.T) {
+	const userID = "fixture-user"
+
+	jsonStore, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	sqliteStore, err := db.NewSQLiteKeyValueStore("")
+	require.NoError(t, err)
+	defer sqliteStore.Close()
+
+	stores := map[string]db.KeyValueStore{
+		"json":   jsonStore,
+		"sqlite": sqliteStore,
+	}
+	for name, store := range stores {
+		require.NoError(t, ImportFixtures(store, userID), "importing fixtures into %s store", name)
+	}
+
+	jsonBS, err := jsonStore.Retrieve(userID, "BeliefSystem")
+	require.NoError(t, err)
+	sqliteBS, err := sqliteStore.Retrieve(userID, "BeliefSystem")
+	require.NoError(t, err)
+	require.IsType(t, &models.BeliefSystem{}, sqliteBS)
+	assert.Equal(t, jsonBS, sqliteBS)
+
+	jsonBeliefs := listBeliefs(t, jsonStore, userID)
+	sqliteBeliefs := listBeliefs(t, sqliteStore, userID)
+	require.NotEmpty(t, jsonBeliefs)
+	assert.Equal(t, jsonBeliefs, sqliteBeliefs)
+
+	for _, belief := range jsonBeliefs {
+		jsonBelief, err := jsonStore.Retrieve(userID, belief.ID)
+		require.NoError(t, err)
+		sqliteBelief, err := sqliteStore.Retrieve(userID, belief.ID)
+		require.NoError(t, err)
+		assert.Equal(t, jsonBelief, sqliteBelief)
+	}
+
+	for name, store := range stores {
+		store.ClearStore()
+		_, err := store.Retrieve(userID, "BeliefSystem")
+		assert.Error(t, err, "%s store should be empty after ClearStore", name)
+	}
+}
+
+func listBeliefs(t *testing.T, store db.KeyValueStore, userID string) []models.Belief {
+	beliefs, err := db.ListOfType[models.Belief](store, userID)
+	require.NoError(t, err)
+	sort.Slice(beliefs, func(i, j int) bool { return beliefs[i].ID < beliefs[j].ID })
+	return beliefs
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var storeOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "epistemic_me_store_operations_total",
+	Help: "Key-value store operations, by operation and outcome (ok, not_found, version_conflict or error).",
+}, []string{"operation", "outcome"})
+
+// instrumentedStore counts every operation on the store it wraps.
+type instrumentedStore struct {
+	KeyValueStore
+}
+
+// NewInstrumentedStore wraps store so its operations are counted in the Prometheus registry.
+func NewInstrumentedStore(store KeyValueStore) KeyValueStore {
+	return &instrumentedStore{KeyValueStore: store}
+}
+
+func countOperation(operation string, err error) {
+	outcome := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrNotFound):
+		outcome = "not_found"
+	case errors.Is(err, ErrVersionConflict):
+		outcome = "version_conflict"
+	default:
+		outcome = "error"
+	}
+	storeOperations.WithLabelValues(operation, outcome).Inc()
+}
+
+func (s *instrumentedStore) Store(developerId string, key string, value interface{}, version int, opts ...StoreOption) error {
+	err := s.KeyValueStore.Store(developerId, key, value, version, opts...)
+	countOperation("store", err)
+	return err
+}
+
+func (s *instrumentedStore) ForceStore(developerId string, key string, value interface{}, version int, opts ...StoreOption) error {
+	err := s.KeyValueStore.ForceStore(developerId, key, value, version, opts...)
+	countOperation("force_store", err)
+	return err
+}
+
+func (s *instrumentedStore) Retrieve(developerId string, key string) (interface{}, error) {
+	value, err := s.KeyValueStore.Retrieve(developerId, key)
+	countOperation("retrieve", err)
+	return value, err
+}
+
+func (s *instrumentedStore) RetrieveAllVersions(developerId string, key string) ([]interface{}, error) {
+	values, err := s.KeyValueStore.RetrieveAllVersions(developerId, key)
+	countOperation("retrieve_all_versions", err)
+	return values, err
+}
+
+func (s *instrumentedStore) ListByType(developerId string, objType reflect.Type) ([]interface{}, error) {
+	values, err := s.KeyValueStore.ListByType(developerId, objType)
+	countOperation("list_by_type", err)
+	return values, err
+}
+
+func (s *instrumentedStore) ListAllByType(objType reflect.Type) ([]interface{}, error) {
+	values, err := s.KeyValueStore.ListAllByType(objType)
+	countOperation("list_all_by_type", err)
+	return values, err
+}
+
+func (s *instrumentedStore) Delete(developerId string, key string) error {
+	err := s.KeyValueStore.Delete(developerId, key)
+	countOperation("delete", err)
+	return err
+}
+
+func (s *instrumentedStore) ClearStore() {
+	s.KeyValueStore.ClearStore()
+	countOperation("clear", nil)
+}
+
+func (s *instrumentedStore) SweepExpired() (int, error) {
+	removed, err := s.KeyValueStore.SweepExpired()
+	countOperation("sweep_expired", err)
+	return removed, err
+}
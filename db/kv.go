@@ -7,36 +7,59 @@ import (
 	"os"
 	"reflect"
 	"sync"
+	"time"
 )
 
-// KeyValueStore holds the in-memory store and a mutex for thread-safe access.
-type KeyValueStore struct {
-	store    map[string]map[string][]storedValue // developer_id -> key -> []storedValue (slice to hold different versions)
+// JSONKeyValueStore is a KeyValueStore that keeps all data in memory and
+// optionally persists it to a JSON file.
+type JSONKeyValueStore struct {
+	store    map[string]map[string][]storedValue             // developer_id -> key -> []storedValue (slice to hold different versions)
+	index    map[string]map[reflect.Type]map[string]struct{} // developer_id -> type -> keys whose latest version has that type
 	mu       sync.RWMutex
 	filePath string     // New field for persistence
 	diskMu   sync.Mutex // New mutex for disk operations
+	// generation counts the changes made to store and is guarded by mu; flushed is the generation
+	// last written to disk and is guarded by diskMu. Together they keep a slow writer from
+	// overwriting the file with an older snapshot.
+	generation uint64
+	flushed    uint64
 }
 
-// storedValue holds the JSON string, the type of the original object, and the version.
+// diskSnapshot is a serializable copy of the store taken at a given generation.
+type diskSnapshot struct {
+	generation uint64
+	data       map[string]map[string][]serializableStoredValue
+}
+
+// storedValue holds the JSON string, the type of the original object, the version
+// and an optional expiry (zero means the value never expires).
 type storedValue struct {
-	JsonData string
-	Type     reflect.Type
-	Version  int
+	JsonData           string
+	Type               reflect.Type
+	Version            int
+	ExpiresAtMillisUTC int64
+}
+
+// expired reports whether the value has an expiry at or before now.
+func (v storedValue) expired(now time.Time) bool {
+	return v.ExpiresAtMillisUTC != 0 && v.ExpiresAtMillisUTC <= now.UnixMilli()
 }
 
 // serializableStoredValue is a serializable version of storedValue
 type serializableStoredValue struct {
-	JsonData string
-	Type     string
-	Version  int
+	JsonData           string
+	Type               string
+	Version            int
+	ExpiresAtMillisUTC int64 `json:",omitempty"`
 }
 
-// NewKeyValueStore initializes and returns a new KeyValueStore.
+// NewKeyValueStore initializes and returns a new JSON-backed KeyValueStore.
 // If filePath is provided, it attempts to load the store from the file.
-func NewKeyValueStore(filePath string) (*KeyValueStore, error) {
+func NewKeyValueStore(filePath string) (*JSONKeyValueStore, error) {
 	log.Printf("Creating new KeyValueStore with filePath: %s", filePath)
-	kvs := &KeyValueStore{
+	kvs := &JSONKeyValueStore{
 		store:    make(map[string]map[string][]storedValue),
+		index:    make(map[string]map[reflect.Type]map[string]struct{}),
 		filePath: filePath,
 	}
 
@@ -66,61 +89,66 @@ func NewKeyValueStore(filePath string) (*KeyValueStore, error) {
 }
 
 // SaveToDisk persists the current state of the store to the file specified by filePath.
-func (kvs *KeyValueStore) SaveToDisk() error {
+func (kvs *JSONKeyValueStore) SaveToDisk() error {
 	if kvs.filePath == "" {
 		return nil // No persistence requested
 	}
 
-	kvs.diskMu.Lock()
-	defer kvs.diskMu.Unlock()
-
 	kvs.mu.RLock()
-	defer kvs.mu.RUnlock()
+	snapshot := kvs.snapshot()
+	kvs.mu.RUnlock()
 
-	// Create a snapshot of the store while holding the read lock
-	snapshot := make(map[string]map[string][]storedValue)
-	for developer, developerStore := range kvs.store {
-		snapshot[developer] = make(map[string][]storedValue)
-		for key, values := range developerStore {
-			snapshot[developer][key] = make([]storedValue, len(values))
-			copy(snapshot[developer][key], values)
-		}
-	}
+	return kvs.flush(snapshot)
+}
 
-	// Work with the snapshot to create the serializable store
-	serializableStore := make(map[string]map[string][]serializableStoredValue)
-	for developer, developerStore := range snapshot {
-		serializableStore[developer] = make(map[string][]serializableStoredValue)
+// snapshot copies the store into its serializable form. The caller must hold the lock, which
+// only needs to cover the copy: the snapshot is written by flush once the lock is released.
+func (kvs *JSONKeyValueStore) snapshot() diskSnapshot {
+	data := make(map[string]map[string][]serializableStoredValue, len(kvs.store))
+	for developer, developerStore := range kvs.store {
+		data[developer] = make(map[string][]serializableStoredValue, len(developerStore))
 		for key, values := range developerStore {
 			serializableValues := make([]serializableStoredValue, len(values))
 			for i, v := range values {
 				serializableValues[i] = serializableStoredValue{
-					JsonData: v.JsonData,
-					Type:     v.Type.String(),
-					Version:  v.Version,
+					JsonData:           v.JsonData,
+					Type:               typeName(v.Type),
+					Version:            v.Version,
+					ExpiresAtMillisUTC: v.ExpiresAtMillisUTC,
 				}
 			}
-			serializableStore[developer][key] = serializableValues
+			data[developer][key] = serializableValues
 		}
 	}
+	return diskSnapshot{generation: kvs.generation, data: data}
+}
+
+// flush writes a snapshot to filePath, unless a snapshot of a later generation has already been
+// written by a concurrent change.
+func (kvs *JSONKeyValueStore) flush(snapshot diskSnapshot) error {
+	kvs.diskMu.Lock()
+	defer kvs.diskMu.Unlock()
+
+	if snapshot.generation < kvs.flushed {
+		return nil
+	}
 
-	// Marshal the serializable store to JSON
-	jsonData, err := json.Marshal(serializableStore)
+	jsonData, err := json.Marshal(snapshot.data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal store: %w", err)
 	}
 
-	// Write to the file
 	err = os.WriteFile(kvs.filePath, jsonData, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write to file: %w", err)
 	}
 
+	kvs.flushed = snapshot.generation
 	return nil
 }
 
 // LoadFromDisk loads the store state from the file specified by filePath.
-func (kvs *KeyValueStore) LoadFromDisk() error {
+func (kvs *JSONKeyValueStore) LoadFromDisk() error {
 	data, err := os.ReadFile(kvs.filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
@@ -146,136 +174,168 @@ func (kvs *KeyValueStore) LoadFromDisk() error {
 					return fmt.Errorf("failed to get type from name: %w", err)
 				}
 				storedValues[i] = storedValue{
-					JsonData: v.JsonData,
-					Type:     t,
-					Version:  v.Version,
+					JsonData:           v.JsonData,
+					Type:               t,
+					Version:            v.Version,
+					ExpiresAtMillisUTC: v.ExpiresAtMillisUTC,
 				}
 			}
 			kvs.store[developer][key] = storedValues
 		}
 	}
+	kvs.rebuildIndex()
 
 	return nil
 }
 
 // Store checks if all fields in the given struct have JSON tags and stores the struct as JSON.
-// It stores the value with the specified version number.
-func (kvs *KeyValueStore) Store(developerId string, key string, value interface{}, version int) error {
-	log.Printf("Storing value of type %T for developer %s with key %s and version %d", value, developerId, key, version)
+// It stores the value with the specified version number, which must be greater than the
+// latest stored version; otherwise ErrVersionConflict is returned.
+func (kvs *JSONKeyValueStore) Store(developerId string, key string, value interface{}, version int, opts ...StoreOption) error {
+	return kvs.put(developerId, key, value, version, false, opts)
+}
 
-	v := reflect.ValueOf(value)
-	if v.Kind() != reflect.Struct {
-		return fmt.Errorf("value must be a struct")
-	}
+// ForceStore stores the value like Store but skips the version check: it replaces the value
+// stored under the same version and drops any later versions, so the forced value is what
+// Retrieve returns. It is meant for migrations and for last-writer-wins aggregates.
+func (kvs *JSONKeyValueStore) ForceStore(developerId string, key string, value interface{}, version int, opts ...StoreOption) error {
+	return kvs.put(developerId, key, value, version, true, opts)
+}
 
-	// Check for JSON annotations
-	t := v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		field := t.Field(i)
-		if _, ok := field.Tag.Lookup("json"); !ok {
-			return fmt.Errorf("field %s does not have a json tag", field.Name)
-		}
-	}
+func (kvs *JSONKeyValueStore) put(developerId string, key string, value interface{}, version int, force bool, opts []StoreOption) error {
+	log.Printf("Storing value of type %T for developer %s with key %s and version %d", value, developerId, key, version)
 
-	// Convert to JSON
-	jsonData, err := json.Marshal(value)
+	// Check for JSON annotations and convert to JSON
+	jsonData, err := marshalStructValue(value)
 	if err != nil {
 		return err
 	}
 	kvs.mu.Lock()
-	defer kvs.mu.Unlock()
+
+	// Insert the value at the correct version position
+	existingValues := kvs.store[developerId][key]
+	var previousType reflect.Type
+	if len(existingValues) > 0 {
+		previousType = existingValues[len(existingValues)-1].Type
+	}
+
+	// An expired key is treated as absent, so its old versions are dropped
+	if len(existingValues) > 0 && existingValues[len(existingValues)-1].expired(time.Now()) {
+		existingValues = nil
+	}
+
+	if !force && len(existingValues) > 0 {
+		if latest := existingValues[len(existingValues)-1].Version; version <= latest {
+			kvs.mu.Unlock()
+			return fmt.Errorf("%w: key %s is at version %d, got %d", ErrVersionConflict, key, latest, version)
+		}
+	}
+	// A forced write becomes the latest value, so the versions after it are dropped
+	if force {
+		kept := existingValues[:0:0]
+		for _, storedVal := range existingValues {
+			if storedVal.Version <= version {
+				kept = append(kept, storedVal)
+			}
+		}
+		existingValues = kept
+	}
 
 	// Perform in-memory store operation
 	if _, exists := kvs.store[developerId]; !exists {
 		kvs.store[developerId] = make(map[string][]storedValue)
 	}
 
-	// Insert the value at the correct version position
-	existingValues := kvs.store[developerId][key]
+	options := applyStoreOptions(opts)
+	if options.dropOlder {
+		kept := existingValues[:0:0]
+		for _, storedVal := range existingValues {
+			if storedVal.Version >= version {
+				kept = append(kept, storedVal)
+			}
+		}
+		existingValues = kept
+	}
+
+	newValue := storedValue{
+		JsonData:           jsonData,
+		Type:               reflect.TypeOf(value),
+		Version:            version,
+		ExpiresAtMillisUTC: options.expiresAtMillisUTC(),
+	}
 
 	// Check if the version already exists
+	replaced := false
 	for i, storedVal := range existingValues {
 		if storedVal.Version == version {
 			// Replace the existing version
-			kvs.store[developerId][key][i] = storedValue{
-				JsonData: string(jsonData),
-				Type:     reflect.TypeOf(value),
-				Version:  version,
-			}
-			return nil
+			existingValues[i] = newValue
+			kvs.store[developerId][key] = existingValues
+			replaced = true
+			break
 		}
 	}
 
-	kvs.store[developerId][key] = append(existingValues, storedValue{
-		JsonData: string(jsonData),
-		Type:     reflect.TypeOf(value),
-		Version:  version,
-	})
-
-	// Sort by version (in case versions are added out of order)
-	kvs.sortByVersion(developerId, key)
-
-	// Create a copy of the data to be persisted
-	var dataToPersist map[string]map[string][]storedValue
-	if kvs.filePath != "" {
-		dataToPersist = make(map[string]map[string][]storedValue)
-		for d, developerStore := range kvs.store {
-			dataToPersist[d] = make(map[string][]storedValue)
-			for k, values := range developerStore {
-				dataToPersist[d][k] = make([]storedValue, len(values))
-				copy(dataToPersist[d][k], values)
-			}
-		}
-	}
+	if !replaced {
+		kvs.store[developerId][key] = append(existingValues, newValue)
 
-	// Perform disk persistence outside of the lock
-	if kvs.filePath != "" {
-		err := kvs.saveToDiskWithData(dataToPersist)
-		if err != nil {
-			return err
-		}
+		// Sort by version (in case versions are added out of order)
+		kvs.sortByVersion(developerId, key)
 	}
+	values := kvs.store[developerId][key]
+	kvs.reindexKey(developerId, key, previousType, values[len(values)-1].Type)
 
-	return nil
-}
+	// Only the snapshot is taken under the write lock; the disk write happens outside it
+	snapshot := kvs.changed()
+	kvs.mu.Unlock()
 
-func (kvs *KeyValueStore) saveToDiskWithData(data map[string]map[string][]storedValue) error {
-	kvs.diskMu.Lock()
-	defer kvs.diskMu.Unlock()
+	return kvs.flushChange(snapshot)
+}
 
-	// Convert to serializable format
-	serializableStore := make(map[string]map[string][]serializableStoredValue)
-	for developer, developerStore := range data {
-		serializableStore[developer] = make(map[string][]serializableStoredValue)
-		for key, values := range developerStore {
-			serializableValues := make([]serializableStoredValue, len(values))
-			for i, v := range values {
-				serializableValues[i] = serializableStoredValue{
-					JsonData: v.JsonData,
-					Type:     v.Type.String(),
-					Version:  v.Version,
-				}
-			}
-			serializableStore[developer][key] = serializableValues
-		}
+// reindexKey moves a key from the index entry of its previous latest type to that of its new latest type.
+// The caller must hold the write lock.
+func (kvs *JSONKeyValueStore) reindexKey(developerId, key string, previousType, newType reflect.Type) {
+	if previousType != nil && previousType != newType {
+		kvs.unindexKey(developerId, key, previousType)
 	}
-
-	// Marshal and write to disk
-	jsonData, err := json.Marshal(serializableStore)
-	if err != nil {
-		return fmt.Errorf("failed to marshal store: %w", err)
+	if newType == nil {
+		return
+	}
+	if _, exists := kvs.index[developerId]; !exists {
+		kvs.index[developerId] = make(map[reflect.Type]map[string]struct{})
 	}
+	if _, exists := kvs.index[developerId][newType]; !exists {
+		kvs.index[developerId][newType] = make(map[string]struct{})
+	}
+	kvs.index[developerId][newType][key] = struct{}{}
+}
 
-	err = os.WriteFile(kvs.filePath, jsonData, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write to file: %w", err)
+// unindexKey removes a key from the index entry of the given type. The caller must hold the write lock.
+func (kvs *JSONKeyValueStore) unindexKey(developerId, key string, t reflect.Type) {
+	keys := kvs.index[developerId][t]
+	delete(keys, key)
+	if len(keys) == 0 {
+		delete(kvs.index[developerId], t)
+	}
+	if len(kvs.index[developerId]) == 0 {
+		delete(kvs.index, developerId)
 	}
+}
 
-	return nil
+// rebuildIndex recomputes the type index from the store. The caller must hold the write lock.
+func (kvs *JSONKeyValueStore) rebuildIndex() {
+	kvs.index = make(map[string]map[reflect.Type]map[string]struct{})
+	for developer, developerStore := range kvs.store {
+		for key, values := range developerStore {
+			if len(values) > 0 {
+				kvs.reindexKey(developer, key, nil, values[len(values)-1].Type)
+			}
+		}
+	}
 }
 
 // sortByVersion sorts the versions of a key for a developer in ascending order.
-func (kvs *KeyValueStore) sortByVersion(developer, key string) {
+func (kvs *JSONKeyValueStore) sortByVersion(developer, key string) {
 	values := kvs.store[developer][key]
 	for i := 1; i < len(values); i++ {
 		for j := i; j > 0 && values[j-1].Version > values[j].Version; j-- {
@@ -285,19 +345,19 @@ func (kvs *KeyValueStore) sortByVersion(developer, key string) {
 }
 
 // Retrieve gets the latest stored value under the given developer and key, and deserializes it into the original object type.
-func (kvs *KeyValueStore) Retrieve(developerId string, key string) (interface{}, error) {
+func (kvs *JSONKeyValueStore) Retrieve(developerId string, key string) (interface{}, error) {
 	log.Printf("Retrieving key %s for developer %s", key, developerId)
 	kvs.mu.RLock()
 	defer kvs.mu.RUnlock()
 
 	developerStore, developerExists := kvs.store[developerId]
 	if !developerExists {
-		return nil, fmt.Errorf("developer not found")
+		return nil, ErrDeveloperNotFound
 	}
 
 	storedValues, keyExists := developerStore[key]
-	if !keyExists || len(storedValues) == 0 {
-		return nil, fmt.Errorf("key not found")
+	if !keyExists || len(storedValues) == 0 || storedValues[len(storedValues)-1].expired(time.Now()) {
+		return nil, ErrKeyNotFound
 	}
 
 	// Get the latest version
@@ -317,19 +377,19 @@ func (kvs *KeyValueStore) Retrieve(developerId string, key string) (interface{},
 }
 
 // RetrieveAllVersions retrieves all versions of the stored value under the given developer and key.
-func (kvs *KeyValueStore) RetrieveAllVersions(developerId string, key string) ([]interface{}, error) {
+func (kvs *JSONKeyValueStore) RetrieveAllVersions(developerId string, key string) ([]interface{}, error) {
 	log.Printf("Retrieving all versions for key %s for developer %s", key, developerId)
 	kvs.mu.RLock()
 	defer kvs.mu.RUnlock()
 
 	developerStore, developerExists := kvs.store[developerId]
 	if !developerExists {
-		return nil, fmt.Errorf("developer not found")
+		return nil, ErrDeveloperNotFound
 	}
 
 	storedValues, keyExists := developerStore[key]
-	if !keyExists || len(storedValues) == 0 {
-		return nil, fmt.Errorf("key not found")
+	if !keyExists || len(storedValues) == 0 || storedValues[len(storedValues)-1].expired(time.Now()) {
+		return nil, ErrKeyNotFound
 	}
 
 	// Retrieve all versions
@@ -351,59 +411,131 @@ func (kvs *KeyValueStore) RetrieveAllVersions(developerId string, key string) ([
 
 // ListByType lists all objects of a given type associated with a developer.
 // It ensures that only the latest versions are returned.
-func (kvs *KeyValueStore) ListByType(developerId string, objType reflect.Type) ([]interface{}, error) {
+func (kvs *JSONKeyValueStore) ListByType(developerId string, objType reflect.Type) ([]interface{}, error) {
 	kvs.mu.RLock()
 	defer kvs.mu.RUnlock()
 
 	developerStore, developerExists := kvs.store[developerId]
 	if !developerExists {
-		return nil, fmt.Errorf("developer not found")
+		return nil, ErrDeveloperNotFound
 	}
 
+	return kvs.decodeIndexed(developerStore, kvs.index[developerId][objType], time.Now())
+}
+
+// decodeIndexed decodes the latest unexpired version of each of the given keys. The caller must hold the lock.
+func (kvs *JSONKeyValueStore) decodeIndexed(developerStore map[string][]storedValue, keys map[string]struct{}, now time.Time) ([]interface{}, error) {
 	var result []interface{}
-	for _, storedValues := range developerStore {
-		if len(storedValues) > 0 {
-			latestValue := storedValues[len(storedValues)-1]
-			if latestValue.Type == objType {
-				v := reflect.New(latestValue.Type).Interface()
-				err := json.Unmarshal([]byte(latestValue.JsonData), v)
-				if err != nil {
-					return nil, err
-				}
-				result = append(result, v)
-			}
+	for key := range keys {
+		storedValues := developerStore[key]
+		latestValue := storedValues[len(storedValues)-1]
+		if latestValue.expired(now) {
+			continue
 		}
+		v := reflect.New(latestValue.Type).Interface()
+		err := json.Unmarshal([]byte(latestValue.JsonData), v)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
 	}
-
 	return result, nil
 }
 
+// Delete removes every version stored under the given developer and key.
+func (kvs *JSONKeyValueStore) Delete(developerId string, key string) error {
+	kvs.mu.Lock()
+	developerStore, developerExists := kvs.store[developerId]
+	if !developerExists {
+		kvs.mu.Unlock()
+		return ErrDeveloperNotFound
+	}
+	values, keyExists := developerStore[key]
+	if !keyExists || len(values) == 0 {
+		kvs.mu.Unlock()
+		return ErrKeyNotFound
+	}
+	kvs.unindexKey(developerId, key, values[len(values)-1].Type)
+	delete(developerStore, key)
+	if len(developerStore) == 0 {
+		delete(kvs.store, developerId)
+	}
+	snapshot := kvs.changed()
+	kvs.mu.Unlock()
+
+	return kvs.flushChange(snapshot)
+}
+
 // ClearStore removes all data from the KeyValueStore
-func (kvs *KeyValueStore) ClearStore() {
+func (kvs *JSONKeyValueStore) ClearStore() {
+	kvs.mu.Lock()
 	kvs.store = make(map[string]map[string][]storedValue)
-	kvs.SaveToDisk() // If you want to clear the persistent storage as well
+	kvs.index = make(map[string]map[reflect.Type]map[string]struct{})
+	snapshot := kvs.changed()
+	kvs.mu.Unlock()
+	kvs.flushChange(snapshot) // If you want to clear the persistent storage as well
+}
+
+// changed records a change to the store and, when it is persisted, returns the snapshot to
+// write. The caller must hold the write lock.
+func (kvs *JSONKeyValueStore) changed() *diskSnapshot {
+	kvs.generation++
+	if kvs.filePath == "" {
+		return nil
+	}
+	snapshot := kvs.snapshot()
+	return &snapshot
+}
+
+// flushChange writes a snapshot returned by changed, if any.
+func (kvs *JSONKeyValueStore) flushChange(snapshot *diskSnapshot) error {
+	if snapshot == nil {
+		return nil
+	}
+	return kvs.flush(*snapshot)
+}
+
+// SweepExpired removes every key whose latest version has expired and returns how many stored versions were removed.
+func (kvs *JSONKeyValueStore) SweepExpired() (int, error) {
+	now := time.Now()
+	removed := 0
+
+	kvs.mu.Lock()
+	for developer, developerStore := range kvs.store {
+		for key, values := range developerStore {
+			if len(values) > 0 && values[len(values)-1].expired(now) {
+				kvs.unindexKey(developer, key, values[len(values)-1].Type)
+				delete(developerStore, key)
+				removed += len(values)
+			}
+		}
+		if len(developerStore) == 0 {
+			delete(kvs.store, developer)
+		}
+	}
+	if removed == 0 {
+		kvs.mu.Unlock()
+		return 0, nil
+	}
+	snapshot := kvs.changed()
+	kvs.mu.Unlock()
+
+	return removed, kvs.flushChange(snapshot)
 }
 
 // ListAllByType lists all objects of a given type across all developers.
-func (kvs *KeyValueStore) ListAllByType(objType reflect.Type) ([]interface{}, error) {
+func (kvs *JSONKeyValueStore) ListAllByType(objType reflect.Type) ([]interface{}, error) {
 	kvs.mu.RLock()
 	defer kvs.mu.RUnlock()
 
+	now := time.Now()
 	var result []interface{}
-	for _, developerStore := range kvs.store {
-		for _, storedValues := range developerStore {
-			if len(storedValues) > 0 {
-				latestValue := storedValues[len(storedValues)-1]
-				if latestValue.Type == objType {
-					v := reflect.New(latestValue.Type).Interface()
-					err := json.Unmarshal([]byte(latestValue.JsonData), v)
-					if err != nil {
-						return nil, err
-					}
-					result = append(result, v)
-				}
-			}
+	for developer, types := range kvs.index {
+		values, err := kvs.decodeIndexed(kvs.store[developer], types[objType], now)
+		if err != nil {
+			return nil, err
 		}
+		result = append(result, values...)
 	}
 
 	return result, nil
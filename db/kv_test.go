@@ -2,10 +2,13 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -93,7 +96,75 @@ func TestKeyValueStore(t *testing.T) {
 	})
 }
 
-func runTests(t *testing.T, store *KeyValueStore) error {
+func TestSQLiteKeyValueStore(t *testing.T) {
+	t.Run("In-Memory Store", func(t *testing.T) {
+		store, err := NewSQLiteKeyValueStore("")
+		require.NoError(t, err)
+		defer store.Close()
+		require.NoError(t, runTests(t, store))
+	})
+
+	t.Run("On-Disk Store", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "kvstore.db")
+
+		store, err := NewSQLiteKeyValueStore(filePath)
+		require.NoError(t, err)
+		require.NoError(t, runTests(t, store))
+		require.NoError(t, testPersistence(t, store))
+		require.NoError(t, store.Close())
+
+		// Reopen the database and make sure the data survived
+		reopened, err := NewSQLiteKeyValueStore(filePath)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		retrieved, err := reopened.Retrieve("testDeveloper", "persistenceKey")
+		require.NoError(t, err)
+		assert.Equal(t, TestStruct{ID: "1", Name: "Persistence Test"}, *retrieved.(*TestStruct))
+	})
+
+	t.Run("Drop Older Versions", func(t *testing.T) {
+		err = testDropOlderVersions(t, store)
+		assert.NoError(t, err)
+	})
+	if err != nil {
+		return err
+	}
+
+	t.Run("StoreWithVersionReplacement", func(t *testing.T) {
+		store, err := NewSQLiteKeyValueStore("")
+		require.NoError(t, err)
+		defer store.Close()
+
+		require.NoError(t, store.Store("testDeveloper", "testkey", TestStruct{ID: "1", Name: "Test1"}, 1))
+		err = store.Store("testDeveloper", "testkey", TestStruct{ID: "1", Name: "Test2"}, 1)
+		require.ErrorIs(t, err, ErrVersionConflict)
+		require.NoError(t, store.ForceStore("testDeveloper", "testkey", TestStruct{ID: "1", Name: "Test2"}, 1))
+
+		versions, err := store.RetrieveAllVersions("testDeveloper", "testkey")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		assert.Equal(t, "Test2", versions[0].(*TestStruct).Name)
+	})
+
+	t.Run("Not Found Errors", func(t *testing.T) {
+		store, err := NewSQLiteKeyValueStore("")
+		require.NoError(t, err)
+		defer store.Close()
+
+		_, err = store.Retrieve("missing", "key")
+		assert.EqualError(t, err, "developer not found")
+
+		require.NoError(t, store.Store("dev", "key", TestStruct{ID: "1"}, 1))
+		_, err = store.Retrieve("dev", "missing")
+		assert.EqualError(t, err, "key not found")
+
+		_, err = store.ListByType("missing", reflect.TypeOf(TestStruct{}))
+		assert.EqualError(t, err, "developer not found")
+	})
+}
+
+func runTests(t *testing.T, store KeyValueStore) error {
 	var err error
 
 	t.Run("Store and Retrieve", func(t *testing.T) {
@@ -114,7 +185,7 @@ func runTests(t *testing.T, store *KeyValueStore) error {
 
 	t.Run("ListByType", func(t *testing.T) {
 		// Clear the store before this test
-		store.store = make(map[string]map[string][]storedValue)
+		store.ClearStore()
 		err = testListByType(t, store)
 		assert.NoError(t, err)
 	})
@@ -122,6 +193,30 @@ func runTests(t *testing.T, store *KeyValueStore) error {
 		return err
 	}
 
+	t.Run("Version Conflict", func(t *testing.T) {
+		err = testVersionConflict(t, store)
+		assert.NoError(t, err)
+	})
+	if err != nil {
+		return err
+	}
+
+	t.Run("Expiry", func(t *testing.T) {
+		err = testExpiry(t, store)
+		assert.NoError(t, err)
+	})
+	if err != nil {
+		return err
+	}
+
+	t.Run("Drop Older Versions", func(t *testing.T) {
+		err = testDropOlderVersions(t, store)
+		assert.NoError(t, err)
+	})
+	if err != nil {
+		return err
+	}
+
 	t.Run("StoreWithVersionReplacement", func(t *testing.T) {
 		TestKeyValueStore_StoreWithVersionReplacement(t)
 	})
@@ -132,7 +227,7 @@ func runTests(t *testing.T, store *KeyValueStore) error {
 	return nil
 }
 
-func testStoreAndRetrieve(t *testing.T, store *KeyValueStore) error {
+func testStoreAndRetrieve(t *testing.T, store KeyValueStore) error {
 	developerId := "testDeveloper"
 	key := "testKey"
 	value := TestStruct{ID: "1", Name: "Test"}
@@ -173,7 +268,7 @@ func testStoreAndRetrieve(t *testing.T, store *KeyValueStore) error {
 	return nil
 }
 
-func testStoreMultipleVersions(t *testing.T, store *KeyValueStore) error {
+func testStoreMultipleVersions(t *testing.T, store KeyValueStore) error {
 	developerId := "testDeveloper"
 	key := "multiVersionKey"
 	value1 := TestStruct{ID: "1", Name: "Version 1"}
@@ -209,7 +304,7 @@ func testStoreMultipleVersions(t *testing.T, store *KeyValueStore) error {
 	return nil
 }
 
-func testListByType(t *testing.T, store *KeyValueStore) error {
+func testListByType(t *testing.T, store KeyValueStore) error {
 	developerId := "testDeveloper"
 	value1 := TestStruct{ID: "1", Name: "Test1"}
 	value2 := TestStruct{ID: "2", Name: "Test2"}
@@ -236,7 +331,127 @@ func testListByType(t *testing.T, store *KeyValueStore) error {
 	return nil
 }
 
-func testPersistence(t *testing.T, store *KeyValueStore) error {
+func testVersionConflict(t *testing.T, store KeyValueStore) error {
+	developerId := "testDeveloper"
+	key := "conflictKey"
+
+	if err := store.Store(developerId, key, TestStruct{ID: "1", Name: "Version 2"}, 2); err != nil {
+		return fmt.Errorf("failed to store initial version: %v", err)
+	}
+
+	for _, version := range []int{1, 2} {
+		err := store.Store(developerId, key, TestStruct{ID: "1", Name: "Stale"}, version)
+		if !errors.Is(err, ErrVersionConflict) {
+			return fmt.Errorf("expected ErrVersionConflict for version %d, got %v", version, err)
+		}
+	}
+
+	if err := store.Store(developerId, key, TestStruct{ID: "1", Name: "Version 3"}, 3); err != nil {
+		return fmt.Errorf("failed to store newer version: %v", err)
+	}
+
+	retrieved, err := store.Retrieve(developerId, key)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve value: %v", err)
+	}
+	if retrieved.(*TestStruct).Name != "Version 3" {
+		return fmt.Errorf("expected latest version to be stored, got %+v", retrieved)
+	}
+
+	return nil
+}
+
+func testExpiry(t *testing.T, store KeyValueStore) error {
+	developerId := "expiryDeveloper"
+	value := TestStruct{ID: "1", Name: "Ephemeral"}
+
+	if err := store.Store(developerId, "expired", value, 1, WithExpiry(time.Now().Add(-time.Second))); err != nil {
+		return fmt.Errorf("failed to store expired value: %v", err)
+	}
+	if err := store.Store(developerId, "live", value, 1, WithTTL(time.Hour)); err != nil {
+		return fmt.Errorf("failed to store live value: %v", err)
+	}
+	if err := store.Store(developerId, "permanent", value, 1); err != nil {
+		return fmt.Errorf("failed to store permanent value: %v", err)
+	}
+
+	if _, err := store.Retrieve(developerId, "expired"); err == nil {
+		return fmt.Errorf("expected expired value to be treated as not found")
+	}
+	if _, err := store.Retrieve(developerId, "live"); err != nil {
+		return fmt.Errorf("failed to retrieve live value: %v", err)
+	}
+
+	results, err := store.ListByType(developerId, reflect.TypeOf(TestStruct{}))
+	if err != nil {
+		return fmt.Errorf("failed to list by type: %v", err)
+	}
+	if len(results) != 2 {
+		return fmt.Errorf("expected 2 unexpired results, got %d", len(results))
+	}
+
+	removed, err := store.SweepExpired()
+	if err != nil {
+		return fmt.Errorf("failed to sweep expired values: %v", err)
+	}
+	if removed != 1 {
+		return fmt.Errorf("expected 1 swept value, got %d", removed)
+	}
+
+	// An expired key can be written again from version 1
+	if err := store.Store(developerId, "expired", value, 1); err != nil {
+		return fmt.Errorf("failed to store over swept value: %v", err)
+	}
+
+	return nil
+}
+
+func testDropOlderVersions(t *testing.T, store KeyValueStore) error {
+	developerId := "dropOlderDeveloper"
+	for version := 1; version <= 2; version++ {
+		if err := store.Store(developerId, "log", TestStruct{ID: "1", Name: fmt.Sprint(version)}, version); err != nil {
+			return fmt.Errorf("failed to store version %d: %v", version, err)
+		}
+	}
+	if err := store.Store(developerId, "log", TestStruct{ID: "1", Name: "3"}, 3, DropOlderVersions()); err != nil {
+		return fmt.Errorf("failed to store version 3: %v", err)
+	}
+
+	versions, err := store.RetrieveAllVersions(developerId, "log")
+	if err != nil {
+		return fmt.Errorf("failed to retrieve all versions: %v", err)
+	}
+	if len(versions) != 1 {
+		return fmt.Errorf("expected only the latest version to be kept, got %d", len(versions))
+	}
+	// The version check still applies
+	if err := store.Store(developerId, "log", TestStruct{ID: "1", Name: "stale"}, 3, DropOlderVersions()); !errors.Is(err, ErrVersionConflict) {
+		return fmt.Errorf("expected a version conflict, got %v", err)
+	}
+	return nil
+}
+
+func TestExpirySweeper(t *testing.T) {
+	store, err := NewKeyValueStore("")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Store("dev", "key", TestStruct{ID: "1"}, 1, WithTTL(10*time.Millisecond)))
+
+	sweeper := StartExpirySweeper(store, 5*time.Millisecond)
+	defer sweeper.Stop()
+
+	require.Eventually(t, func() bool {
+		store.mu.RLock()
+		defer store.mu.RUnlock()
+		_, exists := store.store["dev"]
+		return !exists
+	}, time.Second, 5*time.Millisecond, "expired key should be swept")
+
+	sweeper.Stop()
+	sweeper.Stop() // stopping twice is a no-op
+}
+
+func testPersistence(t *testing.T, store KeyValueStore) error {
 	developerId := "testDeveloper"
 	key := "persistenceKey"
 	value := TestStruct{ID: "1", Name: "Persistence Test"}
@@ -293,8 +508,14 @@ func TestKeyValueStore_StoreWithVersionReplacement(t *testing.T) {
 		t.Fatalf("Failed to store initial value: %v", err)
 	}
 
-	// Store a new value with the same version (1)
+	// Storing a new value with the same version (1) is a version conflict
 	err = kvs.Store(developerId, key, value2, 1)
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Expected ErrVersionConflict, got %v", err)
+	}
+
+	// Force-store a new value with the same version (1)
+	err = kvs.ForceStore(developerId, key, value2, 1)
 	if err != nil {
 		t.Fatalf("Failed to store replacement value: %v", err)
 	}
@@ -355,3 +576,249 @@ func TestKeyValueStore_StoreWithVersionReplacement(t *testing.T) {
 		}
 	}
 }
+
+// benchOtherStruct is a second type mixed into the benchmark store so ListByType has entries to skip.
+type benchOtherStruct struct {
+	ID string `json:"id"`
+}
+
+// listByTypeScan is the full-scan ListByType implementation the type index replaced,
+// kept as the baseline for BenchmarkListByType.
+func listByTypeScan(kvs *JSONKeyValueStore, developerId string, objType reflect.Type) ([]interface{}, error) {
+	kvs.mu.RLock()
+	defer kvs.mu.RUnlock()
+
+	var result []interface{}
+	for _, storedValues := range kvs.store[developerId] {
+		latestValue := storedValues[len(storedValues)-1]
+		if latestValue.Type == objType {
+			v := reflect.New(latestValue.Type).Interface()
+			if err := json.Unmarshal([]byte(latestValue.JsonData), v); err != nil {
+				return nil, err
+			}
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+func BenchmarkListByType(b *testing.B) {
+	const (
+		records    = 10000
+		developers = 10
+	)
+	store, err := NewKeyValueStore("")
+	require.NoError(b, err)
+	for i := 0; i < records; i++ {
+		developerId := fmt.Sprintf("developer-%d", i%developers)
+		key := fmt.Sprintf("key-%d", i)
+		var value interface{} = benchOtherStruct{ID: key}
+		// One record in fifty is the listed type
+		if i%50 == 0 {
+			value = TestStruct{ID: key, Name: "listed"}
+		}
+		require.NoError(b, store.Store(developerId, key, value, 1))
+	}
+	objType := reflect.TypeOf(TestStruct{})
+
+	b.Run("Scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := listByTypeScan(store, "developer-0", objType); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := store.ListByType("developer-0", objType); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestListByTypeIndex(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "kvstore.json")
+	store, err := NewKeyValueStore(filePath)
+	require.NoError(t, err)
+
+	testType := reflect.TypeOf(TestStruct{})
+	otherType := reflect.TypeOf(benchOtherStruct{})
+
+	require.NoError(t, store.Store("dev", "a", TestStruct{ID: "a"}, 1))
+	require.NoError(t, store.Store("dev", "b", TestStruct{ID: "b"}, 1))
+
+	// Overwriting an entry with a different type moves it in the index
+	require.NoError(t, store.Store("dev", "b", benchOtherStruct{ID: "b"}, 2))
+	results, err := store.ListByType("dev", testType)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+	results, err = store.ListByType("dev", otherType)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// A rejected write leaves the index untouched
+	err = store.Store("dev", "a", benchOtherStruct{ID: "a"}, 1)
+	require.ErrorIs(t, err, ErrVersionConflict)
+	results, err = store.ListByType("dev", testType)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// The index is rebuilt when the store is reloaded from disk
+	RegisterType(benchOtherStruct{})
+	reloaded, err := NewKeyValueStore(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, store.index, reloaded.index)
+
+	// Swept entries are removed from the index
+	require.NoError(t, reloaded.Store("dev", "c", TestStruct{ID: "c"}, 1, WithExpiry(time.Now().Add(-time.Second))))
+	_, err = reloaded.SweepExpired()
+	require.NoError(t, err)
+	assert.NotContains(t, reloaded.index["dev"][testType], "c")
+}
+
+func TestForceStoreBecomesLatest(t *testing.T) {
+	jsonStore, err := NewKeyValueStore("")
+	require.NoError(t, err)
+	sqliteStore, err := NewSQLiteKeyValueStore("")
+	require.NoError(t, err)
+	defer sqliteStore.Close()
+
+	for name, store := range map[string]KeyValueStore{"JSON": jsonStore, "SQLite": sqliteStore} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, store.ForceStore("dev", "key", TestStruct{ID: "1", Name: "v1"}, 1))
+			require.NoError(t, store.ForceStore("dev", "key", TestStruct{ID: "1", Name: "v4"}, 4))
+
+			// A forced write at a lower version replaces the later ones
+			require.NoError(t, store.ForceStore("dev", "key", TestStruct{ID: "1", Name: "forced"}, 1))
+			retrieved, err := store.Retrieve("dev", "key")
+			require.NoError(t, err)
+			assert.Equal(t, "forced", retrieved.(*TestStruct).Name)
+			versions, err := store.RetrieveAllVersions("dev", "key")
+			require.NoError(t, err)
+			assert.Len(t, versions, 1)
+
+			// Versioned writes carry on from the forced version
+			require.NoError(t, store.Store("dev", "key", TestStruct{ID: "1", Name: "v2"}, 2))
+			retrieved, err = store.Retrieve("dev", "key")
+			require.NoError(t, err)
+			assert.Equal(t, "v2", retrieved.(*TestStruct).Name)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	jsonStore, err := NewKeyValueStore("")
+	require.NoError(t, err)
+	sqliteStore, err := NewSQLiteKeyValueStore("")
+	require.NoError(t, err)
+	defer sqliteStore.Close()
+
+	for name, store := range map[string]KeyValueStore{"json": jsonStore, "sqlite": sqliteStore} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, store.Store("dev", "gone", TestStruct{ID: "1"}, 1))
+			require.NoError(t, store.Store("dev", "gone", TestStruct{ID: "1"}, 2))
+			require.NoError(t, store.Store("dev", "kept", TestStruct{ID: "2"}, 1))
+
+			require.NoError(t, store.Delete("dev", "gone"))
+			_, err := store.Retrieve("dev", "gone")
+			assert.Error(t, err)
+			_, err = store.RetrieveAllVersions("dev", "gone")
+			assert.Error(t, err)
+
+			results, err := store.ListByType("dev", reflect.TypeOf(TestStruct{}))
+			require.NoError(t, err)
+			assert.Len(t, results, 1)
+
+			assert.Error(t, store.Delete("dev", "gone"))
+		})
+	}
+}
+
+// TestKeyValueStoreConcurrentAccess exercises the store from many goroutines at once. Run it with
+// go test -race ./db/ to check that every access to the maps and the file is synchronized.
+func TestKeyValueStoreConcurrentAccess(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "kvstore.json")
+	store, err := NewKeyValueStore(filePath)
+	require.NoError(t, err)
+
+	const workers = 8
+	const writes = 25
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			developerId := fmt.Sprintf("dev-%d", w)
+			for i := 1; i <= writes; i++ {
+				key := fmt.Sprintf("key-%d", i%5)
+				assert.NoError(t, store.ForceStore(developerId, key, TestStruct{ID: key, Name: fmt.Sprint(i)}, i))
+				_, err := store.Retrieve(developerId, key)
+				assert.NoError(t, err)
+				_, err = store.ListByType(developerId, reflect.TypeOf(TestStruct{}))
+				assert.NoError(t, err)
+				_, err = store.ListAllByType(reflect.TypeOf(TestStruct{}))
+				assert.NoError(t, err)
+				if i%10 == 0 {
+					assert.NoError(t, store.Delete(developerId, key))
+					_, err = store.SweepExpired()
+					assert.NoError(t, err)
+				}
+			}
+		}(w)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < writes; i++ {
+			assert.NoError(t, store.SaveToDisk())
+		}
+	}()
+	wg.Wait()
+
+	// The file holds the last change even though flushes from different goroutines interleaved
+	require.NoError(t, store.ForceStore("dev-final", "key", TestStruct{ID: "final"}, 1))
+	reloaded, err := NewKeyValueStore(filePath)
+	require.NoError(t, err)
+	for w := 0; w < workers; w++ {
+		expected, err := store.ListByType(fmt.Sprintf("dev-%d", w), reflect.TypeOf(TestStruct{}))
+		require.NoError(t, err)
+		actual, err := reloaded.ListByType(fmt.Sprintf("dev-%d", w), reflect.TypeOf(TestStruct{}))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, expected, actual)
+	}
+	_, err = reloaded.Retrieve("dev-final", "key")
+	assert.NoError(t, err)
+
+	store.ClearStore()
+	_, err = store.Retrieve("dev-final", "key")
+	assert.Error(t, err)
+}
+
+func TestRetrievedValuesAreCopies(t *testing.T) {
+	jsonStore, err := NewKeyValueStore("")
+	require.NoError(t, err)
+	sqliteStore, err := NewSQLiteKeyValueStore("")
+	require.NoError(t, err)
+
+	for name, store := range map[string]KeyValueStore{"JSON": jsonStore, "SQLite": sqliteStore} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, store.Store("dev", "key", TestStruct{ID: "1", Name: "original"}, 1))
+
+			retrieved, err := store.Retrieve("dev", "key")
+			require.NoError(t, err)
+			retrieved.(*TestStruct).Name = "mutated"
+
+			listed, err := store.ListByType("dev", reflect.TypeOf(TestStruct{}))
+			require.NoError(t, err)
+			require.Len(t, listed, 1)
+			assert.Equal(t, "original", listed[0].(*TestStruct).Name)
+			listed[0].(*TestStruct).Name = "mutated"
+
+			again, err := store.Retrieve("dev", "key")
+			require.NoError(t, err)
+			assert.Equal(t, &TestStruct{ID: "1", Name: "original"}, again)
+		})
+	}
+}
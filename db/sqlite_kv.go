@@ -0,0 +1,368 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the pure Go "sqlite" driver
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS kv_store (
+	self_model_id TEXT    NOT NULL,
+	key           TEXT    NOT NULL,
+	version       INTEGER NOT NULL,
+	type          TEXT    NOT NULL,
+	json_data     TEXT    NOT NULL,
+	expires_at    INTEGER,
+	PRIMARY KEY (self_model_id, key, version)
+);
+CREATE INDEX IF NOT EXISTS kv_store_type ON kv_store (self_model_id, type);
+`
+
+// latestVersionsQuery selects the latest, unexpired version of every key matching the given filter.
+// Its first parameter is the current time in milliseconds.
+const latestVersionsQuery = `
+SELECT kv.type, kv.json_data FROM kv_store kv
+JOIN (
+	SELECT self_model_id, key, MAX(version) AS version FROM kv_store GROUP BY self_model_id, key
+) latest ON kv.self_model_id = latest.self_model_id AND kv.key = latest.key AND kv.version = latest.version
+WHERE (kv.expires_at IS NULL OR kv.expires_at > ?) AND %s`
+
+// sweepExpiredQuery deletes every version of the keys whose latest version expired before the given time.
+const sweepExpiredQuery = `
+DELETE FROM kv_store WHERE (self_model_id, key) IN (
+	SELECT kv.self_model_id, kv.key FROM kv_store kv
+	JOIN (
+		SELECT self_model_id, key, MAX(version) AS version FROM kv_store GROUP BY self_model_id, key
+	) latest ON kv.self_model_id = latest.self_model_id AND kv.key = latest.key AND kv.version = latest.version
+	WHERE kv.expires_at IS NOT NULL AND kv.expires_at <= ?
+)`
+
+// SQLiteKeyValueStore is a KeyValueStore backed by a SQLite database.
+// Rows are keyed on (self_model_id, key, version) and hold the value as a JSON blob.
+// The self_model_id column holds the same owner ID the JSON store calls developerId.
+type SQLiteKeyValueStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteKeyValueStore opens (or creates) the SQLite database at path.
+// An empty path creates an in-memory database.
+func NewSQLiteKeyValueStore(path string) (*SQLiteKeyValueStore, error) {
+	log.Printf("Creating new SQLite KeyValueStore with path: %s", path)
+	dsn := path
+	if dsn == "" {
+		dsn = ":memory:"
+	}
+
+	sqlDB, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	// A single connection serializes writes and keeps an in-memory database alive.
+	sqlDB.SetMaxOpenConns(1)
+
+	if _, err := sqlDB.Exec(sqliteSchema); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+	if err := addColumnIfMissing(sqlDB, "kv_store", "expires_at", "INTEGER"); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return &SQLiteKeyValueStore{db: sqlDB}, nil
+}
+
+// Close closes the underlying database.
+func (kvs *SQLiteKeyValueStore) Close() error {
+	return kvs.db.Close()
+}
+
+// Store checks if all fields in the given struct have JSON tags and stores the struct as JSON.
+// It stores the value with the specified version number, which must be greater than the
+// latest stored version; otherwise ErrVersionConflict is returned.
+func (kvs *SQLiteKeyValueStore) Store(developerId string, key string, value interface{}, version int, opts ...StoreOption) error {
+	return kvs.put(developerId, key, value, version, false, opts)
+}
+
+// ForceStore stores the value like Store but skips the version check: it replaces the row
+// stored under the same version and deletes any later versions, so the forced value is what
+// Retrieve returns.
+func (kvs *SQLiteKeyValueStore) ForceStore(developerId string, key string, value interface{}, version int, opts ...StoreOption) error {
+	return kvs.put(developerId, key, value, version, true, opts)
+}
+
+func (kvs *SQLiteKeyValueStore) put(developerId string, key string, value interface{}, version int, force bool, opts []StoreOption) error {
+	log.Printf("Storing value of type %T for developer %s with key %s and version %d", value, developerId, key, version)
+
+	jsonData, err := marshalStructValue(value)
+	if err != nil {
+		return err
+	}
+
+	tx, err := kvs.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var latestVersion, latestExpiry sql.NullInt64
+	err = tx.QueryRow(
+		`SELECT version, expires_at FROM kv_store WHERE self_model_id = ? AND key = ? ORDER BY version DESC LIMIT 1`,
+		developerId, key,
+	).Scan(&latestVersion, &latestExpiry)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read latest version: %w", err)
+	}
+
+	// An expired key is treated as absent, so its old versions are dropped
+	if latestExpiry.Valid && latestExpiry.Int64 <= time.Now().UnixMilli() {
+		if _, err := tx.Exec(`DELETE FROM kv_store WHERE self_model_id = ? AND key = ?`, developerId, key); err != nil {
+			return fmt.Errorf("failed to remove expired value: %w", err)
+		}
+		latestVersion = sql.NullInt64{}
+	}
+
+	if !force && latestVersion.Valid && int64(version) <= latestVersion.Int64 {
+		return fmt.Errorf("%w: key %s is at version %d, got %d", ErrVersionConflict, key, latestVersion.Int64, version)
+	}
+	// A forced write becomes the latest value, so the versions after it are dropped
+	if force && latestVersion.Valid && int64(version) < latestVersion.Int64 {
+		if _, err := tx.Exec(`DELETE FROM kv_store WHERE self_model_id = ? AND key = ? AND version > ?`, developerId, key, version); err != nil {
+			return fmt.Errorf("failed to remove later versions: %w", err)
+		}
+	}
+
+	options := applyStoreOptions(opts)
+	if options.dropOlder {
+		if _, err := tx.Exec(`DELETE FROM kv_store WHERE self_model_id = ? AND key = ? AND version < ?`, developerId, key, version); err != nil {
+			return fmt.Errorf("failed to remove older versions: %w", err)
+		}
+	}
+
+	var expiresAt sql.NullInt64
+	if millis := options.expiresAtMillisUTC(); millis != 0 {
+		expiresAt = sql.NullInt64{Int64: millis, Valid: true}
+	}
+
+	_, err = tx.Exec(
+		`INSERT OR REPLACE INTO kv_store (self_model_id, key, version, type, json_data, expires_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		developerId, key, version, typeName(reflect.TypeOf(value)), jsonData, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store value: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Retrieve gets the latest stored value under the given developer and key.
+func (kvs *SQLiteKeyValueStore) Retrieve(developerId string, key string) (interface{}, error) {
+	log.Printf("Retrieving key %s for developer %s", key, developerId)
+
+	var typeStr, jsonData string
+	var expiresAt sql.NullInt64
+	err := kvs.db.QueryRow(
+		`SELECT type, json_data, expires_at FROM kv_store WHERE self_model_id = ? AND key = ? ORDER BY version DESC LIMIT 1`,
+		developerId, key,
+	).Scan(&typeStr, &jsonData, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, kvs.notFoundError(developerId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve value: %w", err)
+	}
+	if expiresAt.Valid && expiresAt.Int64 <= time.Now().UnixMilli() {
+		return nil, ErrKeyNotFound
+	}
+
+	return decodeStoredValue(typeStr, jsonData)
+}
+
+// RetrieveAllVersions retrieves all versions of the stored value under the given developer and key.
+func (kvs *SQLiteKeyValueStore) RetrieveAllVersions(developerId string, key string) ([]interface{}, error) {
+	log.Printf("Retrieving all versions for key %s for developer %s", key, developerId)
+
+	var expiresAt sql.NullInt64
+	err := kvs.db.QueryRow(
+		`SELECT expires_at FROM kv_store WHERE self_model_id = ? AND key = ? ORDER BY version DESC LIMIT 1`,
+		developerId, key,
+	).Scan(&expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, kvs.notFoundError(developerId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve versions: %w", err)
+	}
+	if expiresAt.Valid && expiresAt.Int64 <= time.Now().UnixMilli() {
+		return nil, ErrKeyNotFound
+	}
+
+	rows, err := kvs.db.Query(
+		`SELECT type, json_data FROM kv_store WHERE self_model_id = ? AND key = ? ORDER BY version ASC`,
+		developerId, key,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve versions: %w", err)
+	}
+	return decodeRows(rows)
+}
+
+// ListByType lists the latest version of all objects of a given type associated with a developer.
+func (kvs *SQLiteKeyValueStore) ListByType(developerId string, objType reflect.Type) ([]interface{}, error) {
+	exists, err := kvs.developerExists(developerId)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrDeveloperNotFound
+	}
+
+	rows, err := kvs.db.Query(
+		fmt.Sprintf(latestVersionsQuery, "kv.self_model_id = ? AND kv.type = ?"),
+		time.Now().UnixMilli(), developerId, typeName(objType),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list values: %w", err)
+	}
+	return decodeRows(rows)
+}
+
+// ListAllByType lists the latest version of all objects of a given type across all developers.
+func (kvs *SQLiteKeyValueStore) ListAllByType(objType reflect.Type) ([]interface{}, error) {
+	rows, err := kvs.db.Query(fmt.Sprintf(latestVersionsQuery, "kv.type = ?"), time.Now().UnixMilli(), typeName(objType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list values: %w", err)
+	}
+	return decodeRows(rows)
+}
+
+// Delete removes every version stored under the given developer and key.
+func (kvs *SQLiteKeyValueStore) Delete(developerId string, key string) error {
+	res, err := kvs.db.Exec(`DELETE FROM kv_store WHERE self_model_id = ? AND key = ?`, developerId, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete value: %w", err)
+	}
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to count deleted values: %w", err)
+	}
+	if removed == 0 {
+		return kvs.notFoundError(developerId)
+	}
+	return nil
+}
+
+// ClearStore removes all data from the KeyValueStore
+func (kvs *SQLiteKeyValueStore) ClearStore() {
+	if _, err := kvs.db.Exec(`DELETE FROM kv_store`); err != nil {
+		log.Printf("Failed to clear sqlite store: %v", err)
+	}
+}
+
+// SweepExpired removes every key whose latest version has expired and returns how many stored versions were removed.
+func (kvs *SQLiteKeyValueStore) SweepExpired() (int, error) {
+	res, err := kvs.db.Exec(sweepExpiredQuery, time.Now().UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("failed to sweep expired values: %w", err)
+	}
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count swept values: %w", err)
+	}
+	return int(removed), nil
+}
+
+func (kvs *SQLiteKeyValueStore) developerExists(developerId string) (bool, error) {
+	var one int
+	err := kvs.db.QueryRow(`SELECT 1 FROM kv_store WHERE self_model_id = ? LIMIT 1`, developerId).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up developer: %w", err)
+	}
+	return true, nil
+}
+
+// notFoundError mirrors the JSON store, which distinguishes a missing developer from a missing key.
+func (kvs *SQLiteKeyValueStore) notFoundError(developerId string) error {
+	exists, err := kvs.developerExists(developerId)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrDeveloperNotFound
+	}
+	return ErrKeyNotFound
+}
+
+// addColumnIfMissing adds a column to databases created before the column was part of the schema.
+func addColumnIfMissing(sqlDB *sql.DB, table, column, columnType string) error {
+	rows, err := sqlDB.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return fmt.Errorf("failed to read %s schema: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, ctype      string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("failed to read %s schema: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read %s schema: %w", table, err)
+	}
+	rows.Close()
+
+	if _, err := sqlDB.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, columnType)); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// decodeStoredValue unmarshals jsonData into a new instance of the named type.
+func decodeStoredValue(typeStr, jsonData string) (interface{}, error) {
+	t, err := getTypeFromName(typeStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get type from name: %w", err)
+	}
+	v := reflect.New(t).Interface()
+	if err := json.Unmarshal([]byte(jsonData), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func decodeRows(rows *sql.Rows) ([]interface{}, error) {
+	defer rows.Close()
+
+	var result []interface{}
+	for rows.Next() {
+		var typeStr, jsonData string
+		if err := rows.Scan(&typeStr, &jsonData); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		v, err := decodeStoredValue(typeStr, jsonData)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,200 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// KeyValueStore is the versioned storage abstraction used by the services.
+// Values are scoped by an owner ID (a developer, self model or philosophy ID)
+// and a key, and every write carries an explicit version number.
+type KeyValueStore interface {
+	// Store serializes value as JSON and stores it under the given version.
+	// The version must be greater than the latest stored version, otherwise
+	// the write is rejected with ErrVersionConflict.
+	Store(developerId string, key string, value interface{}, version int, opts ...StoreOption) error
+	// ForceStore stores value without the version check, replacing any value
+	// stored under the same version and dropping later ones, so the forced
+	// value becomes the latest.
+	ForceStore(developerId string, key string, value interface{}, version int, opts ...StoreOption) error
+	// Retrieve returns the latest version stored under the given key. Like the other reads,
+	// it decodes a fresh value on every call, so mutating the result never changes the
+	// stored data; only Store and ForceStore do.
+	Retrieve(developerId string, key string) (interface{}, error)
+	// RetrieveAllVersions returns every stored version in ascending order.
+	RetrieveAllVersions(developerId string, key string) ([]interface{}, error)
+	// ListByType returns the latest version of every value of objType owned by developerId.
+	ListByType(developerId string, objType reflect.Type) ([]interface{}, error)
+	// ListAllByType returns the latest version of every value of objType across all owners.
+	ListAllByType(objType reflect.Type) ([]interface{}, error)
+	// Delete removes every version stored under the given key.
+	Delete(developerId string, key string) error
+	// ClearStore removes all data from the store.
+	ClearStore()
+	// SweepExpired removes every key whose latest version has expired and
+	// returns the number of stored versions removed.
+	SweepExpired() (int, error)
+}
+
+// ListOfType returns the latest version of every value of type T owned by developerId, as
+// ListByType does, with each value already asserted to T.
+func ListOfType[T any](store KeyValueStore, developerId string) ([]T, error) {
+	values, err := store.ListByType(developerId, reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return valuesOfType[T](values)
+}
+
+// ListAllOfType returns the latest version of every value of type T across all owners, as
+// ListAllByType does, with each value already asserted to T.
+func ListAllOfType[T any](store KeyValueStore) ([]T, error) {
+	values, err := store.ListAllByType(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return valuesOfType[T](values)
+}
+
+// valuesOfType asserts every listed value to T. Listed values are decoded as pointers, but values
+// of T itself are accepted too.
+func valuesOfType[T any](values []interface{}) ([]T, error) {
+	typed := make([]T, 0, len(values))
+	for _, value := range values {
+		switch v := value.(type) {
+		case *T:
+			typed = append(typed, *v)
+		case T:
+			typed = append(typed, v)
+		default:
+			return nil, fmt.Errorf("listed value has type %T, expected %s", value, typeName(reflect.TypeOf((*T)(nil)).Elem()))
+		}
+	}
+	return typed, nil
+}
+
+// StoreOption configures a single Store or ForceStore call.
+type StoreOption func(*storeOptions)
+
+type storeOptions struct {
+	expiresAt time.Time
+	dropOlder bool
+}
+
+// WithExpiry makes the stored value expire at the given time. Once the latest
+// version of a key has expired the key is treated as not found.
+func WithExpiry(expiresAt time.Time) StoreOption {
+	return func(o *storeOptions) {
+		o.expiresAt = expiresAt
+	}
+}
+
+// DropOlderVersions removes the versions stored before the written one once the write succeeds.
+// It suits values, such as logs, that are read and rewritten whole under a new version, where only
+// the latest version is ever needed.
+func DropOlderVersions() StoreOption {
+	return func(o *storeOptions) {
+		o.dropOlder = true
+	}
+}
+
+// WithTTL makes the stored value expire after the given duration.
+func WithTTL(ttl time.Duration) StoreOption {
+	return WithExpiry(time.Now().Add(ttl))
+}
+
+func applyStoreOptions(opts []StoreOption) storeOptions {
+	var o storeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// expiresAtMillisUTC returns the expiry in milliseconds, or 0 if the value never expires.
+func (o storeOptions) expiresAtMillisUTC() int64 {
+	if o.expiresAt.IsZero() {
+		return 0
+	}
+	return o.expiresAt.UnixMilli()
+}
+
+// Backend identifies a KeyValueStore implementation.
+type Backend string
+
+const (
+	BackendJSON   Backend = "json"
+	BackendSQLite Backend = "sqlite"
+)
+
+// ParseBackend converts a backend name (as found in configuration) to a Backend.
+// An empty name selects the JSON backend.
+func ParseBackend(name string) (Backend, error) {
+	switch Backend(strings.ToLower(strings.TrimSpace(name))) {
+	case "", BackendJSON:
+		return BackendJSON, nil
+	case BackendSQLite:
+		return BackendSQLite, nil
+	default:
+		return "", fmt.Errorf("unknown key value store backend: %s", name)
+	}
+}
+
+// OpenKeyValueStore creates a KeyValueStore for the given backend.
+// An empty path keeps the data in memory only.
+func OpenKeyValueStore(backend Backend, path string) (KeyValueStore, error) {
+	switch backend {
+	case BackendJSON:
+		kvs, err := NewKeyValueStore(path)
+		if err != nil {
+			return nil, err
+		}
+		return kvs, nil
+	case BackendSQLite:
+		kvs, err := NewSQLiteKeyValueStore(path)
+		if err != nil {
+			return nil, err
+		}
+		return kvs, nil
+	default:
+		return nil, fmt.Errorf("unknown key value store backend: %s", backend)
+	}
+}
+
+// typeName returns the fully qualified name used to persist a value's type.
+// reflect.Type.String() is not unique here since both pb/models and svc/models
+// declare types such as models.Belief.
+func typeName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		return "*" + typeName(t.Elem())
+	}
+	if t.PkgPath() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// marshalStructValue validates that value is a struct whose fields all carry json tags and marshals it.
+func marshalStructValue(value interface{}) (string, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Struct {
+		return "", fmt.Errorf("value must be a struct")
+	}
+
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := t.Field(i)
+		if _, ok := field.Tag.Lookup("json"); !ok {
+			return "", fmt.Errorf("field %s does not have a json tag", field.Name)
+		}
+	}
+
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
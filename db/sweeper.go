@@ -0,0 +1,53 @@
+package db
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// ExpirySweeper periodically removes expired entries from a KeyValueStore.
+type ExpirySweeper struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// StartExpirySweeper starts a goroutine that calls SweepExpired on the store every interval.
+// The returned sweeper must be stopped to release the goroutine.
+func StartExpirySweeper(store KeyValueStore, interval time.Duration) *ExpirySweeper {
+	s := &ExpirySweeper{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				removed, err := store.SweepExpired()
+				if err != nil {
+					log.Printf("Failed to sweep expired entries: %v", err)
+				} else if removed > 0 {
+					log.Printf("Swept %d expired entries", removed)
+				}
+			}
+		}
+	}()
+
+	return s
+}
+
+// Stop stops the sweeper and waits for its goroutine to exit. It is safe to call more than once.
+func (s *ExpirySweeper) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"epistemic-me-core/pb/models"
+	svcmodels "epistemic-me-core/svc/models"
 )
 
 // TestStruct is used for testing purposes
@@ -72,6 +73,29 @@ func init() {
 	RegisterType(models.SelfModel{})
 	RegisterType(models.User{})
 	RegisterType(models.Philosophy{})
+	// Register the service models, which are what the services actually store.
+	// These are registered after the pb models so the ambiguous short names
+	// (e.g. "models.Belief") written by older JSON files resolve to them.
+	RegisterType(svcmodels.Developer{})
+	RegisterType(svcmodels.Belief{})
+	RegisterType(svcmodels.BeliefHistory{})
+	RegisterType(svcmodels.BeliefEvidenceLog{})
+	RegisterType(svcmodels.BeliefAnalysisRecord{})
+	RegisterType(svcmodels.BeliefSystem{})
+	RegisterType(svcmodels.BeliefSystemSnapshot{})
+	RegisterType(svcmodels.Dialectic{})
+	RegisterType(svcmodels.DialecticBaseline{})
+	RegisterType(svcmodels.DialecticSummary{})
+	RegisterType(svcmodels.EmailClaim{})
+	RegisterType(svcmodels.IdempotencyRecord{})
+	RegisterType(svcmodels.Resource{})
+	RegisterType(svcmodels.SelfModel{})
+	RegisterType(svcmodels.SelfModelOwner{})
+	RegisterType(svcmodels.User{})
+	RegisterType(svcmodels.Webhook{})
+	RegisterType(svcmodels.RevokedAPIKey{})
+	RegisterType(svcmodels.IssuedAPIKey{})
+	RegisterType(svcmodels.Philosophy{})
 	// Register TestStruct
 	RegisterType(TestStruct{})
 }
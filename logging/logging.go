@@ -0,0 +1,139 @@
+// Package logging writes leveled log lines tagged with the request they belong to, so a single
+// RPC can be followed through the service layers.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// ParseLevel parses a level name such as "debug" or "WARN".
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", name)
+}
+
+var currentLevel atomic.Int32
+
+func init() {
+	currentLevel.Store(int32(LevelInfo))
+}
+
+// SetLevel drops every line below level.
+func SetLevel(level Level) {
+	currentLevel.Store(int32(level))
+}
+
+// Enabled reports whether lines at level are written.
+func Enabled(level Level) bool {
+	return int32(level) >= currentLevel.Load()
+}
+
+type fieldsKey struct{}
+
+// requestFields identify the request a log line belongs to.
+type requestFields struct {
+	requestID   string
+	method      string
+	selfModelID string
+}
+
+// NewContext tags ctx with a request's correlation ID and RPC method.
+func NewContext(ctx context.Context, requestID, method string) context.Context {
+	fields := fieldsFrom(ctx)
+	fields.requestID = requestID
+	fields.method = method
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// WithSelfModelID tags ctx with the self model the request operates on.
+func WithSelfModelID(ctx context.Context, selfModelID string) context.Context {
+	fields := fieldsFrom(ctx)
+	fields.selfModelID = selfModelID
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// RequestID returns the correlation ID ctx was tagged with, if any.
+func RequestID(ctx context.Context) string {
+	return fieldsFrom(ctx).requestID
+}
+
+func fieldsFrom(ctx context.Context) requestFields {
+	if ctx == nil {
+		return requestFields{}
+	}
+	fields, _ := ctx.Value(fieldsKey{}).(requestFields)
+	return fields
+}
+
+// Logf writes a line at level, prefixed with the request fields carried by ctx.
+func Logf(ctx context.Context, level Level, format string, v ...interface{}) {
+	if !Enabled(level) {
+		return
+	}
+
+	var line strings.Builder
+	fmt.Fprintf(&line, "level=%s", level)
+	fields := fieldsFrom(ctx)
+	if fields.requestID != "" {
+		fmt.Fprintf(&line, " request_id=%s", fields.requestID)
+	}
+	if fields.method != "" {
+		fmt.Fprintf(&line, " method=%s", fields.method)
+	}
+	if fields.selfModelID != "" {
+		fmt.Fprintf(&line, " self_model_id=%s", fields.selfModelID)
+	}
+	fmt.Fprintf(&line, " msg=%q", fmt.Sprintf(format, v...))
+	log.Print(line.String())
+}
+
+func Debugf(ctx context.Context, format string, v ...interface{}) {
+	Logf(ctx, LevelDebug, format, v...)
+}
+
+func Infof(ctx context.Context, format string, v ...interface{}) {
+	Logf(ctx, LevelInfo, format, v...)
+}
+
+func Warnf(ctx context.Context, format string, v ...interface{}) {
+	Logf(ctx, LevelWarn, format, v...)
+}
+
+func Errorf(ctx context.Context, format string, v ...interface{}) {
+	Logf(ctx, LevelError, format, v...)
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	flags := log.Flags()
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+		SetLevel(LevelInfo)
+	})
+	return &buf
+}
+
+func TestLogfIncludesRequestFields(t *testing.T) {
+	buf := captureLog(t)
+
+	ctx := NewContext(context.Background(), "req-1", "/epistemic_me.EpistemicMeService/UpdateDialectic")
+	ctx = WithSelfModelID(ctx, "self-model-1")
+	Infof(ctx, "stored dialectic %s", "di_1")
+
+	want := `level=info request_id=req-1 method=/epistemic_me.EpistemicMeService/UpdateDialectic self_model_id=self-model-1 msg="stored dialectic di_1"`
+	assert.Equal(t, want, strings.TrimSpace(buf.String()))
+	assert.Equal(t, "req-1", RequestID(ctx))
+}
+
+func TestSetLevelFiltersLines(t *testing.T) {
+	buf := captureLog(t)
+
+	level, err := ParseLevel("WARN")
+	require.NoError(t, err)
+	SetLevel(level)
+	Infof(context.Background(), "dropped")
+	Errorf(context.Background(), "kept")
+
+	assert.Equal(t, `level=error msg="kept"`, strings.TrimSpace(buf.String()))
+
+	_, err = ParseLevel("verbose")
+	assert.Error(t, err)
+}
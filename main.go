@@ -4,6 +4,8 @@ import (
 	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	db "epistemic-me-core/db"
 	"epistemic-me-core/server"
@@ -15,18 +17,43 @@ func main() {
 		log.Fatalf("OPENAI_API_KEY environment variable not set")
 	}
 
-	kvStore, err := db.NewKeyValueStore("./epistemic_me.json")
+	// KV_STORE_BACKEND selects the storage backend ("json" or "sqlite"),
+	// KV_STORE_PATH optionally overrides the file it persists to.
+	backend, err := db.ParseBackend(os.Getenv("KV_STORE_BACKEND"))
+	if err != nil {
+		log.Fatalf("Invalid KV_STORE_BACKEND: %v", err)
+	}
+	kvStorePath := os.Getenv("KV_STORE_PATH")
+	if kvStorePath == "" {
+		kvStorePath = "./epistemic_me.json"
+		if backend == db.BackendSQLite {
+			kvStorePath = "./epistemic_me.db"
+		}
+	}
+
+	kvStore, err := db.OpenKeyValueStore(backend, kvStorePath)
 	if err != nil {
 		log.Printf("Warning: Failed to create KeyValueStore: %v", err)
 		log.Println("Continuing with in-memory storage. Data will not be persisted.")
-		kvStore, err = db.NewKeyValueStore("")
+		kvStore, err = db.OpenKeyValueStore(backend, "")
 		if err != nil {
 			log.Fatalf("Failed to create in-memory KeyValueStore: %v", err)
 		}
 	}
-	log.Println("Successfully created KeyValueStore")
+	log.Printf("Successfully created %s KeyValueStore", backend)
+
+	srv, wg, _ := server.RunServerWithConfig(kvStore, server.ConfigFromEnv())
+
+	// Drain in-flight requests on SIGINT or SIGTERM, e.g. during a deploy
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %v, shutting down", sig)
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+	}()
 
-	srv, wg, _ := server.RunServer(kvStore, "8080") // Pass kvStore as a pointer
 	wg.Wait()
-	_ = srv.Shutdown(context.Background())
 }
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"connectrpc.com/connect"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/logging"
+	"epistemic-me-core/svc"
+)
+
+// aiKeyInterceptor binds requests from developers who stored their own OpenAI key to a helper
+// using that key, taken from helpers. Other requests keep using the server's key. A stored key that
+// cannot be decrypted fails the request rather than billing it to the server's key.
+type aiKeyInterceptor struct {
+	developerSvc *svc.DeveloperService
+	helpers      *ai.HelperPool
+}
+
+func newAIKeyInterceptor(developerSvc *svc.DeveloperService, helpers *ai.HelperPool) *aiKeyInterceptor {
+	return &aiKeyInterceptor{developerSvc: developerSvc, helpers: helpers}
+}
+
+func (i *aiKeyInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, err := i.bind(ctx, req.Header())
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (i *aiKeyInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *aiKeyInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := i.bind(ctx, conn.RequestHeader())
+		if err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+// bind returns ctx with the helper of the OpenAI key stored by the developer owning the request's
+// API key, if they stored one.
+func (i *aiKeyInterceptor) bind(ctx context.Context, header http.Header) (context.Context, error) {
+	apiKey := headerAPIKey(ctx, header)
+	if apiKey == "" {
+		return ctx, nil
+	}
+	openAIKey, err := i.developerSvc.OpenAIKeyForAPIKey(apiKey)
+	if err != nil {
+		logging.Errorf(ctx, "failed to read the developer's OpenAI key: %v", err)
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	if openAIKey != "" {
+		ctx = ai.ContextWithHelper(ctx, i.helpers.Get(openAIKey))
+	}
+	return ctx, nil
+}
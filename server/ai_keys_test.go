@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ai "epistemic-me-core/ai"
+	db "epistemic-me-core/db"
+	pb "epistemic-me-core/pb"
+	pbmodels "epistemic-me-core/pb/models"
+	"epistemic-me-core/pb/pbconnect"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+)
+
+// extractedBelief is the belief extracted from every document by the fake AI.
+const extractedBelief = `{"beliefs": [{"content": "I believe sleep keeps me sharp", "type": "causal"}]}`
+
+func TestAIKeyInterceptorUsesDeveloperOpenAIKey(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	developerSvc := svc.NewDeveloperService(kv, nil)
+	require.NoError(t, developerSvc.SetOpenAIKeySecret("test-secret"))
+
+	created, err := developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Dev", Email: "dev@example.com"})
+	require.NoError(t, err)
+	stored, err := developerSvc.SetOpenAIKey(&models.SetOpenAIKeyInput{DeveloperID: created.Developer.ID, OpenAIAPIKey: "sk-developer"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, stored.Developer.EncryptedOpenAIKey)
+	assert.NotContains(t, stored.Developer.EncryptedOpenAIKey, "sk-developer", "the key is stored encrypted")
+
+	clients := make(map[string]*fakeai.Client)
+	helpers := ai.NewHelperPool(ai.DefaultHelperPoolSize, func(apiKey string) *ai.AIHelper {
+		clients[apiKey] = fakeai.New("ok")
+		return ai.NewAIHelperWithClient(clients[apiKey])
+	})
+	serverClient := fakeai.New("ok")
+	serverHelper := ai.NewAIHelperWithClient(serverClient)
+
+	next := func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		_, err := serverHelper.WithContext(ctx).CompletePrompt("hello")
+		return connect.NewResponse(&pingMessage{}), err
+	}
+	call := func(apiKey string) {
+		req := connect.NewRequest(&pingMessage{})
+		req.Header().Set("x-api-key", apiKey)
+		_, err := newAIKeyInterceptor(developerSvc, helpers).WrapUnary(next)(context.Background(), req)
+		require.NoError(t, err)
+	}
+
+	call(created.Developer.APIKeys[0])
+	call(created.Developer.APIKeys[0])
+	require.Contains(t, clients, "sk-developer")
+	assert.Len(t, clients, 1, "helpers are cached per key")
+	assert.Equal(t, 2, clients["sk-developer"].Calls())
+	assert.Equal(t, 0, serverClient.Calls())
+
+	call("5d6f8e0a-0000-4000-8000-000000000000")
+	assert.Equal(t, 1, serverClient.Calls(), "requests without an override use the server's key")
+
+	_, err = developerSvc.SetOpenAIKey(&models.SetOpenAIKeyInput{DeveloperID: created.Developer.ID})
+	require.NoError(t, err)
+	call(created.Developer.APIKeys[0])
+	assert.Equal(t, 2, serverClient.Calls(), "a removed override falls back to the server's key")
+}
+
+func TestAIKeyInterceptorFailsOnUndecryptableKey(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	developerSvc := svc.NewDeveloperService(kv, nil)
+	require.NoError(t, developerSvc.SetOpenAIKeySecret("test-secret"))
+	created, err := developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Dev", Email: "dev@example.com"})
+	require.NoError(t, err)
+	_, err = developerSvc.SetOpenAIKey(&models.SetOpenAIKeyInput{DeveloperID: created.Developer.ID, OpenAIAPIKey: "sk-developer"})
+	require.NoError(t, err)
+
+	// A rotated secret can no longer open the stored key
+	rotated := svc.NewDeveloperService(kv, nil)
+	require.NoError(t, rotated.SetOpenAIKeySecret("another-secret"))
+	helpers := ai.NewHelperPool(ai.DefaultHelperPoolSize, func(apiKey string) *ai.AIHelper {
+		return ai.NewAIHelperWithClient(fakeai.New("ok"))
+	})
+
+	called := false
+	next := func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		called = true
+		return connect.NewResponse(&pingMessage{}), nil
+	}
+	req := connect.NewRequest(&pingMessage{})
+	req.Header().Set("x-api-key", created.Developer.APIKeys[0])
+	_, err = newAIKeyInterceptor(rotated, helpers).WrapUnary(next)(context.Background(), req)
+	assert.Equal(t, connect.CodeInternal, connect.CodeOf(err))
+	assert.False(t, called, "the request is not served with the server's key")
+}
+
+func TestGenerateAnswerUsesDeveloperOpenAIKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	serverClient := fakeai.New("ok")
+	opts := DefaultServerOptions()
+	opts.AIHelper = ai.NewAIHelperWithClient(serverClient)
+	opts.OpenAIKeyEncryptionSecret = "test-secret"
+	s, err := NewServerWithOptions(opts)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	developerClient := fakeai.New("ok")
+	s.aiHelpers = ai.NewHelperPool(ai.DefaultHelperPoolSize, func(apiKey string) *ai.AIHelper {
+		return ai.NewAIHelperWithClient(developerClient)
+	})
+	apiKey := issueAPIKey(t, s)
+	developer, err := s.developerSvc.GetDeveloperByAPIKey(apiKey)
+	require.NoError(t, err)
+	_, err = s.developerSvc.SetOpenAIKey(&models.SetOpenAIKeyInput{DeveloperID: developer.ID, OpenAIAPIKey: "sk-developer"})
+	require.NoError(t, err)
+	_, err = s.selfModelSvc.CreateSelfModel(context.Background(), &models.CreateSelfModelInput{ID: "answer-model-1"})
+	require.NoError(t, err)
+
+	serverCalls := serverClient.Calls()
+	next := func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return s.GenerateAnswer(ctx, req.(*connect.Request[pb.GenerateAnswerRequest]))
+	}
+	req := connect.NewRequest(&pb.GenerateAnswerRequest{SelfModelId: "answer-model-1", Question: "How do you sleep?"})
+	req.Header().Set("x-api-key", apiKey)
+	resp, err := newAIKeyInterceptor(s.developerSvc, s.aiHelpers).WrapUnary(next)(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.(*connect.Response[pb.GenerateAnswerResponse]).Msg.Answer)
+
+	assert.Equal(t, 1, developerClient.Calls())
+	assert.Equal(t, serverCalls, serverClient.Calls())
+}
+
+func TestPerspectivesUseDeveloperOpenAIKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	serverClient := fakeai.New("ok")
+	opts := DefaultServerOptions()
+	opts.AIHelper = ai.NewAIHelperWithClient(serverClient)
+	opts.OpenAIKeyEncryptionSecret = "test-secret"
+	s, err := NewServerWithOptions(opts)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	developerClient := fakeai.New("ok")
+	s.aiHelpers = ai.NewHelperPool(ai.DefaultHelperPoolSize, func(apiKey string) *ai.AIHelper {
+		return ai.NewAIHelperWithClient(developerClient)
+	})
+	apiKey := issueAPIKey(t, s)
+	developer, err := s.developerSvc.GetDeveloperByAPIKey(apiKey)
+	require.NoError(t, err)
+	_, err = s.developerSvc.SetOpenAIKey(&models.SetOpenAIKeyInput{DeveloperID: developer.ID, OpenAIAPIKey: "sk-developer"})
+	require.NoError(t, err)
+
+	serverCalls := serverClient.Calls()
+	next := func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return s.GetPerspectives(ctx, req.(*connect.Request[pb.GetPerspectivesRequest]))
+	}
+	req := connect.NewRequest(&pb.GetPerspectivesRequest{
+		SelfModelIds: []string{"perspective-model-1"},
+		Question:     "How do you sleep?",
+		Answer:       "Eight hours",
+	})
+	req.Header().Set("x-api-key", apiKey)
+	resp, err := newAIKeyInterceptor(s.developerSvc, s.aiHelpers).WrapUnary(next)(context.Background(), req)
+	require.NoError(t, err)
+	perspectives := resp.(*connect.Response[pb.GetPerspectivesResponse]).Msg
+	assert.Empty(t, perspectives.Errors)
+	assert.Len(t, perspectives.Perspectives, 1)
+
+	assert.Equal(t, 2, developerClient.Calls(), "both perspective calls use the developer's key")
+	assert.Equal(t, serverCalls, serverClient.Calls())
+}
+
+func TestBootstrapStreamUsesDeveloperOpenAIKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	serverClient := fakeai.New(extractedBelief)
+	opts := DefaultServerOptions()
+	opts.AIHelper = ai.NewAIHelperWithClient(serverClient)
+	opts.OpenAIKeyEncryptionSecret = "test-secret"
+	s, err := NewServerWithOptions(opts)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	developerClient := fakeai.New(extractedBelief)
+	s.aiHelpers = ai.NewHelperPool(ai.DefaultHelperPoolSize, func(apiKey string) *ai.AIHelper {
+		return ai.NewAIHelperWithClient(developerClient)
+	})
+	apiKey := issueAPIKey(t, s)
+	developer, err := s.developerSvc.GetDeveloperByAPIKey(apiKey)
+	require.NoError(t, err)
+	_, err = s.developerSvc.SetOpenAIKey(&models.SetOpenAIKeyInput{DeveloperID: developer.ID, OpenAIAPIKey: "sk-developer"})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	path, handler := pbconnect.NewEpistemicMeServiceHandler(s, connect.WithInterceptors(
+		newRequestLoggingInterceptor(),
+		newAIKeyInterceptor(s.developerSvc, s.aiHelpers),
+	))
+	mux.Handle(path, handler)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	client := pbconnect.NewEpistemicMeServiceClient(http.DefaultClient, ts.URL)
+	req := connect.NewRequest(&pb.BootstrapSelfModelRequest{
+		SelfModelId: "bootstrap-model-1",
+		Documents: []*pbmodels.Resource{
+			{Type: pbmodels.ResourceType_CHAT_LOG, SourceId: "sleep-journal", Content: "Sleep keeps me sharp."},
+			{Type: pbmodels.ResourceType_SURVEY_RESPONSE, SourceId: "diet-survey", Content: "Vegetables give me energy."},
+		},
+	})
+	req.Header().Set("x-api-key", apiKey)
+	stream, err := client.BootstrapSelfModel(context.Background(), req)
+	require.NoError(t, err)
+	var summary *pb.BootstrapSummary
+	for stream.Receive() {
+		if msg := stream.Msg().GetSummary(); msg != nil {
+			summary = msg
+		}
+	}
+	require.NoError(t, stream.Err())
+	require.NotNil(t, summary)
+	assert.NotEmpty(t, stream.ResponseHeader().Get(requestIDHeader), "streams are tagged with a correlation ID")
+
+	assert.Equal(t, 2, developerClient.Calls(), "every document is extracted with the developer's key")
+	assert.Zero(t, serverClient.Calls())
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	ai "epistemic-me-core/ai"
+	pb "epistemic-me-core/pb"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+)
+
+// issueAPIKey creates a developer on s and returns their API key.
+func issueAPIKey(t *testing.T, s *Server) string {
+	created, err := s.developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Dev"})
+	require.NoError(t, err)
+	return created.Developer.APIKeys[0]
+}
+
+func TestValidateAPIKeyAcceptsOnlyActiveKeys(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	opts := DefaultServerOptions()
+	opts.AIHelper = ai.NewAIHelperWithClient(fakeai.New("ok"))
+	s, err := NewServerWithOptions(opts)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	validate := func(ctx context.Context, apiKey string) connect.Code {
+		req := connect.NewRequest(&pingMessage{})
+		if apiKey != "" {
+			req.Header().Set("x-api-key", apiKey)
+		}
+		if _, err := validateAPIKey(ctx, s.developerSvc, req); err != nil {
+			return connect.CodeOf(err)
+		}
+		return 0
+	}
+
+	apiKey := issueAPIKey(t, s)
+	assert.Zero(t, validate(context.Background(), apiKey))
+	assert.Equal(t, connect.CodeUnauthenticated, validate(context.Background(), ""))
+	assert.Equal(t, connect.CodeUnauthenticated, validate(context.Background(), "5d6f8e0a-0000-4000-8000-000000000000"),
+		"well-formed keys the server never issued are rejected")
+
+	// Keys sent as metadata are checked like those sent as headers
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "5d6f8e0a-0000-4000-8000-000000000000"))
+	assert.Equal(t, connect.CodeUnauthenticated, validate(ctx, apiKey))
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", apiKey))
+	assert.Zero(t, validate(ctx, ""))
+
+	developer, err := s.developerSvc.GetDeveloperByAPIKey(apiKey)
+	require.NoError(t, err)
+	_, err = s.developerSvc.RevokeAPIKey(&models.RevokeAPIKeyInput{DeveloperID: developer.ID, APIKey: apiKey})
+	require.NoError(t, err)
+	assert.Equal(t, connect.CodeUnauthenticated, validate(context.Background(), apiKey))
+}
+
+func TestGetDeveloperIsLimitedToItsOwnerAndMasksKeys(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	opts := DefaultServerOptions()
+	opts.AIHelper = ai.NewAIHelperWithClient(fakeai.New("ok"))
+	s, err := NewServerWithOptions(opts)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	apiKey := issueAPIKey(t, s)
+	developer, err := s.developerSvc.GetDeveloperByAPIKey(apiKey)
+	require.NoError(t, err)
+	otherKey := issueAPIKey(t, s)
+
+	getDeveloper := func(apiKey string) (*pb.GetDeveloperResponse, error) {
+		req := connect.NewRequest(&pb.GetDeveloperRequest{Id: developer.ID})
+		req.Header().Set("x-api-key", apiKey)
+		resp, err := s.GetDeveloper(context.Background(), req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	_, err = getDeveloper(otherKey)
+	assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err), "another developer's key cannot read the developer")
+
+	resp, err := getDeveloper(apiKey)
+	require.NoError(t, err)
+	assert.Equal(t, developer.ID, resp.Developer.Id)
+	require.Len(t, resp.Developer.ApiKeys, 1)
+	assert.NotEqual(t, apiKey, resp.Developer.ApiKeys[0], "the key itself is not sent back")
+	assert.Equal(t, models.MaskAPIKey(apiKey), resp.Developer.ApiKeys[0])
+}
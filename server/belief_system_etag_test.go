@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ai "epistemic-me-core/ai"
+	pb "epistemic-me-core/pb"
+	pbmodels "epistemic-me-core/pb/models"
+	"epistemic-me-core/tests/fakeai"
+)
+
+func TestGetBeliefSystemNotModified(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	opts := DefaultServerOptions()
+	opts.AIHelper = ai.NewAIHelperWithClient(fakeai.New("ok"))
+	s, err := NewServerWithOptions(opts)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	apiKey := issueAPIKey(t, s)
+	getBeliefSystem := func(ifChangedSince string) *pb.GetBeliefSystemResponse {
+		req := connect.NewRequest(&pb.GetBeliefSystemRequest{SelfModelId: "self-model-1", IfChangedSince: ifChangedSince})
+		req.Header().Set("x-api-key", apiKey)
+		resp, err := s.GetBeliefSystem(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, resp.Msg.Etag, resp.Header().Get("ETag"))
+		return resp.Msg
+	}
+	createBelief := func(content string) {
+		req := connect.NewRequest(&pb.CreateBeliefRequest{
+			SelfModelId:   "self-model-1",
+			BeliefContent: content,
+			BeliefType:    pbmodels.BeliefType_STATEMENT,
+		})
+		req.Header().Set("x-api-key", apiKey)
+		_, err := s.CreateBelief(context.Background(), req)
+		require.NoError(t, err)
+	}
+
+	createBelief("I believe sleep matters")
+	first := getBeliefSystem("")
+	require.NotEmpty(t, first.Etag)
+	require.NotNil(t, first.BeliefSystem)
+	assert.False(t, first.NotModified)
+
+	// An unchanged belief system is not sent again for its own token
+	unchanged := getBeliefSystem(first.Etag)
+	assert.True(t, unchanged.NotModified)
+	assert.Nil(t, unchanged.BeliefSystem)
+	assert.Equal(t, first.Etag, unchanged.Etag)
+
+	createBelief("I believe rest is important")
+	changed := getBeliefSystem(first.Etag)
+	assert.False(t, changed.NotModified)
+	require.NotNil(t, changed.BeliefSystem)
+	assert.NotEqual(t, first.Etag, changed.Etag)
+	assert.Len(t, changed.BeliefSystem.Beliefs, 2)
+
+	// The token does not depend on the order the beliefs happen to be listed in
+	createBelief("I believe naps help in the afternoon")
+	createBelief("I believe screens keep me awake")
+	current := getBeliefSystem("")
+	for i := 0; i < 10; i++ {
+		polled := getBeliefSystem(current.Etag)
+		assert.True(t, polled.NotModified, "poll %d", i)
+		assert.Equal(t, current.Etag, polled.Etag)
+	}
+
+	// The token is checked before the belief system is conceptualized or measured, and may also be
+	// sent as an If-None-Match header
+	req := connect.NewRequest(&pb.GetBeliefSystemRequest{SelfModelId: "self-model-1", Conceptualize: true, IncludeMetrics: true})
+	req.Header().Set("x-api-key", apiKey)
+	req.Header().Set("If-None-Match", `"`+current.Etag+`"`)
+	resp, err := s.GetBeliefSystem(context.Background(), req)
+	require.NoError(t, err)
+	assert.True(t, resp.Msg.NotModified)
+	assert.Nil(t, resp.Msg.BeliefSystem)
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/cors"
+)
+
+// Config holds the listen port, CORS policy, shutdown behaviour and rate limits the server runs with.
+type Config struct {
+	// Port to listen on. Empty picks a free port, which the tests rely on.
+	Port           string
+	AllowedOrigins []string
+	AllowedHeaders []string
+	// ShutdownGracePeriod is how long a shutdown waits for in-flight requests before cancelling them.
+	ShutdownGracePeriod time.Duration
+	// RateLimitRequestsPerSecond and RateLimitBurst size the token bucket of each API key unless its
+	// developer overrides them. A rate of zero disables rate limiting.
+	RateLimitRequestsPerSecond float64
+	RateLimitBurst             int
+	// MetricsEnabled serves Prometheus metrics on /metrics and counts RPCs and store operations.
+	MetricsEnabled bool
+}
+
+// DefaultConfig returns the port and CORS policy used when nothing is configured.
+func DefaultConfig() Config {
+	return Config{
+		Port: "8080",
+		AllowedOrigins: []string{
+			"http://localhost:8081",
+			"http://localhost:3001",
+			"http://localhost:3000",
+			"http://localhost",
+		},
+		AllowedHeaders: []string{
+			"Accept",
+			"Content-Type",
+			"Content-Length",
+			"Accept-Encoding",
+			"X-CSRF-Token",
+			"Authorization",
+			"Connect-Protocol-Version",
+			"Connect-Timeout-Ms",
+			"x-api-key",
+			"Origin",
+			requestIDHeader,
+		},
+		ShutdownGracePeriod:        DefaultShutdownGracePeriod,
+		RateLimitRequestsPerSecond: DefaultRateLimitRequestsPerSecond,
+		RateLimitBurst:             DefaultRateLimitBurst,
+	}
+}
+
+// ConfigFromEnv overrides the defaults with SERVER_PORT, CORS_ALLOWED_ORIGINS,
+// CORS_ALLOWED_HEADERS, SHUTDOWN_GRACE_PERIOD, RATE_LIMIT_RPS, RATE_LIMIT_BURST and
+// METRICS_ENABLED. The CORS lists are comma separated and replace the defaults.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	if port := os.Getenv("SERVER_PORT"); port != "" {
+		cfg.Port = port
+	}
+	if origins := splitList(os.Getenv("CORS_ALLOWED_ORIGINS")); len(origins) > 0 {
+		cfg.AllowedOrigins = origins
+	}
+	if headers := splitList(os.Getenv("CORS_ALLOWED_HEADERS")); len(headers) > 0 {
+		cfg.AllowedHeaders = headers
+	}
+	if value := os.Getenv("SHUTDOWN_GRACE_PERIOD"); value != "" {
+		gracePeriod, err := time.ParseDuration(value)
+		if err == nil && gracePeriod >= 0 {
+			cfg.ShutdownGracePeriod = gracePeriod
+		} else {
+			log.Printf("Invalid SHUTDOWN_GRACE_PERIOD %q, using %v", value, cfg.ShutdownGracePeriod)
+		}
+	}
+	if value := os.Getenv("RATE_LIMIT_RPS"); value != "" {
+		rps, err := strconv.ParseFloat(value, 64)
+		if err == nil && rps >= 0 {
+			cfg.RateLimitRequestsPerSecond = rps
+		} else {
+			log.Printf("Invalid RATE_LIMIT_RPS %q, using %v", value, cfg.RateLimitRequestsPerSecond)
+		}
+	}
+	if value := os.Getenv("RATE_LIMIT_BURST"); value != "" {
+		burst, err := strconv.Atoi(value)
+		if err == nil && burst > 0 {
+			cfg.RateLimitBurst = burst
+		} else {
+			log.Printf("Invalid RATE_LIMIT_BURST %q, using %d", value, cfg.RateLimitBurst)
+		}
+	}
+	if value := os.Getenv("METRICS_ENABLED"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err == nil {
+			cfg.MetricsEnabled = enabled
+		} else {
+			log.Printf("Invalid METRICS_ENABLED %q, leaving metrics disabled", value)
+		}
+	}
+	return cfg
+}
+
+// Validate checks that every allowed origin is an absolute http(s) URL without a path, or "*",
+// and that the shutdown grace period and rate limits are usable.
+func (c Config) Validate() error {
+	if c.ShutdownGracePeriod < 0 {
+		return fmt.Errorf("shutdown grace period must not be negative, got %v", c.ShutdownGracePeriod)
+	}
+	if c.RateLimitRequestsPerSecond < 0 {
+		return fmt.Errorf("rate limit must not be negative, got %v", c.RateLimitRequestsPerSecond)
+	}
+	if c.RateLimitRequestsPerSecond > 0 && c.RateLimitBurst < 1 {
+		return fmt.Errorf("rate limit burst must be at least 1, got %d", c.RateLimitBurst)
+	}
+	if len(c.AllowedOrigins) == 0 {
+		return fmt.Errorf("at least one allowed origin is required")
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return fmt.Errorf("invalid allowed origin %q: %w", origin, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("invalid allowed origin %q: scheme must be http or https", origin)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid allowed origin %q: missing host", origin)
+		}
+		if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("invalid allowed origin %q: must not have a path, query or fragment", origin)
+		}
+	}
+	return nil
+}
+
+// corsHandler wraps next with the CORS policy from cfg.
+func corsHandler(cfg Config, next http.Handler) http.Handler {
+	origins := make([]string, len(cfg.AllowedOrigins))
+	for i, origin := range cfg.AllowedOrigins {
+		// Browsers send origins without a trailing slash
+		origins[i] = strings.TrimSuffix(origin, "/")
+	}
+	return cors.New(cors.Options{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   []string{"Content-Length", "Content-Type", requestIDHeader},
+		AllowCredentials: true,
+		Debug:            false,
+	}).Handler(next)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
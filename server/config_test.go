@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func preflight(handler http.Handler, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, "/epistemic_me.EpistemicMeService/GetSelfModel", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type,x-api-key")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSPreflight(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AllowedOrigins = []string{"https://app.example.com"}
+	handler := corsHandler(cfg, http.NotFoundHandler())
+
+	allowed := preflight(handler, "https://app.example.com")
+	assert.Equal(t, "https://app.example.com", allowed.Header().Get("Access-Control-Allow-Origin"))
+
+	rejected := preflight(handler, "http://localhost:3000")
+	assert.Empty(t, rejected.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	for _, origin := range []string{"localhost:3000", "ftp://example.com", "http://", "https://example.com/app", "://bad"} {
+		cfg := DefaultConfig()
+		cfg.AllowedOrigins = []string{origin}
+		assert.Error(t, cfg.Validate(), origin)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("SERVER_PORT", "9090")
+	t.Setenv("CORS_ALLOWED_ORIGINS", " https://a.example.com, https://b.example.com ")
+	t.Setenv("CORS_ALLOWED_HEADERS", "")
+
+	cfg := ConfigFromEnv()
+	assert.Equal(t, "9090", cfg.Port)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, cfg.AllowedOrigins)
+	assert.Equal(t, DefaultConfig().AllowedHeaders, cfg.AllowedHeaders)
+}
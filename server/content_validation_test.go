@@ -0,0 +1,103 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	db "epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+)
+
+func TestContentValidationIsInvalidArgument(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+	bsvc.SetMaxContentLength(20)
+	dsvc := svc.NewDialecticService(kv, nil, nil, nil)
+	dsvc.SetMaxContentLength(20)
+
+	tests := []struct {
+		name    string
+		call    func(content string) error
+		content string
+		wantErr error
+	}{
+		{
+			name:    "empty belief",
+			call:    createBelief(bsvc),
+			content: "  \n\t ",
+			wantErr: svc.ErrEmptyContent,
+		},
+		{
+			name:    "belief too long",
+			call:    createBelief(bsvc),
+			content: strings.Repeat("a", 21),
+			wantErr: svc.ErrContentTooLong,
+		},
+		{
+			name: "answer too long",
+			call: func(content string) error {
+				_, err := dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+					ID:          "di_unknown",
+					SelfModelID: "self-model-1",
+					Answer:      models.UserAnswer{UserAnswer: content},
+				})
+				return err
+			},
+			content: strings.Repeat("a", 21),
+			wantErr: svc.ErrContentTooLong,
+		},
+		{
+			name: "empty edited answer",
+			call: func(content string) error {
+				_, err := dsvc.EditAnswer(&models.EditAnswerInput{
+					DialecticID:   "di_unknown",
+					SelfModelID:   "self-model-1",
+					InteractionID: "in_unknown",
+					Answer:        content,
+				})
+				return err
+			},
+			content: " ",
+			wantErr: svc.ErrEmptyContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(tt.content)
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, connect.CodeInvalidArgument, storeErrorCode(err))
+		})
+	}
+}
+
+func TestCreateBeliefTrimsContent(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   "self-model-1",
+		BeliefContent: "  I believe sleep matters\n",
+		BeliefType:    models.Statement,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "I believe sleep matters", created.Belief.GetContentAsString())
+}
+
+func createBelief(bsvc *svc.BeliefService) func(content string) error {
+	return func(content string) error {
+		_, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+			SelfModelID:   "self-model-1",
+			BeliefContent: content,
+			BeliefType:    models.Statement,
+		})
+		return err
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	ai "epistemic-me-core/ai"
+	db "epistemic-me-core/db"
+)
+
+const (
+	healthStatusOK          = "ok"
+	healthStatusDegraded    = "degraded"
+	healthStatusUnavailable = "unavailable"
+
+	// aiProbeTTL is how long the result of an AI provider probe is reused, so frequent health
+	// checks do not turn into frequent OpenAI calls.
+	aiProbeTTL = time.Minute
+	// aiProbeTimeout bounds a single AI provider probe.
+	aiProbeTimeout = 5 * time.Second
+)
+
+// healthReport describes whether the server and its dependencies are usable.
+type healthReport struct {
+	Status              string `json:"status"`
+	StoreReachable      bool   `json:"store_reachable"`
+	StoreError          string `json:"store_error,omitempty"`
+	OpenAIKeyConfigured bool   `json:"openai_key_configured"`
+	// AIProvider is "ok", "unchecked" when probing is disabled, or the probe's error
+	AIProvider string `json:"ai_provider"`
+}
+
+// healthChecker reports store reachability and AI configuration. Probing the AI provider is
+// optional and its result is cached for aiProbeTTL.
+type healthChecker struct {
+	kvStore db.KeyValueStore
+	aih     *ai.AIHelper
+	probeAI bool
+
+	mu           sync.Mutex
+	lastProbe    time.Time
+	lastProbeErr error
+}
+
+func newHealthChecker(kvStore db.KeyValueStore, aih *ai.AIHelper, probeAI bool) *healthChecker {
+	return &healthChecker{kvStore: kvStore, aih: aih, probeAI: probeAI}
+}
+
+func (h *healthChecker) check(ctx context.Context) healthReport {
+	report := healthReport{
+		Status:              healthStatusOK,
+		StoreReachable:      true,
+		OpenAIKeyConfigured: os.Getenv("OPENAI_API_KEY") != "",
+		AIProvider:          "unchecked",
+	}
+
+	// A missing key proves the store answered; any other error means it could not be read
+	if _, err := h.kvStore.Retrieve("healthcheck", "healthcheck"); err != nil && !errors.Is(err, db.ErrNotFound) {
+		report.StoreReachable = false
+		report.StoreError = err.Error()
+	}
+
+	if h.probeAI && h.aih != nil && report.OpenAIKeyConfigured {
+		if err := h.probe(ctx); err != nil {
+			report.AIProvider = err.Error()
+		} else {
+			report.AIProvider = "ok"
+		}
+	}
+
+	switch {
+	case !report.StoreReachable:
+		report.Status = healthStatusUnavailable
+	case !report.OpenAIKeyConfigured, report.AIProvider != "ok" && report.AIProvider != "unchecked":
+		report.Status = healthStatusDegraded
+	}
+	return report
+}
+
+// probe pings the AI provider, reusing the last result while it is fresher than aiProbeTTL.
+func (h *healthChecker) probe(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.lastProbe.IsZero() && time.Since(h.lastProbe) < aiProbeTTL {
+		return h.lastProbeErr
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, aiProbeTimeout)
+	defer cancel()
+	h.lastProbeErr = h.aih.Ping(ctx)
+	h.lastProbe = time.Now()
+	return h.lastProbeErr
+}
+
+// ServeHTTP serves the report as JSON for load balancers, answering 503 when the store is unreachable.
+func (h *healthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.check(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status == healthStatusUnavailable {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	db "epistemic-me-core/db"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listingClient fails model listing with err and counts how often it was asked.
+type listingClient struct {
+	err   error
+	calls int
+}
+
+func (c *listingClient) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	return openai.ChatCompletionResponse{}, errors.New("not implemented")
+}
+
+func (c *listingClient) ListModels(ctx context.Context) (openai.ModelsList, error) {
+	c.calls++
+	return openai.ModelsList{}, c.err
+}
+
+func getHealth(t *testing.T, h *healthChecker) (int, healthReport) {
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var report healthReport
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&report))
+	return recorder.Code, report
+}
+
+func TestHealthz(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+
+	t.Run("with key", func(t *testing.T) {
+		t.Setenv("OPENAI_API_KEY", "sk-test")
+		code, report := getHealth(t, newHealthChecker(kv, nil, false))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, healthStatusOK, report.Status)
+		assert.True(t, report.StoreReachable)
+		assert.True(t, report.OpenAIKeyConfigured)
+		assert.Equal(t, "unchecked", report.AIProvider)
+	})
+
+	t.Run("without key", func(t *testing.T) {
+		t.Setenv("OPENAI_API_KEY", "")
+		code, report := getHealth(t, newHealthChecker(kv, nil, false))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, healthStatusDegraded, report.Status)
+		assert.False(t, report.OpenAIKeyConfigured)
+	})
+
+	t.Run("unreachable AI provider is cached", func(t *testing.T) {
+		t.Setenv("OPENAI_API_KEY", "sk-test")
+		client := &listingClient{err: errors.New("connection refused")}
+		h := newHealthChecker(kv, ai.NewAIHelperWithClient(client), true)
+
+		_, report := getHealth(t, h)
+		assert.Equal(t, healthStatusDegraded, report.Status)
+		assert.Equal(t, "connection refused", report.AIProvider)
+		getHealth(t, h)
+		assert.Equal(t, 1, client.calls, "the probe result should be reused")
+	})
+}
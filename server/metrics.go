@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	rpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "epistemic_me_rpc_requests_total",
+		Help: "RPCs handled, by procedure and Connect status code.",
+	}, []string{"procedure", "code"})
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "epistemic_me_rpc_duration_seconds",
+		Help: "Time taken to handle an RPC, by procedure.",
+		// Some RPCs wait on the AI provider, so the buckets reach past the default 10s
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"procedure"})
+)
+
+// metricsInterceptor counts every request by procedure and outcome and records how long it took.
+// A stream is counted once, when its handler returns.
+type metricsInterceptor struct{}
+
+func newMetricsInterceptor() metricsInterceptor {
+	return metricsInterceptor{}
+}
+
+func (metricsInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		start := time.Now()
+		resp, err := next(ctx, req)
+		observeRPC(req.Spec().Procedure, start, err)
+		return resp, err
+	}
+}
+
+func (metricsInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (metricsInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		start := time.Now()
+		err := next(ctx, conn)
+		observeRPC(conn.Spec().Procedure, start, err)
+		return err
+	}
+}
+
+// observeRPC records an RPC to procedure that started at start and ended with err.
+func observeRPC(procedure string, start time.Time, err error) {
+	code := "ok"
+	if err != nil {
+		code = connect.CodeOf(err).String()
+	}
+	rpcRequests.WithLabelValues(procedure, code).Inc()
+	rpcDuration.WithLabelValues(procedure).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+
+	db "epistemic-me-core/db"
+	pb "epistemic-me-core/pb"
+	"epistemic-me-core/pb/pbconnect"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scrape returns the value of every sample on /metrics, keyed by its name and labels.
+func scrape(t *testing.T, baseURL string) map[string]float64 {
+	resp, err := http.Get(baseURL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	samples := make(map[string]float64)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		require.NoError(t, err, line)
+		samples[line[:i]] = value
+	}
+	require.NoError(t, scanner.Err())
+	return samples
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	s := &Server{health: newHealthChecker(db.NewInstrumentedStore(kv), nil, false)}
+
+	mux := http.NewServeMux()
+	path, handler := pbconnect.NewEpistemicMeServiceHandler(s, connect.WithInterceptors(newMetricsInterceptor()))
+	mux.Handle(path, handler)
+	mux.Handle("/metrics", promhttp.Handler())
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	requests := `epistemic_me_rpc_requests_total{code="ok",procedure="` + pbconnect.EpistemicMeServiceHealthCheckProcedure + `"}`
+	latencies := `epistemic_me_rpc_duration_seconds_count{procedure="` + pbconnect.EpistemicMeServiceHealthCheckProcedure + `"}`
+	retrievals := `epistemic_me_store_operations_total{operation="retrieve",outcome="not_found"}`
+	before := scrape(t, ts.URL)
+
+	client := pbconnect.NewEpistemicMeServiceClient(http.DefaultClient, ts.URL)
+	_, err = client.HealthCheck(context.Background(), connect.NewRequest(&pb.HealthCheckRequest{}))
+	require.NoError(t, err)
+
+	after := scrape(t, ts.URL)
+	assert.Equal(t, before[requests]+1, after[requests])
+	assert.Equal(t, before[latencies]+1, after[latencies])
+	assert.Equal(t, before[retrievals]+1, after[retrievals])
+}
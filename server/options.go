@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	ai "epistemic-me-core/ai"
+	db "epistemic-me-core/db"
+	svc "epistemic-me-core/svc"
+)
+
+// ServerOptions holds everything NewServerWithOptions wires the services from, so a server can be
+// built without reading the environment. Start from DefaultServerOptions, as zero values of the
+// limits below mean "unbounded" rather than "default".
+type ServerOptions struct {
+	// KVStore, when set, is used as is. Otherwise a store is opened with StoreBackend at StorePath;
+	// an empty StorePath keeps the data in memory only.
+	KVStore      db.KeyValueStore
+	StoreBackend db.Backend
+	StorePath    string
+
+	// AIHelper, when set, makes the server's AI calls. Otherwise a helper is created for
+	// OpenAIAPIKey with PromptSet, Sampling, ResponseCacheSize, PromptTokenBudget and
+	// QuestionHistoryWindow.
+	AIHelper              *ai.AIHelper
+	OpenAIAPIKey          string
+	PromptSet             ai.PromptSet
+	Sampling              ai.SamplingConfig
+	ResponseCacheSize     int
+	PromptTokenBudget     int
+	QuestionHistoryWindow int
+	// OpenAIKeyEncryptionSecret lets developers store their own OpenAI keys. Empty disables it.
+	OpenAIKeyEncryptionSecret string
+
+	MaxBeliefHistory        int
+	MaxContentLength        int
+	IdempotencyTTL          time.Duration
+	PerspectiveConcurrency  int
+	UpdateDialecticBudget   time.Duration
+	ExtractionHistoryWindow int
+	AnswerMatchThreshold    float32
+	KeyRotationGrace        time.Duration
+	// AllowedCohorts are the cohorts SetCohort accepts. Empty accepts any cohort.
+	AllowedCohorts []string
+
+	InferPossibleStates  bool
+	FastOpeningQuestions bool
+	HealthCheckProbeAI   bool
+
+	// PhilosophiesPath is the directory of philosophies preloaded at start-up. Empty skips the
+	// preload.
+	PhilosophiesPath string
+}
+
+// DefaultServerOptions returns the services' defaults, with an in-memory JSON store and no AI
+// helper or philosophies.
+func DefaultServerOptions() ServerOptions {
+	return ServerOptions{
+		StoreBackend:            db.BackendJSON,
+		MaxBeliefHistory:        svc.DefaultMaxBeliefHistory,
+		MaxContentLength:        svc.DefaultMaxContentLength,
+		IdempotencyTTL:          svc.DefaultIdempotencyTTL,
+		PerspectiveConcurrency:  svc.DefaultPerspectiveConcurrency,
+		UpdateDialecticBudget:   defaultUpdateDialecticBudget,
+		ExtractionHistoryWindow: svc.DefaultExtractionHistoryWindow,
+		AnswerMatchThreshold:    svc.DefaultAnswerMatchThreshold,
+		KeyRotationGrace:        svc.DefaultKeyRotationGrace,
+		QuestionHistoryWindow:   ai.DefaultQuestionHistoryWindow,
+	}
+}
+
+// ServerOptionsFromEnv reads the options from the environment variables documented in the README.
+// Philosophies are preloaded from Philosophies/philosophies under the working directory.
+func ServerOptionsFromEnv() (ServerOptions, error) {
+	opts := DefaultServerOptions()
+	backend, err := db.ParseBackend(os.Getenv("KV_STORE_BACKEND"))
+	if err != nil {
+		return opts, err
+	}
+	opts.StoreBackend = backend
+	opts.StorePath = os.Getenv("KV_STORE_PATH")
+
+	opts.OpenAIAPIKey = os.Getenv("OPENAI_API_KEY")
+	opts.PromptSet = aiPromptSet()
+	opts.Sampling = aiSampling()
+	opts.ResponseCacheSize = aiResponseCacheSize()
+	opts.PromptTokenBudget = aiPromptTokenBudget()
+	opts.QuestionHistoryWindow = questionHistoryWindow()
+	opts.OpenAIKeyEncryptionSecret = os.Getenv("OPENAI_KEY_ENCRYPTION_SECRET")
+
+	opts.MaxBeliefHistory = maxBeliefHistory()
+	opts.MaxContentLength = maxContentLength()
+	opts.IdempotencyTTL = idempotencyTTL()
+	opts.PerspectiveConcurrency = perspectiveConcurrency()
+	opts.UpdateDialecticBudget = updateDialecticBudget()
+	opts.ExtractionHistoryWindow = extractionHistoryWindow()
+	opts.AnswerMatchThreshold = answerMatchThreshold()
+	opts.KeyRotationGrace = keyRotationGrace()
+	opts.AllowedCohorts = allowedCohorts()
+
+	opts.InferPossibleStates = os.Getenv("INFER_POSSIBLE_STATES") == "true"
+	opts.FastOpeningQuestions = os.Getenv("FAST_OPENING_QUESTIONS") == "true"
+	opts.HealthCheckProbeAI = os.Getenv("HEALTH_CHECK_PROBE_AI") == "true"
+
+	workspaceRoot, err := os.Getwd()
+	if err != nil {
+		return opts, fmt.Errorf("failed to get workspace root: %w", err)
+	}
+	opts.PhilosophiesPath = filepath.Join(workspaceRoot, "Philosophies", "philosophies")
+	return opts, nil
+}
+
+// NewServerWithOptions wires the services from opts. Close stops the background work it starts.
+func NewServerWithOptions(opts ServerOptions) (*Server, error) {
+	kvStore := opts.KVStore
+	if kvStore == nil {
+		backend := opts.StoreBackend
+		if backend == "" {
+			backend = db.BackendJSON
+		}
+		var err error
+		if kvStore, err = db.OpenKeyValueStore(backend, opts.StorePath); err != nil {
+			return nil, fmt.Errorf("failed to open %s store: %w", backend, err)
+		}
+	}
+
+	newAIHelper := func(apiKey string) *ai.AIHelper {
+		aih := ai.NewAIHelper(apiKey,
+			ai.WithPromptSet(opts.PromptSet),
+			ai.WithSampling(opts.Sampling),
+			ai.WithPromptTokenBudget(opts.PromptTokenBudget),
+			ai.WithQuestionHistoryWindow(opts.QuestionHistoryWindow),
+		)
+		aih.EnableResponseCache(opts.ResponseCacheSize)
+		return aih
+	}
+	aih := opts.AIHelper
+	if aih == nil {
+		if opts.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("an AI helper or an OpenAI API key is required")
+		}
+		aih = newAIHelper(opts.OpenAIAPIKey)
+	}
+
+	if opts.PhilosophiesPath != "" {
+		if _, err := os.Stat(opts.PhilosophiesPath); err != nil {
+			return nil, fmt.Errorf("philosophies directory not found at %s: %w", opts.PhilosophiesPath, err)
+		}
+	}
+	developerSvc := svc.NewDeveloperService(kvStore, aih)
+	developerSvc.SetKeyRotationGrace(opts.KeyRotationGrace)
+	if err := developerSvc.IndexAPIKeys(); err != nil {
+		return nil, err
+	}
+	if err := developerSvc.IndexEmails(); err != nil {
+		return nil, err
+	}
+	if err := developerSvc.SetOpenAIKeySecret(opts.OpenAIKeyEncryptionSecret); err != nil {
+		log.Printf("Developer OpenAI keys disabled: %v", err)
+	}
+
+	bsvc := svc.NewBeliefService(kvStore, aih)
+	bsvc.SetMaxHistory(opts.MaxBeliefHistory)
+	bsvc.SetMaxContentLength(opts.MaxContentLength)
+	bsvc.SetIdempotencyTTL(opts.IdempotencyTTL)
+	bsvc.SetInferPossibleStates(opts.InferPossibleStates)
+	// Nothing below may fail, or the dispatcher's workers would be left running
+	webhooks := svc.StartWebhookDispatcher(kvStore, svc.DefaultWebhookRetryPolicy)
+	bsvc.SetBeliefSystemObserver(webhooks)
+	de := svc.NewDialecticEpistemology(bsvc, aih)
+	pe := svc.NewPerspectiveTakingEpistemology(bsvc, aih)
+	dsvc := svc.NewDialecticService(kvStore, aih, pe, de)
+	dsvc.SetPerspectiveConcurrency(opts.PerspectiveConcurrency)
+	dsvc.SetMaxContentLength(opts.MaxContentLength)
+	dsvc.SetUpdateBudget(opts.UpdateDialecticBudget)
+	dsvc.SetExtractionHistoryWindow(opts.ExtractionHistoryWindow)
+	dsvc.SetAnswerMatchThreshold(opts.AnswerMatchThreshold)
+	dsvc.SetIdempotencyTTL(opts.IdempotencyTTL)
+	dsvc.SetBeliefSystemObserver(webhooks)
+	if opts.FastOpeningQuestions {
+		dsvc.SetOpeningQuestions(svc.DefaultOpeningQuestions)
+	}
+	// Answers a restart interrupted are processed again in the background
+	if err := dsvc.ResumeBackgroundUpdates(); err != nil {
+		log.Printf("Failed to resume background belief updates: %v", err)
+	}
+	sms := svc.NewSelfModelService(kvStore, dsvc, bsvc)
+	sms.SetAnswerGenerator(aih)
+	sms.SetAllowedCohorts(opts.AllowedCohorts)
+
+	if opts.PhilosophiesPath != "" {
+		preLoadSvc := svc.NewPreloadSvc(sms, pe, opts.PhilosophiesPath)
+		if err := preLoadSvc.RunPreload(context.Background()); err != nil {
+			log.Printf("Failed to preload philosophies: %v", err)
+		}
+	}
+
+	return &Server{
+		bsvc:         bsvc,
+		dsvc:         dsvc,
+		kvStore:      kvStore,
+		selfModelSvc: sms,
+		developerSvc: developerSvc,
+		userSvc:      svc.NewUserService(kvStore, aih),
+		health:       newHealthChecker(kvStore, aih, opts.HealthCheckProbeAI),
+		webhooks:     webhooks,
+		aiHelpers:    ai.NewHelperPool(ai.DefaultHelperPoolSize, newAIHelper),
+	}, nil
+}
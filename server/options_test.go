@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ai "epistemic-me-core/ai"
+	pb "epistemic-me-core/pb"
+	pbmodels "epistemic-me-core/pb/models"
+	"epistemic-me-core/tests/fakeai"
+)
+
+func TestNewServerWithOptionsInMemory(t *testing.T) {
+	// Nothing is read from the environment
+	t.Setenv("OPENAI_API_KEY", "")
+
+	client := fakeai.New("ok")
+	opts := DefaultServerOptions()
+	opts.AIHelper = ai.NewAIHelperWithClient(client)
+	s, err := NewServerWithOptions(opts)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	apiKey := issueAPIKey(t, s)
+	createBelief := func(content string) string {
+		req := connect.NewRequest(&pb.CreateBeliefRequest{
+			SelfModelId:   "self-model-1",
+			BeliefContent: content,
+			BeliefType:    pbmodels.BeliefType_STATEMENT,
+		})
+		req.Header().Set("x-api-key", apiKey)
+		resp, err := s.CreateBelief(context.Background(), req)
+		require.NoError(t, err)
+		return resp.Msg.Belief.Id
+	}
+	first := createBelief("I believe sleep matters")
+	second := createBelief("I believe rest is important")
+
+	req := connect.NewRequest(&pb.MergeBeliefsRequest{SelfModelId: "self-model-1", BeliefIds: []string{first, second}})
+	req.Header().Set("x-api-key", apiKey)
+	merged, err := s.MergeBeliefs(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, client.Calls(), "AI calls go through the configured helper")
+	assert.Equal(t, "ok", merged.Msg.Belief.Content[0].RawStr)
+}
+
+func TestNewServerWithOptionsRequiresAI(t *testing.T) {
+	_, err := NewServerWithOptions(DefaultServerOptions())
+	assert.Error(t, err)
+}
+
+func TestNewServerWithOptionsStartsNothingOnError(t *testing.T) {
+	opts := DefaultServerOptions()
+	opts.AIHelper = ai.NewAIHelperWithClient(fakeai.New("ok"))
+	opts.PhilosophiesPath = filepath.Join(t.TempDir(), "missing")
+
+	goroutines := runtime.NumGoroutine()
+	_, err := NewServerWithOptions(opts)
+	require.Error(t, err)
+	assert.Equal(t, goroutines, runtime.NumGoroutine(), "no webhook workers are left running")
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"epistemic-me-core/svc/models"
+)
+
+const (
+	// DefaultRateLimitRequestsPerSecond and DefaultRateLimitBurst apply to developers without an
+	// override.
+	DefaultRateLimitRequestsPerSecond = 20
+	DefaultRateLimitBurst             = 100
+
+	// rateLimitIdleTTL is how long a bucket is kept after its last request. A dropped bucket starts
+	// full again, which is no more than an idle developer would have accumulated.
+	rateLimitIdleTTL = 10 * time.Minute
+	// rateLimitCleanupInterval is how often idle buckets are looked for.
+	rateLimitCleanupInterval = time.Minute
+	// rateLimitRefreshInterval is how often a developer's override is looked up again, so a
+	// changed limit applies without a restart.
+	rateLimitRefreshInterval = time.Minute
+
+	// unauthenticatedBucket is the bucket shared by every API key no developer holds, so made-up
+	// keys neither get a bucket each nor a fresh burst each.
+	unauthenticatedBucket = "unauthenticated"
+)
+
+// tokenBucket holds the tokens left for one developer.
+type tokenBucket struct {
+	limit    models.RateLimit
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+	// limitChecked is when limit was last looked up
+	limitChecked time.Time
+}
+
+// rateLimiter keeps an in-memory token bucket per developer.
+type rateLimiter struct {
+	defaultLimit models.RateLimit
+	// lookupLimit returns a developer's override, or nil to use defaultLimit
+	lookupLimit func(developerID string) *models.RateLimit
+	now         func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(defaultLimit models.RateLimit, lookupLimit func(developerID string) *models.RateLimit) *rateLimiter {
+	return &rateLimiter{
+		defaultLimit: defaultLimit,
+		lookupLimit:  lookupLimit,
+		now:          time.Now,
+		buckets:      make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from developerID's bucket, reporting false when it is empty.
+func (l *rateLimiter) allow(developerID string) bool {
+	bucket := l.bucket(developerID)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if elapsed := now.Sub(bucket.updated).Seconds(); elapsed > 0 {
+		bucket.tokens += elapsed * bucket.limit.RequestsPerSecond
+		if bucket.tokens > float64(bucket.limit.Burst) {
+			bucket.tokens = float64(bucket.limit.Burst)
+		}
+		bucket.updated = now
+	}
+	bucket.lastSeen = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// bucket returns developerID's bucket, creating a full one on first use. The developer's override
+// is looked up then and again every rateLimitRefreshInterval, outside the lock since it reads the
+// store. A lowered burst caps the tokens the bucket holds.
+func (l *rateLimiter) bucket(developerID string) *tokenBucket {
+	l.mu.Lock()
+	bucket, ok := l.buckets[developerID]
+	fresh := ok && l.now().Sub(bucket.limitChecked) < rateLimitRefreshInterval
+	l.mu.Unlock()
+	if fresh {
+		return bucket
+	}
+
+	limit := l.defaultLimit
+	if l.lookupLimit != nil && developerID != unauthenticatedBucket {
+		if override := l.lookupLimit(developerID); override != nil {
+			limit = *override
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if bucket, ok = l.buckets[developerID]; !ok {
+		bucket = &tokenBucket{limit: limit, tokens: float64(limit.Burst), updated: now}
+		l.buckets[developerID] = bucket
+	} else if bucket.limit != limit {
+		bucket.limit = limit
+		bucket.tokens = min(bucket.tokens, float64(limit.Burst))
+	}
+	bucket.limitChecked = now
+	return bucket
+}
+
+// cleanup drops the buckets idle for longer than idleTTL.
+func (l *rateLimiter) cleanup(idleTTL time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for developerID, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) > idleTTL {
+			delete(l.buckets, developerID)
+		}
+	}
+}
+
+// startCleanup runs cleanup every interval until the returned function is called.
+func (l *rateLimiter) startCleanup(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.cleanup(rateLimitIdleTTL)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// rateLimitInterceptor rejects requests with CodeResourceExhausted once the developer holding their
+// API key has used up their bucket. The key is read like the handlers read it, from the request
+// metadata or its headers, and authenticated first, so all of a developer's keys share a bucket and
+// keys no developer holds share unauthenticatedBucket. Requests without a key are left to the
+// handlers' own API key checks.
+type rateLimitInterceptor struct {
+	limiter *rateLimiter
+	// authenticate returns the ID of the developer holding an API key
+	authenticate func(apiKey string) (string, error)
+}
+
+func newRateLimitInterceptor(limiter *rateLimiter, authenticate func(apiKey string) (string, error)) *rateLimitInterceptor {
+	return &rateLimitInterceptor{limiter: limiter, authenticate: authenticate}
+}
+
+func (i *rateLimitInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if err := i.check(ctx, req.Header()); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (i *rateLimitInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *rateLimitInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := i.check(ctx, conn.RequestHeader()); err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+// check takes a token for the developer holding the API key sent with a request, failing once
+// their bucket is empty.
+func (i *rateLimitInterceptor) check(ctx context.Context, header http.Header) error {
+	apiKey := headerAPIKey(ctx, header)
+	if apiKey == "" {
+		return nil
+	}
+	bucket := unauthenticatedBucket
+	if developerID, err := i.authenticate(apiKey); err == nil && developerID != "" {
+		bucket = developerID
+	}
+	if !i.limiter.allow(bucket) {
+		return connect.NewError(connect.CodeResourceExhausted, fmt.Errorf("rate limit exceeded for API key"))
+	}
+	return nil
+}
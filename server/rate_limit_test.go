@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+)
+
+type pingMessage struct{}
+
+// keyIsDeveloper authenticates every API key as a developer of the same ID.
+func keyIsDeveloper(apiKey string) (string, error) {
+	return apiKey, nil
+}
+
+// callLimited sends n requests with apiKey through the rate limit interceptor and returns how many
+// were rejected with CodeResourceExhausted.
+func callLimited(t *testing.T, limiter *rateLimiter, apiKey string, n int) int {
+	return callLimitedAs(t, limiter, keyIsDeveloper, apiKey, n)
+}
+
+// callLimitedAs is callLimited with API keys authenticated by authenticate.
+func callLimitedAs(t *testing.T, limiter *rateLimiter, authenticate func(string) (string, error), apiKey string, n int) int {
+	next := func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&pingMessage{}), nil
+	}
+	call := newRateLimitInterceptor(limiter, authenticate).WrapUnary(next)
+
+	rejected := 0
+	for i := 0; i < n; i++ {
+		req := connect.NewRequest(&pingMessage{})
+		req.Header().Set("x-api-key", apiKey)
+		if _, err := call(context.Background(), req); err != nil {
+			assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+			rejected++
+		}
+	}
+	return rejected
+}
+
+func TestRateLimitRejectsRequestsAboveBurst(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(models.RateLimit{RequestsPerSecond: 1, Burst: 3}, nil)
+	limiter.now = func() time.Time { return now }
+
+	assert.Equal(t, 7, callLimited(t, limiter, "key-a", 10))
+	// Other keys have their own bucket
+	assert.Equal(t, 0, callLimited(t, limiter, "key-b", 3))
+
+	// Tokens refill at the configured rate
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, 1, callLimited(t, limiter, "key-a", 3))
+}
+
+func TestRateLimitUsesDeveloperOverride(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(models.RateLimit{RequestsPerSecond: 1, Burst: 2}, func(apiKey string) *models.RateLimit {
+		if apiKey == "generous" {
+			return &models.RateLimit{RequestsPerSecond: 1, Burst: 10}
+		}
+		return nil
+	})
+	limiter.now = func() time.Time { return now }
+
+	assert.Equal(t, 0, callLimited(t, limiter, "generous", 10))
+	assert.Equal(t, 8, callLimited(t, limiter, "default", 10))
+}
+
+func TestRateLimitRereadsDeveloperOverride(t *testing.T) {
+	now := time.Now()
+	override := &models.RateLimit{RequestsPerSecond: 1, Burst: 10}
+	limiter := newRateLimiter(models.RateLimit{RequestsPerSecond: 1, Burst: 2}, func(apiKey string) *models.RateLimit {
+		return override
+	})
+	limiter.now = func() time.Time { return now }
+
+	assert.Equal(t, 0, callLimited(t, limiter, "key", 5))
+
+	// A lowered override applies once it is looked up again, capping the tokens left
+	override = &models.RateLimit{RequestsPerSecond: 1, Burst: 2}
+	assert.Equal(t, 0, callLimited(t, limiter, "key", 1))
+	now = now.Add(rateLimitRefreshInterval)
+	assert.Equal(t, 3, callLimited(t, limiter, "key", 5))
+}
+
+func TestRateLimitReadsKeyFromMetadata(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(models.RateLimit{RequestsPerSecond: 1, Burst: 1}, nil)
+	limiter.now = func() time.Time { return now }
+	next := func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&pingMessage{}), nil
+	}
+	call := newRateLimitInterceptor(limiter, keyIsDeveloper).WrapUnary(next)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "metadata-key"))
+	_, err := call(ctx, connect.NewRequest(&pingMessage{}))
+	assert.NoError(t, err)
+	_, err = call(ctx, connect.NewRequest(&pingMessage{}))
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+}
+
+func TestRateLimitBucketsAreKeyedByDeveloper(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(models.RateLimit{RequestsPerSecond: 1, Burst: 3}, nil)
+	limiter.now = func() time.Time { return now }
+	authenticate := func(apiKey string) (string, error) {
+		switch apiKey {
+		case "key-1", "key-2":
+			return "dev_1", nil
+		}
+		return "", svc.ErrUnknownAPIKey
+	}
+
+	// A developer's keys draw on the same bucket
+	assert.Equal(t, 0, callLimitedAs(t, limiter, authenticate, "key-1", 2))
+	assert.Equal(t, 1, callLimitedAs(t, limiter, authenticate, "key-2", 2))
+
+	// Keys no developer holds share one bucket, so each made-up key gets no fresh burst
+	assert.Equal(t, 0, callLimitedAs(t, limiter, authenticate, "made-up-1", 3))
+	assert.Equal(t, 1, callLimitedAs(t, limiter, authenticate, "made-up-2", 1))
+	assert.Len(t, limiter.buckets, 2)
+}
+
+func TestRateLimitCleanupDropsIdleKeys(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(models.RateLimit{RequestsPerSecond: 1, Burst: 1}, nil)
+	limiter.now = func() time.Time { return now }
+
+	callLimited(t, limiter, "idle", 1)
+	now = now.Add(30 * time.Second)
+	callLimited(t, limiter, "active", 1)
+
+	limiter.cleanup(10 * time.Second)
+	assert.NotContains(t, limiter.buckets, "idle")
+	assert.Contains(t, limiter.buckets, "active")
+}
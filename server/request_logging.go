@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/google/uuid"
+
+	"epistemic-me-core/logging"
+)
+
+// requestIDHeader carries the correlation ID of a request. A caller-supplied ID is reused so
+// traces can span the Playground and the server; otherwise one is generated.
+const requestIDHeader = "X-Request-Id"
+
+// requestLoggingInterceptor tags every request's context with a correlation ID, its method and
+// the self model it targets, and writes one summary line per request with its duration and outcome.
+// A stream's self model is in its first message, which is read after its context is built, so
+// streams are tagged with their ID and method only.
+type requestLoggingInterceptor struct{}
+
+func newRequestLoggingInterceptor() requestLoggingInterceptor {
+	return requestLoggingInterceptor{}
+}
+
+func (requestLoggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		requestID := requestIDFrom(req.Header())
+		ctx = logging.NewContext(ctx, requestID, req.Spec().Procedure)
+		if msg, ok := req.Any().(interface{ GetSelfModelId() string }); ok && msg.GetSelfModelId() != "" {
+			ctx = logging.WithSelfModelID(ctx, msg.GetSelfModelId())
+		}
+
+		start := time.Now()
+		resp, err := next(ctx, req)
+		if err = logOutcome(ctx, requestID, start, err); err != nil {
+			return nil, err
+		}
+		resp.Header().Set(requestIDHeader, requestID)
+		return resp, nil
+	}
+}
+
+func (requestLoggingInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (requestLoggingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		requestID := requestIDFrom(conn.RequestHeader())
+		ctx = logging.NewContext(ctx, requestID, conn.Spec().Procedure)
+		// The headers go out with the first message, so the ID is set before the handler sends one
+		conn.ResponseHeader().Set(requestIDHeader, requestID)
+
+		start := time.Now()
+		return logOutcome(ctx, requestID, start, next(ctx, conn))
+	}
+}
+
+// requestIDFrom returns the caller's correlation ID from header, or a new one.
+func requestIDFrom(header http.Header) string {
+	if requestID := header.Get(requestIDHeader); requestID != "" {
+		return requestID
+	}
+	return uuid.New().String()
+}
+
+// logOutcome writes the summary line of a request that started at start and returns its error,
+// tagged with requestID.
+func logOutcome(ctx context.Context, requestID string, start time.Time, err error) error {
+	duration := time.Since(start)
+	if err != nil {
+		if connectErr, ok := err.(*connect.Error); ok {
+			connectErr.Meta().Set(requestIDHeader, requestID)
+		}
+		logging.Warnf(ctx, "request failed in %s: %s: %v", duration, connect.CodeOf(err), err)
+		return err
+	}
+	logging.Infof(ctx, "request completed in %s", duration)
+	return nil
+}
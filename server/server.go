@@ -2,25 +2,28 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"connectrpc.com/connect"
-	"github.com/google/uuid"
-	"github.com/rs/cors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc/metadata"
 
 	ai "epistemic-me-core/ai"
 	db "epistemic-me-core/db"
+	"epistemic-me-core/logging"
 	pb "epistemic-me-core/pb"
 	models "epistemic-me-core/pb/models"
 	"epistemic-me-core/pb/pbconnect"
@@ -31,197 +34,1162 @@ import (
 type Server struct {
 	bsvc         *svc.BeliefService
 	dsvc         *svc.DialecticService
-	kvStore      *db.KeyValueStore
+	kvStore      db.KeyValueStore
 	selfModelSvc *svc.SelfModelService
 	developerSvc *svc.DeveloperService
 	userSvc      *svc.UserService
+	health       *healthChecker
+	webhooks     *svc.WebhookDispatcher
+	// aiHelpers holds the helpers bound to developers' own OpenAI keys
+	aiHelpers *ai.HelperPool
 }
 
 // Move validateAPIKey to be a regular function instead of a method
 func validateAPIKey[T any](
 	ctx context.Context,
+	developerSvc *svc.DeveloperService,
 	req *connect.Request[T],
 ) (context.Context, error) {
-	var apiKey string
+	apiKey := requestAPIKey(ctx, req)
+	if apiKey == "" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("missing API key"))
+	}
+
+	if _, err := developerSvc.AuthenticateAPIKey(apiKey); errors.Is(err, svc.ErrAPIKeyRevoked) || errors.Is(err, svc.ErrUnknownAPIKey) {
+		return nil, connect.NewError(connect.CodeUnauthenticated, err)
+	} else if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
 
-	// First try metadata
+	return ctx, nil
+}
+
+// requestAPIKey returns the API key sent in the request metadata or, failing that, its headers.
+func requestAPIKey(ctx context.Context, req connect.AnyRequest) string {
+	return headerAPIKey(ctx, req.Header())
+}
+
+// headerAPIKey returns the API key sent in the request metadata or, failing that, in header. It
+// serves streaming handlers, whose request is read only after their interceptors have run.
+func headerAPIKey(ctx context.Context, header http.Header) string {
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		if apiKeys := md.Get("x-api-key"); len(apiKeys) > 0 {
-			apiKey = apiKeys[0]
+		if apiKeys := md.Get("x-api-key"); len(apiKeys) > 0 && apiKeys[0] != "" {
+			return apiKeys[0]
 		}
 	}
+	return header.Get("x-api-key")
+}
 
-	// Then try headers
-	if apiKey == "" {
-		apiKey = req.Header().Get("x-api-key")
+// requireDeveloper rejects requests whose API key does not belong to developerID.
+func requireDeveloper[T any](ctx context.Context, developerSvc *svc.DeveloperService, req *connect.Request[T], developerID string) error {
+	developer, err := developerSvc.GetDeveloperByAPIKey(requestAPIKey(ctx, req))
+	if err != nil || developer.ID != developerID {
+		return connect.NewError(connect.CodePermissionDenied, fmt.Errorf("API key does not belong to developer %q", developerID))
 	}
+	return nil
+}
 
-	if apiKey == "" {
-		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("missing API key"))
+// requestDeveloperID returns the ID of the developer owning a request's API key, or "" when no
+// developer holds it.
+func requestDeveloperID[T any](ctx context.Context, developerSvc *svc.DeveloperService, req *connect.Request[T]) string {
+	if developer, err := developerSvc.GetDeveloperByAPIKey(requestAPIKey(ctx, req)); err == nil {
+		return developer.ID
+	}
+	return ""
+}
+
+// requesterID identifies who sent a request: the developer owning its API key, or the key itself
+// when no developer holds it.
+func requesterID[T any](ctx context.Context, developerSvc *svc.DeveloperService, req *connect.Request[T]) string {
+	apiKey := requestAPIKey(ctx, req)
+	if developer, err := developerSvc.GetDeveloperByAPIKey(apiKey); err == nil {
+		return developer.ID
+	}
+	return apiKey
+}
+
+// storeErrorCode maps service errors to a Connect code. Version conflicts are
+// reported as Aborted so clients know to re-read and retry, and edits to an
+// unanswered interaction as FailedPrecondition. Requests that ran out of time
+// are reported as DeadlineExceeded, and emails that are already registered as
+// AlreadyExists.
+func storeErrorCode(err error) connect.Code {
+	switch {
+	case errors.Is(err, db.ErrVersionConflict),
+		errors.Is(err, svc.ErrRequestInProgress):
+		return connect.CodeAborted
+	case errors.Is(err, svc.ErrBackgroundQueueFull):
+		return connect.CodeResourceExhausted
+	case errors.Is(err, context.DeadlineExceeded):
+		return connect.CodeDeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return connect.CodeCanceled
+	case errors.Is(err, svc.ErrEmailTaken):
+		return connect.CodeAlreadyExists
+	case errors.Is(err, svc.ErrInvalidWebhookURL),
+		errors.Is(err, svc.ErrWebhookHostNotAllowed),
+		errors.Is(err, svc.ErrInvalidCohort),
+		errors.Is(err, svc.ErrInvalidPageToken),
+		errors.Is(err, svc.ErrIdempotencyKeyReused),
+		errors.Is(err, svc.ErrNoAnswers),
+		errors.Is(err, svc.ErrAnswerNotMatched),
+		errors.Is(err, svc.ErrInvalidResource),
+		errors.Is(err, svc.ErrNoEvidence),
+		errors.Is(err, svc.ErrNoResources),
+		errors.Is(err, svc.ErrNoBeliefs),
+		errors.Is(err, svc.ErrInvalidBeliefType),
+		errors.Is(err, svc.ErrNoTags),
+		errors.Is(err, svc.ErrInvalidAnnotation),
+		errors.Is(err, svc.ErrInvalidEvalScore),
+		errors.Is(err, svc.ErrEmptyContent),
+		errors.Is(err, svc.ErrContentTooLong):
+		return connect.CodeInvalidArgument
+	case errors.Is(err, svc.ErrInteractionNotAnswered),
+		errors.Is(err, svc.ErrOpenAIKeysDisabled),
+		errors.Is(err, svc.ErrNoPendingQuestions),
+		errors.Is(err, svc.ErrObservationContextInUse):
+		return connect.CodeFailedPrecondition
+	case errors.Is(err, svc.ErrSelfModelNotFound),
+		errors.Is(err, svc.ErrBeliefNotFound),
+		errors.Is(err, svc.ErrResourceNotFound),
+		errors.Is(err, svc.ErrBeliefSystemNotFound),
+		errors.Is(err, svc.ErrSnapshotNotFound),
+		errors.Is(err, svc.ErrDialecticNotFound),
+		errors.Is(err, svc.ErrInteractionNotFound),
+		errors.Is(err, svc.ErrObservationContextNotFound),
+		errors.Is(err, svc.ErrAPIKeyNotFound),
+		errors.Is(err, db.ErrNotFound):
+		return connect.CodeNotFound
+	}
+	return connect.CodeInternal
+}
+
+// Update the CreateBelief method to handle evidence
+func (s *Server) CreateBelief(
+	ctx context.Context,
+	req *connect.Request[pb.CreateBeliefRequest],
+) (*connect.Response[pb.CreateBeliefResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("CreateBelief called with request: %+v", req.Msg)
+
+	// Convert protobuf belief type to internal type
+	beliefType, err := svcmodels.BeliefTypeFromProto(req.Msg.BeliefType)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	input := &svcmodels.CreateBeliefInput{
+		SelfModelID:    req.Msg.SelfModelId,
+		BeliefContent:  req.Msg.BeliefContent,
+		BeliefType:     beliefType,
+		DryRun:         req.Msg.DryRun,
+		IdempotencyKey: req.Msg.IdempotencyKey,
+		RequesterID:    requesterID(ctx, s.developerSvc, req),
+	}
+
+	// Handle evidence if provided
+	switch evidence := req.Msg.Evidence.(type) {
+	case *pb.CreateBeliefRequest_HypothesisEvidence:
+		input.BeliefEvidence = &svcmodels.BeliefEvidence{
+			Type:      svcmodels.EvidenceTypeHypothesis,
+			Content:   evidence.HypothesisEvidence.Evidence,
+			IsCounter: evidence.HypothesisEvidence.IsCounterfactual,
+		}
+	case *pb.CreateBeliefRequest_ActionOutcome:
+		input.BeliefEvidence = &svcmodels.BeliefEvidence{
+			Type:    svcmodels.EvidenceTypeAction,
+			Action:  evidence.ActionOutcome.Action,
+			Outcome: evidence.ActionOutcome.Outcome,
+		}
+	}
+
+	response, err := s.bsvc.WithContext(ctx).CreateBelief(input)
+	if err != nil {
+		log.Printf("CreateBelief ERROR: %v", err)
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.CreateBeliefResponse{
+		Belief:       response.Belief.ToProto(),
+		BeliefSystem: response.BeliefSystem.ToProto(),
+	}), nil
+}
+
+// BatchCreateBeliefs creates several beliefs at once, reporting the outcome of each.
+func (s *Server) BatchCreateBeliefs(
+	ctx context.Context,
+	req *connect.Request[pb.BatchCreateBeliefsRequest],
+) (*connect.Response[pb.BatchCreateBeliefsResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &svcmodels.BatchCreateBeliefsInput{
+		SelfModelID: req.Msg.SelfModelId,
+		Mode:        svcmodels.BatchMode(req.Msg.Mode),
+	}
+	for _, belief := range req.Msg.Beliefs {
+		// An unknown type is left invalid for the service to report against its belief
+		beliefType, _ := svcmodels.BeliefTypeFromProto(belief.BeliefType)
+		input.Beliefs = append(input.Beliefs, svcmodels.BatchBeliefInput{
+			BeliefContent: belief.BeliefContent,
+			BeliefType:    beliefType,
+		})
+	}
+
+	response, err := s.bsvc.WithContext(ctx).BatchCreateBeliefs(input)
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	beliefs := make([]*models.Belief, 0, len(response.Beliefs))
+	for _, belief := range response.Beliefs {
+		beliefs = append(beliefs, belief.ToProto())
+	}
+	return connect.NewResponse(&pb.BatchCreateBeliefsResponse{
+		Beliefs:      beliefs,
+		BeliefSystem: response.BeliefSystem.ToProto(),
+		Results:      response.Result.ToProto(),
+	}), nil
+}
+
+func (s *Server) ListBeliefs(
+	ctx context.Context,
+	req *connect.Request[pb.ListBeliefsRequest],
+) (*connect.Response[pb.ListBeliefsResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("ListBeliefs called with request:", req.Msg)
+
+	response, err := s.bsvc.WithContext(ctx).ListBeliefs(&svcmodels.ListBeliefsInput{
+		SelfModelID: req.Msg.SelfModelId,
+		BeliefIDs:   req.Msg.BeliefIds,
+		Cohort:      req.Msg.Cohort,
+		DeveloperID: requestDeveloperID(ctx, s.developerSvc, req),
+		Tags:        req.Msg.Tags,
+	})
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if response == nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("unexpected nil response"))
+	}
+
+	var beliefPbs []*models.Belief
+	for _, belief := range response.Beliefs {
+		beliefPbs = append(beliefPbs, belief.ToProto())
+	}
+
+	protoResponse := &pb.ListBeliefsResponse{
+		Beliefs:      beliefPbs,
+		BeliefSystem: response.BeliefSystem.ToProto(),
+	}
+
+	return connect.NewResponse(protoResponse), nil
+}
+
+func (s *Server) GetBelief(
+	ctx context.Context,
+	req *connect.Request[pb.GetBeliefRequest],
+) (*connect.Response[pb.GetBeliefResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).GetBelief(&svcmodels.GetBeliefInput{
+		SelfModelID: req.Msg.SelfModelId,
+		BeliefID:    req.Msg.BeliefId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	beliefContexts := make([]*models.BeliefContext, 0, len(response.BeliefContexts))
+	for _, bc := range response.BeliefContexts {
+		beliefContexts = append(beliefContexts, bc.ToProto())
+	}
+	return connect.NewResponse(&pb.GetBeliefResponse{
+		Belief:         response.Belief.ToProto(),
+		BeliefContexts: beliefContexts,
+	}), nil
+}
+
+// AnalyzeBeliefSystem scores a self model's current belief system without a dialectic turn.
+func (s *Server) AnalyzeBeliefSystem(
+	ctx context.Context,
+	req *connect.Request[pb.AnalyzeBeliefSystemRequest],
+) (*connect.Response[pb.AnalyzeBeliefSystemResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).AnalyzeBeliefSystem(&svcmodels.AnalyzeBeliefSystemInput{
+		SelfModelID:   req.Msg.SelfModelId,
+		DialecticType: svcmodels.DialecticTypeFromProto(req.Msg.DialecticType),
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.AnalyzeBeliefSystemResponse{
+		Analysis: response.Analysis.ToProto(),
+		Cached:   response.Cached,
+	}), nil
+}
+
+// CreateResource stores an external resource that beliefs can cite as evidence, optionally citing
+// it for a belief right away.
+func (s *Server) CreateResource(
+	ctx context.Context,
+	req *connect.Request[pb.CreateResourceRequest],
+) (*connect.Response[pb.CreateResourceResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).CreateResource(&svcmodels.CreateResourceInput{
+		SelfModelID: req.Msg.SelfModelId,
+		Type:        svcmodels.ResourceType(req.Msg.Type),
+		Content:     req.Msg.Content,
+		SourceID:    req.Msg.SourceId,
+		Metadata:    req.Msg.Metadata,
+		BeliefID:    req.Msg.BeliefId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	protoResponse := &pb.CreateResourceResponse{
+		Resource: response.Resource.ToProto(),
+	}
+	if response.Belief != nil {
+		protoResponse.Belief = response.Belief.ToProto()
+	}
+	return connect.NewResponse(protoResponse), nil
+}
+
+// BootstrapSelfModel builds a self model's belief system from a set of documents, streaming a
+// progress event after each document and ending with a summary of the bootstrap. Processing stops
+// when the client goes away.
+func (s *Server) BootstrapSelfModel(
+	ctx context.Context,
+	req *connect.Request[pb.BootstrapSelfModelRequest],
+	stream *connect.ServerStream[pb.BootstrapSelfModelResponse],
+) error {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resources := make([]svcmodels.Resource, 0, len(req.Msg.Documents))
+	for _, document := range req.Msg.Documents {
+		resources = append(resources, svcmodels.Resource{
+			Type:     svcmodels.ResourceType(document.Type),
+			Content:  document.Content,
+			SourceID: document.SourceId,
+			Metadata: document.Metadata,
+		})
+	}
+	// A progress event that cannot be sent means the client is gone, so the bootstrap is cancelled
+	var sendErr error
+	progress := func(p svcmodels.BootstrapProgress) {
+		if sendErr != nil {
+			return
+		}
+		sendErr = stream.Send(&pb.BootstrapSelfModelResponse{
+			Event: &pb.BootstrapSelfModelResponse_Progress{Progress: p.ToProto()},
+		})
+		if sendErr != nil {
+			cancel()
+		}
+	}
+	response, err := s.bsvc.WithContext(ctx).BootstrapSelfModel(&svcmodels.BootstrapSelfModelInput{
+		SelfModelID: req.Msg.SelfModelId,
+		Resources:   resources,
+	}, progress)
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return connect.NewError(storeErrorCode(err), err)
+	}
+
+	beliefs := make([]*models.Belief, 0, len(response.Beliefs))
+	for _, belief := range response.Beliefs {
+		beliefs = append(beliefs, belief.ToProto())
+	}
+	observationContexts := make([]*models.ObservationContext, 0, len(response.ObservationContexts))
+	for _, oc := range response.ObservationContexts {
+		observationContexts = append(observationContexts, oc.ToProto())
+	}
+	return stream.Send(&pb.BootstrapSelfModelResponse{
+		Event: &pb.BootstrapSelfModelResponse_Summary{Summary: &pb.BootstrapSummary{
+			Beliefs:             beliefs,
+			ObservationContexts: observationContexts,
+			BeliefsSkipped:      int32(response.BeliefsSkipped),
+			BeliefSystem:        response.BeliefSystem.ToProto(),
+		}},
+	})
+}
+
+// AddEvidence adds evidence to a belief, a stored resource to cite or a hypothesis or action
+// outcome, and recomputes the belief's confidence.
+func (s *Server) AddEvidence(
+	ctx context.Context,
+	req *connect.Request[pb.AddEvidenceRequest],
+) (*connect.Response[pb.AddEvidenceResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &svcmodels.AddEvidenceInput{
+		SelfModelID: req.Msg.SelfModelId,
+		BeliefID:    req.Msg.BeliefId,
+		ResourceID:  req.Msg.ResourceId,
+	}
+	switch evidence := req.Msg.Evidence.(type) {
+	case *pb.AddEvidenceRequest_HypothesisEvidence:
+		input.Evidence = &svcmodels.BeliefEvidence{
+			Type:      svcmodels.EvidenceTypeHypothesis,
+			Content:   evidence.HypothesisEvidence.Evidence,
+			IsCounter: evidence.HypothesisEvidence.IsCounterfactual,
+		}
+	case *pb.AddEvidenceRequest_ActionOutcome:
+		input.Evidence = &svcmodels.BeliefEvidence{
+			Type:    svcmodels.EvidenceTypeAction,
+			Action:  evidence.ActionOutcome.Action,
+			Outcome: evidence.ActionOutcome.Outcome,
+		}
+	}
+
+	response, err := s.bsvc.WithContext(ctx).AddEvidence(input)
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.AddEvidenceResponse{
+		Belief:   response.Belief.ToProto(),
+		Evidence: svcmodels.BeliefEvidenceToProto(response.Evidence),
+	}), nil
+}
+
+// AddBeliefTags adds curators' tags to a belief.
+func (s *Server) AddBeliefTags(
+	ctx context.Context,
+	req *connect.Request[pb.AddBeliefTagsRequest],
+) (*connect.Response[pb.AddBeliefTagsResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).AddBeliefTags(&svcmodels.BeliefTagsInput{
+		SelfModelID: req.Msg.SelfModelId,
+		BeliefID:    req.Msg.BeliefId,
+		Tags:        req.Msg.Tags,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.AddBeliefTagsResponse{
+		Belief: response.Belief.ToProto(),
+	}), nil
+}
+
+// RemoveBeliefTags removes tags from a belief.
+func (s *Server) RemoveBeliefTags(
+	ctx context.Context,
+	req *connect.Request[pb.RemoveBeliefTagsRequest],
+) (*connect.Response[pb.RemoveBeliefTagsResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).RemoveBeliefTags(&svcmodels.BeliefTagsInput{
+		SelfModelID: req.Msg.SelfModelId,
+		BeliefID:    req.Msg.BeliefId,
+		Tags:        req.Msg.Tags,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.RemoveBeliefTagsResponse{
+		Belief: response.Belief.ToProto(),
+	}), nil
+}
+
+// ListBeliefEvidence returns the evidence given for a belief, oldest first.
+func (s *Server) ListBeliefEvidence(
+	ctx context.Context,
+	req *connect.Request[pb.ListBeliefEvidenceRequest],
+) (*connect.Response[pb.ListBeliefEvidenceResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).ListBeliefEvidence(&svcmodels.ListBeliefEvidenceInput{
+		SelfModelID: req.Msg.SelfModelId,
+		BeliefID:    req.Msg.BeliefId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.ListBeliefEvidenceResponse{
+		Evidence: svcmodels.BeliefEvidenceToProto(response.Evidence),
+	}), nil
+}
+
+func (s *Server) GetBeliefHistory(
+	ctx context.Context,
+	req *connect.Request[pb.GetBeliefHistoryRequest],
+) (*connect.Response[pb.GetBeliefHistoryResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("GetBeliefHistory called with request:", req.Msg)
+
+	response, err := s.bsvc.WithContext(ctx).GetBeliefHistory(&svcmodels.GetBeliefHistoryInput{
+		SelfModelID: req.Msg.SelfModelId,
+		BeliefID:    req.Msg.BeliefId,
+	})
+	if err != nil {
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	}
+
+	history := make([]*models.Belief, len(response.History))
+	for i := range response.History {
+		history[i] = response.History[i].ToProto()
+	}
+
+	return connect.NewResponse(&pb.GetBeliefHistoryResponse{
+		Belief:  response.Belief.ToProto(),
+		History: history,
+	}), nil
+}
+
+func (s *Server) GetBeliefProvenance(
+	ctx context.Context,
+	req *connect.Request[pb.GetBeliefProvenanceRequest],
+) (*connect.Response[pb.GetBeliefProvenanceResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).GetBeliefProvenance(&svcmodels.GetBeliefProvenanceInput{
+		SelfModelID: req.Msg.SelfModelId,
+		BeliefID:    req.Msg.BeliefId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.GetBeliefProvenanceResponse{
+		Provenance: response.Provenance.ToProto(),
+	}), nil
+}
+
+func (s *Server) MergeBeliefs(
+	ctx context.Context,
+	req *connect.Request[pb.MergeBeliefsRequest],
+) (*connect.Response[pb.MergeBeliefsResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("MergeBeliefs called with request:", req.Msg)
+
+	if len(req.Msg.BeliefIds) < 2 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("at least two belief ids are required"))
+	}
+
+	response, err := s.bsvc.WithContext(ctx).MergeBeliefs(&svcmodels.MergeBeliefsInput{
+		SelfModelID: req.Msg.SelfModelId,
+		BeliefIDs:   req.Msg.BeliefIds,
+	})
+	if err != nil {
+		log.Printf("MergeBeliefs ERROR: %v", err)
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.MergeBeliefsResponse{
+		Belief:       response.Belief.ToProto(),
+		BeliefSystem: response.BeliefSystem.ToProto(),
+	}), nil
+}
+
+func (s *Server) DeleteBelief(
+	ctx context.Context,
+	req *connect.Request[pb.DeleteBeliefRequest],
+) (*connect.Response[pb.DeleteBeliefResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("DeleteBelief called with request:", req.Msg)
+
+	response, err := s.bsvc.WithContext(ctx).DeleteBelief(&svcmodels.DeleteBeliefInput{
+		SelfModelID: req.Msg.SelfModelId,
+		ID:          req.Msg.BeliefId,
+	})
+	if err != nil {
+		log.Printf("DeleteBelief ERROR: %v", err)
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.DeleteBeliefResponse{
+		Belief:       response.Belief.ToProto(),
+		DialecticIds: response.DialecticIDs,
+	}), nil
+}
+
+func (s *Server) SaveBeliefSystemSnapshot(
+	ctx context.Context,
+	req *connect.Request[pb.SaveBeliefSystemSnapshotRequest],
+) (*connect.Response[pb.SaveBeliefSystemSnapshotResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Msg.Name == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("snapshot name is required"))
+	}
+
+	response, err := s.bsvc.WithContext(ctx).SaveBeliefSystemSnapshot(&svcmodels.SaveBeliefSystemSnapshotInput{
+		SelfModelID: req.Msg.SelfModelId,
+		Name:        req.Msg.Name,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.SaveBeliefSystemSnapshotResponse{
+		Snapshot: response.Snapshot.ToProto(),
+	}), nil
+}
+
+func (s *Server) RestoreBeliefSystemSnapshot(
+	ctx context.Context,
+	req *connect.Request[pb.RestoreBeliefSystemSnapshotRequest],
+) (*connect.Response[pb.RestoreBeliefSystemSnapshotResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).RestoreBeliefSystemSnapshot(&svcmodels.RestoreBeliefSystemSnapshotInput{
+		SelfModelID: req.Msg.SelfModelId,
+		Name:        req.Msg.Name,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.RestoreBeliefSystemSnapshotResponse{
+		BeliefSystem: response.BeliefSystem.ToProto(),
+	}), nil
+}
+
+func (s *Server) ListBeliefSystemSnapshots(
+	ctx context.Context,
+	req *connect.Request[pb.ListBeliefSystemSnapshotsRequest],
+) (*connect.Response[pb.ListBeliefSystemSnapshotsResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).ListBeliefSystemSnapshots(&svcmodels.ListBeliefSystemSnapshotsInput{
+		SelfModelID: req.Msg.SelfModelId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	snapshots := make([]*models.BeliefSystemSnapshot, 0, len(response.Snapshots))
+	for _, snapshot := range response.Snapshots {
+		snapshots = append(snapshots, snapshot.ToProto())
+	}
+	return connect.NewResponse(&pb.ListBeliefSystemSnapshotsResponse{
+		Snapshots: snapshots,
+	}), nil
+}
+
+func (s *Server) CreateDialectic(ctx context.Context, req *connect.Request[pb.CreateDialecticRequest]) (*connect.Response[pb.CreateDialecticResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("CreateDialectic called with request: %+v", req.Msg)
+
+	input := &svcmodels.CreateDialecticInput{
+		SelfModelID:       req.Msg.SelfModelId,
+		DialecticType:     svcmodels.DialecticType(req.Msg.DialecticType),
+		LearningObjective: svcmodels.LearningObjectiveFromProto(req.Msg.LearningObjective),
+		IdempotencyKey:    req.Msg.IdempotencyKey,
+		RequesterID:       requesterID(ctx, s.developerSvc, req),
+	}
+	log.Printf("CreateDialectic input: %+v", input)
+
+	response, err := s.dsvc.WithContext(ctx).CreateDialectic(input)
+	if err != nil {
+		log.Printf("CreateDialectic ERROR: %v", err)
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	log.Printf("CreateDialectic response: %+v", response)
+
+	protoResponse := &pb.CreateDialecticResponse{
+		Dialectic: response.Dialectic.ToProto(),
+	}
+	log.Printf("CreateDialectic proto response: %+v", protoResponse)
+
+	return connect.NewResponse(protoResponse), nil
+}
+
+func (s *Server) ListDialectics(
+	ctx context.Context,
+	req *connect.Request[pb.ListDialecticsRequest],
+) (*connect.Response[pb.ListDialecticsResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("ListDialectics called with request:", req.Msg)
+
+	response, err := s.dsvc.WithContext(ctx).ListDialectics(&svcmodels.ListDialecticsInput{
+		SelfModelID: req.Msg.SelfModelId,
+		Cohort:      req.Msg.Cohort,
+		DeveloperID: requestDeveloperID(ctx, s.developerSvc, req),
+	})
+
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	if response == nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("unexpected nil response"))
+	}
+
+	var dialecticPbs []*models.Dialectic
+	for _, dialectic := range response.Dialectics {
+		dialecticPbs = append(dialecticPbs, dialectic.ToProto())
+	}
+
+	return connect.NewResponse(&pb.ListDialecticsResponse{
+		Dialectics: dialecticPbs,
+	}), nil
+}
+
+// GetDialectic returns a dialectic with its interactions, their annotations and eval scores.
+func (s *Server) GetDialectic(
+	ctx context.Context,
+	req *connect.Request[pb.GetDialecticRequest],
+) (*connect.Response[pb.GetDialecticResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.dsvc.WithContext(ctx).GetDialectic(&svcmodels.GetDialecticInput{
+		ID:          req.Msg.Id,
+		SelfModelID: req.Msg.SelfModelId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.GetDialecticResponse{
+		Dialectic: response.Dialectic.ToProto(),
+	}), nil
+}
+
+func (s *Server) GetPerspectives(
+	ctx context.Context,
+	req *connect.Request[pb.GetPerspectivesRequest],
+) (*connect.Response[pb.GetPerspectivesResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.dsvc.WithContext(ctx).GetPerspectives(&svcmodels.GetPerspectivesInput{
+		SelfModelIDs: req.Msg.SelfModelIds,
+		Question:     req.Msg.Question,
+		Answer:       req.Msg.Answer,
+	})
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	var perspectivePbs []*models.Perspective
+	for _, perspective := range response.Perspectives {
+		perspectivePbs = append(perspectivePbs, perspective.ToProto())
+	}
+
+	return connect.NewResponse(&pb.GetPerspectivesResponse{
+		Perspectives: perspectivePbs,
+		Errors:       response.Errors,
+	}), nil
+}
+
+func (s *Server) DeleteDialectic(
+	ctx context.Context,
+	req *connect.Request[pb.DeleteDialecticRequest],
+) (*connect.Response[pb.DeleteDialecticResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.dsvc.WithContext(ctx).DeleteDialectic(&svcmodels.DeleteDialecticInput{
+		ID:          req.Msg.Id,
+		SelfModelID: req.Msg.SelfModelId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.DeleteDialecticResponse{
+		Id: response.DialecticID,
+	}), nil
+}
+
+func (s *Server) EditDialecticAnswer(
+	ctx context.Context,
+	req *connect.Request[pb.EditDialecticAnswerRequest],
+) (*connect.Response[pb.EditDialecticAnswerResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.dsvc.WithContext(ctx).EditAnswer(&svcmodels.EditAnswerInput{
+		DialecticID:   req.Msg.DialecticId,
+		SelfModelID:   req.Msg.SelfModelId,
+		InteractionID: req.Msg.InteractionId,
+		Answer:        req.Msg.Answer,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	extractedBeliefs := make([]*models.Belief, 0, len(response.ExtractedBeliefs))
+	for _, belief := range response.ExtractedBeliefs {
+		extractedBeliefs = append(extractedBeliefs, belief.ToProto())
+	}
+	return connect.NewResponse(&pb.EditDialecticAnswerResponse{
+		Dialectic:        response.Dialectic.ToProto(),
+		RemovedBeliefIds: response.RemovedBeliefIDs,
+		ExtractedBeliefs: extractedBeliefs,
+	}), nil
+}
+
+// AnnotateInteraction adds a curator's note and quality rating to a dialectic interaction.
+func (s *Server) AnnotateInteraction(
+	ctx context.Context,
+	req *connect.Request[pb.AnnotateInteractionRequest],
+) (*connect.Response[pb.AnnotateInteractionResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
 	}
 
-	// Verify the API key format (should be a UUID)
-	if _, err := uuid.Parse(apiKey); err != nil {
-		return nil, connect.NewError(connect.CodeUnauthenticated, fmt.Errorf("invalid API key format"))
+	response, err := s.dsvc.WithContext(ctx).AnnotateInteraction(&svcmodels.AnnotateInteractionInput{
+		DialecticID:   req.Msg.DialecticId,
+		SelfModelID:   req.Msg.SelfModelId,
+		InteractionID: req.Msg.InteractionId,
+		AnnotatorID:   req.Msg.AnnotatorId,
+		Note:          req.Msg.Note,
+		QualityScore:  req.Msg.QualityScore,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
 	}
 
-	// TODO: Add actual API key validation against the database
-	// For now, we'll consider any well-formed UUID as valid
-	// This should be replaced with actual validation logic
-
-	return ctx, nil
+	return connect.NewResponse(&pb.AnnotateInteractionResponse{
+		Annotation:  response.Annotation.ToProto(),
+		Annotations: svcmodels.InteractionAnnotationsToProto(response.Annotations),
+	}), nil
 }
 
-// Update the CreateBelief method to handle evidence
-func (s *Server) CreateBelief(
+// IngestEvalScores attaches the scores of evals run outside the server, such as on an
+// ExportDialecticForEval dataset, to the dialectic's interactions.
+func (s *Server) IngestEvalScores(
 	ctx context.Context,
-	req *connect.Request[pb.CreateBeliefRequest],
-) (*connect.Response[pb.CreateBeliefResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	req *connect.Request[pb.IngestEvalScoresRequest],
+) (*connect.Response[pb.IngestEvalScoresResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Printf("CreateBelief called with request: %+v", req.Msg)
+	scores := make([]svcmodels.InteractionEvalScore, 0, len(req.Msg.Scores))
+	for _, score := range req.Msg.Scores {
+		scores = append(scores, svcmodels.InteractionEvalScore{
+			InteractionID: score.InteractionId,
+			Metric:        score.Metric,
+			Score:         score.Score,
+			Passed:        score.Passed,
+		})
+	}
+	response, err := s.dsvc.WithContext(ctx).IngestEvalScores(&svcmodels.IngestEvalScoresInput{
+		DialecticID: req.Msg.DialecticId,
+		SelfModelID: req.Msg.SelfModelId,
+		Scores:      scores,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
 
-	// Convert protobuf belief type to internal type
-	beliefType, err := svcmodels.BeliefTypeFromProto(req.Msg.BeliefType)
+	return connect.NewResponse(&pb.IngestEvalScoresResponse{
+		Dialectic: response.Dialectic.ToProto(),
+	}), nil
+}
+
+// ListInteractionAnnotations returns the annotations of a dialectic interaction, oldest first.
+func (s *Server) ListInteractionAnnotations(
+	ctx context.Context,
+	req *connect.Request[pb.ListInteractionAnnotationsRequest],
+) (*connect.Response[pb.ListInteractionAnnotationsResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		return nil, err
 	}
 
-	input := &svcmodels.CreateBeliefInput{
+	response, err := s.dsvc.WithContext(ctx).ListInteractionAnnotations(&svcmodels.ListInteractionAnnotationsInput{
+		DialecticID:   req.Msg.DialecticId,
 		SelfModelID:   req.Msg.SelfModelId,
-		BeliefContent: req.Msg.BeliefContent,
-		BeliefType:    beliefType,
+		InteractionID: req.Msg.InteractionId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
 	}
 
-	// Handle evidence if provided
-	switch evidence := req.Msg.Evidence.(type) {
-	case *pb.CreateBeliefRequest_HypothesisEvidence:
-		input.BeliefEvidence = &svcmodels.BeliefEvidence{
-			Type:      svcmodels.EvidenceTypeHypothesis,
-			Content:   evidence.HypothesisEvidence.Evidence,
-			IsCounter: evidence.HypothesisEvidence.IsCounterfactual,
-		}
-	case *pb.CreateBeliefRequest_ActionOutcome:
-		input.BeliefEvidence = &svcmodels.BeliefEvidence{
-			Type:    svcmodels.EvidenceTypeAction,
-			Action:  evidence.ActionOutcome.Action,
-			Outcome: evidence.ActionOutcome.Outcome,
-		}
-	}
+	return connect.NewResponse(&pb.ListInteractionAnnotationsResponse{
+		Annotations: svcmodels.InteractionAnnotationsToProto(response.Annotations),
+	}), nil
+}
 
-	response, err := s.bsvc.CreateBelief(input)
+// ExportDialecticForEval returns a dialectic as a JSON DeepEval dataset, one test case per answered
+// interaction.
+func (s *Server) ExportDialecticForEval(
+	ctx context.Context,
+	req *connect.Request[pb.ExportDialecticForEvalRequest],
+) (*connect.Response[pb.ExportDialecticForEvalResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
-		log.Printf("CreateBelief ERROR: %v", err)
 		return nil, err
 	}
 
-	return connect.NewResponse(&pb.CreateBeliefResponse{
-		Belief:       response.Belief.ToProto(),
-		BeliefSystem: response.BeliefSystem.ToProto(),
+	response, err := s.dsvc.WithContext(ctx).ExportDialecticForEval(&svcmodels.ExportDialecticForEvalInput{
+		DialecticID: req.Msg.DialecticId,
+		SelfModelID: req.Msg.SelfModelId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	document, err := json.Marshal(response.Export)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to encode eval export: %w", err))
+	}
+	return connect.NewResponse(&pb.ExportDialecticForEvalResponse{
+		Document:      document,
+		TestCaseCount: int32(len(response.Export.TestCases)),
 	}), nil
 }
 
-func (s *Server) ListBeliefs(
+// ReprocessDialectic extracts the beliefs of a dialectic's answers again with the current prompts,
+// replacing those extracted before.
+func (s *Server) ReprocessDialectic(
 	ctx context.Context,
-	req *connect.Request[pb.ListBeliefsRequest],
-) (*connect.Response[pb.ListBeliefsResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	req *connect.Request[pb.ReprocessDialecticRequest],
+) (*connect.Response[pb.ReprocessDialecticResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("ListBeliefs called with request:", req.Msg)
-
-	response, err := s.bsvc.ListBeliefs(&svcmodels.ListBeliefsInput{
+	response, err := s.dsvc.WithContext(ctx).ReprocessDialectic(&svcmodels.ReprocessDialecticInput{
+		DialecticID: req.Msg.DialecticId,
 		SelfModelID: req.Msg.SelfModelId,
-		BeliefIDs:   req.Msg.BeliefIds,
+		DryRun:      req.Msg.DryRun,
 	})
-
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, connect.NewError(storeErrorCode(err), err)
 	}
 
-	if response == nil {
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("unexpected nil response"))
+	extractedBeliefs := make([]*models.Belief, 0, len(response.ExtractedBeliefs))
+	for _, belief := range response.ExtractedBeliefs {
+		extractedBeliefs = append(extractedBeliefs, belief.ToProto())
 	}
+	return connect.NewResponse(&pb.ReprocessDialecticResponse{
+		Dialectic:        response.Dialectic.ToProto(),
+		RemovedBeliefIds: response.RemovedBeliefIDs,
+		ExtractedBeliefs: extractedBeliefs,
+		DryRun:           response.DryRun,
+	}), nil
+}
 
-	var beliefPbs []*models.Belief
-	for _, belief := range response.Beliefs {
-		beliefPbs = append(beliefPbs, belief.ToProto())
+func (s *Server) ListInteractions(
+	ctx context.Context,
+	req *connect.Request[pb.ListInteractionsRequest],
+) (*connect.Response[pb.ListInteractionsResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
 	}
 
-	protoResponse := &pb.ListBeliefsResponse{
-		Beliefs:      beliefPbs,
-		BeliefSystem: response.BeliefSystem.ToProto(),
+	response, err := s.dsvc.WithContext(ctx).ListInteractions(&svcmodels.ListInteractionsInput{
+		DialecticID: req.Msg.DialecticId,
+		SelfModelID: req.Msg.SelfModelId,
+		Status:      svcmodels.DialecticalInteractionStatus(req.Msg.Status),
+		Type:        svcmodels.InteractionType(req.Msg.Type),
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
 	}
 
-	return connect.NewResponse(protoResponse), nil
+	interactions := make([]*models.InteractionSummary, 0, len(response.Interactions))
+	for _, interaction := range response.Interactions {
+		interactions = append(interactions, interaction.ToProto())
+	}
+	return connect.NewResponse(&pb.ListInteractionsResponse{
+		Interactions: interactions,
+	}), nil
 }
 
-func (s *Server) CreateDialectic(ctx context.Context, req *connect.Request[pb.CreateDialecticRequest]) (*connect.Response[pb.CreateDialecticResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+func (s *Server) GetInteractionStatus(
+	ctx context.Context,
+	req *connect.Request[pb.GetInteractionStatusRequest],
+) (*connect.Response[pb.GetInteractionStatusResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Printf("CreateDialectic called with request: %+v", req.Msg)
+	response, err := s.dsvc.WithContext(ctx).GetInteractionStatus(&svcmodels.GetInteractionStatusInput{
+		DialecticID:   req.Msg.DialecticId,
+		SelfModelID:   req.Msg.SelfModelId,
+		InteractionID: req.Msg.InteractionId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
 
-	input := &svcmodels.CreateDialecticInput{
-		SelfModelID:       req.Msg.SelfModelId,
-		DialecticType:     svcmodels.DialecticType(req.Msg.DialecticType),
-		LearningObjective: svcmodels.LearningObjectiveFromProto(req.Msg.LearningObjective),
+	extractedBeliefs := make([]*models.Belief, 0, len(response.ExtractedBeliefs))
+	for _, belief := range response.ExtractedBeliefs {
+		extractedBeliefs = append(extractedBeliefs, belief.ToProto())
 	}
-	log.Printf("CreateDialectic input: %+v", input)
+	return connect.NewResponse(&pb.GetInteractionStatusResponse{
+		Status:           models.STATUS(response.Status),
+		ExtractedBeliefs: extractedBeliefs,
+		ProcessingError:  response.ProcessingError,
+	}), nil
+}
 
-	response, err := s.dsvc.CreateDialectic(input)
+func (s *Server) PreviewNextQuestion(
+	ctx context.Context,
+	req *connect.Request[pb.PreviewNextQuestionRequest],
+) (*connect.Response[pb.PreviewNextQuestionResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
-		log.Printf("CreateDialectic ERROR: %v", err)
 		return nil, err
 	}
 
-	log.Printf("CreateDialectic response: %+v", response)
-
-	protoResponse := &pb.CreateDialecticResponse{
-		Dialectic: response.Dialectic.ToProto(),
+	response, err := s.dsvc.WithContext(ctx).PreviewNextQuestion(&svcmodels.PreviewNextQuestionInput{
+		DialecticID: req.Msg.DialecticId,
+		SelfModelID: req.Msg.SelfModelId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
 	}
-	log.Printf("CreateDialectic proto response: %+v", protoResponse)
 
-	return connect.NewResponse(protoResponse), nil
+	return connect.NewResponse(&pb.PreviewNextQuestionResponse{
+		Question: response.Question,
+	}), nil
 }
 
-func (s *Server) ListDialectics(
+// BulkAnswer answers several pending questions of a dialectic at once, such as the user's turns of
+// an imported transcript.
+func (s *Server) BulkAnswer(
 	ctx context.Context,
-	req *connect.Request[pb.ListDialecticsRequest],
-) (*connect.Response[pb.ListDialecticsResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	req *connect.Request[pb.BulkAnswerRequest],
+) (*connect.Response[pb.BulkAnswerResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("ListDialectics called with request:", req.Msg)
-
-	response, err := s.dsvc.ListDialectics(&svcmodels.ListDialecticsInput{
+	response, err := s.dsvc.WithContext(ctx).BulkAnswer(&svcmodels.BulkAnswerInput{
+		DialecticID: req.Msg.DialecticId,
 		SelfModelID: req.Msg.SelfModelId,
+		Answers:     req.Msg.Answers,
+		Mode:        svcmodels.BatchMode(req.Msg.Mode),
 	})
-
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, connect.NewError(storeErrorCode(err), err)
 	}
 
-	if response == nil {
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("unexpected nil response"))
+	return connect.NewResponse(&pb.BulkAnswerResponse{
+		Dialectic:              response.Dialectic.ToProto(),
+		AnsweredInteractionIds: response.AnsweredInteractionIDs,
+		Results:                response.Result.ToProto(),
+	}), nil
+}
+
+func (s *Server) RunSyntheticDialectic(
+	ctx context.Context,
+	req *connect.Request[pb.RunSyntheticDialecticRequest],
+) (*connect.Response[pb.RunSyntheticDialecticResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
 	}
 
-	var dialecticPbs []*models.Dialectic
-	for _, dialectic := range response.Dialectics {
-		dialecticPbs = append(dialecticPbs, dialectic.ToProto())
+	response, err := s.dsvc.WithContext(ctx).RunSyntheticDialectic(&svcmodels.RunSyntheticDialecticInput{
+		SelfModelID:       req.Msg.SelfModelId,
+		DialecticType:     svcmodels.DialecticType(req.Msg.DialecticType),
+		LearningObjective: svcmodels.LearningObjectiveFromProto(req.Msg.LearningObjective),
+		MaxTurns:          req.Msg.MaxTurns,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
 	}
 
-	return connect.NewResponse(&pb.ListDialecticsResponse{
-		Dialectics: dialecticPbs,
+	return connect.NewResponse(&pb.RunSyntheticDialecticResponse{
+		Dialectic: response.Dialectic.ToProto(),
+		Turns:     int32(response.Turns),
+		Completed: response.Completed,
 	}), nil
 }
 
@@ -229,7 +1197,7 @@ func (s *Server) UpdateDialectic(
 	ctx context.Context,
 	req *connect.Request[pb.UpdateDialecticRequest],
 ) (*connect.Response[pb.UpdateDialecticResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
@@ -242,6 +1210,11 @@ func (s *Server) UpdateDialectic(
 		DryRun:       req.Msg.DryRun,
 		QuestionBlob: req.Msg.QuestionBlob,
 		AnswerBlob:   req.Msg.AnswerBlob,
+
+		PruneInvalidatedBeliefs: req.Msg.PruneInvalidatedBeliefs,
+		StrictPerspectives:      req.Msg.StrictPerspectives,
+		ComputeSurprise:         req.Msg.ComputeSurprise,
+		Background:              req.Msg.Background,
 	}
 
 	// Set Answer if provided
@@ -258,9 +1231,9 @@ func (s *Server) UpdateDialectic(
 		input.CustomQuestion = &customQ
 	}
 
-	response, err := s.dsvc.UpdateDialectic(input)
+	response, err := s.dsvc.WithContext(ctx).UpdateDialectic(input)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, connect.NewError(storeErrorCode(err), err)
 	}
 
 	if response == nil {
@@ -268,7 +1241,195 @@ func (s *Server) UpdateDialectic(
 	}
 
 	return connect.NewResponse(&pb.UpdateDialecticResponse{
-		Dialectic: response.Dialectic.ToProto(),
+		Dialectic:         response.Dialectic.ToProto(),
+		PerspectiveErrors: response.PerspectiveErrors,
+	}), nil
+}
+
+func (s *Server) GetLearningObjectiveProgress(
+	ctx context.Context,
+	req *connect.Request[pb.GetLearningObjectiveProgressRequest],
+) (*connect.Response[pb.GetLearningObjectiveProgressResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("GetLearningObjectiveProgress called with request:", req.Msg)
+
+	response, err := s.dsvc.WithContext(ctx).GetLearningObjectiveProgress(&svcmodels.GetLearningObjectiveProgressInput{
+		DialecticID: req.Msg.DialecticId,
+		SelfModelID: req.Msg.SelfModelId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.GetLearningObjectiveProgressResponse{
+		LearningObjective: response.LearningObjective.ToProto(),
+		Progress:          response.Progress.ToProto(),
+	}), nil
+}
+
+func (s *Server) GetDialecticMetrics(
+	ctx context.Context,
+	req *connect.Request[pb.GetDialecticMetricsRequest],
+) (*connect.Response[pb.GetDialecticMetricsResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.dsvc.WithContext(ctx).ComputeDialecticMetrics(&svcmodels.ComputeDialecticMetricsInput{
+		DialecticID: req.Msg.DialecticId,
+		SelfModelID: req.Msg.SelfModelId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.GetDialecticMetricsResponse{
+		Metrics: response.Metrics.ToProto(),
+	}), nil
+}
+
+func (s *Server) ListObservationContexts(
+	ctx context.Context,
+	req *connect.Request[pb.ListObservationContextsRequest],
+) (*connect.Response[pb.ListObservationContextsResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).ListObservationContexts(&svcmodels.ListObservationContextsInput{
+		SelfModelID: req.Msg.SelfModelId,
+		RootsOnly:   req.Msg.RootsOnly,
+		AsTree:      req.Msg.AsTree,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	observationContexts := make([]*models.ObservationContext, 0, len(response.ObservationContexts))
+	for _, oc := range response.ObservationContexts {
+		observationContexts = append(observationContexts, oc.ToProto())
+	}
+	return connect.NewResponse(&pb.ListObservationContextsResponse{
+		ObservationContexts: observationContexts,
+		Tree:                response.Tree.ToProto(),
+	}), nil
+}
+
+func (s *Server) GetEpistemicContext(
+	ctx context.Context,
+	req *connect.Request[pb.GetEpistemicContextRequest],
+) (*connect.Response[pb.GetEpistemicContextResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).GetEpistemicContext(&svcmodels.GetEpistemicContextInput{
+		SelfModelID: req.Msg.SelfModelId,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	epistemicContexts := make([]*models.EpistemicContext, 0, len(response.EpistemicContexts))
+	for _, ec := range response.EpistemicContexts {
+		if proto := ec.ToProto(); proto != nil {
+			epistemicContexts = append(epistemicContexts, proto)
+		}
+	}
+	beliefLinks := make([]*models.BeliefContextLink, 0, len(response.BeliefLinks))
+	for _, link := range response.BeliefLinks {
+		beliefLinks = append(beliefLinks, link.ToProto())
+	}
+	return connect.NewResponse(&pb.GetEpistemicContextResponse{
+		EpistemicContexts: epistemicContexts,
+		BeliefLinks:       beliefLinks,
+	}), nil
+}
+
+func (s *Server) CreateObservationContext(
+	ctx context.Context,
+	req *connect.Request[pb.CreateObservationContextRequest],
+) (*connect.Response[pb.CreateObservationContextResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Msg.Name == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("observation context name is required"))
+	}
+
+	response, err := s.bsvc.WithContext(ctx).CreateObservationContext(&svcmodels.CreateObservationContextInput{
+		SelfModelID:    req.Msg.SelfModelId,
+		ID:             req.Msg.Id,
+		Name:           req.Msg.Name,
+		ParentID:       req.Msg.ParentId,
+		PossibleStates: req.Msg.PossibleStates,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.CreateObservationContextResponse{
+		ObservationContext: response.ObservationContext.ToProto(),
+	}), nil
+}
+
+func (s *Server) UpdateObservationContext(
+	ctx context.Context,
+	req *connect.Request[pb.UpdateObservationContextRequest],
+) (*connect.Response[pb.UpdateObservationContextResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).UpdateObservationContext(&svcmodels.UpdateObservationContextInput{
+		SelfModelID:    req.Msg.SelfModelId,
+		ID:             req.Msg.Id,
+		Name:           req.Msg.Name,
+		ParentID:       req.Msg.ParentId,
+		PossibleStates: req.Msg.PossibleStates,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.UpdateObservationContextResponse{
+		ObservationContext: response.ObservationContext.ToProto(),
+	}), nil
+}
+
+func (s *Server) DeleteObservationContext(
+	ctx context.Context,
+	req *connect.Request[pb.DeleteObservationContextRequest],
+) (*connect.Response[pb.DeleteObservationContextResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.bsvc.WithContext(ctx).DeleteObservationContext(&svcmodels.DeleteObservationContextInput{
+		SelfModelID:      req.Msg.SelfModelId,
+		ID:               req.Msg.Id,
+		ReparentChildren: req.Msg.ReparentChildren,
+		Force:            req.Msg.Force,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.DeleteObservationContextResponse{
+		ObservationContext:         response.ObservationContext.ToProto(),
+		ChildIds:                   response.ChildIDs,
+		RemovedBeliefContextsCount: response.RemovedBeliefContextsCount,
 	}), nil
 }
 
@@ -277,22 +1438,39 @@ func (s *Server) GetBeliefSystem(
 	ctx context.Context,
 	req *connect.Request[pb.GetBeliefSystemRequest],
 ) (*connect.Response[pb.GetBeliefSystemResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
 
 	log.Printf("GetBeliefSystem called with request: %+v", req.Msg)
 
-	beliefSystem, err := s.bsvc.GetBeliefSystem(req.Msg.SelfModelId)
+	beliefSystem, err := s.bsvc.WithContext(ctx).GetBeliefSystemInContexts(req.Msg.SelfModelId, req.Msg.ObservationContextIds)
 	if err != nil {
 		log.Printf("GetBeliefSystem ERROR: %v", err)
-		return nil, err
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	// A client polling with the token of the belief system it holds gets no belief system back
+	// while nothing has changed. The token is that of the stored belief system, so an unchanged one
+	// is answered before it is conceptualized or measured.
+	etag, err := svc.BeliefSystemETag(beliefSystem)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	ifNoneMatch := req.Msg.IfChangedSince
+	if ifNoneMatch == "" {
+		ifNoneMatch = strings.Trim(req.Header().Get("If-None-Match"), `"`)
+	}
+	if ifNoneMatch != "" && ifNoneMatch == etag {
+		response := connect.NewResponse(&pb.GetBeliefSystemResponse{NotModified: true, Etag: etag})
+		response.Header().Set("ETag", etag)
+		return response, nil
 	}
 
 	// Generate conceptualization if requested
 	if req.Msg.Conceptualize {
-		err = s.bsvc.ConceptualizeBeliefSystem(beliefSystem)
+		err = s.bsvc.WithContext(ctx).ConceptualizeBeliefSystem(beliefSystem)
 		if err != nil {
 			log.Printf("ConceptualizeBeliefSystem ERROR: %v", err)
 			return nil, err
@@ -301,16 +1479,19 @@ func (s *Server) GetBeliefSystem(
 
 	// Include metrics if requested
 	if req.Msg.IncludeMetrics {
-		err = s.bsvc.ComputeMetrics(beliefSystem)
+		err = s.bsvc.WithContext(ctx).ComputeMetrics(beliefSystem)
 		if err != nil {
 			log.Printf("ComputeMetrics ERROR: %v", err)
 			return nil, err
 		}
 	}
 
-	return connect.NewResponse(&pb.GetBeliefSystemResponse{
+	response := connect.NewResponse(&pb.GetBeliefSystemResponse{
 		BeliefSystem: beliefSystem.ToProto(),
-	}), nil
+		Etag:         etag,
+	})
+	response.Header().Set("ETag", etag)
+	return response, nil
 }
 
 // Add this method to your server type
@@ -321,7 +1502,7 @@ func (s *Server) UpdateKeyValueStore(ctx context.Context, req *connect.Request[p
 }
 
 func (s *Server) CreateSelfModel(ctx context.Context, req *connect.Request[pb.CreateSelfModelRequest]) (*connect.Response[pb.CreateSelfModelResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
@@ -340,7 +1521,7 @@ func (s *Server) CreateSelfModel(ctx context.Context, req *connect.Request[pb.Cr
 }
 
 func (s *Server) GetSelfModel(ctx context.Context, req *connect.Request[pb.GetSelfModelRequest]) (*connect.Response[pb.GetSelfModelResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
@@ -350,15 +1531,45 @@ func (s *Server) GetSelfModel(ctx context.Context, req *connect.Request[pb.GetSe
 	}
 	resp, err := s.selfModelSvc.GetSelfModel(ctx, input)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, connect.NewError(storeErrorCode(err), err)
 	}
 	return connect.NewResponse(&pb.GetSelfModelResponse{
 		SelfModel: resp.SelfModel.ToProto(),
 	}), nil
 }
 
+func (s *Server) GetSelfModelDetail(ctx context.Context, req *connect.Request[pb.GetSelfModelDetailRequest]) (*connect.Response[pb.GetSelfModelDetailResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.selfModelSvc.GetSelfModelDetail(ctx, &svcmodels.GetSelfModelDetailInput{
+		SelfModelID:         req.Msg.SelfModelId,
+		ExcludeBeliefSystem: req.Msg.ExcludeBeliefSystem,
+		ExcludePhilosophies: req.Msg.ExcludePhilosophies,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	response := &pb.GetSelfModelDetailResponse{
+		SelfModel:            resp.SelfModel.ToProto(),
+		Philosophies:         make([]*models.Philosophy, 0, len(resp.Philosophies)),
+		DialecticCount:       resp.DialecticCount,
+		ActiveDialecticCount: resp.ActiveDialecticCount,
+	}
+	if resp.BeliefSystem != nil {
+		response.BeliefSystem = resp.BeliefSystem.ToProto()
+	}
+	for _, philosophy := range resp.Philosophies {
+		response.Philosophies = append(response.Philosophies, philosophy.ToProto())
+	}
+	return connect.NewResponse(response), nil
+}
+
 func (s *Server) AddPhilosophy(ctx context.Context, req *connect.Request[pb.AddPhilosophyRequest]) (*connect.Response[pb.AddPhilosophyResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
@@ -376,8 +1587,27 @@ func (s *Server) AddPhilosophy(ctx context.Context, req *connect.Request[pb.AddP
 	}), nil
 }
 
+func (s *Server) SetCohort(ctx context.Context, req *connect.Request[pb.SetCohortRequest]) (*connect.Response[pb.SetCohortResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.selfModelSvc.SetCohort(ctx, &svcmodels.SetCohortInput{
+		SelfModelID: req.Msg.SelfModelId,
+		Cohort:      req.Msg.Cohort,
+		DeveloperID: requestDeveloperID(ctx, s.developerSvc, req),
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+	return connect.NewResponse(&pb.SetCohortResponse{
+		SelfModel: resp.SelfModel.ToProto(),
+	}), nil
+}
+
 func (s *Server) CreatePhilosophy(ctx context.Context, req *connect.Request[pb.CreatePhilosophyRequest]) (*connect.Response[pb.CreatePhilosophyResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
@@ -399,6 +1629,19 @@ func (s *Server) CreatePhilosophy(ctx context.Context, req *connect.Request[pb.C
 	return connect.NewResponse(&pb.CreatePhilosophyResponse{
 		Philosophy:                      resp.Philosophy.ToProto(),
 		ExtrapolatedObservationContexts: protoContexts,
+		Warnings:                        resp.Warnings,
+	}), nil
+}
+
+func (s *Server) HealthCheck(ctx context.Context, req *connect.Request[pb.HealthCheckRequest]) (*connect.Response[pb.HealthCheckResponse], error) {
+	// Health checks are made by load balancers, which carry no API key
+	report := s.health.check(ctx)
+	return connect.NewResponse(&pb.HealthCheckResponse{
+		Status:              report.Status,
+		StoreReachable:      report.StoreReachable,
+		StoreError:          report.StoreError,
+		OpenaiKeyConfigured: report.OpenAIKeyConfigured,
+		AiProvider:          report.AIProvider,
 	}), nil
 }
 
@@ -411,7 +1654,7 @@ func (s *Server) CreateDeveloper(ctx context.Context, req *connect.Request[pb.Cr
 
 	response, err := s.developerSvc.CreateDeveloper(input)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, connect.NewError(storeErrorCode(err), err)
 	}
 
 	// The response should already include the API key from the service
@@ -423,7 +1666,7 @@ func (s *Server) CreateDeveloper(ctx context.Context, req *connect.Request[pb.Cr
 }
 
 func (s *Server) CreateUser(ctx context.Context, req *connect.Request[pb.CreateUserRequest]) (*connect.Response[pb.CreateUserResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
@@ -439,7 +1682,7 @@ func (s *Server) CreateUser(ctx context.Context, req *connect.Request[pb.CreateU
 	response, err := s.userSvc.CreateUser(input)
 	if err != nil {
 		log.Printf("CreateUser ERROR: %v", err)
-		return nil, err
+		return nil, connect.NewError(storeErrorCode(err), err)
 	}
 
 	protoResponse := &pb.CreateUserResponse{
@@ -449,12 +1692,110 @@ func (s *Server) CreateUser(ctx context.Context, req *connect.Request[pb.CreateU
 	return connect.NewResponse(protoResponse), nil
 }
 
+func (s *Server) ListUsers(ctx context.Context, req *connect.Request[pb.ListUsersRequest]) (*connect.Response[pb.ListUsersResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireDeveloper(ctx, s.developerSvc, req, req.Msg.DeveloperId); err != nil {
+		return nil, err
+	}
+
+	response, err := s.userSvc.ListUsers(&svcmodels.ListUsersInput{
+		DeveloperID: req.Msg.DeveloperId,
+		PageSize:    int(req.Msg.PageSize),
+		PageToken:   req.Msg.PageToken,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	users := make([]*models.User, 0, len(response.Users))
+	for _, user := range response.Users {
+		users = append(users, user.ToProto())
+	}
+	return connect.NewResponse(&pb.ListUsersResponse{
+		Users:         users,
+		NextPageToken: response.NextPageToken,
+	}), nil
+}
+
+func (s *Server) RotateAPIKey(ctx context.Context, req *connect.Request[pb.RotateAPIKeyRequest]) (*connect.Response[pb.RotateAPIKeyResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireDeveloper(ctx, s.developerSvc, req, req.Msg.DeveloperId); err != nil {
+		return nil, err
+	}
+
+	response, err := s.developerSvc.RotateAPIKey(&svcmodels.RotateAPIKeyInput{DeveloperID: req.Msg.DeveloperId})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+	return connect.NewResponse(&pb.RotateAPIKeyResponse{
+		ApiKey:    response.APIKey,
+		Developer: response.Developer.ToProto(),
+	}), nil
+}
+
+// SetDeveloperOpenAIKey stores the OpenAI key the developer's requests make their AI calls with, or
+// removes it when the key is empty.
+func (s *Server) SetDeveloperOpenAIKey(ctx context.Context, req *connect.Request[pb.SetDeveloperOpenAIKeyRequest]) (*connect.Response[pb.SetDeveloperOpenAIKeyResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireDeveloper(ctx, s.developerSvc, req, req.Msg.DeveloperId); err != nil {
+		return nil, err
+	}
+
+	response, err := s.developerSvc.SetOpenAIKey(&svcmodels.SetOpenAIKeyInput{
+		DeveloperID:  req.Msg.DeveloperId,
+		OpenAIAPIKey: req.Msg.OpenaiApiKey,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+	return connect.NewResponse(&pb.SetDeveloperOpenAIKeyResponse{
+		Developer: response.Developer.ToProto(),
+	}), nil
+}
+
+func (s *Server) RevokeAPIKey(ctx context.Context, req *connect.Request[pb.RevokeAPIKeyRequest]) (*connect.Response[pb.RevokeAPIKeyResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// A developer may only revoke its own keys
+	developer, err := s.developerSvc.GetDeveloperByAPIKey(requestAPIKey(ctx, req))
+	if err != nil {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("API key does not belong to a developer"))
+	}
+
+	response, err := s.developerSvc.RevokeAPIKey(&svcmodels.RevokeAPIKeyInput{
+		DeveloperID: developer.ID,
+		APIKey:      req.Msg.ApiKey,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+	return connect.NewResponse(&pb.RevokeAPIKeyResponse{
+		Developer: response.Developer.ToProto(),
+	}), nil
+}
+
 func (s *Server) GetDeveloper(ctx context.Context, req *connect.Request[pb.GetDeveloperRequest]) (*connect.Response[pb.GetDeveloperResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := requireDeveloper(ctx, s.developerSvc, req, req.Msg.Id); err != nil {
+		return nil, err
+	}
+
 	log.Printf("GetDeveloper called with request: %+v", req.Msg)
 
 	input := &svcmodels.GetDeveloperInput{
@@ -467,21 +1808,45 @@ func (s *Server) GetDeveloper(ctx context.Context, req *connect.Request[pb.GetDe
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
+	// The keys were handed out when they were issued; only their prefixes are sent back
 	protoResponse := &pb.GetDeveloperResponse{
-		Developer: response.ToProto(),
+		Developer: response.ToRedactedProto(),
 	}
 
 	return connect.NewResponse(protoResponse), nil
 }
 
+func (s *Server) RegisterWebhook(ctx context.Context, req *connect.Request[pb.RegisterWebhookRequest]) (*connect.Response[pb.RegisterWebhookResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireDeveloper(ctx, s.developerSvc, req, req.Msg.DeveloperId); err != nil {
+		return nil, err
+	}
+
+	response, err := s.developerSvc.RegisterWebhook(&svcmodels.RegisterWebhookInput{
+		DeveloperID: req.Msg.DeveloperId,
+		URL:         req.Msg.Url,
+	})
+	if err != nil {
+		logging.Errorf(ctx, "RegisterWebhook ERROR: %v", err)
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.RegisterWebhookResponse{
+		Webhook: response.Webhook.ToProto(),
+	}), nil
+}
+
 func (s *Server) PreprocessQuestionAnswer(ctx context.Context, req *connect.Request[pb.PreprocessQuestionAnswerRequest]) (*connect.Response[pb.PreprocessQuestionAnswerResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Use dialectic service method
-	result, err := s.dsvc.PreprocessQuestionAnswers(&svcmodels.PreprocessQuestionAnswerInput{
+	result, err := s.dsvc.WithContext(ctx).PreprocessQuestionAnswers(&svcmodels.PreprocessQuestionAnswerInput{
 		QuestionBlobs: req.Msg.QuestionBlobs,
 		AnswerBlobs:   req.Msg.AnswerBlobs,
 	})
@@ -493,8 +1858,9 @@ func (s *Server) PreprocessQuestionAnswer(ctx context.Context, req *connect.Requ
 	protoPairs := make([]*pb.QuestionAnswerPair, len(result.QAPairs))
 	for i, pair := range result.QAPairs {
 		protoPairs[i] = &pb.QuestionAnswerPair{
-			Question: pair.Question,
-			Answer:   pair.Answer,
+			Question:   pair.Question,
+			Answer:     pair.Answer,
+			Confidence: pair.Confidence,
 		}
 	}
 
@@ -503,8 +1869,144 @@ func (s *Server) PreprocessQuestionAnswer(ctx context.Context, req *connect.Requ
 	}), nil
 }
 
+// exportChunkSize is the largest slice of an export document sent in a single stream message
+const exportChunkSize = 64 * 1024
+
+func (s *Server) ExportSelfModel(
+	ctx context.Context,
+	req *connect.Request[pb.ExportSelfModelRequest],
+	stream *connect.ServerStream[pb.ExportSelfModelResponse],
+) error {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return err
+	}
+
+	log.Println("ExportSelfModel called with request:", req.Msg)
+
+	response, err := s.selfModelSvc.ExportSelfModel(ctx, &svcmodels.ExportSelfModelInput{
+		SelfModelID: req.Msg.SelfModelId,
+	})
+	if err != nil {
+		return connect.NewError(connect.CodeInternal, err)
+	}
+
+	document, err := json.Marshal(response.Export)
+	if err != nil {
+		return connect.NewError(connect.CodeInternal, fmt.Errorf("failed to encode export: %w", err))
+	}
+
+	// Stream the document in chunks so large self models don't need a single oversized message
+	for offset := 0; offset < len(document); offset += exportChunkSize {
+		end := min(offset+exportChunkSize, len(document))
+		err := stream.Send(&pb.ExportSelfModelResponse{
+			SchemaVersion: int32(response.Export.SchemaVersion),
+			Chunk:         document[offset:end],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) ImportSelfModel(
+	ctx context.Context,
+	req *connect.Request[pb.ImportSelfModelRequest],
+) (*connect.Response[pb.ImportSelfModelResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("ImportSelfModel called for self model %q with a %d byte document", req.Msg.SelfModelId, len(req.Msg.Document))
+
+	response, err := s.selfModelSvc.ImportSelfModel(ctx, &svcmodels.ImportSelfModelInput{
+		Document:    req.Msg.Document,
+		SelfModelID: req.Msg.SelfModelId,
+	})
+	if err != nil {
+		if errors.Is(err, svc.ErrIncompatibleExport) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&pb.ImportSelfModelResponse{
+		SelfModel: response.SelfModel.ToProto(),
+	}), nil
+}
+
+func (s *Server) GenerateAnswer(ctx context.Context, req *connect.Request[pb.GenerateAnswerRequest]) (*connect.Response[pb.GenerateAnswerResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.selfModelSvc.GenerateAnswer(ctx, &svcmodels.GenerateAnswerInput{
+		SelfModelID:            req.Msg.SelfModelId,
+		Question:               req.Msg.Question,
+		AdditionalPhilosophies: req.Msg.AdditionalPhilosophies,
+		OverridePhilosophies:   req.Msg.OverridePhilosophies,
+	})
+	if err != nil {
+		return nil, connect.NewError(storeErrorCode(err), err)
+	}
+
+	return connect.NewResponse(&pb.GenerateAnswerResponse{
+		Answer: resp.Answer,
+	}), nil
+}
+
+func (s *Server) GetPhilosophy(ctx context.Context, req *connect.Request[pb.GetPhilosophyRequest]) (*connect.Response[pb.GetPhilosophyResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.selfModelSvc.GetPhilosophy(ctx, &svcmodels.GetPhilosophyInput{
+		PhilosophyID: req.Msg.PhilosophyId,
+	})
+	if err != nil {
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	}
+
+	var protoContexts []*models.ObservationContext
+	for _, oc := range resp.ExtrapolatedObservationContexts {
+		protoContexts = append(protoContexts, oc.ToProto())
+	}
+
+	return connect.NewResponse(&pb.GetPhilosophyResponse{
+		Philosophy:                      resp.Philosophy.ToProto(),
+		ExtrapolatedObservationContexts: protoContexts,
+	}), nil
+}
+
+func (s *Server) ListPhilosophies(ctx context.Context, req *connect.Request[pb.ListPhilosophiesRequest]) (*connect.Response[pb.ListPhilosophiesResponse], error) {
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.selfModelSvc.ListPhilosophies(ctx, &svcmodels.ListPhilosophiesInput{
+		ExtrapolateContexts: req.Msg.ExtrapolateContexts,
+	})
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	var protoPhilosophies []*models.PhilosophySummary
+	for _, p := range resp.Philosophies {
+		protoPhilosophies = append(protoPhilosophies, p.ToProto())
+	}
+
+	return connect.NewResponse(&pb.ListPhilosophiesResponse{
+		Philosophies: protoPhilosophies,
+	}), nil
+}
+
 func (s *Server) UpdatePhilosophy(ctx context.Context, req *connect.Request[pb.UpdatePhilosophyRequest]) (*connect.Response[pb.UpdatePhilosophyResponse], error) {
-	ctx, err := validateAPIKey(ctx, req)
+	ctx, err := validateAPIKey(ctx, s.developerSvc, req)
 	if err != nil {
 		return nil, err
 	}
@@ -527,91 +2029,336 @@ func (s *Server) UpdatePhilosophy(ctx context.Context, req *connect.Request[pb.U
 	return connect.NewResponse(&pb.UpdatePhilosophyResponse{
 		Philosophy:                      resp.Philosophy.ToProto(),
 		ExtrapolatedObservationContexts: protoContexts,
+		Warnings:                        resp.Warnings,
 	}), nil
 }
 
-func NewServer(kvStore *db.KeyValueStore) *Server {
+// NewServer creates a server on kvStore configured from the environment. See NewServerWithOptions
+// to build one without reading the environment.
+func NewServer(kvStore db.KeyValueStore) (*Server, error) {
 	if kvStore == nil {
-		log.Fatal("KeyValueStore is nil in NewServer")
+		return nil, fmt.Errorf("KeyValueStore is nil in NewServer")
+	}
+	if os.Getenv("OPENAI_API_KEY") == "" {
+		return nil, fmt.Errorf("OPENAI_API_KEY environment variable is not set")
 	}
 
-	// Get OpenAI API key from environment
-	openAIKey := os.Getenv("OPENAI_API_KEY")
-	if openAIKey == "" {
-		log.Fatal("OPENAI_API_KEY environment variable is not set")
+	opts, err := ServerOptionsFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("invalid server options: %w", err)
 	}
+	opts.KVStore = kvStore
+	return NewServerWithOptions(opts)
+}
 
-	aih := ai.NewAIHelper(openAIKey)
-	bsvc := svc.NewBeliefService(kvStore, aih)
-	de := svc.NewDialecticEpistemology(bsvc, aih)
-	pe := svc.NewPerspectiveTakingEpistemology(bsvc, aih)
-	dsvc := svc.NewDialecticService(kvStore, aih, pe, de)
-	sms := svc.NewSelfModelService(kvStore, dsvc, bsvc)
+// Close stops the server's webhook deliveries. It is safe to call more than once.
+func (s *Server) Close() {
+	s.webhooks.Stop()
+}
 
-	// Get the workspace root directory
-	workspaceRoot, err := os.Getwd()
+// developerRateLimit returns the rate limit override stored on developerID, if any.
+func (s *Server) developerRateLimit(developerID string) *svcmodels.RateLimit {
+	developer, err := s.developerSvc.GetDeveloper(&svcmodels.GetDeveloperInput{ID: developerID})
 	if err != nil {
-		log.Printf("Failed to get workspace root: %v", err)
 		return nil
 	}
+	return developer.RateLimit
+}
 
-	// Use absolute path for philosophies directory
-	philosophiesPath := filepath.Join(workspaceRoot, "Philosophies", "philosophies")
-	if _, err := os.Stat(philosophiesPath); os.IsNotExist(err) {
-		log.Printf("Philosophies directory not found at %s: %v", philosophiesPath, err)
-		return nil
+// defaultSweepInterval is how often expired store entries are removed unless
+// KV_STORE_SWEEP_INTERVAL is set.
+const defaultSweepInterval = time.Minute
+
+func sweepInterval() time.Duration {
+	if value := os.Getenv("KV_STORE_SWEEP_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err == nil && interval > 0 {
+			return interval
+		}
+		log.Printf("Invalid KV_STORE_SWEEP_INTERVAL %q, using %v", value, defaultSweepInterval)
+	}
+	return defaultSweepInterval
+}
+
+// maxBeliefHistory is the number of superseded versions kept per belief, read from
+// BELIEF_HISTORY_MAX.
+func maxBeliefHistory() int {
+	if value := os.Getenv("BELIEF_HISTORY_MAX"); value != "" {
+		maxHistory, err := strconv.Atoi(value)
+		if err == nil && maxHistory > 0 {
+			return maxHistory
+		}
+		log.Printf("Invalid BELIEF_HISTORY_MAX %q, using %d", value, svc.DefaultMaxBeliefHistory)
+	}
+	return svc.DefaultMaxBeliefHistory
+}
+
+// maxContentLength is the most characters a belief's content or a dialectic answer may hold, read
+// from MAX_CONTENT_LENGTH.
+func maxContentLength() int {
+	if value := os.Getenv("MAX_CONTENT_LENGTH"); value != "" {
+		maxLength, err := strconv.Atoi(value)
+		if err == nil && maxLength > 0 {
+			return maxLength
+		}
+		log.Printf("Invalid MAX_CONTENT_LENGTH %q, using %d", value, svc.DefaultMaxContentLength)
+	}
+	return svc.DefaultMaxContentLength
+}
+
+// logLevel is the minimum level of request log lines, read from GOLOG_LOG_LEVEL.
+func logLevel() logging.Level {
+	value := os.Getenv("GOLOG_LOG_LEVEL")
+	if value == "" {
+		return logging.LevelInfo
+	}
+	level, err := logging.ParseLevel(value)
+	if err != nil {
+		log.Printf("Invalid GOLOG_LOG_LEVEL %q, using %s", value, logging.LevelInfo)
+	}
+	return level
+}
+
+// perspectiveConcurrency is how many perspective requests run at once, read from
+// PERSPECTIVE_CONCURRENCY.
+func perspectiveConcurrency() int {
+	if value := os.Getenv("PERSPECTIVE_CONCURRENCY"); value != "" {
+		concurrency, err := strconv.Atoi(value)
+		if err == nil && concurrency > 0 {
+			return concurrency
+		}
+		log.Printf("Invalid PERSPECTIVE_CONCURRENCY %q, using %d", value, svc.DefaultPerspectiveConcurrency)
+	}
+	return svc.DefaultPerspectiveConcurrency
+}
+
+// extractionHistoryWindow is how many earlier answers belief extraction sees, read from
+// EXTRACTION_HISTORY_WINDOW.
+func extractionHistoryWindow() int {
+	if value := os.Getenv("EXTRACTION_HISTORY_WINDOW"); value != "" {
+		window, err := strconv.Atoi(value)
+		if err == nil && window >= 0 {
+			return window
+		}
+		log.Printf("Invalid EXTRACTION_HISTORY_WINDOW %q, using %d", value, svc.DefaultExtractionHistoryWindow)
+	}
+	return svc.DefaultExtractionHistoryWindow
+}
+
+// answerMatchThreshold is the confidence PreprocessQuestionAnswer needs to keep a matched answer,
+// read from ANSWER_MATCH_THRESHOLD.
+func answerMatchThreshold() float32 {
+	if value := os.Getenv("ANSWER_MATCH_THRESHOLD"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 32)
+		if err == nil && threshold >= 0 && threshold <= 1 {
+			return float32(threshold)
+		}
+		log.Printf("Invalid ANSWER_MATCH_THRESHOLD %q, using %v", value, svc.DefaultAnswerMatchThreshold)
+	}
+	return svc.DefaultAnswerMatchThreshold
+}
+
+// allowedCohorts is the comma-separated set of cohorts SetCohort accepts, read from COHORTS. When
+// unset any cohort is accepted.
+func allowedCohorts() []string {
+	var cohorts []string
+	for _, cohort := range strings.Split(os.Getenv("COHORTS"), ",") {
+		if cohort = strings.TrimSpace(cohort); cohort != "" {
+			cohorts = append(cohorts, cohort)
+		}
+	}
+	return cohorts
+}
+
+// keyRotationGrace is how long a developer's previous API keys stay valid after a rotation, read
+// from API_KEY_ROTATION_GRACE.
+func keyRotationGrace() time.Duration {
+	if value := os.Getenv("API_KEY_ROTATION_GRACE"); value != "" {
+		grace, err := time.ParseDuration(value)
+		if err == nil && grace >= 0 {
+			return grace
+		}
+		log.Printf("Invalid API_KEY_ROTATION_GRACE %q, using %v", value, svc.DefaultKeyRotationGrace)
+	}
+	return svc.DefaultKeyRotationGrace
+}
+
+// idempotencyTTL is how long the idempotency keys of creation requests are remembered, read from
+// IDEMPOTENCY_KEY_TTL.
+func idempotencyTTL() time.Duration {
+	if value := os.Getenv("IDEMPOTENCY_KEY_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err == nil && ttl > 0 {
+			return ttl
+		}
+		log.Printf("Invalid IDEMPOTENCY_KEY_TTL %q, using %v", value, svc.DefaultIdempotencyTTL)
+	}
+	return svc.DefaultIdempotencyTTL
+}
+
+// defaultUpdateDialecticBudget is how long an UpdateDialectic may take unless
+// UPDATE_DIALECTIC_BUDGET is set.
+const defaultUpdateDialecticBudget = 2 * time.Minute
+
+// updateDialecticBudget bounds each UpdateDialectic across all of its AI calls, read from
+// UPDATE_DIALECTIC_BUDGET. Zero removes the bound.
+func updateDialecticBudget() time.Duration {
+	if value := os.Getenv("UPDATE_DIALECTIC_BUDGET"); value != "" {
+		budget, err := time.ParseDuration(value)
+		if err == nil && budget >= 0 {
+			return budget
+		}
+		log.Printf("Invalid UPDATE_DIALECTIC_BUDGET %q, using %v", value, defaultUpdateDialecticBudget)
+	}
+	return defaultUpdateDialecticBudget
+}
+
+// aiResponseCacheSize is how many AI responses are kept for repeated identical prompts, read from
+// AI_RESPONSE_CACHE_SIZE. Zero, the default, disables the cache.
+func aiResponseCacheSize() int {
+	if value := os.Getenv("AI_RESPONSE_CACHE_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err == nil && size >= 0 {
+			return size
+		}
+		log.Printf("Invalid AI_RESPONSE_CACHE_SIZE %q, disabling the response cache", value)
+	}
+	return 0
+}
+
+// questionHistoryWindow is how many recent answers questions are generated from verbatim, read from
+// QUESTION_HISTORY_WINDOW.
+func questionHistoryWindow() int {
+	if value := os.Getenv("QUESTION_HISTORY_WINDOW"); value != "" {
+		window, err := strconv.Atoi(value)
+		if err == nil && window >= 0 {
+			return window
+		}
+		log.Printf("Invalid QUESTION_HISTORY_WINDOW %q, using %d", value, ai.DefaultQuestionHistoryWindow)
+	}
+	return ai.DefaultQuestionHistoryWindow
+}
+
+// aiPromptTokenBudget is the estimated size, in tokens, that prompts carrying a belief system are
+// trimmed to, read from AI_PROMPT_TOKEN_BUDGET. Zero keeps ai.DefaultPromptTokenBudget.
+func aiPromptTokenBudget() int {
+	if value := os.Getenv("AI_PROMPT_TOKEN_BUDGET"); value != "" {
+		budget, err := strconv.Atoi(value)
+		if err == nil && budget > 0 {
+			return budget
+		}
+		log.Printf("Invalid AI_PROMPT_TOKEN_BUDGET %q, using %d", value, ai.DefaultPromptTokenBudget)
 	}
+	return 0
+}
 
-	preLoadSvc := svc.NewPreloadSvc(sms, pe, philosophiesPath)
-	err = preLoadSvc.RunPreload(context.Background())
+// aiPromptSet reads prompt overrides from the JSON file named by AI_PROMPTS_FILE. Prompts missing
+// from the file, or all of them when it is unset or invalid, keep their defaults.
+func aiPromptSet() ai.PromptSet {
+	path := os.Getenv("AI_PROMPTS_FILE")
+	if path == "" {
+		return ai.PromptSet{}
+	}
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Printf("Failed to preload philosophies: %v", err)
+		log.Printf("Failed to read AI_PROMPTS_FILE %q, using the default prompts: %v", path, err)
+		return ai.PromptSet{}
+	}
+	var prompts ai.PromptSet
+	if err := json.Unmarshal(data, &prompts); err != nil {
+		log.Printf("Invalid AI_PROMPTS_FILE %q, using the default prompts: %v", path, err)
+		return ai.PromptSet{}
 	}
+	if err := prompts.Validate(); err != nil {
+		log.Printf("Invalid AI_PROMPTS_FILE %q, using the default prompts: %v", path, err)
+		return ai.PromptSet{}
+	}
+	return prompts
+}
 
-	return &Server{
-		bsvc:         bsvc,
-		dsvc:         dsvc,
-		kvStore:      kvStore,
-		selfModelSvc: sms,
-		developerSvc: svc.NewDeveloperService(kvStore, aih),
-		userSvc:      svc.NewUserService(kvStore, aih),
+// aiSampling reads the sampling parameters sent with every AI request from AI_TEMPERATURE, AI_TOP_P
+// and AI_SEED. Unset or invalid values are left to the provider's defaults.
+func aiSampling() ai.SamplingConfig {
+	var sampling ai.SamplingConfig
+	for name, target := range map[string]**float32{"AI_TEMPERATURE": &sampling.Temperature, "AI_TOP_P": &sampling.TopP} {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 32)
+			if err != nil || parsed < 0 {
+				log.Printf("Invalid %s %q, using the provider default", name, value)
+				continue
+			}
+			f := float32(parsed)
+			*target = &f
+		}
+	}
+	if value := os.Getenv("AI_SEED"); value != "" {
+		seed, err := strconv.Atoi(value)
+		if err != nil {
+			log.Printf("Invalid AI_SEED %q, sending no seed", value)
+		} else {
+			sampling.Seed = &seed
+		}
 	}
+	return sampling
 }
 
-func RunServer(kvStore *db.KeyValueStore, port string) (*http.Server, *sync.WaitGroup, string) {
+// RunServer starts the server on port with the default CORS policy.
+func RunServer(kvStore db.KeyValueStore, port string) (*http.Server, *sync.WaitGroup, string) {
+	cfg := DefaultConfig()
+	cfg.Port = port
+	return RunServerWithConfig(kvStore, cfg)
+}
+
+// RunServerWithConfig starts the server with the port and CORS policy from cfg, exiting if the
+// configuration is invalid. The returned WaitGroup is done once the server has been shut down,
+// its in-flight requests have finished and its webhook deliveries have stopped.
+func RunServerWithConfig(kvStore db.KeyValueStore, cfg Config) (*http.Server, *sync.WaitGroup, string) {
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid server configuration: %v", err)
+	}
+	port := cfg.Port
+
 	// Suppress server logs for tests
 	if os.Getenv("GOLOG_LOG_LEVEL") == "error" {
 		log.SetOutput(ioutil.Discard)
 	}
 
-	svcServer := NewServer(kvStore)
+	logging.SetLevel(logLevel())
+
+	if cfg.MetricsEnabled {
+		kvStore = db.NewInstrumentedStore(kvStore)
+	}
+	svcServer, err := NewServer(kvStore)
+	if err != nil {
+		log.Fatalf("Failed to create server: %v", err)
+	}
+
+	interceptors := []connect.Interceptor{
+		newRequestLoggingInterceptor(),
+		newAIKeyInterceptor(svcServer.developerSvc, svcServer.aiHelpers),
+	}
+	if cfg.MetricsEnabled {
+		interceptors = append(interceptors, newMetricsInterceptor())
+	}
+	var stopRateLimitCleanup func()
+	if cfg.RateLimitRequestsPerSecond > 0 {
+		limiter := newRateLimiter(
+			svcmodels.RateLimit{RequestsPerSecond: cfg.RateLimitRequestsPerSecond, Burst: cfg.RateLimitBurst},
+			svcServer.developerRateLimit,
+		)
+		stopRateLimitCleanup = limiter.startCleanup(rateLimitCleanupInterval)
+		interceptors = append(interceptors, newRateLimitInterceptor(limiter, svcServer.developerSvc.AuthenticateAPIKey))
+	}
 
 	mux := http.NewServeMux()
-	path, handler := pbconnect.NewEpistemicMeServiceHandler(svcServer)
+	path, handler := pbconnect.NewEpistemicMeServiceHandler(svcServer, connect.WithInterceptors(interceptors...))
 	mux.Handle(path, handler)
-
-	corsHandler := cors.New(cors.Options{
-		AllowedOrigins: []string{"http://localhost:8081", "http://localhost:3001", "http://localhost:3000", "http://localhost"},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{
-			"Accept",
-			"Content-Type",
-			"Content-Length",
-			"Accept-Encoding",
-			"X-CSRF-Token",
-			"Authorization",
-			"Connect-Protocol-Version",
-			"Connect-Timeout-Ms",
-			"x-api-key",
-			"Origin",
-		},
-		ExposedHeaders:   []string{"Content-Length", "Content-Type"},
-		AllowCredentials: true,
-		Debug:            false,
-	})
+	mux.Handle("/healthz", svcServer.health)
+	if cfg.MetricsEnabled {
+		mux.Handle("/metrics", promhttp.Handler())
+	}
 
 	var listener net.Listener
-	var err error
 
 	if port == "" {
 		// For testing, use a dynamic port
@@ -628,8 +2375,13 @@ func RunServer(kvStore *db.KeyValueStore, port string) (*http.Server, *sync.Wait
 		}
 	}
 
-	srv := &http.Server{
-		Handler: h2c.NewHandler(corsHandler.Handler(mux), &http2.Server{}),
+	srv, requests := newDrainingServer(h2c.NewHandler(corsHandler(cfg, mux), &http2.Server{}), cfg.ShutdownGracePeriod)
+
+	// Periodically remove expired store entries, stopping with the server
+	sweeper := db.StartExpirySweeper(kvStore, sweepInterval())
+	srv.RegisterOnShutdown(sweeper.Stop)
+	if stopRateLimitCleanup != nil {
+		srv.RegisterOnShutdown(stopRateLimitCleanup)
 	}
 
 	var wg sync.WaitGroup
@@ -642,6 +2394,10 @@ func RunServer(kvStore *db.KeyValueStore, port string) (*http.Server, *sync.Wait
 		if err := srv.Serve(listener); err != http.ErrServerClosed {
 			log.Fatalf("ListenAndServe(): %v", err)
 		}
+		// The webhooks of requests still draining are queued until they finish, so deliveries
+		// stop only after them
+		requests.wait()
+		svcServer.Close()
 	}()
 
 	return srv, &wg, port
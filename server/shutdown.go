@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultShutdownGracePeriod is how long a shutdown waits for in-flight requests, such as
+// UpdateDialectic calls waiting on the LLM, before cancelling them.
+const DefaultShutdownGracePeriod = 30 * time.Second
+
+// newDrainingServer returns an http.Server whose shutdown drains in-flight requests. Shutdown
+// already stops accepting connections and waits for active ones; the hook registered here
+// cancels every request context once that wait has lasted gracePeriod, so handlers that
+// honour their context return instead of holding the deploy up. The returned requests track the
+// handlers still running, so work they hand off can be stopped once they are done.
+func newDrainingServer(handler http.Handler, gracePeriod time.Duration) (*http.Server, *inFlightRequests) {
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	requests := newInFlightRequests()
+	srv := &http.Server{
+		Handler: requests.track(handler),
+		BaseContext: func(net.Listener) context.Context {
+			return requestCtx
+		},
+	}
+	srv.RegisterOnShutdown(func() {
+		time.AfterFunc(gracePeriod, cancelRequests)
+	})
+	return srv, requests
+}
+
+// inFlightRequests counts the requests being handled.
+type inFlightRequests struct {
+	mu    sync.Mutex
+	idle  *sync.Cond
+	count int
+}
+
+func newInFlightRequests() *inFlightRequests {
+	requests := &inFlightRequests{}
+	requests.idle = sync.NewCond(&requests.mu)
+	return requests
+}
+
+func (r *inFlightRequests) track(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		r.count++
+		r.mu.Unlock()
+		defer func() {
+			r.mu.Lock()
+			r.count--
+			if r.count == 0 {
+				r.idle.Broadcast()
+			}
+			r.mu.Unlock()
+		}()
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// wait returns once no request is being handled.
+func (r *inFlightRequests) wait() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for r.count > 0 {
+		r.idle.Wait()
+	}
+}
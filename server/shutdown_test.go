@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startDrainingServer serves handler with the given grace period, returning its URL.
+func startDrainingServer(t *testing.T, handler http.Handler, gracePeriod time.Duration) (*http.Server, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv, _ := newDrainingServer(handler, gracePeriod)
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+	return srv, "http://" + listener.Addr().String()
+}
+
+func TestShutdownWaitsForInFlightRequest(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+	srv, url := startDrainingServer(t, handler, time.Second)
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	<-started
+	require.NoError(t, srv.Shutdown(context.Background()))
+	assert.Equal(t, http.StatusOK, <-status)
+}
+
+func TestShutdownCancelsRequestsAfterGracePeriod(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-r.Context().Done():
+			cancelled <- r.Context().Err()
+		case <-time.After(10 * time.Second):
+			cancelled <- nil
+		}
+	})
+	srv, url := startDrainingServer(t, handler, 50*time.Millisecond)
+
+	go http.Get(url)
+	<-started
+
+	shutdownStarted := time.Now()
+	require.NoError(t, srv.Shutdown(context.Background()))
+	assert.Less(t, time.Since(shutdownStarted), 5*time.Second)
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+}
+
+func TestInFlightRequestsWaitForHandlers(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv, requests := newDrainingServer(handler, time.Second)
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+
+	go http.Get("http://" + listener.Addr().String())
+	<-started
+
+	drained := make(chan struct{})
+	go func() {
+		requests.wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		t.Fatal("wait returned while a request was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("wait did not return once the request finished")
+	}
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ai "epistemic-me-core/ai"
+	pb "epistemic-me-core/pb"
+	"epistemic-me-core/tests/fakeai"
+)
+
+func TestRegisterWebhookRequiresOwningDeveloper(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	opts := DefaultServerOptions()
+	opts.AIHelper = ai.NewAIHelperWithClient(fakeai.New("ok"))
+	s, err := NewServerWithOptions(opts)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	ownerKey, otherKey := issueAPIKey(t, s), issueAPIKey(t, s)
+	owner, err := s.developerSvc.GetDeveloperByAPIKey(ownerKey)
+	require.NoError(t, err)
+
+	register := func(apiKey string) error {
+		req := connect.NewRequest(&pb.RegisterWebhookRequest{DeveloperId: owner.ID, Url: "https://93.184.215.14/hook"})
+		req.Header().Set("x-api-key", apiKey)
+		_, err := s.RegisterWebhook(context.Background(), req)
+		return err
+	}
+
+	assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(register(otherKey)))
+	assert.NoError(t, register(ownerKey))
+}
@@ -0,0 +1,12 @@
+package svc
+
+// DefaultAnswerMatchThreshold is the confidence below which PreprocessQuestionAnswers and BulkAnswer
+// leave a question unanswered rather than keep the answer matched to it.
+const DefaultAnswerMatchThreshold = 0.5
+
+// SetAnswerMatchThreshold sets the confidence, from 0 to 1, that an answer matched to a question by
+// PreprocessQuestionAnswers or BulkAnswer needs to be kept. Weaker matches leave their question
+// unanswered. Zero keeps every match.
+func (dsvc *DialecticService) SetAnswerMatchThreshold(threshold float32) {
+	dsvc.answerMatchThreshold = threshold
+}
@@ -0,0 +1,207 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc/models"
+
+	"github.com/google/uuid"
+)
+
+// DefaultKeyRotationGrace is how long a developer's previous keys stay valid after a rotation
+// unless SetKeyRotationGrace is called.
+const DefaultKeyRotationGrace = 24 * time.Hour
+
+// revokedAPIKeyScope is the store owner of the records of revoked keys.
+const revokedAPIKeyScope = "revoked_api_keys"
+
+// issuedAPIKeyScope is the store owner of the index of active keys.
+const issuedAPIKeyScope = "api_keys"
+
+// SetKeyRotationGrace sets how long keys replaced by RotateAPIKey keep authenticating.
+func (s *DeveloperService) SetKeyRotationGrace(grace time.Duration) {
+	s.rotationGrace = grace
+}
+
+// RotateAPIKey issues a new API key for a developer. The keys the developer held before stay valid
+// for the rotation grace window, so clients can switch over without downtime.
+func (s *DeveloperService) RotateAPIKey(input *models.RotateAPIKeyInput) (*models.RotateAPIKeyOutput, error) {
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+
+	developer, err := s.GetDeveloper(&models.GetDeveloperInput{ID: input.DeveloperID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.retireExpiredKeys(developer, now); err != nil {
+		return nil, err
+	}
+	if developer.APIKeyExpiries == nil {
+		developer.APIKeyExpiries = make(map[string]int64)
+	}
+	for _, key := range developer.APIKeys {
+		if _, ok := developer.APIKeyExpiries[key]; !ok {
+			developer.APIKeyExpiries[key] = now.Add(s.rotationGrace).UnixMilli()
+		}
+	}
+	apiKey := uuid.New().String()
+	developer.APIKeys = append(developer.APIKeys, apiKey)
+
+	if err := s.storeDeveloper(developer, now); err != nil {
+		return nil, err
+	}
+	for _, key := range developer.APIKeys {
+		if err := s.indexKey(developer, key); err != nil {
+			return nil, err
+		}
+	}
+	return &models.RotateAPIKeyOutput{APIKey: apiKey, Developer: developer}, nil
+}
+
+// RevokeAPIKey invalidates one of a developer's API keys immediately.
+func (s *DeveloperService) RevokeAPIKey(input *models.RevokeAPIKeyInput) (*models.RevokeAPIKeyOutput, error) {
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+
+	developer, err := s.GetDeveloper(&models.GetDeveloperInput{ID: input.DeveloperID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !developer.KeyActive(input.APIKey, now) {
+		return nil, fmt.Errorf("%w for developer %s", ErrAPIKeyNotFound, input.DeveloperID)
+	}
+
+	// Recorded first, so the key is rejected even if storing the developer fails
+	if err := s.recordRevoked(developer.ID, input.APIKey, now); err != nil {
+		return nil, err
+	}
+	keys := developer.APIKeys[:0]
+	for _, key := range developer.APIKeys {
+		if key != input.APIKey {
+			keys = append(keys, key)
+		}
+	}
+	developer.APIKeys = keys
+	delete(developer.APIKeyExpiries, input.APIKey)
+	if err := s.retireExpiredKeys(developer, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.storeDeveloper(developer, now); err != nil {
+		return nil, err
+	}
+	return &models.RevokeAPIKeyOutput{Developer: developer}, nil
+}
+
+// AuthenticateAPIKey returns the ID of the developer holding apiKey. It returns ErrAPIKeyRevoked
+// when the key was revoked or its rotation grace window is over, and ErrUnknownAPIKey when the key
+// was never issued.
+func (s *DeveloperService) AuthenticateAPIKey(apiKey string) (string, error) {
+	value, err := s.kvStore.Retrieve(issuedAPIKeyScope, apiKey)
+	if errors.Is(err, db.ErrNotFound) {
+		if _, err := s.kvStore.Retrieve(revokedAPIKeyScope, apiKey); err == nil {
+			return "", ErrAPIKeyRevoked
+		}
+		return "", ErrUnknownAPIKey
+	} else if err != nil {
+		return "", fmt.Errorf("failed to check API key: %w", err)
+	}
+	issued, ok := value.(*models.IssuedAPIKey)
+	if !ok {
+		return "", fmt.Errorf("invalid issued API key data type")
+	}
+	if issued.ExpiresAtMillisUTC != 0 && time.Now().UnixMilli() >= issued.ExpiresAtMillisUTC {
+		return "", ErrAPIKeyRevoked
+	}
+	return issued.DeveloperID, nil
+}
+
+// IndexAPIKeys adds the active keys of developers stored before keys were indexed to the index
+// AuthenticateAPIKey reads.
+func (s *DeveloperService) IndexAPIKeys() error {
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+
+	developers, err := s.kvStore.ListAllByType(reflect.TypeOf(models.Developer{}))
+	if err != nil {
+		return fmt.Errorf("failed to list developers: %w", err)
+	}
+	now := time.Now()
+	for _, dev := range developers {
+		developer, ok := dev.(*models.Developer)
+		if !ok {
+			continue
+		}
+		for _, key := range developer.APIKeys {
+			if !developer.KeyActive(key, now) {
+				continue
+			}
+			if _, err := s.kvStore.Retrieve(issuedAPIKeyScope, key); err == nil {
+				continue
+			}
+			if err := s.indexKey(developer, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// retireExpiredKeys moves the keys whose rotation grace window is over from the developer to the
+// revoked keys.
+func (s *DeveloperService) retireExpiredKeys(developer *models.Developer, now time.Time) error {
+	keys := developer.APIKeys[:0]
+	for _, key := range developer.APIKeys {
+		if expiry, ok := developer.APIKeyExpiries[key]; ok && now.UnixMilli() >= expiry {
+			if err := s.recordRevoked(developer.ID, key, now); err != nil {
+				return err
+			}
+			delete(developer.APIKeyExpiries, key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	developer.APIKeys = keys
+	return nil
+}
+
+// recordRevoked records apiKey as revoked and removes it from the index of active keys.
+func (s *DeveloperService) recordRevoked(developerID, apiKey string, now time.Time) error {
+	revoked := models.RevokedAPIKey{APIKey: apiKey, DeveloperID: developerID, RevokedAtMillisUTC: now.UnixMilli()}
+	if err := s.kvStore.ForceStore(revokedAPIKeyScope, apiKey, revoked, 1); err != nil {
+		return fmt.Errorf("failed to record revoked API key: %w", err)
+	}
+	if err := s.kvStore.Delete(issuedAPIKeyScope, apiKey); err != nil && !errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("failed to unindex revoked API key: %w", err)
+	}
+	return nil
+}
+
+// indexKey adds apiKey, held by developer, to the index of active keys.
+func (s *DeveloperService) indexKey(developer *models.Developer, apiKey string) error {
+	issued := models.IssuedAPIKey{
+		APIKey:             apiKey,
+		DeveloperID:        developer.ID,
+		ExpiresAtMillisUTC: developer.APIKeyExpiries[apiKey],
+	}
+	if err := s.kvStore.ForceStore(issuedAPIKeyScope, apiKey, issued, 1); err != nil {
+		return fmt.Errorf("failed to index API key: %w", err)
+	}
+	return nil
+}
+
+func (s *DeveloperService) storeDeveloper(developer *models.Developer, now time.Time) error {
+	developer.UpdatedAt = now.UnixMilli()
+	if err := s.kvStore.ForceStore(developer.ID, "developer", *developer, 1); err != nil {
+		return fmt.Errorf("failed to store developer: %w", err)
+	}
+	return nil
+}
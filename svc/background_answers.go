@@ -0,0 +1,367 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	ai "epistemic-me-core/ai"
+	db "epistemic-me-core/db"
+	"epistemic-me-core/logging"
+	"epistemic-me-core/svc/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// backgroundWorkers is how many answers given in background mode are processed at once.
+	backgroundWorkers = 4
+	// backgroundQueueSize is how many answers can be queued or processed on a worker before
+	// UpdateDialectic turns background updates for its self models away.
+	backgroundQueueSize = 64
+	// maxBackgroundStoreAttempts is how often storing a processed answer is retried when the
+	// dialectic was updated in the meantime.
+	maxBackgroundStoreAttempts = 5
+)
+
+// backgroundQueue runs jobs on a fixed set of workers started with the first job. The jobs of a self
+// model all run on the same worker, one at a time and in the order they were submitted, so its
+// belief system is never updated by two answers at once.
+type backgroundQueue struct {
+	start sync.Once
+	jobs  []chan func()
+	// slots holds a token for every job reserved, queued or running on a worker, bounding its queue
+	slots   []chan struct{}
+	pending sync.WaitGroup
+}
+
+func newBackgroundQueue() *backgroundQueue {
+	q := &backgroundQueue{
+		jobs:  make([]chan func(), backgroundWorkers),
+		slots: make([]chan struct{}, backgroundWorkers),
+	}
+	for i := range q.jobs {
+		q.jobs[i] = make(chan func(), backgroundQueueSize)
+		q.slots[i] = make(chan struct{}, backgroundQueueSize)
+	}
+	return q
+}
+
+// worker returns the index of the worker running selfModelID's jobs.
+func (q *backgroundQueue) worker(selfModelID string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(selfModelID))
+	return int(hash.Sum32() % uint32(len(q.jobs)))
+}
+
+// reserve takes a place for a job of selfModelID on its worker, reporting false without waiting
+// when the worker's queue is full. A reserved place is filled with submit or given back with release.
+func (q *backgroundQueue) reserve(selfModelID string) bool {
+	select {
+	case q.slots[q.worker(selfModelID)] <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release gives back a place reserved for a job of selfModelID that will not be submitted.
+func (q *backgroundQueue) release(selfModelID string) {
+	<-q.slots[q.worker(selfModelID)]
+}
+
+// submit queues job on selfModelID's worker, in the place reserve took for it.
+func (q *backgroundQueue) submit(selfModelID string, job func()) {
+	q.start.Do(func() {
+		for i := range q.jobs {
+			go func(jobs <-chan func(), slots <-chan struct{}) {
+				for job := range jobs {
+					job()
+					<-slots
+					q.pending.Done()
+				}
+			}(q.jobs[i], q.slots[i])
+		}
+	})
+	q.pending.Add(1)
+	q.jobs[q.worker(selfModelID)] <- job
+}
+
+// WaitForBackgroundUpdates blocks until every answer given in background mode so far has been
+// processed.
+func (dsvc *DialecticService) WaitForBackgroundUpdates() {
+	dsvc.background.pending.Wait()
+}
+
+// answerResult is what processing an answer adds to its interaction, and the belief system to store
+// once the interaction is.
+type answerResult struct {
+	beliefSystem      *models.BeliefSystem
+	qa                *models.QuestionAnswerInteraction
+	prediction        *models.Prediction
+	perspectives      []models.Perspective
+	perspectiveErrors map[string]string
+}
+
+// processAnswer extracts the beliefs of the answer to interaction in the light of the earlier
+// interactions in history, adds them to bs and gathers the surprise and perspectives requested by
+// input. Nothing is stored: the caller stores the result's belief system once the dialectic is.
+func (dsvc *DialecticService) processAnswer(input *models.UpdateDialecticInput, interaction *models.DialecticalInteraction, history []ai.InteractionEvent, perspectiveModelIDs []string, bs *models.BeliefSystem) (*answerResult, error) {
+	// Extract beliefs from the answer
+	interactionEvent := ai.InteractionEvent{
+		Question: getQuestion(interaction),
+		Answer:   input.Answer.UserAnswer,
+	}
+
+	classifiedBeliefs, err := dsvc.aih.GetInteractionEventAsClassifiedBeliefsWithHistory(interactionEvent, history)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract beliefs: %w", err)
+	}
+
+	extractedBeliefs := make([]*models.Belief, 0, len(classifiedBeliefs))
+	for _, classified := range classifiedBeliefs {
+		extractedBelief := &models.Belief{
+			ID:                  uuid.New().String(),
+			Content:             []models.Content{{RawStr: classified.Content}},
+			Type:                classified.Type,
+			SourceInteractionID: interaction.ID,
+		}
+		extractedBeliefs = append(extractedBeliefs, extractedBelief)
+	}
+
+	// Drop the existing beliefs that the new ones invalidate
+	if input.PruneInvalidatedBeliefs && len(bs.Beliefs) > 0 && len(extractedBeliefs) > 0 {
+		keptIDs, deletedIDs, err := dsvc.aih.DetermineBeliefValidity(bs.Beliefs, extractedBeliefs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine belief validity: %w", err)
+		}
+		logging.Infof(dsvc.context(), "Belief validity: kept %v, deleted %v", keptIDs, deletedIDs)
+		removeBeliefs(bs, deletedIDs)
+	}
+
+	// Add the extracted beliefs to the BeliefSystem
+	bs.Beliefs = append(bs.Beliefs, extractedBeliefs...)
+
+	oldQA := getQuestionAnswer(interaction.Interaction)
+	result := &answerResult{
+		beliefSystem: bs,
+		qa: &models.QuestionAnswerInteraction{
+			Question: oldQA.Question,
+			Answer: models.UserAnswer{
+				UserAnswer:         input.Answer.UserAnswer,
+				CreatedAtMillisUTC: time.Now().UnixMilli(),
+			},
+			ExtractedBeliefs:   extractedBeliefs,
+			UpdatedAtMillisUTC: time.Now().UnixMilli(),
+		},
+	}
+
+	if input.ComputeSurprise {
+		prediction, err := dsvc.generatePredictedObservation(interaction, input.Answer.UserAnswer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute surprise: %w", err)
+		}
+		result.prediction = prediction
+		logging.Infof(dsvc.context(), "Surprise for interaction %s: %.3f", interaction.ID, prediction.Discrepancy.KlDivergence)
+	}
+
+	// For all perspectives we've attached to the dialectic, provide perspectives on the latest
+	// dialectic interaction
+	if len(perspectiveModelIDs) > 0 {
+		perspectives, errs := dsvc.collectPerspectives(bs, perspectiveModelIDs, result.qa.Question.Question, result.qa.Answer.UserAnswer)
+		for i, perspectiveModelID := range perspectiveModelIDs {
+			if errs[i] != nil {
+				if input.StrictPerspectives {
+					return nil, errs[i]
+				}
+				logging.Warnf(dsvc.context(), "Skipping perspective: %v", errs[i])
+				if result.perspectiveErrors == nil {
+					result.perspectiveErrors = make(map[string]string)
+				}
+				result.perspectiveErrors[perspectiveModelID] = errs[i].Error()
+				continue
+			}
+			result.perspectives = append(result.perspectives, *perspectives[i])
+		}
+	}
+
+	return result, nil
+}
+
+// apply records the processed answer on its interaction.
+func (r *answerResult) apply(interaction *models.DialecticalInteraction) {
+	interaction.Status = models.StatusAnswered
+	interaction.Type = models.InteractionTypeQuestionAnswer
+	interaction.Interaction = &models.InteractionData{
+		QuestionAnswer: r.qa,
+	}
+	interaction.UpdatedAtMillisUTC = time.Now().UnixMilli()
+	interaction.ProcessingError = ""
+	if r.prediction != nil {
+		interaction.Prediction = r.prediction
+	}
+	interaction.Perspectives = append(interaction.Perspectives, r.perspectives...)
+}
+
+// markAnswerProcessing records an answer whose beliefs are still to be extracted.
+func markAnswerProcessing(interaction *models.DialecticalInteraction, answer string) {
+	oldQA := getQuestionAnswer(interaction.Interaction)
+	interaction.Status = models.StatusProcessing
+	interaction.Type = models.InteractionTypeQuestionAnswer
+	interaction.Interaction = &models.InteractionData{
+		QuestionAnswer: &models.QuestionAnswerInteraction{
+			Question: oldQA.Question,
+			Answer: models.UserAnswer{
+				UserAnswer:         answer,
+				CreatedAtMillisUTC: time.Now().UnixMilli(),
+			},
+			UpdatedAtMillisUTC: time.Now().UnixMilli(),
+		},
+	}
+	interaction.UpdatedAtMillisUTC = time.Now().UnixMilli()
+}
+
+// finishAnswer processes an answer given in background mode to the last of the previous
+// interactions and records the result on its interaction. The belief system is read when the job
+// runs, so answers queued one after another each build on the beliefs of the one before. When
+// processing fails the interaction is still marked answered, with the error in its ProcessingError.
+func (dsvc *DialecticService) finishAnswer(input models.UpdateDialecticInput, previous []models.DialecticalInteraction, perspectiveModelIDs []string) {
+	lastIdx := len(previous) - 1
+	interaction := previous[lastIdx]
+	result, err := dsvc.processBackgroundAnswer(&input, previous, perspectiveModelIDs)
+	update := func(stored *models.DialecticalInteraction) {
+		if err != nil {
+			stored.Status = models.StatusAnswered
+			stored.ProcessingError = err.Error()
+			stored.UpdatedAtMillisUTC = time.Now().UnixMilli()
+			return
+		}
+		result.apply(stored)
+	}
+	if err != nil {
+		logging.Errorf(dsvc.context(), "Failed to process answer to interaction %s: %v", interaction.ID, err)
+	}
+
+	updated, storeErr := dsvc.updateProcessingInteraction(input.SelfModelID, input.ID, interaction.ID, update)
+	if storeErr != nil {
+		logging.Errorf(dsvc.context(), "Failed to store processed answer to interaction %s: %v", interaction.ID, storeErr)
+		return
+	}
+	// An answer edited or deleted while it was processed leaves the beliefs untouched
+	if updated && result != nil {
+		if err := storeBeliefSystem(dsvc.kvStore, dsvc.observer, input.SelfModelID, result.beliefSystem); err != nil {
+			logging.Errorf(dsvc.context(), "Failed to store the belief system updated by interaction %s: %v", interaction.ID, err)
+		}
+	}
+}
+
+// processBackgroundAnswer runs the belief updates UpdateDialectic makes before extracting an
+// answer's beliefs, then extracts them into the stored belief system.
+func (dsvc *DialecticService) processBackgroundAnswer(input *models.UpdateDialecticInput, previous []models.DialecticalInteraction, perspectiveModelIDs []string) (*answerResult, error) {
+	if _, err := dsvc.dialecticEpiSvc.Process(&models.DialecticEvent{
+		PreviousInteractions: previous,
+	}, false, input.SelfModelID); err != nil {
+		return nil, err
+	}
+	bs, err := dsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+	lastIdx := len(previous) - 1
+	history := dsvc.extractionHistory(previous[:lastIdx])
+	return dsvc.processAnswer(input, &previous[lastIdx], history, perspectiveModelIDs, bs)
+}
+
+// ResumeBackgroundUpdates queues again the answers whose interactions are still processing, such as
+// those a restart interrupted. The options of the original update are not stored, so the answers
+// are processed without pruning, surprise or strict perspectives.
+func (dsvc *DialecticService) ResumeBackgroundUpdates() error {
+	dialectics, err := db.ListAllOfType[models.Dialectic](dsvc.kvStore)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve dialectics: %w", err)
+	}
+	for _, dialectic := range dialectics {
+		for i, interaction := range dialectic.UserInteractions {
+			qa := getQuestionAnswer(interaction.Interaction)
+			if interaction.Status != models.StatusProcessing || qa == nil {
+				continue
+			}
+			input := models.UpdateDialecticInput{
+				ID:          dialectic.ID,
+				SelfModelID: dialectic.SelfModelID,
+				Answer:      models.UserAnswer{UserAnswer: qa.Answer.UserAnswer},
+				Background:  true,
+			}
+			// An answer that finds its worker's queue full stays processing for the next resume
+			if !dsvc.background.reserve(dialectic.SelfModelID) {
+				logging.Warnf(dsvc.context(), "Background queue is full, leaving the answer to interaction %s for the next resume", interaction.ID)
+				continue
+			}
+			previous := append([]models.DialecticalInteraction(nil), dialectic.UserInteractions[:i+1]...)
+			perspectiveModelIDs := dialectic.PerspectiveModelIDs
+			dsvc.background.submit(dialectic.SelfModelID, func() {
+				dsvc.finishAnswer(input, previous, perspectiveModelIDs)
+			})
+			logging.Infof(dsvc.context(), "Resumed processing the answer to interaction %s", interaction.ID)
+		}
+	}
+	return nil
+}
+
+// updateProcessingInteraction applies update to an interaction still being processed, re-reading
+// the dialectic when it was updated in the meantime, and reports whether it was applied. An
+// interaction no longer processing, because its answer was edited or the dialectic deleted, is left
+// alone.
+func (dsvc *DialecticService) updateProcessingInteraction(selfModelID, dialecticID, interactionID string, update func(*models.DialecticalInteraction)) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		dialectic, err := dsvc.retrieveDialecticValue(selfModelID, dialecticID)
+		if err != nil {
+			return false, err
+		}
+		index := -1
+		for i := range dialectic.UserInteractions {
+			if dialectic.UserInteractions[i].ID == interactionID {
+				index = i
+				break
+			}
+		}
+		if index < 0 || dialectic.UserInteractions[index].Status != models.StatusProcessing {
+			return false, nil
+		}
+
+		update(&dialectic.UserInteractions[index])
+		dialectic.Version++
+		err = dsvc.storeDialecticValue(selfModelID, dialectic)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, db.ErrVersionConflict) || attempt == maxBackgroundStoreAttempts {
+			return false, err
+		}
+	}
+}
+
+// GetInteractionStatus reports whether the answer to an interaction has been processed, for
+// clients polling after an update in background mode.
+func (dsvc *DialecticService) GetInteractionStatus(input *models.GetInteractionStatusInput) (*models.GetInteractionStatusOutput, error) {
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+	for _, interaction := range dialectic.UserInteractions {
+		if interaction.ID != input.InteractionID {
+			continue
+		}
+		output := &models.GetInteractionStatusOutput{
+			Status:          interaction.Status,
+			ProcessingError: interaction.ProcessingError,
+		}
+		if qa := getQuestionAnswer(interaction.Interaction); qa != nil {
+			output.ExtractedBeliefs = qa.ExtractedBeliefs
+		}
+		return output, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInteractionNotFound, input.InteractionID)
+}
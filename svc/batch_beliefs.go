@@ -0,0 +1,65 @@
+package svc
+
+import (
+	"fmt"
+
+	"epistemic-me-core/logging"
+	"epistemic-me-core/svc/models"
+
+	"github.com/google/uuid"
+)
+
+// BatchCreateBeliefs creates several beliefs with a single update of the belief system. Every
+// belief is validated before any is stored: in all-or-nothing mode an invalid belief fails the
+// batch, in best-effort mode it is skipped and its error reported in the result.
+func (bsvc *BeliefService) BatchCreateBeliefs(input *models.BatchCreateBeliefsInput) (*models.BatchCreateBeliefsOutput, error) {
+	if len(input.Beliefs) == 0 {
+		return nil, ErrNoBeliefs
+	}
+
+	output := &models.BatchCreateBeliefsOutput{Beliefs: []models.Belief{}}
+	for i, item := range input.Beliefs {
+		content, err := validateContent("belief content", item.BeliefContent, bsvc.maxContentLength)
+		if err == nil && (item.BeliefType < models.Statement || item.BeliefType > models.Causal) {
+			err = fmt.Errorf("%w: %d", ErrInvalidBeliefType, item.BeliefType)
+		}
+		if err != nil {
+			if input.Mode != models.BatchModeBestEffort {
+				return nil, fmt.Errorf("belief %d: %w", i, err)
+			}
+			output.Result.Fail(i, err)
+			continue
+		}
+		belief := models.Belief{
+			ID:          "bi_" + uuid.New().String(),
+			SelfModelID: input.SelfModelID,
+			Content:     []models.Content{{RawStr: content}},
+			Type:        item.BeliefType,
+			Version:     1,
+			Active:      true,
+		}
+		output.Beliefs = append(output.Beliefs, belief)
+		output.Result.Succeed(i, belief.ID)
+	}
+
+	beliefSystem, err := bsvc.retrieveBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve belief system: %w", err)
+	}
+	for i := range output.Beliefs {
+		if err := bsvc.storeBeliefValue(input.SelfModelID, &output.Beliefs[i]); err != nil {
+			return nil, fmt.Errorf("failed to store belief: %w", err)
+		}
+		beliefCopy := output.Beliefs[i]
+		beliefSystem.Beliefs = append(beliefSystem.Beliefs, &beliefCopy)
+	}
+	if len(output.Beliefs) > 0 {
+		if err := storeBeliefSystem(bsvc.kvStore, bsvc.observer, input.SelfModelID, beliefSystem); err != nil {
+			return nil, fmt.Errorf("failed to store belief system: %w", err)
+		}
+	}
+
+	logging.Infof(bsvc.context(), "Created %d of %d beliefs for self model %s", len(output.Beliefs), len(input.Beliefs), input.SelfModelID)
+	output.BeliefSystem = *beliefSystem
+	return output, nil
+}
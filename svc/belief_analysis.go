@@ -0,0 +1,77 @@
+package svc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	ai "epistemic-me-core/ai"
+	db "epistemic-me-core/db"
+	"epistemic-me-core/svc/models"
+)
+
+func beliefAnalysisKey(strategy ai.DialecticStrategy) string {
+	return fmt.Sprintf("BeliefAnalysis:%d", strategy)
+}
+
+// AnalyzeBeliefSystem scores the coherence, consistency and falsifiability of a self model's
+// current belief system, with feedback and recommendations, following the strategy of the input's
+// dialectic type. The analysis is cached by a hash of the belief system, so it is only repeated
+// once the beliefs change.
+func (bsvc *BeliefService) AnalyzeBeliefSystem(input *models.AnalyzeBeliefSystemInput) (*models.AnalyzeBeliefSystemOutput, error) {
+	beliefSystem, err := bsvc.GetBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+	if len(beliefSystem.Beliefs) == 0 {
+		return nil, fmt.Errorf("%w: self model %s has no beliefs", ErrBeliefSystemNotFound, input.SelfModelID)
+	}
+
+	strategy := determineDialecticStrategy(input.DialecticType)
+	hash := beliefSystemHash(beliefSystem)
+	key := beliefAnalysisKey(strategy)
+
+	value, err := bsvc.kvStore.Retrieve(input.SelfModelID, key)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return nil, fmt.Errorf("failed to retrieve belief analysis: %w", err)
+	}
+	if record, ok := value.(*models.BeliefAnalysisRecord); ok && record.BeliefSystemHash == hash {
+		return &models.AnalyzeBeliefSystemOutput{Analysis: record.Analysis, Cached: true}, nil
+	}
+
+	analysis, err := bsvc.ai.GenerateAnalysisForStrategy(strategy, beliefSystem, nil, ai.InteractionEvent{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze belief system: %w", err)
+	}
+	if analysis == nil {
+		return nil, fmt.Errorf("failed to analyze belief system: no analysis returned")
+	}
+
+	record := models.BeliefAnalysisRecord{BeliefSystemHash: hash, Analysis: *analysis}
+	if err := bsvc.kvStore.ForceStore(input.SelfModelID, key, record, 1); err != nil {
+		return nil, fmt.Errorf("failed to store belief analysis: %w", err)
+	}
+	return &models.AnalyzeBeliefSystemOutput{Analysis: *analysis}, nil
+}
+
+// beliefSystemHash identifies the active beliefs of a belief system by their ID, version and
+// content, whatever their order.
+func beliefSystemHash(beliefSystem *models.BeliefSystem) string {
+	var entries []string
+	for _, belief := range beliefSystem.Beliefs {
+		if belief == nil || !belief.Active {
+			continue
+		}
+		entries = append(entries, fmt.Sprintf("%s\x00%d\x00%s", belief.ID, belief.Version, belief.GetContentAsString()))
+	}
+	sort.Strings(entries)
+
+	hash := sha256.New()
+	for _, entry := range entries {
+		hash.Write([]byte(entry))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
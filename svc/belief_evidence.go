@@ -0,0 +1,181 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	db "epistemic-me-core/db"
+	"epistemic-me-core/svc/models"
+)
+
+// initialBeliefConfidence is the confidence of a belief before any evidence is weighed.
+const initialBeliefConfidence = 0.5
+
+// evidenceWeights follows the ladder of evidence: a hypothesis is theory only, an action outcome
+// is the user's own experience, and a cited resource such as a paper is research. Each piece of
+// evidence closes this share of the gap to full confidence, or to none when it is counterfactual.
+var evidenceWeights = map[models.EvidenceType]float64{
+	models.EvidenceTypeHypothesis: 0.15,
+	models.EvidenceTypeAction:     0.25,
+	models.EvidenceTypeResource:   0.35,
+}
+
+// evidenceConfidence weighs the evidence of a belief, oldest first, into a confidence between 0
+// and 1. Supporting evidence raises it and counterfactual evidence lowers it.
+func evidenceConfidence(evidence []models.BeliefEvidence) float64 {
+	confidence := initialBeliefConfidence
+	for _, e := range evidence {
+		weight := evidenceWeights[e.Type]
+		if e.IsCounter {
+			confidence -= confidence * weight
+		} else {
+			confidence += (1 - confidence) * weight
+		}
+	}
+	return confidence
+}
+
+func beliefEvidenceKey(beliefID string) string {
+	return beliefID + ":evidence"
+}
+
+// AddEvidence appends supporting evidence to a belief: the stored resource input names, cited as
+// with CreateResource, or a hypothesis or action outcome. Evidence accumulates, and the belief's
+// confidence is recomputed from all of it.
+func (bsvc *BeliefService) AddEvidence(input *models.AddEvidenceInput) (*models.AddEvidenceOutput, error) {
+	var belief *models.Belief
+	var evidence []models.BeliefEvidence
+	var err error
+	switch {
+	case input.ResourceID != "":
+		if _, err := bsvc.retrieveResource(input.SelfModelID, input.ResourceID); err != nil {
+			return nil, err
+		}
+		belief, evidence, err = bsvc.citeResource(input.SelfModelID, input.BeliefID, input.ResourceID)
+	case input.Evidence != nil:
+		belief, err = bsvc.retrieveBeliefValue(input.SelfModelID, input.BeliefID)
+		if err != nil {
+			return nil, err
+		}
+		belief, evidence, err = bsvc.addEvidence(input.SelfModelID, belief, *input.Evidence)
+	default:
+		return nil, ErrNoEvidence
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AddEvidenceOutput{
+		Belief:   *belief,
+		Evidence: evidence,
+	}, nil
+}
+
+// ListBeliefEvidence returns the evidence given for a belief, oldest first.
+func (bsvc *BeliefService) ListBeliefEvidence(input *models.ListBeliefEvidenceInput) (*models.ListBeliefEvidenceOutput, error) {
+	if _, err := bsvc.retrieveBeliefValue(input.SelfModelID, input.BeliefID); err != nil {
+		return nil, err
+	}
+	evidenceLog, err := bsvc.retrieveEvidenceLog(input.SelfModelID, input.BeliefID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ListBeliefEvidenceOutput{Evidence: evidenceLog.Evidence}, nil
+}
+
+// addEvidence records evidence for belief and stores a new version of the belief with its
+// confidence recomputed from all of its evidence. The new confidence is also rated on the belief's
+// contexts, where the dialectic metrics read it.
+func (bsvc *BeliefService) addEvidence(selfModelID string, belief *models.Belief, evidence models.BeliefEvidence) (*models.Belief, []models.BeliefEvidence, error) {
+	accumulated, err := bsvc.recordEvidence(selfModelID, belief.ID, evidence)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	belief.Confidence = evidenceConfidence(accumulated)
+	belief.Version++
+	if err := bsvc.storeBeliefValue(selfModelID, belief); err != nil {
+		return nil, nil, fmt.Errorf("failed to store belief: %w", err)
+	}
+	if err := bsvc.rateBeliefContexts(selfModelID, belief.ID, belief.Confidence); err != nil {
+		return nil, nil, err
+	}
+	return belief, accumulated, nil
+}
+
+// rateBeliefContexts adds a confidence rating to every belief context of a belief in the stored
+// belief system, if there is one.
+func (bsvc *BeliefService) rateBeliefContexts(selfModelID, beliefID string, confidence float64) error {
+	beliefSystem, err := bsvc.storedBeliefSystem(selfModelID)
+	if errors.Is(err, ErrBeliefSystemNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rated := false
+	for _, ppc := range predictiveProcessingContexts(beliefSystem) {
+		for _, bc := range ppc.BeliefContexts {
+			if bc != nil && bc.BeliefID == beliefID {
+				bc.ConfidenceRatings = append(bc.ConfidenceRatings, models.ConfidenceRating{ConfidenceScore: confidence})
+				rated = true
+			}
+		}
+	}
+	if !rated {
+		return nil
+	}
+
+	err = storeBeliefSystem(bsvc.kvStore, bsvc.observer, selfModelID, beliefSystem)
+	if err != nil {
+		return fmt.Errorf("failed to store belief system: %w", err)
+	}
+	return nil
+}
+
+func (bsvc *BeliefService) retrieveEvidenceLog(selfModelID, beliefID string) (*models.BeliefEvidenceLog, error) {
+	value, err := bsvc.kvStore.Retrieve(selfModelID, beliefEvidenceKey(beliefID))
+	if errors.Is(err, db.ErrNotFound) {
+		// A belief nobody has given evidence for has no log yet
+		return &models.BeliefEvidenceLog{BeliefID: beliefID, Evidence: []models.BeliefEvidence{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve belief evidence: %w", err)
+	}
+	evidenceLog, ok := value.(*models.BeliefEvidenceLog)
+	if !ok {
+		return nil, fmt.Errorf("invalid belief evidence data type: %T", value)
+	}
+	// Logs stored before they were versioned were all stored under version 1
+	if evidenceLog.Version == 0 {
+		evidenceLog.Version = 1
+	}
+	return evidenceLog, nil
+}
+
+// recordEvidence appends evidence to the evidence log of a belief, returning the whole log. An
+// append that conflicts with a concurrent one is retried on the new log.
+func (bsvc *BeliefService) recordEvidence(selfModelID, beliefID string, evidence models.BeliefEvidence) ([]models.BeliefEvidence, error) {
+	if evidence.CreatedAtMillisUTC == 0 {
+		evidence.CreatedAtMillisUTC = time.Now().UnixMilli()
+	}
+	for attempt := 1; ; attempt++ {
+		evidenceLog, err := bsvc.retrieveEvidenceLog(selfModelID, beliefID)
+		if err != nil {
+			return nil, err
+		}
+		evidenceLog.Evidence = append(evidenceLog.Evidence, evidence)
+		evidenceLog.Version++
+
+		err = bsvc.kvStore.Store(selfModelID, beliefEvidenceKey(beliefID), *evidenceLog, int(evidenceLog.Version), db.DropOlderVersions())
+		if errors.Is(err, db.ErrVersionConflict) && attempt < maxLogWriteAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to store belief evidence: %w", err)
+		}
+		return evidenceLog.Evidence, nil
+	}
+}
@@ -0,0 +1,67 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"epistemic-me-core/svc/models"
+)
+
+// GetBeliefProvenance returns the question and answer a belief was extracted from, following its
+// SourceInteractionID to the dialectic holding that interaction. Beliefs not extracted from an
+// answer are reported as created directly.
+func (bsvc *BeliefService) GetBeliefProvenance(input *models.GetBeliefProvenanceInput) (*models.GetBeliefProvenanceOutput, error) {
+	belief, err := bsvc.findBelief(input.SelfModelID, input.BeliefID)
+	if err != nil {
+		return nil, err
+	}
+
+	provenance := models.BeliefProvenance{BeliefID: belief.ID, Source: models.BeliefSourceDirect}
+	if belief.SourceInteractionID == "" {
+		return &models.GetBeliefProvenanceOutput{Provenance: provenance}, nil
+	}
+	provenance.Source = models.BeliefSourceDialectic
+	provenance.InteractionID = belief.SourceInteractionID
+
+	dialectics, err := bsvc.kvStore.ListByType(input.SelfModelID, reflect.TypeOf(models.Dialectic{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve dialectics: %w", err)
+	}
+	for _, value := range dialectics {
+		dialectic, ok := value.(*models.Dialectic)
+		if !ok {
+			continue
+		}
+		for _, interaction := range dialectic.UserInteractions {
+			if interaction.ID != belief.SourceInteractionID {
+				continue
+			}
+			provenance.DialecticID = dialectic.ID
+			if qa := getQuestionAnswer(interaction.Interaction); qa != nil {
+				provenance.Question = qa.Question.Question
+				provenance.Answer = qa.Answer.UserAnswer
+				provenance.AnsweredAtMillisUTC = qa.Answer.CreatedAtMillisUTC
+			}
+			return &models.GetBeliefProvenanceOutput{Provenance: provenance}, nil
+		}
+	}
+	return &models.GetBeliefProvenanceOutput{Provenance: provenance}, nil
+}
+
+// findBelief looks a belief up in the self model's belief system, where beliefs extracted in
+// dialectics live, and then among the beliefs stored on their own.
+func (bsvc *BeliefService) findBelief(selfModelID, beliefID string) (*models.Belief, error) {
+	beliefSystem, err := bsvc.storedBeliefSystem(selfModelID)
+	if err != nil && !errors.Is(err, ErrBeliefSystemNotFound) {
+		return nil, err
+	}
+	if beliefSystem != nil {
+		for _, belief := range beliefSystem.Beliefs {
+			if belief != nil && belief.ID == beliefID {
+				return belief, nil
+			}
+		}
+	}
+	return bsvc.retrieveBeliefValue(selfModelID, beliefID)
+}
@@ -1,42 +1,140 @@
 package svc
 
 import (
+	"context"
 	ai "epistemic-me-core/ai"
 	db "epistemic-me-core/db"
+	"epistemic-me-core/logging"
 	"epistemic-me-core/svc/models"
+	"errors"
 	"fmt"
-	"log"
 	"reflect"
-	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-const (
-	LogLevelDebug = iota
-	LogLevelInfo
-	LogLevelWarn
-	LogLevelError
-)
-
-var currentLogLevel = LogLevelInfo
+// DefaultMaxBeliefHistory is the number of superseded versions kept per belief
+const DefaultMaxBeliefHistory = 50
 
 type BeliefService struct {
-	kvStore *db.KeyValueStore
-	ai      *ai.AIHelper
+	kvStore    db.KeyValueStore
+	ai         *ai.AIHelper
+	maxHistory int
+	// maxContentLength is the most characters a belief's content may hold
+	maxContentLength int
+	// observer, when set, is told about every change persisted to a belief system
+	observer BeliefSystemObserver
+	// idempotency remembers the beliefs created for idempotency keys
+	idempotency *idempotencyKeys
+	// inferPossibleStates, when set, fills in the states of observation contexts during
+	// conceptualization
+	inferPossibleStates bool
+	ctx                 context.Context
 }
 
 // NewBeliefService initializes and returns a new BeliefService.
-func NewBeliefService(kvStore *db.KeyValueStore, ai *ai.AIHelper) *BeliefService {
+func NewBeliefService(kvStore db.KeyValueStore, ai *ai.AIHelper) *BeliefService {
 	return &BeliefService{
-		kvStore: kvStore,
-		ai:      ai,
+		kvStore:          kvStore,
+		ai:               ai,
+		maxHistory:       DefaultMaxBeliefHistory,
+		maxContentLength: DefaultMaxContentLength,
+		idempotency:      newIdempotencyKeys(kvStore),
+	}
+}
+
+// SetIdempotencyTTL sets how long the idempotency keys of CreateBelief requests are remembered.
+func (bsvc *BeliefService) SetIdempotencyTTL(ttl time.Duration) {
+	bsvc.idempotency.ttl = ttl
+}
+
+// WithContext returns a copy of the service that tags its log lines with the request carried by ctx
+// and makes its AI calls within ctx.
+func (bsvc *BeliefService) WithContext(ctx context.Context) *BeliefService {
+	scoped := *bsvc
+	scoped.ctx = ctx
+	if bsvc.ai != nil {
+		scoped.ai = bsvc.ai.WithContext(ctx)
+	}
+	return &scoped
+}
+
+func (bsvc *BeliefService) context() context.Context {
+	if bsvc.ctx == nil {
+		return context.Background()
 	}
+	return bsvc.ctx
+}
+
+// SetBeliefSystemObserver makes the service tell observer about every change it persists to a
+// belief system.
+func (bsvc *BeliefService) SetBeliefSystemObserver(observer BeliefSystemObserver) {
+	bsvc.observer = observer
+}
+
+// SetMaxHistory sets how many superseded versions are kept per belief, dropping the oldest first.
+func (bsvc *BeliefService) SetMaxHistory(maxHistory int) {
+	bsvc.maxHistory = maxHistory
+}
+
+// SetMaxContentLength sets how many characters a belief's content may hold. Zero allows any length.
+func (bsvc *BeliefService) SetMaxContentLength(maxLength int) {
+	bsvc.maxContentLength = maxLength
 }
 
 func (bsvc *BeliefService) CreateBelief(input *models.CreateBeliefInput) (*models.CreateBeliefOutput, error) {
+	content, err := validateContent("belief content", input.BeliefContent, bsvc.maxContentLength)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := *input
+	trimmed.BeliefContent = content
+	input = &trimmed
+
 	newBeliefId := "bi_" + uuid.New().String()
 
+	// A dry run stores nothing, so it neither claims nor replays an idempotency key
+	if input.DryRun {
+		return bsvc.createBelief(input, newBeliefId)
+	}
+	existingID, err := bsvc.idempotency.claim(input.RequesterID, idempotentCreateBelief, input.IdempotencyKey, input.SelfModelID, newBeliefId)
+	if err != nil {
+		return nil, err
+	}
+	if existingID != "" {
+		return bsvc.createdBelief(input.SelfModelID, existingID)
+	}
+	output, err := bsvc.createBelief(input, newBeliefId)
+	if err != nil {
+		bsvc.idempotency.release(input.RequesterID, idempotentCreateBelief, input.IdempotencyKey)
+		return nil, err
+	}
+	return output, nil
+}
+
+// createdBelief returns the output of the CreateBelief request that created beliefID.
+func (bsvc *BeliefService) createdBelief(selfModelID, beliefID string) (*models.CreateBeliefOutput, error) {
+	belief, err := bsvc.retrieveBeliefValue(selfModelID, beliefID)
+	if errors.Is(err, ErrBeliefNotFound) {
+		return nil, ErrRequestInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+	beliefSystem, err := bsvc.retrieveBeliefSystem(selfModelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve belief system: %w", err)
+	}
+	return &models.CreateBeliefOutput{
+		Belief:       *belief,
+		BeliefSystem: *beliefSystem,
+	}, nil
+}
+
+// createBelief adds a new belief to the self model's belief system. A dry run builds the same
+// belief and belief system without storing the belief, its evidence or the belief system.
+func (bsvc *BeliefService) createBelief(input *models.CreateBeliefInput, newBeliefId string) (*models.CreateBeliefOutput, error) {
 	belief := models.Belief{
 		ID:          newBeliefId,
 		SelfModelID: input.SelfModelID,
@@ -45,19 +143,30 @@ func (bsvc *BeliefService) CreateBelief(input *models.CreateBeliefInput) (*model
 		Version:     1,
 		Active:      true,
 	}
+	if input.BeliefEvidence != nil {
+		belief.Confidence = evidenceConfidence([]models.BeliefEvidence{*input.BeliefEvidence})
+	}
 
 	// Try to get existing belief system or create new one
-	beliefSystem, err := bsvc.retrieveBeliefSystem(input.SelfModelID)
+	var beliefSystem *models.BeliefSystem
+	var err error
+	if input.DryRun {
+		beliefSystem, err = bsvc.previewBeliefSystem(input.SelfModelID)
+	} else {
+		beliefSystem, err = bsvc.retrieveBeliefSystem(input.SelfModelID)
+	}
 	if err != nil {
-		if strings.Contains(err.Error(), "key not found") {
-			// Create new belief system
-			beliefSystem = &models.BeliefSystem{
-				Beliefs:           make([]*models.Belief, 0),
-				EpistemicContexts: make([]*models.EpistemicContext, 0),
-			}
-		} else {
-			return nil, fmt.Errorf("failed to retrieve belief system: %w", err)
-		}
+		return nil, fmt.Errorf("failed to retrieve belief system: %w", err)
+	}
+
+	// Create a copy of the belief to store in the belief system
+	beliefCopy := belief // Make a copy of the belief value
+	beliefSystem.Beliefs = append(beliefSystem.Beliefs, &beliefCopy)
+	if input.DryRun {
+		return &models.CreateBeliefOutput{
+			Belief:       belief,
+			BeliefSystem: *beliefSystem,
+		}, nil
 	}
 
 	// Store the belief first
@@ -66,12 +175,14 @@ func (bsvc *BeliefService) CreateBelief(input *models.CreateBeliefInput) (*model
 		return nil, fmt.Errorf("failed to store belief: %w", err)
 	}
 
-	// Create a copy of the belief to store in the belief system
-	beliefCopy := belief // Make a copy of the belief value
-	beliefSystem.Beliefs = append(beliefSystem.Beliefs, &beliefCopy)
+	if input.BeliefEvidence != nil {
+		if _, err := bsvc.recordEvidence(input.SelfModelID, belief.ID, *input.BeliefEvidence); err != nil {
+			return nil, err
+		}
+	}
 
 	// Store updated belief system
-	err = bsvc.kvStore.Store(input.SelfModelID, "BeliefSystem", *beliefSystem, 1)
+	err = storeBeliefSystem(bsvc.kvStore, bsvc.observer, input.SelfModelID, beliefSystem)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store belief system: %w", err)
 	}
@@ -85,7 +196,7 @@ func (bsvc *BeliefService) CreateBelief(input *models.CreateBeliefInput) (*model
 func (bsvc *BeliefService) UpdateBelief(input *models.UpdateBeliefInput) (*models.UpdateBeliefOutput, error) {
 	existingBelief, err := bsvc.retrieveBeliefValue(input.SelfModelID, input.ID)
 	if err != nil {
-		logf(LogLevelError, "Error in Retrieve: %v", err)
+		logging.Errorf(bsvc.context(), "Error in Retrieve: %v", err)
 		return nil, err
 	}
 
@@ -97,7 +208,7 @@ func (bsvc *BeliefService) UpdateBelief(input *models.UpdateBeliefInput) (*model
 	if !input.DryRun {
 		err = bsvc.storeBeliefValue(input.SelfModelID, existingBelief)
 		if err != nil {
-			log.Printf("Error in Store: %v", err)
+			logging.Errorf(bsvc.context(), "Error in Store: %v", err)
 			return nil, err
 		}
 	}
@@ -107,7 +218,7 @@ func (bsvc *BeliefService) UpdateBelief(input *models.UpdateBeliefInput) (*model
 	beliefSystem, err := bsvc.GetBeliefSystemFromBeliefs([]*models.Belief{existingBelief})
 
 	if err != nil {
-		logf(LogLevelError, "Error in getBeliefSystemFromBeliefs: %v", err)
+		logging.Errorf(bsvc.context(), "Error in getBeliefSystemFromBeliefs: %v", err)
 		return nil, err
 	}
 
@@ -117,20 +228,33 @@ func (bsvc *BeliefService) UpdateBelief(input *models.UpdateBeliefInput) (*model
 	}, nil
 }
 
+// DeleteBelief deactivates a belief and removes what refers to it: its belief contexts and
+// associations in the belief system, and its entries in the extracted beliefs of every dialectic.
 func (bsvc *BeliefService) DeleteBelief(input *models.DeleteBeliefInput) (*models.DeleteBeliefOutput, error) {
 	existingBelief, err := bsvc.retrieveBeliefValue(input.SelfModelID, input.ID)
 	if err != nil {
-		logf(LogLevelError, "Error in Retrieve: %v", err)
+		logging.Errorf(bsvc.context(), "Error in Retrieve: %v", err)
 		return nil, err
 	}
 
 	existingBelief.Active = false
 	existingBelief.Version++
 	// todo: @deen update temporal information
+	var dialecticIDs []string
 	if !input.DryRun {
 		err = bsvc.storeBeliefValue(input.SelfModelID, existingBelief)
 		if err != nil {
-			log.Printf("Error in Store: %v", err)
+			logging.Errorf(bsvc.context(), "Error in Store: %v", err)
+			return nil, err
+		}
+
+		err = bsvc.removeBeliefFromBeliefSystem(input.SelfModelID, existingBelief.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		dialecticIDs, err = bsvc.removeExtractedBelief(input.SelfModelID, existingBelief.ID)
+		if err != nil {
 			return nil, err
 		}
 	}
@@ -139,7 +263,7 @@ func (bsvc *BeliefService) DeleteBelief(input *models.DeleteBeliefInput) (*model
 	if input.ComputeBeliefSystem {
 		beliefSystem, err = bsvc.GetBeliefSystemFromBeliefs([]*models.Belief{existingBelief})
 		if err != nil {
-			logf(LogLevelError, "Error in getBeliefSystemFromBeliefs: %v", err)
+			logging.Errorf(bsvc.context(), "Error in getBeliefSystemFromBeliefs: %v", err)
 			return nil, err
 		}
 	}
@@ -147,26 +271,334 @@ func (bsvc *BeliefService) DeleteBelief(input *models.DeleteBeliefInput) (*model
 	return &models.DeleteBeliefOutput{
 		Belief:       *existingBelief,
 		BeliefSystem: *beliefSystem,
+		DialecticIDs: dialecticIDs,
 	}, nil
 }
 
-func (bsvc *BeliefService) ListBeliefs(input *models.ListBeliefsInput) (*models.ListBeliefsOutput, error) {
-	logf(LogLevelDebug, "ListBeliefs called with input: %+v", input)
+// removeBeliefFromBeliefSystem drops the belief contexts and epistemic context associations of a
+// deleted belief from the stored belief system, if there is one.
+func (bsvc *BeliefService) removeBeliefFromBeliefSystem(selfModelID, beliefID string) error {
+	beliefSystem, err := bsvc.storedBeliefSystem(selfModelID)
+	if errors.Is(err, ErrBeliefSystemNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 
-	// Use ListByType to get all Belief objects for the user
-	beliefObjects, err := bsvc.kvStore.ListByType(input.SelfModelID, reflect.TypeOf(models.Belief{}))
+	for _, ec := range beliefSystem.EpistemicContexts {
+		if ec == nil {
+			continue
+		}
+		associated := make([]string, 0, len(ec.AssociatedBeleifs))
+		for _, id := range ec.AssociatedBeleifs {
+			if id != beliefID {
+				associated = append(associated, id)
+			}
+		}
+		ec.AssociatedBeleifs = associated
+
+		if ppc := ec.PredictiveProcessingContext; ppc != nil {
+			beliefContexts := make([]*models.BeliefContext, 0, len(ppc.BeliefContexts))
+			for _, bc := range ppc.BeliefContexts {
+				if bc != nil && bc.BeliefID != beliefID {
+					beliefContexts = append(beliefContexts, bc)
+				}
+			}
+			ppc.BeliefContexts = beliefContexts
+		}
+	}
+
+	err = storeBeliefSystem(bsvc.kvStore, bsvc.observer, selfModelID, beliefSystem)
 	if err != nil {
-		return nil, fmt.Errorf("error retrieving beliefs: %v", err)
+		return fmt.Errorf("failed to store belief system: %w", err)
+	}
+	return nil
+}
+
+// removeExtractedBelief strips a deleted belief from the extracted beliefs of the self model's
+// dialectics and returns the IDs of the dialectics that changed.
+func (bsvc *BeliefService) removeExtractedBelief(selfModelID, beliefID string) ([]string, error) {
+	values, err := bsvc.kvStore.ListByType(selfModelID, reflect.TypeOf(models.Dialectic{}))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving dialectics: %w", err)
+	}
+
+	dialecticIDs := make([]string, 0)
+	for _, value := range values {
+		dialectic, ok := value.(*models.Dialectic)
+		if !ok {
+			continue
+		}
+
+		changed := false
+		for _, interaction := range dialectic.UserInteractions {
+			qa := getQuestionAnswer(interaction.Interaction)
+			if qa == nil {
+				continue
+			}
+			extracted := make([]*models.Belief, 0, len(qa.ExtractedBeliefs))
+			for _, belief := range qa.ExtractedBeliefs {
+				if belief != nil && belief.ID == beliefID {
+					changed = true
+					continue
+				}
+				extracted = append(extracted, belief)
+			}
+			qa.ExtractedBeliefs = extracted
+		}
+		if !changed {
+			continue
+		}
+
+		if dialectic.Version == 0 {
+			dialectic.Version = int32(len(dialectic.UserInteractions))
+		}
+		dialectic.Version++
+		err = bsvc.kvStore.Store(selfModelID, dialectic.ID, *dialectic, int(dialectic.Version))
+		if err != nil {
+			return nil, fmt.Errorf("failed to store dialectic %s: %w", dialectic.ID, err)
+		}
+		dialecticIDs = append(dialecticIDs, dialectic.ID)
+	}
+	return dialecticIDs, nil
+}
+
+// MergeBeliefs consolidates several overlapping beliefs into a single belief synthesized by the AIHelper.
+// The merged beliefs are deactivated and every reference to them in the belief system is repointed
+// to the consolidated belief.
+func (bsvc *BeliefService) MergeBeliefs(input *models.MergeBeliefsInput) (*models.MergeBeliefsOutput, error) {
+	if len(input.BeliefIDs) < 2 {
+		return nil, fmt.Errorf("at least two beliefs are required to merge, got %d", len(input.BeliefIDs))
+	}
+
+	mergedIDs := make(map[string]bool, len(input.BeliefIDs))
+	existingBeliefs := make([]*models.Belief, 0, len(input.BeliefIDs))
+	beliefStrs := make([]string, 0, len(input.BeliefIDs))
+	for _, id := range input.BeliefIDs {
+		if mergedIDs[id] {
+			return nil, fmt.Errorf("belief %s is listed more than once", id)
+		}
+		mergedIDs[id] = true
+
+		belief, err := bsvc.retrieveBeliefValue(input.SelfModelID, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve belief %s: %w", id, err)
+		}
+		if !belief.Active {
+			return nil, fmt.Errorf("belief %s is not active", id)
+		}
+		existingBeliefs = append(existingBeliefs, belief)
+		beliefStrs = append(beliefStrs, belief.GetContentAsString())
+	}
+
+	mergedContent, err := bsvc.ai.MergeBeliefs(beliefStrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge beliefs: %w", err)
+	}
+
+	mergedBelief := models.Belief{
+		ID:          "bi_" + uuid.New().String(),
+		SelfModelID: input.SelfModelID,
+		Content:     []models.Content{{RawStr: mergedContent}},
+		Type:        existingBeliefs[0].Type,
+		Version:     1,
+		Active:      true,
+	}
+	err = bsvc.storeBeliefValue(input.SelfModelID, &mergedBelief)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store merged belief: %w", err)
+	}
+
+	// Retire the beliefs that were merged
+	for _, belief := range existingBeliefs {
+		belief.Active = false
+		belief.Version++
+		err = bsvc.storeBeliefValue(input.SelfModelID, belief)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deactivate belief %s: %w", belief.ID, err)
+		}
+	}
+
+	beliefSystem, err := bsvc.retrieveBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve belief system: %w", err)
+	}
+	repointBeliefReferences(beliefSystem, mergedIDs, mergedBelief.ID)
+
+	err = storeBeliefSystem(bsvc.kvStore, bsvc.observer, input.SelfModelID, beliefSystem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store belief system: %w", err)
+	}
+
+	return &models.MergeBeliefsOutput{
+		Belief:       mergedBelief,
+		BeliefSystem: *beliefSystem,
+	}, nil
+}
+
+// repointBeliefReferences rewrites every reference to one of oldIDs in the belief system to newID.
+// Belief contexts that end up describing the same observation context are combined into one.
+func repointBeliefReferences(beliefSystem *models.BeliefSystem, oldIDs map[string]bool, newID string) {
+	for _, ec := range beliefSystem.EpistemicContexts {
+		if ec == nil {
+			continue
+		}
+		ec.AssociatedBeleifs = repointIDs(ec.AssociatedBeleifs, oldIDs, newID)
+
+		ppc := ec.PredictiveProcessingContext
+		if ppc == nil {
+			continue
+		}
+
+		repointed := make(map[string]*models.BeliefContext)
+		beliefContexts := make([]*models.BeliefContext, 0, len(ppc.BeliefContexts))
+		for _, bc := range ppc.BeliefContexts {
+			if bc == nil || !oldIDs[bc.BeliefID] {
+				beliefContexts = append(beliefContexts, bc)
+				continue
+			}
+
+			bc.BeliefID = newID
+			if existing, ok := repointed[bc.ObservationContextID]; ok {
+				existing.ConfidenceRatings = append(existing.ConfidenceRatings, bc.ConfidenceRatings...)
+				existing.Evidence = append(existing.Evidence, bc.Evidence...)
+				existing.DialecticInteractionIDs = append(existing.DialecticInteractionIDs, bc.DialecticInteractionIDs...)
+				continue
+			}
+			repointed[bc.ObservationContextID] = bc
+			beliefContexts = append(beliefContexts, bc)
+		}
+		ppc.BeliefContexts = beliefContexts
+	}
+}
+
+// repointIDs replaces any of oldIDs in ids with a single occurrence of newID
+func repointIDs(ids []string, oldIDs map[string]bool, newID string) []string {
+	if len(ids) == 0 {
+		return ids
+	}
+
+	result := make([]string, 0, len(ids))
+	added := false
+	for _, id := range ids {
+		if oldIDs[id] || id == newID {
+			if !added {
+				result = append(result, newID)
+				added = true
+			}
+			continue
+		}
+		result = append(result, id)
+	}
+	return result
+}
+
+// GetBelief returns an active belief and the belief contexts that refer to it, without assembling
+// the rest of the belief system.
+func (bsvc *BeliefService) GetBelief(input *models.GetBeliefInput) (*models.GetBeliefOutput, error) {
+	belief, err := bsvc.retrieveBeliefValue(input.SelfModelID, input.BeliefID)
+	if err != nil {
+		return nil, err
+	}
+	if !belief.Active {
+		return nil, fmt.Errorf("%w: %s has been deleted", ErrBeliefNotFound, input.BeliefID)
 	}
 
-	// Convert the list of interface{} to []*models.Belief
-	beliefs := make([]*models.Belief, 0)
-	for _, obj := range beliefObjects {
-		if belief, ok := obj.(*models.Belief); ok {
-			beliefs = append(beliefs, belief)
+	beliefContexts := make([]*models.BeliefContext, 0)
+	beliefSystem, err := bsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil && !errors.Is(err, ErrBeliefSystemNotFound) {
+		return nil, err
+	}
+	if beliefSystem != nil {
+		for _, ppc := range predictiveProcessingContexts(beliefSystem) {
+			for _, bc := range ppc.BeliefContexts {
+				if bc != nil && bc.BeliefID == belief.ID {
+					beliefContexts = append(beliefContexts, bc)
+				}
+			}
 		}
 	}
 
+	return &models.GetBeliefOutput{
+		Belief:         *belief,
+		BeliefContexts: beliefContexts,
+	}, nil
+}
+
+// GetBeliefHistory returns the current version of a belief along with its superseded versions ordered by version.
+func (bsvc *BeliefService) GetBeliefHistory(input *models.GetBeliefHistoryInput) (*models.GetBeliefHistoryOutput, error) {
+	belief, err := bsvc.retrieveBeliefValue(input.SelfModelID, input.BeliefID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := bsvc.retrieveBeliefHistory(input.SelfModelID, input.BeliefID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GetBeliefHistoryOutput{
+		Belief:  *belief,
+		History: history.Versions,
+	}, nil
+}
+
+// ListObservationContexts returns the observation contexts of every epistemic context in a self
+// model's belief system, keeping the first of any that share an ID. RootsOnly keeps only contexts
+// without a parent. AsTree also nests all of them by their parents, with any structural warnings.
+func (bsvc *BeliefService) ListObservationContexts(input *models.ListObservationContextsInput) (*models.ListObservationContextsOutput, error) {
+	beliefSystem, err := bsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]*models.ObservationContext, 0)
+	seen := make(map[string]bool)
+	for _, epistemicContext := range beliefSystem.EpistemicContexts {
+		if epistemicContext == nil || epistemicContext.PredictiveProcessingContext == nil {
+			continue
+		}
+		for _, oc := range epistemicContext.PredictiveProcessingContext.ObservationContexts {
+			if oc == nil || seen[oc.ID] {
+				continue
+			}
+			seen[oc.ID] = true
+			all = append(all, oc)
+		}
+	}
+
+	output := &models.ListObservationContextsOutput{ObservationContexts: all}
+	if input.RootsOnly {
+		output.ObservationContexts = make([]*models.ObservationContext, 0)
+		for _, oc := range all {
+			if oc.ParentID == "" {
+				output.ObservationContexts = append(output.ObservationContexts, oc)
+			}
+		}
+	}
+	if input.AsTree {
+		output.Tree = models.BuildObservationContextTree(all)
+		for _, warning := range output.Tree.Warnings {
+			logging.Warnf(bsvc.context(), "Observation contexts of %s: %s", input.SelfModelID, warning)
+		}
+	}
+	return output, nil
+}
+
+func (bsvc *BeliefService) ListBeliefs(input *models.ListBeliefsInput) (*models.ListBeliefsOutput, error) {
+	logging.Debugf(bsvc.context(), "ListBeliefs called with input: %+v", input)
+
+	// Use ListByType to get all Belief objects for the user, or for the users in the cohort
+	beliefValues, err := listForCohort[models.Belief](bsvc.kvStore, input.DeveloperID, input.SelfModelID, input.Cohort)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving beliefs: %v", err)
+	}
+	beliefs := make([]*models.Belief, 0, len(beliefValues))
+	for i := range beliefValues {
+		beliefs = append(beliefs, &beliefValues[i])
+	}
+
 	// If specific belief IDs are provided, filter the beliefs
 	if len(input.BeliefIDs) > 0 {
 		filteredBeliefs := make([]*models.Belief, 0)
@@ -181,6 +613,16 @@ func (bsvc *BeliefService) ListBeliefs(input *models.ListBeliefsInput) (*models.
 		beliefs = filteredBeliefs
 	}
 
+	if len(input.Tags) > 0 {
+		taggedBeliefs := make([]*models.Belief, 0)
+		for _, belief := range beliefs {
+			if hasTags(belief, input.Tags) {
+				taggedBeliefs = append(taggedBeliefs, belief)
+			}
+		}
+		beliefs = taggedBeliefs
+	}
+
 	// Only return active beliefs
 	activeBeliefs := make([]*models.Belief, 0)
 	for _, belief := range beliefs {
@@ -191,7 +633,7 @@ func (bsvc *BeliefService) ListBeliefs(input *models.ListBeliefsInput) (*models.
 
 	beliefSystem, err := bsvc.GetBeliefSystemFromBeliefs(beliefs)
 	if err != nil {
-		logf(LogLevelError, "Error in getBeliefSystemFromBeliefs: %v", err)
+		logging.Errorf(bsvc.context(), "Error in getBeliefSystemFromBeliefs: %v", err)
 		return nil, err
 	}
 
@@ -202,7 +644,7 @@ func (bsvc *BeliefService) ListBeliefs(input *models.ListBeliefsInput) (*models.
 }
 
 func (bsvc *BeliefService) GetBeliefSystemFromBeliefs(beliefs []*models.Belief) (*models.BeliefSystem, error) {
-	logf(LogLevelDebug, "getBeliefSystemFromBeliefs called with %d beliefs", len(beliefs))
+	logging.Debugf(bsvc.context(), "getBeliefSystemFromBeliefs called with %d beliefs", len(beliefs))
 
 	return &models.BeliefSystem{
 		Beliefs: beliefs,
@@ -246,14 +688,81 @@ func (bsvc *BeliefService) beliefMatchesContexts(belief *models.Belief, contextI
 
 // Add this method to BeliefService
 func (bsvc *BeliefService) storeBeliefValue(selfModelID string, belief *models.Belief) error {
-	return bsvc.kvStore.Store(selfModelID, belief.ID, *belief, int(belief.Version))
+	// Keep the version being superseded so the belief's evolution can be audited
+	var previous *models.Belief
+	if belief.Version > 1 {
+		if value, err := bsvc.kvStore.Retrieve(selfModelID, belief.ID); err == nil {
+			previous, _ = value.(*models.Belief)
+		}
+	}
+
+	err := bsvc.kvStore.Store(selfModelID, belief.ID, *belief, int(belief.Version))
+	if err != nil {
+		return err
+	}
+
+	if previous != nil {
+		return bsvc.appendBeliefHistory(selfModelID, previous)
+	}
+	return nil
+}
+
+func beliefHistoryKey(beliefID string) string {
+	return beliefID + ":history"
+}
+
+func (bsvc *BeliefService) retrieveBeliefHistory(selfModelID, beliefID string) (*models.BeliefHistory, error) {
+	value, err := bsvc.kvStore.Retrieve(selfModelID, beliefHistoryKey(beliefID))
+	if err != nil {
+		// A belief that has never been updated has no history yet
+		return &models.BeliefHistory{BeliefID: beliefID, Versions: []models.Belief{}}, nil
+	}
+	history, ok := value.(*models.BeliefHistory)
+	if !ok {
+		return nil, fmt.Errorf("invalid belief history data type: %T", value)
+	}
+	// Histories stored before they were versioned were all stored under version 1
+	if history.Version == 0 {
+		history.Version = 1
+	}
+	return history, nil
+}
+
+// maxLogWriteAttempts is how often an append to a belief's history or evidence log is retried
+// when another append to it lands first.
+const maxLogWriteAttempts = 10
+
+// appendBeliefHistory records a superseded belief version, dropping the oldest versions beyond
+// maxHistory. An append that conflicts with a concurrent one is retried on the new history.
+func (bsvc *BeliefService) appendBeliefHistory(selfModelID string, belief *models.Belief) error {
+	for attempt := 1; ; attempt++ {
+		history, err := bsvc.retrieveBeliefHistory(selfModelID, belief.ID)
+		if err != nil {
+			return err
+		}
+
+		history.Versions = append(history.Versions, *belief)
+		if bsvc.maxHistory > 0 && len(history.Versions) > bsvc.maxHistory {
+			history.Versions = history.Versions[len(history.Versions)-bsvc.maxHistory:]
+		}
+		history.Version++
+
+		err = bsvc.kvStore.Store(selfModelID, beliefHistoryKey(belief.ID), *history, int(history.Version), db.DropOlderVersions())
+		if errors.Is(err, db.ErrVersionConflict) && attempt < maxLogWriteAttempts {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store belief history: %w", err)
+		}
+		return nil
+	}
 }
 
 // Add this method to BeliefService
 func (bsvc *BeliefService) retrieveBeliefValue(selfModelID, beliefID string) (*models.Belief, error) {
 	value, err := bsvc.kvStore.Retrieve(selfModelID, beliefID)
 	if err != nil {
-		return nil, err
+		return nil, wrapNotFound(err, ErrBeliefNotFound)
 	}
 	belief, ok := value.(*models.Belief)
 	if !ok {
@@ -262,22 +771,24 @@ func (bsvc *BeliefService) retrieveBeliefValue(selfModelID, beliefID string) (*m
 	return belief, nil
 }
 
-func logf(level int, format string, v ...interface{}) {
-	if level >= currentLogLevel {
-		log.Printf(format, v...)
-	}
-}
-
 // Add this method to BeliefService
 func (bsvc *BeliefService) retrieveBeliefSystem(selfModelID string) (*models.BeliefSystem, error) {
 	value, err := bsvc.kvStore.Retrieve(selfModelID, "BeliefSystem")
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return nil, fmt.Errorf("failed to retrieve belief system: %w", err)
+	}
 	if err != nil || value == nil {
 		// Create a new belief system if one doesn't exist
 		beliefSystem := &models.BeliefSystem{
 			Beliefs:           make([]*models.Belief, 0),
 			EpistemicContexts: make([]*models.EpistemicContext, 0),
+			Version:           beliefSystemVersion,
+		}
+		err = bsvc.kvStore.Store(selfModelID, "BeliefSystem", *beliefSystem, beliefSystemVersion)
+		if errors.Is(err, db.ErrVersionConflict) {
+			// Created concurrently, so use that one
+			return bsvc.retrieveBeliefSystem(selfModelID)
 		}
-		err = bsvc.kvStore.Store(selfModelID, "BeliefSystem", *beliefSystem, 1)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create initial belief system: %v", err)
 		}
@@ -304,20 +815,235 @@ func (bsvc *BeliefService) retrieveBeliefSystem(selfModelID string) (*models.Bel
 	return beliefSystem, nil
 }
 
+// beliefSystemVersion is the version a belief system is first stored under, and the one taken for
+// a belief system stored before belief systems were versioned.
+const beliefSystemVersion = 1
+
+// maxBeliefSystemWriteAttempts is how often storeBeliefSystem retries a write that conflicts with
+// another one before giving up.
+const maxBeliefSystemWriteAttempts = 10
+
+// storeBeliefSystem stores a self model's belief system and tells observer what changed compared
+// with the stored one. The write is versioned: when the belief system was written since
+// beliefSystem was read, the changes made to beliefSystem since that read are merged into the
+// stored one, so concurrent writers keep each other's beliefs. A belief system that was not read
+// from the store, with Version 0, is merged as if every belief in it were new. On success
+// beliefSystem holds what was stored.
+func storeBeliefSystem(kvStore db.KeyValueStore, observer BeliefSystemObserver, selfModelID string, beliefSystem *models.BeliefSystem) error {
+	for attempt := 1; ; attempt++ {
+		stored, storedVersion, err := latestBeliefSystem(kvStore, selfModelID)
+		if err != nil {
+			return err
+		}
+		next := *beliefSystem
+		switch {
+		case stored == nil:
+		case int(beliefSystem.Version) == storedVersion:
+		// Both unversioned: beliefSystem was read from a belief system stored before versioning
+		case beliefSystem.Version == 0 && stored.Version == 0:
+		default:
+			var base *models.BeliefSystem
+			if beliefSystem.Version != 0 {
+				if base, err = beliefSystemAtVersion(kvStore, selfModelID, int(beliefSystem.Version)); err != nil {
+					return err
+				}
+			}
+			next = mergeBeliefSystem(base, beliefSystem, stored)
+		}
+		next.Version = int32(storedVersion + 1)
+
+		err = kvStore.Store(selfModelID, "BeliefSystem", next, int(next.Version))
+		if errors.Is(err, db.ErrVersionConflict) && attempt < maxBeliefSystemWriteAttempts {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		*beliefSystem = next
+		if observer != nil {
+			if diff := models.DiffBeliefSystems(stored, &next); !diff.Empty() {
+				observer.BeliefSystemChanged(selfModelID, diff)
+			}
+		}
+		return nil
+	}
+}
+
+// latestBeliefSystem returns the stored belief system of a self model with its version, or nil
+// when none is stored.
+func latestBeliefSystem(kvStore db.KeyValueStore, selfModelID string) (*models.BeliefSystem, int, error) {
+	value, err := kvStore.Retrieve(selfModelID, "BeliefSystem")
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve belief system: %w", err)
+	}
+	stored, ok := value.(*models.BeliefSystem)
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid belief system data type: %T", value)
+	}
+	if stored.Version == 0 {
+		return stored, beliefSystemVersion, nil
+	}
+	return stored, int(stored.Version), nil
+}
+
+// beliefSystemAtVersion returns the belief system a self model had at version, or nil when that
+// version is no longer stored.
+func beliefSystemAtVersion(kvStore db.KeyValueStore, selfModelID string, version int) (*models.BeliefSystem, error) {
+	values, err := kvStore.RetrieveAllVersions(selfModelID, "BeliefSystem")
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve belief system versions: %w", err)
+	}
+	for _, value := range values {
+		beliefSystem, ok := value.(*models.BeliefSystem)
+		if !ok {
+			continue
+		}
+		if stored := int(beliefSystem.Version); stored == version || (stored == 0 && version == beliefSystemVersion) {
+			return beliefSystem, nil
+		}
+	}
+	return nil, nil
+}
+
+// mergeBeliefSystem applies the changes made from base to changed onto stored: beliefs added or
+// updated in changed replace those with the same ID in stored, beliefs removed from it are removed
+// from stored, and its epistemic contexts are kept if changed altered them. A nil base counts every
+// belief of changed as added.
+func mergeBeliefSystem(base, changed, stored *models.BeliefSystem) models.BeliefSystem {
+	diff := models.DiffBeliefSystems(base, changed)
+	merged := models.BeliefSystem{EpistemicContexts: stored.EpistemicContexts}
+	if diff.EpistemicContextsChanged {
+		merged.EpistemicContexts = changed.EpistemicContexts
+	}
+
+	removed := make(map[string]bool, len(diff.RemovedBeliefIDs))
+	for _, id := range diff.RemovedBeliefIDs {
+		removed[id] = true
+	}
+	replacements := make(map[string]*models.Belief, len(diff.AddedBeliefs)+len(diff.UpdatedBeliefs))
+	for _, belief := range append(diff.AddedBeliefs, diff.UpdatedBeliefs...) {
+		replacements[belief.ID] = belief
+	}
+	for _, belief := range stored.Beliefs {
+		if belief == nil || removed[belief.ID] {
+			continue
+		}
+		if replacement, ok := replacements[belief.ID]; ok {
+			belief = replacement
+			delete(replacements, belief.ID)
+		}
+		merged.Beliefs = append(merged.Beliefs, belief)
+	}
+	// What is left was added, or updated after stored removed it, in changed's order
+	for _, belief := range changed.Beliefs {
+		if belief != nil && replacements[belief.ID] != nil {
+			merged.Beliefs = append(merged.Beliefs, belief)
+			delete(replacements, belief.ID)
+		}
+	}
+	if merged.Beliefs == nil {
+		merged.Beliefs = []*models.Belief{}
+	}
+	return merged
+}
+
+// previewBeliefSystem returns the belief system as retrieveBeliefSystem does, but for a self model
+// without one it returns an empty belief system instead of storing it.
+func (bsvc *BeliefService) previewBeliefSystem(selfModelID string) (*models.BeliefSystem, error) {
+	if _, err := bsvc.kvStore.Retrieve(selfModelID, "BeliefSystem"); errors.Is(err, db.ErrNotFound) {
+		return &models.BeliefSystem{
+			Beliefs:           make([]*models.Belief, 0),
+			EpistemicContexts: make([]*models.EpistemicContext, 0),
+		}, nil
+	}
+	return bsvc.retrieveBeliefSystem(selfModelID)
+}
+
 // Add this method to the BeliefService
 func (bsvc *BeliefService) GetBeliefSystem(selfModelID string) (*models.BeliefSystem, error) {
 	beliefSystem, err := bsvc.retrieveBeliefSystem(selfModelID)
 	if err != nil {
-		logf(LogLevelError, "Error retrieving belief system: %v", err)
+		logging.Errorf(bsvc.context(), "Error retrieving belief system: %v", err)
 		return nil, fmt.Errorf("error retrieving belief system: %v", err)
 	}
 
 	return beliefSystem, nil
 }
 
+// SetInferPossibleStates turns on inferring, during conceptualization, the possible states of
+// observation contexts that have none. Each inference is an AI call.
+func (bsvc *BeliefService) SetInferPossibleStates(infer bool) {
+	bsvc.inferPossibleStates = infer
+}
+
 // Add these methods to BeliefService
 func (bsvc *BeliefService) ConceptualizeBeliefSystem(beliefSystem *models.BeliefSystem) error {
 	// TODO: Implementation will use AI helper to generate conceptualization
+	if bsvc.inferPossibleStates {
+		return bsvc.inferObservationContextStates(beliefSystem)
+	}
+	return nil
+}
+
+// inferObservationContextStates gives every observation context without possible states the states
+// inferred from the beliefs linked to it, and starts the conditional probabilities of those belief
+// contexts that have none as a uniform distribution over them. Contexts no belief is linked to are
+// left alone.
+func (bsvc *BeliefService) inferObservationContextStates(beliefSystem *models.BeliefSystem) error {
+	beliefsByID := make(map[string]*models.Belief, len(beliefSystem.Beliefs))
+	for _, belief := range beliefSystem.Beliefs {
+		beliefsByID[belief.ID] = belief
+	}
+
+	for _, epistemicContext := range beliefSystem.EpistemicContexts {
+		ppc := epistemicContext.PredictiveProcessingContext
+		if ppc == nil {
+			continue
+		}
+		for _, oc := range ppc.ObservationContexts {
+			if len(oc.PossibleStates) > 0 {
+				continue
+			}
+			var linked []*models.BeliefContext
+			var beliefs []string
+			for _, bc := range ppc.BeliefContexts {
+				belief, ok := beliefsByID[bc.BeliefID]
+				if bc.ObservationContextID != oc.ID || !ok {
+					continue
+				}
+				linked = append(linked, bc)
+				beliefs = append(beliefs, belief.GetContentAsString())
+			}
+			if len(beliefs) == 0 {
+				continue
+			}
+
+			states, err := bsvc.ai.InferPossibleStates(oc.Name, beliefs)
+			if err != nil {
+				return fmt.Errorf("failed to infer states of observation context %s: %w", oc.ID, err)
+			}
+			if len(states) == 0 {
+				continue
+			}
+			oc.PossibleStates = states
+			for _, bc := range linked {
+				if len(bc.ConditionalProbs) > 0 {
+					continue
+				}
+				bc.ConditionalProbs = make(map[string]float32, len(states))
+				for _, state := range states {
+					bc.ConditionalProbs[state] = 1 / float32(len(states))
+				}
+			}
+		}
+		normalizeBeliefContexts(bsvc.context(), ppc)
+	}
 	return nil
 }
 
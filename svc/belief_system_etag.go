@@ -0,0 +1,29 @@
+package svc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"epistemic-me-core/svc/models"
+)
+
+// BeliefSystemETag returns a token identifying the content of a belief system. The token is a hash
+// of the belief system's JSON form, so it changes whenever anything a client would receive does, and
+// is the same for equal belief systems across calls and server restarts. Beliefs are hashed in ID
+// order, since the order they are listed in is not stable.
+func BeliefSystemETag(beliefSystem *models.BeliefSystem) (string, error) {
+	sorted := *beliefSystem
+	sorted.Beliefs = append([]*models.Belief(nil), beliefSystem.Beliefs...)
+	sort.SliceStable(sorted.Beliefs, func(i, j int) bool {
+		return sorted.Beliefs[i].ID < sorted.Beliefs[j].ID
+	})
+	data, err := json.Marshal(&sorted)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode belief system: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
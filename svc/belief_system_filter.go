@@ -0,0 +1,61 @@
+package svc
+
+import (
+	"epistemic-me-core/svc/models"
+)
+
+// GetBeliefSystemInContexts returns a self model's belief system narrowed to the beliefs held in the
+// given observation contexts or in contexts nested under them. The epistemic contexts keep only
+// those observation contexts and the belief contexts within them. Without context IDs the whole
+// belief system is returned, as by GetBeliefSystem.
+func (bsvc *BeliefService) GetBeliefSystemInContexts(selfModelID string, observationContextIDs []string) (*models.BeliefSystem, error) {
+	beliefSystem, err := bsvc.GetBeliefSystem(selfModelID)
+	if err != nil || len(observationContextIDs) == 0 {
+		return beliefSystem, err
+	}
+	return filterBeliefSystemByContexts(beliefSystem, observationContextIDs), nil
+}
+
+// filterBeliefSystemByContexts builds a belief system holding the parts of beliefSystem that fall
+// under the given observation contexts. beliefSystem is left unchanged.
+func filterBeliefSystemByContexts(beliefSystem *models.BeliefSystem, observationContextIDs []string) *models.BeliefSystem {
+	inScope := make(map[string]bool)
+	for _, id := range models.DescendantContextIDs(allObservationContexts(beliefSystem), observationContextIDs) {
+		inScope[id] = true
+	}
+
+	filtered := &models.BeliefSystem{
+		Beliefs:           []*models.Belief{},
+		EpistemicContexts: []*models.EpistemicContext{},
+	}
+	beliefsInScope := make(map[string]bool)
+	for _, ec := range beliefSystem.EpistemicContexts {
+		if ec == nil || ec.PredictiveProcessingContext == nil {
+			continue
+		}
+		ppc := &models.PredictiveProcessingContext{}
+		for _, oc := range ec.PredictiveProcessingContext.ObservationContexts {
+			if oc != nil && inScope[oc.ID] {
+				ppc.ObservationContexts = append(ppc.ObservationContexts, oc)
+			}
+		}
+		for _, bc := range ec.PredictiveProcessingContext.BeliefContexts {
+			if bc != nil && inScope[bc.ObservationContextID] {
+				ppc.BeliefContexts = append(ppc.BeliefContexts, bc)
+				beliefsInScope[bc.BeliefID] = true
+			}
+		}
+		if len(ppc.ObservationContexts) > 0 || len(ppc.BeliefContexts) > 0 {
+			filtered.EpistemicContexts = append(filtered.EpistemicContexts, &models.EpistemicContext{
+				AssociatedBeleifs:           ec.AssociatedBeleifs,
+				PredictiveProcessingContext: ppc,
+			})
+		}
+	}
+	for _, belief := range beliefSystem.Beliefs {
+		if belief != nil && beliefsInScope[belief.ID] {
+			filtered.Beliefs = append(filtered.Beliefs, belief)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,156 @@
+package svc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"epistemic-me-core/svc/models"
+)
+
+func beliefSystemSnapshotKey(name string) string {
+	return "BeliefSystemSnapshot:" + name
+}
+
+// SaveBeliefSystemSnapshot saves the current belief system of a self model, with its active beliefs
+// and all of its epistemic contexts, under a name. Saving under a name that is already taken stores
+// a new version of that snapshot.
+func (bsvc *BeliefService) SaveBeliefSystemSnapshot(input *models.SaveBeliefSystemSnapshotInput) (*models.SaveBeliefSystemSnapshotOutput, error) {
+	if input.Name == "" {
+		return nil, fmt.Errorf("snapshot name is required")
+	}
+
+	beliefSystem, err := bsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+	beliefs, err := bsvc.ListBeliefs(&models.ListBeliefsInput{SelfModelID: input.SelfModelID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve beliefs: %w", err)
+	}
+	beliefSystem.Beliefs = beliefs.Beliefs
+
+	snapshot := &models.BeliefSystemSnapshot{
+		Name:               input.Name,
+		SelfModelID:        input.SelfModelID,
+		Version:            1,
+		CreatedAtMillisUTC: time.Now().UnixMilli(),
+		BeliefSystem:       *beliefSystem,
+	}
+	if previous, err := bsvc.retrieveBeliefSystemSnapshot(input.SelfModelID, input.Name); err == nil {
+		snapshot.Version = previous.Version + 1
+	}
+
+	err = bsvc.kvStore.Store(input.SelfModelID, beliefSystemSnapshotKey(input.Name), *snapshot, int(snapshot.Version))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+
+	return &models.SaveBeliefSystemSnapshotOutput{Snapshot: snapshot.Summary()}, nil
+}
+
+// RestoreBeliefSystemSnapshot replaces the belief system of a self model with a saved snapshot.
+// Beliefs in the snapshot are stored again as new versions, so their history is kept, and active
+// beliefs missing from the snapshot are deactivated. The snapshot itself is left in place and can
+// be restored again.
+func (bsvc *BeliefService) RestoreBeliefSystemSnapshot(input *models.RestoreBeliefSystemSnapshotInput) (*models.RestoreBeliefSystemSnapshotOutput, error) {
+	snapshot, err := bsvc.retrieveBeliefSystemSnapshot(input.SelfModelID, input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotBeliefs := make(map[string]*models.Belief, len(snapshot.BeliefSystem.Beliefs))
+	for _, belief := range snapshot.BeliefSystem.Beliefs {
+		snapshotBeliefs[belief.ID] = belief
+	}
+
+	stored, err := bsvc.kvStore.ListByType(input.SelfModelID, reflect.TypeOf(models.Belief{}))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving beliefs: %w", err)
+	}
+	currentBeliefs := make(map[string]*models.Belief, len(stored))
+	for _, obj := range stored {
+		if belief, ok := obj.(*models.Belief); ok {
+			currentBeliefs[belief.ID] = belief
+		}
+	}
+
+	for _, current := range currentBeliefs {
+		if _, ok := snapshotBeliefs[current.ID]; ok || !current.Active {
+			continue
+		}
+		current.Active = false
+		current.Version++
+		if err := bsvc.storeBeliefValue(input.SelfModelID, current); err != nil {
+			return nil, fmt.Errorf("failed to deactivate belief %s: %w", current.ID, err)
+		}
+	}
+
+	for _, belief := range snapshot.BeliefSystem.Beliefs {
+		restored := *belief
+		restored.Active = true
+		if current, ok := currentBeliefs[belief.ID]; ok {
+			if current.Active && current.Type == restored.Type && reflect.DeepEqual(current.Content, restored.Content) {
+				continue
+			}
+			restored.Version = current.Version + 1
+		}
+		if err := bsvc.storeBeliefValue(input.SelfModelID, &restored); err != nil {
+			return nil, fmt.Errorf("failed to restore belief %s: %w", belief.ID, err)
+		}
+	}
+
+	// The snapshot replaces the stored belief system rather than being merged into it
+	_, storedVersion, err := latestBeliefSystem(bsvc.kvStore, input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.BeliefSystem.Version = int32(storedVersion)
+	err = storeBeliefSystem(bsvc.kvStore, bsvc.observer, input.SelfModelID, &snapshot.BeliefSystem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store belief system: %w", err)
+	}
+
+	beliefSystem, err := bsvc.retrieveBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RestoreBeliefSystemSnapshotOutput{BeliefSystem: *beliefSystem}, nil
+}
+
+// ListBeliefSystemSnapshots lists the latest version of every snapshot saved for a self model,
+// oldest first.
+func (bsvc *BeliefService) ListBeliefSystemSnapshots(input *models.ListBeliefSystemSnapshotsInput) (*models.ListBeliefSystemSnapshotsOutput, error) {
+	stored, err := bsvc.kvStore.ListByType(input.SelfModelID, reflect.TypeOf(models.BeliefSystemSnapshot{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", wrapNotFound(err, ErrBeliefSystemNotFound))
+	}
+
+	summaries := make([]*models.BeliefSystemSnapshotSummary, 0, len(stored))
+	for _, obj := range stored {
+		if snapshot, ok := obj.(*models.BeliefSystemSnapshot); ok {
+			summaries = append(summaries, snapshot.Summary())
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].CreatedAtMillisUTC != summaries[j].CreatedAtMillisUTC {
+			return summaries[i].CreatedAtMillisUTC < summaries[j].CreatedAtMillisUTC
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+
+	return &models.ListBeliefSystemSnapshotsOutput{Snapshots: summaries}, nil
+}
+
+func (bsvc *BeliefService) retrieveBeliefSystemSnapshot(selfModelID, name string) (*models.BeliefSystemSnapshot, error) {
+	value, err := bsvc.kvStore.Retrieve(selfModelID, beliefSystemSnapshotKey(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve snapshot %q: %w", name, wrapNotFound(err, ErrSnapshotNotFound))
+	}
+	snapshot, ok := value.(*models.BeliefSystemSnapshot)
+	if !ok {
+		return nil, fmt.Errorf("invalid snapshot data type: %T", value)
+	}
+	return snapshot, nil
+}
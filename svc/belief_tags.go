@@ -0,0 +1,78 @@
+package svc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"epistemic-me-core/svc/models"
+)
+
+// AddBeliefTags adds curators' tags to a belief, storing a new version of it. Tags are trimmed, and
+// tags the belief already carries are not added twice.
+func (bsvc *BeliefService) AddBeliefTags(input *models.BeliefTagsInput) (*models.BeliefTagsOutput, error) {
+	return bsvc.updateBeliefTags(input, func(tags []string, tag string) []string {
+		if slices.Contains(tags, tag) {
+			return tags
+		}
+		return append(tags, tag)
+	})
+}
+
+// RemoveBeliefTags removes tags from a belief, storing a new version of it. Removing a tag the
+// belief does not carry changes nothing.
+func (bsvc *BeliefService) RemoveBeliefTags(input *models.BeliefTagsInput) (*models.BeliefTagsOutput, error) {
+	return bsvc.updateBeliefTags(input, func(tags []string, tag string) []string {
+		return slices.DeleteFunc(tags, func(t string) bool { return t == tag })
+	})
+}
+
+// updateBeliefTags applies change for each of input's tags to the belief's tags, and stores the
+// belief when they changed.
+func (bsvc *BeliefService) updateBeliefTags(input *models.BeliefTagsInput, change func(tags []string, tag string) []string) (*models.BeliefTagsOutput, error) {
+	tags := normalizeTags(input.Tags)
+	if len(tags) == 0 {
+		return nil, ErrNoTags
+	}
+	belief, err := bsvc.retrieveBeliefValue(input.SelfModelID, input.BeliefID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := slices.Clone(belief.Tags)
+	for _, tag := range tags {
+		updated = change(updated, tag)
+	}
+	if slices.Equal(updated, belief.Tags) {
+		return &models.BeliefTagsOutput{Belief: *belief}, nil
+	}
+
+	belief.Tags = updated
+	belief.Version++
+	if err := bsvc.storeBeliefValue(input.SelfModelID, belief); err != nil {
+		return nil, fmt.Errorf("failed to store belief: %w", err)
+	}
+	return &models.BeliefTagsOutput{Belief: *belief}, nil
+}
+
+// normalizeTags trims tags, dropping blank and repeated ones.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// hasTags reports whether belief carries every one of tags.
+func hasTags(belief *models.Belief, tags []string) bool {
+	for _, tag := range normalizeTags(tags) {
+		if !slices.Contains(belief.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package svc
+
+import (
+	"fmt"
+	"strings"
+
+	"epistemic-me-core/logging"
+	"epistemic-me-core/svc/models"
+
+	"github.com/google/uuid"
+)
+
+// BootstrapSelfModel builds a self model's belief system from a set of documents. The beliefs of
+// every document are extracted and classified first; beliefs repeating one already held, or one
+// extracted earlier, are skipped. Each document's beliefs are then linked to an observation context
+// named after the document and the belief system is conceptualized. Nothing is stored until every
+// document has been extracted, so a failed or cancelled extraction stores nothing; the beliefs are
+// then stored one by one and the belief system last. progress, when not nil, is called after each
+// document is extracted.
+func (bsvc *BeliefService) BootstrapSelfModel(input *models.BootstrapSelfModelInput, progress func(models.BootstrapProgress)) (*models.BootstrapSelfModelOutput, error) {
+	if len(input.Resources) == 0 {
+		return nil, ErrNoResources
+	}
+	for _, resource := range input.Resources {
+		if strings.TrimSpace(resource.Content) == "" {
+			return nil, ErrInvalidResource
+		}
+	}
+
+	beliefSystem, err := bsvc.retrieveBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(beliefSystem.Beliefs))
+	for _, belief := range beliefSystem.Beliefs {
+		seen[models.BeliefContentKey(belief.GetContentAsString())] = true
+	}
+
+	output := &models.BootstrapSelfModelOutput{Beliefs: []*models.Belief{}}
+	documentBeliefs := make([][]*models.Belief, len(input.Resources))
+	for i, resource := range input.Resources {
+		if err := bsvc.context().Err(); err != nil {
+			return nil, fmt.Errorf("bootstrap stopped after %d of %d documents: %w", i, len(input.Resources), err)
+		}
+		classified, err := bsvc.ai.ExtractClassifiedBeliefsFromResource(resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract beliefs from document %d: %w", i+1, err)
+		}
+		for _, extracted := range classified {
+			content, err := validateContent("belief content", extracted.Content, bsvc.maxContentLength)
+			if err != nil || seen[models.BeliefContentKey(content)] {
+				output.BeliefsSkipped++
+				continue
+			}
+			seen[models.BeliefContentKey(content)] = true
+			belief := &models.Belief{
+				ID:          "bi_" + uuid.New().String(),
+				SelfModelID: input.SelfModelID,
+				Content:     []models.Content{{RawStr: content}},
+				Type:        extracted.Type,
+				Version:     1,
+				Active:      true,
+			}
+			documentBeliefs[i] = append(documentBeliefs[i], belief)
+			output.Beliefs = append(output.Beliefs, belief)
+		}
+		if progress != nil {
+			progress(models.BootstrapProgress{
+				DocumentsProcessed: i + 1,
+				DocumentCount:      len(input.Resources),
+				BeliefsExtracted:   len(output.Beliefs),
+				Document:           documentName(resource, i),
+			})
+		}
+	}
+	if err := bsvc.context().Err(); err != nil {
+		return nil, fmt.Errorf("bootstrap stopped after extracting every document: %w", err)
+	}
+
+	ppc := NewPredictiveProcessingService().EnsurePredictiveProcessingContext(beliefSystem)
+	for i, beliefs := range documentBeliefs {
+		if len(beliefs) == 0 {
+			continue
+		}
+		oc := &models.ObservationContext{
+			ID:   uuid.New().String(),
+			Name: documentName(input.Resources[i], i),
+		}
+		ppc.ObservationContexts = append(ppc.ObservationContexts, oc)
+		output.ObservationContexts = append(output.ObservationContexts, oc)
+		for _, belief := range beliefs {
+			ppc.BeliefContexts = append(ppc.BeliefContexts, &models.BeliefContext{
+				BeliefID:             belief.ID,
+				ObservationContextID: oc.ID,
+				ConditionalProbs:     map[string]float32{},
+			})
+		}
+	}
+	beliefSystem.Beliefs = append(beliefSystem.Beliefs, output.Beliefs...)
+	if err := bsvc.ConceptualizeBeliefSystem(beliefSystem); err != nil {
+		return nil, err
+	}
+
+	for _, belief := range output.Beliefs {
+		if err := bsvc.storeBeliefValue(input.SelfModelID, belief); err != nil {
+			return nil, fmt.Errorf("failed to store belief: %w", err)
+		}
+	}
+	if err := storeBeliefSystem(bsvc.kvStore, bsvc.observer, input.SelfModelID, beliefSystem); err != nil {
+		return nil, fmt.Errorf("failed to store belief system: %w", err)
+	}
+
+	logging.Infof(bsvc.context(), "Bootstrapped self model %s from %d documents: %d beliefs, %d observation contexts, %d beliefs skipped",
+		input.SelfModelID, len(input.Resources), len(output.Beliefs), len(output.ObservationContexts), output.BeliefsSkipped)
+	output.BeliefSystem = *beliefSystem
+	return output, nil
+}
+
+// documentName names the observation context of the i-th bootstrapped document after its source,
+// or its position when it has none.
+func documentName(resource models.Resource, i int) string {
+	if resource.SourceID != "" {
+		return resource.SourceID
+	}
+	return fmt.Sprintf("Document %d", i+1)
+}
@@ -0,0 +1,111 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc/models"
+)
+
+// SetAllowedCohorts restricts SetCohort to the given cohorts. With none configured any cohort is
+// accepted.
+func (s *SelfModelService) SetAllowedCohorts(cohorts []string) {
+	s.allowedCohorts = make(map[string]bool, len(cohorts))
+	for _, cohort := range cohorts {
+		s.allowedCohorts[cohort] = true
+	}
+}
+
+// SetCohort assigns a self model, and the user it belongs to if there is one, to a cohort. An empty
+// cohort removes the assignment.
+func (s *SelfModelService) SetCohort(ctx context.Context, input *models.SetCohortInput) (*models.SetCohortOutput, error) {
+	if input.Cohort != "" && len(s.allowedCohorts) > 0 && !s.allowedCohorts[input.Cohort] {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCohort, input.Cohort)
+	}
+
+	storedSelfModel, err := s.kvStore.Retrieve(input.SelfModelID, "SelfModel")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve self model: %w", wrapNotFound(err, ErrSelfModelNotFound))
+	}
+	selfModel, ok := storedSelfModel.(*models.SelfModel)
+	if !ok {
+		return nil, fmt.Errorf("invalid self model data")
+	}
+
+	selfModel.Cohort = input.Cohort
+	if err := s.kvStore.ForceStore(input.SelfModelID, "SelfModel", *selfModel, 1); err != nil {
+		return nil, fmt.Errorf("failed to update self model: %w", err)
+	}
+
+	// The self model of a user shares the user's ID
+	users, err := s.kvStore.ListAllByType(reflect.TypeOf(models.User{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve users: %w", err)
+	}
+	for _, obj := range users {
+		user, ok := obj.(*models.User)
+		if !ok || user.ID != input.SelfModelID {
+			continue
+		}
+		user.Cohort = input.Cohort
+		if err := s.kvStore.ForceStore(user.DeveloperID, "user_"+user.ID, *user, 1); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+		break
+	}
+
+	return &models.SetCohortOutput{SelfModel: selfModel}, nil
+}
+
+// listForCohort lists the values of type T owned by selfModelID, or by every self model in cohort
+// when selfModelID is empty. With a cohort set, a self model outside it owns nothing, and without a
+// selfModelID only the self models of developerID's users are members.
+func listForCohort[T any](kvStore db.KeyValueStore, developerID, selfModelID, cohort string) ([]T, error) {
+	if cohort == "" {
+		return db.ListOfType[T](kvStore, selfModelID)
+	}
+
+	candidates := []string{selfModelID}
+	if selfModelID == "" {
+		users, err := db.ListOfType[models.User](kvStore, developerID)
+		if err != nil && !errors.Is(err, db.ErrDeveloperNotFound) {
+			return nil, fmt.Errorf("failed to retrieve users: %w", err)
+		}
+		// The self model of a user shares the user's ID
+		candidates = candidates[:0]
+		for _, user := range users {
+			if user.DeveloperID == developerID {
+				candidates = append(candidates, user.ID)
+			}
+		}
+	}
+
+	var members []string
+	for _, candidate := range candidates {
+		stored, err := kvStore.Retrieve(candidate, "SelfModel")
+		if errors.Is(err, db.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve self model: %w", err)
+		}
+		if selfModel, ok := stored.(*models.SelfModel); ok && selfModel.Cohort == cohort {
+			members = append(members, selfModel.ID)
+		}
+	}
+	sort.Strings(members)
+
+	var values []T
+	for _, member := range members {
+		owned, err := db.ListOfType[T](kvStore, member)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, owned...)
+	}
+	return values, nil
+}
@@ -0,0 +1,24 @@
+package svc
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxContentLength is the most characters a belief's content or a dialectic answer may hold
+// unless SetMaxContentLength is called.
+const DefaultMaxContentLength = 10000
+
+// validateContent returns content without surrounding whitespace, failing when nothing is left or
+// when it is longer than maxLength characters. A maxLength of zero or less allows any length.
+func validateContent(kind, content string, maxLength int) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("%w: %s is required", ErrEmptyContent, kind)
+	}
+	if length := utf8.RuneCountInString(content); maxLength > 0 && length > maxLength {
+		return "", fmt.Errorf("%w: %s has %d characters, the limit is %d", ErrContentTooLong, kind, length, maxLength)
+	}
+	return content, nil
+}
@@ -1,26 +1,41 @@
 package svc
 
 import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
 	ai "epistemic-me-core/ai"
 	db "epistemic-me-core/db"
 	"epistemic-me-core/svc/models"
 	"errors"
 	"fmt"
-	"reflect"
+	"net"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type DeveloperService struct {
-	kvStore *db.KeyValueStore
+	kvStore db.KeyValueStore
 	ai      *ai.AIHelper
+
+	// keysMu serializes changes to developers' API keys
+	keysMu sync.Mutex
+	// rotationGrace is how long keys replaced by a rotation stay valid
+	rotationGrace time.Duration
+	// openAIKeyCipher encrypts developers' OpenAI keys; nil until SetOpenAIKeySecret is called
+	openAIKeyCipher cipher.AEAD
+	// allowPrivateWebhookHosts lets webhooks point at loopback, link-local and private addresses
+	allowPrivateWebhookHosts bool
 }
 
-func NewDeveloperService(kvStore *db.KeyValueStore, ai *ai.AIHelper) *DeveloperService {
+func NewDeveloperService(kvStore db.KeyValueStore, ai *ai.AIHelper) *DeveloperService {
 	return &DeveloperService{
-		kvStore: kvStore,
-		ai:      ai,
+		kvStore:       kvStore,
+		ai:            ai,
+		rotationGrace: DefaultKeyRotationGrace,
 	}
 }
 
@@ -34,8 +49,15 @@ func (s *DeveloperService) CreateDeveloper(input *models.CreateDeveloperInput) (
 		UpdatedAt: time.Now().UnixMilli(),
 	}
 
+	if err := claimEmail(s.kvStore, developerEmailScope, developer.Email, developer.ID); err != nil {
+		return nil, err
+	}
 	err := s.kvStore.Store(developer.ID, "developer", developer, 1)
 	if err != nil {
+		releaseEmail(s.kvStore, developerEmailScope, developer.Email)
+		return nil, err
+	}
+	if err := s.indexKey(&developer, developer.APIKeys[0]); err != nil {
 		return nil, err
 	}
 
@@ -67,27 +89,94 @@ func (s *DeveloperService) GetDeveloper(input *models.GetDeveloperInput) (*model
 	return developer, nil
 }
 
-// Update the GetDeveloperByAPIKey method
-
+// GetDeveloperByAPIKey returns the developer holding apiKey, if the key is active.
 func (s *DeveloperService) GetDeveloperByAPIKey(apiKey string) (*models.Developer, error) {
-	developers, err := s.kvStore.ListAllByType(reflect.TypeOf(models.Developer{}))
+	developerID, err := s.AuthenticateAPIKey(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	developer, err := s.GetDeveloper(&models.GetDeveloperInput{ID: developerID})
 	if err != nil {
 		return nil, err
 	}
+	if !developer.KeyActive(apiKey, time.Now()) {
+		return nil, errors.New("developer not found for the given API key")
+	}
+	return developer, nil
+}
+
+// RegisterWebhook sets the URL that is posted to whenever the belief system of one of the developer's
+// users changes, and issues the secret deliveries are signed with. Registering again replaces both.
+func (s *DeveloperService) RegisterWebhook(input *models.RegisterWebhookInput) (*models.RegisterWebhookOutput, error) {
+	if _, err := s.GetDeveloper(&models.GetDeveloperInput{ID: input.DeveloperID}); err != nil {
+		return nil, err
+	}
+	parsed, err := url.Parse(input.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w, got %q", ErrInvalidWebhookURL, input.URL)
+	}
+	if !s.allowPrivateWebhookHosts {
+		if err := checkWebhookHost(parsed.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	webhook := models.Webhook{
+		DeveloperID:        input.DeveloperID,
+		URL:                input.URL,
+		Secret:             hex.EncodeToString(secret),
+		Version:            1,
+		CreatedAtMillisUTC: time.Now().UnixMilli(),
+	}
+	if previous, err := s.kvStore.Retrieve(input.DeveloperID, "webhook"); err == nil {
+		if previous, ok := previous.(*models.Webhook); ok {
+			webhook.Version = previous.Version + 1
+		}
+	}
+
+	err = s.kvStore.Store(input.DeveloperID, "webhook", webhook, int(webhook.Version))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store webhook: %w", err)
+	}
+
+	return &models.RegisterWebhookOutput{Webhook: webhook}, nil
+}
 
-	for _, dev := range developers {
-		developer, ok := dev.(*models.Developer)
-		if !ok {
-			continue
+// SetAllowPrivateWebhookHosts sets whether webhooks may point at loopback, link-local and private
+// addresses. They are rejected by default, since deliveries are posted from inside the server's
+// network.
+func (s *DeveloperService) SetAllowPrivateWebhookHosts(allow bool) {
+	s.allowPrivateWebhookHosts = allow
+}
+
+// checkWebhookHost returns ErrWebhookHostNotAllowed when host is, or resolves to, an address that
+// is not publicly routable.
+func checkWebhookHost(host string) error {
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		resolved, err := net.LookupIP(host)
+		if err != nil {
+			return fmt.Errorf("%w: failed to resolve %q: %w", ErrInvalidWebhookURL, host, err)
 		}
-		for _, key := range developer.APIKeys {
-			if key == apiKey {
-				return developer, nil
-			}
+		ips = resolved
+	}
+	for _, ip := range ips {
+		if isPrivateAddress(ip) {
+			return fmt.Errorf("%w, %q resolves to %s", ErrWebhookHostNotAllowed, host, ip)
 		}
 	}
+	return nil
+}
 
-	return nil, errors.New("developer not found for the given API key")
+// isPrivateAddress reports whether ip is loopback, link-local, private or unspecified, which
+// webhooks may not reach.
+func isPrivateAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsPrivate() || ip.IsUnspecified() || ip.IsInterfaceLocalMulticast()
 }
 
 // Add other methods as needed (e.g., GetDeveloper, UpdateDeveloper, DeleteDeveloper)
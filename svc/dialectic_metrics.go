@@ -0,0 +1,113 @@
+package svc
+
+import (
+	ai "epistemic-me-core/ai"
+	metric "epistemic-me-core/svc/metrics"
+	"epistemic-me-core/svc/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// relevanceBatchSize is how many question and answer pairs are checked per AI call.
+const relevanceBatchSize = 20
+
+// ComputeDialecticMetrics computes the personalization metrics of a dialectic over its answered
+// questions: how many beliefs each answer yielded, how the learning objective's topics were covered
+// turn by turn, how many answers actually answered their question and how confident the self model
+// is in the extracted beliefs. Relevance is checked relevanceBatchSize answers per AI call.
+func (dsvc *DialecticService) ComputeDialecticMetrics(input *models.ComputeDialecticMetricsInput) (*models.ComputeDialecticMetricsOutput, error) {
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &models.DialecticMetrics{
+		DialecticID:         dialectic.ID,
+		ComputedAtMillisUTC: time.Now().UnixMilli(),
+	}
+	var events []ai.InteractionEvent
+	var turns []string
+	extractedBeliefIDs := make(map[string]bool)
+	for _, interaction := range dialectic.UserInteractions {
+		qa := getQuestionAnswer(interaction.Interaction)
+		if qa == nil {
+			continue
+		}
+		metrics.QuestionCount++
+		if interaction.Status != models.StatusAnswered || qa.Answer.UserAnswer == "" {
+			continue
+		}
+		metrics.AnsweredCount++
+		events = append(events, ai.InteractionEvent{Question: qa.Question.Question, Answer: qa.Answer.UserAnswer})
+
+		turn := []string{qa.Question.Question, qa.Answer.UserAnswer}
+		for _, belief := range qa.ExtractedBeliefs {
+			extractedBeliefIDs[belief.ID] = true
+			turn = append(turn, belief.GetContentAsString())
+		}
+		turns = append(turns, strings.Join(turn, "\n"))
+		metrics.ExtractedBeliefCount += int32(len(qa.ExtractedBeliefs))
+	}
+	if metrics.AnsweredCount > 0 {
+		metrics.BeliefYieldPerQuestion = float32(metrics.ExtractedBeliefCount) / float32(metrics.AnsweredCount)
+	}
+
+	if dialectic.LearningObjective != nil {
+		metrics.TopicCoverageProgression = metric.ComputeTopicCoverageProgression(dialectic.LearningObjective.Topics, turns)
+	}
+	if dialectic.LearningObjectiveProgress != nil {
+		metrics.CompletionPercentage = dialectic.LearningObjectiveProgress.CompletionPercentage
+	}
+
+	for start := 0; start < len(events); start += relevanceBatchSize {
+		end := min(start+relevanceBatchSize, len(events))
+		relevant, err := dsvc.aih.AreAnswersToQuestions(events[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to check answer relevance: %w", err)
+		}
+		for _, isRelevant := range relevant {
+			if isRelevant {
+				metrics.RelevantAnswerCount++
+			}
+		}
+	}
+	if metrics.AnsweredCount > 0 {
+		metrics.AnswerRelevance = float32(metrics.RelevantAnswerCount) / float32(metrics.AnsweredCount)
+	}
+
+	// Confidence lives on the belief contexts of the self model's belief system
+	if stored, err := dsvc.kvStore.Retrieve(input.SelfModelID, "BeliefSystem"); err == nil {
+		if bs, ok := stored.(*models.BeliefSystem); ok {
+			confidence := latestBeliefConfidence(bs, extractedBeliefIDs)
+			var total float64
+			for _, score := range confidence {
+				total += score
+			}
+			if len(confidence) > 0 {
+				metrics.RatedBeliefCount = int32(len(confidence))
+				metrics.AverageBeliefConfidence = float32(total / float64(len(confidence)))
+			}
+		}
+	}
+
+	return &models.ComputeDialecticMetricsOutput{Metrics: metrics}, nil
+}
+
+// latestBeliefConfidence returns the latest confidence rating of each of the given beliefs that has
+// a rated belief context, keyed by belief ID.
+func latestBeliefConfidence(bs *models.BeliefSystem, beliefIDs map[string]bool) map[string]float64 {
+	confidence := make(map[string]float64)
+	for _, epistemicContext := range bs.EpistemicContexts {
+		if epistemicContext == nil || epistemicContext.PredictiveProcessingContext == nil {
+			continue
+		}
+		for _, beliefContext := range epistemicContext.PredictiveProcessingContext.BeliefContexts {
+			if beliefContext == nil || !beliefIDs[beliefContext.BeliefID] || len(beliefContext.ConfidenceRatings) == 0 {
+				continue
+			}
+			confidence[beliefContext.BeliefID] = beliefContext.ConfidenceRatings[len(beliefContext.ConfidenceRatings)-1].ConfidenceScore
+		}
+	}
+	return confidence
+}
@@ -1,12 +1,14 @@
 package svc
 
 import (
+	"context"
 	ai "epistemic-me-core/ai"
 	db "epistemic-me-core/db"
+	"epistemic-me-core/logging"
 	"epistemic-me-core/svc/models"
+	"errors"
 	"fmt"
-	"log"
-	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,50 +16,173 @@ import (
 )
 
 type DialecticService struct {
-	kvStore                 *db.KeyValueStore
+	kvStore                 db.KeyValueStore
 	aih                     *ai.AIHelper
-	perspectiveTakingEpiSvc *PerspectiveTakingEpistemology
+	perspectiveTakingEpiSvc PerspectiveResponder
 	dialecticEpiSvc         *DialecticalEpistemology
+	perspectiveConcurrency  int
+	// observer, when set, is told about every change persisted to a belief system
+	observer BeliefSystemObserver
+	// background runs the belief updates of answers given in background mode
+	background *backgroundQueue
+	// openingQuestions, when set, open new dialectics without an AI call
+	openingQuestions map[models.DialecticType]string
+	// updateBudget, when positive, is the deadline of each UpdateDialectic
+	updateBudget time.Duration
+	// extractionHistoryWindow is how many earlier answers belief extraction sees
+	extractionHistoryWindow int
+	// answerMatchThreshold is the confidence an answer matched to a question needs to be kept
+	answerMatchThreshold float32
+	// maxContentLength is the most characters an answer may hold
+	maxContentLength int
+	// idempotency remembers the dialectics created for idempotency keys
+	idempotency *idempotencyKeys
+	ctx         context.Context
 }
 
 // NewDialecticService initializes and returns a new DialecticService.
-func NewDialecticService(kvStore *db.KeyValueStore, aih *ai.AIHelper,
-	perspectiveTakingEpiSvc *PerspectiveTakingEpistemology,
+func NewDialecticService(kvStore db.KeyValueStore, aih *ai.AIHelper,
+	perspectiveTakingEpiSvc PerspectiveResponder,
 	dialecticEpistemologySvc *DialecticalEpistemology) *DialecticService {
 	return &DialecticService{
 		kvStore:                 kvStore,
 		aih:                     aih,
 		perspectiveTakingEpiSvc: perspectiveTakingEpiSvc,
 		dialecticEpiSvc:         dialecticEpistemologySvc,
+		perspectiveConcurrency:  DefaultPerspectiveConcurrency,
+		background:              newBackgroundQueue(),
+		extractionHistoryWindow: DefaultExtractionHistoryWindow,
+		answerMatchThreshold:    DefaultAnswerMatchThreshold,
+		maxContentLength:        DefaultMaxContentLength,
+		idempotency:             newIdempotencyKeys(kvStore),
 	}
 }
 
+// SetIdempotencyTTL sets how long the idempotency keys of CreateDialectic requests are remembered.
+func (dsvc *DialecticService) SetIdempotencyTTL(ttl time.Duration) {
+	dsvc.idempotency.ttl = ttl
+}
+
+// WithContext returns a copy of the service that tags its log lines with the request carried by ctx
+// and makes its AI calls within ctx.
+func (dsvc *DialecticService) WithContext(ctx context.Context) *DialecticService {
+	scoped := *dsvc
+	scoped.ctx = ctx
+	if dsvc.aih != nil {
+		scoped.aih = dsvc.aih.WithContext(ctx)
+	}
+	if dsvc.dialecticEpiSvc != nil {
+		scoped.dialecticEpiSvc = dsvc.dialecticEpiSvc.withContext(ctx)
+	}
+	if pte, ok := dsvc.perspectiveTakingEpiSvc.(*PerspectiveTakingEpistemology); ok && pte != nil {
+		scoped.perspectiveTakingEpiSvc = pte.withContext(ctx)
+	}
+	return &scoped
+}
+
+func (dsvc *DialecticService) context() context.Context {
+	if dsvc.ctx == nil {
+		return context.Background()
+	}
+	return dsvc.ctx
+}
+
+// SetMaxContentLength sets how many characters an answer may hold. Zero allows any length.
+func (dsvc *DialecticService) SetMaxContentLength(maxLength int) {
+	dsvc.maxContentLength = maxLength
+}
+
+// SetPerspectiveConcurrency sets how many perspective requests may run at once.
+func (dsvc *DialecticService) SetPerspectiveConcurrency(concurrency int) {
+	dsvc.perspectiveConcurrency = concurrency
+}
+
+// SetUpdateBudget bounds how long UpdateDialectic may take across all of its AI calls. Once the
+// budget is spent the update fails with an error wrapping context.DeadlineExceeded. Zero removes
+// the bound.
+func (dsvc *DialecticService) SetUpdateBudget(budget time.Duration) {
+	dsvc.updateBudget = budget
+}
+
+// SetBeliefSystemObserver makes the service tell observer about every change it persists to a
+// belief system while updating dialectics.
+func (dsvc *DialecticService) SetBeliefSystemObserver(observer BeliefSystemObserver) {
+	dsvc.observer = observer
+}
+
 // Add this method to DialecticService
 func (dsvc *DialecticService) storeDialecticValue(selfModelID string, dialectic *models.Dialectic) error {
-	log.Printf("Storing dialectic: %+v", dialectic)
-	return dsvc.kvStore.Store(selfModelID, dialectic.ID, *dialectic, len(dialectic.UserInteractions))
+	logging.Debugf(dsvc.context(), "Storing dialectic: %+v", dialectic)
+	return dsvc.kvStore.Store(selfModelID, dialectic.ID, *dialectic, int(dialectic.Version))
 }
 
 // Add this method to DialecticService
 func (dsvc *DialecticService) retrieveDialecticValue(selfModelID, dialecticID string) (*models.Dialectic, error) {
 	value, err := dsvc.kvStore.Retrieve(selfModelID, dialecticID)
 	if err != nil {
-		return nil, err
+		return nil, wrapNotFound(err, ErrDialecticNotFound)
 	}
-	log.Printf("Retrieved dialectic value: %+v", value)
+	logging.Debugf(dsvc.context(), "Retrieved dialectic value: %+v", value)
+	var dialectic *models.Dialectic
 	switch d := value.(type) {
 	case models.Dialectic:
-		return &d, nil
+		dialectic = &d
 	case *models.Dialectic:
-		return d, nil
+		dialectic = d
 	default:
 		return nil, fmt.Errorf("retrieved value is not a Dialectic: %T", value)
 	}
+	// Dialectics stored before versioning used the interaction count as their store version
+	if dialectic.Version == 0 {
+		dialectic.Version = int32(len(dialectic.UserInteractions))
+	}
+	return dialectic, nil
+}
+
+// storedBeliefSystem returns the self model's stored belief system, or an empty one when nothing has
+// been stored yet.
+func (dsvc *DialecticService) storedBeliefSystem(selfModelID string) (*models.BeliefSystem, error) {
+	value, err := dsvc.kvStore.Retrieve(selfModelID, "BeliefSystem")
+	if errors.Is(err, db.ErrNotFound) {
+		return &models.BeliefSystem{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve belief system: %w", err)
+	}
+	bs, ok := value.(*models.BeliefSystem)
+	if !ok {
+		return nil, fmt.Errorf("retrieved value is not a BeliefSystem: %T", value)
+	}
+	return bs, nil
 }
 
 func (dsvc *DialecticService) CreateDialectic(input *models.CreateDialecticInput) (*models.CreateDialecticOutput, error) {
 	newDialecticId := "di_" + uuid.New().String()
 
+	existingID, err := dsvc.idempotency.claim(input.RequesterID, idempotentCreateDialectic, input.IdempotencyKey, input.SelfModelID, newDialecticId)
+	if err != nil {
+		return nil, err
+	}
+	if existingID != "" {
+		dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, existingID)
+		if errors.Is(err, ErrDialecticNotFound) {
+			return nil, ErrRequestInProgress
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &models.CreateDialecticOutput{DialecticID: dialectic.ID, Dialectic: *dialectic}, nil
+	}
+	output, err := dsvc.createDialectic(input, newDialecticId)
+	if err != nil {
+		dsvc.idempotency.release(input.RequesterID, idempotentCreateDialectic, input.IdempotencyKey)
+		return nil, err
+	}
+	return output, nil
+}
+
+func (dsvc *DialecticService) createDialectic(input *models.CreateDialecticInput, newDialecticId string) (*models.CreateDialecticOutput, error) {
+
 	dialectic := &models.Dialectic{
 		ID:          newDialecticId,
 		SelfModelID: input.SelfModelID,
@@ -67,12 +192,13 @@ func (dsvc *DialecticService) CreateDialectic(input *models.CreateDialecticInput
 		},
 		UserInteractions:  []models.DialecticalInteraction{},
 		LearningObjective: input.LearningObjective,
+		Version:           1,
 	}
 
 	// If there's a learning objective, generate initial question based on it
 	if input.LearningObjective != nil {
 		// Generate the first question based on learning objective
-		question, err := dsvc.aih.GenerateQuestionForLearningObjective(input.LearningObjective, dialectic.UserInteractions)
+		question, err := dsvc.aih.GenerateQuestionForLearningObjective(input.LearningObjective, dialectic.UserInteractions, nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate initial question: %w", err)
 		}
@@ -80,6 +206,9 @@ func (dsvc *DialecticService) CreateDialectic(input *models.CreateDialecticInput
 		// Create initial interaction with the generated question
 		interaction := createNewQuestionInteraction(question)
 		dialectic.UserInteractions = append(dialectic.UserInteractions, interaction)
+	} else if question, ok := dsvc.openingQuestion(input); ok {
+		// In fast mode a self model without beliefs gets the fixed opening question
+		dialectic.UserInteractions = append(dialectic.UserInteractions, createNewQuestionInteraction(question))
 	} else {
 		// Generate the first interaction using existing logic for non-learning objective dialectics
 		response, err := dsvc.dialecticEpiSvc.Respond(&models.BeliefSystem{}, &models.DialecticEvent{
@@ -94,7 +223,7 @@ func (dsvc *DialecticService) CreateDialectic(input *models.CreateDialecticInput
 	// Add perspective selves if specified
 	if len(input.PerspectiveModelIDs) > 0 {
 		dialectic.PerspectiveModelIDs = input.PerspectiveModelIDs
-		log.Printf("Adding perspective selves: %v", dialectic.PerspectiveModelIDs)
+		logging.Infof(dsvc.context(), "Adding perspective selves: %v", dialectic.PerspectiveModelIDs)
 	}
 
 	err := dsvc.storeDialecticValue(input.SelfModelID, dialectic)
@@ -102,169 +231,343 @@ func (dsvc *DialecticService) CreateDialectic(input *models.CreateDialecticInput
 		return nil, fmt.Errorf("failed to store new dialectic: %w", err)
 	}
 
+	err = dsvc.storeDialecticBaseline(input.SelfModelID, dialectic.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store dialectic baseline: %w", err)
+	}
+
 	return &models.CreateDialecticOutput{
 		DialecticID: newDialecticId,
 		Dialectic:   *dialectic,
 	}, nil
 }
 
-func (dsvc *DialecticService) ListDialectics(input *models.ListDialecticsInput) (*models.ListDialecticsOutput, error) {
-	dialectics, err := dsvc.kvStore.ListByType(input.SelfModelID, reflect.TypeOf(models.Dialectic{}))
+// dialecticBaselineKey is the store key of a dialectic's baseline. It is kept apart from the
+// "BeliefSystem" key so GetBeliefSystem never sees it.
+func dialecticBaselineKey(dialecticID string) string {
+	return dialecticID + ":baseline"
+}
+
+// storeDialecticBaseline snapshots the self model's current belief system for a new dialectic.
+func (dsvc *DialecticService) storeDialecticBaseline(selfModelID, dialecticID string) error {
+	baseline := models.DialecticBaseline{
+		DialecticID:        dialecticID,
+		SelfModelID:        selfModelID,
+		CreatedAtMillisUTC: time.Now().UnixMilli(),
+	}
+	value, err := dsvc.kvStore.Retrieve(selfModelID, "BeliefSystem")
+	if beliefSystem, ok := value.(*models.BeliefSystem); err == nil && ok {
+		baseline.BeliefSystem = beliefSystem
+	} else {
+		baseline.Empty = true
+	}
+
+	// Store rather than ForceStore, so an existing baseline is never overwritten
+	return dsvc.kvStore.Store(selfModelID, dialecticBaselineKey(dialecticID), baseline, 1)
+}
+
+// GetDialecticBaseline returns the belief system snapshot taken when the dialectic was created.
+func (dsvc *DialecticService) GetDialecticBaseline(input *models.GetDialecticBaselineInput) (*models.GetDialecticBaselineOutput, error) {
+	value, err := dsvc.kvStore.Retrieve(input.SelfModelID, dialecticBaselineKey(input.DialecticID))
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve dialectics: %v", err)
+		return nil, fmt.Errorf("failed to retrieve baseline for dialectic %s: %w", input.DialecticID, err)
+	}
+	baseline, ok := value.(*models.DialecticBaseline)
+	if !ok {
+		return nil, fmt.Errorf("retrieved value is not a DialecticBaseline: %T", value)
 	}
+	return &models.GetDialecticBaselineOutput{Baseline: baseline}, nil
+}
 
-	var dialecticValues []models.Dialectic
-	for _, d := range dialectics {
-		if dialectic, ok := d.(*models.Dialectic); ok && dialectic.SelfModelID == input.SelfModelID {
-			dialecticValues = append(dialecticValues, *dialectic)
+// EditAnswer replaces the answer of an answered interaction. The beliefs extracted from the old
+// answer are removed from the belief system and beliefs are extracted from the new one.
+func (dsvc *DialecticService) EditAnswer(input *models.EditAnswerInput) (*models.EditAnswerOutput, error) {
+	answer, err := validateContent("answer", input.Answer, dsvc.maxContentLength)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := *input
+	trimmed.Answer = answer
+	input = &trimmed
+
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+	idx := -1
+	for i, interaction := range dialectic.UserInteractions {
+		if interaction.ID == input.InteractionID {
+			idx = i
+			break
 		}
 	}
+	if idx == -1 {
+		return nil, fmt.Errorf("%w: %s", ErrInteractionNotFound, input.InteractionID)
+	}
+	interaction := &dialectic.UserInteractions[idx]
+	qa := getQuestionAnswer(interaction.Interaction)
+	if interaction.Status != models.StatusAnswered || qa == nil {
+		return nil, fmt.Errorf("%w: %s", ErrInteractionNotAnswered, input.InteractionID)
+	}
 
-	return &models.ListDialecticsOutput{
-		Dialectics: dialecticValues,
+	extractedBeliefs, err := dsvc.extractInteractionBeliefs(interaction, qa.Question.Question, input.Answer, dialectic.UserInteractions[:idx])
+	if err != nil {
+		return nil, err
+	}
+
+	bs, err := dsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+	removedIDs := interactionBeliefIDs(bs, interaction, qa)
+	removeBeliefs(bs, removedIDs)
+	bs.Beliefs = append(bs.Beliefs, extractedBeliefs...)
+
+	now := time.Now().UnixMilli()
+	qa.Answer.UserAnswer = input.Answer
+	qa.ExtractedBeliefs = extractedBeliefs
+	qa.UpdatedAtMillisUTC = now
+	interaction.UpdatedAtMillisUTC = now
+	dialectic.Version++
+	// The dialectic's versioned write goes first, so a conflicting edit leaves the beliefs untouched
+	if err := dsvc.storeDialecticValue(input.SelfModelID, dialectic); err != nil {
+		return nil, err
+	}
+	if err := storeBeliefSystem(dsvc.kvStore, dsvc.observer, input.SelfModelID, bs); err != nil {
+		return nil, fmt.Errorf("failed to store updated belief system: %w", err)
+	}
+	// The edited answer may be one the summary condensed
+	if err := dsvc.dropInteractionSummary(input.SelfModelID, dialectic.ID); err != nil {
+		return nil, err
+	}
+
+	logging.Infof(dsvc.context(), "Edited answer of interaction %s: removed %d beliefs, extracted %d", input.InteractionID, len(removedIDs), len(extractedBeliefs))
+	return &models.EditAnswerOutput{
+		Dialectic:        *dialectic,
+		RemovedBeliefIDs: removedIDs,
+		ExtractedBeliefs: extractedBeliefs,
 	}, nil
 }
 
-func (dsvc *DialecticService) UpdateDialectic(input *models.UpdateDialecticInput) (*models.UpdateDialecticOutput, error) {
-	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.ID)
+// RunSyntheticDialectic runs a learning objective dialectic with the self model answering its own
+// questions from its belief system and philosophies. Each turn answers the pending question through
+// UpdateDialectic, so beliefs are extracted and progress is checked exactly as for a real user. The
+// run ends once the objective stops asking questions, at its completion threshold or question cap,
+// or after MaxTurns answers, which defaults to the objective's question cap.
+func (dsvc *DialecticService) RunSyntheticDialectic(input *models.RunSyntheticDialecticInput) (*models.RunSyntheticDialecticOutput, error) {
+	if input.LearningObjective == nil {
+		return nil, fmt.Errorf("a learning objective is required to run a synthetic dialectic")
+	}
+	maxTurns := int(input.MaxTurns)
+	if maxTurns <= 0 {
+		maxTurns = int(input.LearningObjective.GetMaxQuestions())
+	}
+
+	storedSelfModel, err := dsvc.kvStore.Retrieve(input.SelfModelID, "SelfModel")
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to retrieve self model: %w", wrapNotFound(err, ErrSelfModelNotFound))
+	}
+	selfModel, ok := storedSelfModel.(*models.SelfModel)
+	if !ok {
+		return nil, fmt.Errorf("invalid self model data")
 	}
-	log.Printf("Retrieved dialectic with %d interactions", len(dialectic.UserInteractions))
+	philosophies := philosophyDescriptions(dsvc.kvStore, selfModel.Philosophies)
 
-	if input.Answer.UserAnswer != "" {
-		bs, err := dsvc.dialecticEpiSvc.Process(&models.DialecticEvent{
-			PreviousInteractions: dialectic.UserInteractions,
-		}, input.DryRun, input.SelfModelID)
-		if err != nil {
-			return nil, err
-		}
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{
+		SelfModelID:       input.SelfModelID,
+		DialecticType:     input.DialecticType,
+		LearningObjective: input.LearningObjective,
+	})
+	if err != nil {
+		return nil, err
+	}
+	dialectic := created.Dialectic
 
-		// Extract beliefs from the answer
-		interactionEvent := ai.InteractionEvent{
-			Question: getQuestion(&dialectic.UserInteractions[len(dialectic.UserInteractions)-1]),
-			Answer:   input.Answer.UserAnswer,
+	turns := 0
+	for ; turns < maxTurns; turns++ {
+		last := &dialectic.UserInteractions[len(dialectic.UserInteractions)-1]
+		if last.Status != models.StatusPendingAnswer {
+			break
 		}
 
-		extractedBeliefStrings, err := dsvc.aih.GetInteractionEventAsBelief(interactionEvent)
+		// Reload the belief system each turn so answers build on the beliefs extracted so far
+		stored, err := dsvc.kvStore.Retrieve(input.SelfModelID, "BeliefSystem")
 		if err != nil {
-			return nil, fmt.Errorf("failed to extract beliefs: %w", err)
+			return nil, fmt.Errorf("failed to retrieve belief system: %w", wrapNotFound(err, ErrBeliefSystemNotFound))
 		}
-
-		extractedBeliefs := make([]*models.Belief, 0, len(extractedBeliefStrings))
-		for _, beliefStr := range extractedBeliefStrings {
-			extractedBelief := &models.Belief{
-				ID:      uuid.New().String(),
-				Content: []models.Content{{RawStr: beliefStr}},
-				Type:    models.Statement,
-			}
-			extractedBeliefs = append(extractedBeliefs, extractedBelief)
+		beliefSystem, ok := stored.(*models.BeliefSystem)
+		if !ok {
+			return nil, fmt.Errorf("invalid belief system data")
 		}
 
-		// Add the extracted beliefs to the BeliefSystem
-		bs.Beliefs = append(bs.Beliefs, extractedBeliefs...)
-		err = dsvc.kvStore.Store(input.SelfModelID, "BeliefSystem", *bs, len(bs.Beliefs))
+		question := getQuestion(last)
+		answer, err := dsvc.aih.GenerateAnswerFromBeliefSystem(question, beliefSystem, philosophies)
 		if err != nil {
-			return nil, fmt.Errorf("failed to store updated belief system: %w", err)
+			return nil, fmt.Errorf("failed to generate answer to %q: %w", question, err)
 		}
+		logging.Infof(dsvc.context(), "Synthetic dialectic %s turn %d: answered %q", dialectic.ID, turns+1, question)
 
-		// Update the last interaction with the answer and extracted beliefs
-		lastIdx := len(dialectic.UserInteractions) - 1
-		oldQA := getQuestionAnswer(dialectic.UserInteractions[lastIdx].Interaction)
-		qa := &models.QuestionAnswerInteraction{
-			Question: oldQA.Question,
-			Answer: models.UserAnswer{
-				UserAnswer:         input.Answer.UserAnswer,
-				CreatedAtMillisUTC: time.Now().UnixMilli(),
-			},
-			ExtractedBeliefs:   extractedBeliefs,
-			UpdatedAtMillisUTC: time.Now().UnixMilli(),
+		updated, err := dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+			ID:          dialectic.ID,
+			SelfModelID: input.SelfModelID,
+			Answer:      models.UserAnswer{UserAnswer: answer},
+		})
+		if err != nil {
+			return nil, err
 		}
+		dialectic = updated.Dialectic
+	}
+
+	last := dialectic.UserInteractions[len(dialectic.UserInteractions)-1]
+	return &models.RunSyntheticDialecticOutput{
+		Dialectic: dialectic,
+		Turns:     turns,
+		Completed: last.Status != models.StatusPendingAnswer,
+	}, nil
+}
 
-		dialectic.UserInteractions[lastIdx].Status = models.StatusAnswered
-		dialectic.UserInteractions[lastIdx].Type = models.InteractionTypeQuestionAnswer
-		dialectic.UserInteractions[lastIdx].Interaction = &models.InteractionData{
-			QuestionAnswer: qa,
+// ListInteractions returns a dialectic's interactions matching the input's status and type
+// filters, ordered by when they were last updated.
+func (dsvc *DialecticService) ListInteractions(input *models.ListInteractionsInput) (*models.ListInteractionsOutput, error) {
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+
+	interactions := []models.InteractionSummary{}
+	for _, interaction := range dialectic.UserInteractions {
+		if input.Status != models.StatusInvalid && interaction.Status != input.Status {
+			continue
+		}
+		if input.Type != models.InteractionTypeInvalid && interaction.Type != input.Type {
+			continue
 		}
-		dialectic.UserInteractions[lastIdx].UpdatedAtMillisUTC = time.Now().UnixMilli()
+		interactions = append(interactions, interaction.Summary())
+	}
+	sort.SliceStable(interactions, func(i, j int) bool {
+		return interactions[i].UpdatedAtMillisUTC < interactions[j].UpdatedAtMillisUTC
+	})
 
-		lastInteraction := dialectic.UserInteractions[len(dialectic.UserInteractions)-1]
+	return &models.ListInteractionsOutput{Interactions: interactions}, nil
+}
 
-		// For all perspectives we've attached to the dialectic, provide perspectives on the latest
-		// dialectic interaction
-		if dialectic.PerspectiveModelIDs != nil {
-			for _, perspectiveModelID := range dialectic.PerspectiveModelIDs {
-				perspective, err := dsvc.perspectiveTakingEpiSvc.Respond(bs, models.EpistemicRequest{
-					SelfModelID: perspectiveModelID,
-					Content: map[string]interface{}{
-						"question": lastInteraction.Interaction.QuestionAnswer.Question,
-						"answer":   lastInteraction.Interaction.QuestionAnswer.Answer,
-					},
-				})
+// DeleteDialectic removes a dialectic along with its baseline and interaction summary.
+func (dsvc *DialecticService) DeleteDialectic(input *models.DeleteDialecticInput) (*models.DeleteDialecticOutput, error) {
+	if _, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.ID); err != nil {
+		return nil, err
+	}
+	if err := dsvc.kvStore.Delete(input.SelfModelID, input.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete dialectic: %w", err)
+	}
+	// Dialectics created before baselines were recorded have none to clean up
+	if err := dsvc.kvStore.Delete(input.SelfModelID, dialecticBaselineKey(input.ID)); err != nil {
+		logging.Warnf(dsvc.context(), "No baseline deleted for dialectic %s: %v", input.ID, err)
+	}
+	if err := dsvc.dropInteractionSummary(input.SelfModelID, input.ID); err != nil {
+		logging.Warnf(dsvc.context(), "Interaction summary of dialectic %s kept: %v", input.ID, err)
+	}
+	return &models.DeleteDialecticOutput{DialecticID: input.ID}, nil
+}
 
-				if err != nil {
-					return nil, err
-				}
+func (dsvc *DialecticService) ListDialectics(input *models.ListDialecticsInput) (*models.ListDialecticsOutput, error) {
+	dialectics, err := listForCohort[models.Dialectic](dsvc.kvStore, input.DeveloperID, input.SelfModelID, input.Cohort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve dialectics: %v", err)
+	}
 
-				lastInteraction.Perspectives = append(lastInteraction.Perspectives, models.Perspective{
-					Response:    *perspective,
-					SelfModelID: perspectiveModelID,
-				})
-			}
+	var dialecticValues []models.Dialectic
+	for _, dialectic := range dialectics {
+		if input.SelfModelID == "" || dialectic.SelfModelID == input.SelfModelID {
+			dialecticValues = append(dialecticValues, dialectic)
 		}
+	}
 
-		// If we have a learning objective, check completion and generate next question
-		if dialectic.LearningObjective != nil {
-			// Get the current belief system
-			bs, err := dsvc.dialecticEpiSvc.Process(&models.DialecticEvent{
-				PreviousInteractions: dialectic.UserInteractions,
-			}, false, dialectic.SelfModelID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get belief system: %w", err)
-			}
+	return &models.ListDialecticsOutput{
+		Dialectics: dialecticValues,
+	}, nil
+}
 
-			// Get the self model using existing KeyValueStore.Retrieve
-			selfModelValue, err := dsvc.kvStore.Retrieve(dialectic.SelfModelID, "SelfModel")
-			if err != nil {
-				return nil, fmt.Errorf("failed to get self model: %w", err)
-			}
+// GetDialectic returns a stored dialectic.
+func (dsvc *DialecticService) GetDialectic(input *models.GetDialecticInput) (*models.GetDialecticOutput, error) {
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.GetDialecticOutput{Dialectic: *dialectic}, nil
+}
 
-			// Convert to SelfModel type
-			selfModel, ok := selfModelValue.(*models.SelfModel)
-			if !ok {
-				return nil, fmt.Errorf("invalid self model type")
-			}
+func (dsvc *DialecticService) UpdateDialectic(input *models.UpdateDialecticInput) (*models.UpdateDialecticOutput, error) {
+	if input.Answer.UserAnswer != "" {
+		answer, err := validateContent("answer", input.Answer.UserAnswer, dsvc.maxContentLength)
+		if err != nil {
+			return nil, err
+		}
+		trimmed := *input
+		trimmed.Answer.UserAnswer = answer
+		input = &trimmed
+	}
+	if dsvc.updateBudget <= 0 {
+		return dsvc.updateDialectic(input)
+	}
+	ctx, cancel := context.WithTimeout(dsvc.context(), dsvc.updateBudget)
+	defer cancel()
+	output, err := dsvc.WithContext(ctx).updateDialectic(input)
+	if err != nil && ctx.Err() == context.DeadlineExceeded && !errors.Is(err, context.DeadlineExceeded) {
+		// The AI call that ran out of time may have reported it without wrapping the context error
+		err = fmt.Errorf("%w: %v", context.DeadlineExceeded, err)
+	}
+	return output, err
+}
 
-			// Update the belief system with current beliefs
-			selfModel.BeliefSystem = bs
+func (dsvc *DialecticService) updateDialectic(input *models.UpdateDialecticInput) (*models.UpdateDialecticOutput, error) {
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.ID)
+	if err != nil {
+		return nil, err
+	}
+	logging.Infof(dsvc.context(), "Retrieved dialectic with %d interactions", len(dialectic.UserInteractions))
+	dialectic.Version++
 
-			completionPercentage, err := dsvc.aih.CheckLearningObjectiveCompletion(dialectic.LearningObjective, selfModel)
+	var perspectiveErrors map[string]string
+	var backgroundJob func()
+	// answeredBeliefSystem is stored once the dialectic is
+	var answeredBeliefSystem *models.BeliefSystem
+
+	if input.Answer.UserAnswer != "" {
+		var bs *models.BeliefSystem
+		lastIdx := len(dialectic.UserInteractions) - 1
+		if input.Background && !input.DryRun {
+			// Record the answer now and update the belief system once the dialectic is stored. The
+			// next question is asked from the belief system as it stands.
+			bs, err = dsvc.storedBeliefSystem(input.SelfModelID)
 			if err != nil {
-				return nil, fmt.Errorf("failed to check learning objective completion: %w", err)
+				return nil, err
 			}
-			dialectic.LearningObjective.CompletionPercentage = completionPercentage
-
-			// If not complete (less than 95%), generate next question based on learning objective
-			if completionPercentage < 95 {
-				nextQuestion, err := dsvc.aih.GenerateQuestionForLearningObjective(dialectic.LearningObjective, dialectic.UserInteractions)
-				if err != nil {
-					return nil, fmt.Errorf("failed to generate next question: %w", err)
-				}
-
-				interaction := createNewQuestionInteraction(nextQuestion)
-				dialectic.UserInteractions = append(dialectic.UserInteractions, interaction)
+			previous := append([]models.DialecticalInteraction(nil), dialectic.UserInteractions...)
+			perspectiveModelIDs := dialectic.PerspectiveModelIDs
+			background := dsvc.WithContext(context.WithoutCancel(dsvc.context()))
+			backgroundJob = func() {
+				background.finishAnswer(*input, previous, perspectiveModelIDs)
 			}
+			markAnswerProcessing(&dialectic.UserInteractions[lastIdx], input.Answer.UserAnswer)
 		} else {
-			// Generate the next interaction using existing logic for non-learning objective dialectics
-			response, err := dsvc.dialecticEpiSvc.Respond(bs, &models.DialecticEvent{
+			bs, err = dsvc.dialecticEpiSvc.Process(&models.DialecticEvent{
 				PreviousInteractions: dialectic.UserInteractions,
-			}, input.Answer.UserAnswer)
+			}, input.DryRun, input.SelfModelID)
+			if err != nil {
+				return nil, err
+			}
+			history := dsvc.extractionHistory(dialectic.UserInteractions[:lastIdx])
+			result, err := dsvc.processAnswer(input, &dialectic.UserInteractions[lastIdx], history, dialectic.PerspectiveModelIDs, bs)
 			if err != nil {
 				return nil, err
 			}
+			result.apply(&dialectic.UserInteractions[lastIdx])
+			perspectiveErrors = result.perspectiveErrors
+			answeredBeliefSystem = result.beliefSystem
+		}
 
-			dialectic.UserInteractions = append(dialectic.UserInteractions, *response.NewInteraction)
+		if err := dsvc.appendNextQuestion(dialectic, bs, input.Answer.UserAnswer); err != nil {
+			return nil, err
 		}
 	}
 
@@ -276,14 +579,8 @@ func (dsvc *DialecticService) UpdateDialectic(input *models.UpdateDialecticInput
 			return nil, fmt.Errorf("failed to extract questions: %w", err)
 		}
 
-		// Create new interactions for each question
+		// Create new interactions for each question, which come back trimmed, non-empty and deduplicated
 		for _, q := range questions {
-			// Skip if question is empty
-			if q == "" {
-				log.Printf("Skipping empty question")
-				continue
-			}
-
 			interaction := createNewQuestionInteraction(q)
 
 			dialectic.UserInteractions = append(dialectic.UserInteractions, interaction)
@@ -302,6 +599,8 @@ func (dsvc *DialecticService) UpdateDialectic(input *models.UpdateDialecticInput
 
 		// Generate the first interaction
 		response, err := dsvc.dialecticEpiSvc.Respond(bs, &models.DialecticEvent{
+			SelfModelID:          dialectic.SelfModelID,
+			DialecticID:          dialectic.ID,
 			PreviousInteractions: dialectic.UserInteractions,
 		}, "")
 		if err != nil {
@@ -312,18 +611,233 @@ func (dsvc *DialecticService) UpdateDialectic(input *models.UpdateDialecticInput
 	}
 
 	if !input.DryRun {
+		// A background answer is turned away before anything is stored while its queue is full
+		if backgroundJob != nil && !dsvc.background.reserve(input.SelfModelID) {
+			return nil, ErrBackgroundQueueFull
+		}
+		// The dialectic's versioned write goes first, so a conflicting update leaves the beliefs untouched
 		err = dsvc.storeDialecticValue(input.SelfModelID, dialectic)
 		if err != nil {
+			if backgroundJob != nil {
+				dsvc.background.release(input.SelfModelID)
+			}
 			return nil, err
 		}
+		if answeredBeliefSystem != nil {
+			if err := storeBeliefSystem(dsvc.kvStore, dsvc.observer, input.SelfModelID, answeredBeliefSystem); err != nil {
+				return nil, fmt.Errorf("failed to store updated belief system: %w", err)
+			}
+		}
+	}
+	logging.Infof(dsvc.context(), "Storing dialectic with %d interactions", len(dialectic.UserInteractions))
+
+	if backgroundJob != nil {
+		dsvc.background.submit(input.SelfModelID, backgroundJob)
 	}
-	log.Printf("Storing dialectic with %d interactions", len(dialectic.UserInteractions))
 
 	return &models.UpdateDialecticOutput{
-		Dialectic: *dialectic,
+		Dialectic:         *dialectic,
+		PerspectiveErrors: perspectiveErrors,
+	}, nil
+}
+
+// appendNextQuestion asks the dialectic's next question. A dialectic with a learning objective gets
+// the next question toward it, unless the objective is complete or its question cap is reached;
+// any other dialectic asks whatever the epistemology responds to answer with.
+func (dsvc *DialecticService) appendNextQuestion(dialectic *models.Dialectic, bs *models.BeliefSystem, answer string) error {
+	if dialectic.LearningObjective != nil {
+		progress, err := dsvc.computeLearningObjectiveProgress(dialectic)
+		if err != nil {
+			return err
+		}
+		completionPercentage := progress.CompletionPercentage
+		questionCount := countQuestions(dialectic.UserInteractions)
+
+		// If not complete and under the question cap, generate next question based on learning objective
+		if completionPercentage < dialectic.LearningObjective.GetCompletionThreshold() &&
+			questionCount < int(dialectic.LearningObjective.GetMaxQuestions()) {
+			nextQuestion, err := dsvc.aih.GenerateQuestionForLearningObjective(dialectic.LearningObjective, dialectic.UserInteractions, progress)
+			if err != nil {
+				return fmt.Errorf("failed to generate next question: %w", err)
+			}
+
+			interaction := createNewQuestionInteraction(nextQuestion)
+			dialectic.UserInteractions = append(dialectic.UserInteractions, interaction)
+		}
+		return nil
+	}
+
+	// Generate the next interaction using existing logic for non-learning objective dialectics
+	response, err := dsvc.dialecticEpiSvc.Respond(bs, &models.DialecticEvent{
+		SelfModelID:          dialectic.SelfModelID,
+		DialecticID:          dialectic.ID,
+		PreviousInteractions: dialectic.UserInteractions,
+	}, answer)
+	if err != nil {
+		return err
+	}
+
+	dialectic.UserInteractions = append(dialectic.UserInteractions, *response.NewInteraction)
+	return nil
+}
+
+// PreviewNextQuestion returns the question the dialectic would ask given its belief system and the
+// answers so far, without storing anything. A question still awaiting its answer is left out of the
+// history, since no answer to it is known yet.
+func (dsvc *DialecticService) PreviewNextQuestion(input *models.PreviewNextQuestionInput) (*models.PreviewNextQuestionOutput, error) {
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+	interactions := make([]models.DialecticalInteraction, 0, len(dialectic.UserInteractions))
+	for _, interaction := range dialectic.UserInteractions {
+		if interaction.Status != models.StatusPendingAnswer {
+			interactions = append(interactions, interaction)
+		}
+	}
+
+	if dialectic.LearningObjective != nil {
+		question, err := dsvc.aih.GenerateQuestionForLearningObjective(dialectic.LearningObjective, interactions, dialectic.LearningObjectiveProgress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate next question: %w", err)
+		}
+		return &models.PreviewNextQuestionOutput{Question: question}, nil
+	}
+
+	bs, err := dsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+	response, err := dsvc.dialecticEpiSvc.Respond(bs, &models.DialecticEvent{
+		SelfModelID:          input.SelfModelID,
+		DialecticID:          dialectic.ID,
+		PreviousInteractions: interactions,
+		KeepSummary:          true,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate next question: %w", err)
+	}
+	return &models.PreviewNextQuestionOutput{Question: getQuestion(response.NewInteraction)}, nil
+}
+
+// GetLearningObjectiveProgress returns the progress of a dialectic's learning objective.
+// The progress cached by the last update is returned when available, so the AI is only
+// consulted for dialectics that have never been analyzed.
+func (dsvc *DialecticService) GetLearningObjectiveProgress(input *models.GetLearningObjectiveProgressInput) (*models.GetLearningObjectiveProgressOutput, error) {
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+	if dialectic.LearningObjective == nil {
+		return nil, fmt.Errorf("dialectic %s has no learning objective", dialectic.ID)
+	}
+
+	if dialectic.LearningObjectiveProgress == nil {
+		if _, err := dsvc.computeLearningObjectiveProgress(dialectic); err != nil {
+			return nil, err
+		}
+		dialectic.Version++
+		if err := dsvc.storeDialecticValue(input.SelfModelID, dialectic); err != nil {
+			return nil, fmt.Errorf("failed to cache learning objective progress: %w", err)
+		}
+	}
+
+	return &models.GetLearningObjectiveProgressOutput{
+		LearningObjective: dialectic.LearningObjective,
+		Progress:          dialectic.LearningObjectiveProgress,
 	}, nil
 }
 
+// computeLearningObjectiveProgress analyzes the current belief system against the dialectic's
+// learning objective and caches the result on the dialectic.
+func (dsvc *DialecticService) computeLearningObjectiveProgress(dialectic *models.Dialectic) (*models.LearningObjectiveProgress, error) {
+	// Get the current belief system
+	bs, err := dsvc.dialecticEpiSvc.Process(&models.DialecticEvent{
+		PreviousInteractions: dialectic.UserInteractions,
+	}, false, dialectic.SelfModelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get belief system: %w", err)
+	}
+
+	// Get the self model using existing KeyValueStore.Retrieve
+	selfModelValue, err := dsvc.kvStore.Retrieve(dialectic.SelfModelID, "SelfModel")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get self model: %w", err)
+	}
+
+	// Convert to SelfModel type
+	selfModel, ok := selfModelValue.(*models.SelfModel)
+	if !ok {
+		return nil, fmt.Errorf("invalid self model type")
+	}
+
+	// Update the belief system with current beliefs
+	selfModel.BeliefSystem = bs
+
+	progress, err := dsvc.aih.CheckLearningObjectiveCompletion(dialectic.LearningObjective, selfModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check learning objective completion: %w", err)
+	}
+	dialectic.LearningObjective.CompletionPercentage = progress.CompletionPercentage
+	dialectic.LearningObjectiveProgress = progress
+	return progress, nil
+}
+
+// removeBeliefs removes the given beliefs and every belief context referencing them from the belief system
+func removeBeliefs(bs *models.BeliefSystem, beliefIDs []string) {
+	if len(beliefIDs) == 0 {
+		return
+	}
+	removed := make(map[string]bool, len(beliefIDs))
+	for _, id := range beliefIDs {
+		removed[id] = true
+	}
+
+	beliefs := make([]*models.Belief, 0, len(bs.Beliefs))
+	for _, belief := range bs.Beliefs {
+		if !removed[belief.ID] {
+			beliefs = append(beliefs, belief)
+		}
+	}
+	bs.Beliefs = beliefs
+
+	for _, ec := range bs.EpistemicContexts {
+		if ec == nil {
+			continue
+		}
+		associated := make([]string, 0, len(ec.AssociatedBeleifs))
+		for _, id := range ec.AssociatedBeleifs {
+			if !removed[id] {
+				associated = append(associated, id)
+			}
+		}
+		ec.AssociatedBeleifs = associated
+
+		ppc := ec.PredictiveProcessingContext
+		if ppc == nil {
+			continue
+		}
+		beliefContexts := make([]*models.BeliefContext, 0, len(ppc.BeliefContexts))
+		for _, bc := range ppc.BeliefContexts {
+			if bc != nil && !removed[bc.BeliefID] {
+				beliefContexts = append(beliefContexts, bc)
+			}
+		}
+		ppc.BeliefContexts = beliefContexts
+	}
+}
+
+// countQuestions returns the number of question interactions asked so far
+func countQuestions(interactions []models.DialecticalInteraction) int {
+	count := 0
+	for _, interaction := range interactions {
+		if getQuestionAnswer(interaction.Interaction) != nil {
+			count++
+		}
+	}
+	return count
+}
+
 // Helper function to get questions from pending interactions
 func getPendingQuestions(interactions []models.DialecticalInteraction, indices []int) []string {
 	questions := make([]string, len(indices))
@@ -369,6 +883,8 @@ func determineDialecticStrategy(dialecticType models.DialecticType) ai.Dialectic
 	}
 }
 
+// PreprocessDialectic answers the pending questions of dialectic that answerBlob holds answers to,
+// extracting the beliefs of all of them with a single AI call.
 func (dsvc *DialecticService) PreprocessDialectic(answerBlob *string, dialectic *models.Dialectic) error {
 	var pendingIndices []int
 	for i, interaction := range dialectic.UserInteractions {
@@ -379,7 +895,7 @@ func (dsvc *DialecticService) PreprocessDialectic(answerBlob *string, dialectic
 
 	if len(pendingIndices) > 0 {
 		// Try to match answers to questions using AI
-		matches, err := dsvc.aih.MatchAnswersToQuestions(
+		matches, err := dsvc.aih.MatchAnswersToQuestionsWithConfidence(
 			*answerBlob,
 			getPendingQuestions(dialectic.UserInteractions, pendingIndices),
 		)
@@ -387,11 +903,24 @@ func (dsvc *DialecticService) PreprocessDialectic(answerBlob *string, dialectic
 			return fmt.Errorf("failed to match answers: %w", err)
 		}
 
-		// Update matched interactions
-		for questionIdx, answer := range matches {
+		// Collect the matched interactions, then extract their beliefs together
+		var answeredIndices []int
+		var answered []*models.QuestionAnswerInteraction
+		var events []ai.InteractionEvent
+		for questionIdx, match := range matches {
+			answer := match.Answer
 			// Skip if answer is empty
-			if answer == "" {
-				log.Printf("Skipping empty answer for question index %d", questionIdx)
+			if answer == "" || answer == ai.NoAnswerProvided {
+				logging.Warnf(dsvc.context(), "Skipping empty answer for question index %d", questionIdx)
+				continue
+			}
+			if questionIdx >= len(pendingIndices) {
+				continue
+			}
+			// Leave the question pending when the match is too weak
+			if match.Confidence < dsvc.answerMatchThreshold {
+				logging.Infof(dsvc.context(), "Leaving question index %d pending, match confidence %.2f is below %.2f",
+					questionIdx, match.Confidence, dsvc.answerMatchThreshold)
 				continue
 			}
 
@@ -399,7 +928,7 @@ func (dsvc *DialecticService) PreprocessDialectic(answerBlob *string, dialectic
 
 			// Validate the existing question is not empty
 			if qa := getQuestionAnswer(dialectic.UserInteractions[idx].Interaction); qa != nil && qa.Question.Question == "" {
-				log.Printf("Skipping interaction with empty question at index %d", idx)
+				logging.Warnf(dsvc.context(), "Skipping interaction with empty question at index %d", idx)
 				continue
 			}
 
@@ -415,55 +944,204 @@ func (dsvc *DialecticService) PreprocessDialectic(answerBlob *string, dialectic
 
 			// Double-check both question and answer
 			if qa.Question.Question == "" || qa.Answer.UserAnswer == "" {
-				log.Printf("Skipping interaction - missing question or answer. Question: %q, Answer: %q",
+				logging.Warnf(dsvc.context(), "Skipping interaction - missing question or answer. Question: %q, Answer: %q",
 					qa.Question.Question, qa.Answer.UserAnswer)
 				continue
 			}
 
-			log.Printf("Created QuestionAnswer interaction with Question: %q, Answer: %q",
+			logging.Debugf(dsvc.context(), "Created QuestionAnswer interaction with Question: %q, Answer: %q",
 				qa.Question.Question, qa.Answer.UserAnswer)
 
-			// Extract beliefs for this Q&A pair
-			interactionEvent := ai.InteractionEvent{
+			answeredIndices = append(answeredIndices, idx)
+			answered = append(answered, qa)
+			events = append(events, ai.InteractionEvent{
 				Question: qa.Question.Question,
 				Answer:   answer,
-			}
+			})
+		}
 
-			extractedBeliefStrings, err := dsvc.aih.GetInteractionEventAsBelief(interactionEvent)
-			if err != nil {
-				return fmt.Errorf("failed to extract beliefs: %w", err)
-			}
+		// Extract beliefs for every Q&A pair with one AI call
+		extractedBeliefStrings, err := dsvc.aih.GetInteractionEventsAsBeliefs(events)
+		if err != nil {
+			return fmt.Errorf("failed to extract beliefs: %w", err)
+		}
 
-			extractedBeliefs := make([]*models.Belief, 0, len(extractedBeliefStrings))
-			for _, beliefStr := range extractedBeliefStrings {
+		for i, qa := range answered {
+			idx := answeredIndices[i]
+
+			extractedBeliefs := make([]*models.Belief, 0, len(extractedBeliefStrings[i]))
+			for _, beliefStr := range extractedBeliefStrings[i] {
 				belief := &models.Belief{
-					ID:      uuid.New().String(),
-					Content: []models.Content{{RawStr: beliefStr}},
-					Type:    models.Statement,
+					ID:                  uuid.New().String(),
+					Content:             []models.Content{{RawStr: beliefStr}},
+					Type:                models.Statement,
+					SourceInteractionID: dialectic.UserInteractions[idx].ID,
 				}
 				extractedBeliefs = append(extractedBeliefs, belief)
-				log.Printf("Extracted belief: %+v", belief)
+				logging.Debugf(dsvc.context(), "Extracted belief: %+v", belief)
 			}
 
 			if len(extractedBeliefs) > 0 {
 				qa.ExtractedBeliefs = append(qa.ExtractedBeliefs, extractedBeliefs...)
-				log.Printf("Added %d extracted beliefs to QuestionAnswer", len(extractedBeliefs))
+				logging.Infof(dsvc.context(), "Added %d extracted beliefs to QuestionAnswer", len(extractedBeliefs))
 			}
 
 			// Update the interaction
+			qa.UpdatedAtMillisUTC = time.Now().UnixMilli()
+			dialectic.UserInteractions[idx].Status = models.StatusAnswered
 			dialectic.UserInteractions[idx].Type = models.InteractionTypeQuestionAnswer
 			dialectic.UserInteractions[idx].Interaction = &models.InteractionData{
 				QuestionAnswer: qa,
 			}
+			dialectic.UserInteractions[idx].UpdatedAtMillisUTC = time.Now().UnixMilli()
 
-			log.Printf("Created QuestionAnswer interaction with Question: %q, Answer: %q", qa.Question.Question, qa.Answer.UserAnswer)
-			log.Printf("QuestionAnswer interaction after adding belief: %+v", qa)
+			logging.Debugf(dsvc.context(), "Created QuestionAnswer interaction with Question: %q, Answer: %q", qa.Question.Question, qa.Answer.UserAnswer)
+			logging.Debugf(dsvc.context(), "QuestionAnswer interaction after adding belief: %+v", qa)
 		}
 	}
 
 	return nil
 }
 
+// BulkAnswer answers several pending questions of a dialectic at once, for example when importing a
+// conversation transcript. The answers are matched to the pending questions and the beliefs of all
+// matched answers are extracted together, instead of one UpdateDialectic per answer. A single next
+// question is asked once no question is left pending. Blank answers, such as empty transcript turns,
+// are skipped; any other invalid answer, or one matching no pending question confidently enough,
+// fails the request before anything is stored in all-or-nothing mode and is skipped in best-effort
+// mode. The result reports what became of every answer: the interaction it answered, or why it
+// answered none.
+func (dsvc *DialecticService) BulkAnswer(input *models.BulkAnswerInput) (*models.BulkAnswerOutput, error) {
+	var answers []string
+	// answerIndices holds the position in input.Answers of each of answers
+	var answerIndices []int
+	invalid := make(map[int]error)
+	for i, answer := range input.Answers {
+		answer, err := validateContent("answer", answer, dsvc.maxContentLength)
+		if err != nil && !errors.Is(err, ErrEmptyContent) && input.Mode != models.BatchModeBestEffort {
+			return nil, fmt.Errorf("answer %d: %w", i, err)
+		}
+		if err != nil {
+			invalid[i] = err
+			continue
+		}
+		answers = append(answers, answer)
+		answerIndices = append(answerIndices, i)
+	}
+	if len(answers) == 0 {
+		return nil, ErrNoAnswers
+	}
+
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+	dialectic.Version++
+
+	var pendingIndices []int
+	for i, interaction := range dialectic.UserInteractions {
+		if interaction.Status == models.StatusPendingAnswer {
+			pendingIndices = append(pendingIndices, i)
+		}
+	}
+	if len(pendingIndices) == 0 {
+		return nil, ErrNoPendingQuestions
+	}
+
+	answerBlob := strings.Join(answers, "\n\n")
+	if err := dsvc.PreprocessDialectic(&answerBlob, dialectic); err != nil {
+		return nil, err
+	}
+
+	var answeredIDs []string
+	var extractedBeliefs []*models.Belief
+	// answeredBy maps the position in input.Answers of each matched answer to the interaction it answered
+	answeredBy := make(map[int]string)
+	stillPending := false
+	for _, idx := range pendingIndices {
+		interaction := dialectic.UserInteractions[idx]
+		if interaction.Status != models.StatusAnswered {
+			stillPending = true
+			continue
+		}
+		answeredIDs = append(answeredIDs, interaction.ID)
+		qa := getQuestionAnswer(interaction.Interaction)
+		extractedBeliefs = append(extractedBeliefs, qa.ExtractedBeliefs...)
+		if i := matchedAnswerIndex(answers, qa.Answer.UserAnswer); i >= 0 {
+			if _, ok := answeredBy[answerIndices[i]]; !ok {
+				answeredBy[answerIndices[i]] = interaction.ID
+			}
+		}
+	}
+	logging.Infof(dsvc.context(), "Matched %d answers to %d pending questions", len(answeredIDs), len(pendingIndices))
+
+	var result models.BatchResult
+	for i := range input.Answers {
+		if err, ok := invalid[i]; ok {
+			result.Fail(i, err)
+			continue
+		}
+		interactionID, ok := answeredBy[i]
+		if !ok && input.Mode != models.BatchModeBestEffort {
+			return nil, fmt.Errorf("answer %d: %w", i, ErrAnswerNotMatched)
+		}
+		if ok {
+			result.Succeed(i, interactionID)
+		} else {
+			result.Fail(i, ErrAnswerNotMatched)
+		}
+	}
+
+	bs, err := dsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+	bs.Beliefs = append(bs.Beliefs, extractedBeliefs...)
+
+	if len(answeredIDs) > 0 && !stillPending {
+		if err := dsvc.appendNextQuestion(dialectic, bs, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	// The dialectic's versioned write goes first, so a conflicting bulk answer leaves the beliefs untouched
+	if err := dsvc.storeDialecticValue(input.SelfModelID, dialectic); err != nil {
+		return nil, err
+	}
+	if len(extractedBeliefs) > 0 {
+		if err := storeBeliefSystem(dsvc.kvStore, dsvc.observer, input.SelfModelID, bs); err != nil {
+			return nil, fmt.Errorf("failed to store updated belief system: %w", err)
+		}
+	}
+
+	return &models.BulkAnswerOutput{
+		Dialectic:              *dialectic,
+		AnsweredInteractionIDs: answeredIDs,
+		Result:                 result,
+	}, nil
+}
+
+// matchedAnswerIndex returns the index of the answer among answers that matched was taken from,
+// or -1 if it came from none of them. An answer equal to matched wins over one merely containing it,
+// so a short answer repeated within a longer one is traced to the right answer.
+func matchedAnswerIndex(answers []string, matched string) int {
+	matched = strings.TrimSpace(matched)
+	if matched == "" {
+		return -1
+	}
+	for i, answer := range answers {
+		if strings.TrimSpace(answer) == matched {
+			return i
+		}
+	}
+	for i, answer := range answers {
+		if strings.Contains(answer, matched) {
+			return i
+		}
+	}
+	return -1
+}
+
 // ExecuteAction performs an action and produces an observation
 func (dsvc *DialecticService) ExecuteAction(action *models.Action, interaction *models.DialecticalInteraction, answer ...string) (*models.Observation, error) {
 	// beliefContext, err := dsvc.getBeliefContextFromInteraction(interaction)
@@ -538,24 +1216,55 @@ func (dsvc *DialecticService) interpretResourceAsState(resource *models.Resource
 	return resource.Content
 }
 
-// generatePredictedObservation creates a predicted observation for a dialectical interaction
-func (dsvc *DialecticService) generatePredictedObservation(interaction *models.DialecticalInteraction) (*models.Prediction, error) {
+// answerStates are the states answers are placed on to compare predicted and actual answers,
+// the same ones observation contexts use.
+var answerStates = []string{"Positive", "Negative", "Neutral"}
+
+// AnswerPredictor predicts answers to questions and places answers on states, so predicted and
+// actual answers can be compared. *ai.AIHelper is the production implementation.
+type AnswerPredictor interface {
+	PredictAnswer(question string) (string, error)
+	GetAnswerStateDistributions(question string, answers []string, states []string) ([]map[string]float32, error)
+}
+
+// generatePredictedObservation predicts an answer to the interaction's question and compares it with
+// the actual answer.
+func (dsvc *DialecticService) generatePredictedObservation(interaction *models.DialecticalInteraction, answer string) (*models.Prediction, error) {
 	if interaction == nil {
 		return nil, fmt.Errorf("interaction cannot be nil")
 	}
+	return predictObservation(dsvc.aih, interaction.ID, getQuestion(interaction), answer)
+}
 
-	predictedAnswer, err := dsvc.aih.PredictAnswer(getQuestion(interaction))
+// predictObservation predicts an answer to question and places it and the actual answer on
+// answerStates in a single call; the discrepancy between the two distributions measures how much
+// the answer revealed.
+func predictObservation(predictor AnswerPredictor, interactionID, question, answer string) (*models.Prediction, error) {
+	predictedAnswer, err := predictor.PredictAnswer(question)
 	if err != nil {
 		return nil, fmt.Errorf("failed to predict answer: %w", err)
 	}
 
+	distributions, err := predictor.GetAnswerStateDistributions(question, []string{predictedAnswer, answer}, answerStates)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
 	return &models.Prediction{
+		PredictedObservation: &models.Observation{
+			DialecticInteractionID: interactionID,
+			Type:                   models.Answer,
+			StateDistribution:      distributions[0],
+			Timestamp:              now,
+		},
 		Observation: &models.Observation{
-			DialecticInteractionID: interaction.ID,
+			DialecticInteractionID: interactionID,
 			Type:                   models.Answer,
-			StateDistribution:      map[string]float32{predictedAnswer: 1.0},
-			Timestamp:              time.Now().UnixMilli(),
+			StateDistribution:      distributions[1],
+			Timestamp:              now,
 		},
+		Discrepancy: models.NewDiscrepancy(interactionID, distributions[0], distributions[1]),
 	}, nil
 }
 
@@ -625,17 +1334,17 @@ func (dsvc *DialecticService) PreprocessQuestionAnswers(input *models.Preprocess
 
 	// Process question blobs
 	for i, questionBlob := range input.QuestionBlobs {
-		log.Printf("Processing Question Blob %d:\n%s\n", i+1, questionBlob)
+		logging.Debugf(dsvc.context(), "Processing Question Blob %d:\n%s\n", i+1, questionBlob)
 
 		// Extract only the question section
 		questionSection := extractQuestionsFromBlob(questionBlob)
-		log.Printf("Question Section %d:\n%s\n", i+1, questionSection)
+		logging.Debugf(dsvc.context(), "Question Section %d:\n%s\n", i+1, questionSection)
 
 		extractedQuestions, err := dsvc.aih.ExtractQuestionsFromText(questionSection)
 		if err != nil {
 			return nil, fmt.Errorf("failed to extract questions: %w", err)
 		}
-		log.Printf("Extracted Questions %d: %v\n", i+1, extractedQuestions)
+		logging.Debugf(dsvc.context(), "Extracted Questions %d: %v\n", i+1, extractedQuestions)
 		questions = append(questions, extractedQuestions...)
 	}
 
@@ -644,24 +1353,31 @@ func (dsvc *DialecticService) PreprocessQuestionAnswers(input *models.Preprocess
 	for i, question := range questions {
 		qaPairs[i] = &models.QuestionAnswerPair{
 			Question: question,
-			Answer:   "No answer provided",
+			Answer:   ai.NoAnswerProvided,
 		}
 	}
 
 	// Process answer blobs
 	allAnswers := strings.Join(input.AnswerBlobs, "\n\n")
-	log.Printf("Combined Answer Blob:\n%s\n", allAnswers)
-	matches, err := dsvc.aih.MatchAnswersToQuestions(allAnswers, questions)
+	logging.Debugf(dsvc.context(), "Combined Answer Blob:\n%s\n", allAnswers)
+	matches, err := dsvc.aih.MatchAnswersToQuestionsWithConfidence(allAnswers, questions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to match answers: %w", err)
 	}
-	log.Printf("Matched Answers: %v\n", matches)
+	logging.Debugf(dsvc.context(), "Matched Answers: %v\n", matches)
 
-	// Update answers for each question
+	// Update answers for each question, leaving it unanswered when the match is too weak
 	for i, match := range matches {
-		if i < len(qaPairs) && match != "" {
-			qaPairs[i].Answer = match
+		if i >= len(qaPairs) || match.Answer == "" {
+			continue
+		}
+		qaPairs[i].Confidence = match.Confidence
+		if match.Confidence < dsvc.answerMatchThreshold {
+			logging.Infof(dsvc.context(), "Leaving %q unanswered, match confidence %.2f is below %.2f",
+				qaPairs[i].Question, match.Confidence, dsvc.answerMatchThreshold)
+			continue
 		}
+		qaPairs[i].Answer = match.Answer
 	}
 
 	return &models.PreprocessQuestionAnswerOutput{
@@ -1,6 +1,7 @@
 package svc
 
 import (
+	"context"
 	ai "epistemic-me-core/ai"
 	"epistemic-me-core/svc/models"
 	"fmt"
@@ -28,6 +29,18 @@ func NewDialecticEpistemology(beliefService *BeliefService, ai *ai.AIHelper) *Di
 	}
 }
 
+// withContext returns a copy whose AI calls are made within ctx.
+func (de *DialecticalEpistemology) withContext(ctx context.Context) *DialecticalEpistemology {
+	scoped := *de
+	if de.ai != nil {
+		scoped.ai = de.ai.WithContext(ctx)
+	}
+	if de.bsvc != nil {
+		scoped.bsvc = de.bsvc.WithContext(ctx)
+	}
+	return &scoped
+}
+
 func (de *DialecticalEpistemology) Process(event *models.DialecticEvent, dryRun bool, selfModelID string) (*models.BeliefSystem, error) {
 	startTime := time.Now()
 	var updatedBeliefs []models.Belief
@@ -157,7 +170,7 @@ func (de *DialecticalEpistemology) Respond(bs *models.BeliefSystem, event *model
 		}
 	}
 
-	nextInteraction, interactionErr := de.generatePendingDialecticalInteraction(event.PreviousInteractions, bs, customQuestion)
+	nextInteraction, interactionErr := de.generatePendingDialecticalInteraction(event, bs, customQuestion)
 	if interactionErr != nil {
 		err = interactionErr
 	} else {
@@ -210,16 +223,24 @@ func getDialecticalInteractionAsEvent(interaction models.DialecticalInteraction)
 	if qa == nil {
 		return nil, fmt.Errorf("interaction is not a QuestionAnswer type")
 	}
+	var extractedBeliefs []string
+	for _, belief := range qa.ExtractedBeliefs {
+		if belief != nil {
+			extractedBeliefs = append(extractedBeliefs, belief.GetContentAsString())
+		}
+	}
 	return &ai.InteractionEvent{
-		Question: qa.Question.Question,
-		Answer:   qa.Answer.UserAnswer,
+		Question:         qa.Question.Question,
+		Answer:           qa.Answer.UserAnswer,
+		ExtractedBeliefs: extractedBeliefs,
 	}, nil
 }
 
-func (de *DialecticalEpistemology) generatePendingDialecticalInteraction(previousInteractions []models.DialecticalInteraction, userBeliefSystem *models.BeliefSystem, customQuestion *string) (*models.DialecticalInteraction, error) {
+func (de *DialecticalEpistemology) generatePendingDialecticalInteraction(event *models.DialecticEvent, userBeliefSystem *models.BeliefSystem, customQuestion *string) (*models.DialecticalInteraction, error) {
 	var events []ai.InteractionEvent
-	for _, interaction := range previousInteractions {
-		if interaction.Status == models.StatusAnswered {
+	for _, interaction := range event.PreviousInteractions {
+		// Answers still being processed in the background count as asked questions
+		if interaction.Status == models.StatusAnswered || interaction.Status == models.StatusProcessing {
 			interactionEvent, err := getDialecticalInteractionAsEvent(interaction)
 			if err != nil {
 				log.Printf("Error in getDialecticalInteractionAsEvent: %v", err)
@@ -229,18 +250,16 @@ func (de *DialecticalEpistemology) generatePendingDialecticalInteraction(previou
 		}
 	}
 
-	beliefStrings := make([]string, len(userBeliefSystem.Beliefs))
-	for i, belief := range userBeliefSystem.Beliefs {
-		beliefStrings[i] = belief.GetContentAsString()
-	}
-
 	var question string
 	var err error
 
 	if customQuestion != nil {
 		question = *customQuestion
 	} else {
-		question, err = de.ai.GenerateQuestion(strings.Join(beliefStrings, " "), events)
+		if events, err = de.condenseEvents(event, events); err != nil {
+			return nil, err
+		}
+		question, err = de.ai.GenerateQuestionForBeliefSystem(userBeliefSystem, events)
 		if err != nil {
 			log.Printf("Error in GenerateQuestion: %v", err)
 			return nil, err
@@ -339,4 +358,10 @@ func (de *DialecticalEpistemology) updatePredictiveProcessingWithInteraction(
 		}
 		ppc.BeliefContexts = append(ppc.BeliefContexts, bc)
 	}
+
+	ctx := context.Background()
+	if de.bsvc != nil {
+		ctx = de.bsvc.context()
+	}
+	normalizeBeliefContexts(ctx, ppc)
 }
@@ -0,0 +1,102 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/logging"
+	"epistemic-me-core/svc/models"
+)
+
+// developerEmailScope is the store owner of the claims on developer emails, which are unique across
+// all developers. User emails are claimed under their developer, so they only need to be unique
+// among that developer's users.
+const developerEmailScope = "developer_emails"
+
+// normalizeEmail is the form emails are compared in.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func emailClaimKey(email string) string {
+	return "email_" + normalizeEmail(email)
+}
+
+// claimEmail reserves email within scope for ownerID. The claim is stored at version 1, so the
+// store's version check makes claiming atomic: of two concurrent claims only one succeeds and the
+// other gets ErrEmailTaken. An empty email claims nothing.
+func claimEmail(kvStore db.KeyValueStore, scope, email, ownerID string) error {
+	if normalizeEmail(email) == "" {
+		return nil
+	}
+	claim := models.EmailClaim{Email: normalizeEmail(email), OwnerID: ownerID}
+	if err := kvStore.Store(scope, emailClaimKey(email), claim, 1); err != nil {
+		if errors.Is(err, db.ErrVersionConflict) {
+			return fmt.Errorf("%w: %q", ErrEmailTaken, email)
+		}
+		return fmt.Errorf("failed to claim email: %w", err)
+	}
+	return nil
+}
+
+// releaseEmail drops a claim whose owner could not be stored.
+func releaseEmail(kvStore db.KeyValueStore, scope, email string) {
+	if normalizeEmail(email) != "" {
+		_ = kvStore.Delete(scope, emailClaimKey(email))
+	}
+}
+
+// IndexEmails claims the emails of the developers and users stored before emails were claimed, so
+// new registrations cannot reuse them. When stored records already share an email the oldest keeps
+// it and the others are logged, since they predate the check.
+func (s *DeveloperService) IndexEmails() error {
+	developers, err := db.ListAllOfType[models.Developer](s.kvStore)
+	if err != nil {
+		return fmt.Errorf("failed to list developers: %w", err)
+	}
+	sort.Slice(developers, func(i, j int) bool { return developers[i].CreatedAt < developers[j].CreatedAt })
+	for _, developer := range developers {
+		if err := backfillEmail(s.kvStore, developerEmailScope, developer.Email, developer.ID); err != nil {
+			return err
+		}
+	}
+
+	users, err := db.ListAllOfType[models.User](s.kvStore)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt < users[j].CreatedAt })
+	for _, user := range users {
+		if user.DeveloperID == "" {
+			continue
+		}
+		if err := backfillEmail(s.kvStore, user.DeveloperID, user.Email, user.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillEmail claims email within scope for ownerID unless ownerID already holds it.
+func backfillEmail(kvStore db.KeyValueStore, scope, email, ownerID string) error {
+	if normalizeEmail(email) == "" {
+		return nil
+	}
+	if value, err := kvStore.Retrieve(scope, emailClaimKey(email)); err == nil {
+		if claim, ok := value.(*models.EmailClaim); ok && claim.OwnerID != ownerID {
+			logging.Warnf(context.Background(), "%s shares the email %q with %s", ownerID, normalizeEmail(email), claim.OwnerID)
+		}
+		return nil
+	} else if !errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("failed to read email claim: %w", err)
+	}
+	err := claimEmail(kvStore, scope, email, ownerID)
+	if errors.Is(err, ErrEmailTaken) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+
+	"epistemic-me-core/db"
+)
+
+var (
+	// ErrSelfModelNotFound is returned when no self model is stored under the requested ID
+	ErrSelfModelNotFound = errors.New("self model not found")
+
+	// ErrBeliefNotFound is returned when no belief is stored under the requested ID
+	ErrBeliefNotFound = errors.New("belief not found")
+
+	// ErrBeliefSystemNotFound is returned when a self model has no stored belief system
+	ErrBeliefSystemNotFound = errors.New("belief system not found")
+
+	// ErrSnapshotNotFound is returned when a self model has no belief system snapshot with the
+	// requested name
+	ErrSnapshotNotFound = errors.New("belief system snapshot not found")
+
+	// ErrResourceNotFound is returned when no resource is stored under the requested ID
+	ErrResourceNotFound = errors.New("resource not found")
+
+	// ErrNoTags is returned when tagging or untagging a belief without any non-blank tag
+	ErrNoTags = errors.New("no tags given")
+
+	// ErrInvalidResource is returned when creating a resource without content or a known type
+	ErrInvalidResource = errors.New("resource needs content and a known type")
+
+	// ErrNoResources is returned when bootstrapping a self model without any documents
+	ErrNoResources = errors.New("no resources given")
+
+	// ErrNoBeliefs is returned when creating a batch of beliefs without any
+	ErrNoBeliefs = errors.New("no beliefs given")
+
+	// ErrInvalidBeliefType is returned for a belief type that is not a statement, falsifiable or causal
+	ErrInvalidBeliefType = errors.New("invalid belief type")
+
+	// ErrNoEvidence is returned when adding to a belief neither a resource nor other evidence
+	ErrNoEvidence = errors.New("no evidence given")
+
+	// ErrDialecticNotFound is returned when no dialectic is stored under the requested ID
+	ErrDialecticNotFound = errors.New("dialectic not found")
+
+	// ErrInteractionNotFound is returned when a dialectic has no interaction with the requested ID
+	ErrInteractionNotFound = errors.New("interaction not found")
+
+	// ErrInteractionNotAnswered is returned when an operation needs an answered interaction
+	ErrInteractionNotAnswered = errors.New("interaction has not been answered")
+
+	// ErrInvalidAnnotation is returned when annotating an interaction without an annotator or with
+	// a quality score outside 0 to 1
+	ErrInvalidAnnotation = errors.New("annotation needs an annotator and a quality score from 0 to 1")
+
+	// ErrInvalidEvalScore is returned when ingesting eval scores without a metric name
+	ErrInvalidEvalScore = errors.New("eval scores need a metric name")
+
+	// ErrNoAnswers is returned when answering a dialectic without any answer text
+	ErrNoAnswers = errors.New("no answers given")
+
+	// ErrNoPendingQuestions is returned when answering a dialectic that has no question awaiting an
+	// answer
+	ErrNoPendingQuestions = errors.New("dialectic has no pending questions")
+
+	// ErrAnswerNotMatched is reported for a bulk answer that matched none of the dialectic's pending
+	// questions confidently enough
+	ErrAnswerNotMatched = errors.New("answer matched no pending question")
+
+	// ErrObservationContextNotFound is returned when a belief system has no observation context with
+	// the requested ID
+	ErrObservationContextNotFound = errors.New("observation context not found")
+
+	// ErrObservationContextInUse is returned when deleting an observation context that belief
+	// contexts still reference
+	ErrObservationContextInUse = errors.New("observation context is referenced by belief contexts")
+
+	// ErrInvalidWebhookURL is returned when registering a webhook URL that is not an absolute http
+	// or https URL
+	ErrInvalidWebhookURL = errors.New("webhook URL must be an absolute http or https URL")
+
+	// ErrWebhookHostNotAllowed is returned when registering a webhook URL whose host is a loopback,
+	// link-local or private address
+	ErrWebhookHostNotAllowed = errors.New("webhook URL must point at a public host")
+
+	// ErrAPIKeyNotFound is returned when a developer holds no API key with the requested value
+	ErrAPIKeyNotFound = errors.New("API key not found")
+
+	// ErrAPIKeyRevoked is returned when authenticating with a key that was revoked or whose
+	// rotation grace window is over
+	ErrAPIKeyRevoked = errors.New("API key has been revoked or has expired")
+
+	// ErrUnknownAPIKey is returned when authenticating with a key this server never issued
+	ErrUnknownAPIKey = errors.New("API key was not issued by this server")
+
+	// ErrOpenAIKeysDisabled is returned when storing a developer's OpenAI key on a server without a
+	// secret to encrypt it with
+	ErrOpenAIKeysDisabled = errors.New("developer OpenAI keys are not enabled on this server")
+
+	// ErrEmailTaken is returned when registering a developer, or a developer's user, with an email
+	// that is already registered
+	ErrEmailTaken = errors.New("email is already registered")
+
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent with a request for a
+	// different self model than the one it was first used with
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used for a different request")
+
+	// ErrRequestInProgress is returned when a request with the same idempotency key is still being
+	// processed
+	ErrRequestInProgress = errors.New("a request with this idempotency key is still being processed")
+
+	// ErrBackgroundQueueFull is returned when an answer is given in background mode while its self
+	// model's share of the background queue is full
+	ErrBackgroundQueueFull = errors.New("too many answers are waiting to be processed in the background")
+
+	// ErrInvalidConditionalProbs is returned when conditional probabilities cannot be normalized
+	// into a distribution over a context's states
+	ErrInvalidConditionalProbs = errors.New("invalid conditional probabilities")
+
+	// ErrInvalidPageToken is returned when a page token was not issued by the listing it is passed to
+	ErrInvalidPageToken = errors.New("invalid page token")
+
+	// ErrInvalidCohort is returned when assigning a cohort outside the configured set
+	ErrInvalidCohort = errors.New("cohort is not one of the configured cohorts")
+
+	// ErrEmptyContent is returned when a belief's content or a dialectic answer is blank
+	ErrEmptyContent = errors.New("content is empty")
+
+	// ErrContentTooLong is returned when a belief's content or a dialectic answer is longer than
+	// the configured maximum
+	ErrContentTooLong = errors.New("content is too long")
+)
+
+// wrapNotFound tags a store lookup failure with sentinel when the key was missing, keeping the
+// store error in the chain. Other failures are returned unchanged.
+func wrapNotFound(err error, sentinel error) error {
+	if errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("%w: %w", sentinel, err)
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package svc
+
+import (
+	"epistemic-me-core/svc/models"
+)
+
+// ExportDialecticForEval exports the answered interactions of a dialectic as DeepEval test cases:
+// each question is an input, its answer the actual output and the beliefs extracted from the
+// answer the context.
+func (dsvc *DialecticService) ExportDialecticForEval(input *models.ExportDialecticForEvalInput) (*models.ExportDialecticForEvalOutput, error) {
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := models.DialecticEvalExport{
+		DialecticID: dialectic.ID,
+		SelfModelID: input.SelfModelID,
+		TestCases:   []models.EvalTestCase{},
+	}
+	for _, interaction := range dialectic.UserInteractions {
+		qa := getQuestionAnswer(interaction.Interaction)
+		if interaction.Status != models.StatusAnswered || qa == nil {
+			continue
+		}
+		beliefs := make([]string, 0, len(qa.ExtractedBeliefs))
+		for _, belief := range qa.ExtractedBeliefs {
+			beliefs = append(beliefs, belief.GetContentAsString())
+		}
+		export.TestCases = append(export.TestCases, models.EvalTestCase{
+			Name:         interaction.ID,
+			Input:        qa.Question.Question,
+			ActualOutput: qa.Answer.UserAnswer,
+			Context:      beliefs,
+			AdditionalMetadata: map[string]string{
+				"dialectic_id":   dialectic.ID,
+				"interaction_id": interaction.ID,
+				"self_model_id":  input.SelfModelID,
+			},
+		})
+	}
+	return &models.ExportDialecticForEvalOutput{Export: export}, nil
+}
@@ -0,0 +1,40 @@
+package svc
+
+import (
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/svc/models"
+)
+
+// DefaultExtractionHistoryWindow is how many earlier answered interactions are shown to the AI when
+// extracting the beliefs of an answer.
+const DefaultExtractionHistoryWindow = 3
+
+// SetExtractionHistoryWindow sets how many earlier answered interactions of a dialectic are shown
+// to the AI when extracting the beliefs of an answer, so an answer referring back to them is
+// understood. Zero extracts each answer on its own.
+func (dsvc *DialecticService) SetExtractionHistoryWindow(window int) {
+	dsvc.extractionHistoryWindow = window
+}
+
+// extractionHistory returns the most recent answered interactions of previous, oldest first and at
+// most the configured window of them.
+func (dsvc *DialecticService) extractionHistory(previous []models.DialecticalInteraction) []ai.InteractionEvent {
+	if dsvc.extractionHistoryWindow <= 0 {
+		return nil
+	}
+	var history []ai.InteractionEvent
+	for i := len(previous) - 1; i >= 0 && len(history) < dsvc.extractionHistoryWindow; i-- {
+		interaction := &previous[i]
+		// Answers still being processed in the background are part of the conversation too
+		if interaction.Status != models.StatusAnswered && interaction.Status != models.StatusProcessing {
+			continue
+		}
+		if answer := getAnswer(interaction); answer != "" {
+			history = append(history, ai.InteractionEvent{Question: getQuestion(interaction), Answer: answer})
+		}
+	}
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history
+}
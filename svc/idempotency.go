@@ -0,0 +1,78 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc/models"
+)
+
+// DefaultIdempotencyTTL is how long an idempotency key is remembered unless SetIdempotencyTTL is
+// called.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+const (
+	idempotentCreateBelief    = "CreateBelief"
+	idempotentCreateDialectic = "CreateDialectic"
+)
+
+// idempotencyKeys remembers which record each idempotency key created.
+type idempotencyKeys struct {
+	kvStore db.KeyValueStore
+	ttl     time.Duration
+}
+
+func newIdempotencyKeys(kvStore db.KeyValueStore) *idempotencyKeys {
+	return &idempotencyKeys{kvStore: kvStore, ttl: DefaultIdempotencyTTL}
+}
+
+// idempotencyOwner is the store owner of a requester's keys, kept apart from its other data.
+func idempotencyOwner(requesterID string) string {
+	return "idempotency:" + requesterID
+}
+
+// claim reserves key for the record resourceID is about to be created as. When the key was already
+// claimed it returns the ID of the record created for it instead. The claim is stored at version 1,
+// so of two concurrent requests with the same key only one creates a record. An empty key claims
+// nothing.
+func (k *idempotencyKeys) claim(requesterID, operation, key, selfModelID, resourceID string) (string, error) {
+	if key == "" {
+		return "", nil
+	}
+	record := models.IdempotencyRecord{
+		Key:                key,
+		Operation:          operation,
+		SelfModelID:        selfModelID,
+		ResourceID:         resourceID,
+		CreatedAtMillisUTC: time.Now().UnixMilli(),
+	}
+	err := k.kvStore.Store(idempotencyOwner(requesterID), operation+":"+key, record, 1, db.WithTTL(k.ttl))
+	if err == nil {
+		return "", nil
+	}
+	if !errors.Is(err, db.ErrVersionConflict) {
+		return "", fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+
+	value, err := k.kvStore.Retrieve(idempotencyOwner(requesterID), operation+":"+key)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve idempotency key: %w", err)
+	}
+	previous, ok := value.(*models.IdempotencyRecord)
+	if !ok {
+		return "", fmt.Errorf("invalid idempotency key data")
+	}
+	if previous.SelfModelID != selfModelID {
+		return "", fmt.Errorf("%w: %q", ErrIdempotencyKeyReused, key)
+	}
+	return previous.ResourceID, nil
+}
+
+// release drops a claim whose record could not be created, so a retry can create it.
+func (k *idempotencyKeys) release(requesterID, operation, key string) {
+	if key != "" {
+		_ = k.kvStore.Delete(idempotencyOwner(requesterID), operation+":"+key)
+	}
+}
@@ -0,0 +1,112 @@
+package svc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"epistemic-me-core/svc/models"
+)
+
+// AnnotateInteraction appends a curator's note and quality rating to an interaction. An
+// interaction may hold any number of annotations; they are kept for review only and never read by
+// belief extraction.
+func (dsvc *DialecticService) AnnotateInteraction(input *models.AnnotateInteractionInput) (*models.AnnotateInteractionOutput, error) {
+	annotation := models.InteractionAnnotation{
+		AnnotatorID:        strings.TrimSpace(input.AnnotatorID),
+		Note:               strings.TrimSpace(input.Note),
+		QualityScore:       input.QualityScore,
+		CreatedAtMillisUTC: time.Now().UnixMilli(),
+	}
+	if annotation.AnnotatorID == "" || annotation.QualityScore < 0 || annotation.QualityScore > 1 {
+		return nil, ErrInvalidAnnotation
+	}
+	// The note is optional, but bounded like answers
+	if annotation.Note != "" {
+		if _, err := validateContent("note", annotation.Note, dsvc.maxContentLength); err != nil {
+			return nil, err
+		}
+	}
+
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+	interaction, err := findInteraction(dialectic, input.InteractionID)
+	if err != nil {
+		return nil, err
+	}
+	interaction.Annotations = append(interaction.Annotations, annotation)
+	dialectic.Version++
+	if err := dsvc.storeDialecticValue(input.SelfModelID, dialectic); err != nil {
+		return nil, err
+	}
+
+	return &models.AnnotateInteractionOutput{
+		Annotation:  annotation,
+		Annotations: interaction.Annotations,
+	}, nil
+}
+
+// IngestEvalScores attaches the scores of evals run outside the server to the interactions of a
+// dialectic. A metric scored again for an interaction replaces its earlier score. Every score is
+// checked before any is stored, so a request naming an unknown interaction changes nothing.
+func (dsvc *DialecticService) IngestEvalScores(input *models.IngestEvalScoresInput) (*models.IngestEvalScoresOutput, error) {
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	for _, score := range input.Scores {
+		metric := strings.TrimSpace(score.Metric)
+		if metric == "" {
+			return nil, ErrInvalidEvalScore
+		}
+		interaction, err := findInteraction(dialectic, score.InteractionID)
+		if err != nil {
+			return nil, err
+		}
+		ingested := models.EvalScore{Metric: metric, Score: score.Score, Passed: score.Passed, IngestedAtMillisUTC: now}
+		if i := slices.IndexFunc(interaction.EvalScores, func(s models.EvalScore) bool { return s.Metric == metric }); i >= 0 {
+			interaction.EvalScores[i] = ingested
+		} else {
+			interaction.EvalScores = append(interaction.EvalScores, ingested)
+		}
+	}
+	if len(input.Scores) > 0 {
+		dialectic.Version++
+		if err := dsvc.storeDialecticValue(input.SelfModelID, dialectic); err != nil {
+			return nil, err
+		}
+	}
+	return &models.IngestEvalScoresOutput{Dialectic: *dialectic}, nil
+}
+
+// ListInteractionAnnotations returns the annotations of an interaction, oldest first.
+func (dsvc *DialecticService) ListInteractionAnnotations(input *models.ListInteractionAnnotationsInput) (*models.ListInteractionAnnotationsOutput, error) {
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+	interaction, err := findInteraction(dialectic, input.InteractionID)
+	if err != nil {
+		return nil, err
+	}
+	annotations := interaction.Annotations
+	if annotations == nil {
+		annotations = []models.InteractionAnnotation{}
+	}
+	return &models.ListInteractionAnnotationsOutput{Annotations: annotations}, nil
+}
+
+// findInteraction returns the interaction of dialectic with the given ID.
+func findInteraction(dialectic *models.Dialectic, interactionID string) (*models.DialecticalInteraction, error) {
+	for i := range dialectic.UserInteractions {
+		if dialectic.UserInteractions[i].ID == interactionID {
+			return &dialectic.UserInteractions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInteractionNotFound, interactionID)
+}
@@ -0,0 +1,77 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc/models"
+)
+
+// interactionSummaryKey is the store key of a dialectic's interaction summary. It is kept apart from
+// the dialectic, so rolling the summary forward never conflicts with the dialectic's versioned writes.
+func interactionSummaryKey(dialecticID string) string {
+	return dialecticID + ":summary"
+}
+
+// condenseEvents returns events with the ones that aged out of the question history window replaced
+// by the summary stored for the event's dialectic. The summary is rolled forward over the events that
+// aged out since it was stored, and stored again unless the event keeps it.
+func (de *DialecticalEpistemology) condenseEvents(event *models.DialecticEvent, events []ai.InteractionEvent) ([]ai.InteractionEvent, error) {
+	if event.DialecticID == "" || event.SelfModelID == "" {
+		return events, nil
+	}
+	key := interactionSummaryKey(event.DialecticID)
+
+	covered := 0
+	condensed := events
+	value, err := de.bsvc.kvStore.Retrieve(event.SelfModelID, key)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return nil, fmt.Errorf("failed to retrieve interaction summary: %w", err)
+	}
+	// A summary covering more than the dialectic asked is stale and left out
+	if stored, ok := value.(*models.DialecticSummary); ok && stored.Interactions <= len(events) {
+		covered = stored.Interactions
+		condensed = append([]ai.InteractionEvent{{
+			Question:         ai.SummaryQuestion,
+			Answer:           stored.Summary,
+			ExtractedBeliefs: stored.ExtractedBeliefs,
+		}}, events[covered:]...)
+	}
+
+	condensed, err = de.ai.CondenseInteractionEvents(condensed)
+	if err != nil {
+		return nil, err
+	}
+	if len(condensed) == 0 || condensed[0].Question != ai.SummaryQuestion || event.KeepSummary {
+		return condensed, nil
+	}
+	if rolled := len(events) - (len(condensed) - 1); rolled != covered {
+		summary := models.DialecticSummary{
+			DialecticID:        event.DialecticID,
+			SelfModelID:        event.SelfModelID,
+			Summary:            condensed[0].Answer,
+			ExtractedBeliefs:   condensed[0].ExtractedBeliefs,
+			Interactions:       rolled,
+			UpdatedAtMillisUTC: time.Now().UnixMilli(),
+		}
+		// The summary only saves work, so failing to store it does not fail the question
+		if err := de.bsvc.kvStore.ForceStore(event.SelfModelID, key, summary, 1); err != nil {
+			log.Printf("Failed to store the interaction summary of dialectic %s: %v", event.DialecticID, err)
+		}
+	}
+	return condensed, nil
+}
+
+// dropInteractionSummary removes the interaction summary of a dialectic, so it is summarized afresh
+// from its interactions as they now are.
+func (dsvc *DialecticService) dropInteractionSummary(selfModelID, dialecticID string) error {
+	err := dsvc.kvStore.Delete(selfModelID, interactionSummaryKey(dialecticID))
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("failed to drop interaction summary: %w", err)
+	}
+	return nil
+}
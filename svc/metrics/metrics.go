@@ -1,6 +1,9 @@
 package metric
 
-import "epistemic-me-core/svc/models"
+import (
+	"epistemic-me-core/svc/models"
+	"strings"
+)
 
 // Clarification is a metric of how much a belief has been
 // engaged with by a user through using some Dialectical Strategy.
@@ -29,3 +32,23 @@ func ComputeClarifiedMetric(versionedBeliefs [][]models.Belief) (models.Metric,
 	// Return the average clarification across all beliefs
 	return models.Average(clarification_scores)
 }
+
+// ComputeTopicCoverageProgression returns, after each turn, the share of topics mentioned in that
+// turn or an earlier one. Topics are matched case-insensitively against the turn's text.
+func ComputeTopicCoverageProgression(topics []string, turns []string) []float32 {
+	if len(topics) == 0 {
+		return nil
+	}
+	covered := make(map[string]bool, len(topics))
+	progression := make([]float32, 0, len(turns))
+	for _, turn := range turns {
+		turn = strings.ToLower(turn)
+		for _, topic := range topics {
+			if !covered[topic] && strings.Contains(turn, strings.ToLower(topic)) {
+				covered[topic] = true
+			}
+		}
+		progression = append(progression, float32(len(covered))/float32(len(topics)))
+	}
+	return progression
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	pbmodels "epistemic-me-core/pb/models"
+)
+
+// BatchMode decides what a batch operation does when some of its items are invalid.
+type BatchMode int32
+
+const (
+	// BatchModeAllOrNothing fails the whole batch on the first invalid item, changing nothing
+	BatchModeAllOrNothing BatchMode = iota
+	// BatchModeBestEffort skips the invalid items, reporting why, and carries out the rest
+	BatchModeBestEffort
+)
+
+// BatchItemResult is the outcome of one item of a batch, identified by its position in the request.
+type BatchItemResult struct {
+	Index     int    `json:"index"`
+	Succeeded bool   `json:"succeeded"`
+	Error     string `json:"error,omitempty"`
+	// ID identifies what the item created, when it created something
+	ID string `json:"id,omitempty"`
+}
+
+// BatchResult holds the outcome of every item of a batch, in request order.
+type BatchResult struct {
+	Items []BatchItemResult `json:"items"`
+}
+
+// Succeed records that the item at index succeeded, creating id if not empty.
+func (r *BatchResult) Succeed(index int, id string) {
+	r.Items = append(r.Items, BatchItemResult{Index: index, Succeeded: true, ID: id})
+}
+
+// Fail records that the item at index failed with err.
+func (r *BatchResult) Fail(index int, err error) {
+	r.Items = append(r.Items, BatchItemResult{Index: index, Error: err.Error()})
+}
+
+// FailedCount returns the number of items that failed.
+func (r BatchResult) FailedCount() int {
+	failed := 0
+	for _, item := range r.Items {
+		if !item.Succeeded {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (r BatchResult) ToProto() []*pbmodels.BatchItemResult {
+	items := make([]*pbmodels.BatchItemResult, len(r.Items))
+	for i, item := range r.Items {
+		items[i] = &pbmodels.BatchItemResult{
+			Index:     int32(item.Index),
+			Succeeded: item.Succeeded,
+			Error:     item.Error,
+			Id:        item.ID,
+		}
+	}
+	return items
+}
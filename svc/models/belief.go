@@ -28,12 +28,70 @@ type Belief struct {
 	Type        BeliefType `json:"type"`
 	Content     []Content  `json:"content"`
 	Active      bool       `json:"active"`
+	// SourceInteractionID is the dialectic interaction the belief was extracted from, if any
+	SourceInteractionID string `json:"source_interaction_id,omitempty"`
+	// EvidenceResourceIDs are the resources cited as supporting evidence for the belief
+	EvidenceResourceIDs []string `json:"evidence_resource_ids,omitempty"`
+	// Confidence is weighed from the evidence given for the belief, from 0 to 1. It is zero until
+	// evidence is given.
+	Confidence float64 `json:"confidence,omitempty"`
+	// Tags are curators' labels for the belief, such as "core" or "needs-review"
+	Tags []string `json:"tags,omitempty"`
+}
+
+// BeliefHistory holds the superseded versions of a belief, oldest first
+type BeliefHistory struct {
+	BeliefID string   `json:"belief_id"`
+	Versions []Belief `json:"versions"`
+	// Version is the store version the history was read at; 0 when it has not been stored yet
+	Version int32 `json:"version,omitempty"`
+}
+
+// BeliefSystemSnapshot is a named checkpoint of a self model's belief system, including its active
+// beliefs. Saving under an existing name stores a new version of the snapshot.
+type BeliefSystemSnapshot struct {
+	Name               string       `json:"name"`
+	SelfModelID        string       `json:"self_model_id"`
+	Version            int32        `json:"version"`
+	CreatedAtMillisUTC int64        `json:"created_at_millis_utc"`
+	BeliefSystem       BeliefSystem `json:"belief_system"`
+}
+
+// Summary describes the snapshot without its belief system.
+func (s *BeliefSystemSnapshot) Summary() *BeliefSystemSnapshotSummary {
+	return &BeliefSystemSnapshotSummary{
+		Name:               s.Name,
+		Version:            s.Version,
+		CreatedAtMillisUTC: s.CreatedAtMillisUTC,
+		BeliefCount:        int32(len(s.BeliefSystem.Beliefs)),
+	}
+}
+
+// BeliefSystemSnapshotSummary describes a saved belief system snapshot.
+type BeliefSystemSnapshotSummary struct {
+	Name               string `json:"name"`
+	Version            int32  `json:"version"`
+	CreatedAtMillisUTC int64  `json:"created_at_millis_utc"`
+	BeliefCount        int32  `json:"belief_count"`
+}
+
+func (s *BeliefSystemSnapshotSummary) ToProto() *pbmodels.BeliefSystemSnapshot {
+	return &pbmodels.BeliefSystemSnapshot{
+		Name:               s.Name,
+		Version:            s.Version,
+		CreatedAtMillisUtc: s.CreatedAtMillisUTC,
+		BeliefCount:        s.BeliefCount,
+	}
 }
 
 // BeliefSystem with BeliefContexts
 type BeliefSystem struct {
 	Beliefs           []*Belief           `json:"beliefs"`
 	EpistemicContexts []*EpistemicContext `json:"epistemic_context"`
+	// Version is the store version the belief system was read at, so a write based on a stale
+	// read conflicts instead of dropping what was written since. It is 0 for a belief system
+	// stored before belief systems were versioned.
+	Version int32 `json:"version,omitempty"`
 }
 
 func (bs BeliefSystem) ToProto() *pbmodels.BeliefSystem {
@@ -73,6 +131,11 @@ func (b Belief) ToProto() *pbmodels.Belief {
 		Version:     b.Version,
 		Type:        protoType,
 		Content:     contentToProto(b.Content),
+
+		SourceInteractionId: b.SourceInteractionID,
+		EvidenceResourceIds: b.EvidenceResourceIDs,
+		Confidence:          b.Confidence,
+		Tags:                b.Tags,
 	}
 }
 
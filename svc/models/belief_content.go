@@ -0,0 +1,59 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// beliefPrefix matches a leading "I believe", or "I believe that", in any casing.
+var beliefPrefix = regexp.MustCompile(`(?i)^i\s+believe(\s+that)?\b\s*`)
+
+// quoteReplacer straightens typographic quotes and apostrophes.
+var quoteReplacer = strings.NewReplacer(
+	"“", `"`, "”", `"`, "„", `"`, "«", `"`, "»", `"`,
+	"‘", "'", "’", "'", "`", "'",
+)
+
+// NormalizeBeliefContent returns the form an extracted belief is stored and displayed in: quotes
+// straightened, whitespace collapsed to single spaces, quotes wrapping the whole belief dropped and
+// a repeated "I believe" prefix, as in "I believe that I believe...", reduced to one.
+func NormalizeBeliefContent(content string) string {
+	content = trimWrappingQuotes(strings.Join(strings.Fields(quoteReplacer.Replace(content)), " "))
+
+	match := beliefPrefix.FindStringSubmatch(content)
+	if match == nil {
+		return content
+	}
+	rest := content
+	for prefix := match[0]; prefix != ""; prefix = beliefPrefix.FindString(rest) {
+		rest = trimWrappingQuotes(rest[len(prefix):])
+	}
+	if rest == "" {
+		return content
+	}
+	if match[1] != "" {
+		return "I believe that " + rest
+	}
+	return "I believe " + rest
+}
+
+// BeliefContentKey returns the form beliefs are compared by when deduplicating: the normalized
+// content in lower case, without its "I believe" prefix or trailing punctuation. Beliefs with the
+// same key say the same thing.
+func BeliefContentKey(content string) string {
+	key := strings.ToLower(NormalizeBeliefContent(content))
+	key = beliefPrefix.ReplaceAllString(key, "")
+	return strings.TrimSpace(strings.TrimRight(key, ".!?"))
+}
+
+// trimWrappingQuotes drops a pair of matching quotes around the whole of content.
+func trimWrappingQuotes(content string) string {
+	for len(content) >= 2 {
+		first, last := content[0], content[len(content)-1]
+		if first != last || (first != '"' && first != '\'') {
+			break
+		}
+		content = strings.TrimSpace(content[1 : len(content)-1])
+	}
+	return content
+}
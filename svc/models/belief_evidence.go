@@ -0,0 +1,45 @@
+package models
+
+import (
+	pbmodels "epistemic-me-core/pb/models"
+)
+
+// BeliefEvidenceLog holds the evidence added to a belief, oldest first. Evidence is only ever
+// appended, so a belief accumulates the evidence given for it over time.
+type BeliefEvidenceLog struct {
+	BeliefID string           `json:"belief_id"`
+	Evidence []BeliefEvidence `json:"evidence"`
+	// Version is the store version the log was read at; 0 when it has not been stored yet
+	Version int32 `json:"version,omitempty"`
+}
+
+func (e BeliefEvidence) ToProto() *pbmodels.BeliefEvidence {
+	var protoType pbmodels.EvidenceType
+	switch e.Type {
+	case EvidenceTypeHypothesis:
+		protoType = pbmodels.EvidenceType_HYPOTHESIS
+	case EvidenceTypeAction:
+		protoType = pbmodels.EvidenceType_ACTION_OUTCOME
+	case EvidenceTypeResource:
+		protoType = pbmodels.EvidenceType_RESOURCE
+	}
+
+	return &pbmodels.BeliefEvidence{
+		Type:               protoType,
+		Content:            e.Content,
+		IsCounterfactual:   e.IsCounter,
+		Action:             e.Action,
+		Outcome:            e.Outcome,
+		ResourceId:         e.ResourceID,
+		CreatedAtMillisUtc: e.CreatedAtMillisUTC,
+	}
+}
+
+// BeliefEvidenceToProto converts the evidence of a belief, keeping its order.
+func BeliefEvidenceToProto(evidence []BeliefEvidence) []*pbmodels.BeliefEvidence {
+	result := make([]*pbmodels.BeliefEvidence, len(evidence))
+	for i, e := range evidence {
+		result[i] = e.ToProto()
+	}
+	return result
+}
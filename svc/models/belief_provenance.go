@@ -0,0 +1,38 @@
+package models
+
+import (
+	pbmodels "epistemic-me-core/pb/models"
+)
+
+const (
+	// BeliefSourceDialectic marks a belief extracted from the answer to a dialectic interaction.
+	BeliefSourceDialectic = "dialectic"
+	// BeliefSourceDirect marks a belief created directly, such as through CreateBelief, rather than
+	// extracted from an answer.
+	BeliefSourceDirect = "direct"
+)
+
+// BeliefProvenance is where a belief came from. For a belief extracted in a dialectic it holds the
+// question and answer that produced it; the dialectic fields are empty when the dialectic has since
+// been deleted.
+type BeliefProvenance struct {
+	BeliefID            string `json:"belief_id"`
+	Source              string `json:"source"`
+	DialecticID         string `json:"dialectic_id,omitempty"`
+	InteractionID       string `json:"interaction_id,omitempty"`
+	Question            string `json:"question,omitempty"`
+	Answer              string `json:"answer,omitempty"`
+	AnsweredAtMillisUTC int64  `json:"answered_at_millis_utc,omitempty"`
+}
+
+func (p *BeliefProvenance) ToProto() *pbmodels.BeliefProvenance {
+	return &pbmodels.BeliefProvenance{
+		BeliefId:            p.BeliefID,
+		Source:              p.Source,
+		DialecticId:         p.DialecticID,
+		InteractionId:       p.InteractionID,
+		Question:            p.Question,
+		Answer:              p.Answer,
+		AnsweredAtMillisUtc: p.AnsweredAtMillisUTC,
+	}
+}
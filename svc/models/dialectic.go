@@ -45,6 +45,8 @@ const (
 	StatusInvalid       DialecticalInteractionStatus = 0
 	StatusPendingAnswer DialecticalInteractionStatus = 1
 	StatusAnswered      DialecticalInteractionStatus = 2
+	// StatusProcessing marks an answer whose beliefs are still being extracted in the background
+	StatusProcessing DialecticalInteractionStatus = 3
 )
 
 // DialecticType represents the type of dialectic strategy.
@@ -205,6 +207,70 @@ type DialecticalInteraction struct {
 	Interaction        *InteractionData             `json:"interaction,omitempty"`
 	UpdatedAtMillisUTC int64                        `json:"updatedAtMillisUtc"`
 	Perspectives       []Perspective                `json:"perspectives"`
+	// Prediction compares the answer predicted for the question with the actual answer. Its
+	// discrepancy's KL divergence is the question's surprise score; it is nil unless requested.
+	Prediction *Prediction `json:"prediction,omitempty"`
+	// ProcessingError is why the beliefs of an answer processed in the background could not be
+	// extracted
+	ProcessingError string `json:"processingError,omitempty"`
+	// Annotations are curators' notes on the interaction, oldest first. They play no part in
+	// belief extraction.
+	Annotations []InteractionAnnotation `json:"annotations,omitempty"`
+	// EvalScores are the latest scores of external evals of the interaction, one per metric
+	EvalScores []EvalScore `json:"evalScores,omitempty"`
+}
+
+// EvalScore is the result of an eval metric run outside the server, such as a DeepEval metric.
+type EvalScore struct {
+	Metric              string  `json:"metric"`
+	Score               float64 `json:"score"`
+	Passed              bool    `json:"passed"`
+	IngestedAtMillisUTC int64   `json:"ingestedAtMillisUtc"`
+}
+
+func (s EvalScore) ToProto() *pbmodels.EvalScore {
+	return &pbmodels.EvalScore{
+		Metric:              s.Metric,
+		Score:               s.Score,
+		Passed:              s.Passed,
+		IngestedAtMillisUtc: s.IngestedAtMillisUTC,
+	}
+}
+
+// InteractionAnnotation is a curator's note and quality rating of an interaction.
+type InteractionAnnotation struct {
+	AnnotatorID string `json:"annotatorId"`
+	Note        string `json:"note,omitempty"`
+	// QualityScore rates the interaction from 0 to 1
+	QualityScore       float32 `json:"qualityScore"`
+	CreatedAtMillisUTC int64   `json:"createdAtMillisUtc"`
+}
+
+func (a InteractionAnnotation) ToProto() *pbmodels.InteractionAnnotation {
+	return &pbmodels.InteractionAnnotation{
+		AnnotatorId:        a.AnnotatorID,
+		Note:               a.Note,
+		QualityScore:       a.QualityScore,
+		CreatedAtMillisUtc: a.CreatedAtMillisUTC,
+	}
+}
+
+// InteractionAnnotationsToProto converts annotations to their protobuf form.
+func InteractionAnnotationsToProto(annotations []InteractionAnnotation) []*pbmodels.InteractionAnnotation {
+	result := make([]*pbmodels.InteractionAnnotation, len(annotations))
+	for i, a := range annotations {
+		result[i] = a.ToProto()
+	}
+	return result
+}
+
+// SurpriseScore returns how much the answer diverged from the predicted one, and whether a
+// prediction was made.
+func (di DialecticalInteraction) SurpriseScore() (float32, bool) {
+	if di.Prediction == nil || di.Prediction.Discrepancy == nil {
+		return 0, false
+	}
+	return di.Prediction.Discrepancy.KlDivergence, true
 }
 
 // QuestionAnswerInteraction represents a Q&A interaction for belief extraction
@@ -239,6 +305,15 @@ func (di DialecticalInteraction) ToProto() *pbmodels.DialecticalInteraction {
 		Id:                 di.ID,
 		UpdatedAtMillisUtc: di.UpdatedAtMillisUTC,
 		Perspectives:       perspectiveSliceToProto(di.Perspectives),
+		ProcessingError:    di.ProcessingError,
+		Annotations:        InteractionAnnotationsToProto(di.Annotations),
+	}
+	for _, score := range di.EvalScores {
+		proto.EvalScores = append(proto.EvalScores, score.ToProto())
+	}
+	if surprise, ok := di.SurpriseScore(); ok {
+		proto.SurpriseScore = surprise
+		proto.Discrepancy = di.Prediction.Discrepancy.ToProto()
 	}
 
 	if di.Interaction != nil {
@@ -254,6 +329,42 @@ func (di DialecticalInteraction) ToProto() *pbmodels.DialecticalInteraction {
 	return proto
 }
 
+// InteractionSummary describes an interaction without its extracted beliefs or perspectives.
+type InteractionSummary struct {
+	ID                 string                       `json:"id"`
+	Status             DialecticalInteractionStatus `json:"status"`
+	Type               InteractionType              `json:"type"`
+	Question           string                       `json:"question,omitempty"`
+	Answer             string                       `json:"answer,omitempty"`
+	UpdatedAtMillisUTC int64                        `json:"updatedAtMillisUtc"`
+}
+
+// Summary returns the interaction's identity, status and question and answer text.
+func (di DialecticalInteraction) Summary() InteractionSummary {
+	summary := InteractionSummary{
+		ID:                 di.ID,
+		Status:             di.Status,
+		Type:               di.Type,
+		UpdatedAtMillisUTC: di.UpdatedAtMillisUTC,
+	}
+	if di.Interaction != nil && di.Interaction.QuestionAnswer != nil {
+		summary.Question = di.Interaction.QuestionAnswer.Question.Question
+		summary.Answer = di.Interaction.QuestionAnswer.Answer.UserAnswer
+	}
+	return summary
+}
+
+func (is InteractionSummary) ToProto() *pbmodels.InteractionSummary {
+	return &pbmodels.InteractionSummary{
+		Id:                 is.ID,
+		Status:             pbmodels.STATUS(is.Status),
+		Type:               is.Type.ToProto(),
+		Question:           is.Question,
+		Answer:             is.Answer,
+		UpdatedAtMillisUtc: is.UpdatedAtMillisUTC,
+	}
+}
+
 // Add ToProto methods for each interaction type
 func (qa *QuestionAnswerInteraction) ToProto() *pbmodels.QuestionAnswerInteraction {
 	if qa == nil {
@@ -320,6 +431,13 @@ type BeliefAnalysis struct {
 	VerifiedBeliefs []string `json:"verified_beliefs"`
 }
 
+// BeliefAnalysisRecord caches the last analysis of a belief system with one strategy, along with
+// the hash of the belief system it analyzed.
+type BeliefAnalysisRecord struct {
+	BeliefSystemHash string         `json:"belief_system_hash"`
+	Analysis         BeliefAnalysis `json:"analysis"`
+}
+
 func (ba BeliefAnalysis) ToProto() *pbmodels.BeliefAnalysis {
 	return &pbmodels.BeliefAnalysis{
 		Coherence:       ba.Coherence,
@@ -332,12 +450,49 @@ func (ba BeliefAnalysis) ToProto() *pbmodels.BeliefAnalysis {
 	}
 }
 
+const (
+	// DefaultCompletionThreshold is the completion percentage at which a learning objective is considered met
+	DefaultCompletionThreshold float32 = 95
+	// DefaultMaxQuestions caps the number of questions asked for a learning objective
+	DefaultMaxQuestions int32 = 20
+)
+
+// TopicStrategy decides which topic of a learning objective each question targets.
+type TopicStrategy int32
+
+const (
+	// TopicStrategyFocusLowest targets the topic with the lowest coverage, going deep on one topic
+	// until another falls behind it
+	TopicStrategyFocusLowest TopicStrategy = iota
+	// TopicStrategyRoundRobin targets the topics in turn, so every topic is asked about early
+	TopicStrategyRoundRobin
+)
+
 // LearningObjective represents what we want to learn about the user
 type LearningObjective struct {
-	Description          string     // Natural language description of what to learn
-	Topics               []string   // Key topics to explore (e.g., "sleep", "diet", "exercise")
-	TargetBeliefType     BeliefType // Type of beliefs to collect
-	CompletionPercentage float32    // Percentage of completion (0-100)
+	Description          string        // Natural language description of what to learn
+	Topics               []string      // Key topics to explore (e.g., "sleep", "diet", "exercise")
+	TargetBeliefType     BeliefType    // Type of beliefs to collect
+	CompletionPercentage float32       // Percentage of completion (0-100)
+	CompletionThreshold  float32       // Percentage of completion at which to stop asking questions (defaults to 95)
+	MaxQuestions         int32         // Maximum number of questions to ask (defaults to 20)
+	TopicStrategy        TopicStrategy // How the topic of each question is chosen (defaults to focusing on the least covered topic)
+}
+
+// GetCompletionThreshold returns the completion threshold, falling back to DefaultCompletionThreshold when unset
+func (lo *LearningObjective) GetCompletionThreshold() float32 {
+	if lo.CompletionThreshold <= 0 {
+		return DefaultCompletionThreshold
+	}
+	return lo.CompletionThreshold
+}
+
+// GetMaxQuestions returns the question cap, falling back to DefaultMaxQuestions when unset
+func (lo *LearningObjective) GetMaxQuestions() int32 {
+	if lo.MaxQuestions <= 0 {
+		return DefaultMaxQuestions
+	}
+	return lo.MaxQuestions
 }
 
 func (lo *LearningObjective) ToProto() *pbmodels.LearningObjective {
@@ -349,6 +504,9 @@ func (lo *LearningObjective) ToProto() *pbmodels.LearningObjective {
 		Topics:               lo.Topics,
 		TargetBeliefType:     pbmodels.BeliefType(lo.TargetBeliefType),
 		CompletionPercentage: lo.CompletionPercentage,
+		CompletionThreshold:  lo.CompletionThreshold,
+		MaxQuestions:         lo.MaxQuestions,
+		TopicStrategy:        pbmodels.TopicStrategy(lo.TopicStrategy),
 	}
 }
 
@@ -361,6 +519,53 @@ func LearningObjectiveFromProto(lo *pbmodels.LearningObjective) *LearningObjecti
 		Topics:               lo.Topics,
 		TargetBeliefType:     BeliefType(lo.TargetBeliefType),
 		CompletionPercentage: lo.CompletionPercentage,
+		CompletionThreshold:  lo.CompletionThreshold,
+		MaxQuestions:         lo.MaxQuestions,
+		TopicStrategy:        TopicStrategy(lo.TopicStrategy),
+	}
+}
+
+// LearningObjectiveProgress is the analysis of how well a belief system covers a learning objective.
+type LearningObjectiveProgress struct {
+	CompletionPercentage float32                   `json:"completion_percentage"`
+	TopicCoverage        map[string]*TopicCoverage `json:"topic_coverage"`
+	Explanation          string                    `json:"explanation"`
+	UpdatedAtMillisUTC   int64                     `json:"updated_at_millis_utc"`
+}
+
+// TopicCoverage describes which belief categories have been collected for a single topic.
+type TopicCoverage struct {
+	Percentage        float32            `json:"percentage"`
+	CoveredCategories []string           `json:"covered_categories"`
+	MissingCategories []string           `json:"missing_categories"`
+	BeliefQuality     map[string]float32 `json:"belief_quality"`
+}
+
+func (p *LearningObjectiveProgress) ToProto() *pbmodels.LearningObjectiveProgress {
+	if p == nil {
+		return nil
+	}
+	proto := &pbmodels.LearningObjectiveProgress{
+		CompletionPercentage: p.CompletionPercentage,
+		TopicCoverage:        make(map[string]*pbmodels.TopicCoverage, len(p.TopicCoverage)),
+		Explanation:          p.Explanation,
+		UpdatedAtMillisUtc:   p.UpdatedAtMillisUTC,
+	}
+	for topic, coverage := range p.TopicCoverage {
+		proto.TopicCoverage[topic] = coverage.ToProto()
+	}
+	return proto
+}
+
+func (tc *TopicCoverage) ToProto() *pbmodels.TopicCoverage {
+	if tc == nil {
+		return nil
+	}
+	return &pbmodels.TopicCoverage{
+		Percentage:        tc.Percentage,
+		CoveredCategories: tc.CoveredCategories,
+		MissingCategories: tc.MissingCategories,
+		BeliefQuality:     tc.BeliefQuality,
 	}
 }
 
@@ -373,6 +578,11 @@ type Dialectic struct {
 	Analysis            *BeliefAnalysis          `json:"analysis,omitempty"`
 	PerspectiveModelIDs []string                 `json:"perspective_model_ids,omitempty"`
 	LearningObjective   *LearningObjective       `json:"learning_objective,omitempty"`
+	// LearningObjectiveProgress caches the last computed progress towards LearningObjective
+	LearningObjectiveProgress *LearningObjectiveProgress `json:"learning_objective_progress,omitempty"`
+	// Version is incremented on every update and used as the store version,
+	// so concurrent updates of the same dialectic conflict instead of clobbering each other.
+	Version int32 `json:"version"`
 }
 
 func (d *Dialectic) MarshalBinary() ([]byte, error) {
@@ -480,6 +690,9 @@ func BeliefFromProto(proto *pbmodels.Belief) *Belief {
 		Version:     proto.Version,
 		Type:        BeliefType(proto.Type),
 		Content:     ContentFromProto(proto.Content),
+
+		SourceInteractionID: proto.SourceInteractionId,
+		Tags:                proto.Tags,
 	}
 }
 
@@ -516,6 +729,29 @@ func ContentFromProto(protoContent []*pbmodels.Content) []Content {
 	return content
 }
 
+// DialecticBaseline is the belief system of a self model as it was when a dialectic was created.
+// It is written once and never updated, so later belief system changes can be diffed against it.
+type DialecticBaseline struct {
+	DialecticID  string        `json:"dialectic_id"`
+	SelfModelID  string        `json:"self_model_id"`
+	BeliefSystem *BeliefSystem `json:"belief_system,omitempty"`
+	// Empty marks a self model that had no belief system yet when the dialectic was created
+	Empty              bool  `json:"empty"`
+	CreatedAtMillisUTC int64 `json:"created_at_millis_utc"`
+}
+
+// DialecticSummary condenses the interactions of a dialectic that have aged out of the question
+// history window. It is rolled forward as more of them age out, so each is summarized once.
+type DialecticSummary struct {
+	DialecticID      string   `json:"dialectic_id"`
+	SelfModelID      string   `json:"self_model_id"`
+	Summary          string   `json:"summary"`
+	ExtractedBeliefs []string `json:"extracted_beliefs,omitempty"`
+	// Interactions is how many of the dialectic's asked questions, from the first, the summary covers
+	Interactions       int   `json:"interactions"`
+	UpdatedAtMillisUTC int64 `json:"updated_at_millis_utc"`
+}
+
 // Perspective represents a viewpoint or interpretation
 type Perspective struct {
 	Response    string `json:"response"`
@@ -552,4 +788,7 @@ type PreprocessQuestionAnswerOutput struct {
 type QuestionAnswerPair struct {
 	Question string
 	Answer   string
+	// Confidence is how sure the AI is that the matched answer addresses the question, from 0 to 1.
+	// An answer matched with too little confidence is left out, but its confidence is still given.
+	Confidence float32
 }
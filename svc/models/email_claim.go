@@ -0,0 +1,8 @@
+package models
+
+// EmailClaim reserves an email address for the developer or user that registered with it. Claims
+// are stored under the address so a second registration with it is rejected.
+type EmailClaim struct {
+	Email   string `json:"email"`
+	OwnerID string `json:"owner_id"`
+}
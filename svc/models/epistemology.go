@@ -30,6 +30,11 @@ type DialecticResponse struct {
 type DialecticEvent struct {
 	SelfModelID          string
 	PreviousInteractions []DialecticalInteraction
+	// DialecticID names the dialectic whose interaction summary is read and rolled forward. Without
+	// it, interactions that aged out of the question history window are summarized afresh.
+	DialecticID string
+	// KeepSummary leaves the stored interaction summary as it was, even if more interactions aged out
+	KeepSummary bool
 }
 
 type PerspectiveTakingEpistemicEvent struct {
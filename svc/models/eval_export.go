@@ -0,0 +1,36 @@
+package models
+
+// DialecticEvalExport is a dialectic in the JSON shape of a DeepEval dataset, so it can be loaded
+// with EvaluationDataset.add_test_cases_from_json_file using DeepEval's default key names. Each
+// answered interaction is one test case.
+type DialecticEvalExport struct {
+	DialecticID string         `json:"dialectic_id"`
+	SelfModelID string         `json:"self_model_id"`
+	TestCases   []EvalTestCase `json:"test_cases"`
+}
+
+// EvalTestCase is one question and answer of a dialectic as a DeepEval LLMTestCase. There is no
+// expected output, as a dialectic has no reference answers.
+type EvalTestCase struct {
+	// Name is the interaction's ID
+	Name string `json:"name"`
+	// Input is the question the dialectic asked
+	Input string `json:"input"`
+	// ActualOutput is the user's answer
+	ActualOutput string `json:"actual_output"`
+	// Context holds the beliefs extracted from the answer
+	Context []string `json:"context"`
+	// AdditionalMetadata identifies the dialectic and interaction the case came from
+	AdditionalMetadata map[string]string `json:"additional_metadata"`
+}
+
+// ExportDialecticForEvalInput identifies the dialectic to export as an eval dataset.
+type ExportDialecticForEvalInput struct {
+	DialecticID string `json:"dialectic_id"`
+	SelfModelID string `json:"self_model_id"`
+}
+
+// ExportDialecticForEvalOutput represents a dialectic exported as an eval dataset.
+type ExportDialecticForEvalOutput struct {
+	Export DialecticEvalExport `json:"export"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// IdempotencyRecord maps an idempotency key a client sent with a creation request to the record
+// the request created, so a retry with the same key returns it instead of creating another.
+type IdempotencyRecord struct {
+	Key                string `json:"key"`
+	Operation          string `json:"operation"`
+	SelfModelID        string `json:"self_model_id"`
+	ResourceID         string `json:"resource_id"`
+	CreatedAtMillisUTC int64  `json:"created_at_millis_utc"`
+}
@@ -5,6 +5,12 @@ type ListBeliefsInput struct {
 	SelfModelID     string   `json:"self_model_id"`
 	BeliefIDs       []string `json:"belief_ids,omitempty"`
 	GetBeliefSystem bool     `json:"compute_belief_system"`
+	// Cohort, when set, restricts the list to self models in the cohort. Without a SelfModelID the
+	// beliefs of every self model in the cohort belonging to one of DeveloperID's users are listed.
+	Cohort      string `json:"cohort,omitempty"`
+	DeveloperID string `json:"developer_id,omitempty"`
+	// Tags, when set, restricts the list to beliefs carrying every one of the tags.
+	Tags []string `json:"tags,omitempty"`
 }
 
 // CreateBeliefInput represents an input to create a new belief.
@@ -14,6 +20,24 @@ type CreateBeliefInput struct {
 	BeliefType     BeliefType      `json:"belief_type"`
 	DryRun         bool            `json:"dry_run"`
 	BeliefEvidence *BeliefEvidence `json:"evidence,omitempty"`
+	// IdempotencyKey, when set, makes retries return the belief the first request created. Keys are
+	// scoped to RequesterID, the developer sending the request.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	RequesterID    string `json:"requester_id,omitempty"`
+}
+
+// BatchCreateBeliefsInput represents an input to create several beliefs at once. Mode decides
+// whether an invalid belief fails the batch or is skipped.
+type BatchCreateBeliefsInput struct {
+	SelfModelID string             `json:"self_model_id"`
+	Beliefs     []BatchBeliefInput `json:"beliefs"`
+	Mode        BatchMode          `json:"mode"`
+}
+
+// BatchBeliefInput is one belief of a BatchCreateBeliefsInput.
+type BatchBeliefInput struct {
+	BeliefContent string     `json:"belief_content"`
+	BeliefType    BeliefType `json:"belief_type"`
 }
 
 // BeliefEvidence represents evidence for a belief
@@ -24,6 +48,9 @@ type BeliefEvidence struct {
 	Action            string       `json:"action,omitempty"`
 	Outcome           string       `json:"outcome,omitempty"`
 	PerspectiveSelves []string     `json:"perspective_selves"`
+	// ResourceID is the cited resource of resource evidence
+	ResourceID         string `json:"resource_id,omitempty"`
+	CreatedAtMillisUTC int64  `json:"created_at_millis_utc"`
 }
 
 type EvidenceType int
@@ -31,8 +58,58 @@ type EvidenceType int
 const (
 	EvidenceTypeHypothesis EvidenceType = iota
 	EvidenceTypeAction
+	// EvidenceTypeResource cites a stored resource, such as a scientific paper
+	EvidenceTypeResource
 )
 
+// AnalyzeBeliefSystemInput identifies the self model whose belief system is analyzed, and the
+// dialectic type whose strategy the analysis follows.
+type AnalyzeBeliefSystemInput struct {
+	SelfModelID   string        `json:"self_model_id"`
+	DialecticType DialecticType `json:"dialectic_type"`
+}
+
+// GetEpistemicContextInput identifies the self model whose epistemic contexts are returned.
+type GetEpistemicContextInput struct {
+	SelfModelID string `json:"self_model_id"`
+}
+
+// ListObservationContextsInput represents an input to list the observation contexts of a self
+// model's belief system.
+type ListObservationContextsInput struct {
+	SelfModelID string `json:"self_model_id"`
+	RootsOnly   bool   `json:"roots_only"`
+	AsTree      bool   `json:"as_tree"`
+}
+
+// CreateObservationContextInput represents an input to add an observation context to a self
+// model's belief system. An ID is generated when none is given.
+type CreateObservationContextInput struct {
+	SelfModelID    string   `json:"self_model_id"`
+	ID             string   `json:"id,omitempty"`
+	Name           string   `json:"name"`
+	ParentID       string   `json:"parent_id,omitempty"`
+	PossibleStates []string `json:"possible_states,omitempty"`
+}
+
+// UpdateObservationContextInput represents an input to edit an observation context. Empty fields
+// are left unchanged; ParentID is only applied when set.
+type UpdateObservationContextInput struct {
+	SelfModelID    string   `json:"self_model_id"`
+	ID             string   `json:"id"`
+	Name           string   `json:"name,omitempty"`
+	ParentID       *string  `json:"parent_id,omitempty"`
+	PossibleStates []string `json:"possible_states,omitempty"`
+}
+
+// DeleteObservationContextInput represents an input to delete an observation context.
+type DeleteObservationContextInput struct {
+	SelfModelID      string `json:"self_model_id"`
+	ID               string `json:"id"`
+	ReparentChildren bool   `json:"reparent_children"`
+	Force            bool   `json:"force"`
+}
+
 // UpdateBeliefInput represents an input to update an existing belief
 type UpdateBeliefInput struct {
 	SelfModelID          string     `json:"self_model_id"`
@@ -43,6 +120,87 @@ type UpdateBeliefInput struct {
 	DryRun               bool       `json:"dry_run"`
 }
 
+// MergeBeliefsInput represents an input to consolidate several beliefs into one.
+type MergeBeliefsInput struct {
+	SelfModelID string   `json:"self_model_id"`
+	BeliefIDs   []string `json:"belief_ids"`
+}
+
+// GetBeliefInput represents an input to get a single belief.
+type GetBeliefInput struct {
+	SelfModelID string `json:"self_model_id"`
+	BeliefID    string `json:"belief_id"`
+}
+
+// CreateResourceInput represents an input to store an external resource, such as a scientific
+// paper, that beliefs can cite as evidence.
+type CreateResourceInput struct {
+	SelfModelID string            `json:"self_model_id"`
+	Type        ResourceType      `json:"type"`
+	Content     string            `json:"content"`
+	SourceID    string            `json:"source_id,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	// BeliefID, when set, cites the new resource as supporting evidence for that belief
+	BeliefID string `json:"belief_id,omitempty"`
+}
+
+// BootstrapSelfModelInput represents an input to build a self model's belief system from documents.
+type BootstrapSelfModelInput struct {
+	SelfModelID string     `json:"self_model_id"`
+	Resources   []Resource `json:"resources"`
+}
+
+// AddEvidenceInput represents an input to add evidence to a belief: either a stored resource to
+// cite, or a hypothesis or action outcome.
+type AddEvidenceInput struct {
+	SelfModelID string          `json:"self_model_id"`
+	BeliefID    string          `json:"belief_id"`
+	ResourceID  string          `json:"resource_id,omitempty"`
+	Evidence    *BeliefEvidence `json:"evidence,omitempty"`
+}
+
+// BeliefTagsInput represents an input to add tags to, or remove tags from, a belief.
+type BeliefTagsInput struct {
+	SelfModelID string   `json:"self_model_id"`
+	BeliefID    string   `json:"belief_id"`
+	Tags        []string `json:"tags"`
+}
+
+// ListBeliefEvidenceInput identifies the belief whose evidence is listed.
+type ListBeliefEvidenceInput struct {
+	SelfModelID string `json:"self_model_id"`
+	BeliefID    string `json:"belief_id"`
+}
+
+// GetBeliefHistoryInput represents an input to get the version history of a belief.
+type GetBeliefHistoryInput struct {
+	SelfModelID string `json:"self_model_id"`
+	BeliefID    string `json:"belief_id"`
+}
+
+// GetBeliefProvenanceInput represents an input to find where a belief came from.
+type GetBeliefProvenanceInput struct {
+	SelfModelID string `json:"self_model_id"`
+	BeliefID    string `json:"belief_id"`
+}
+
+// SaveBeliefSystemSnapshotInput represents an input to save a named snapshot of a belief system.
+type SaveBeliefSystemSnapshotInput struct {
+	SelfModelID string `json:"self_model_id"`
+	Name        string `json:"name"`
+}
+
+// RestoreBeliefSystemSnapshotInput represents an input to restore a belief system from a snapshot.
+type RestoreBeliefSystemSnapshotInput struct {
+	SelfModelID string `json:"self_model_id"`
+	Name        string `json:"name"`
+}
+
+// ListBeliefSystemSnapshotsInput represents an input to list the snapshots of a belief system.
+type ListBeliefSystemSnapshotsInput struct {
+	SelfModelID string `json:"self_model_id"`
+}
+
 // UpdateBeliefInput represents an input to update an existing belief
 type DeleteBeliefInput struct {
 	SelfModelID         string `json:"self_model_id"`
@@ -57,11 +215,19 @@ type CreateDialecticInput struct {
 	DialecticType       DialecticType      `json:"dialectic_type"`
 	PerspectiveModelIDs []string           `json:"perspective_model_ids,omitempty"`
 	LearningObjective   *LearningObjective `json:"learning_objective,omitempty"`
+	// IdempotencyKey, when set, makes retries return the dialectic the first request created. Keys
+	// are scoped to RequesterID, the developer sending the request.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	RequesterID    string `json:"requester_id,omitempty"`
 }
 
 // ListDialecticsInput represents an input to list dialectics.
 type ListDialecticsInput struct {
 	SelfModelID string `json:"self_model_id"`
+	// Cohort, when set, restricts the list to self models in the cohort. Without a SelfModelID the
+	// dialectics of every self model in the cohort belonging to one of DeveloperID's users are listed.
+	Cohort      string `json:"cohort,omitempty"`
+	DeveloperID string `json:"developer_id,omitempty"`
 }
 
 // UpdateDialecticInput represents an input to update an existing dialectic.
@@ -73,6 +239,147 @@ type UpdateDialecticInput struct {
 	CustomQuestion *string    `json:"custom_question,omitempty"`
 	QuestionBlob   string
 	AnswerBlob     string
+	// PruneInvalidatedBeliefs asks the AI which existing beliefs are invalidated by the
+	// newly extracted ones and removes them from the belief system
+	PruneInvalidatedBeliefs bool `json:"prune_invalidated_beliefs"`
+	// StrictPerspectives fails the update when any perspective model fails to respond,
+	// instead of reporting the failure in UpdateDialecticOutput.PerspectiveErrors
+	StrictPerspectives bool `json:"strict_perspectives"`
+	// ComputeSurprise predicts an answer to the question before comparing it with the user's, and
+	// stores the surprise on the interaction. It costs two extra AI calls.
+	ComputeSurprise bool `json:"compute_surprise"`
+	// Background stores the answer and returns the next question without waiting for the answer's
+	// beliefs to be extracted. The answered interaction is marked StatusProcessing until the
+	// belief system has been updated, which GetInteractionStatus reports. Ignored for dry runs.
+	// The answer is turned away, with nothing stored, while too many of its self model's answers
+	// are waiting to be processed.
+	Background bool `json:"background"`
+}
+
+// DeleteDialecticInput represents an input to delete a dialectic.
+type DeleteDialecticInput struct {
+	ID          string `json:"dialectic_id"`
+	SelfModelID string `json:"self_model_id"`
+}
+
+// GetDialecticBaselineInput represents an input to get the belief system snapshot taken when a dialectic was created.
+type GetDialecticBaselineInput struct {
+	DialecticID string `json:"dialectic_id"`
+	SelfModelID string `json:"self_model_id"`
+}
+
+// EditAnswerInput represents an input to replace the answer of an answered interaction.
+type EditAnswerInput struct {
+	DialecticID   string `json:"dialectic_id"`
+	SelfModelID   string `json:"self_model_id"`
+	InteractionID string `json:"interaction_id"`
+	Answer        string `json:"answer"`
+}
+
+// ReprocessDialecticInput represents an input to extract a dialectic's beliefs again. A dry run
+// reports the changes without storing them.
+type ReprocessDialecticInput struct {
+	DialecticID string `json:"dialectic_id"`
+	SelfModelID string `json:"self_model_id"`
+	DryRun      bool   `json:"dry_run"`
+}
+
+// RunSyntheticDialecticInput represents an input to run a learning objective dialectic answered by
+// the self model itself. A MaxTurns of zero falls back to the objective's question cap.
+type RunSyntheticDialecticInput struct {
+	SelfModelID       string             `json:"self_model_id"`
+	DialecticType     DialecticType      `json:"dialectic_type"`
+	LearningObjective *LearningObjective `json:"learning_objective"`
+	MaxTurns          int32              `json:"max_turns"`
+}
+
+// AnnotateInteractionInput represents an input to add a curator's annotation to an interaction.
+type AnnotateInteractionInput struct {
+	DialecticID   string  `json:"dialectic_id"`
+	SelfModelID   string  `json:"self_model_id"`
+	InteractionID string  `json:"interaction_id"`
+	AnnotatorID   string  `json:"annotator_id"`
+	Note          string  `json:"note,omitempty"`
+	QualityScore  float32 `json:"quality_score"`
+}
+
+// IngestEvalScoresInput represents an input to attach the scores of external evals to the
+// interactions of a dialectic.
+type IngestEvalScoresInput struct {
+	DialecticID string                 `json:"dialectic_id"`
+	SelfModelID string                 `json:"self_model_id"`
+	Scores      []InteractionEvalScore `json:"scores"`
+}
+
+// InteractionEvalScore is an eval metric's result for one interaction.
+type InteractionEvalScore struct {
+	InteractionID string  `json:"interaction_id"`
+	Metric        string  `json:"metric"`
+	Score         float64 `json:"score"`
+	Passed        bool    `json:"passed"`
+}
+
+// GetDialecticInput identifies the dialectic to get.
+type GetDialecticInput struct {
+	ID          string `json:"dialectic_id"`
+	SelfModelID string `json:"self_model_id"`
+}
+
+// ListInteractionAnnotationsInput identifies the interaction whose annotations are listed.
+type ListInteractionAnnotationsInput struct {
+	DialecticID   string `json:"dialectic_id"`
+	SelfModelID   string `json:"self_model_id"`
+	InteractionID string `json:"interaction_id"`
+}
+
+// GetInteractionStatusInput identifies an interaction whose processing status is polled.
+type GetInteractionStatusInput struct {
+	DialecticID   string `json:"dialectic_id"`
+	SelfModelID   string `json:"self_model_id"`
+	InteractionID string `json:"interaction_id"`
+}
+
+// ListInteractionsInput represents an input to list a dialectic's interactions. A zero Status or
+// Type matches every interaction.
+type ListInteractionsInput struct {
+	DialecticID string                       `json:"dialectic_id"`
+	SelfModelID string                       `json:"self_model_id"`
+	Status      DialecticalInteractionStatus `json:"status"`
+	Type        InteractionType              `json:"type"`
+}
+
+// BulkAnswerInput holds answers to several of a dialectic's pending questions, in no particular
+// order, such as the user's turns of an imported conversation.
+type BulkAnswerInput struct {
+	DialecticID string    `json:"dialectic_id"`
+	SelfModelID string    `json:"self_model_id"`
+	Answers     []string  `json:"answers"`
+	Mode        BatchMode `json:"mode"`
+}
+
+// PreviewNextQuestionInput identifies the dialectic whose next question is previewed.
+type PreviewNextQuestionInput struct {
+	DialecticID string `json:"dialectic_id"`
+	SelfModelID string `json:"self_model_id"`
+}
+
+// GetLearningObjectiveProgressInput represents an input to get the progress of a dialectic's learning objective.
+type GetLearningObjectiveProgressInput struct {
+	DialecticID string `json:"dialectic_id"`
+	SelfModelID string `json:"self_model_id"`
+}
+
+// ComputeDialecticMetricsInput represents an input to compute the personalization metrics of a dialectic.
+type ComputeDialecticMetricsInput struct {
+	DialecticID string `json:"dialectic_id"`
+	SelfModelID string `json:"self_model_id"`
+}
+
+// GetPerspectivesInput represents an input to ask several self models for their perspective on a question and answer.
+type GetPerspectivesInput struct {
+	SelfModelIDs []string `json:"self_model_ids"`
+	Question     string   `json:"question"`
+	Answer       string   `json:"answer"`
 }
 
 // GetBeliefSystemInput represents an input to get belief system details.
@@ -88,8 +395,43 @@ type CreateDeveloperInput struct {
 type GetDeveloperInput struct {
 	ID string
 }
+
+// RegisterWebhookInput sets the URL notified of belief system changes for a developer's users.
+// Registering again replaces the URL and issues a new secret.
+type RegisterWebhookInput struct {
+	DeveloperID string `json:"developer_id"`
+	URL         string `json:"url"`
+}
+
+// RotateAPIKeyInput requests a new API key for a developer. The developer's current keys stay
+// valid for the rotation grace window.
+type RotateAPIKeyInput struct {
+	DeveloperID string `json:"developer_id"`
+}
+
+// RevokeAPIKeyInput invalidates one of a developer's API keys immediately.
+type RevokeAPIKeyInput struct {
+	DeveloperID string `json:"developer_id"`
+	APIKey      string `json:"api_key"`
+}
+
+// SetOpenAIKeyInput stores the OpenAI key a developer's AI calls are made with. An empty key
+// removes it, so the server's key is used again.
+type SetOpenAIKeyInput struct {
+	DeveloperID  string `json:"developer_id"`
+	OpenAIAPIKey string `json:"openai_api_key"`
+}
+
 type CreateUserInput struct {
 	DeveloperID string `json:"developer_id"`
 	Name        string `json:"name"`  // Can be empty
 	Email       string `json:"email"` // Can be empty
 }
+
+// ListUsersInput represents a page of the users a developer created. A zero PageSize uses the
+// default page size; an empty PageToken starts at the first page.
+type ListUsersInput struct {
+	DeveloperID string `json:"developer_id"`
+	PageSize    int    `json:"page_size"`
+	PageToken   string `json:"page_token"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "errors"
+import (
+	pbmodels "epistemic-me-core/pb/models"
+	"errors"
+)
 
 type Metric struct {
 	Label       string
@@ -41,3 +44,47 @@ func Average(metrics []Metric) (Metric, error) {
 
 	return averageMetric, nil
 }
+
+// DialecticMetrics are the personalization metrics of a dialectic, computed over its answered
+// question and answer interactions.
+type DialecticMetrics struct {
+	DialecticID          string `json:"dialectic_id"`
+	QuestionCount        int32  `json:"question_count"`
+	AnsweredCount        int32  `json:"answered_count"`
+	ExtractedBeliefCount int32  `json:"extracted_belief_count"`
+	// BeliefYieldPerQuestion is the number of beliefs extracted per answered question
+	BeliefYieldPerQuestion float32 `json:"belief_yield_per_question"`
+	// TopicCoverageProgression holds, after each answered question, the share (0-1) of the learning
+	// objective's topics the conversation has touched. It is empty without a learning objective.
+	TopicCoverageProgression []float32 `json:"topic_coverage_progression"`
+	// CompletionPercentage is the learning objective's last computed progress (0-100)
+	CompletionPercentage float32 `json:"completion_percentage"`
+	RelevantAnswerCount  int32   `json:"relevant_answer_count"`
+	// AnswerRelevance is the share (0-1) of answers that answer their question
+	AnswerRelevance float32 `json:"answer_relevance"`
+	// AverageBeliefConfidence averages the latest confidence rating of the extracted beliefs that
+	// still have a belief context. RatedBeliefCount is how many did.
+	RatedBeliefCount        int32   `json:"rated_belief_count"`
+	AverageBeliefConfidence float32 `json:"average_belief_confidence"`
+	ComputedAtMillisUTC     int64   `json:"computed_at_millis_utc"`
+}
+
+func (m *DialecticMetrics) ToProto() *pbmodels.DialecticMetrics {
+	if m == nil {
+		return nil
+	}
+	return &pbmodels.DialecticMetrics{
+		DialecticId:              m.DialecticID,
+		QuestionCount:            m.QuestionCount,
+		AnsweredCount:            m.AnsweredCount,
+		ExtractedBeliefCount:     m.ExtractedBeliefCount,
+		BeliefYieldPerQuestion:   m.BeliefYieldPerQuestion,
+		TopicCoverageProgression: m.TopicCoverageProgression,
+		CompletionPercentage:     m.CompletionPercentage,
+		RelevantAnswerCount:      m.RelevantAnswerCount,
+		AnswerRelevance:          m.AnswerRelevance,
+		RatedBeliefCount:         m.RatedBeliefCount,
+		AverageBeliefConfidence:  m.AverageBeliefConfidence,
+		ComputedAtMillisUtc:      m.ComputedAtMillisUTC,
+	}
+}
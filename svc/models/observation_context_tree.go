@@ -0,0 +1,184 @@
+package models
+
+import (
+	pbmodels "epistemic-me-core/pb/models"
+	"fmt"
+	"strings"
+)
+
+// UnattachedContextsID is the ID of the synthetic root that holds the contexts whose parent is
+// missing and the contexts that break a parent cycle.
+const UnattachedContextsID = "unattached"
+
+// ObservationContextNode is an observation context together with the contexts nested in it.
+type ObservationContextNode struct {
+	Context  *ObservationContext       `json:"context"`
+	Children []*ObservationContextNode `json:"children"`
+}
+
+// ObservationContextTree holds observation contexts nested by their ParentID, and the structural
+// problems found while assembling them.
+type ObservationContextTree struct {
+	Roots    []*ObservationContextNode `json:"roots"`
+	Warnings []string                  `json:"warnings,omitempty"`
+}
+
+// BuildObservationContextTree nests contexts under their parents, keeping the input order among
+// siblings. Contexts without a ParentID are roots. A context whose parent is not in contexts, or
+// that sits on a parent cycle, is attached to a synthetic root with ID UnattachedContextsID and
+// reported in Warnings; a cycle is cut at the first context found on it. Of contexts sharing an
+// ID, the first is kept.
+func BuildObservationContextTree(contexts []*ObservationContext) *ObservationContextTree {
+	tree := &ObservationContextTree{}
+
+	byID := make(map[string]*ObservationContext, len(contexts))
+	var ordered []*ObservationContext
+	for _, oc := range contexts {
+		if oc == nil {
+			continue
+		}
+		if _, ok := byID[oc.ID]; ok {
+			tree.Warnings = append(tree.Warnings, fmt.Sprintf("observation context %q is listed more than once", oc.ID))
+			continue
+		}
+		byID[oc.ID] = oc
+		ordered = append(ordered, oc)
+	}
+
+	children := make(map[string][]*ObservationContext)
+	for _, oc := range ordered {
+		if oc.ParentID != "" {
+			children[oc.ParentID] = append(children[oc.ParentID], oc)
+		}
+	}
+
+	attached := make(map[string]bool, len(ordered))
+	var build func(oc *ObservationContext) *ObservationContextNode
+	build = func(oc *ObservationContext) *ObservationContextNode {
+		attached[oc.ID] = true
+		node := &ObservationContextNode{Context: oc}
+		for _, child := range children[oc.ID] {
+			// A child already attached closes a cycle back to this branch
+			if !attached[child.ID] {
+				node.Children = append(node.Children, build(child))
+			}
+		}
+		return node
+	}
+
+	for _, oc := range ordered {
+		if oc.ParentID == "" {
+			tree.Roots = append(tree.Roots, build(oc))
+		}
+	}
+
+	unattached := &ObservationContextNode{
+		Context: &ObservationContext{ID: UnattachedContextsID, Name: "Unattached contexts"},
+	}
+	for _, oc := range ordered {
+		if _, ok := byID[oc.ParentID]; oc.ParentID != "" && !ok {
+			tree.Warnings = append(tree.Warnings, fmt.Sprintf("observation context %q has unknown parent %q", oc.ID, oc.ParentID))
+			unattached.Children = append(unattached.Children, build(oc))
+		}
+	}
+
+	// Whatever is left hangs off a parent cycle. Following the parents of such a context always
+	// comes back round, and the first context seen twice is on the cycle.
+	for _, oc := range ordered {
+		if attached[oc.ID] {
+			continue
+		}
+		seen := make(map[string]bool)
+		var path []string
+		current := oc
+		for !seen[current.ID] {
+			seen[current.ID] = true
+			path = append(path, current.ID)
+			current = byID[current.ParentID]
+		}
+		cycle := path[indexOf(path, current.ID):]
+		tree.Warnings = append(tree.Warnings, fmt.Sprintf("observation contexts form a parent cycle: %s", strings.Join(append(cycle, current.ID), " -> ")))
+		unattached.Children = append(unattached.Children, build(current))
+	}
+
+	if len(unattached.Children) > 0 {
+		tree.Roots = append(tree.Roots, unattached)
+	}
+	return tree
+}
+
+// DescendantContextIDs returns rootIDs followed by the IDs of every context nested under them,
+// following ParentID breadth first in the order of contexts. Each ID is listed once, so a parent
+// cycle does not loop.
+func DescendantContextIDs(contexts []*ObservationContext, rootIDs []string) []string {
+	children := make(map[string][]string)
+	for _, oc := range contexts {
+		if oc != nil && oc.ParentID != "" {
+			children[oc.ParentID] = append(children[oc.ParentID], oc.ID)
+		}
+	}
+
+	ids := make([]string, 0, len(rootIDs))
+	seen := make(map[string]bool)
+	queue := append([]string{}, rootIDs...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		queue = append(queue, children[id]...)
+	}
+	return ids
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// Depth returns the number of contexts on the longest path from a root to a leaf.
+func (t *ObservationContextTree) Depth() int {
+	var depth func(nodes []*ObservationContextNode) int
+	depth = func(nodes []*ObservationContextNode) int {
+		deepest := 0
+		for _, node := range nodes {
+			if d := 1 + depth(node.Children); d > deepest {
+				deepest = d
+			}
+		}
+		return deepest
+	}
+	return depth(t.Roots)
+}
+
+func (n *ObservationContextNode) ToProto() *pbmodels.ObservationContextNode {
+	children := make([]*pbmodels.ObservationContextNode, len(n.Children))
+	for i, child := range n.Children {
+		children[i] = child.ToProto()
+	}
+	return &pbmodels.ObservationContextNode{
+		Context:  n.Context.ToProto(),
+		Children: children,
+	}
+}
+
+func (t *ObservationContextTree) ToProto() *pbmodels.ObservationContextTree {
+	if t == nil {
+		return nil
+	}
+	roots := make([]*pbmodels.ObservationContextNode, len(t.Roots))
+	for i, root := range t.Roots {
+		roots[i] = root.ToProto()
+	}
+	return &pbmodels.ObservationContextTree{
+		Roots:    roots,
+		Warnings: t.Warnings,
+	}
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	pbmodels "epistemic-me-core/pb/models"
 )
 
@@ -10,22 +12,160 @@ type ListBeliefsOutput struct {
 	BeliefSystem BeliefSystem `json:"belief_system"`
 }
 
+// GetEpistemicContextOutput represents the epistemic contexts of a belief system without its
+// beliefs. BeliefLinks lists, for each belief with a belief context, the observation contexts it is
+// linked to.
+type GetEpistemicContextOutput struct {
+	EpistemicContexts []*EpistemicContext  `json:"epistemic_contexts"`
+	BeliefLinks       []*BeliefContextLink `json:"belief_links"`
+}
+
+// ListObservationContextsOutput represents the observation contexts of a belief system, in the
+// order their epistemic contexts list them. Tree is set when requested.
+type ListObservationContextsOutput struct {
+	ObservationContexts []*ObservationContext   `json:"observation_contexts"`
+	Tree                *ObservationContextTree `json:"tree,omitempty"`
+}
+
+// CreateObservationContextOutput represents an output after creating an observation context.
+type CreateObservationContextOutput struct {
+	ObservationContext ObservationContext `json:"observation_context"`
+}
+
+// UpdateObservationContextOutput represents an output after updating an observation context.
+type UpdateObservationContextOutput struct {
+	ObservationContext ObservationContext `json:"observation_context"`
+}
+
+// DeleteObservationContextOutput represents an output after deleting an observation context,
+// with the IDs of the children that were moved and the number of belief contexts removed.
+type DeleteObservationContextOutput struct {
+	ObservationContext         ObservationContext `json:"observation_context"`
+	ChildIDs                   []string           `json:"child_ids"`
+	RemovedBeliefContextsCount int32              `json:"removed_belief_contexts_count"`
+}
+
 // CreateBeliefOutput represents an output after creating a new belief.
 type CreateBeliefOutput struct {
 	Belief       Belief       `json:"belief"`
 	BeliefSystem BeliefSystem `json:"belief_system"`
 }
 
+// BatchCreateBeliefsOutput represents an output after creating several beliefs, with the outcome
+// of every requested belief.
+type BatchCreateBeliefsOutput struct {
+	Beliefs      []Belief     `json:"beliefs"`
+	BeliefSystem BeliefSystem `json:"belief_system"`
+	Result       BatchResult  `json:"result"`
+}
+
 // UpdateBeliefOutput represents an output after updating a belief.
 type UpdateBeliefOutput struct {
 	Belief       Belief       `json:"belief"`
 	BeliefSystem BeliefSystem `json:"belief_system"`
 }
 
-// UpdateBeliefOutput represents an output after updating a belief.
+// MergeBeliefsOutput represents an output after merging beliefs.
+type MergeBeliefsOutput struct {
+	Belief       Belief       `json:"belief"`
+	BeliefSystem BeliefSystem `json:"belief_system"`
+}
+
+// GetBeliefOutput represents a belief with the belief contexts that refer to it.
+type GetBeliefOutput struct {
+	Belief         Belief           `json:"belief"`
+	BeliefContexts []*BeliefContext `json:"belief_contexts"`
+}
+
+// CreateResourceOutput represents the stored resource, and the belief citing it when one was given.
+type CreateResourceOutput struct {
+	Resource Resource `json:"resource"`
+	Belief   *Belief  `json:"belief,omitempty"`
+}
+
+// AnalyzeBeliefSystemOutput represents the analysis of a belief system. Cached is set when the
+// belief system had not changed since it was last analyzed with the same strategy.
+type AnalyzeBeliefSystemOutput struct {
+	Analysis BeliefAnalysis `json:"analysis"`
+	Cached   bool           `json:"cached"`
+}
+
+// BootstrapSelfModelOutput summarizes a bootstrap: the beliefs created, the observation contexts
+// formed for the documents they came from, and how many extracted beliefs were dropped as
+// duplicates or invalid.
+type BootstrapSelfModelOutput struct {
+	Beliefs             []*Belief             `json:"beliefs"`
+	ObservationContexts []*ObservationContext `json:"observation_contexts"`
+	BeliefsSkipped      int                   `json:"beliefs_skipped"`
+	BeliefSystem        BeliefSystem          `json:"belief_system"`
+}
+
+// BootstrapProgress reports how far a bootstrap has got: DocumentsProcessed of DocumentCount
+// documents have had their beliefs extracted, yielding BeliefsExtracted new beliefs so far.
+type BootstrapProgress struct {
+	DocumentsProcessed int    `json:"documents_processed"`
+	DocumentCount      int    `json:"document_count"`
+	BeliefsExtracted   int    `json:"beliefs_extracted"`
+	Document           string `json:"document"`
+}
+
+func (p BootstrapProgress) ToProto() *pbmodels.BootstrapProgress {
+	return &pbmodels.BootstrapProgress{
+		DocumentsProcessed: int32(p.DocumentsProcessed),
+		DocumentCount:      int32(p.DocumentCount),
+		BeliefsExtracted:   int32(p.BeliefsExtracted),
+		Document:           p.Document,
+	}
+}
+
+// AddEvidenceOutput represents a belief after adding evidence to it, with all of its evidence.
+type AddEvidenceOutput struct {
+	Belief   Belief           `json:"belief"`
+	Evidence []BeliefEvidence `json:"evidence"`
+}
+
+// BeliefTagsOutput represents a belief after its tags were changed.
+type BeliefTagsOutput struct {
+	Belief Belief `json:"belief"`
+}
+
+// ListBeliefEvidenceOutput represents the evidence of a belief, oldest first.
+type ListBeliefEvidenceOutput struct {
+	Evidence []BeliefEvidence `json:"evidence"`
+}
+
+// GetBeliefHistoryOutput represents the current version of a belief and its superseded versions.
+type GetBeliefHistoryOutput struct {
+	Belief  Belief   `json:"belief"`
+	History []Belief `json:"history"`
+}
+
+// GetBeliefProvenanceOutput represents where a belief came from.
+type GetBeliefProvenanceOutput struct {
+	Provenance BeliefProvenance `json:"provenance"`
+}
+
+// SaveBeliefSystemSnapshotOutput represents an output after saving a belief system snapshot.
+type SaveBeliefSystemSnapshotOutput struct {
+	Snapshot *BeliefSystemSnapshotSummary `json:"snapshot"`
+}
+
+// RestoreBeliefSystemSnapshotOutput represents the belief system after restoring a snapshot.
+type RestoreBeliefSystemSnapshotOutput struct {
+	BeliefSystem BeliefSystem `json:"belief_system"`
+}
+
+// ListBeliefSystemSnapshotsOutput represents the saved snapshots of a belief system, oldest first.
+type ListBeliefSystemSnapshotsOutput struct {
+	Snapshots []*BeliefSystemSnapshotSummary `json:"snapshots"`
+}
+
+// DeleteBeliefOutput represents an output after deleting a belief. DialecticIDs lists the
+// dialectics whose extracted beliefs referenced it.
 type DeleteBeliefOutput struct {
 	Belief       Belief       `json:"belief"`
 	BeliefSystem BeliefSystem `json:"belief_system"`
+	DialecticIDs []string     `json:"dialectic_ids,omitempty"`
 }
 
 // CreateDialecticOutput represents an output after creating a new dialectic.
@@ -42,6 +182,110 @@ type ListDialecticsOutput struct {
 // UpdateDialecticOutput represents an output after updating a dialectic.
 type UpdateDialecticOutput struct {
 	Dialectic Dialectic `json:"dialectic"`
+	// PerspectiveErrors maps each perspective model that failed to respond to its failure
+	PerspectiveErrors map[string]string `json:"perspective_errors,omitempty"`
+}
+
+// DeleteDialecticOutput represents an output after deleting a dialectic.
+type DeleteDialecticOutput struct {
+	DialecticID string `json:"dialectic_id"`
+}
+
+// GetDialecticBaselineOutput represents the belief system snapshot taken when a dialectic was created.
+type GetDialecticBaselineOutput struct {
+	Baseline *DialecticBaseline `json:"baseline"`
+}
+
+// EditAnswerOutput represents the dialectic after an answer was edited, with the beliefs the edit
+// removed and added.
+type EditAnswerOutput struct {
+	Dialectic        Dialectic `json:"dialectic"`
+	RemovedBeliefIDs []string  `json:"removed_belief_ids"`
+	ExtractedBeliefs []*Belief `json:"extracted_beliefs"`
+}
+
+// ReprocessDialecticOutput represents the dialectic after its beliefs were extracted again, with the
+// beliefs removed and added, or that would be for a dry run.
+type ReprocessDialecticOutput struct {
+	Dialectic        Dialectic `json:"dialectic"`
+	RemovedBeliefIDs []string  `json:"removed_belief_ids"`
+	ExtractedBeliefs []*Belief `json:"extracted_beliefs"`
+	DryRun           bool      `json:"dry_run"`
+}
+
+// RunSyntheticDialecticOutput represents the conversation of a synthetic dialectic. Completed is
+// false when the run stopped at MaxTurns with a question still pending.
+type RunSyntheticDialecticOutput struct {
+	Dialectic Dialectic `json:"dialectic"`
+	Turns     int       `json:"turns"`
+	Completed bool      `json:"completed"`
+}
+
+// AnnotateInteractionOutput represents the annotation added to an interaction, with all of the
+// interaction's annotations.
+type AnnotateInteractionOutput struct {
+	Annotation  InteractionAnnotation   `json:"annotation"`
+	Annotations []InteractionAnnotation `json:"annotations"`
+}
+
+// IngestEvalScoresOutput represents the dialectic with the ingested eval scores attached.
+type IngestEvalScoresOutput struct {
+	Dialectic Dialectic `json:"dialectic"`
+}
+
+// GetDialecticOutput represents a dialectic.
+type GetDialecticOutput struct {
+	Dialectic Dialectic `json:"dialectic"`
+}
+
+// ListInteractionAnnotationsOutput represents the annotations of an interaction, oldest first.
+type ListInteractionAnnotationsOutput struct {
+	Annotations []InteractionAnnotation `json:"annotations"`
+}
+
+// GetInteractionStatusOutput reports an interaction's status and, once its answer has been
+// processed, the beliefs extracted from it.
+type GetInteractionStatusOutput struct {
+	Status           DialecticalInteractionStatus `json:"status"`
+	ExtractedBeliefs []*Belief                    `json:"extracted_beliefs"`
+	ProcessingError  string                       `json:"processing_error,omitempty"`
+}
+
+// BulkAnswerOutput represents a dialectic after answering several of its pending questions at once.
+type BulkAnswerOutput struct {
+	Dialectic Dialectic `json:"dialectic"`
+	// AnsweredInteractionIDs lists the pending interactions the answers were matched to
+	AnsweredInteractionIDs []string `json:"answered_interaction_ids"`
+	// Result holds the outcome of every answer
+	Result BatchResult `json:"result"`
+}
+
+// PreviewNextQuestionOutput is the question a dialectic would ask next, which was not stored.
+type PreviewNextQuestionOutput struct {
+	Question string `json:"question"`
+}
+
+// ListInteractionsOutput represents a dialectic's interactions, oldest update first.
+type ListInteractionsOutput struct {
+	Interactions []InteractionSummary `json:"interactions"`
+}
+
+// GetLearningObjectiveProgressOutput represents the progress of a dialectic's learning objective.
+type GetLearningObjectiveProgressOutput struct {
+	LearningObjective *LearningObjective         `json:"learning_objective"`
+	Progress          *LearningObjectiveProgress `json:"progress"`
+}
+
+// ComputeDialecticMetricsOutput represents the personalization metrics of a dialectic.
+type ComputeDialecticMetricsOutput struct {
+	Metrics *DialecticMetrics `json:"metrics"`
+}
+
+// GetPerspectivesOutput represents the perspectives of several self models, in request order.
+// Errors maps the ID of each self model whose perspective failed to the failure.
+type GetPerspectivesOutput struct {
+	Perspectives []Perspective     `json:"perspectives"`
+	Errors       map[string]string `json:"errors,omitempty"`
 }
 
 // GetBeliefSystemOutput represents an output containing a belief system.
@@ -57,10 +301,23 @@ type CreateDeveloperOutput struct {
 	Developer Developer `json:"developer"`
 }
 
+// RegisterWebhookOutput holds the registered webhook, including the secret its deliveries are
+// signed with.
+type RegisterWebhookOutput struct {
+	Webhook Webhook `json:"webhook"`
+}
+
 type CreateUserOutput struct {
 	User User `json:"user"`
 }
 
+// ListUsersOutput represents a page of a developer's users, oldest first. NextPageToken is empty on
+// the last page.
+type ListUsersOutput struct {
+	Users         []User `json:"users"`
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
+
 type Developer struct {
 	ID        string   `json:"id"`
 	Name      string   `json:"name"`
@@ -68,6 +325,64 @@ type Developer struct {
 	APIKeys   []string `json:"api_keys"`
 	CreatedAt int64    `json:"created_at"`
 	UpdatedAt int64    `json:"updated_at"`
+	// RateLimit overrides the server's default request rate for this developer's API keys
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+	// APIKeyExpiries holds, in milliseconds UTC, when each key replaced by a rotation stops being
+	// accepted. Keys without an entry do not expire.
+	APIKeyExpiries map[string]int64 `json:"api_key_expiries,omitempty"`
+	// EncryptedOpenAIKey is the developer's own OpenAI key, encrypted with the server's secret. AI
+	// calls made for the developer's requests use it instead of the server's key.
+	EncryptedOpenAIKey string `json:"encrypted_openai_key,omitempty"`
+}
+
+// KeyActive reports whether apiKey is one of the developer's keys and has not expired by now.
+func (d *Developer) KeyActive(apiKey string, now time.Time) bool {
+	for _, key := range d.APIKeys {
+		if key == apiKey {
+			expiry, ok := d.APIKeyExpiries[apiKey]
+			return !ok || now.UnixMilli() < expiry
+		}
+	}
+	return false
+}
+
+// RevokedAPIKey records a key revoked before its expiry, so it is rejected even though no
+// developer holds it anymore.
+type RevokedAPIKey struct {
+	APIKey             string `json:"api_key"`
+	DeveloperID        string `json:"developer_id"`
+	RevokedAtMillisUTC int64  `json:"revoked_at_millis_utc"`
+}
+
+// IssuedAPIKey indexes an active API key by its value, so a request's key is checked with a
+// single lookup instead of a scan of every developer.
+type IssuedAPIKey struct {
+	APIKey      string `json:"api_key"`
+	DeveloperID string `json:"developer_id"`
+	// ExpiresAtMillisUTC is when a key replaced by a rotation stops being accepted; zero if never
+	ExpiresAtMillisUTC int64 `json:"expires_at_millis_utc,omitempty"`
+}
+
+// RotateAPIKeyOutput holds the newly issued key and the developer holding it.
+type RotateAPIKeyOutput struct {
+	APIKey    string     `json:"api_key"`
+	Developer *Developer `json:"developer"`
+}
+
+// RevokeAPIKeyOutput holds the developer after the key was revoked.
+type RevokeAPIKeyOutput struct {
+	Developer *Developer `json:"developer"`
+}
+
+// SetOpenAIKeyOutput holds the developer after its OpenAI key was stored or removed.
+type SetOpenAIKeyOutput struct {
+	Developer *Developer `json:"developer"`
+}
+
+// RateLimit is a token bucket: RequestsPerSecond tokens are added per second, up to Burst.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
 }
 
 func (d *Developer) ToProto() *pbmodels.Developer {
@@ -81,11 +396,32 @@ func (d *Developer) ToProto() *pbmodels.Developer {
 	}
 }
 
+// ToRedactedProto is ToProto with every API key cut to its prefix, for responses that describe a
+// developer without handing out their keys.
+func (d *Developer) ToRedactedProto() *pbmodels.Developer {
+	developer := d.ToProto()
+	developer.ApiKeys = make([]string, len(d.APIKeys))
+	for i, apiKey := range d.APIKeys {
+		developer.ApiKeys[i] = MaskAPIKey(apiKey)
+	}
+	return developer
+}
+
+// MaskAPIKey returns the first characters of apiKey, enough for a developer to tell their keys apart.
+func MaskAPIKey(apiKey string) string {
+	const visible = 8
+	if len(apiKey) <= visible {
+		return "..."
+	}
+	return apiKey[:visible] + "..."
+}
+
 type User struct {
 	ID          string `json:"id"`
 	DeveloperID string `json:"developer_id"`
 	Name        string `json:"name"`
 	Email       string `json:"email"`
+	Cohort      string `json:"cohort,omitempty"`
 	CreatedAt   int64  `json:"created_at"`
 	UpdatedAt   int64  `json:"updated_at"`
 }
@@ -96,6 +432,7 @@ func (u *User) ToProto() *pbmodels.User {
 		DeveloperId: u.DeveloperID,
 		Name:        u.Name,
 		Email:       u.Email,
+		Cohort:      u.Cohort,
 		CreatedAt:   u.CreatedAt,
 		UpdatedAt:   u.UpdatedAt,
 	}
@@ -2,8 +2,10 @@ package models
 
 import (
 	pbmodels "epistemic-me-core/pb/models"
+	"math"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -47,6 +49,44 @@ type BeliefSystemMetrics struct {
 	TotalBeliefStatements   int32   `json:"total_belief_statements"`
 }
 
+// BeliefContextLink lists the observation contexts a belief has belief contexts in, in the order
+// the belief contexts appear.
+type BeliefContextLink struct {
+	BeliefID              string   `json:"belief_id"`
+	ObservationContextIDs []string `json:"observation_context_ids"`
+}
+
+func (l BeliefContextLink) ToProto() *pbmodels.BeliefContextLink {
+	return &pbmodels.BeliefContextLink{
+		BeliefId:              l.BeliefID,
+		ObservationContextIds: l.ObservationContextIDs,
+	}
+}
+
+// BeliefContextLinks collects the linkage of every belief in the given contexts, in the order the
+// beliefs first appear. A belief linked to the same observation context twice lists it once.
+func BeliefContextLinks(ppcs []*PredictiveProcessingContext) []*BeliefContextLink {
+	links := make([]*BeliefContextLink, 0)
+	byBelief := make(map[string]*BeliefContextLink)
+	linked := make(map[[2]string]bool)
+	for _, ppc := range ppcs {
+		for _, bc := range ppc.BeliefContexts {
+			if bc == nil || linked[[2]string{bc.BeliefID, bc.ObservationContextID}] {
+				continue
+			}
+			linked[[2]string{bc.BeliefID, bc.ObservationContextID}] = true
+			link, ok := byBelief[bc.BeliefID]
+			if !ok {
+				link = &BeliefContextLink{BeliefID: bc.BeliefID, ObservationContextIDs: []string{}}
+				byBelief[bc.BeliefID] = link
+				links = append(links, link)
+			}
+			link.ObservationContextIDs = append(link.ObservationContextIDs, bc.ObservationContextID)
+		}
+	}
+	return links
+}
+
 func (bc BeliefContext) ToProto() *pbmodels.BeliefContext {
 	return &pbmodels.BeliefContext{
 		BeliefId:                 bc.BeliefID,
@@ -143,6 +183,35 @@ type BeliefContext struct {
 	EmotionIntensity        float32            `json:"emotion_intensity"`
 }
 
+// discrepancySmoothing stands in for a zero prior probability, so an answer the prediction ruled
+// out gives a large but finite divergence.
+const discrepancySmoothing = 1e-6
+
+// NewDiscrepancy compares a predicted state distribution with the observed one. KlDivergence is
+// KL(posterior || prior) in nats, so it grows the more the observation surprised the prediction.
+func NewDiscrepancy(dialecticInteractionID string, prior, posterior map[string]float32) *Discrepancy {
+	discrepancy := &Discrepancy{
+		DialecticInteractionID: dialecticInteractionID,
+		PriorProbabilities:     prior,
+		PosteriorProbabilities: posterior,
+		Timestamp:              time.Now().UnixMilli(),
+		PointwiseKlTerms:       make(map[string]float32, len(posterior)),
+	}
+	for state, p := range posterior {
+		if p <= 0 {
+			continue
+		}
+		q := float64(prior[state])
+		if q < discrepancySmoothing {
+			q = discrepancySmoothing
+		}
+		term := float32(float64(p) * math.Log(float64(p)/q))
+		discrepancy.PointwiseKlTerms[state] = term
+		discrepancy.KlDivergence += term
+	}
+	return discrepancy
+}
+
 func (d Discrepancy) ToProto() *pbmodels.Discrepancy {
 	return &pbmodels.Discrepancy{
 		DialecticInteractionId: d.DialecticInteractionID,
@@ -210,6 +279,9 @@ func beliefContextsToProto(contexts []*BeliefContext) []*pbmodels.BeliefContext
 // ExtrapolateObservationContexts parses the Experiential Narrative section of a markdown philosophy description.
 // It extracts [[C: ...]] as ObservationContext names and [[S: ...]] as possible states, using the existing ObservationContext model.
 // Each context gets a generated UUID, and states are added to the most recent context at the current depth. ParentID is set based on indentation (2 spaces = one level).
+// Markdown headings inside the section also become contexts, named by the heading's [[C: ...]] or its text, and the contexts
+// beneath a heading become its children, so narrative-style sections get a hierarchy too.
+// A context mentioned more than once is merged into the first one, keeping its parent and the union of its states in first-seen order.
 // Only the Experiential Narrative section is parsed.
 func ExtrapolateObservationContexts(description string) []*ObservationContext {
 	// Find the Experiential Narrative section
@@ -220,13 +292,70 @@ func ExtrapolateObservationContexts(description string) []*ObservationContext {
 
 	contextRe := regexp.MustCompile(`\[\[C:([^\]]+)\]\]`)
 	stateRe := regexp.MustCompile(`\[\[S:([^\]]+)\]\]`)
+	headingRe := regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
 
 	var contexts []*ObservationContext
+	contextsByName := make(map[string]*ObservationContext)
+	// contextNamed returns the context with the given name, creating it under parentID if it hasn't been seen yet
+	contextNamed := func(name string, parentID string) *ObservationContext {
+		key := normalizeContextName(name)
+		if ctx, ok := contextsByName[key]; ok {
+			return ctx
+		}
+		ctx := &ObservationContext{
+			ID:             uuid.New().String(),
+			Name:           name,
+			ParentID:       parentID,
+			PossibleStates: []string{},
+		}
+		contextsByName[key] = ctx
+		contexts = append(contexts, ctx)
+		return ctx
+	}
+
 	// Stack of most recent context at each depth
 	contextStack := make(map[int]*ObservationContext)
+	// Contexts derived from the enclosing headings, by heading level, and the innermost of them
+	headingStack := make(map[int]*ObservationContext)
+	var heading *ObservationContext
 
 	lines := strings.Split(expSection, "\n")
-	for _, line := range lines {
+	// The first line is the section heading itself
+	for _, line := range lines[1:] {
+		if match := headingRe.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			level := len(match[1])
+			name := strings.TrimSpace(stateRe.ReplaceAllString(match[2], ""))
+			if contextMatch := contextRe.FindStringSubmatch(name); contextMatch != nil {
+				name = strings.TrimSpace(contextMatch[1])
+			}
+			if name == "" {
+				continue
+			}
+
+			parentID := ""
+			for l := level - 1; l > 0; l-- {
+				if parent, ok := headingStack[l]; ok {
+					parentID = parent.ID
+					break
+				}
+			}
+			ctx := contextNamed(name, parentID)
+			for _, stateMatch := range stateRe.FindAllStringSubmatch(match[2], -1) {
+				ctx.addPossibleState(strings.TrimSpace(stateMatch[1]))
+			}
+
+			// A new heading closes every deeper heading and the contexts nested under the previous one
+			for l := range headingStack {
+				if l >= level {
+					delete(headingStack, l)
+				}
+			}
+			headingStack[level] = ctx
+			heading = ctx
+			contextStack = make(map[int]*ObservationContext)
+			continue
+		}
+
 		// Count leading spaces for depth (2 spaces = one level)
 		depth := 0
 		for i := 0; i < len(line); i++ {
@@ -242,17 +371,14 @@ func ExtrapolateObservationContexts(description string) []*ObservationContext {
 		contextMatches := contextRe.FindAllStringSubmatch(line, -1)
 		for _, match := range contextMatches {
 			ctxName := strings.TrimSpace(match[1])
-			ctx := &ObservationContext{
-				ID:             uuid.New().String(),
-				Name:           ctxName,
-				ParentID:       "",
-				PossibleStates: []string{},
-			}
-			// Set ParentID if there is a context at depth-1
+			// Set ParentID if there is a context at depth-1, otherwise fall back to the enclosing heading
+			parentID := ""
 			if parent, ok := contextStack[depth-1]; ok && depth > 0 {
-				ctx.ParentID = parent.ID
+				parentID = parent.ID
+			} else if heading != nil {
+				parentID = heading.ID
 			}
-			contexts = append(contexts, ctx)
+			ctx := contextNamed(ctxName, parentID)
 			contextStack[depth] = ctx
 			// Remove deeper contexts from stack
 			for d := depth + 1; ; d++ {
@@ -269,7 +395,7 @@ func ExtrapolateObservationContexts(description string) []*ObservationContext {
 		for _, match := range stateMatches {
 			stateName := strings.TrimSpace(match[1])
 			if ctx, ok := contextStack[depth]; ok {
-				ctx.PossibleStates = append(ctx.PossibleStates, stateName)
+				ctx.addPossibleState(stateName)
 			}
 		}
 	}
@@ -277,16 +403,39 @@ func ExtrapolateObservationContexts(description string) []*ObservationContext {
 	return contexts
 }
 
+// normalizeContextName folds case and whitespace so repeated mentions of a context match
+func normalizeContextName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// addPossibleState appends state unless the context already has it
+func (oc *ObservationContext) addPossibleState(state string) {
+	for _, existing := range oc.PossibleStates {
+		if existing == state {
+			return
+		}
+	}
+	oc.PossibleStates = append(oc.PossibleStates, state)
+}
+
 // extractExperientialNarrativeSection extracts the Experiential Narrative section from the markdown.
+// The section runs until the next heading of the same or a higher level; deeper headings stay in the section.
 func extractExperientialNarrativeSection(md string) string {
 	start := strings.Index(md, "## Experiential Narrative")
 	if start == -1 {
 		return ""
 	}
-	// Find the next section or end of string
-	end := strings.Index(md[start+1:], "## ")
-	if end == -1 {
+	// Find the next section or end of string, skipping the section's own heading line
+	bodyStart := start + len("## Experiential Narrative")
+	if newline := strings.Index(md[bodyStart:], "\n"); newline != -1 {
+		bodyStart += newline
+	} else {
+		return md[start:]
+	}
+	nextSectionRe := regexp.MustCompile(`(?m)^#{1,2}\s`)
+	end := nextSectionRe.FindStringIndex(md[bodyStart:])
+	if end == nil {
 		return md[start:]
 	}
-	return md[start : start+1+end]
+	return md[start : bodyStart+end[0]]
 }
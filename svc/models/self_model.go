@@ -10,6 +10,8 @@ type SelfModel struct {
 	Philosophies []string      `json:"philosophies"`
 	BeliefSystem *BeliefSystem `json:"belief_system"`
 	Dialectics   []*Dialectic  `json:"dialectics"`
+	// Cohort groups self models for analytics; empty when unassigned
+	Cohort string `json:"cohort,omitempty"`
 }
 
 func (sa *SelfModel) ToProto() *pbmodels.SelfModel {
@@ -22,6 +24,7 @@ func (sa *SelfModel) ToProto() *pbmodels.SelfModel {
 		Id:           sa.ID,
 		Philosophies: sa.Philosophies,
 		Dialectics:   protoDialectics,
+		Cohort:       sa.Cohort,
 	}
 
 	if sa.BeliefSystem != nil {
@@ -36,6 +39,9 @@ type Philosophy struct {
 	ID                  string `json:"id"`
 	Description         string `json:"description"`
 	ExtrapolateContexts bool   `json:"extrapolate_contexts"`
+	// ExtrapolatedContexts are the contexts parsed from Description, valid while DescriptionHash matches it
+	ExtrapolatedContexts []*ObservationContext `json:"extrapolated_contexts,omitempty"`
+	DescriptionHash      string                `json:"description_hash,omitempty"`
 }
 
 func (p *Philosophy) ToProto() *pbmodels.Philosophy {
@@ -68,6 +74,27 @@ type GetSelfModelOutput struct {
 	SelfModel *SelfModel `json:"self_model"`
 }
 
+// GetSelfModelDetailInput represents the input for retrieving a self-model together with its
+// belief system, philosophies and dialectic counts. The exclude flags leave sections out for
+// lighter responses.
+type GetSelfModelDetailInput struct {
+	SelfModelID         string `json:"self_model_id"`
+	ExcludeBeliefSystem bool   `json:"exclude_belief_system"`
+	ExcludePhilosophies bool   `json:"exclude_philosophies"`
+}
+
+// GetSelfModelDetailOutput represents a self-model with the sections a detail view needs. The
+// self model itself carries neither its belief system nor its dialectics; the belief system is
+// returned separately and the dialectics are only counted. A dialectic is active while it has a
+// question awaiting an answer.
+type GetSelfModelDetailOutput struct {
+	SelfModel            *SelfModel    `json:"self_model"`
+	BeliefSystem         *BeliefSystem `json:"belief_system,omitempty"`
+	Philosophies         []*Philosophy `json:"philosophies,omitempty"`
+	DialecticCount       int32         `json:"dialectic_count"`
+	ActiveDialecticCount int32         `json:"active_dialectic_count"`
+}
+
 // AddPhilosophyInput represents the input for adding a philosophy to a self-model
 type AddPhilosophyInput struct {
 	SelfModelID  string `json:"self_model_id"`
@@ -79,6 +106,18 @@ type AddPhilosophyOutput struct {
 	UpdatedSelfModel *SelfModel `json:"updated_self_model"`
 }
 
+// SetCohortInput represents the input for assigning a self model to a cohort. An empty cohort
+// removes the assignment.
+type SetCohortInput struct {
+	SelfModelID string `json:"self_model_id"`
+	Cohort      string `json:"cohort"`
+}
+
+// SetCohortOutput represents the self model after its cohort was set
+type SetCohortOutput struct {
+	SelfModel *SelfModel `json:"self_model"`
+}
+
 // CreatePhilosophyInput represents the input for creating a new philosophy
 type CreatePhilosophyInput struct {
 	Description         string `json:"description"`
@@ -89,6 +128,8 @@ type CreatePhilosophyInput struct {
 type CreatePhilosophyOutput struct {
 	Philosophy                      *Philosophy           `json:"philosophy"`
 	ExtrapolatedObservationContexts []*ObservationContext `json:"extrapolated_observation_contexts,omitempty"`
+	// Warnings lists malformed context tags found in the description; they never block the write
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // UpdatePhilosophyInput represents the input for updating an existing philosophy
@@ -105,4 +146,98 @@ type UpdatePhilosophyInput struct {
 type UpdatePhilosophyOutput struct {
 	Philosophy                      *Philosophy           `json:"philosophy"`
 	ExtrapolatedObservationContexts []*ObservationContext `json:"extrapolated_observation_contexts,omitempty"`
+	// Warnings lists malformed context tags found in the description; they never block the write
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// GetPhilosophyInput represents the input for retrieving a single philosophy
+type GetPhilosophyInput struct {
+	PhilosophyID string `json:"philosophy_id"`
+}
+
+// GetPhilosophyOutput represents a stored philosophy and its extrapolated observation contexts
+type GetPhilosophyOutput struct {
+	Philosophy                      *Philosophy           `json:"philosophy"`
+	ExtrapolatedObservationContexts []*ObservationContext `json:"extrapolated_observation_contexts,omitempty"`
+}
+
+// ListPhilosophiesInput represents the input for listing stored philosophies.
+// ExtrapolateContexts, when set, keeps only philosophies whose flag matches it.
+type ListPhilosophiesInput struct {
+	ExtrapolateContexts *bool `json:"extrapolate_contexts,omitempty"`
+}
+
+// PhilosophySummary describes a stored philosophy without its extrapolated contexts
+type PhilosophySummary struct {
+	ID                       string `json:"id"`
+	Description              string `json:"description"`
+	ExtrapolateContexts      bool   `json:"extrapolate_contexts"`
+	ExtrapolatedContextCount int32  `json:"extrapolated_context_count"`
+}
+
+func (p *PhilosophySummary) ToProto() *pbmodels.PhilosophySummary {
+	return &pbmodels.PhilosophySummary{
+		Id:                       p.ID,
+		Description:              p.Description,
+		ExtrapolateContexts:      p.ExtrapolateContexts,
+		ExtrapolatedContextCount: p.ExtrapolatedContextCount,
+	}
+}
+
+// ListPhilosophiesOutput represents the output of listing philosophies
+type ListPhilosophiesOutput struct {
+	Philosophies []*PhilosophySummary `json:"philosophies"`
+}
+
+// GenerateAnswerInput represents the input for answering a question as a self model would.
+// AdditionalPhilosophies are philosophy descriptions added to the self model's own, or used in
+// their place when OverridePhilosophies is set.
+type GenerateAnswerInput struct {
+	SelfModelID            string   `json:"self_model_id"`
+	Question               string   `json:"question"`
+	AdditionalPhilosophies []string `json:"additional_philosophies,omitempty"`
+	OverridePhilosophies   bool     `json:"override_philosophies,omitempty"`
+}
+
+// GenerateAnswerOutput represents a synthetic answer given in the voice of a self model
+type GenerateAnswerOutput struct {
+	Answer string `json:"answer"`
+}
+
+// SelfModelExportSchemaVersion is the schema version written by ExportSelfModel. Bump it whenever the
+// export document changes shape so imports can migrate older documents.
+const SelfModelExportSchemaVersion = 1
+
+// SelfModelExport is a versioned snapshot of everything tied to a self model. Learning objectives
+// are exported as part of the dialectics they belong to.
+type SelfModelExport struct {
+	SchemaVersion       int           `json:"schema_version"`
+	ExportedAtMillisUTC int64         `json:"exported_at_millis_utc"`
+	SelfModel           *SelfModel    `json:"self_model"`
+	BeliefSystem        *BeliefSystem `json:"belief_system"`
+	Beliefs             []*Belief     `json:"beliefs"`
+	Dialectics          []*Dialectic  `json:"dialectics"`
+	Philosophies        []*Philosophy `json:"philosophies"`
+}
+
+// ExportSelfModelInput represents the input for exporting a self-model
+type ExportSelfModelInput struct {
+	SelfModelID string `json:"self_model_id"`
+}
+
+// ExportSelfModelOutput represents the output after exporting a self-model
+type ExportSelfModelOutput struct {
+	Export *SelfModelExport `json:"export"`
+}
+
+// ImportSelfModelInput represents the input for restoring a self-model from an export document.
+// SelfModelID is optional and defaults to the ID of the exported self model.
+type ImportSelfModelInput struct {
+	Document    []byte `json:"document"`
+	SelfModelID string `json:"self_model_id"`
+}
+
+// ImportSelfModelOutput represents the output after importing a self-model
+type ImportSelfModelOutput struct {
+	SelfModel *SelfModel `json:"self_model"`
 }
@@ -0,0 +1,8 @@
+package models
+
+// SelfModelOwner records the developer whose user a self model belongs to. It is stored under the
+// self model so the owner can be found without scanning every developer's users.
+type SelfModelOwner struct {
+	SelfModelID string `json:"self_model_id"`
+	DeveloperID string `json:"developer_id"`
+}
@@ -25,6 +25,11 @@ const (
 	ResourceTypePhilosophy
 )
 
+// Valid reports whether t is one of the known resource types.
+func (t ResourceType) Valid() bool {
+	return t > ResourceTypeInvalid && t <= ResourceTypePhilosophy
+}
+
 type Resource struct {
 	ID       string            `json:"id"`
 	Type     ResourceType      `json:"type"`
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"reflect"
+
+	pbmodels "epistemic-me-core/pb/models"
+)
+
+// WebhookEventBeliefSystemChanged is the type of the event sent when a belief system is persisted
+// with a change.
+const WebhookEventBeliefSystemChanged = "belief_system.changed"
+
+// Webhook is the URL a developer registered to be notified of changes to their users' belief
+// systems. Deliveries are signed with Secret.
+type Webhook struct {
+	DeveloperID        string `json:"developer_id"`
+	URL                string `json:"url"`
+	Secret             string `json:"secret"`
+	Version            int32  `json:"version"`
+	CreatedAtMillisUTC int64  `json:"created_at_millis_utc"`
+}
+
+func (w *Webhook) ToProto() *pbmodels.Webhook {
+	return &pbmodels.Webhook{
+		DeveloperId:        w.DeveloperID,
+		Url:                w.URL,
+		Secret:             w.Secret,
+		CreatedAtMillisUtc: w.CreatedAtMillisUTC,
+	}
+}
+
+// WebhookEvent is the JSON body posted to a developer's webhook.
+type WebhookEvent struct {
+	ID                 string           `json:"id"`
+	Type               string           `json:"type"`
+	DeveloperID        string           `json:"developer_id"`
+	SelfModelID        string           `json:"self_model_id"`
+	CreatedAtMillisUTC int64            `json:"created_at_millis_utc"`
+	Diff               BeliefSystemDiff `json:"diff"`
+}
+
+// WebhookDeadLetter records an event that could not be delivered after every attempt.
+type WebhookDeadLetter struct {
+	Event    WebhookEvent `json:"event"`
+	URL      string       `json:"url"`
+	Attempts int          `json:"attempts"`
+	Error    string       `json:"error"`
+}
+
+// BeliefSystemDiff describes how a belief system changed between two stored versions. Beliefs are
+// matched by ID; a belief present in both versions with any difference is listed as updated, in its
+// new form.
+type BeliefSystemDiff struct {
+	AddedBeliefs             []*Belief `json:"added_beliefs"`
+	UpdatedBeliefs           []*Belief `json:"updated_beliefs"`
+	RemovedBeliefIDs         []string  `json:"removed_belief_ids"`
+	EpistemicContextsChanged bool      `json:"epistemic_contexts_changed"`
+}
+
+// Empty reports whether the diff describes no change.
+func (d *BeliefSystemDiff) Empty() bool {
+	return len(d.AddedBeliefs) == 0 && len(d.UpdatedBeliefs) == 0 && len(d.RemovedBeliefIDs) == 0 &&
+		!d.EpistemicContextsChanged
+}
+
+// DiffBeliefSystems compares two versions of a belief system, either of which may be nil.
+func DiffBeliefSystems(before, after *BeliefSystem) BeliefSystemDiff {
+	if before == nil {
+		before = &BeliefSystem{}
+	}
+	if after == nil {
+		after = &BeliefSystem{}
+	}
+
+	diff := BeliefSystemDiff{
+		AddedBeliefs:     []*Belief{},
+		UpdatedBeliefs:   []*Belief{},
+		RemovedBeliefIDs: []string{},
+	}
+	previous := make(map[string]*Belief, len(before.Beliefs))
+	for _, belief := range before.Beliefs {
+		if belief != nil {
+			previous[belief.ID] = belief
+		}
+	}
+	current := make(map[string]bool, len(after.Beliefs))
+	for _, belief := range after.Beliefs {
+		if belief == nil {
+			continue
+		}
+		current[belief.ID] = true
+		old, ok := previous[belief.ID]
+		switch {
+		case !ok:
+			diff.AddedBeliefs = append(diff.AddedBeliefs, belief)
+		case !reflect.DeepEqual(old, belief):
+			diff.UpdatedBeliefs = append(diff.UpdatedBeliefs, belief)
+		}
+	}
+	for _, belief := range before.Beliefs {
+		if belief != nil && !current[belief.ID] {
+			diff.RemovedBeliefIDs = append(diff.RemovedBeliefIDs, belief.ID)
+		}
+	}
+	diff.EpistemicContextsChanged = !equalEpistemicContexts(before.EpistemicContexts, after.EpistemicContexts)
+	return diff
+}
+
+// equalEpistemicContexts treats a nil and an empty list as equal, since both are stored as no contexts.
+func equalEpistemicContexts(a, b []*EpistemicContext) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,272 @@
+package svc
+
+import (
+	"fmt"
+
+	"epistemic-me-core/svc/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateObservationContext adds an observation context to a self model's belief system. The context
+// joins the predictive processing context that holds its parent, or the first one when it has no
+// parent, and a new epistemic context is started when the belief system has none.
+func (bsvc *BeliefService) CreateObservationContext(input *models.CreateObservationContextInput) (*models.CreateObservationContextOutput, error) {
+	if input.Name == "" {
+		return nil, fmt.Errorf("observation context name is required")
+	}
+
+	beliefSystem, err := bsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	id := input.ID
+	if id == "" {
+		id = uuid.New().String()
+	}
+	if len(findObservationContexts(beliefSystem, id)) > 0 {
+		return nil, fmt.Errorf("observation context %s already exists", id)
+	}
+
+	var target *models.PredictiveProcessingContext
+	if input.ParentID != "" {
+		for _, ppc := range predictiveProcessingContexts(beliefSystem) {
+			if containsObservationContext(ppc, input.ParentID) {
+				target = ppc
+				break
+			}
+		}
+		if target == nil {
+			return nil, fmt.Errorf("%w: parent %s", ErrObservationContextNotFound, input.ParentID)
+		}
+	} else if ppcs := predictiveProcessingContexts(beliefSystem); len(ppcs) > 0 {
+		target = ppcs[0]
+	} else {
+		target = &models.PredictiveProcessingContext{}
+		beliefSystem.EpistemicContexts = append(beliefSystem.EpistemicContexts, &models.EpistemicContext{
+			PredictiveProcessingContext: target,
+		})
+	}
+
+	possibleStates := input.PossibleStates
+	if possibleStates == nil {
+		possibleStates = []string{}
+	}
+	observationContext := &models.ObservationContext{
+		ID:             id,
+		Name:           input.Name,
+		ParentID:       input.ParentID,
+		PossibleStates: possibleStates,
+	}
+	target.ObservationContexts = append(target.ObservationContexts, observationContext)
+
+	if err := storeBeliefSystem(bsvc.kvStore, bsvc.observer, input.SelfModelID, beliefSystem); err != nil {
+		return nil, fmt.Errorf("failed to store belief system: %w", err)
+	}
+
+	return &models.CreateObservationContextOutput{ObservationContext: *observationContext}, nil
+}
+
+// UpdateObservationContext renames, reparents or replaces the possible states of an observation
+// context. Fields left empty are kept; an empty ParentID moves the context to the top level. Every
+// epistemic context listing the observation context is updated.
+func (bsvc *BeliefService) UpdateObservationContext(input *models.UpdateObservationContextInput) (*models.UpdateObservationContextOutput, error) {
+	beliefSystem, err := bsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := findObservationContexts(beliefSystem, input.ID)
+	if len(copies) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrObservationContextNotFound, input.ID)
+	}
+
+	if input.ParentID != nil && *input.ParentID != "" {
+		if err := checkObservationContextParent(beliefSystem, input.ID, *input.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, oc := range copies {
+		if input.Name != "" {
+			oc.Name = input.Name
+		}
+		if input.ParentID != nil {
+			oc.ParentID = *input.ParentID
+		}
+		if input.PossibleStates != nil {
+			oc.PossibleStates = append([]string{}, input.PossibleStates...)
+		}
+	}
+
+	if err := storeBeliefSystem(bsvc.kvStore, bsvc.observer, input.SelfModelID, beliefSystem); err != nil {
+		return nil, fmt.Errorf("failed to store belief system: %w", err)
+	}
+
+	return &models.UpdateObservationContextOutput{ObservationContext: *copies[0]}, nil
+}
+
+// DeleteObservationContext removes an observation context from every epistemic context listing it.
+// Its children move to its own parent when ReparentChildren is set and become top-level contexts
+// otherwise. A context still referenced by belief contexts is only deleted when Force is set, in
+// which case those belief contexts are removed along with it.
+func (bsvc *BeliefService) DeleteObservationContext(input *models.DeleteObservationContextInput) (*models.DeleteObservationContextOutput, error) {
+	beliefSystem, err := bsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := findObservationContexts(beliefSystem, input.ID)
+	if len(copies) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrObservationContextNotFound, input.ID)
+	}
+	deleted := *copies[0]
+
+	references := 0
+	for _, ppc := range predictiveProcessingContexts(beliefSystem) {
+		for _, bc := range ppc.BeliefContexts {
+			if bc != nil && bc.ObservationContextID == input.ID {
+				references++
+			}
+		}
+	}
+	if references > 0 && !input.Force {
+		return nil, fmt.Errorf("%w: %s is referenced by %d belief contexts", ErrObservationContextInUse, input.ID, references)
+	}
+
+	newParentID := ""
+	if input.ReparentChildren {
+		newParentID = deleted.ParentID
+	}
+
+	childIDs := make([]string, 0)
+	moved := make(map[string]bool)
+	for _, ppc := range predictiveProcessingContexts(beliefSystem) {
+		observationContexts := make([]*models.ObservationContext, 0, len(ppc.ObservationContexts))
+		for _, oc := range ppc.ObservationContexts {
+			if oc == nil || oc.ID == input.ID {
+				continue
+			}
+			if oc.ParentID == input.ID {
+				oc.ParentID = newParentID
+				if !moved[oc.ID] {
+					moved[oc.ID] = true
+					childIDs = append(childIDs, oc.ID)
+				}
+			}
+			observationContexts = append(observationContexts, oc)
+		}
+		ppc.ObservationContexts = observationContexts
+
+		beliefContexts := make([]*models.BeliefContext, 0, len(ppc.BeliefContexts))
+		for _, bc := range ppc.BeliefContexts {
+			if bc == nil || bc.ObservationContextID != input.ID {
+				beliefContexts = append(beliefContexts, bc)
+			}
+		}
+		ppc.BeliefContexts = beliefContexts
+	}
+
+	if err := storeBeliefSystem(bsvc.kvStore, bsvc.observer, input.SelfModelID, beliefSystem); err != nil {
+		return nil, fmt.Errorf("failed to store belief system: %w", err)
+	}
+
+	return &models.DeleteObservationContextOutput{
+		ObservationContext:         deleted,
+		ChildIDs:                   childIDs,
+		RemovedBeliefContextsCount: int32(references),
+	}, nil
+}
+
+// GetEpistemicContext returns the epistemic contexts of a self model's belief system, with the
+// observation contexts each belief is linked to, for inspecting the predictive processing structure
+// without the beliefs themselves.
+func (bsvc *BeliefService) GetEpistemicContext(input *models.GetEpistemicContextInput) (*models.GetEpistemicContextOutput, error) {
+	beliefSystem, err := bsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	epistemicContexts := make([]*models.EpistemicContext, 0, len(beliefSystem.EpistemicContexts))
+	for _, ec := range beliefSystem.EpistemicContexts {
+		if ec != nil {
+			epistemicContexts = append(epistemicContexts, ec)
+		}
+	}
+	return &models.GetEpistemicContextOutput{
+		EpistemicContexts: epistemicContexts,
+		BeliefLinks:       models.BeliefContextLinks(predictiveProcessingContexts(beliefSystem)),
+	}, nil
+}
+
+// storedBeliefSystem retrieves the belief system stored for a self model as is, without creating
+// one when it is missing.
+func (bsvc *BeliefService) storedBeliefSystem(selfModelID string) (*models.BeliefSystem, error) {
+	value, err := bsvc.kvStore.Retrieve(selfModelID, "BeliefSystem")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve belief system: %w", wrapNotFound(err, ErrBeliefSystemNotFound))
+	}
+	beliefSystem, ok := value.(*models.BeliefSystem)
+	if !ok {
+		return nil, fmt.Errorf("invalid belief system data type: %T", value)
+	}
+	return beliefSystem, nil
+}
+
+func predictiveProcessingContexts(beliefSystem *models.BeliefSystem) []*models.PredictiveProcessingContext {
+	ppcs := make([]*models.PredictiveProcessingContext, 0, len(beliefSystem.EpistemicContexts))
+	for _, ec := range beliefSystem.EpistemicContexts {
+		if ec != nil && ec.PredictiveProcessingContext != nil {
+			ppcs = append(ppcs, ec.PredictiveProcessingContext)
+		}
+	}
+	return ppcs
+}
+
+func containsObservationContext(ppc *models.PredictiveProcessingContext, id string) bool {
+	for _, oc := range ppc.ObservationContexts {
+		if oc != nil && oc.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// findObservationContexts returns every copy of the observation context with the given ID, as
+// epistemic contexts describing the same situation share their observation contexts.
+func findObservationContexts(beliefSystem *models.BeliefSystem, id string) []*models.ObservationContext {
+	var copies []*models.ObservationContext
+	for _, ppc := range predictiveProcessingContexts(beliefSystem) {
+		for _, oc := range ppc.ObservationContexts {
+			if oc != nil && oc.ID == id {
+				copies = append(copies, oc)
+			}
+		}
+	}
+	return copies
+}
+
+// allObservationContexts lists the observation contexts of every epistemic context, including the
+// copies epistemic contexts share.
+func allObservationContexts(beliefSystem *models.BeliefSystem) []*models.ObservationContext {
+	var contexts []*models.ObservationContext
+	for _, ppc := range predictiveProcessingContexts(beliefSystem) {
+		contexts = append(contexts, ppc.ObservationContexts...)
+	}
+	return contexts
+}
+
+// checkObservationContextParent rejects moving the context id under parentID when the parent does
+// not exist or is the context itself or one of its descendants.
+func checkObservationContextParent(beliefSystem *models.BeliefSystem, id, parentID string) error {
+	if len(findObservationContexts(beliefSystem, parentID)) == 0 {
+		return fmt.Errorf("%w: parent %s", ErrObservationContextNotFound, parentID)
+	}
+	for _, descendant := range models.DescendantContextIDs(allObservationContexts(beliefSystem), []string{id}) {
+		if descendant == parentID {
+			return fmt.Errorf("observation context %s cannot be moved under its own descendant %s", id, parentID)
+		}
+	}
+	return nil
+}
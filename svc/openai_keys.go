@@ -0,0 +1,96 @@
+package svc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"epistemic-me-core/svc/models"
+)
+
+// SetOpenAIKeySecret sets the secret developers' OpenAI keys are encrypted with at rest. Until it is
+// set, developers cannot store their own OpenAI keys and every AI call uses the server's key.
+func (s *DeveloperService) SetOpenAIKeySecret(secret string) error {
+	if secret == "" {
+		s.openAIKeyCipher = nil
+		return nil
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return fmt.Errorf("failed to create OpenAI key cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("failed to create OpenAI key cipher: %w", err)
+	}
+	s.openAIKeyCipher = aead
+	return nil
+}
+
+// SetOpenAIKey stores, encrypted, the OpenAI key the developer's AI calls are made with. An empty
+// key removes the developer's key, so the server's key is used again.
+func (s *DeveloperService) SetOpenAIKey(input *models.SetOpenAIKeyInput) (*models.SetOpenAIKeyOutput, error) {
+	if s.openAIKeyCipher == nil && input.OpenAIAPIKey != "" {
+		return nil, ErrOpenAIKeysDisabled
+	}
+
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+
+	developer, err := s.GetDeveloper(&models.GetDeveloperInput{ID: input.DeveloperID})
+	if err != nil {
+		return nil, err
+	}
+	developer.EncryptedOpenAIKey = ""
+	if input.OpenAIAPIKey != "" {
+		if developer.EncryptedOpenAIKey, err = s.encryptOpenAIKey(input.OpenAIAPIKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.storeDeveloper(developer, time.Now()); err != nil {
+		return nil, err
+	}
+	return &models.SetOpenAIKeyOutput{Developer: developer}, nil
+}
+
+// OpenAIKeyForAPIKey returns the OpenAI key of the developer holding apiKey, or an empty string when
+// no developer holds it or the developer has not stored an OpenAI key.
+func (s *DeveloperService) OpenAIKeyForAPIKey(apiKey string) (string, error) {
+	developer, err := s.GetDeveloperByAPIKey(apiKey)
+	if err != nil || developer.EncryptedOpenAIKey == "" {
+		return "", nil
+	}
+	if s.openAIKeyCipher == nil {
+		return "", ErrOpenAIKeysDisabled
+	}
+	return s.decryptOpenAIKey(developer.EncryptedOpenAIKey)
+}
+
+// encryptOpenAIKey seals key with a random nonce, which is kept in front of the ciphertext.
+func (s *DeveloperService) encryptOpenAIKey(key string) (string, error) {
+	nonce := make([]byte, s.openAIKeyCipher.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to encrypt OpenAI key: %w", err)
+	}
+	sealed := s.openAIKeyCipher.Seal(nonce, nonce, []byte(key), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *DeveloperService) decryptOpenAIKey(encrypted string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < s.openAIKeyCipher.NonceSize() {
+		return "", fmt.Errorf("failed to decrypt OpenAI key: malformed ciphertext")
+	}
+	nonceSize := s.openAIKeyCipher.NonceSize()
+	key, err := s.openAIKeyCipher.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt OpenAI key: %w", err)
+	}
+	return string(key), nil
+}
@@ -0,0 +1,51 @@
+package svc
+
+import (
+	"errors"
+
+	db "epistemic-me-core/db"
+	"epistemic-me-core/svc/models"
+)
+
+// DefaultOpeningQuestions are the questions that open a dialectic in fast mode, by dialectic type.
+var DefaultOpeningQuestions = map[models.DialecticType]string{
+	models.DialecticTypeDefault:           "What do you believe makes a day a healthy day for you?",
+	models.DialecticTypeSleepDietExercise: "How do you believe your sleep, diet and exercise affect your energy from one day to the next?",
+}
+
+// SetOpeningQuestions turns on fast mode, in which a new dialectic opens with the fixed question
+// configured for its type instead of one generated by the AI, as long as the self model has no
+// beliefs yet. Dialectics with a learning objective, or of a type without a configured question,
+// still get a generated one. A nil map turns fast mode off.
+func (dsvc *DialecticService) SetOpeningQuestions(questions map[models.DialecticType]string) {
+	dsvc.openingQuestions = questions
+}
+
+// openingQuestion returns the fixed opening question for a new dialectic, or false when the
+// question should be generated.
+func (dsvc *DialecticService) openingQuestion(input *models.CreateDialecticInput) (string, bool) {
+	if dsvc.openingQuestions == nil || input.LearningObjective != nil {
+		return "", false
+	}
+	dialecticType := input.DialecticType
+	if dialecticType == models.DialecticTypeInvalid {
+		dialecticType = models.DialecticTypeDefault
+	}
+	question, ok := dsvc.openingQuestions[dialecticType]
+	if !ok || question == "" {
+		return "", false
+	}
+
+	// Once the self model has beliefs there is context worth asking about
+	value, err := dsvc.kvStore.Retrieve(input.SelfModelID, "BeliefSystem")
+	if errors.Is(err, db.ErrNotFound) {
+		return question, true
+	}
+	if err != nil {
+		return "", false
+	}
+	if bs, ok := value.(*models.BeliefSystem); ok && len(bs.Beliefs) > 0 {
+		return "", false
+	}
+	return question, true
+}
@@ -1,9 +1,9 @@
 package svc
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,7 +21,7 @@ type InteractionEvent struct {
 // OptimizedDialecticService provides performance-optimized implementations
 // of DialecticService functions, particularly focusing on reducing AI calls
 type OptimizedDialecticService struct {
-	kvStore                    *db.KeyValueStore
+	kvStore                    db.KeyValueStore
 	aiHelper                   AIHelperInterface
 	dialecticEpiSvc            *DialecticalEpistemology
 	enablePredictiveProcessing bool
@@ -35,7 +35,7 @@ type AIHelperInterface interface {
 }
 
 // NewOptimizedDialecticService creates a new instance of OptimizedDialecticService
-func NewOptimizedDialecticService(kvStore *db.KeyValueStore, aiHelper AIHelperInterface, dialecticEpiSvc *DialecticalEpistemology) *OptimizedDialecticService {
+func NewOptimizedDialecticService(kvStore db.KeyValueStore, aiHelper AIHelperInterface, dialecticEpiSvc *DialecticalEpistemology) *OptimizedDialecticService {
 	return &OptimizedDialecticService{
 		kvStore:                    kvStore,
 		aiHelper:                   aiHelper,
@@ -49,7 +49,7 @@ func NewOptimizedDialecticService(kvStore *db.KeyValueStore, aiHelper AIHelperIn
 func NewOptimizedDialecticServiceForTesting(kvStore interface{}, aiHelper AIHelperInterface, dialecticEpiSvc *DialecticalEpistemology) *OptimizedDialecticService {
 	// Use the existing service constructor but with type assertion to handle mock objects
 	return &OptimizedDialecticService{
-		kvStore:                    kvStore.(*db.KeyValueStore),
+		kvStore:                    kvStore.(db.KeyValueStore),
 		aiHelper:                   aiHelper,
 		dialecticEpiSvc:            dialecticEpiSvc,
 		enablePredictiveProcessing: true,
@@ -68,6 +68,10 @@ func (svc *OptimizedDialecticService) OptimizedUpdateDialectic(input *models.Upd
 	}
 	log.Printf("Retrieved dialectic with %d interactions in %v",
 		len(dialectic.UserInteractions), time.Since(startTime))
+	if dialectic.Version == 0 {
+		dialectic.Version = int32(len(dialectic.UserInteractions))
+	}
+	dialectic.Version++
 
 	if input.Answer.UserAnswer != "" {
 		// OPTIMIZATION: Process the belief system with dialecticEpiSvc but enhance PredictiveProcessingContext
@@ -79,13 +83,23 @@ func (svc *OptimizedDialecticService) OptimizedUpdateDialectic(input *models.Upd
 		}
 
 		// Additional PredictiveProcessingContext enhancements beyond what Process provides
+		var prediction *models.Prediction
 		if svc.enablePredictiveProcessing {
 			// Extract the current interaction for enhanced context tracking
+			current := &dialectic.UserInteractions[len(dialectic.UserInteractions)-1]
 			interactionEvent := InteractionEvent{
-				Question: svc.getQuestion(&dialectic.UserInteractions[len(dialectic.UserInteractions)-1]),
+				Question: svc.getQuestion(current),
 				Answer:   input.Answer.UserAnswer,
 			}
 
+			// Compare the answer with a predicted one when asked to and the AI helper can predict
+			if predictor, ok := svc.aiHelper.(AnswerPredictor); ok && input.ComputeSurprise {
+				prediction, err = predictObservation(predictor, current.ID, interactionEvent.Question, interactionEvent.Answer)
+				if err != nil {
+					return nil, fmt.Errorf("failed to compute surprise: %w", err)
+				}
+			}
+
 			// Extract beliefs from the latest answer (to ensure fresh context)
 			extractedBeliefStrings, err := svc.aiHelper.GetInteractionEventAsBelief(interactionEvent)
 			if err != nil {
@@ -97,8 +111,9 @@ func (svc *OptimizedDialecticService) OptimizedUpdateDialectic(input *models.Upd
 			for _, beliefStr := range extractedBeliefStrings {
 				// Check if this belief already exists in updated belief system
 				beliefExists := false
+				key := models.BeliefContentKey(beliefStr)
 				for _, existingBelief := range bs.Beliefs {
-					if strings.Contains(existingBelief.GetContentAsString(), beliefStr) {
+					if models.BeliefContentKey(existingBelief.GetContentAsString()) == key {
 						beliefExists = true
 						break
 					}
@@ -117,11 +132,11 @@ func (svc *OptimizedDialecticService) OptimizedUpdateDialectic(input *models.Upd
 			}
 
 			// Enhanced PredictiveProcessingContext updates with conversation awareness
-			svc.updatePredictiveProcessingContext(bs, dialectic, extractedBeliefs, interactionEvent)
+			svc.updatePredictiveProcessingContext(bs, dialectic, extractedBeliefs, interactionEvent, prediction)
 		}
 
 		// Store the updated belief system
-		err = svc.kvStore.Store(input.SelfModelID, "BeliefSystem", *bs, len(bs.Beliefs))
+		err = storeBeliefSystem(svc.kvStore, nil, input.SelfModelID, bs)
 		if err != nil {
 			return nil, fmt.Errorf("failed to store updated belief system: %w", err)
 		}
@@ -167,9 +182,12 @@ func (svc *OptimizedDialecticService) OptimizedUpdateDialectic(input *models.Upd
 			QuestionAnswer: qa,
 		}
 		dialectic.UserInteractions[lastIdx].UpdatedAtMillisUTC = time.Now().UnixMilli()
+		dialectic.UserInteractions[lastIdx].Prediction = prediction
 
 		// Generate next question using dialecticEpiSvc (leveraging the standard implementation)
 		response, err := svc.dialecticEpiSvc.Respond(bs, &models.DialecticEvent{
+			SelfModelID:          input.SelfModelID,
+			DialecticID:          dialectic.ID,
 			PreviousInteractions: dialectic.UserInteractions,
 		}, "")
 		if err != nil {
@@ -200,6 +218,8 @@ func (svc *OptimizedDialecticService) OptimizedUpdateDialectic(input *models.Upd
 
 		// Generate the next question using dialecticEpiSvc
 		response, err := svc.dialecticEpiSvc.Respond(bs, &models.DialecticEvent{
+			SelfModelID:          input.SelfModelID,
+			DialecticID:          dialectic.ID,
 			PreviousInteractions: dialectic.UserInteractions,
 		}, "")
 		if err != nil {
@@ -249,12 +269,15 @@ func (svc *OptimizedDialecticService) processQuestionBlob(
 	return nil
 }
 
-// updatePredictiveProcessingContext updates the PPC with the new observation and belief contexts
+// updatePredictiveProcessingContext updates the PPC with the new observation and belief contexts.
+// The epistemic emotion of the new belief contexts comes from how the answer compared with
+// prediction, which may be nil.
 func (svc *OptimizedDialecticService) updatePredictiveProcessingContext(
 	bs *models.BeliefSystem,
 	dialectic *models.Dialectic,
 	newBeliefs []*models.Belief,
 	interactionEvent InteractionEvent,
+	prediction *models.Prediction,
 ) {
 	// Initialize PredictiveProcessingContext if not already present
 	if len(bs.EpistemicContexts) == 0 {
@@ -287,6 +310,12 @@ func (svc *OptimizedDialecticService) updatePredictiveProcessingContext(
 	}
 	ppc.ObservationContexts = append(ppc.ObservationContexts, oc)
 
+	emotion, intensity := epistemicEmotionFromPrediction(prediction)
+	discrepancyIDs := []string{}
+	if prediction != nil && prediction.Discrepancy != nil {
+		discrepancyIDs = append(discrepancyIDs, prediction.Discrepancy.DialecticInteractionID)
+	}
+
 	// Create BeliefContext entries for each new belief
 	for _, belief := range newBeliefs {
 		bc := &models.BeliefContext{
@@ -299,19 +328,20 @@ func (svc *OptimizedDialecticService) updatePredictiveProcessingContext(
 				},
 			},
 			ConditionalProbs:        map[string]float32{},
-			DialecticInteractionIDs: []string{},
-			EpistemicEmotion:        models.Confirmation, // Default to confirmation
-			EmotionIntensity:        0.5,                 // Default intensity
+			DialecticInteractionIDs: append([]string{}, discrepancyIDs...),
+			EpistemicEmotion:        emotion,
+			EmotionIntensity:        intensity,
 		}
 		ppc.BeliefContexts = append(ppc.BeliefContexts, bc)
 	}
+	normalizeBeliefContexts(context.Background(), ppc)
 }
 
 // retrieveBeliefSystem gets the belief system from the key-value store
 func (svc *OptimizedDialecticService) retrieveBeliefSystem(selfModelID string) (*models.BeliefSystem, error) {
 	bsValue, err := svc.kvStore.Retrieve(selfModelID, "BeliefSystem")
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve belief system: %w", err)
+		return nil, fmt.Errorf("failed to retrieve belief system: %w", wrapNotFound(err, ErrBeliefSystemNotFound))
 	}
 
 	bs, ok := bsValue.(*models.BeliefSystem)
@@ -325,13 +355,13 @@ func (svc *OptimizedDialecticService) retrieveBeliefSystem(selfModelID string) (
 // Helper functions reused from the original DialecticService
 
 func (svc *OptimizedDialecticService) storeDialecticValue(selfModelID string, dialectic *models.Dialectic) error {
-	return svc.kvStore.Store(selfModelID, fmt.Sprintf("Dialectic:%s", dialectic.ID), dialectic, len(dialectic.UserInteractions))
+	return svc.kvStore.Store(selfModelID, fmt.Sprintf("Dialectic:%s", dialectic.ID), dialectic, int(dialectic.Version))
 }
 
 func (svc *OptimizedDialecticService) retrieveDialecticValue(selfModelID, dialecticID string) (*models.Dialectic, error) {
 	value, err := svc.kvStore.Retrieve(selfModelID, fmt.Sprintf("Dialectic:%s", dialecticID))
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve dialectic: %w", err)
+		return nil, fmt.Errorf("failed to retrieve dialectic: %w", wrapNotFound(err, ErrDialecticNotFound))
 	}
 
 	dialectic, ok := value.(*models.Dialectic)
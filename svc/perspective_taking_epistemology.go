@@ -1,6 +1,7 @@
 package svc
 
 import (
+	"context"
 	ai "epistemic-me-core/ai"
 	"epistemic-me-core/svc/models"
 	"errors"
@@ -20,6 +21,18 @@ func NewPerspectiveTakingEpistemology(beliefService *BeliefService, ai *ai.AIHel
 	}
 }
 
+// withContext returns a copy whose AI calls are made within ctx.
+func (pte *PerspectiveTakingEpistemology) withContext(ctx context.Context) *PerspectiveTakingEpistemology {
+	scoped := *pte
+	if pte.ai != nil {
+		scoped.ai = pte.ai.WithContext(ctx)
+	}
+	if pte.bsvc != nil {
+		scoped.bsvc = pte.bsvc.WithContext(ctx)
+	}
+	return &scoped
+}
+
 func (de *PerspectiveTakingEpistemology) Process(event *models.PerspectiveTakingEpistemicEvent, dryRun bool, selfModelID string) (*models.BeliefSystem, error) {
 
 	bs, err := de.bsvc.GetBeliefSystem(selfModelID)
@@ -0,0 +1,84 @@
+package svc
+
+import (
+	"epistemic-me-core/svc/models"
+	"fmt"
+	"sync"
+)
+
+// DefaultPerspectiveConcurrency bounds how many perspective LLM calls run at once.
+const DefaultPerspectiveConcurrency = 4
+
+// PerspectiveResponder produces a self model's perspective on a question and answer.
+// PerspectiveTakingEpistemology is the production implementation.
+type PerspectiveResponder interface {
+	Respond(bs *models.BeliefSystem, request models.EpistemicRequest) (*string, error)
+}
+
+// GetPerspectives asks each of the given self models how it would interpret a question and answer.
+// A failure for one model is reported in the output rather than failing the whole batch.
+func (dsvc *DialecticService) GetPerspectives(input *models.GetPerspectivesInput) (*models.GetPerspectivesOutput, error) {
+	if len(input.SelfModelIDs) == 0 {
+		return nil, fmt.Errorf("at least one self model ID is required")
+	}
+	if input.Question == "" || input.Answer == "" {
+		return nil, fmt.Errorf("question and answer are required")
+	}
+
+	perspectives, errs := dsvc.collectPerspectives(nil, input.SelfModelIDs, input.Question, input.Answer)
+
+	output := &models.GetPerspectivesOutput{}
+	for i, selfModelID := range input.SelfModelIDs {
+		if errs[i] != nil {
+			if output.Errors == nil {
+				output.Errors = make(map[string]string)
+			}
+			output.Errors[selfModelID] = errs[i].Error()
+			continue
+		}
+		output.Perspectives = append(output.Perspectives, *perspectives[i])
+	}
+	return output, nil
+}
+
+// collectPerspectives requests a perspective from every self model concurrently, using at most
+// perspectiveConcurrency workers. Results and errors are indexed like selfModelIDs.
+func (dsvc *DialecticService) collectPerspectives(bs *models.BeliefSystem, selfModelIDs []string, question, answer string) ([]*models.Perspective, []error) {
+	perspectives := make([]*models.Perspective, len(selfModelIDs))
+	errs := make([]error, len(selfModelIDs))
+
+	workers := dsvc.perspectiveConcurrency
+	if workers <= 0 {
+		workers = DefaultPerspectiveConcurrency
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(selfModelIDs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				response, err := dsvc.perspectiveTakingEpiSvc.Respond(bs, models.EpistemicRequest{
+					SelfModelID: selfModelIDs[i],
+					Content: map[string]interface{}{
+						"question": question,
+						"answer":   answer,
+					},
+				})
+				if err != nil {
+					errs[i] = fmt.Errorf("perspective for self model %s: %w", selfModelIDs[i], err)
+					continue
+				}
+				perspectives[i] = &models.Perspective{Response: *response, SelfModelID: selfModelIDs[i]}
+			}
+		}()
+	}
+	for i := range selfModelIDs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return perspectives, errs
+}
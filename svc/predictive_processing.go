@@ -1,10 +1,13 @@
 package svc
 
 import (
+	"context"
 	"fmt"
+	"math"
 
 	"github.com/google/uuid"
 
+	"epistemic-me-core/logging"
 	"epistemic-me-core/svc/models"
 )
 
@@ -67,6 +70,34 @@ func (pps *PredictiveProcessingService) CreateObservationContext(
 	return oc
 }
 
+const (
+	// defaultEmotionIntensity is used for belief contexts without a prediction to compare against
+	defaultEmotionIntensity = 0.5
+	// confirmationDivergence and surpriseDivergence bound, in nats of KL divergence between the
+	// predicted and actual answer states, the answers read as confirmation and as curiosity.
+	// Answers diverging further are a surprise.
+	confirmationDivergence = 0.1
+	surpriseDivergence     = 1.0
+)
+
+// epistemicEmotionFromPrediction reads the epistemic emotion of an answer from how far it diverged
+// from the predicted one. A confirmation's intensity falls as the divergence grows; curiosity's and
+// surprise's rise with it. Without a prediction it is a confirmation of default intensity.
+func epistemicEmotionFromPrediction(prediction *models.Prediction) (models.EpistemicEmotion, float32) {
+	if prediction == nil || prediction.Discrepancy == nil {
+		return models.Confirmation, defaultEmotionIntensity
+	}
+	divergence := float64(prediction.Discrepancy.KlDivergence)
+	switch {
+	case divergence < confirmationDivergence:
+		return models.Confirmation, float32(math.Exp(-divergence))
+	case divergence < surpriseDivergence:
+		return models.Curiosity, float32(1 - math.Exp(-divergence))
+	default:
+		return models.Surprise, float32(1 - math.Exp(-divergence))
+	}
+}
+
 // CreateBeliefContext links a belief to an observation context
 func (pps *PredictiveProcessingService) CreateBeliefContext(
 	ppc *models.PredictiveProcessingContext,
@@ -87,15 +118,86 @@ func (pps *PredictiveProcessingService) CreateBeliefContext(
 		ConditionalProbs:        map[string]float32{},
 		DialecticInteractionIDs: []string{},
 		EpistemicEmotion:        models.Confirmation,
-		EmotionIntensity:        0.5,
+		EmotionIntensity:        defaultEmotionIntensity,
 	}
 
 	// Add to the list of belief contexts
 	ppc.BeliefContexts = append(ppc.BeliefContexts, bc)
+	normalizeBeliefContexts(context.Background(), ppc)
 
 	return bc
 }
 
+// significantRenormalization is how far normalization may move a state's conditional probability
+// before it is logged as a warning.
+const significantRenormalization = 0.05
+
+// NormalizeConditionalProbs rescales conditional probabilities to a distribution over states:
+// probabilities of states outside states are dropped, missing states get zero and the rest are
+// scaled to sum to 1. Without states the probabilities' own states are used. It also returns the
+// largest change made to any state's probability. Negative or non-finite probabilities, and ones
+// that are all zero, are rejected with ErrInvalidConditionalProbs. An empty map means no
+// distribution is known yet and is returned as is.
+func NormalizeConditionalProbs(probs map[string]float32, states []string) (map[string]float32, float32, error) {
+	if len(probs) == 0 {
+		return probs, 0, nil
+	}
+	for state, p := range probs {
+		if p < 0 || math.IsNaN(float64(p)) || math.IsInf(float64(p), 0) {
+			return nil, 0, fmt.Errorf("%w: state %q has probability %v", ErrInvalidConditionalProbs, state, p)
+		}
+	}
+	if len(states) == 0 {
+		for state := range probs {
+			states = append(states, state)
+		}
+	}
+
+	var sum float64
+	for _, state := range states {
+		sum += float64(probs[state])
+	}
+	if sum == 0 {
+		return nil, 0, fmt.Errorf("%w: no probability on any of the context's states", ErrInvalidConditionalProbs)
+	}
+
+	normalized := make(map[string]float32, len(states))
+	var maxChange float32
+	for _, state := range states {
+		normalized[state] = float32(float64(probs[state]) / sum)
+		if change := float32(math.Abs(float64(normalized[state] - probs[state]))); change > maxChange {
+			maxChange = change
+		}
+	}
+	for state, p := range probs {
+		if _, ok := normalized[state]; !ok && p > maxChange {
+			maxChange = p
+		}
+	}
+	return normalized, maxChange, nil
+}
+
+// normalizeBeliefContexts normalizes the conditional probabilities of every belief context over
+// the possible states of its observation context. Invalid probabilities are logged and cleared.
+func normalizeBeliefContexts(ctx context.Context, ppc *models.PredictiveProcessingContext) {
+	statesByContext := make(map[string][]string, len(ppc.ObservationContexts))
+	for _, oc := range ppc.ObservationContexts {
+		statesByContext[oc.ID] = oc.PossibleStates
+	}
+	for _, bc := range ppc.BeliefContexts {
+		normalized, change, err := NormalizeConditionalProbs(bc.ConditionalProbs, statesByContext[bc.ObservationContextID])
+		if err != nil {
+			logging.Warnf(ctx, "Clearing conditional probabilities of belief %s in observation context %s: %v", bc.BeliefID, bc.ObservationContextID, err)
+			bc.ConditionalProbs = map[string]float32{}
+			continue
+		}
+		if change > significantRenormalization {
+			logging.Warnf(ctx, "Renormalizing conditional probabilities of belief %s in observation context %s changed a state by %.2f", bc.BeliefID, bc.ObservationContextID, change)
+		}
+		bc.ConditionalProbs = normalized
+	}
+}
+
 // AddObservationFromInteraction creates a new observation from a question-answer interaction
 // and links it to any beliefs that were extracted from the answer
 func (pps *PredictiveProcessingService) AddObservationFromInteraction(
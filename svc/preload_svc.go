@@ -4,10 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"epistemic-me-core/svc/models"
+	"errors"
 	"io/ioutil"
 	"log"
 	"path/filepath"
-	"strings"
 
 	"github.com/google/uuid"
 )
@@ -113,9 +113,7 @@ func (ps *PreloadService) RunPreload(ctx context.Context) error {
 		})
 
 		if err != nil {
-			// If it's not the "invalid self model data" error, just return
-			// todo: fix whether this should be "developer" or "self model"
-			if !strings.Contains(err.Error(), "developer not found") {
+			if !errors.Is(err, ErrSelfModelNotFound) {
 				return err
 			}
 
@@ -0,0 +1,120 @@
+package svc
+
+import (
+	"fmt"
+	"time"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/logging"
+	"epistemic-me-core/svc/models"
+
+	"github.com/google/uuid"
+)
+
+// ReprocessDialectic extracts the beliefs of every answered interaction of a dialectic again with
+// the current prompts. The beliefs extracted from its answers before are removed from the belief
+// system and replaced by the new ones. A dry run reports the changes without storing them.
+func (dsvc *DialecticService) ReprocessDialectic(input *models.ReprocessDialecticInput) (*models.ReprocessDialecticOutput, error) {
+	dialectic, err := dsvc.retrieveDialecticValue(input.SelfModelID, input.DialecticID)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := dsvc.storedBeliefSystem(input.SelfModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make(map[string]bool)
+	removedIDs := []string{}
+	extractedBeliefs := []*models.Belief{}
+	now := time.Now().UnixMilli()
+	for i := range dialectic.UserInteractions {
+		interaction := &dialectic.UserInteractions[i]
+		qa := getQuestionAnswer(interaction.Interaction)
+		if interaction.Status != models.StatusAnswered || qa == nil {
+			continue
+		}
+		for _, id := range interactionBeliefIDs(bs, interaction, qa) {
+			if !removed[id] {
+				removed[id] = true
+				removedIDs = append(removedIDs, id)
+			}
+		}
+
+		var beliefs []*models.Belief
+		if answer := qa.Answer.UserAnswer; answer != "" && answer != ai.NoAnswerProvided {
+			beliefs, err = dsvc.extractInteractionBeliefs(interaction, qa.Question.Question, answer, dialectic.UserInteractions[:i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		extractedBeliefs = append(extractedBeliefs, beliefs...)
+		qa.ExtractedBeliefs = beliefs
+		qa.UpdatedAtMillisUTC = now
+	}
+
+	if !input.DryRun {
+		// The dialectic's versioned write goes first, so a dialectic updated while it was reprocessed
+		// keeps its beliefs
+		dialectic.Version++
+		if err := dsvc.storeDialecticValue(input.SelfModelID, dialectic); err != nil {
+			return nil, err
+		}
+		removeBeliefs(bs, removedIDs)
+		bs.Beliefs = append(bs.Beliefs, extractedBeliefs...)
+		if err := storeBeliefSystem(dsvc.kvStore, dsvc.observer, input.SelfModelID, bs); err != nil {
+			return nil, fmt.Errorf("failed to store reprocessed belief system: %w", err)
+		}
+	}
+
+	logging.Infof(dsvc.context(), "Reprocessed dialectic %s (dry run %t): removed %d beliefs, extracted %d", dialectic.ID, input.DryRun, len(removedIDs), len(extractedBeliefs))
+	return &models.ReprocessDialecticOutput{
+		Dialectic:        *dialectic,
+		RemovedBeliefIDs: removedIDs,
+		ExtractedBeliefs: extractedBeliefs,
+		DryRun:           input.DryRun,
+	}, nil
+}
+
+// extractInteractionBeliefs extracts the beliefs of answer to question, with the interactions
+// before it as context, as beliefs sourced from interaction.
+func (dsvc *DialecticService) extractInteractionBeliefs(interaction *models.DialecticalInteraction, question, answer string, previous []models.DialecticalInteraction) ([]*models.Belief, error) {
+	classifiedBeliefs, err := dsvc.aih.GetInteractionEventAsClassifiedBeliefsWithHistory(ai.InteractionEvent{
+		Question: question,
+		Answer:   answer,
+	}, dsvc.extractionHistory(previous))
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract beliefs: %w", err)
+	}
+	beliefs := make([]*models.Belief, 0, len(classifiedBeliefs))
+	for _, classified := range classifiedBeliefs {
+		beliefs = append(beliefs, &models.Belief{
+			ID:                  uuid.New().String(),
+			Content:             []models.Content{{RawStr: classified.Content}},
+			Type:                classified.Type,
+			SourceInteractionID: interaction.ID,
+		})
+	}
+	return beliefs, nil
+}
+
+// interactionBeliefIDs returns the IDs of the beliefs extracted from interaction. Beliefs are traced
+// to the interaction by their source, or by the interaction's record of what it extracted for
+// beliefs created before sources were recorded.
+func interactionBeliefIDs(bs *models.BeliefSystem, interaction *models.DialecticalInteraction, qa *models.QuestionAnswerInteraction) []string {
+	seen := make(map[string]bool)
+	ids := []string{}
+	for _, belief := range qa.ExtractedBeliefs {
+		if !seen[belief.ID] {
+			seen[belief.ID] = true
+			ids = append(ids, belief.ID)
+		}
+	}
+	for _, belief := range bs.Beliefs {
+		if interaction.ID != "" && belief.SourceInteractionID == interaction.ID && !seen[belief.ID] {
+			seen[belief.ID] = true
+			ids = append(ids, belief.ID)
+		}
+	}
+	return ids
+}
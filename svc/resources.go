@@ -0,0 +1,80 @@
+package svc
+
+import (
+	"fmt"
+	"slices"
+
+	"epistemic-me-core/svc/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateResource stores an external resource for a self model, such as a scientific paper or
+// measurement data, so beliefs can cite it as evidence. When input names a belief, the resource is
+// cited as that belief's supporting evidence.
+func (bsvc *BeliefService) CreateResource(input *models.CreateResourceInput) (*models.CreateResourceOutput, error) {
+	if !input.Type.Valid() || input.Content == "" {
+		return nil, ErrInvalidResource
+	}
+	// Check the belief first, so a resource is not stored for a request that fails
+	if input.BeliefID != "" {
+		if _, err := bsvc.retrieveBeliefValue(input.SelfModelID, input.BeliefID); err != nil {
+			return nil, err
+		}
+	}
+
+	resource := models.Resource{
+		ID:       "res_" + uuid.New().String(),
+		Type:     input.Type,
+		Content:  input.Content,
+		SourceID: input.SourceID,
+		Metadata: input.Metadata,
+	}
+	if err := bsvc.kvStore.Store(input.SelfModelID, resource.ID, resource, 1); err != nil {
+		return nil, fmt.Errorf("failed to store resource: %w", err)
+	}
+
+	output := &models.CreateResourceOutput{Resource: resource}
+	if input.BeliefID != "" {
+		belief, _, err := bsvc.citeResource(input.SelfModelID, input.BeliefID, resource.ID)
+		if err != nil {
+			return nil, err
+		}
+		output.Belief = belief
+	}
+	return output, nil
+}
+
+// citeResource adds resourceID to the evidence of a belief, storing a new version of the belief
+// with its confidence recomputed. Citing a resource the belief already cites changes nothing.
+func (bsvc *BeliefService) citeResource(selfModelID, beliefID, resourceID string) (*models.Belief, []models.BeliefEvidence, error) {
+	belief, err := bsvc.retrieveBeliefValue(selfModelID, beliefID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if slices.Contains(belief.EvidenceResourceIDs, resourceID) {
+		evidenceLog, err := bsvc.retrieveEvidenceLog(selfModelID, beliefID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return belief, evidenceLog.Evidence, nil
+	}
+
+	belief.EvidenceResourceIDs = append(belief.EvidenceResourceIDs, resourceID)
+	return bsvc.addEvidence(selfModelID, belief, models.BeliefEvidence{
+		Type:       models.EvidenceTypeResource,
+		ResourceID: resourceID,
+	})
+}
+
+func (bsvc *BeliefService) retrieveResource(selfModelID, resourceID string) (*models.Resource, error) {
+	value, err := bsvc.kvStore.Retrieve(selfModelID, resourceID)
+	if err != nil {
+		return nil, wrapNotFound(err, ErrResourceNotFound)
+	}
+	resource, ok := value.(*models.Resource)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a resource", ErrResourceNotFound, resourceID)
+	}
+	return resource, nil
+}
@@ -0,0 +1,266 @@
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"epistemic-me-core/svc/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrIncompatibleExport is returned when an export document cannot be imported by this server
+var ErrIncompatibleExport = errors.New("incompatible self model export")
+
+// ExportSelfModel gathers everything stored for a self model into a single versioned document.
+func (s *SelfModelService) ExportSelfModel(ctx context.Context, input *models.ExportSelfModelInput) (*models.ExportSelfModelOutput, error) {
+	if input.SelfModelID == "" {
+		return nil, fmt.Errorf("self model ID cannot be empty")
+	}
+
+	storedSelfModel, err := s.kvStore.Retrieve(input.SelfModelID, "SelfModel")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve self model: %v", err)
+	}
+	selfModel, ok := storedSelfModel.(*models.SelfModel)
+	if !ok {
+		return nil, fmt.Errorf("invalid self model data")
+	}
+
+	export := &models.SelfModelExport{
+		SchemaVersion:       models.SelfModelExportSchemaVersion,
+		ExportedAtMillisUTC: time.Now().UnixMilli(),
+		SelfModel:           selfModel,
+		Beliefs:             []*models.Belief{},
+		Dialectics:          []*models.Dialectic{},
+		Philosophies:        []*models.Philosophy{},
+	}
+
+	if storedBeliefSystem, err := s.kvStore.Retrieve(input.SelfModelID, "BeliefSystem"); err == nil {
+		beliefSystem, ok := storedBeliefSystem.(*models.BeliefSystem)
+		if !ok {
+			return nil, fmt.Errorf("invalid belief system data")
+		}
+		export.BeliefSystem = beliefSystem
+	}
+	// The self model's copy of the belief system and dialectics is superseded by the exported records
+	selfModel.BeliefSystem = nil
+	selfModel.Dialectics = nil
+
+	// Export every belief, including inactive ones, so the self model can be restored as it was
+	beliefs, err := s.kvStore.ListByType(input.SelfModelID, reflect.TypeOf(models.Belief{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve beliefs: %v", err)
+	}
+	for _, b := range beliefs {
+		if belief, ok := b.(*models.Belief); ok {
+			export.Beliefs = append(export.Beliefs, belief)
+		}
+	}
+
+	dialectics, err := s.kvStore.ListByType(input.SelfModelID, reflect.TypeOf(models.Dialectic{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve dialectics: %v", err)
+	}
+	for _, d := range dialectics {
+		if dialectic, ok := d.(*models.Dialectic); ok {
+			export.Dialectics = append(export.Dialectics, dialectic)
+		}
+	}
+
+	// Philosophies are stored under their own ID rather than the self model's; ones that no longer exist are skipped
+	for _, philosophyID := range selfModel.Philosophies {
+		storedPhilosophy, err := s.kvStore.Retrieve(philosophyID, "Philosophy")
+		if err != nil {
+			continue
+		}
+		if philosophy, ok := storedPhilosophy.(*models.Philosophy); ok {
+			export.Philosophies = append(export.Philosophies, philosophy)
+		}
+	}
+
+	return &models.ExportSelfModelOutput{Export: export}, nil
+}
+
+// ImportSelfModel restores a self model from a document produced by ExportSelfModel. Beliefs, dialectics,
+// philosophies and observation contexts get fresh IDs so the import never collides with existing records,
+// and every reference between them is rewritten to match.
+func (s *SelfModelService) ImportSelfModel(ctx context.Context, input *models.ImportSelfModelInput) (*models.ImportSelfModelOutput, error) {
+	var export models.SelfModelExport
+	if err := json.Unmarshal(input.Document, &export); err != nil {
+		return nil, fmt.Errorf("%w: invalid document: %v", ErrIncompatibleExport, err)
+	}
+	if export.SchemaVersion < 1 || export.SchemaVersion > models.SelfModelExportSchemaVersion {
+		return nil, fmt.Errorf("%w: schema version %d is not supported, expected 1 to %d",
+			ErrIncompatibleExport, export.SchemaVersion, models.SelfModelExportSchemaVersion)
+	}
+	if export.SelfModel == nil {
+		return nil, fmt.Errorf("%w: document has no self model", ErrIncompatibleExport)
+	}
+
+	selfModelID := input.SelfModelID
+	if selfModelID == "" {
+		selfModelID = export.SelfModel.ID
+	}
+	if selfModelID == "" {
+		return nil, fmt.Errorf("self model ID cannot be empty")
+	}
+	if _, err := s.kvStore.Retrieve(selfModelID, "SelfModel"); err == nil {
+		return nil, fmt.Errorf("self model %s already exists", selfModelID)
+	}
+
+	ids := newImportIDMap()
+
+	// Philosophies are stored under their own ID
+	philosophyIDs := make([]string, 0, len(export.SelfModel.Philosophies))
+	for _, philosophy := range export.Philosophies {
+		if philosophy == nil {
+			continue
+		}
+		newID := ids.remap(philosophy.ID, uuid.New().String())
+		philosophy.ID = newID
+		err := s.kvStore.ForceStore(newID, "Philosophy", *philosophy, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store philosophy: %v", err)
+		}
+	}
+	for _, philosophyID := range export.SelfModel.Philosophies {
+		philosophyIDs = append(philosophyIDs, ids.get(philosophyID))
+	}
+
+	for _, belief := range export.Beliefs {
+		if belief == nil {
+			continue
+		}
+		belief.ID = ids.remap(belief.ID, "bi_"+uuid.New().String())
+		belief.SelfModelID = selfModelID
+		err := s.kvStore.Store(selfModelID, belief.ID, *belief, int(belief.Version))
+		if err != nil {
+			return nil, fmt.Errorf("failed to store belief: %v", err)
+		}
+	}
+
+	beliefSystem := export.BeliefSystem
+	if beliefSystem == nil {
+		beliefSystem = &models.BeliefSystem{}
+	}
+	remapBeliefSystem(beliefSystem, ids, selfModelID)
+	beliefSystem.Version = beliefSystemVersion
+	err := s.kvStore.ForceStore(selfModelID, "BeliefSystem", *beliefSystem, beliefSystemVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store belief system: %v", err)
+	}
+
+	for _, dialectic := range export.Dialectics {
+		if dialectic == nil {
+			continue
+		}
+		dialectic.ID = ids.remap(dialectic.ID, "di_"+uuid.New().String())
+		dialectic.SelfModelID = selfModelID
+		for _, interaction := range dialectic.UserInteractions {
+			if qa := getQuestionAnswer(interaction.Interaction); qa != nil {
+				remapBeliefs(qa.ExtractedBeliefs, ids, selfModelID)
+			}
+		}
+		if dialectic.Version == 0 {
+			dialectic.Version = 1
+		}
+		err := s.kvStore.Store(selfModelID, dialectic.ID, *dialectic, int(dialectic.Version))
+		if err != nil {
+			return nil, fmt.Errorf("failed to store dialectic: %v", err)
+		}
+	}
+
+	selfModel := export.SelfModel
+	selfModel.ID = selfModelID
+	selfModel.Philosophies = philosophyIDs
+	selfModel.BeliefSystem = beliefSystem
+	selfModel.Dialectics = nil
+	err = s.kvStore.ForceStore(selfModelID, "SelfModel", *selfModel, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store self model: %v", err)
+	}
+
+	return &models.ImportSelfModelOutput{SelfModel: selfModel}, nil
+}
+
+// importIDMap tracks the IDs assigned to imported records, keyed by their exported ID
+type importIDMap map[string]string
+
+func newImportIDMap() importIDMap {
+	return make(importIDMap)
+}
+
+// remap records newID for oldID, reusing the ID already assigned if oldID was seen before
+func (m importIDMap) remap(oldID, newID string) string {
+	if existing, ok := m[oldID]; ok {
+		return existing
+	}
+	m[oldID] = newID
+	return newID
+}
+
+// get returns the ID assigned to oldID, or oldID itself for records that were not remapped
+func (m importIDMap) get(oldID string) string {
+	if newID, ok := m[oldID]; ok {
+		return newID
+	}
+	return oldID
+}
+
+func remapBeliefs(beliefs []*models.Belief, ids importIDMap, selfModelID string) {
+	for _, belief := range beliefs {
+		if belief == nil {
+			continue
+		}
+		belief.ID = ids.get(belief.ID)
+		belief.SelfModelID = selfModelID
+	}
+}
+
+// remapBeliefSystem rewrites the belief and observation context references in a belief system.
+// Observation contexts are assigned new IDs first so parents and belief contexts can point at them.
+func remapBeliefSystem(beliefSystem *models.BeliefSystem, ids importIDMap, selfModelID string) {
+	remapBeliefs(beliefSystem.Beliefs, ids, selfModelID)
+
+	for _, ec := range beliefSystem.EpistemicContexts {
+		if ec == nil || ec.PredictiveProcessingContext == nil {
+			continue
+		}
+		for _, oc := range ec.PredictiveProcessingContext.ObservationContexts {
+			if oc != nil {
+				oc.ID = ids.remap(oc.ID, uuid.New().String())
+			}
+		}
+	}
+
+	for _, ec := range beliefSystem.EpistemicContexts {
+		if ec == nil {
+			continue
+		}
+		for i, beliefID := range ec.AssociatedBeleifs {
+			ec.AssociatedBeleifs[i] = ids.get(beliefID)
+		}
+
+		ppc := ec.PredictiveProcessingContext
+		if ppc == nil {
+			continue
+		}
+		for _, oc := range ppc.ObservationContexts {
+			if oc != nil && oc.ParentID != "" {
+				oc.ParentID = ids.get(oc.ParentID)
+			}
+		}
+		for _, bc := range ppc.BeliefContexts {
+			if bc == nil {
+				continue
+			}
+			bc.BeliefID = ids.get(bc.BeliefID)
+			bc.ObservationContextID = ids.get(bc.ObservationContextID)
+		}
+	}
+}
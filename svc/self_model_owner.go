@@ -0,0 +1,57 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc/models"
+)
+
+const selfModelOwnerKey = "SelfModelOwner"
+
+// storeSelfModelOwner records that selfModelID belongs to a user of developerID.
+func storeSelfModelOwner(kvStore db.KeyValueStore, selfModelID, developerID string) error {
+	owner := models.SelfModelOwner{SelfModelID: selfModelID, DeveloperID: developerID}
+	return kvStore.ForceStore(selfModelID, selfModelOwnerKey, owner, 1)
+}
+
+// selfModelDeveloper returns the developer whose user selfModelID belongs to, or "" when it belongs
+// to no user.
+func selfModelDeveloper(kvStore db.KeyValueStore, selfModelID string) (string, error) {
+	value, err := kvStore.Retrieve(selfModelID, selfModelOwnerKey)
+	if errors.Is(err, db.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	owner, ok := value.(*models.SelfModelOwner)
+	if !ok {
+		return "", fmt.Errorf("invalid self model owner data type: %T", value)
+	}
+	return owner.DeveloperID, nil
+}
+
+// indexSelfModelOwners records the owner of the self model of every stored user that lacks one,
+// such as users created before owners were recorded.
+func indexSelfModelOwners(kvStore db.KeyValueStore) error {
+	users, err := kvStore.ListAllByType(reflect.TypeOf(models.User{}))
+	if err != nil {
+		return err
+	}
+	for _, obj := range users {
+		user, ok := obj.(*models.User)
+		if !ok || user.DeveloperID == "" {
+			continue
+		}
+		if developerID, err := selfModelDeveloper(kvStore, user.ID); err != nil || developerID != "" {
+			continue
+		}
+		if err := storeSelfModelOwner(kvStore, user.ID, user.DeveloperID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
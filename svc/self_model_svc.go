@@ -2,25 +2,41 @@ package svc
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"reflect"
 	"regexp"
+	"sort"
+	"strings"
 	"sync"
 
+	ai "epistemic-me-core/ai"
 	"epistemic-me-core/db"
+	"epistemic-me-core/logging"
 	"epistemic-me-core/svc/models"
 
 	"github.com/google/uuid"
 )
 
 type SelfModelService struct {
-	kvStore *db.KeyValueStore
-	dsvc    *DialecticService
-	bsvc    *BeliefService
-	cache   map[string][]*models.ObservationContext
-	cacheMu sync.RWMutex
+	kvStore         db.KeyValueStore
+	dsvc            *DialecticService
+	bsvc            *BeliefService
+	answerGenerator AnswerGenerator
+	cache           map[string][]*models.ObservationContext
+	cacheMu         sync.RWMutex
+	// allowedCohorts, when not empty, is the set SetCohort accepts
+	allowedCohorts map[string]bool
 }
 
-func NewSelfModelService(kvStore *db.KeyValueStore, dsvc *DialecticService, bsvc *BeliefService) *SelfModelService {
+// AnswerGenerator answers a question in the voice of a belief system and its philosophies.
+// *ai.AIHelper is the production implementation.
+type AnswerGenerator interface {
+	GenerateAnswerFromBeliefSystem(question string, beliefSystem *models.BeliefSystem, philosophies []string) (string, error)
+}
+
+func NewSelfModelService(kvStore db.KeyValueStore, dsvc *DialecticService, bsvc *BeliefService) *SelfModelService {
 	return &SelfModelService{
 		kvStore: kvStore,
 		dsvc:    dsvc,
@@ -29,6 +45,11 @@ func NewSelfModelService(kvStore *db.KeyValueStore, dsvc *DialecticService, bsvc
 	}
 }
 
+// SetAnswerGenerator sets what GenerateAnswer uses to answer as a self model.
+func (s *SelfModelService) SetAnswerGenerator(generator AnswerGenerator) {
+	s.answerGenerator = generator
+}
+
 func (s *SelfModelService) CreateSelfModel(ctx context.Context, input *models.CreateSelfModelInput) (*models.CreateSelfModelOutput, error) {
 	if input.ID == "" {
 		return nil, fmt.Errorf("self model ID cannot be empty")
@@ -36,6 +57,7 @@ func (s *SelfModelService) CreateSelfModel(ctx context.Context, input *models.Cr
 
 	// Create an empty belief system for the self model
 	emptyBeliefSystem := &models.BeliefSystem{
+		Version: beliefSystemVersion,
 		Beliefs: []*models.Belief{},
 		EpistemicContexts: []*models.EpistemicContext{
 			{
@@ -48,7 +70,7 @@ func (s *SelfModelService) CreateSelfModel(ctx context.Context, input *models.Cr
 	}
 
 	// Store the belief system separately
-	err := s.kvStore.Store(input.ID, "BeliefSystem", *emptyBeliefSystem, 1)
+	err := s.kvStore.ForceStore(input.ID, "BeliefSystem", *emptyBeliefSystem, beliefSystemVersion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store belief system: %v", err)
 	}
@@ -61,7 +83,7 @@ func (s *SelfModelService) CreateSelfModel(ctx context.Context, input *models.Cr
 	}
 
 	// Store the self model
-	err = s.kvStore.Store(input.ID, "SelfModel", *selfModel, 1)
+	err = s.kvStore.ForceStore(input.ID, "SelfModel", *selfModel, 1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store self model: %v", err)
 	}
@@ -72,7 +94,7 @@ func (s *SelfModelService) CreateSelfModel(ctx context.Context, input *models.Cr
 func (s *SelfModelService) GetSelfModel(ctx context.Context, input *models.GetSelfModelInput) (*models.GetSelfModelOutput, error) {
 	storedSelfModel, err := s.kvStore.Retrieve(input.SelfModelID, "SelfModel")
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve self model: %v", err)
+		return nil, fmt.Errorf("failed to retrieve self model: %w", wrapNotFound(err, ErrSelfModelNotFound))
 	}
 
 	selfModel, ok := storedSelfModel.(*models.SelfModel)
@@ -83,7 +105,7 @@ func (s *SelfModelService) GetSelfModel(ctx context.Context, input *models.GetSe
 	// Retrieve the belief system separately
 	storedBeliefSystem, err := s.kvStore.Retrieve(input.SelfModelID, "BeliefSystem")
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve belief system: %v", err)
+		return nil, fmt.Errorf("failed to retrieve belief system: %w", wrapNotFound(err, ErrBeliefSystemNotFound))
 	}
 
 	beliefSystem, ok := storedBeliefSystem.(*models.BeliefSystem)
@@ -107,6 +129,116 @@ func (s *SelfModelService) GetSelfModel(ctx context.Context, input *models.GetSe
 	return &models.GetSelfModelOutput{SelfModel: selfModel}, nil
 }
 
+// GetSelfModelDetail returns a self model together with its belief system, the philosophies
+// attached to it and how many of its dialectics are still active, in a single call. Philosophies
+// that can no longer be found are left out.
+func (s *SelfModelService) GetSelfModelDetail(ctx context.Context, input *models.GetSelfModelDetailInput) (*models.GetSelfModelDetailOutput, error) {
+	selfModelOutput, err := s.GetSelfModel(ctx, &models.GetSelfModelInput{SelfModelID: input.SelfModelID})
+	if err != nil {
+		return nil, err
+	}
+	selfModel := selfModelOutput.SelfModel
+
+	output := &models.GetSelfModelDetailOutput{
+		DialecticCount: int32(len(selfModel.Dialectics)),
+	}
+	for _, dialectic := range selfModel.Dialectics {
+		interactions := dialectic.UserInteractions
+		if len(interactions) > 0 && interactions[len(interactions)-1].Status == models.StatusPendingAnswer {
+			output.ActiveDialecticCount++
+		}
+	}
+
+	if !input.ExcludeBeliefSystem {
+		beliefs, err := s.bsvc.ListBeliefs(&models.ListBeliefsInput{SelfModelID: input.SelfModelID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve beliefs: %w", err)
+		}
+		output.BeliefSystem = selfModel.BeliefSystem
+		output.BeliefSystem.Beliefs = beliefs.Beliefs
+	}
+
+	if !input.ExcludePhilosophies {
+		output.Philosophies = make([]*models.Philosophy, 0, len(selfModel.Philosophies))
+		for _, philosophyID := range selfModel.Philosophies {
+			philosophy, err := s.GetPhilosophy(ctx, &models.GetPhilosophyInput{PhilosophyID: philosophyID})
+			if err != nil {
+				logging.Warnf(ctx, "Skipping philosophy %s of self model %s: %v", philosophyID, input.SelfModelID, err)
+				continue
+			}
+			output.Philosophies = append(output.Philosophies, philosophy.Philosophy)
+		}
+	}
+
+	selfModel.BeliefSystem = nil
+	selfModel.Dialectics = nil
+	output.SelfModel = selfModel
+	return output, nil
+}
+
+// GenerateAnswer answers a question as the self model would, from its belief system and the
+// descriptions of its philosophies. This lets a synthetic dialectic run with the model standing in
+// for the user.
+func (s *SelfModelService) GenerateAnswer(ctx context.Context, input *models.GenerateAnswerInput) (*models.GenerateAnswerOutput, error) {
+	if input.Question == "" {
+		return nil, fmt.Errorf("question cannot be empty")
+	}
+	if s.answerGenerator == nil {
+		return nil, fmt.Errorf("answer generation is not configured")
+	}
+
+	storedSelfModel, err := s.kvStore.Retrieve(input.SelfModelID, "SelfModel")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve self model: %w", wrapNotFound(err, ErrSelfModelNotFound))
+	}
+	selfModel, ok := storedSelfModel.(*models.SelfModel)
+	if !ok {
+		return nil, fmt.Errorf("invalid self model data")
+	}
+
+	storedBeliefSystem, err := s.kvStore.Retrieve(input.SelfModelID, "BeliefSystem")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve belief system: %w", wrapNotFound(err, ErrBeliefSystemNotFound))
+	}
+	beliefSystem, ok := storedBeliefSystem.(*models.BeliefSystem)
+	if !ok {
+		return nil, fmt.Errorf("invalid belief system data")
+	}
+
+	var philosophies []string
+	if !input.OverridePhilosophies {
+		philosophies = philosophyDescriptions(s.kvStore, selfModel.Philosophies)
+	}
+	philosophies = append(philosophies, input.AdditionalPhilosophies...)
+
+	// ctx carries the helper of the developer's own OpenAI key, if they stored one
+	generator := s.answerGenerator
+	if aih, ok := generator.(*ai.AIHelper); ok {
+		generator = aih.WithContext(ctx)
+	}
+	answer, err := generator.GenerateAnswerFromBeliefSystem(input.Question, beliefSystem, philosophies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate answer: %w", err)
+	}
+	return &models.GenerateAnswerOutput{Answer: answer}, nil
+}
+
+// philosophyDescriptions returns the descriptions of the given philosophies. Philosophies are
+// stored under their own ID; ones that no longer exist are skipped.
+func philosophyDescriptions(kvStore db.KeyValueStore, philosophyIDs []string) []string {
+	var descriptions []string
+	for _, philosophyID := range philosophyIDs {
+		stored, err := kvStore.Retrieve(philosophyID, "Philosophy")
+		if err != nil {
+			continue
+		}
+		if philosophy, ok := stored.(*models.Philosophy); ok {
+			descriptions = append(descriptions, philosophy.Description)
+		}
+	}
+	return descriptions
+}
+
 func (s *SelfModelService) AddPhilosophy(ctx context.Context, input *models.AddPhilosophyInput) (*models.AddPhilosophyOutput, error) {
 	storedSelfModel, err := s.kvStore.Retrieve(input.SelfModelID, "SelfModel")
 	if err != nil {
@@ -120,7 +252,7 @@ func (s *SelfModelService) AddPhilosophy(ctx context.Context, input *models.AddP
 
 	selfModel.Philosophies = append(selfModel.Philosophies, input.PhilosophyID)
 
-	err = s.kvStore.Store(input.SelfModelID, "SelfModel", *selfModel, 1)
+	err = s.kvStore.ForceStore(input.SelfModelID, "SelfModel", *selfModel, 1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update self model: %v", err)
 	}
@@ -148,39 +280,78 @@ func extrapolateObservationContexts(description string) []*models.ObservationCon
 	return contexts
 }
 
+// validateContextTags reports malformed [[C: ...]] and [[S: ...]] tags in a philosophy description.
+// The warnings are advisory: extrapolation simply skips tags it cannot parse.
+func validateContextTags(description string) []string {
+	var warnings []string
+	seenContext := false
+	for i, line := range strings.Split(description, "\n") {
+		lineNo := i + 1
+		rest := line
+		for {
+			open := strings.Index(rest, "[[")
+			if open < 0 {
+				break
+			}
+			rest = rest[open+2:]
+			end := strings.Index(rest, "]]")
+			if next := strings.Index(rest, "[["); end < 0 || (next >= 0 && next < end) {
+				snippet := rest
+				if next >= 0 {
+					snippet = rest[:next]
+				}
+				warnings = append(warnings, fmt.Sprintf("line %d: unclosed tag %q", lineNo, "[["+strings.TrimSpace(snippet)))
+				continue
+			}
+			tag := rest[:end]
+			rest = rest[end+2:]
+
+			kind, name, ok := strings.Cut(tag, ":")
+			kind = strings.TrimSpace(kind)
+			if !ok || (kind != "C" && kind != "S") {
+				warnings = append(warnings, fmt.Sprintf("line %d: unrecognized tag %q", lineNo, "[["+tag+"]]"))
+				continue
+			}
+			name = strings.TrimSpace(name)
+			if name == "" {
+				warnings = append(warnings, fmt.Sprintf("line %d: empty %s tag name", lineNo, kind))
+				continue
+			}
+			if kind == "C" {
+				seenContext = true
+			} else if !seenContext {
+				warnings = append(warnings, fmt.Sprintf("line %d: state %q has no parent context", lineNo, name))
+			}
+		}
+	}
+	return warnings
+}
+
 func (s *SelfModelService) CreatePhilosophy(ctx context.Context, input *models.CreatePhilosophyInput) (*models.CreatePhilosophyOutput, error) {
 	philosophy := &models.Philosophy{
 		ID:                  uuid.New().String(),
 		Description:         input.Description,
 		ExtrapolateContexts: input.ExtrapolateContexts,
 	}
+	refreshPhilosophyContexts(philosophy)
 
-	err := s.kvStore.Store(philosophy.ID, "Philosophy", *philosophy, 1)
+	err := s.kvStore.ForceStore(philosophy.ID, "Philosophy", *philosophy, 1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store philosophy: %v", err)
 	}
 
-	var extrapolated []*models.ObservationContext
-	if input.ExtrapolateContexts {
-		s.cacheMu.RLock()
-		cached, ok := s.cache[philosophy.ID]
-		s.cacheMu.RUnlock()
-		if ok {
-			extrapolated = cached
-		} else {
-			extrapolated = extrapolateObservationContexts(input.Description)
-			s.cacheMu.Lock()
-			s.cache[philosophy.ID] = extrapolated
-			s.cacheMu.Unlock()
-		}
-	}
+	s.cachePhilosophyContexts(philosophy)
 
-	return &models.CreatePhilosophyOutput{Philosophy: philosophy, ExtrapolatedObservationContexts: extrapolated}, nil
+	return &models.CreatePhilosophyOutput{
+		Philosophy:                      philosophy,
+		ExtrapolatedObservationContexts: philosophy.ExtrapolatedContexts,
+		Warnings:                        validateContextTags(input.Description),
+	}, nil
 }
 
 // Add this method to update the belief system of a self model
 func (s *SelfModelService) UpdateSelfModelBeliefSystem(ctx context.Context, selfModelID string, beliefSystem *models.BeliefSystem) error {
-	err := s.kvStore.Store(selfModelID, "BeliefSystem", *beliefSystem, 1)
+	err := storeBeliefSystem(s.kvStore, nil, selfModelID, beliefSystem)
 	if err != nil {
 		return fmt.Errorf("failed to update belief system: %v", err)
 	}
@@ -205,26 +376,128 @@ func (s *SelfModelService) UpdatePhilosophy(ctx context.Context, input *models.U
 
 	philosophy.Description = input.Description
 	philosophy.ExtrapolateContexts = input.ExtrapolateContexts
+	// Contexts are only re-parsed when the description actually changed
+	refreshPhilosophyContexts(philosophy)
 
-	err = s.kvStore.Store(philosophy.ID, "Philosophy", *philosophy, 1)
+	err = s.kvStore.ForceStore(philosophy.ID, "Philosophy", *philosophy, 1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store updated philosophy: %v", err)
 	}
 
-	// Invalidate cache on update
+	s.cachePhilosophyContexts(philosophy)
+
+	return &models.UpdatePhilosophyOutput{
+		Philosophy:                      philosophy,
+		ExtrapolatedObservationContexts: philosophy.ExtrapolatedContexts,
+		Warnings:                        validateContextTags(input.Description),
+	}, nil
+}
+
+// GetPhilosophy retrieves a stored philosophy along with its cached extrapolated contexts.
+func (s *SelfModelService) GetPhilosophy(ctx context.Context, input *models.GetPhilosophyInput) (*models.GetPhilosophyOutput, error) {
+	if input.PhilosophyID == "" {
+		return nil, fmt.Errorf("philosophy ID cannot be empty")
+	}
+
+	stored, err := s.kvStore.Retrieve(input.PhilosophyID, "Philosophy")
+	if err != nil {
+		return nil, fmt.Errorf("philosophy %s not found: %v", input.PhilosophyID, err)
+	}
+
+	philosophy, ok := stored.(*models.Philosophy)
+	if !ok {
+		return nil, fmt.Errorf("invalid philosophy data")
+	}
+
+	contexts, err := s.philosophyContexts(philosophy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GetPhilosophyOutput{
+		Philosophy:                      philosophy,
+		ExtrapolatedObservationContexts: contexts,
+	}, nil
+}
+
+// ListPhilosophies lists every stored philosophy, optionally filtered by its ExtrapolateContexts flag.
+func (s *SelfModelService) ListPhilosophies(ctx context.Context, input *models.ListPhilosophiesInput) (*models.ListPhilosophiesOutput, error) {
+	stored, err := s.kvStore.ListAllByType(reflect.TypeOf(models.Philosophy{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list philosophies: %v", err)
+	}
+
+	summaries := make([]*models.PhilosophySummary, 0, len(stored))
+	for _, obj := range stored {
+		philosophy, ok := obj.(*models.Philosophy)
+		if !ok {
+			continue
+		}
+		if input.ExtrapolateContexts != nil && philosophy.ExtrapolateContexts != *input.ExtrapolateContexts {
+			continue
+		}
+		contexts, err := s.philosophyContexts(philosophy)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, &models.PhilosophySummary{
+			ID:                       philosophy.ID,
+			Description:              philosophy.Description,
+			ExtrapolateContexts:      philosophy.ExtrapolateContexts,
+			ExtrapolatedContextCount: int32(len(contexts)),
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ID < summaries[j].ID })
+
+	return &models.ListPhilosophiesOutput{Philosophies: summaries}, nil
+}
+
+// philosophyContexts returns the extrapolated contexts of a stored philosophy. Records written before
+// contexts were persisted, or whose description no longer matches its hash, are re-parsed and saved.
+func (s *SelfModelService) philosophyContexts(philosophy *models.Philosophy) ([]*models.ObservationContext, error) {
+	if refreshPhilosophyContexts(philosophy) {
+		err := s.kvStore.ForceStore(philosophy.ID, "Philosophy", *philosophy, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store philosophy contexts: %v", err)
+		}
+	}
+	s.cachePhilosophyContexts(philosophy)
+	return philosophy.ExtrapolatedContexts, nil
+}
+
+// cachePhilosophyContexts mirrors a philosophy's persisted contexts into the in-memory cache.
+func (s *SelfModelService) cachePhilosophyContexts(philosophy *models.Philosophy) {
 	s.cacheMu.Lock()
-	delete(s.cache, philosophy.ID)
-	s.cacheMu.Unlock()
+	defer s.cacheMu.Unlock()
+	if philosophy.ExtrapolateContexts {
+		s.cache[philosophy.ID] = philosophy.ExtrapolatedContexts
+	} else {
+		delete(s.cache, philosophy.ID)
+	}
+}
 
-	var extrapolated []*models.ObservationContext
-	if input.ExtrapolateContexts {
-		extrapolated = extrapolateObservationContexts(input.Description)
-		s.cacheMu.Lock()
-		s.cache[philosophy.ID] = extrapolated
-		s.cacheMu.Unlock()
+// refreshPhilosophyContexts brings a philosophy's persisted contexts in line with its description and
+// ExtrapolateContexts flag, reporting whether anything changed.
+func refreshPhilosophyContexts(philosophy *models.Philosophy) bool {
+	if !philosophy.ExtrapolateContexts {
+		changed := philosophy.DescriptionHash != "" || philosophy.ExtrapolatedContexts != nil
+		philosophy.ExtrapolatedContexts = nil
+		philosophy.DescriptionHash = ""
+		return changed
 	}
 
-	return &models.UpdatePhilosophyOutput{Philosophy: philosophy, ExtrapolatedObservationContexts: extrapolated}, nil
+	hash := descriptionHash(philosophy.Description)
+	if philosophy.DescriptionHash == hash {
+		return false
+	}
+	philosophy.ExtrapolatedContexts = extrapolateObservationContexts(philosophy.Description)
+	philosophy.DescriptionHash = hash
+	return true
+}
+
+func descriptionHash(description string) string {
+	sum := sha256.Sum256([]byte(description))
+	return hex.EncodeToString(sum[:])
 }
 
 func (s *SelfModelService) Cache() map[string][]*models.ObservationContext {
@@ -4,17 +4,22 @@ import (
 	ai "epistemic-me-core/ai"
 	db "epistemic-me-core/db"
 	"epistemic-me-core/svc/models"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type UserService struct {
-	kvStore *db.KeyValueStore
+	kvStore db.KeyValueStore
 	ai      *ai.AIHelper
 }
 
-func NewUserService(kvStore *db.KeyValueStore, ai *ai.AIHelper) *UserService {
+func NewUserService(kvStore db.KeyValueStore, ai *ai.AIHelper) *UserService {
 	return &UserService{
 		kvStore: kvStore,
 		ai:      ai,
@@ -31,14 +36,85 @@ func (s *UserService) CreateUser(input *models.CreateUserInput) (*models.CreateU
 		UpdatedAt:   time.Now().UnixMilli(),
 	}
 
+	if err := claimEmail(s.kvStore, input.DeveloperID, user.Email, user.ID); err != nil {
+		return nil, err
+	}
 	err := s.kvStore.Store(input.DeveloperID, "user_"+user.ID, user, 1)
 	if err != nil {
+		releaseEmail(s.kvStore, input.DeveloperID, user.Email)
 		return nil, err
 	}
+	// The user's self model shares the user's ID
+	if err := storeSelfModelOwner(s.kvStore, user.ID, input.DeveloperID); err != nil {
+		_ = s.kvStore.Delete(input.DeveloperID, "user_"+user.ID)
+		releaseEmail(s.kvStore, input.DeveloperID, user.Email)
+		return nil, fmt.Errorf("failed to store self model owner: %w", err)
+	}
 
 	return &models.CreateUserOutput{
 		User: user,
 	}, nil
 }
 
+// DefaultUserPageSize and MaxUserPageSize bound the pages returned by ListUsers.
+const (
+	DefaultUserPageSize = 50
+	MaxUserPageSize     = 500
+)
+
+// ListUsers returns a page of the users created by a developer, oldest first. The page token is the
+// offset of the next page.
+func (s *UserService) ListUsers(input *models.ListUsersInput) (*models.ListUsersOutput, error) {
+	if input.DeveloperID == "" {
+		return nil, fmt.Errorf("developer ID is required")
+	}
+
+	offset := 0
+	if input.PageToken != "" {
+		parsed, err := strconv.Atoi(input.PageToken)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPageToken, input.PageToken)
+		}
+		offset = parsed
+	}
+	pageSize := input.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultUserPageSize
+	}
+	if pageSize > MaxUserPageSize {
+		pageSize = MaxUserPageSize
+	}
+
+	// Users are stored under their developer, so the type index holds exactly the developer's users
+	stored, err := s.kvStore.ListByType(input.DeveloperID, reflect.TypeOf(models.User{}))
+	if err != nil && !errors.Is(err, db.ErrDeveloperNotFound) {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	users := make([]models.User, 0, len(stored))
+	for _, obj := range stored {
+		if user, ok := obj.(*models.User); ok && user.DeveloperID == input.DeveloperID {
+			users = append(users, *user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].CreatedAt != users[j].CreatedAt {
+			return users[i].CreatedAt < users[j].CreatedAt
+		}
+		return users[i].ID < users[j].ID
+	})
+
+	output := &models.ListUsersOutput{Users: []models.User{}}
+	if offset >= len(users) {
+		return output, nil
+	}
+	end := offset + pageSize
+	if end < len(users) {
+		output.NextPageToken = strconv.Itoa(end)
+	} else {
+		end = len(users)
+	}
+	output.Users = users[offset:end]
+	return output, nil
+}
+
 // Add other methods as needed (e.g., GetUser, UpdateUser, DeleteUser)
@@ -0,0 +1,329 @@
+package svc
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	db "epistemic-me-core/db"
+	"epistemic-me-core/logging"
+	"epistemic-me-core/svc/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// WebhookSignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the request body,
+	// keyed with the developer's webhook secret.
+	WebhookSignatureHeader = "X-Epistemic-Me-Signature"
+	// WebhookEventHeader carries the event type and WebhookDeliveryHeader the event ID, which stays
+	// the same across retries.
+	WebhookEventHeader    = "X-Epistemic-Me-Event"
+	WebhookDeliveryHeader = "X-Epistemic-Me-Delivery"
+
+	// webhookQueueSize is how many events can wait for delivery before new ones are dead-lettered.
+	webhookQueueSize = 256
+	// webhookWorkers is how many deliveries are attempted at once.
+	webhookWorkers = 4
+	// maxWebhookDeadLetters is how many undelivered events are kept for inspection, oldest dropped first.
+	maxWebhookDeadLetters = 100
+)
+
+// WebhookRetryPolicy controls how often a failed delivery is retried. The wait before each retry
+// doubles, starting at Backoff.
+type WebhookRetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// DefaultWebhookRetryPolicy tries each delivery five times over about fifteen seconds.
+var DefaultWebhookRetryPolicy = WebhookRetryPolicy{MaxAttempts: 5, Backoff: time.Second}
+
+// BeliefSystemObserver is told about every change persisted to a belief system.
+type BeliefSystemObserver interface {
+	BeliefSystemChanged(selfModelID string, diff models.BeliefSystemDiff)
+}
+
+type webhookDelivery struct {
+	selfModelID string
+	diff        models.BeliefSystemDiff
+	createdAt   time.Time
+}
+
+// WebhookDispatcher posts belief system changes to the webhook registered by the developer owning
+// the self model. Deliveries happen in the background, are retried on failure and end up in the
+// dead-letter log once every attempt has failed.
+type WebhookDispatcher struct {
+	kvStore db.KeyValueStore
+	client  *http.Client
+	retry   WebhookRetryPolicy
+
+	// allowPrivateHosts lets deliveries connect to loopback, link-local and private addresses
+	allowPrivateHosts atomic.Bool
+
+	queue    chan webhookDelivery
+	stop     chan struct{}
+	stopOnce sync.Once
+	workers  sync.WaitGroup
+	// stopMu keeps events from being queued while the dispatcher stops, once nothing reads the queue
+	stopMu sync.RWMutex
+
+	mu          sync.Mutex
+	deadLetters []models.WebhookDeadLetter
+}
+
+// StartWebhookDispatcher starts the goroutines delivering webhook events. The returned dispatcher
+// must be stopped to release them. Users stored before self model owners were recorded get their
+// owner recorded first, so their events reach their developer's webhook.
+func StartWebhookDispatcher(kvStore db.KeyValueStore, retry WebhookRetryPolicy) *WebhookDispatcher {
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+	if err := indexSelfModelOwners(kvStore); err != nil {
+		logging.Errorf(context.Background(), "Failed to record self model owners: %v", err)
+	}
+	d := &WebhookDispatcher{
+		kvStore: kvStore,
+		retry:   retry,
+		queue:   make(chan webhookDelivery, webhookQueueSize),
+		stop:    make(chan struct{}),
+	}
+	d.client = d.newClient()
+	for i := 0; i < webhookWorkers; i++ {
+		d.workers.Add(1)
+		go d.work()
+	}
+	return d
+}
+
+// SetAllowPrivateHosts sets whether deliveries may connect to loopback, link-local and private
+// addresses. They are refused by default, like at registration.
+func (d *WebhookDispatcher) SetAllowPrivateHosts(allow bool) {
+	d.allowPrivateHosts.Store(allow)
+}
+
+// newClient returns the client deliveries are posted with. The address is checked when connecting,
+// after the host has been resolved, since a host checked at registration can later resolve
+// elsewhere. Redirects are not followed, so a webhook cannot send a delivery on to another host.
+func (d *WebhookDispatcher) newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if d.allowPrivateHosts.Load() {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateAddress(ip) {
+				return fmt.Errorf("%w: %s", ErrWebhookHostNotAllowed, address)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        webhookWorkers,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Stop stops the dispatcher. Events already queued are still attempted once, without retries,
+// and dead-lettered if that fails; events arriving after Stop are dead-lettered straight away. It
+// is safe to call more than once.
+func (d *WebhookDispatcher) Stop() {
+	d.stopOnce.Do(func() {
+		d.stopMu.Lock()
+		close(d.stop)
+		d.stopMu.Unlock()
+	})
+	d.workers.Wait()
+}
+
+// BeliefSystemChanged queues the change for delivery without waiting for it. When the queue is full
+// or the dispatcher has stopped, the event is dead-lettered straight away rather than blocking the
+// caller.
+func (d *WebhookDispatcher) BeliefSystemChanged(selfModelID string, diff models.BeliefSystemDiff) {
+	// The caller keeps using the beliefs in diff, so the delivery gets its own copy
+	var copied models.BeliefSystemDiff
+	if data, err := json.Marshal(diff); err == nil && json.Unmarshal(data, &copied) == nil {
+		diff = copied
+	}
+	undelivered := func(reason string) {
+		d.deadLetter(models.WebhookDeadLetter{
+			Event: models.WebhookEvent{Type: models.WebhookEventBeliefSystemChanged, SelfModelID: selfModelID, Diff: diff},
+			Error: reason,
+		})
+	}
+
+	d.stopMu.RLock()
+	defer d.stopMu.RUnlock()
+	select {
+	case <-d.stop:
+		undelivered("webhook dispatcher is stopped")
+		return
+	default:
+	}
+
+	delivery := webhookDelivery{selfModelID: selfModelID, diff: diff, createdAt: time.Now()}
+	select {
+	case d.queue <- delivery:
+	default:
+		undelivered("webhook queue is full")
+	}
+}
+
+// DeadLetters returns the most recent events that could not be delivered, oldest first.
+func (d *WebhookDispatcher) DeadLetters() []models.WebhookDeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]models.WebhookDeadLetter{}, d.deadLetters...)
+}
+
+func (d *WebhookDispatcher) work() {
+	defer d.workers.Done()
+	for {
+		select {
+		case <-d.stop:
+			// Attempt what was queued before stopping; deliver does not retry once stopped
+			for {
+				select {
+				case delivery := <-d.queue:
+					d.deliver(delivery)
+				default:
+					return
+				}
+			}
+		case delivery := <-d.queue:
+			d.deliver(delivery)
+		}
+	}
+}
+
+func (d *WebhookDispatcher) deliver(delivery webhookDelivery) {
+	webhook, err := d.webhookForSelfModel(delivery.selfModelID)
+	if err != nil {
+		logging.Errorf(context.Background(), "Failed to look up the webhook for self model %s: %v", delivery.selfModelID, err)
+		return
+	}
+	if webhook == nil {
+		return
+	}
+
+	event := models.WebhookEvent{
+		ID:                 "evt_" + uuid.New().String(),
+		Type:               models.WebhookEventBeliefSystemChanged,
+		DeveloperID:        webhook.DeveloperID,
+		SelfModelID:        delivery.selfModelID,
+		CreatedAtMillisUTC: delivery.createdAt.UnixMilli(),
+		Diff:               delivery.diff,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		d.deadLetter(models.WebhookDeadLetter{Event: event, URL: webhook.URL, Error: err.Error()})
+		return
+	}
+
+	backoff := d.retry.Backoff
+	for attempt := 1; ; attempt++ {
+		err = d.post(webhook, event, body)
+		if err == nil {
+			return
+		}
+		if attempt == d.retry.MaxAttempts {
+			d.deadLetter(models.WebhookDeadLetter{Event: event, URL: webhook.URL, Attempts: attempt, Error: err.Error()})
+			return
+		}
+		logging.Warnf(context.Background(), "Webhook delivery %s to %s failed on attempt %d: %v", event.ID, webhook.URL, attempt, err)
+		select {
+		case <-d.stop:
+			d.deadLetter(models.WebhookDeadLetter{Event: event, URL: webhook.URL, Attempts: attempt, Error: "dispatcher stopped before retrying: " + err.Error()})
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (d *WebhookDispatcher) post(webhook *models.Webhook, event models.WebhookEvent, body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(WebhookEventHeader, event.Type)
+	request.Header.Set(WebhookDeliveryHeader, event.ID)
+	request.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (d *WebhookDispatcher) deadLetter(letter models.WebhookDeadLetter) {
+	logging.Errorf(context.Background(), "Dead-lettered webhook event %s for self model %s after %d attempts: %s",
+		letter.Event.ID, letter.Event.SelfModelID, letter.Attempts, letter.Error)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deadLetters = append(d.deadLetters, letter)
+	if len(d.deadLetters) > maxWebhookDeadLetters {
+		d.deadLetters = d.deadLetters[len(d.deadLetters)-maxWebhookDeadLetters:]
+	}
+}
+
+// webhookForSelfModel returns the webhook of the developer owning the user behind a self model, or
+// nil when there is none.
+func (d *WebhookDispatcher) webhookForSelfModel(selfModelID string) (*models.Webhook, error) {
+	developerID, err := selfModelDeveloper(d.kvStore, selfModelID)
+	if err != nil {
+		return nil, err
+	}
+	if developerID == "" {
+		return nil, nil
+	}
+
+	value, err := d.kvStore.Retrieve(developerID, "webhook")
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	webhook, ok := value.(*models.Webhook)
+	if !ok {
+		return nil, fmt.Errorf("invalid webhook data type: %T", value)
+	}
+	return webhook, nil
+}
+
+// SignWebhookPayload returns the WebhookSignatureHeader value for body.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,91 @@
+package ai_tests
+
+import (
+	"strings"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dietQuestion is the question the fake AI asks.
+const dietQuestion = "How did your diet change over the last year?"
+
+func TestGenerateQuestionForLearningObjective_UsesProvidedProgress(t *testing.T) {
+	client := fakeai.New(dietQuestion)
+	helper := ai.NewAIHelperWithClient(client)
+
+	objective := &models.LearningObjective{
+		Description: "Understand health habits",
+		Topics:      []string{"sleep", "diet"},
+	}
+	interactions := []models.DialecticalInteraction{
+		{ID: "di_1", Status: models.StatusAnswered},
+	}
+	progress := &models.LearningObjectiveProgress{
+		CompletionPercentage: 50,
+		TopicCoverage: map[string]*models.TopicCoverage{
+			"sleep": {Percentage: 80},
+			"diet":  {Percentage: 20, MissingCategories: []string{"experience-based"}},
+		},
+	}
+
+	question, err := helper.GenerateQuestionForLearningObjective(objective, interactions, progress)
+	require.NoError(t, err)
+	require.NotEmpty(t, question)
+
+	// The coverage already computed should be reused rather than requested again
+	requests := client.Requests()
+	require.Len(t, requests, 1)
+	prompt := requests[0].Messages[len(requests[0].Messages)-1].Content
+	require.True(t, strings.Contains(prompt, "focusing on the topic: diet"), "Prompt should target the least covered topic")
+	require.Contains(t, prompt, "experience-based")
+}
+
+func TestGenerateQuestionForLearningObjective_RoundRobinCyclesTopics(t *testing.T) {
+	client := fakeai.New(dietQuestion)
+	helper := ai.NewAIHelperWithClient(client)
+
+	objective := &models.LearningObjective{
+		Description:   "Understand health habits",
+		Topics:        []string{"sleep", "diet", "exercise"},
+		TopicStrategy: models.TopicStrategyRoundRobin,
+	}
+	// Sleep is by far the least covered, but round-robin still visits every topic in turn
+	progress := &models.LearningObjectiveProgress{
+		TopicCoverage: map[string]*models.TopicCoverage{
+			"sleep":    {Percentage: 5},
+			"diet":     {Percentage: 90},
+			"exercise": {Percentage: 90},
+		},
+	}
+
+	var interactions []models.DialecticalInteraction
+	var topics []string
+	for turn := 0; turn < 5; turn++ {
+		_, err := helper.GenerateQuestionForLearningObjective(objective, interactions, progress)
+		require.NoError(t, err)
+		request := client.Requests()[turn]
+		prompt := request.Messages[len(request.Messages)-1].Content
+		topic := "all"
+		for _, candidate := range objective.Topics {
+			if strings.Contains(prompt, "focusing on the topic: "+candidate) {
+				topic = candidate
+			}
+		}
+		topics = append(topics, topic)
+		interactions = append(interactions, models.DialecticalInteraction{ID: "di_" + topic, Status: models.StatusAnswered})
+	}
+	require.Equal(t, []string{"all", "sleep", "diet", "exercise", "sleep"}, topics)
+
+	// Without a strategy the least covered topic stays the focus
+	objective.TopicStrategy = models.TopicStrategy(0)
+	_, err := helper.GenerateQuestionForLearningObjective(objective, interactions[:3], progress)
+	require.NoError(t, err)
+	requests := client.Requests()
+	last := requests[len(requests)-1]
+	require.Contains(t, last.Messages[len(last.Messages)-1].Content, "focusing on the topic: sleep")
+}
@@ -0,0 +1,56 @@
+package ai_tests
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuestionExtractionNormalizesQuestions(t *testing.T) {
+	tests := []struct {
+		name string
+		blob string
+		want []string
+	}{
+		{
+			name: "duplicates differing in case and spacing",
+			blob: "How many hours do you sleep?\nhow many  hours do you SLEEP?\n  What's your bedtime routine?  ",
+			want: []string{"How many hours do you sleep?", "What's your bedtime routine?"},
+		},
+		{
+			name: "blank and too short questions",
+			blob: "?\n   \n\t?\nWhy?\nDo you nap during the day?",
+			want: []string{"Do you nap during the day?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helper := ai.NewAIHelperWithClient(fakeai.New(tt.blob))
+
+			assert.Equal(t, tt.want, helper.ExtractAndCleanQuestions(tt.blob))
+
+			extracted, err := helper.ExtractQuestionsFromText(tt.blob)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, extracted)
+		})
+	}
+}
+
+func TestExtractAndCleanQuestionsDeduplicatesFormattedQuestions(t *testing.T) {
+	helper := ai.NewAIHelperWithClient(fakeai.New(""))
+
+	blob := "- **Do you have any sleep beliefs?**\n• Do you have any sleep beliefs?\n1. Do you wake up rested?"
+	assert.Equal(t, []string{"Do you have any sleep beliefs?", "Do you wake up rested?"}, helper.ExtractAndCleanQuestions(blob))
+}
+
+func TestExtractQuestionsFromTextWithOnlyBlankQuestions(t *testing.T) {
+	helper := ai.NewAIHelperWithClient(fakeai.New("?\n \n  ?  "))
+
+	_, err := helper.ExtractQuestionsFromText("?")
+	assert.Error(t, err)
+}
@@ -0,0 +1,167 @@
+// Package fakeai provides a configurable stand-in for the OpenAI chat client used by tests.
+package fakeai
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ReplyFunc answers a chat completion request. Returning an error fails the request.
+type ReplyFunc func(ctx context.Context, request openai.ChatCompletionRequest) (string, error)
+
+type rule struct {
+	fragment string
+	reply    ReplyFunc
+}
+
+// Client answers chat completions with canned replies, chosen by the fragments of the prompt they
+// contain, and records the requests it was given. It is safe for concurrent use.
+type Client struct {
+	mu       sync.Mutex
+	reply    ReplyFunc
+	rules    []rule
+	hooks    []func(ctx context.Context, request openai.ChatCompletionRequest) error
+	requests []openai.ChatCompletionRequest
+}
+
+// New returns a client answering every prompt with reply until rules say otherwise.
+func New(reply string) *Client {
+	return NewFunc(Reply(reply))
+}
+
+// NewFunc returns a client answering every prompt by calling reply until rules say otherwise.
+func NewFunc(reply ReplyFunc) *Client {
+	return &Client{reply: reply}
+}
+
+// Reply returns a ReplyFunc always answering content.
+func Reply(content string) ReplyFunc {
+	return func(context.Context, openai.ChatCompletionRequest) (string, error) {
+		return content, nil
+	}
+}
+
+// Sequence returns a ReplyFunc answering with replies in order, repeating the last one.
+func Sequence(replies ...string) ReplyFunc {
+	var mu sync.Mutex
+	next := 0
+	return func(context.Context, openai.ChatCompletionRequest) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		content := replies[next]
+		if next < len(replies)-1 {
+			next++
+		}
+		return content, nil
+	}
+}
+
+// On answers prompts containing fragment with reply.
+func (c *Client) On(fragment, reply string) *Client {
+	return c.OnFunc(fragment, Reply(reply))
+}
+
+// OnFunc answers prompts containing fragment by calling reply. Rules added later take precedence,
+// so a shared script can be specialized by a test. An empty fragment replaces the default reply.
+func (c *Client) OnFunc(fragment string, reply ReplyFunc) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if fragment == "" {
+		c.reply = reply
+	} else {
+		c.rules = append(c.rules, rule{fragment: fragment, reply: reply})
+	}
+	return c
+}
+
+// Before calls hook ahead of answering each request, in the order hooks were added. A hook may
+// block, and returning an error fails the request instead of answering it.
+func (c *Client) Before(hook func(ctx context.Context, request openai.ChatCompletionRequest) error) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, hook)
+	return c
+}
+
+func (c *Client) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	prompt := Prompt(request)
+	c.mu.Lock()
+	c.requests = append(c.requests, request)
+	hooks := append([]func(context.Context, openai.ChatCompletionRequest) error(nil), c.hooks...)
+	reply := c.reply
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if strings.Contains(prompt, c.rules[i].fragment) {
+			reply = c.rules[i].reply
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	// Hooks and replies run unlocked so that they may block, for instance until a test lets them
+	// finish.
+	for _, hook := range hooks {
+		if err := hook(ctx, request); err != nil {
+			return openai.ChatCompletionResponse{}, err
+		}
+	}
+	content, err := reply(ctx, request)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{
+			{Message: openai.ChatCompletionMessage{Content: content}},
+		},
+	}, nil
+}
+
+// Calls returns the number of requests made so far.
+func (c *Client) Calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.requests)
+}
+
+// CallsMatching returns the number of requests made so far whose prompt contains fragment.
+func (c *Client) CallsMatching(fragment string) int {
+	return len(c.RequestsMatching(fragment))
+}
+
+// Requests returns the requests made so far, in order.
+func (c *Client) Requests() []openai.ChatCompletionRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]openai.ChatCompletionRequest(nil), c.requests...)
+}
+
+// RequestsMatching returns the requests made so far whose prompt contains fragment, in order.
+func (c *Client) RequestsMatching(fragment string) []openai.ChatCompletionRequest {
+	var matching []openai.ChatCompletionRequest
+	for _, request := range c.Requests() {
+		if strings.Contains(Prompt(request), fragment) {
+			matching = append(matching, request)
+		}
+	}
+	return matching
+}
+
+// SystemPrompts returns the first message of each request made so far, in order.
+func (c *Client) SystemPrompts() []string {
+	var prompts []string
+	for _, request := range c.Requests() {
+		prompts = append(prompts, request.Messages[0].Content)
+	}
+	return prompts
+}
+
+// Prompt joins the content of the messages of request.
+func Prompt(request openai.ChatCompletionRequest) string {
+	var prompt strings.Builder
+	for _, message := range request.Messages {
+		prompt.WriteString(message.Content)
+	}
+	return prompt.String()
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	pb "epistemic-me-core/pb"
+	models "epistemic-me-core/pb/models"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapSelfModelFromDocuments(t *testing.T) {
+	ctx := context.Background()
+	selfModelID := "bootstrap-self-model-" + generateUUID()
+	_, err := client.CreateSelfModel(ctx, connect.NewRequest(&pb.CreateSelfModelRequest{Id: selfModelID}))
+	require.NoError(t, err, "CreateSelfModel failed")
+
+	stream, err := client.BootstrapSelfModel(ctx, connect.NewRequest(&pb.BootstrapSelfModelRequest{
+		SelfModelId: selfModelID,
+		Documents: []*models.Resource{
+			{
+				Type:     models.ResourceType_CHAT_LOG,
+				SourceId: "sleep-journal",
+				Content:  "I go to bed at ten every night because eight hours of sleep keeps me sharp at work.",
+			},
+			{
+				Type:     models.ResourceType_SURVEY_RESPONSE,
+				SourceId: "diet-survey",
+				Content:  "I think eating vegetables at every meal gives me more energy in the afternoon.",
+			},
+		},
+	}))
+	require.NoError(t, err, "BootstrapSelfModel failed")
+
+	// Progress is reported document by document before the summary
+	var progress []*models.BootstrapProgress
+	var summary *pb.BootstrapSummary
+	for stream.Receive() {
+		if p := stream.Msg().GetProgress(); p != nil {
+			progress = append(progress, p)
+		}
+		if s := stream.Msg().GetSummary(); s != nil {
+			summary = s
+		}
+	}
+	require.NoError(t, stream.Err(), "BootstrapSelfModel stream failed")
+	require.Len(t, progress, 2)
+	for i, p := range progress {
+		assert.Equal(t, int32(i+1), p.DocumentsProcessed)
+		assert.Equal(t, int32(2), p.DocumentCount)
+	}
+	require.NotNil(t, summary, "the stream should end with a summary")
+	require.NotEmpty(t, summary.Beliefs, "beliefs should be extracted from the documents")
+	require.NotEmpty(t, summary.ObservationContexts, "documents should form observation contexts")
+	for _, oc := range summary.ObservationContexts {
+		assert.Contains(t, []string{"sleep-journal", "diet-survey"}, oc.Name)
+	}
+
+	beliefSystem, err := client.GetBeliefSystem(ctx, connect.NewRequest(&pb.GetBeliefSystemRequest{SelfModelId: selfModelID}))
+	require.NoError(t, err, "GetBeliefSystem failed")
+	stored := make(map[string]bool)
+	for _, belief := range beliefSystem.Msg.BeliefSystem.Beliefs {
+		stored[belief.Id] = true
+	}
+	for _, belief := range summary.Beliefs {
+		assert.True(t, stored[belief.Id], "bootstrapped belief %s should be in the belief system", belief.Id)
+	}
+}
@@ -26,12 +26,17 @@ import (
 )
 
 var (
-	kvStore      *db.KeyValueStore
+	kvStore      db.KeyValueStore
 	client       pbconnect.EpistemicMeServiceClient
 	port         string
 	testDevID    string // Store the test developer ID globally
 	testUserID   string // Store the test user ID globally
 	fixtureDevID string // Store the fixture developer ID globally
+
+	// publicClient sends requests without an API key, such as creating the test developer
+	publicClient pbconnect.EpistemicMeServiceClient
+	// clientAPIKey sets the test developer's API key on the requests of client
+	clientAPIKey *apiKeyInterceptor
 )
 
 // Add this type and methods before TestMain
@@ -132,27 +137,18 @@ func TestMain(m *testing.M) {
 	// Start the server using RunServer from server.go with dynamic port
 	srv, wg, port := server.RunServer(kvStore, "")
 
-	// First create a temporary client without API key to create a developer
-	tempClient := pbconnect.NewEpistemicMeServiceClient(http.DefaultClient, "http://localhost:"+port)
-
-	// Create a developer to get an API key
-	createDevResp, err := tempClient.CreateDeveloper(context.Background(), connect.NewRequest(&pb.CreateDeveloperRequest{
-		Name:  "Test Developer",
-		Email: "test@example.com",
-	}))
-	if err != nil {
+	// First create a client without API key to create a developer
+	publicClient = pbconnect.NewEpistemicMeServiceClient(http.DefaultClient, "http://localhost:"+port)
+	clientAPIKey = &apiKeyInterceptor{}
+	if err := issueTestAPIKey(); err != nil {
 		log.Fatalf("Failed to create developer: %v", err)
 	}
 
-	// Get the API key from the response
-	testDevID = createDevResp.Msg.Developer.Id
-	apiKeyForTests := createDevResp.Msg.Developer.ApiKeys[0]
-
 	// Create the client with the API key interceptor
 	client = pbconnect.NewEpistemicMeServiceClient(
 		http.DefaultClient,
 		"http://localhost:"+port,
-		connect.WithInterceptors(&apiKeyInterceptor{apiKey: apiKeyForTests}),
+		connect.WithInterceptors(clientAPIKey),
 	)
 
 	// Setup test state with retry logic
@@ -187,9 +183,27 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
-// clearStore clears the key-value store
+// issueTestAPIKey creates the test developer and sets their API key on the requests of client.
+func issueTestAPIKey() error {
+	createDevResp, err := publicClient.CreateDeveloper(context.Background(), connect.NewRequest(&pb.CreateDeveloperRequest{
+		Name:  "Test Developer",
+		Email: "test@example.com",
+	}))
+	if err != nil {
+		return err
+	}
+	testDevID = createDevResp.Msg.Developer.Id
+	clientAPIKey.apiKey = createDevResp.Msg.Developer.ApiKeys[0]
+	return nil
+}
+
+// clearStore clears the key-value store. The test developer is cleared with it, so a new one is
+// created for client's requests to keep authenticating.
 func clearStore() {
 	kvStore.ClearStore()
+	if err := issueTestAPIKey(); err != nil {
+		log.Fatalf("Failed to create developer: %v", err)
+	}
 }
 
 // resetStore resets the store to a clean state with fixtures
@@ -732,3 +746,43 @@ func TestCreateAndUpdatePhilosophyIntegration(t *testing.T) {
 	_, err = client.UpdatePhilosophy(ctx, connect.NewRequest(badUpdateReq))
 	require.Error(t, err)
 }
+
+func TestListPhilosophiesIntegration(t *testing.T) {
+	ctx := context.Background()
+
+	withContexts, err := client.CreatePhilosophy(ctx, connect.NewRequest(&pb.CreatePhilosophyRequest{
+		Description:         "# Sleep Philosophy\n\n## Experiential Narrative\n[[C: Circadian Rhythm]] [[S: asleep]] → [[S: awake]]\n",
+		ExtrapolateContexts: true,
+	}))
+	require.NoError(t, err)
+	withoutContexts, err := client.CreatePhilosophy(ctx, connect.NewRequest(&pb.CreatePhilosophyRequest{
+		Description:         "# Nutrition Philosophy\n\nEat whole foods.\n",
+		ExtrapolateContexts: false,
+	}))
+	require.NoError(t, err)
+
+	listResp, err := client.ListPhilosophies(ctx, connect.NewRequest(&pb.ListPhilosophiesRequest{}))
+	require.NoError(t, err)
+	listed := make(map[string]*models.PhilosophySummary)
+	for _, p := range listResp.Msg.Philosophies {
+		listed[p.Id] = p
+	}
+	require.Contains(t, listed, withContexts.Msg.Philosophy.Id)
+	require.Contains(t, listed, withoutContexts.Msg.Philosophy.Id)
+	require.Equal(t, withContexts.Msg.Philosophy.Description, listed[withContexts.Msg.Philosophy.Id].Description)
+	require.Equal(t, int32(3), listed[withContexts.Msg.Philosophy.Id].ExtrapolatedContextCount)
+	require.Equal(t, int32(0), listed[withoutContexts.Msg.Philosophy.Id].ExtrapolatedContextCount)
+
+	extrapolate := true
+	filteredResp, err := client.ListPhilosophies(ctx, connect.NewRequest(&pb.ListPhilosophiesRequest{
+		ExtrapolateContexts: &extrapolate,
+	}))
+	require.NoError(t, err)
+	var filteredIDs []string
+	for _, p := range filteredResp.Msg.Philosophies {
+		require.True(t, p.ExtrapolateContexts)
+		filteredIDs = append(filteredIDs, p.Id)
+	}
+	require.Contains(t, filteredIDs, withContexts.Msg.Philosophy.Id)
+	require.NotContains(t, filteredIDs, withoutContexts.Msg.Philosophy.Id)
+}
@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	pb "epistemic-me-core/pb"
+	models "epistemic-me-core/pb/models"
+	svc_models "epistemic-me-core/svc/models"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSelfModelDetail(t *testing.T) {
+	ctx := context.Background()
+	selfModelID := "detail-self-model-" + generateUUID()
+
+	philosophy, err := client.CreatePhilosophy(ctx, connect.NewRequest(&pb.CreatePhilosophyRequest{
+		Description: "# Sleep Philosophy\n\nSleep comes first.\n",
+	}))
+	require.NoError(t, err, "CreatePhilosophy failed")
+	_, err = client.CreateSelfModel(ctx, connect.NewRequest(&pb.CreateSelfModelRequest{
+		Id:           selfModelID,
+		Philosophies: []string{philosophy.Msg.Philosophy.Id},
+	}))
+	require.NoError(t, err, "CreateSelfModel failed")
+	_, err = client.CreateBelief(ctx, connect.NewRequest(&pb.CreateBeliefRequest{
+		SelfModelId:   selfModelID,
+		BeliefContent: "I believe sleep is important",
+		BeliefType:    models.BeliefType_STATEMENT,
+	}))
+	require.NoError(t, err, "CreateBelief failed")
+
+	// One dialectic waits on an answer and one has been answered
+	for id, status := range map[string]svc_models.DialecticalInteractionStatus{
+		"di_active-" + generateUUID():   svc_models.StatusPendingAnswer,
+		"di_answered-" + generateUUID(): svc_models.StatusAnswered,
+	} {
+		require.NoError(t, kvStore.Store(selfModelID, id, svc_models.Dialectic{
+			ID:               id,
+			SelfModelID:      selfModelID,
+			UserInteractions: []svc_models.DialecticalInteraction{{ID: "interaction-1", Status: status}},
+			Version:          1,
+		}, 1))
+	}
+
+	resp, err := client.GetSelfModelDetail(ctx, connect.NewRequest(&pb.GetSelfModelDetailRequest{SelfModelId: selfModelID}))
+	require.NoError(t, err, "GetSelfModelDetail failed")
+	assert.Equal(t, selfModelID, resp.Msg.SelfModel.Id)
+	require.NotNil(t, resp.Msg.BeliefSystem)
+	require.Len(t, resp.Msg.BeliefSystem.Beliefs, 1)
+	assert.Equal(t, "I believe sleep is important", resp.Msg.BeliefSystem.Beliefs[0].Content[0].RawStr)
+	require.Len(t, resp.Msg.Philosophies, 1)
+	assert.Equal(t, philosophy.Msg.Philosophy.Id, resp.Msg.Philosophies[0].Id)
+	assert.Equal(t, int32(2), resp.Msg.DialecticCount)
+	assert.Equal(t, int32(1), resp.Msg.ActiveDialecticCount)
+
+	light, err := client.GetSelfModelDetail(ctx, connect.NewRequest(&pb.GetSelfModelDetailRequest{
+		SelfModelId:         selfModelID,
+		ExcludeBeliefSystem: true,
+		ExcludePhilosophies: true,
+	}))
+	require.NoError(t, err, "GetSelfModelDetail failed")
+	assert.Nil(t, light.Msg.BeliefSystem)
+	assert.Empty(t, light.Msg.Philosophies)
+	assert.Equal(t, int32(2), light.Msg.DialecticCount)
+}
@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	pb "epistemic-me-core/pb"
+	models "epistemic-me-core/pb/models"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportSelfModelRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	sourceID := "export-self-model-" + generateUUID()
+	targetID := "import-self-model-" + generateUUID()
+
+	_, err := client.CreateSelfModel(ctx, connect.NewRequest(&pb.CreateSelfModelRequest{Id: sourceID}))
+	require.NoError(t, err, "CreateSelfModel failed")
+
+	for _, content := range []string{
+		"I believe sleep is important",
+		"I believe exercise improves my mood",
+		"I believe a balanced diet gives me energy",
+	} {
+		_, err := client.CreateBelief(ctx, connect.NewRequest(&pb.CreateBeliefRequest{
+			SelfModelId:   sourceID,
+			BeliefContent: content,
+			BeliefType:    models.BeliefType_STATEMENT,
+		}))
+		require.NoError(t, err, "CreateBelief failed")
+	}
+
+	// Reassemble the streamed export document
+	stream, err := client.ExportSelfModel(ctx, connect.NewRequest(&pb.ExportSelfModelRequest{SelfModelId: sourceID}))
+	require.NoError(t, err, "ExportSelfModel failed")
+	var document []byte
+	for stream.Receive() {
+		document = append(document, stream.Msg().Chunk...)
+	}
+	require.NoError(t, stream.Err(), "ExportSelfModel stream failed")
+	require.NotEmpty(t, document)
+
+	importResp, err := client.ImportSelfModel(ctx, connect.NewRequest(&pb.ImportSelfModelRequest{
+		SelfModelId: targetID,
+		Document:    document,
+	}))
+	require.NoError(t, err, "ImportSelfModel failed")
+	assert.Equal(t, targetID, importResp.Msg.SelfModel.Id)
+
+	sourceBeliefs, err := client.ListBeliefs(ctx, connect.NewRequest(&pb.ListBeliefsRequest{SelfModelId: sourceID}))
+	require.NoError(t, err)
+	importedBeliefs, err := client.ListBeliefs(ctx, connect.NewRequest(&pb.ListBeliefsRequest{SelfModelId: targetID}))
+	require.NoError(t, err)
+
+	require.Len(t, importedBeliefs.Msg.Beliefs, len(sourceBeliefs.Msg.Beliefs))
+	sourceIDs := make(map[string]bool)
+	for _, belief := range sourceBeliefs.Msg.Beliefs {
+		sourceIDs[belief.Id] = true
+	}
+	for _, belief := range importedBeliefs.Msg.Beliefs {
+		assert.False(t, sourceIDs[belief.Id], "imported belief %s should have a new ID", belief.Id)
+	}
+
+	// Importing onto an existing self model is rejected
+	_, err = client.ImportSelfModel(ctx, connect.NewRequest(&pb.ImportSelfModelRequest{
+		SelfModelId: targetID,
+		Document:    document,
+	}))
+	require.Error(t, err)
+}
+
+func TestImportSelfModelRejectsIncompatibleSchema(t *testing.T) {
+	_, err := client.ImportSelfModel(context.Background(), connect.NewRequest(&pb.ImportSelfModelRequest{
+		SelfModelId: "import-self-model-" + generateUUID(),
+		Document:    []byte(`{"schema_version": 99, "self_model": {"id": "future"}}`),
+	}))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
@@ -10,6 +10,7 @@ import (
 	"epistemic-me-core/pb/pbconnect"
 
 	"connectrpc.com/connect"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -25,7 +26,8 @@ func TestDeveloperUserIntegration(t *testing.T) {
 func TestCreateDeveloper(t *testing.T) {
 	ctx := context.Background()
 	name := "Test Developer"
-	email := "test@developer.com"
+	// Developer emails are unique, and this test also runs from TestDeveloperUserIntegration
+	email := "test-" + uuid.New().String() + "@developer.com"
 
 	req := &pb.CreateDeveloperRequest{
 		Name:  name,
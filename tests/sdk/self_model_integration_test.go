@@ -24,7 +24,7 @@ import (
 )
 
 var (
-	kvStore *db.KeyValueStore
+	kvStore db.KeyValueStore
 	client  pbconnect.EpistemicMeServiceClient
 	port    string
 	apiKey  string
@@ -0,0 +1,51 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAIResponseCache(t *testing.T) {
+	client := fakeai.New(sleepQuestion)
+	aih := ai.NewAIHelperWithClient(client)
+	aih.EnableResponseCache(1)
+
+	first, err := aih.GenerateQuestion("", nil)
+	require.NoError(t, err)
+	second, err := aih.GenerateQuestion("", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, client.Calls(), "the repeated prompt should be served from the cache")
+	assert.Equal(t, ai.CacheStats{Hits: 1, Misses: 1, Entries: 1}, aih.CacheStats())
+
+	// Fresh generation skips the cache
+	_, err = aih.WithoutCache().GenerateQuestion("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, client.Calls())
+
+	// A different prompt evicts the only entry
+	_, err = aih.GenerateQuestion("I believe sleep matters", nil)
+	require.NoError(t, err)
+	_, err = aih.GenerateQuestion("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, client.Calls())
+	assert.Equal(t, ai.CacheStats{Hits: 1, Misses: 3, Entries: 1}, aih.CacheStats())
+}
+
+func TestAIResponseCacheDisabledByDefault(t *testing.T) {
+	client := fakeai.New(sleepQuestion)
+	aih := ai.NewAIHelperWithClient(client)
+
+	for i := 0; i < 2; i++ {
+		_, err := aih.GenerateQuestion("", nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, client.Calls())
+	assert.Equal(t, ai.CacheStats{}, aih.CacheStats())
+}
@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAIResponseSchemaValidation(t *testing.T) {
+	event := ai.InteractionEvent{Question: "How do you sleep?", Answer: "Eight hours"}
+	resource := models.Resource{Content: "Sleep matters."}
+	beliefs := []*models.Belief{{ID: "b1", Content: []models.Content{{RawStr: "I believe sleep matters"}}}}
+
+	tests := []struct {
+		name      string
+		response  string
+		call      func(aih *ai.AIHelper) error
+		wantField string
+	}{
+		{
+			name:     "interaction event without beliefs",
+			response: `{"belief": "I believe sleep matters"}`,
+			call: func(aih *ai.AIHelper) error {
+				_, err := aih.GetInteractionEventAsBelief(event)
+				return err
+			},
+			wantField: `"beliefs"`,
+		},
+		{
+			name:     "resource beliefs of the wrong type",
+			response: `{"beliefs": "I believe sleep matters"}`,
+			call: func(aih *ai.AIHelper) error {
+				_, err := aih.ExtractBeliefsFromResource(resource)
+				return err
+			},
+			wantField: `"beliefs"`,
+		},
+		{
+			name:     "belief validity with a null list",
+			response: `{"kept_belief_ids": ["b1"], "deleted_belief_ids": null}`,
+			call: func(aih *ai.AIHelper) error {
+				_, _, err := aih.DetermineBeliefValidity(beliefs, beliefs)
+				return err
+			},
+			wantField: `"deleted_belief_ids"`,
+		},
+		{
+			name:     "no JSON at all",
+			response: "I could not find any beliefs.",
+			call: func(aih *ai.AIHelper) error {
+				_, err := aih.GetInteractionEventAsBelief(event)
+				return err
+			},
+			wantField: "no JSON object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fakeai.New(tt.response)
+			err := tt.call(ai.NewAIHelperWithClient(client))
+
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ai.ErrMalformedResponse)
+			assert.Contains(t, err.Error(), tt.wantField)
+			assert.Len(t, client.Requests(), 2, "a malformed response is retried once")
+		})
+	}
+}
+
+func TestAIResponseSchemaRetrySucceeds(t *testing.T) {
+	client := fakeai.NewFunc(fakeai.Sequence(
+		`{"statements": ["I believe sleep matters"]}`,
+		`{"beliefs": ["I believe sleep matters"]}`,
+	))
+	aih := ai.NewAIHelperWithClient(client)
+
+	beliefs, err := aih.GetInteractionEventAsBelief(ai.InteractionEvent{Question: "How do you sleep?", Answer: "Eight hours"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"I believe sleep matters"}, beliefs)
+
+	requests := client.Requests()
+	require.Len(t, requests, 2)
+	retry := requests[1].Messages
+	require.Len(t, retry, len(requests[0].Messages)+2)
+	assert.Equal(t, `{"statements": ["I believe sleep matters"]}`, retry[len(retry)-2].Content)
+	assert.Contains(t, retry[len(retry)-1].Content, `missing required field "beliefs"`)
+}
@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplingConfigAppliedToRequests(t *testing.T) {
+	temperature := float32(0.2)
+	seed := 42
+	client := fakeai.New(sleepQuestion)
+	aih := ai.NewAIHelperWithClient(client, ai.WithSampling(ai.SamplingConfig{
+		Temperature: &temperature,
+		Seed:        &seed,
+	}))
+
+	_, err := aih.GenerateQuestion("", nil)
+	require.NoError(t, err)
+
+	requests := client.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, temperature, requests[0].Temperature)
+	assert.Zero(t, requests[0].TopP, "an unset top-p is left to the provider")
+	require.NotNil(t, requests[0].Seed)
+	assert.Equal(t, seed, *requests[0].Seed)
+}
+
+func TestSamplingUnsetByDefault(t *testing.T) {
+	client := fakeai.New(sleepQuestion)
+	aih := ai.NewAIHelperWithClient(client)
+
+	_, err := aih.GenerateQuestion("", nil)
+	require.NoError(t, err)
+
+	requests := client.Requests()
+	require.Len(t, requests, 1)
+	assert.Zero(t, requests[0].Temperature)
+	assert.Zero(t, requests[0].TopP)
+	assert.Nil(t, requests[0].Seed)
+}
+
+func TestSamplingOverride(t *testing.T) {
+	temperature := float32(0.2)
+	creative := float32(1.1)
+	seed := 7
+	client := fakeai.New(sleepQuestion)
+	aih := ai.NewAIHelperWithClient(client, ai.WithSampling(ai.SamplingConfig{
+		Temperature: &temperature,
+		Seed:        &seed,
+	}))
+
+	_, err := aih.WithSamplingOverride(ai.SamplingConfig{Temperature: &creative}).GenerateQuestion("", nil)
+	require.NoError(t, err)
+	_, err = aih.GenerateQuestion("", nil)
+	require.NoError(t, err)
+
+	requests := client.Requests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, creative, requests[0].Temperature)
+	require.NotNil(t, requests[0].Seed, "fields missing from the override keep the configured value")
+	assert.Equal(t, seed, *requests[0].Seed)
+	assert.Equal(t, temperature, requests[1].Temperature, "the override applies only to the returned helper")
+}
+
+func TestZeroTemperatureIsSent(t *testing.T) {
+	zero := float32(0)
+	client := fakeai.New(sleepQuestion)
+	aih := ai.NewAIHelperWithClient(client, ai.WithSampling(ai.SamplingConfig{Temperature: &zero}))
+
+	_, err := aih.GenerateQuestion("", nil)
+	require.NoError(t, err)
+
+	requests := client.Requests()
+	require.Len(t, requests, 1)
+	assert.Greater(t, requests[0].Temperature, float32(0), "a zero temperature would be dropped from the request")
+	assert.InDelta(t, 0, requests[0].Temperature, 1e-6)
+}
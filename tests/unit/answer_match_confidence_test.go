@@ -0,0 +1,47 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// answerMatchClient extracts two questions and matches a strong answer to the first and a weak one
+// to the second.
+func answerMatchClient() *fakeai.Client {
+	return fakeai.New("").
+		On(answerMatchingPrompt, "Q1: How much do you sleep?\nA1: I sleep 8 hours\nC1: 0.9\n\nQ2: What do you eat for breakfast?\nA2: I like mornings\nC2: 0.2").
+		On("Extract all distinct questions", "How much do you sleep?\nWhat do you eat for breakfast?")
+}
+
+func TestPreprocessQuestionAnswersLeavesWeakMatchesUnanswered(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	dsvc := svc.NewDialecticService(kv, ai.NewAIHelperWithClient(answerMatchClient()), nil, nil)
+
+	input := &models.PreprocessQuestionAnswerInput{
+		QuestionBlobs: []string{"How much do you sleep? What do you eat for breakfast?"},
+		AnswerBlobs:   []string{"I sleep 8 hours. I like mornings."},
+	}
+
+	output, err := dsvc.PreprocessQuestionAnswers(input)
+	require.NoError(t, err)
+	require.Len(t, output.QAPairs, 2)
+	assert.Equal(t, "I sleep 8 hours", output.QAPairs[0].Answer)
+	assert.InDelta(t, 0.9, output.QAPairs[0].Confidence, 1e-6)
+	assert.Equal(t, ai.NoAnswerProvided, output.QAPairs[1].Answer, "a match below the threshold is left unanswered")
+	assert.InDelta(t, 0.2, output.QAPairs[1].Confidence, 1e-6)
+
+	dsvc.SetAnswerMatchThreshold(0.1)
+	output, err = dsvc.PreprocessQuestionAnswers(input)
+	require.NoError(t, err)
+	require.Len(t, output.QAPairs, 2)
+	assert.Equal(t, "I like mornings", output.QAPairs[1].Answer)
+}
@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// predictingClient predicts that people sleep eight hours, and places answers mentioning eight
+// hours as positive and all others as negative. Other prompts are scripted.
+func predictingClient() *fakeai.Client {
+	return scriptedClient().
+		OnFunc(`"distributions"`, placeByHours).
+		On("predict a likely answer", "I sleep eight hours a night")
+}
+
+// placeByHours places answers mentioning eight hours as positive and all others as negative.
+func placeByHours(ctx context.Context, request openai.ChatCompletionRequest) (string, error) {
+	last := request.Messages[len(request.Messages)-1].Content
+	_, answersJson, _ := strings.Cut(last, "Answers: ")
+	var answers []string
+	if err := json.Unmarshal([]byte(answersJson), &answers); err != nil {
+		return "", err
+	}
+	var distributions []map[string]float32
+	for _, answer := range answers {
+		if strings.Contains(answer, "eight hours") {
+			distributions = append(distributions, map[string]float32{"Positive": 0.8, "Negative": 0.1, "Neutral": 0.1})
+		} else {
+			distributions = append(distributions, map[string]float32{"Positive": 0.05, "Negative": 0.9, "Neutral": 0.05})
+		}
+	}
+	encoded, _ := json.Marshal(map[string]interface{}{"distributions": distributions})
+	return string(encoded), nil
+}
+
+func TestUpdateDialecticSurprise(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	client := predictingClient()
+	aih := ai.NewAIHelperWithClient(client)
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+
+	answer := func(userAnswer string, computeSurprise bool) models.DialecticalInteraction {
+		created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{
+			SelfModelID:       selfModelID,
+			LearningObjective: &models.LearningObjective{Description: "Understand sleep habits", Topics: []string{"sleep"}},
+		})
+		require.NoError(t, err)
+		updated, err := dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+			ID:              created.DialecticID,
+			SelfModelID:     selfModelID,
+			Answer:          models.UserAnswer{UserAnswer: userAnswer},
+			ComputeSurprise: computeSurprise,
+		})
+		require.NoError(t, err)
+		return updated.Dialectic.UserInteractions[0]
+	}
+
+	expected := answer("I always sleep eight hours", true)
+	expectedSurprise, ok := expected.SurpriseScore()
+	require.True(t, ok)
+
+	unexpected := answer("I barely sleep at all", true)
+	unexpectedSurprise, ok := unexpected.SurpriseScore()
+	require.True(t, ok)
+
+	assert.InDelta(t, 0, expectedSurprise, 0.001)
+	assert.Greater(t, unexpectedSurprise, expectedSurprise)
+	assert.Greater(t, unexpectedSurprise, float32(1))
+	assert.InDelta(t, 0.9, unexpected.Prediction.Observation.StateDistribution["Negative"], 0.001)
+	assert.InDelta(t, 0.8, unexpected.Prediction.PredictedObservation.StateDistribution["Positive"], 0.001)
+	assert.Equal(t, 2, client.CallsMatching("predict a likely answer"))
+
+	// Without the flag no prediction is made
+	plain := answer("I barely sleep at all", false)
+	_, ok = plain.SurpriseScore()
+	assert.False(t, ok)
+	assert.Nil(t, plain.Prediction)
+	assert.Equal(t, 2, client.CallsMatching("predict a likely answer"))
+}
@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateAPIKeyKeepsOldKeyUntilRevoked(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	developerSvc := svc.NewDeveloperService(kv, nil)
+
+	created, err := developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Integrator"})
+	require.NoError(t, err)
+	developerID := created.Developer.ID
+	oldKey := created.Developer.APIKeys[0]
+
+	rotated, err := developerSvc.RotateAPIKey(&models.RotateAPIKeyInput{DeveloperID: developerID})
+	require.NoError(t, err)
+	newKey := rotated.APIKey
+	assert.NotEqual(t, oldKey, newKey)
+	assert.ElementsMatch(t, []string{oldKey, newKey}, rotated.Developer.APIKeys)
+
+	for _, key := range []string{oldKey, newKey} {
+		developer, err := developerSvc.GetDeveloperByAPIKey(key)
+		require.NoError(t, err)
+		assert.Equal(t, developerID, developer.ID)
+	}
+
+	_, err = developerSvc.RevokeAPIKey(&models.RevokeAPIKeyInput{DeveloperID: developerID, APIKey: oldKey})
+	require.NoError(t, err)
+
+	_, err = developerSvc.AuthenticateAPIKey(oldKey)
+	assert.ErrorIs(t, err, svc.ErrAPIKeyRevoked)
+	_, err = developerSvc.GetDeveloperByAPIKey(oldKey)
+	assert.Error(t, err)
+	authenticated, err := developerSvc.AuthenticateAPIKey(newKey)
+	require.NoError(t, err)
+	assert.Equal(t, developerID, authenticated)
+
+	_, err = developerSvc.RevokeAPIKey(&models.RevokeAPIKeyInput{DeveloperID: developerID, APIKey: oldKey})
+	assert.ErrorIs(t, err, svc.ErrAPIKeyNotFound)
+}
+
+func TestRotatedAPIKeyExpiresAfterGrace(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	developerSvc := svc.NewDeveloperService(kv, nil)
+	developerSvc.SetKeyRotationGrace(10 * time.Millisecond)
+
+	created, err := developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Integrator"})
+	require.NoError(t, err)
+	oldKey := created.Developer.APIKeys[0]
+
+	rotated, err := developerSvc.RotateAPIKey(&models.RotateAPIKeyInput{DeveloperID: created.Developer.ID})
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+	_, err = developerSvc.AuthenticateAPIKey(oldKey)
+	assert.ErrorIs(t, err, svc.ErrAPIKeyRevoked)
+	_, err = developerSvc.AuthenticateAPIKey(rotated.APIKey)
+	assert.NoError(t, err)
+}
+
+func TestAuthenticateAPIKeyRejectsUnissuedKeys(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	developerSvc := svc.NewDeveloperService(kv, nil)
+
+	_, err = developerSvc.AuthenticateAPIKey("5d6f8e0a-0000-4000-8000-000000000000")
+	assert.ErrorIs(t, err, svc.ErrUnknownAPIKey)
+
+	// Developers stored before keys were indexed authenticate once their keys are indexed
+	developer := models.Developer{ID: "dev_legacy", Name: "Legacy", APIKeys: []string{"legacy-key"}}
+	require.NoError(t, kv.Store(developer.ID, "developer", developer, 1))
+	_, err = developerSvc.AuthenticateAPIKey("legacy-key")
+	assert.ErrorIs(t, err, svc.ErrUnknownAPIKey)
+	require.NoError(t, developerSvc.IndexAPIKeys())
+	developerID, err := developerSvc.AuthenticateAPIKey("legacy-key")
+	require.NoError(t, err)
+	assert.Equal(t, developer.ID, developerID)
+}
@@ -0,0 +1,275 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedExtractionClient holds belief extraction until release is closed. Other prompts are scripted.
+func gatedExtractionClient(release chan struct{}) *fakeai.Client {
+	return answerGatedClient("", release)
+}
+
+// answerGatedClient holds the belief extraction of answers containing answer until release is
+// closed. Other prompts are scripted.
+func answerGatedClient(answer string, release chan struct{}) *fakeai.Client {
+	return scriptedClient().OnFunc("Extract all beliefs", func(ctx context.Context, request openai.ChatCompletionRequest) (string, error) {
+		if strings.Contains(fakeai.Prompt(request), answer) {
+			<-release
+		}
+		return scriptedBeliefs, nil
+	})
+}
+
+func TestUpdateDialecticInBackground(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	release := make(chan struct{})
+	aih := ai.NewAIHelperWithClient(gatedExtractionClient(release))
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+
+	updated, err := dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+		ID:          created.DialecticID,
+		SelfModelID: selfModelID,
+		Answer:      models.UserAnswer{UserAnswer: "Sleep is important to me"},
+		Background:  true,
+	})
+	require.NoError(t, err)
+
+	// The answer is recorded and the next question asked before any belief is extracted
+	require.Len(t, updated.Dialectic.UserInteractions, 2)
+	answered := updated.Dialectic.UserInteractions[0]
+	assert.Equal(t, models.StatusProcessing, answered.Status)
+	assert.Equal(t, "Sleep is important to me", answered.Interaction.QuestionAnswer.Answer.UserAnswer)
+	assert.Empty(t, answered.Interaction.QuestionAnswer.ExtractedBeliefs)
+	assert.Equal(t, models.StatusPendingAnswer, updated.Dialectic.UserInteractions[1].Status)
+
+	status, err := dsvc.GetInteractionStatus(&models.GetInteractionStatusInput{
+		DialecticID:   created.DialecticID,
+		SelfModelID:   selfModelID,
+		InteractionID: answered.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusProcessing, status.Status)
+	assert.Empty(t, status.ExtractedBeliefs)
+
+	close(release)
+	dsvc.WaitForBackgroundUpdates()
+
+	status, err = dsvc.GetInteractionStatus(&models.GetInteractionStatusInput{
+		DialecticID:   created.DialecticID,
+		SelfModelID:   selfModelID,
+		InteractionID: answered.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusAnswered, status.Status)
+	assert.Empty(t, status.ProcessingError)
+	require.Len(t, status.ExtractedBeliefs, 1)
+	assert.Equal(t, "I believe that sleep is important", status.ExtractedBeliefs[0].GetContentAsString())
+	assert.Equal(t, answered.ID, status.ExtractedBeliefs[0].SourceInteractionID)
+
+	beliefSystem, err := bsvc.GetBeliefSystem(selfModelID)
+	require.NoError(t, err)
+	assert.Equal(t, "I believe that sleep is important", beliefContents(beliefSystem.Beliefs)[status.ExtractedBeliefs[0].ID])
+
+	// The next question stays pending
+	interactions, err := dsvc.ListInteractions(&models.ListInteractionsInput{
+		DialecticID: created.DialecticID,
+		SelfModelID: selfModelID,
+		Status:      models.StatusPendingAnswer,
+	})
+	require.NoError(t, err)
+	assert.Len(t, interactions.Interactions, 1)
+}
+
+func TestBackgroundAnswersBuildOnEachOther(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	release := make(chan struct{})
+	aih := ai.NewAIHelperWithClient(gatedExtractionClient(release))
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+
+	// Both answers are queued before either is processed
+	for _, answer := range []string{"Sleep is important to me", "I sleep eight hours a night"} {
+		_, err := dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+			ID:          created.DialecticID,
+			SelfModelID: selfModelID,
+			Answer:      models.UserAnswer{UserAnswer: answer},
+			Background:  true,
+		})
+		require.NoError(t, err)
+	}
+
+	close(release)
+	dsvc.WaitForBackgroundUpdates()
+
+	beliefSystem, err := bsvc.GetBeliefSystem(selfModelID)
+	require.NoError(t, err)
+	assert.Len(t, beliefSystem.Beliefs, 2, "the second answer keeps the beliefs extracted from the first")
+}
+
+func TestResumeBackgroundUpdates(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+
+	// The first service stops before the answer is processed, as a restart would
+	release := make(chan struct{})
+	stoppedAIH := ai.NewAIHelperWithClient(gatedExtractionClient(release))
+	stopped := svc.NewDialecticService(kv, stoppedAIH, nil, svc.NewDialecticEpistemology(svc.NewBeliefService(kv, stoppedAIH), stoppedAIH))
+	t.Cleanup(func() {
+		close(release)
+		stopped.WaitForBackgroundUpdates()
+	})
+	created, err := stopped.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	updated, err := stopped.UpdateDialectic(&models.UpdateDialecticInput{
+		ID:          created.DialecticID,
+		SelfModelID: selfModelID,
+		Answer:      models.UserAnswer{UserAnswer: "Sleep is important to me"},
+		Background:  true,
+	})
+	require.NoError(t, err)
+	answered := updated.Dialectic.UserInteractions[0]
+
+	aih := ai.NewAIHelperWithClient(scriptedClient())
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(svc.NewBeliefService(kv, aih), aih))
+	require.NoError(t, dsvc.ResumeBackgroundUpdates())
+	dsvc.WaitForBackgroundUpdates()
+
+	status, err := dsvc.GetInteractionStatus(&models.GetInteractionStatusInput{
+		DialecticID:   created.DialecticID,
+		SelfModelID:   selfModelID,
+		InteractionID: answered.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusAnswered, status.Status)
+	require.Len(t, status.ExtractedBeliefs, 1)
+	assert.Equal(t, "I believe that sleep is important", status.ExtractedBeliefs[0].GetContentAsString())
+}
+
+func TestGetInteractionStatusUnknownInteraction(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(scriptedClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: "self-model-1"})
+	require.NoError(t, err)
+
+	_, err = dsvc.GetInteractionStatus(&models.GetInteractionStatusInput{
+		DialecticID:   created.DialecticID,
+		SelfModelID:   "self-model-1",
+		InteractionID: "missing",
+	})
+	assert.ErrorIs(t, err, svc.ErrInteractionNotFound)
+}
+
+func TestBackgroundAnswersOfOtherSelfModelsAreNotHeldUp(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	release := make(chan struct{})
+	aih := ai.NewAIHelperWithClient(answerGatedClient("Sleep is important to me", release))
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(svc.NewBeliefService(kv, aih), aih))
+
+	// The two self models are processed on different workers
+	answer := func(selfModelID, userAnswer string) (dialecticID, interactionID string) {
+		require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+		created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+		require.NoError(t, err)
+		updated, err := dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+			ID:          created.DialecticID,
+			SelfModelID: selfModelID,
+			Answer:      models.UserAnswer{UserAnswer: userAnswer},
+			Background:  true,
+		})
+		require.NoError(t, err)
+		return created.DialecticID, updated.Dialectic.UserInteractions[0].ID
+	}
+	status := func(selfModelID, dialecticID, interactionID string) models.DialecticalInteractionStatus {
+		status, err := dsvc.GetInteractionStatus(&models.GetInteractionStatusInput{
+			DialecticID:   dialecticID,
+			SelfModelID:   selfModelID,
+			InteractionID: interactionID,
+		})
+		require.NoError(t, err)
+		return status.Status
+	}
+
+	heldDialectic, heldInteraction := answer("self-model-1", "Sleep is important to me")
+	otherDialectic, otherInteraction := answer("self-model-2", "Vegetables give me energy")
+	assert.Eventually(t, func() bool {
+		return status("self-model-2", otherDialectic, otherInteraction) == models.StatusAnswered
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, models.StatusProcessing, status("self-model-1", heldDialectic, heldInteraction))
+
+	close(release)
+	dsvc.WaitForBackgroundUpdates()
+	assert.Equal(t, models.StatusAnswered, status("self-model-1", heldDialectic, heldInteraction))
+}
+
+func TestFullBackgroundQueueTurnsAnswersAway(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	release := make(chan struct{})
+	aih := ai.NewAIHelperWithClient(gatedExtractionClient(release))
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(svc.NewBeliefService(kv, aih), aih))
+	t.Cleanup(func() {
+		close(release)
+		dsvc.WaitForBackgroundUpdates()
+	})
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+
+	// Extraction is held, so every answer stays queued until the queue is full
+	accepted := 0
+	for ; accepted < 1000; accepted++ {
+		_, err = dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+			ID:          created.DialecticID,
+			SelfModelID: selfModelID,
+			Answer:      models.UserAnswer{UserAnswer: fmt.Sprintf("Answer %d", accepted)},
+			Background:  true,
+		})
+		if err != nil {
+			break
+		}
+	}
+	require.ErrorIs(t, err, svc.ErrBackgroundQueueFull)
+	assert.Positive(t, accepted)
+
+	dialectic, err := dsvc.GetDialectic(&models.GetDialecticInput{ID: created.DialecticID, SelfModelID: selfModelID})
+	require.NoError(t, err)
+	interactions := dialectic.Dialectic.UserInteractions
+	assert.Equal(t, models.StatusPendingAnswer, interactions[len(interactions)-1].Status, "the turned away answer is not stored")
+}
@@ -0,0 +1,54 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchBeliefClient answers batch extraction prompts with batchResponse and single extraction
+// prompts with a fixed belief.
+func batchBeliefClient(batchResponse string) *fakeai.Client {
+	return fakeai.New(`{"beliefs": ["I believe in single extraction"]}`).On(batchExtractionPrompt, batchResponse)
+}
+
+var batchEvents = []ai.InteractionEvent{
+	{Question: "How do you sleep?", Answer: "Eight hours keeps me sharp"},
+	{Question: "What do you eat?", Answer: "Mostly plants"},
+	{Question: "Do you exercise?", Answer: "Not really"},
+}
+
+func TestGetInteractionEventsAsBeliefs_SingleCall(t *testing.T) {
+	client := batchBeliefClient(`{"interactions": [
+		{"index": 2, "beliefs": []},
+		{"index": 0, "beliefs": ["I believe eight hours of sleep keeps me sharp"]},
+		{"index": 1, "beliefs": ["I believe a plant based diet is healthy", "I believe meat is unnecessary"]}
+	]}`)
+	aih := ai.NewAIHelperWithClient(client)
+
+	beliefs, err := aih.GetInteractionEventsAsBeliefs(batchEvents)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, client.Calls())
+	require.Len(t, beliefs, 3)
+	assert.Equal(t, []string{"I believe eight hours of sleep keeps me sharp"}, beliefs[0])
+	assert.Equal(t, []string{"I believe a plant based diet is healthy", "I believe meat is unnecessary"}, beliefs[1])
+	assert.Empty(t, beliefs[2])
+}
+
+func TestGetInteractionEventsAsBeliefs_FallsBackPerEvent(t *testing.T) {
+	client := batchBeliefClient(`{"interactions": [{"index": 7, "beliefs": ["out of range"]}]}`)
+	aih := ai.NewAIHelperWithClient(client)
+
+	beliefs, err := aih.GetInteractionEventsAsBeliefs(batchEvents)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, client.Calls(), "one batch call plus one per event")
+	for _, eventBeliefs := range beliefs {
+		assert.Equal(t, []string{"I believe in single extraction"}, eventBeliefs)
+	}
+}
@@ -0,0 +1,98 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchCreateBeliefsModes(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	const selfModelID = "self-model-1"
+	beliefs := []models.BatchBeliefInput{
+		{BeliefContent: "I believe sleep matters", BeliefType: models.Statement},
+		{BeliefContent: "  ", BeliefType: models.Statement},
+		{BeliefContent: "Running daily keeps me fit", BeliefType: models.Causal},
+	}
+
+	// All or nothing is the default: the blank belief fails the batch and nothing is stored
+	_, err = bsvc.BatchCreateBeliefs(&models.BatchCreateBeliefsInput{SelfModelID: selfModelID, Beliefs: beliefs})
+	assert.ErrorIs(t, err, svc.ErrEmptyContent)
+	listed, err := bsvc.ListBeliefs(&models.ListBeliefsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Empty(t, listed.Beliefs)
+
+	output, err := bsvc.BatchCreateBeliefs(&models.BatchCreateBeliefsInput{
+		SelfModelID: selfModelID,
+		Beliefs:     beliefs,
+		Mode:        models.BatchModeBestEffort,
+	})
+	require.NoError(t, err)
+	require.Len(t, output.Beliefs, 2)
+	require.Len(t, output.Result.Items, 3)
+	assert.Equal(t, 1, output.Result.FailedCount())
+	assert.True(t, output.Result.Items[0].Succeeded)
+	assert.Equal(t, output.Beliefs[0].ID, output.Result.Items[0].ID)
+	assert.False(t, output.Result.Items[1].Succeeded)
+	assert.Equal(t, 1, output.Result.Items[1].Index)
+	assert.Contains(t, output.Result.Items[1].Error, svc.ErrEmptyContent.Error())
+	assert.True(t, output.Result.Items[2].Succeeded)
+	assert.Equal(t, output.Beliefs[1].ID, output.Result.Items[2].ID)
+	assert.Len(t, output.BeliefSystem.Beliefs, 2)
+
+	listed, err = bsvc.ListBeliefs(&models.ListBeliefsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Len(t, listed.Beliefs, 2)
+
+	_, err = bsvc.BatchCreateBeliefs(&models.BatchCreateBeliefsInput{SelfModelID: selfModelID})
+	assert.ErrorIs(t, err, svc.ErrNoBeliefs)
+}
+
+func TestBulkAnswerBestEffortSkipsInvalidAnswers(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(bulkAnswerClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+	dsvc.SetMaxContentLength(50)
+
+	const selfModelID = "self-model-1"
+	dialectic := models.Dialectic{
+		ID:          "di_bulk",
+		SelfModelID: selfModelID,
+		Version:     1,
+		UserInteractions: []models.DialecticalInteraction{
+			questionAnswer("sleep", models.StatusPendingAnswer, 100, "How do you sleep?", ""),
+			questionAnswer("diet", models.StatusPendingAnswer, 200, "What do you eat?", ""),
+			questionAnswer("exercise", models.StatusPendingAnswer, 300, "Do you exercise?", ""),
+		},
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+	answers := []string{"Eight hours", strings.Repeat("a", 51), "Plants", "I run daily"}
+
+	_, err = dsvc.BulkAnswer(&models.BulkAnswerInput{DialecticID: dialectic.ID, SelfModelID: selfModelID, Answers: answers})
+	assert.ErrorIs(t, err, svc.ErrContentTooLong)
+
+	output, err := dsvc.BulkAnswer(&models.BulkAnswerInput{
+		DialecticID: dialectic.ID,
+		SelfModelID: selfModelID,
+		Answers:     answers,
+		Mode:        models.BatchModeBestEffort,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sleep", "diet", "exercise"}, output.AnsweredInteractionIDs)
+	require.Len(t, output.Result.Items, 4)
+	assert.Equal(t, 1, output.Result.FailedCount())
+	assert.False(t, output.Result.Items[1].Succeeded)
+	assert.Contains(t, output.Result.Items[1].Error, svc.ErrContentTooLong.Error())
+}
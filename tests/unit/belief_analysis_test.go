@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sleepAnalysis is the analysis the AI gives of every belief system, fenced as models often do.
+const sleepAnalysis = "```json\n" + `{
+	"coherence": 0.8,
+	"consistency": 0.7,
+	"falsifiability": 0.4,
+	"overall_score": 0.65,
+	"feedback": "Your sleep beliefs support each other",
+	"recommendations": ["Track your sleep for a week"],
+	"verified_beliefs": ["I sleep eight hours"]
+}` + "\n```"
+
+func TestAnalyzeBeliefSystem(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	client := fakeai.New(sleepAnalysis)
+	bsvc := svc.NewBeliefService(kv, ai.NewAIHelperWithClient(client))
+
+	const selfModelID = "self-model-1"
+	_, err = bsvc.CreateBelief(&models.CreateBeliefInput{SelfModelID: selfModelID, BeliefContent: "I sleep eight hours", BeliefType: models.Statement})
+	require.NoError(t, err)
+
+	input := &models.AnalyzeBeliefSystemInput{SelfModelID: selfModelID, DialecticType: models.DialecticTypeSleepDietExercise}
+	output, err := bsvc.AnalyzeBeliefSystem(input)
+	require.NoError(t, err)
+	assert.False(t, output.Cached)
+	assert.Equal(t, models.BeliefAnalysis{
+		Coherence:       0.8,
+		Consistency:     0.7,
+		Falsifiability:  0.4,
+		OverallScore:    0.65,
+		Feedback:        "Your sleep beliefs support each other",
+		Recommendations: []string{"Track your sleep for a week"},
+		VerifiedBeliefs: []string{"I sleep eight hours"},
+	}, output.Analysis)
+	require.Len(t, client.SystemPrompts(), 1)
+	assert.Contains(t, client.SystemPrompts()[0], "related to sleep, diet, and exercise")
+	assert.Contains(t, client.SystemPrompts()[0], "- I sleep eight hours")
+	assert.NotContains(t, client.SystemPrompts()[0], "latest interaction")
+
+	cached, err := bsvc.AnalyzeBeliefSystem(input)
+	require.NoError(t, err)
+	assert.True(t, cached.Cached)
+	assert.Equal(t, output.Analysis, cached.Analysis)
+	assert.Len(t, client.SystemPrompts(), 1, "an unchanged belief system is not analyzed again")
+
+	general, err := bsvc.AnalyzeBeliefSystem(&models.AnalyzeBeliefSystemInput{SelfModelID: selfModelID, DialecticType: models.DialecticTypeDefault})
+	require.NoError(t, err)
+	assert.False(t, general.Cached, "each strategy has its own analysis")
+	require.Len(t, client.SystemPrompts(), 2)
+	assert.False(t, strings.Contains(client.SystemPrompts()[1], "sleep, diet, and exercise"))
+
+	_, err = bsvc.CreateBelief(&models.CreateBeliefInput{SelfModelID: selfModelID, BeliefContent: "Coffee keeps me up", BeliefType: models.Statement})
+	require.NoError(t, err)
+	changed, err := bsvc.AnalyzeBeliefSystem(input)
+	require.NoError(t, err)
+	assert.False(t, changed.Cached, "a changed belief system is analyzed again")
+	assert.Len(t, client.SystemPrompts(), 3)
+
+	_, err = bsvc.AnalyzeBeliefSystem(&models.AnalyzeBeliefSystemInput{SelfModelID: "self-model-empty"})
+	assert.ErrorIs(t, err, svc.ErrBeliefSystemNotFound)
+}
@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// classifyingClient behaves like scriptedClient but answers classifying extraction prompts with
+// one belief of each type.
+func classifyingClient() *fakeai.Client {
+	return scriptedClient().On("classify each one", `{"beliefs": [
+		{"content": "I believe that going to bed at ten gives me more energy the next day", "type": "causal"},
+		{"content": "I believe that I sleep eight hours a night", "type": "falsifiable"},
+		{"content": "I believe that rest matters", "type": "statement"}
+	]}`)
+}
+
+func TestUpdateDialecticClassifiesExtractedBeliefs(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+
+	aih := ai.NewAIHelperWithClient(classifyingClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+
+	dialectic := models.Dialectic{
+		ID:          "di_classify",
+		SelfModelID: selfModelID,
+		LearningObjective: &models.LearningObjective{
+			Description:         "Understand sleep habits",
+			Topics:              []string{"sleep"},
+			CompletionThreshold: 1,
+		},
+		UserInteractions: []models.DialecticalInteraction{
+			{
+				ID:     "interaction-1",
+				Status: models.StatusPendingAnswer,
+				Type:   models.InteractionTypeQuestionAnswer,
+				Interaction: &models.InteractionData{
+					QuestionAnswer: &models.QuestionAnswerInteraction{
+						Question: models.Question{Question: "How do you sleep?"},
+					},
+				},
+			},
+		},
+		Version: 1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	_, err = dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+		ID:          dialectic.ID,
+		SelfModelID: selfModelID,
+		Answer:      models.UserAnswer{UserAnswer: "Going to bed at ten gives me more energy"},
+	})
+	require.NoError(t, err)
+
+	stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+	require.NoError(t, err)
+
+	types := make(map[string]models.BeliefType)
+	for _, belief := range stored.(*models.BeliefSystem).Beliefs {
+		types[belief.GetContentAsString()] = belief.Type
+	}
+	assert.Equal(t, models.Causal, types["I believe that going to bed at ten gives me more energy the next day"])
+	assert.Equal(t, models.Falsifiable, types["I believe that I sleep eight hours a night"])
+	assert.Equal(t, models.Statement, types["I believe that rest matters"])
+}
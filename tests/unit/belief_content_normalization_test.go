@@ -0,0 +1,52 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeBeliefContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "repeated prefix", content: "I believe that I believe sleep matters", want: "I believe that sleep matters"},
+		{name: "prefix in mixed case", content: "i believe I BELIEVE that sleep matters.", want: "I believe sleep matters."},
+		{name: "quoted belief", content: `I believe "I believe sleep matters"`, want: "I believe sleep matters"},
+		{name: "typographic quotes", content: "  “Sleep matters”  ", want: "Sleep matters"},
+		{name: "typographic apostrophe", content: "It’s worth sleeping eight hours", want: "It's worth sleeping eight hours"},
+		{name: "whitespace", content: "Sleep \t matters\n a lot", want: "Sleep matters a lot"},
+		{name: "word starting with that", content: "I believe thatched roofs last", want: "I believe thatched roofs last"},
+		{name: "prefix alone", content: "I believe", want: "I believe"},
+		{name: "only quotes", content: `""`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, models.NormalizeBeliefContent(tt.content))
+		})
+	}
+}
+
+func TestBeliefContentKey(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{name: "repeated prefix", a: "I believe that I believe sleep matters", b: "I believe sleep matters", same: true},
+		{name: "casing and punctuation", a: "Sleep Matters!", b: "sleep matters", same: true},
+		{name: "quoting", a: "“Sleep matters.”", b: `"sleep matters"`, same: true},
+		{name: "with and without prefix", a: "I believe that sleep matters", b: "Sleep matters", same: true},
+		// A belief containing another is not the same belief
+		{name: "containment", a: "I believe sleep matters less than diet", b: "I believe sleep matters", same: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.same, models.BeliefContentKey(tt.a) == models.BeliefContentKey(tt.b))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createAndUpdateBelief(t *testing.T, bsvc *svc.BeliefService, selfModelID string, contents ...string) string {
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: contents[0],
+		BeliefType:    models.Statement,
+	})
+	require.NoError(t, err)
+
+	for _, content := range contents[1:] {
+		_, err := bsvc.UpdateBelief(&models.UpdateBeliefInput{
+			SelfModelID:          selfModelID,
+			ID:                   created.Belief.ID,
+			UpdatedBeliefContent: content,
+			BeliefType:           models.Statement,
+		})
+		require.NoError(t, err)
+	}
+	return created.Belief.ID
+}
+
+func TestGetBeliefHistory(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	const selfModelID = "self-model-1"
+	beliefID := createAndUpdateBelief(t, bsvc, selfModelID,
+		"I believe six hours of sleep is enough",
+		"I believe seven hours of sleep is enough",
+		"I believe eight hours of sleep is enough",
+	)
+
+	output, err := bsvc.GetBeliefHistory(&models.GetBeliefHistoryInput{
+		SelfModelID: selfModelID,
+		BeliefID:    beliefID,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), output.Belief.Version)
+	assert.Equal(t, "I believe eight hours of sleep is enough", output.Belief.GetContentAsString())
+
+	require.Len(t, output.History, 2)
+	assert.Equal(t, int32(1), output.History[0].Version)
+	assert.Equal(t, "I believe six hours of sleep is enough", output.History[0].GetContentAsString())
+	assert.Equal(t, int32(2), output.History[1].Version)
+	assert.Equal(t, "I believe seven hours of sleep is enough", output.History[1].GetContentAsString())
+}
+
+func TestGetBeliefHistoryDropsOldestBeyondMax(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+	bsvc.SetMaxHistory(1)
+
+	const selfModelID = "self-model-1"
+	beliefID := createAndUpdateBelief(t, bsvc, selfModelID, "first", "second", "third")
+
+	output, err := bsvc.GetBeliefHistory(&models.GetBeliefHistoryInput{
+		SelfModelID: selfModelID,
+		BeliefID:    beliefID,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, output.History, 1)
+	assert.Equal(t, int32(2), output.History[0].Version)
+	assert.Equal(t, "second", output.History[0].GetContentAsString())
+}
+
+// pairedReadStore holds the first read of a key ending in suffix until a second such read arrives,
+// so two read-append-write cycles on it overlap.
+type pairedReadStore struct {
+	db.KeyValueStore
+	suffix string
+
+	mu      sync.Mutex
+	reads   int
+	arrived chan struct{}
+}
+
+func newPairedReadStore(kv db.KeyValueStore, suffix string) *pairedReadStore {
+	return &pairedReadStore{KeyValueStore: kv, suffix: suffix, arrived: make(chan struct{})}
+}
+
+func (s *pairedReadStore) Retrieve(developerId string, key string) (interface{}, error) {
+	if strings.HasSuffix(key, s.suffix) {
+		s.mu.Lock()
+		s.reads++
+		reads := s.reads
+		s.mu.Unlock()
+		switch reads {
+		case 1:
+			select {
+			case <-s.arrived:
+			case <-time.After(2 * time.Second):
+			}
+		case 2:
+			close(s.arrived)
+		}
+	}
+	return s.KeyValueStore.Retrieve(developerId, key)
+}
+
+func TestConcurrentBeliefUpdatesKeepTheWholeHistory(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	const selfModelID = "self-model-1"
+	beliefID := createAndUpdateBelief(t, svc.NewBeliefService(kv, nil), selfModelID, "first")
+
+	bsvc := svc.NewBeliefService(newPairedReadStore(kv, ":history"), nil)
+	update := func(content string) error {
+		_, err := bsvc.UpdateBelief(&models.UpdateBeliefInput{
+			SelfModelID:          selfModelID,
+			ID:                   beliefID,
+			UpdatedBeliefContent: content,
+			BeliefType:           models.Statement,
+		})
+		return err
+	}
+
+	// The first update's history append waits for the second one's, so both read the same history
+	first := make(chan error, 1)
+	go func() { first <- update("second") }()
+	require.Eventually(t, func() bool {
+		output, err := bsvc.GetBelief(&models.GetBeliefInput{SelfModelID: selfModelID, BeliefID: beliefID})
+		return err == nil && output.Belief.Version == 2
+	}, 2*time.Second, time.Millisecond)
+	require.NoError(t, update("third"))
+	require.NoError(t, <-first)
+
+	output, err := bsvc.GetBeliefHistory(&models.GetBeliefHistoryInput{SelfModelID: selfModelID, BeliefID: beliefID})
+	require.NoError(t, err)
+	var contents []string
+	for _, version := range output.History {
+		contents = append(contents, version.GetContentAsString())
+	}
+	assert.ElementsMatch(t, []string{"first", "second"}, contents)
+}
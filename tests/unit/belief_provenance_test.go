@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeliefProvenanceOfExtractedBelief(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(scriptedClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	updated, err := dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+		ID:          created.DialecticID,
+		SelfModelID: selfModelID,
+		Answer:      models.UserAnswer{UserAnswer: "Sleep is important to me"},
+	})
+	require.NoError(t, err)
+
+	answered := updated.Dialectic.UserInteractions[0]
+	extracted := answered.Interaction.QuestionAnswer.ExtractedBeliefs
+	require.Len(t, extracted, 1)
+
+	output, err := bsvc.GetBeliefProvenance(&models.GetBeliefProvenanceInput{
+		SelfModelID: selfModelID,
+		BeliefID:    extracted[0].ID,
+	})
+	require.NoError(t, err)
+	provenance := output.Provenance
+	assert.Equal(t, extracted[0].ID, provenance.BeliefID)
+	assert.Equal(t, models.BeliefSourceDialectic, provenance.Source)
+	assert.Equal(t, created.DialecticID, provenance.DialecticID)
+	assert.Equal(t, answered.ID, provenance.InteractionID)
+	assert.Equal(t, "What do you believe about sleep?", provenance.Question)
+	assert.Equal(t, "Sleep is important to me", provenance.Answer)
+	assert.NotZero(t, provenance.AnsweredAtMillisUTC)
+}
+
+func TestBeliefProvenanceOfCreatedBelief(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   "self-model-1",
+		BeliefContent: "I believe walking after dinner helps digestion",
+		BeliefType:    models.Causal,
+	})
+	require.NoError(t, err)
+
+	output, err := bsvc.GetBeliefProvenance(&models.GetBeliefProvenanceInput{
+		SelfModelID: "self-model-1",
+		BeliefID:    created.Belief.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.BeliefSourceDirect, output.Provenance.Source)
+	assert.Empty(t, output.Provenance.DialecticID)
+
+	_, err = bsvc.GetBeliefProvenance(&models.GetBeliefProvenanceInput{SelfModelID: "self-model-1", BeliefID: "bi_missing"})
+	assert.ErrorIs(t, err, svc.ErrBeliefNotFound)
+}
@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractedBeliefsRecordSourceInteraction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.json")
+	kv, err := db.NewKeyValueStore(path)
+	require.NoError(t, err)
+
+	aih := ai.NewAIHelperWithClient(classifyingClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+	dialectic := models.Dialectic{
+		ID:          "di_source",
+		SelfModelID: selfModelID,
+		LearningObjective: &models.LearningObjective{
+			Description:         "Understand sleep habits",
+			Topics:              []string{"sleep"},
+			CompletionThreshold: 1,
+		},
+		UserInteractions: []models.DialecticalInteraction{
+			{
+				ID:     "interaction-1",
+				Status: models.StatusPendingAnswer,
+				Type:   models.InteractionTypeQuestionAnswer,
+				Interaction: &models.InteractionData{
+					QuestionAnswer: &models.QuestionAnswerInteraction{
+						Question: models.Question{Question: "How do you sleep?"},
+					},
+				},
+			},
+		},
+		Version: 1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	output, err := dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+		ID:          dialectic.ID,
+		SelfModelID: selfModelID,
+		Answer:      models.UserAnswer{UserAnswer: "Going to bed at ten gives me more energy"},
+	})
+	require.NoError(t, err)
+
+	extracted := output.Dialectic.UserInteractions[0].Interaction.QuestionAnswer.ExtractedBeliefs
+	require.NotEmpty(t, extracted)
+	for _, belief := range extracted {
+		assert.Equal(t, "interaction-1", belief.SourceInteractionID)
+		assert.Equal(t, "interaction-1", models.BeliefFromProto(belief.ToProto()).SourceInteractionID)
+	}
+
+	// The source survives a reload of the persisted belief system
+	reloaded, err := db.NewKeyValueStore(path)
+	require.NoError(t, err)
+	stored, err := reloaded.Retrieve(selfModelID, "BeliefSystem")
+	require.NoError(t, err)
+	sources := make(map[string]string)
+	for _, belief := range stored.(*models.BeliefSystem).Beliefs {
+		sources[belief.ID] = belief.SourceInteractionID
+	}
+	for _, belief := range extracted {
+		assert.Equal(t, "interaction-1", sources[belief.ID])
+	}
+}
@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBeliefSystemInContexts(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	const selfModelID = "self-model-1"
+	belief := func(id string) *models.Belief {
+		return &models.Belief{ID: id, Active: true, Content: []models.Content{{RawStr: "I believe " + id}}}
+	}
+	bs := models.BeliefSystem{
+		Beliefs: []*models.Belief{belief("sleep-belief"), belief("rem-belief"), belief("diet-belief")},
+		EpistemicContexts: []*models.EpistemicContext{
+			{PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{
+					{ID: "sleep", Name: "Sleep"},
+					{ID: "rem", Name: "REM", ParentID: "sleep"},
+				},
+				BeliefContexts: []*models.BeliefContext{
+					{BeliefID: "sleep-belief", ObservationContextID: "sleep"},
+					{BeliefID: "rem-belief", ObservationContextID: "rem"},
+				},
+			}},
+			{PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{{ID: "diet", Name: "Diet"}},
+				BeliefContexts:      []*models.BeliefContext{{BeliefID: "diet-belief", ObservationContextID: "diet"}},
+			}},
+		},
+	}
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", bs, 1))
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	beliefIDs := func(beliefSystem *models.BeliefSystem) []string {
+		var ids []string
+		for _, belief := range beliefSystem.Beliefs {
+			ids = append(ids, belief.ID)
+		}
+		return ids
+	}
+
+	// A context includes the contexts nested under it
+	sleep, err := bsvc.GetBeliefSystemInContexts(selfModelID, []string{"sleep"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sleep-belief", "rem-belief"}, beliefIDs(sleep))
+	require.Len(t, sleep.EpistemicContexts, 1)
+	assert.Len(t, sleep.EpistemicContexts[0].PredictiveProcessingContext.ObservationContexts, 2)
+
+	diet, err := bsvc.GetBeliefSystemInContexts(selfModelID, []string{"diet"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"diet-belief"}, beliefIDs(diet))
+
+	rem, err := bsvc.GetBeliefSystemInContexts(selfModelID, []string{"rem"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rem-belief"}, beliefIDs(rem))
+
+	// Without a filter everything is returned
+	all, err := bsvc.GetBeliefSystemInContexts(selfModelID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sleep-belief", "rem-belief", "diet-belief"}, beliefIDs(all))
+	assert.Len(t, all.EpistemicContexts, 2)
+}
@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storedBeliefContents returns the contents of the beliefs in the stored belief system itself,
+// rather than those listed from the stored beliefs.
+func storedBeliefContents(t *testing.T, kv db.KeyValueStore, selfModelID string) []string {
+	stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+	require.NoError(t, err)
+	var contents []string
+	for _, belief := range stored.(*models.BeliefSystem).Beliefs {
+		contents = append(contents, belief.GetContentAsString())
+	}
+	return contents
+}
+
+func TestConcurrentBeliefSystemWritesKeepEachOthersBeliefs(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+	const selfModelID = "self-model-1"
+
+	const writers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+				SelfModelID:   selfModelID,
+				BeliefContent: fmt.Sprintf("I believe habit %d helps", i),
+				BeliefType:    models.Statement,
+			})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	contents := storedBeliefContents(t, kv, selfModelID)
+	for i := 0; i < writers; i++ {
+		assert.Contains(t, contents, fmt.Sprintf("I believe habit %d helps", i))
+	}
+}
+
+func TestStaleBeliefSystemWriteIsMerged(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+	smsvc := svc.NewSelfModelService(kv, nil, bsvc)
+	const selfModelID = "self-model-1"
+
+	removed, err := bsvc.CreateBelief(&models.CreateBeliefInput{SelfModelID: selfModelID, BeliefContent: "I believe naps help", BeliefType: models.Statement})
+	require.NoError(t, err)
+	stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+	require.NoError(t, err)
+	stale := stored.(*models.BeliefSystem)
+
+	// Written after stale was read
+	_, err = bsvc.CreateBelief(&models.CreateBeliefInput{SelfModelID: selfModelID, BeliefContent: "I believe walks help", BeliefType: models.Statement})
+	require.NoError(t, err)
+
+	stale.Beliefs = []*models.Belief{{ID: "stretching", Content: []models.Content{{RawStr: "I believe stretching helps"}}, Type: models.Statement}}
+	require.NoError(t, smsvc.UpdateSelfModelBeliefSystem(context.Background(), selfModelID, stale))
+
+	contents := storedBeliefContents(t, kv, selfModelID)
+	assert.ElementsMatch(t, []string{"I believe walks help", "I believe stretching helps"}, contents,
+		"the stale write removes %q and adds its belief without dropping the newer one", removed.Belief.GetContentAsString())
+}
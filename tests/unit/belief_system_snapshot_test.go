@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeliefSystemSnapshotRoundTrip(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	const selfModelID = "self-model-1"
+	sleep := createAndUpdateBelief(t, bsvc, selfModelID, "I believe eight hours of sleep is enough")
+	exercise := createAndUpdateBelief(t, bsvc, selfModelID, "I believe exercise improves my mood")
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", models.BeliefSystem{
+		EpistemicContexts: []*models.EpistemicContext{{
+			PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{{ID: "sleep", Name: "Sleep"}},
+				BeliefContexts:      []*models.BeliefContext{{BeliefID: sleep, ObservationContextID: "sleep"}},
+			},
+		}},
+	}, 1))
+
+	saved, err := bsvc.SaveBeliefSystemSnapshot(&models.SaveBeliefSystemSnapshotInput{SelfModelID: selfModelID, Name: "baseline"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), saved.Snapshot.BeliefCount)
+	before, err := bsvc.GetBeliefSystem(selfModelID)
+	require.NoError(t, err)
+
+	// Edit, delete and add beliefs, and drop the epistemic contexts
+	_, err = bsvc.UpdateBelief(&models.UpdateBeliefInput{
+		SelfModelID:          selfModelID,
+		ID:                   sleep,
+		UpdatedBeliefContent: "I believe six hours of sleep is enough",
+		BeliefType:           models.Statement,
+	})
+	require.NoError(t, err)
+	_, err = bsvc.DeleteBelief(&models.DeleteBeliefInput{SelfModelID: selfModelID, ID: exercise})
+	require.NoError(t, err)
+	createAndUpdateBelief(t, bsvc, selfModelID, "I believe coffee ruins my sleep")
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", models.BeliefSystem{}, 1))
+
+	restored, err := bsvc.RestoreBeliefSystemSnapshot(&models.RestoreBeliefSystemSnapshotInput{SelfModelID: selfModelID, Name: "baseline"})
+	require.NoError(t, err)
+
+	after, err := bsvc.GetBeliefSystem(selfModelID)
+	require.NoError(t, err)
+	assert.Equal(t, before.EpistemicContexts, after.EpistemicContexts)
+	assert.Equal(t, beliefContents(before.Beliefs), beliefContents(after.Beliefs))
+	assert.Equal(t, beliefContents(before.Beliefs), beliefContents(restored.BeliefSystem.Beliefs))
+
+	// Restoring does not use up the snapshot
+	_, err = bsvc.RestoreBeliefSystemSnapshot(&models.RestoreBeliefSystemSnapshotInput{SelfModelID: selfModelID, Name: "baseline"})
+	require.NoError(t, err)
+
+	listed, err := bsvc.ListBeliefSystemSnapshots(&models.ListBeliefSystemSnapshotsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	require.Len(t, listed.Snapshots, 1)
+	assert.Equal(t, "baseline", listed.Snapshots[0].Name)
+
+	_, err = bsvc.RestoreBeliefSystemSnapshot(&models.RestoreBeliefSystemSnapshotInput{SelfModelID: selfModelID, Name: "missing"})
+	assert.ErrorIs(t, err, svc.ErrSnapshotNotFound)
+}
+
+func beliefContents(beliefs []*models.Belief) map[string]string {
+	contents := make(map[string]string, len(beliefs))
+	for _, belief := range beliefs {
+		contents[belief.ID] = belief.GetContentAsString()
+	}
+	return contents
+}
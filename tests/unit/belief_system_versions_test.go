@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeliefWritesAfterUpdateDialecticAreKept(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+
+	aih := ai.NewAIHelperWithClient(classifyingClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "I believe coffee keeps me awake",
+		BeliefType:    models.Statement,
+	})
+	require.NoError(t, err)
+	coffee := created.Belief
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", models.BeliefSystem{
+		Beliefs: []*models.Belief{&coffee},
+		EpistemicContexts: []*models.EpistemicContext{{
+			PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{{ID: "sleep", Name: "Sleep"}},
+				BeliefContexts:      []*models.BeliefContext{{BeliefID: coffee.ID, ObservationContextID: "sleep"}},
+			},
+		}},
+	}, 1))
+
+	dialectic := models.Dialectic{
+		ID:          "di_versions",
+		SelfModelID: selfModelID,
+		UserInteractions: []models.DialecticalInteraction{{
+			ID:     "interaction-1",
+			Status: models.StatusPendingAnswer,
+			Type:   models.InteractionTypeQuestionAnswer,
+			Interaction: &models.InteractionData{
+				QuestionAnswer: &models.QuestionAnswerInteraction{
+					Question: models.Question{Question: "How do you sleep?"},
+				},
+			},
+		}},
+		Version: 1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	// The answer's beliefs grow the belief system past a single belief
+	_, err = dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+		ID:          dialectic.ID,
+		SelfModelID: selfModelID,
+		Answer:      models.UserAnswer{UserAnswer: "Going to bed at ten gives me more energy"},
+	})
+	require.NoError(t, err)
+	storedBeliefSystem := func() *models.BeliefSystem {
+		stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+		require.NoError(t, err)
+		return stored.(*models.BeliefSystem)
+	}
+	require.Greater(t, len(storedBeliefSystem().Beliefs), 1)
+
+	// Later belief writes are what the belief system reads back
+	_, err = bsvc.DeleteBelief(&models.DeleteBeliefInput{SelfModelID: selfModelID, ID: coffee.ID})
+	require.NoError(t, err)
+	for _, ec := range storedBeliefSystem().EpistemicContexts {
+		if ec.PredictiveProcessingContext == nil {
+			continue
+		}
+		for _, bc := range ec.PredictiveProcessingContext.BeliefContexts {
+			assert.NotEqual(t, coffee.ID, bc.BeliefID)
+		}
+	}
+
+	_, err = bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "I believe naps help me focus",
+		BeliefType:    models.Statement,
+	})
+	require.NoError(t, err)
+	var contents []string
+	for _, belief := range storedBeliefSystem().Beliefs {
+		contents = append(contents, belief.GetContentAsString())
+	}
+	assert.Contains(t, contents, "I believe naps help me focus")
+	assert.Contains(t, contents, "I believe that rest matters")
+}
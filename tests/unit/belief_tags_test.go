@@ -0,0 +1,65 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeliefTagsFilterListBeliefs(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	const selfModelID = "self-model-1"
+	createBelief := func(content string) string {
+		created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+			SelfModelID:   selfModelID,
+			BeliefContent: content,
+			BeliefType:    models.Statement,
+		})
+		require.NoError(t, err)
+		return created.Belief.ID
+	}
+	sleep := createBelief("I believe sleep matters")
+	diet := createBelief("I believe breakfast matters")
+	createBelief("I believe exercise matters")
+
+	tagged, err := bsvc.AddBeliefTags(&models.BeliefTagsInput{SelfModelID: selfModelID, BeliefID: sleep, Tags: []string{" core ", "needs-review", "core"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"core", "needs-review"}, tagged.Belief.Tags)
+	_, err = bsvc.AddBeliefTags(&models.BeliefTagsInput{SelfModelID: selfModelID, BeliefID: diet, Tags: []string{"core"}})
+	require.NoError(t, err)
+
+	listTagged := func(tags ...string) []string {
+		listed, err := bsvc.ListBeliefs(&models.ListBeliefsInput{SelfModelID: selfModelID, Tags: tags})
+		require.NoError(t, err)
+		var ids []string
+		for _, belief := range listed.Beliefs {
+			ids = append(ids, belief.ID)
+		}
+		return ids
+	}
+	assert.ElementsMatch(t, []string{sleep, diet}, listTagged("core"))
+	assert.Equal(t, []string{sleep}, listTagged("core", "needs-review"))
+	assert.Len(t, listTagged(), 3)
+
+	untagged, err := bsvc.RemoveBeliefTags(&models.BeliefTagsInput{SelfModelID: selfModelID, BeliefID: sleep, Tags: []string{"needs-review"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"core"}, untagged.Belief.Tags)
+	assert.Empty(t, listTagged("needs-review"))
+
+	// Tags round-trip through the store and proto conversion
+	stored, err := bsvc.GetBelief(&models.GetBeliefInput{SelfModelID: selfModelID, BeliefID: sleep})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"core"}, stored.Belief.Tags)
+	assert.Equal(t, []string{"core"}, models.BeliefFromProto(stored.Belief.ToProto()).Tags)
+
+	_, err = bsvc.AddBeliefTags(&models.BeliefTagsInput{SelfModelID: selfModelID, BeliefID: sleep, Tags: []string{" "}})
+	assert.ErrorIs(t, err, svc.ErrNoTags)
+}
@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// invalidatingClient behaves like scriptedClient but reports every old belief as invalidated
+// when asked for belief validity, recording the IDs it deleted in deleted.
+func invalidatingClient(deleted *[]string) *fakeai.Client {
+	return scriptedClient().OnFunc("Old Beliefs (JSON):", func(ctx context.Context, request openai.ChatCompletionRequest) (string, error) {
+		prompt := request.Messages[len(request.Messages)-1].Content
+		oldJSON := strings.TrimSpace(strings.SplitN(strings.SplitN(prompt, "Old Beliefs (JSON):", 2)[1], "New Beliefs (JSON):", 2)[0])
+		var oldBeliefs []models.Belief
+		if err := json.Unmarshal([]byte(oldJSON), &oldBeliefs); err != nil {
+			return "", fmt.Errorf("unexpected old beliefs: %w", err)
+		}
+		for _, belief := range oldBeliefs {
+			*deleted = append(*deleted, belief.ID)
+		}
+
+		deletedJSON, _ := json.Marshal(*deleted)
+		return fmt.Sprintf(`{"kept_belief_ids": [], "deleted_belief_ids": %s}`, deletedJSON), nil
+	})
+}
+
+func TestUpdateDialecticPrunesInvalidatedBeliefs(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+
+	var deleted []string
+	aih := ai.NewAIHelperWithClient(invalidatingClient(&deleted))
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+
+	// An already answered interaction makes Process derive beliefs that the new answer can invalidate
+	dialectic := models.Dialectic{
+		ID:          "di_validity",
+		SelfModelID: selfModelID,
+		LearningObjective: &models.LearningObjective{
+			Description:         "Understand sleep habits",
+			Topics:              []string{"sleep"},
+			CompletionThreshold: 1,
+		},
+		UserInteractions: []models.DialecticalInteraction{
+			{
+				ID:     "interaction-1",
+				Status: models.StatusAnswered,
+				Type:   models.InteractionTypeQuestionAnswer,
+				Interaction: &models.InteractionData{
+					QuestionAnswer: &models.QuestionAnswerInteraction{
+						Question: models.Question{Question: "How much do you sleep?"},
+						Answer:   models.UserAnswer{UserAnswer: "Five hours is plenty"},
+					},
+				},
+			},
+		},
+		Version: 1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	_, err = dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+		ID:                      dialectic.ID,
+		SelfModelID:             selfModelID,
+		Answer:                  models.UserAnswer{UserAnswer: "Actually I need eight hours"},
+		PruneInvalidatedBeliefs: true,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, deleted, "belief validity should have been checked")
+
+	stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+	require.NoError(t, err)
+	bs := stored.(*models.BeliefSystem)
+
+	require.NotEmpty(t, bs.Beliefs, "the extracted beliefs should remain")
+	for _, belief := range bs.Beliefs {
+		assert.NotContains(t, deleted, belief.ID)
+	}
+	for _, ec := range bs.EpistemicContexts {
+		if ec.PredictiveProcessingContext == nil {
+			continue
+		}
+		for _, bc := range ec.PredictiveProcessingContext.BeliefContexts {
+			assert.NotContains(t, deleted, bc.BeliefID)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// documentEchoClient answers resource extraction prompts with a causal belief for every sentence
+// of the document.
+func documentEchoClient() *fakeai.Client {
+	return fakeai.NewFunc(func(ctx context.Context, request openai.ChatCompletionRequest) (string, error) {
+		document := strings.TrimPrefix(request.Messages[len(request.Messages)-1].Content, "Extract the beliefs from this document: ")
+		var beliefs []string
+		for _, sentence := range strings.Split(document, ".") {
+			if sentence = strings.TrimSpace(sentence); sentence != "" {
+				beliefs = append(beliefs, fmt.Sprintf(`{"content": %q, "type": "causal"}`, "I believe "+sentence))
+			}
+		}
+		return `{"beliefs": [` + strings.Join(beliefs, ",") + `]}`, nil
+	})
+}
+
+func TestBootstrapSelfModelReportsProgress(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, ai.NewAIHelperWithClient(documentEchoClient()))
+
+	const selfModelID = "self-model-1"
+	input := &models.BootstrapSelfModelInput{
+		SelfModelID: selfModelID,
+		Resources: []models.Resource{
+			{Type: models.ResourceTypeChatLog, SourceID: "sleep-journal", Content: "Sleep keeps me sharp. Naps help me focus."},
+			{Type: models.ResourceTypeSurveyResponse, Content: "Vegetables give me energy."},
+		},
+	}
+
+	var events []models.BootstrapProgress
+	output, err := bsvc.BootstrapSelfModel(input, func(p models.BootstrapProgress) {
+		events = append(events, p)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []models.BootstrapProgress{
+		{DocumentsProcessed: 1, DocumentCount: 2, BeliefsExtracted: 2, Document: "sleep-journal"},
+		{DocumentsProcessed: 2, DocumentCount: 2, BeliefsExtracted: 3, Document: "Document 2"},
+	}, events)
+	assert.Len(t, output.Beliefs, 3)
+
+	// Cancelling while the first document is reported stops before the second and stores nothing
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	const cancelledSelfModelID = "self-model-2"
+	input.SelfModelID = cancelledSelfModelID
+	events = nil
+	_, err = bsvc.WithContext(ctx).BootstrapSelfModel(input, func(p models.BootstrapProgress) {
+		events = append(events, p)
+		cancel()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, events, 1)
+
+	stored, err := kv.Retrieve(cancelledSelfModelID, "BeliefSystem")
+	require.NoError(t, err)
+	assert.Empty(t, stored.(*models.BeliefSystem).Beliefs)
+}
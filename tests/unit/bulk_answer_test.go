@@ -0,0 +1,227 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkAnswerClient matches the transcript answers to the questions in order and extracts one belief
+// per answer in a single batch.
+func bulkAnswerClient() *fakeai.Client {
+	return scriptedClient().
+		On(batchExtractionPrompt, `{"interactions": [
+			{"index": 0, "beliefs": ["I believe that eight hours of sleep is enough"]},
+			{"index": 1, "beliefs": ["I believe that plants are healthy"]},
+			{"index": 2, "beliefs": ["I believe that running daily keeps me fit"]}
+		]}`).
+		On(answerMatchingPrompt, "Q1: How do you sleep?\nA1: Eight hours\nQ2: What do you eat?\nA2: Plants\nQ3: Do you exercise?\nA3: I run daily")
+}
+
+const (
+	answerMatchingPrompt  = "Given this text containing answers"
+	batchExtractionPrompt = "Extract beliefs from these interactions"
+)
+
+func TestBulkAnswerAnswersAllPendingQuestions(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	client := bulkAnswerClient()
+	aih := ai.NewAIHelperWithClient(client)
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	dialectic := models.Dialectic{
+		ID:          "di_bulk",
+		SelfModelID: selfModelID,
+		Version:     1,
+		UserInteractions: []models.DialecticalInteraction{
+			questionAnswer("sleep", models.StatusPendingAnswer, 100, "How do you sleep?", ""),
+			questionAnswer("diet", models.StatusPendingAnswer, 200, "What do you eat?", ""),
+			questionAnswer("exercise", models.StatusPendingAnswer, 300, "Do you exercise?", ""),
+		},
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	output, err := dsvc.BulkAnswer(&models.BulkAnswerInput{
+		DialecticID: dialectic.ID,
+		SelfModelID: selfModelID,
+		Answers:     []string{"Eight hours", "Plants", "I run daily"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sleep", "diet", "exercise"}, output.AnsweredInteractionIDs)
+	assert.Equal(t, 1, client.CallsMatching(batchExtractionPrompt))
+	require.Len(t, output.Result.Items, 3)
+	for i, id := range []string{"sleep", "diet", "exercise"} {
+		assert.True(t, output.Result.Items[i].Succeeded)
+		assert.Equal(t, id, output.Result.Items[i].ID)
+	}
+
+	interactions := output.Dialectic.UserInteractions
+	require.Len(t, interactions, 4)
+	wantAnswers := []string{"Eight hours", "Plants", "I run daily"}
+	for i, want := range wantAnswers {
+		assert.Equal(t, models.StatusAnswered, interactions[i].Status)
+		qa := interactions[i].Interaction.QuestionAnswer
+		require.NotNil(t, qa)
+		assert.Equal(t, want, qa.Answer.UserAnswer)
+		require.Len(t, qa.ExtractedBeliefs, 1)
+		assert.Equal(t, interactions[i].ID, qa.ExtractedBeliefs[0].SourceInteractionID)
+	}
+	assert.Equal(t, models.StatusPendingAnswer, interactions[3].Status, "a single next question is asked")
+
+	bs, err := bsvc.GetBeliefSystem(selfModelID)
+	require.NoError(t, err)
+	assert.Len(t, bs.Beliefs, 3)
+
+	stored, err := dsvc.ListDialectics(&models.ListDialecticsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	require.Len(t, stored.Dialectics, 1)
+	assert.Len(t, stored.Dialectics[0].UserInteractions, 4)
+	assert.Equal(t, int32(2), stored.Dialectics[0].Version)
+
+	answered := models.Dialectic{
+		ID:          "di_answered",
+		SelfModelID: selfModelID,
+		UserInteractions: []models.DialecticalInteraction{
+			questionAnswer("diet", models.StatusAnswered, 100, "What do you eat?", "Plants"),
+		},
+	}
+	require.NoError(t, kv.Store(selfModelID, answered.ID, answered, 1))
+	_, err = dsvc.BulkAnswer(&models.BulkAnswerInput{
+		DialecticID: answered.ID,
+		SelfModelID: selfModelID,
+		Answers:     []string{"Plants"},
+	})
+	assert.ErrorIs(t, err, svc.ErrNoPendingQuestions)
+
+	_, err = dsvc.BulkAnswer(&models.BulkAnswerInput{DialecticID: dialectic.ID, SelfModelID: selfModelID, Answers: []string{" "}})
+	assert.ErrorIs(t, err, svc.ErrNoAnswers)
+}
+
+func TestConflictingBulkAnswerLeavesBeliefsUntouched(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	const selfModelID = "self-model-1"
+	dialectic := models.Dialectic{
+		ID:          "di_bulk",
+		SelfModelID: selfModelID,
+		Version:     1,
+		UserInteractions: []models.DialecticalInteraction{
+			questionAnswer("sleep", models.StatusPendingAnswer, 100, "How do you sleep?", ""),
+			questionAnswer("diet", models.StatusPendingAnswer, 200, "What do you eat?", ""),
+			questionAnswer("exercise", models.StatusPendingAnswer, 300, "Do you exercise?", ""),
+		},
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	conflicting := &conflictingStore{KeyValueStore: kv, key: dialectic.ID}
+	aih := ai.NewAIHelperWithClient(bulkAnswerClient())
+	dsvc := svc.NewDialecticService(conflicting, aih, nil, svc.NewDialecticEpistemology(svc.NewBeliefService(conflicting, aih), aih))
+
+	_, err = dsvc.BulkAnswer(&models.BulkAnswerInput{
+		DialecticID: dialectic.ID,
+		SelfModelID: selfModelID,
+		Answers:     []string{"Eight hours", "Plants", "I run daily"},
+	})
+	require.ErrorIs(t, err, db.ErrVersionConflict)
+	assertNoBeliefsStored(t, kv, selfModelID)
+}
+
+func TestBulkAnswerReportsUnmatchedAnswers(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	client := bulkAnswerClient().On(answerMatchingPrompt,
+		"Q1: How do you sleep?\nA1: Eight hours\nC1: 0.9\n"+
+			"Q2: What do you eat?\nA2: No answer provided\nC2: 0\n"+
+			"Q3: Do you exercise?\nA3: The weather is nice\nC3: 0.2")
+	aih := ai.NewAIHelperWithClient(client)
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	dialectic := models.Dialectic{
+		ID:          "di_bulk",
+		SelfModelID: selfModelID,
+		Version:     1,
+		UserInteractions: []models.DialecticalInteraction{
+			questionAnswer("sleep", models.StatusPendingAnswer, 100, "How do you sleep?", ""),
+			questionAnswer("diet", models.StatusPendingAnswer, 200, "What do you eat?", ""),
+			questionAnswer("exercise", models.StatusPendingAnswer, 300, "Do you exercise?", ""),
+		},
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+	answers := []string{"Eight hours", " ", "The weather is nice"}
+
+	_, err = dsvc.BulkAnswer(&models.BulkAnswerInput{DialecticID: dialectic.ID, SelfModelID: selfModelID, Answers: answers})
+	require.ErrorIs(t, err, svc.ErrAnswerNotMatched, "an unmatched answer fails an all-or-nothing batch")
+	assertNoBeliefsStored(t, kv, selfModelID)
+	versions, err := kv.RetrieveAllVersions(selfModelID, dialectic.ID)
+	require.NoError(t, err)
+	assert.Len(t, versions, 1, "the dialectic is left as it was")
+
+	output, err := dsvc.BulkAnswer(&models.BulkAnswerInput{
+		DialecticID: dialectic.ID,
+		SelfModelID: selfModelID,
+		Answers:     answers,
+		Mode:        models.BatchModeBestEffort,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sleep"}, output.AnsweredInteractionIDs)
+
+	require.Len(t, output.Result.Items, 3)
+	assert.True(t, output.Result.Items[0].Succeeded)
+	assert.Equal(t, "sleep", output.Result.Items[0].ID)
+	assert.False(t, output.Result.Items[1].Succeeded)
+	assert.Contains(t, output.Result.Items[1].Error, svc.ErrEmptyContent.Error())
+	assert.False(t, output.Result.Items[2].Succeeded, "a match under the confidence threshold is not an answer")
+	assert.Equal(t, 2, output.Result.Items[2].Index)
+	assert.Equal(t, svc.ErrAnswerNotMatched.Error(), output.Result.Items[2].Error)
+	assert.Equal(t, 2, output.Result.FailedCount())
+
+	interactions := output.Dialectic.UserInteractions
+	require.Len(t, interactions, 3, "no next question is asked while questions are pending")
+	assert.Equal(t, models.StatusPendingAnswer, interactions[2].Status)
+}
+
+func TestBulkAnswerTracesEachMatchToItsOwnAnswer(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	client := bulkAnswerClient().On(answerMatchingPrompt,
+		"Q1: How do you sleep?\nA1: Well\nQ2: What do you eat?\nA2: Plants\nQ3: Do you exercise?\nA3: Well, I run daily")
+	aih := ai.NewAIHelperWithClient(client)
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	dialectic := models.Dialectic{
+		ID:          "di_bulk",
+		SelfModelID: selfModelID,
+		Version:     1,
+		UserInteractions: []models.DialecticalInteraction{
+			questionAnswer("sleep", models.StatusPendingAnswer, 100, "How do you sleep?", ""),
+			questionAnswer("diet", models.StatusPendingAnswer, 200, "What do you eat?", ""),
+			questionAnswer("exercise", models.StatusPendingAnswer, 300, "Do you exercise?", ""),
+		},
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	output, err := dsvc.BulkAnswer(&models.BulkAnswerInput{
+		DialecticID: dialectic.ID,
+		SelfModelID: selfModelID,
+		Answers:     []string{"Well, I run daily", "Plants", "Well"},
+	})
+	require.NoError(t, err)
+	require.Len(t, output.Result.Items, 3)
+	assert.Equal(t, "exercise", output.Result.Items[0].ID, "an answer containing another's match is not credited with it")
+	assert.Equal(t, "diet", output.Result.Items[1].ID)
+	assert.Equal(t, "sleep", output.Result.Items[2].ID)
+}
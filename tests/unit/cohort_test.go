@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListDialecticsFiltersByCohort(t *testing.T) {
+	ctx := context.Background()
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(scriptedClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+	sms := svc.NewSelfModelService(kv, dsvc, bsvc)
+	developer, err := svc.NewDeveloperService(kv, nil).CreateDeveloper(&models.CreateDeveloperInput{Name: "Researcher"})
+	require.NoError(t, err)
+	developerID := developer.Developer.ID
+
+	selfModelIDs := make(map[string]string)
+	dialecticIDs := make(map[string]string)
+	for _, cohort := range []string{"control", "treatment"} {
+		selfModelID := createCohortMember(t, kv, sms, developerID, cohort)
+		selfModelIDs[cohort] = selfModelID
+		created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+		require.NoError(t, err)
+		dialecticIDs[cohort] = created.DialecticID
+	}
+
+	treatment, err := dsvc.ListDialectics(&models.ListDialecticsInput{Cohort: "treatment", DeveloperID: developerID})
+	require.NoError(t, err)
+	require.Len(t, treatment.Dialectics, 1)
+	assert.Equal(t, dialecticIDs["treatment"], treatment.Dialectics[0].ID)
+
+	outside, err := dsvc.ListDialectics(&models.ListDialecticsInput{SelfModelID: selfModelIDs["control"], Cohort: "treatment"})
+	require.NoError(t, err)
+	assert.Empty(t, outside.Dialectics)
+
+	unfiltered, err := dsvc.ListDialectics(&models.ListDialecticsInput{SelfModelID: selfModelIDs["control"]})
+	require.NoError(t, err)
+	require.Len(t, unfiltered.Dialectics, 1)
+	assert.Equal(t, dialecticIDs["control"], unfiltered.Dialectics[0].ID)
+
+	stored, err := sms.GetSelfModel(ctx, &models.GetSelfModelInput{SelfModelID: selfModelIDs["control"]})
+	require.NoError(t, err)
+	assert.Equal(t, "control", stored.SelfModel.Cohort)
+}
+
+func TestListByCohortIsScopedToDeveloper(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(scriptedClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+	sms := svc.NewSelfModelService(kv, dsvc, bsvc)
+	developerSvc := svc.NewDeveloperService(kv, nil)
+
+	// Both developers put one of their users in a cohort with the same name
+	selfModelIDs := make(map[string]string)
+	for _, name := range []string{"first", "second"} {
+		developer, err := developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: name})
+		require.NoError(t, err)
+		selfModelID := createCohortMember(t, kv, sms, developer.Developer.ID, "treatment")
+		selfModelIDs[developer.Developer.ID] = selfModelID
+
+		_, err = dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+		require.NoError(t, err)
+		_, err = bsvc.CreateBelief(&models.CreateBeliefInput{
+			SelfModelID:   selfModelID,
+			BeliefContent: "I believe " + name + " drafts are never final",
+			BeliefType:    models.Statement,
+		})
+		require.NoError(t, err)
+	}
+
+	for developerID, selfModelID := range selfModelIDs {
+		dialectics, err := dsvc.ListDialectics(&models.ListDialecticsInput{Cohort: "treatment", DeveloperID: developerID})
+		require.NoError(t, err)
+		require.Len(t, dialectics.Dialectics, 1)
+		assert.Equal(t, selfModelID, dialectics.Dialectics[0].SelfModelID)
+
+		beliefs, err := bsvc.ListBeliefs(&models.ListBeliefsInput{Cohort: "treatment", DeveloperID: developerID})
+		require.NoError(t, err)
+		require.Len(t, beliefs.Beliefs, 1)
+		assert.Equal(t, selfModelID, beliefs.Beliefs[0].SelfModelID)
+	}
+
+	unknown, err := dsvc.ListDialectics(&models.ListDialecticsInput{Cohort: "treatment"})
+	require.NoError(t, err)
+	assert.Empty(t, unknown.Dialectics, "a cohort without a developer has no members")
+}
+
+// createCohortMember creates a user of developerID with a self model in cohort and returns the
+// self model's ID.
+func createCohortMember(t *testing.T, kv db.KeyValueStore, sms *svc.SelfModelService, developerID, cohort string) string {
+	ctx := context.Background()
+	user, err := svc.NewUserService(kv, nil).CreateUser(&models.CreateUserInput{DeveloperID: developerID})
+	require.NoError(t, err)
+	_, err = sms.CreateSelfModel(ctx, &models.CreateSelfModelInput{ID: user.User.ID})
+	require.NoError(t, err)
+	set, err := sms.SetCohort(ctx, &models.SetCohortInput{SelfModelID: user.User.ID, Cohort: cohort})
+	require.NoError(t, err)
+	assert.Equal(t, cohort, set.SelfModel.Cohort)
+	return user.User.ID
+}
+
+func TestSetCohortRejectsUnconfiguredCohort(t *testing.T) {
+	ctx := context.Background()
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(scriptedClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+	sms := svc.NewSelfModelService(kv, dsvc, bsvc)
+	sms.SetAllowedCohorts([]string{"control", "treatment"})
+
+	_, err = sms.CreateSelfModel(ctx, &models.CreateSelfModelInput{ID: "self-model-1"})
+	require.NoError(t, err)
+
+	_, err = sms.SetCohort(ctx, &models.SetCohortInput{SelfModelID: "self-model-1", Cohort: "placebo"})
+	assert.ErrorIs(t, err, svc.ErrInvalidCohort)
+
+	_, err = sms.SetCohort(ctx, &models.SetCohortInput{SelfModelID: "self-model-1", Cohort: ""})
+	assert.NoError(t, err)
+}
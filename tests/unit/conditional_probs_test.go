@@ -0,0 +1,73 @@
+package unit
+
+import (
+	"math"
+	"testing"
+
+	"epistemic-me-core/svc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeConditionalProbs(t *testing.T) {
+	states := []string{"Positive", "Negative", "Neutral"}
+	tests := []struct {
+		name      string
+		probs     map[string]float32
+		want      map[string]float32
+		wantShift bool
+	}{
+		{
+			name:  "already normalized",
+			probs: map[string]float32{"Positive": 0.5, "Negative": 0.25, "Neutral": 0.25},
+			want:  map[string]float32{"Positive": 0.5, "Negative": 0.25, "Neutral": 0.25},
+		},
+		{
+			name:      "rescaled and missing states filled",
+			probs:     map[string]float32{"Positive": 3, "Negative": 1},
+			want:      map[string]float32{"Positive": 0.75, "Negative": 0.25, "Neutral": 0},
+			wantShift: true,
+		},
+		{
+			name:      "unknown states dropped",
+			probs:     map[string]float32{"Positive": 0.4, "Elated": 0.6},
+			want:      map[string]float32{"Positive": 1, "Negative": 0, "Neutral": 0},
+			wantShift: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, change, err := svc.NormalizeConditionalProbs(tt.probs, states)
+			require.NoError(t, err)
+
+			var sum float64
+			for state, want := range tt.want {
+				assert.InDelta(t, want, got[state], 1e-6, state)
+				assert.GreaterOrEqual(t, got[state], float32(0))
+				sum += float64(got[state])
+			}
+			assert.Len(t, got, len(states))
+			assert.InDelta(t, 1, sum, 1e-6)
+			assert.Equal(t, tt.wantShift, change > 0.05)
+		})
+	}
+}
+
+func TestNormalizeConditionalProbsRejectsInvalidInput(t *testing.T) {
+	states := []string{"Positive", "Negative"}
+	for name, probs := range map[string]map[string]float32{
+		"negative": {"Positive": 1.2, "Negative": -0.2},
+		"all zero": {"Positive": 0, "Negative": 0},
+		"NaN":      {"Positive": float32(math.NaN())},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := svc.NormalizeConditionalProbs(probs, states)
+			assert.ErrorIs(t, err, svc.ErrInvalidConditionalProbs)
+		})
+	}
+
+	got, _, err := svc.NormalizeConditionalProbs(map[string]float32{}, states)
+	require.NoError(t, err)
+	assert.Empty(t, got, "an unknown distribution stays empty")
+}
@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"reflect"
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBeliefDryRunStoresNothing(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	const selfModelID = "self-model-1"
+	existing, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "I believe sleep matters",
+		BeliefType:    models.Statement,
+	})
+	require.NoError(t, err)
+
+	storedCounts := func() map[string]int {
+		counts := make(map[string]int)
+		for _, value := range []interface{}{models.Belief{}, models.BeliefHistory{}, models.BeliefEvidenceLog{}, models.BeliefSystem{}} {
+			stored, err := kv.ListByType(selfModelID, reflect.TypeOf(value))
+			require.NoError(t, err)
+			counts[reflect.TypeOf(value).Name()] = len(stored)
+		}
+		return counts
+	}
+	before := storedCounts()
+
+	preview, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:    selfModelID,
+		BeliefContent:  "I believe exercise improves my mood",
+		BeliefType:     models.Causal,
+		DryRun:         true,
+		BeliefEvidence: &models.BeliefEvidence{Type: models.EvidenceTypeHypothesis, Content: "I feel better after runs"},
+		IdempotencyKey: "create-exercise",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "I believe exercise improves my mood", preview.Belief.GetContentAsString())
+	var previewIDs []string
+	for _, belief := range preview.BeliefSystem.Beliefs {
+		previewIDs = append(previewIDs, belief.ID)
+	}
+	assert.ElementsMatch(t, []string{existing.Belief.ID, preview.Belief.ID}, previewIDs)
+
+	assert.Equal(t, before, storedCounts())
+	_, err = bsvc.GetBelief(&models.GetBeliefInput{SelfModelID: selfModelID, BeliefID: preview.Belief.ID})
+	assert.ErrorIs(t, err, svc.ErrBeliefNotFound)
+
+	// The dry run did not claim the idempotency key
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:    selfModelID,
+		BeliefContent:  "I believe exercise improves my mood",
+		BeliefType:     models.Causal,
+		IdempotencyKey: "create-exercise",
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, preview.Belief.ID, created.Belief.ID)
+
+	// A self model without a belief system is not given one by a dry run
+	_, err = bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   "self-model-2",
+		BeliefContent: "I believe rest matters",
+		BeliefType:    models.Statement,
+		DryRun:        true,
+	})
+	require.NoError(t, err)
+	_, err = kv.Retrieve("self-model-2", "BeliefSystem")
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
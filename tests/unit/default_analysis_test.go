@@ -0,0 +1,54 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultStrategyAnalysis(t *testing.T) {
+	client := fakeai.NewFunc(fakeai.Sequence(`{
+		"coherence": 0.9,
+		"consistency": 1.4,
+		"falsifiability": -0.1,
+		"overall_score": 0.6,
+		"feedback": "Your beliefs about work are coherent but hard to test",
+		"recommendations": ["Name an outcome that would change your mind"],
+		"verified_beliefs": []
+	}`, "I cannot analyze this"))
+	aih := ai.NewAIHelperWithClient(client)
+	beliefSystem := &models.BeliefSystem{Beliefs: []*models.Belief{
+		{ID: "b1", Active: true, Content: []models.Content{{RawStr: "Deep work needs long blocks of time"}}},
+	}}
+
+	analysis, err := aih.GenerateAnalysisForStrategy(ai.StrategyDefault, beliefSystem, nil, ai.InteractionEvent{
+		Question: "How do you plan your day?",
+		Answer:   "I block my mornings for focused work",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, analysis)
+	for _, score := range []float32{analysis.Coherence, analysis.Consistency, analysis.Falsifiability, analysis.OverallScore} {
+		assert.GreaterOrEqual(t, score, float32(0))
+		assert.LessOrEqual(t, score, float32(1))
+	}
+	assert.InDelta(t, 0.9, analysis.Coherence, 1e-6)
+	assert.Equal(t, float32(1), analysis.Consistency)
+	assert.Equal(t, float32(0), analysis.Falsifiability)
+	assert.NotEmpty(t, analysis.Feedback)
+	assert.NotEmpty(t, analysis.Recommendations)
+
+	prompt := client.SystemPrompts()[0]
+	assert.Contains(t, prompt, "- Deep work needs long blocks of time")
+	assert.Contains(t, prompt, "How do you plan your day?")
+	assert.NotContains(t, prompt, "sleep, diet, and exercise")
+
+	// The next answer cannot be parsed
+	analysis, err = aih.GenerateAnalysisForStrategy(ai.StrategyDefault, beliefSystem, nil, ai.InteractionEvent{})
+	assert.Error(t, err)
+	assert.Nil(t, analysis)
+}
@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteBeliefRemovesReferences(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	const selfModelID = "self-model-1"
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "I believe eight hours of sleep is enough",
+		BeliefType:    models.Statement,
+	})
+	require.NoError(t, err)
+	beliefID := created.Belief.ID
+
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", models.BeliefSystem{
+		EpistemicContexts: []*models.EpistemicContext{{
+			AssociatedBeleifs: []string{beliefID, "other-belief"},
+			PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{{ID: "sleep", Name: "Sleep"}},
+				BeliefContexts: []*models.BeliefContext{
+					{BeliefID: beliefID, ObservationContextID: "sleep"},
+					{BeliefID: "other-belief", ObservationContextID: "sleep"},
+				},
+			},
+		}},
+	}, 1))
+
+	interaction := answeredQuestion("interaction-1", "How much do you sleep?", "Eight hours", "Other belief")
+	qa := interaction.Interaction.QuestionAnswer
+	qa.ExtractedBeliefs = append(qa.ExtractedBeliefs, &models.Belief{ID: beliefID})
+	dialectic := models.Dialectic{
+		ID:               "di_1",
+		SelfModelID:      selfModelID,
+		UserInteractions: []models.DialecticalInteraction{interaction},
+		Version:          1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	output, err := bsvc.DeleteBelief(&models.DeleteBeliefInput{SelfModelID: selfModelID, ID: beliefID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"di_1"}, output.DialecticIDs)
+
+	beliefs, err := bsvc.ListBeliefs(&models.ListBeliefsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Empty(t, beliefs.Beliefs)
+
+	stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+	require.NoError(t, err)
+	epistemicContext := stored.(*models.BeliefSystem).EpistemicContexts[0]
+	assert.Equal(t, []string{"other-belief"}, epistemicContext.AssociatedBeleifs)
+	require.Len(t, epistemicContext.PredictiveProcessingContext.BeliefContexts, 1)
+	assert.Equal(t, "other-belief", epistemicContext.PredictiveProcessingContext.BeliefContexts[0].BeliefID)
+
+	storedDialectic, err := kv.Retrieve(selfModelID, dialectic.ID)
+	require.NoError(t, err)
+	extracted := storedDialectic.(*models.Dialectic).UserInteractions[0].Interaction.QuestionAnswer.ExtractedBeliefs
+	require.Len(t, extracted, 1)
+	assert.Equal(t, "interaction-1-belief-0", extracted[0].ID)
+}
+
+func TestDeleteUnknownBelief(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+
+	_, err = svc.NewBeliefService(kv, nil).DeleteBelief(&models.DeleteBeliefInput{SelfModelID: "self-model-1", ID: "unknown"})
+	assert.ErrorIs(t, err, svc.ErrBeliefNotFound)
+}
@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialecticBaseline(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(scriptedClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	initial := models.BeliefSystem{
+		Beliefs: []*models.Belief{{
+			ID:      "bi_initial",
+			Content: []models.Content{{RawStr: "I believe naps are a waste of time"}},
+			Type:    models.Statement,
+		}},
+	}
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", initial, 1))
+
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{
+		SelfModelID: selfModelID,
+		LearningObjective: &models.LearningObjective{
+			Description: "Understand sleep habits",
+			Topics:      []string{"sleep"},
+		},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+			ID:          created.DialecticID,
+			SelfModelID: selfModelID,
+			Answer:      models.UserAnswer{UserAnswer: "I always sleep eight hours"},
+		})
+		require.NoError(t, err)
+	}
+
+	current, err := bsvc.GetBeliefSystem(selfModelID)
+	require.NoError(t, err)
+	require.NotEqual(t, initial.Beliefs, current.Beliefs, "the updates should have changed the belief system")
+
+	baseline, err := dsvc.GetDialecticBaseline(&models.GetDialecticBaselineInput{
+		DialecticID: created.DialecticID,
+		SelfModelID: selfModelID,
+	})
+	require.NoError(t, err)
+	assert.False(t, baseline.Baseline.Empty)
+	assert.Equal(t, initial.Beliefs, baseline.Baseline.BeliefSystem.Beliefs)
+
+	// The baseline is not part of the self model's dialectics
+	listed, err := dsvc.ListDialectics(&models.ListDialecticsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Len(t, listed.Dialectics, 1)
+
+	_, err = dsvc.DeleteDialectic(&models.DeleteDialecticInput{ID: created.DialecticID, SelfModelID: selfModelID})
+	require.NoError(t, err)
+	_, err = dsvc.GetDialecticBaseline(&models.GetDialecticBaselineInput{
+		DialecticID: created.DialecticID,
+		SelfModelID: selfModelID,
+	})
+	assert.Error(t, err)
+}
+
+func TestDialecticBaseline_EmptyMarker(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	dsvc := svc.NewDialecticService(kv, ai.NewAIHelperWithClient(scriptedClient()), nil, nil)
+
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{
+		SelfModelID:       "self-model-without-beliefs",
+		LearningObjective: &models.LearningObjective{Description: "Understand sleep habits", Topics: []string{"sleep"}},
+	})
+	require.NoError(t, err)
+
+	baseline, err := dsvc.GetDialecticBaseline(&models.GetDialecticBaselineInput{
+		DialecticID: created.DialecticID,
+		SelfModelID: "self-model-without-beliefs",
+	})
+	require.NoError(t, err)
+	assert.True(t, baseline.Baseline.Empty)
+	assert.Nil(t, baseline.Baseline.BeliefSystem)
+}
@@ -0,0 +1,134 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readBarrierStore blocks reads of a single key until every expected reader has read it,
+// so concurrent read-modify-write cycles are guaranteed to overlap.
+type readBarrierStore struct {
+	db.KeyValueStore
+	key   string
+	reads sync.WaitGroup
+}
+
+func (s *readBarrierStore) Retrieve(developerId string, key string) (interface{}, error) {
+	value, err := s.KeyValueStore.Retrieve(developerId, key)
+	if key == s.key {
+		s.reads.Done()
+		s.reads.Wait()
+	}
+	return value, err
+}
+
+// conflictingStore fails every write of a single key with a version conflict, as if another
+// request had updated it first.
+type conflictingStore struct {
+	db.KeyValueStore
+	key string
+}
+
+func (s *conflictingStore) Store(developerId string, key string, value interface{}, version int, opts ...db.StoreOption) error {
+	if key == s.key {
+		return fmt.Errorf("%w: %s", db.ErrVersionConflict, key)
+	}
+	return s.KeyValueStore.Store(developerId, key, value, version, opts...)
+}
+
+func TestConflictingUpdateDialecticLeavesBeliefsUntouched(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(scriptedClient())
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(svc.NewBeliefService(kv, aih), aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+
+	conflicting := &conflictingStore{KeyValueStore: kv, key: created.DialecticID}
+	conflictingSvc := svc.NewDialecticService(conflicting, aih, nil, svc.NewDialecticEpistemology(svc.NewBeliefService(conflicting, aih), aih))
+	_, err = conflictingSvc.UpdateDialectic(&models.UpdateDialecticInput{
+		ID:          created.DialecticID,
+		SelfModelID: selfModelID,
+		Answer:      models.UserAnswer{UserAnswer: "Sleep is important to me"},
+	})
+	require.ErrorIs(t, err, db.ErrVersionConflict)
+
+	assertNoBeliefsStored(t, kv, selfModelID)
+}
+
+// assertNoBeliefsStored checks that the self model's belief system, if any, holds no beliefs.
+func assertNoBeliefsStored(t *testing.T, kv db.KeyValueStore, selfModelID string) {
+	t.Helper()
+	stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+	if errors.Is(err, db.ErrNotFound) {
+		return
+	}
+	require.NoError(t, err)
+	assert.Empty(t, stored.(*models.BeliefSystem).Beliefs, "no belief may be stored for an answer whose dialectic was not")
+}
+
+func TestConcurrentUpdateDialecticConflicts(t *testing.T) {
+	jsonStore, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	sqliteStore, err := db.NewSQLiteKeyValueStore("")
+	require.NoError(t, err)
+	defer sqliteStore.Close()
+
+	for name, store := range map[string]db.KeyValueStore{"json": jsonStore, "sqlite": sqliteStore} {
+		t.Run(name, func(t *testing.T) {
+			const selfModelID = "self-model-1"
+			dialectic := models.Dialectic{
+				ID:               "di_concurrent",
+				SelfModelID:      selfModelID,
+				UserInteractions: []models.DialecticalInteraction{},
+				Version:          1,
+			}
+			require.NoError(t, store.Store(selfModelID, dialectic.ID, dialectic, int(dialectic.Version)))
+
+			barrier := &readBarrierStore{KeyValueStore: store, key: dialectic.ID}
+			barrier.reads.Add(2)
+			dsvc := svc.NewDialecticService(barrier, nil, nil, nil)
+
+			errs := make([]error, 2)
+			var wg sync.WaitGroup
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					_, errs[i] = dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+						ID:          dialectic.ID,
+						SelfModelID: selfModelID,
+					})
+				}(i)
+			}
+			wg.Wait()
+
+			conflicts := 0
+			for _, err := range errs {
+				if err == nil {
+					continue
+				}
+				require.True(t, errors.Is(err, db.ErrVersionConflict), "unexpected error: %v", err)
+				conflicts++
+			}
+			assert.Equal(t, 1, conflicts, "exactly one concurrent update should conflict")
+
+			stored, err := store.Retrieve(selfModelID, dialectic.ID)
+			require.NoError(t, err)
+			assert.Equal(t, int32(2), stored.(*models.Dialectic).Version)
+		})
+	}
+}
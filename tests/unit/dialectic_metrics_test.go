@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// relevanceClient judges batched relevance checks, treating "I don't know" as the only irrelevant
+// answer.
+func relevanceClient() *fakeai.Client {
+	return fakeai.NewFunc(func(ctx context.Context, request openai.ChatCompletionRequest) (string, error) {
+		_, pairsJson, found := strings.Cut(request.Messages[len(request.Messages)-1].Content, "pairs: ")
+		if !found {
+			return "", fmt.Errorf("unexpected prompt")
+		}
+		var pairs []ai.InteractionEvent
+		if err := json.Unmarshal([]byte(pairsJson), &pairs); err != nil {
+			return "", err
+		}
+		relevant := make([]bool, len(pairs))
+		for i, pair := range pairs {
+			relevant[i] = pair.Answer != "I don't know"
+		}
+		content, _ := json.Marshal(map[string][]bool{"relevant": relevant})
+		return string(content), nil
+	})
+}
+
+func answeredQuestion(id, question, answer string, beliefs ...string) models.DialecticalInteraction {
+	qa := &models.QuestionAnswerInteraction{
+		Question: models.Question{Question: question},
+		Answer:   models.UserAnswer{UserAnswer: answer},
+	}
+	for i, belief := range beliefs {
+		qa.ExtractedBeliefs = append(qa.ExtractedBeliefs, &models.Belief{
+			ID:      fmt.Sprintf("%s-belief-%d", id, i),
+			Content: []models.Content{{RawStr: belief}},
+		})
+	}
+	return models.DialecticalInteraction{
+		ID:          id,
+		Status:      models.StatusAnswered,
+		Type:        models.InteractionTypeQuestionAnswer,
+		Interaction: &models.InteractionData{QuestionAnswer: qa},
+	}
+}
+
+func TestComputeDialecticMetrics(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	client := relevanceClient()
+	dsvc := svc.NewDialecticService(kv, ai.NewAIHelperWithClient(client), nil, nil)
+
+	const selfModelID = "self-model-1"
+	pending := answeredQuestion("q4", "Do you exercise?", "")
+	pending.Status = models.StatusPendingAnswer
+	dialectic := models.Dialectic{
+		ID:          "di_metrics",
+		SelfModelID: selfModelID,
+		LearningObjective: &models.LearningObjective{
+			Description: "Learn about rest",
+			Topics:      []string{"sleep", "diet", "exercise", "stress"},
+		},
+		LearningObjectiveProgress: &models.LearningObjectiveProgress{CompletionPercentage: 55},
+		UserInteractions: []models.DialecticalInteraction{
+			answeredQuestion("q1", "How do you sleep?", "Eight hours, every night", "I believe eight hours of sleep is enough", "I believe a routine helps"),
+			answeredQuestion("q2", "What is your diet like?", "I don't know"),
+			answeredQuestion("q3", "How do you handle stress?", "Walks and a good diet", "I believe walking relieves stress"),
+			pending,
+		},
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	// Only q1's beliefs have been rated; the unrelated belief is ignored
+	bs := models.BeliefSystem{
+		EpistemicContexts: []*models.EpistemicContext{{
+			PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				BeliefContexts: []*models.BeliefContext{
+					{BeliefID: "q1-belief-0", ConfidenceRatings: []models.ConfidenceRating{{ConfidenceScore: 0.2}, {ConfidenceScore: 0.9}}},
+					{BeliefID: "q1-belief-1", ConfidenceRatings: []models.ConfidenceRating{{ConfidenceScore: 0.7}}},
+					{BeliefID: "unrelated", ConfidenceRatings: []models.ConfidenceRating{{ConfidenceScore: 0.1}}},
+				},
+			},
+		}},
+	}
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", bs, 1))
+
+	output, err := dsvc.ComputeDialecticMetrics(&models.ComputeDialecticMetricsInput{DialecticID: dialectic.ID, SelfModelID: selfModelID})
+	require.NoError(t, err)
+	metrics := output.Metrics
+
+	assert.Equal(t, dialectic.ID, metrics.DialecticID)
+	assert.Equal(t, int32(4), metrics.QuestionCount)
+	assert.Equal(t, int32(3), metrics.AnsweredCount)
+	assert.Equal(t, int32(3), metrics.ExtractedBeliefCount)
+	assert.InDelta(t, 1.0, metrics.BeliefYieldPerQuestion, 0.001)
+
+	// sleep, then diet, then stress; exercise is only asked about in the pending question
+	assert.Equal(t, []float32{0.25, 0.5, 0.75}, metrics.TopicCoverageProgression)
+	for i := 1; i < len(metrics.TopicCoverageProgression); i++ {
+		assert.GreaterOrEqual(t, metrics.TopicCoverageProgression[i], metrics.TopicCoverageProgression[i-1])
+	}
+	assert.Equal(t, float32(55), metrics.CompletionPercentage)
+
+	assert.Equal(t, 1, client.Calls(), "relevance should be checked in a single batch")
+	assert.Equal(t, int32(2), metrics.RelevantAnswerCount)
+	assert.InDelta(t, 2.0/3.0, metrics.AnswerRelevance, 0.001)
+
+	assert.Equal(t, int32(2), metrics.RatedBeliefCount)
+	assert.InDelta(t, 0.8, metrics.AverageBeliefConfidence, 0.001)
+	assert.NotZero(t, metrics.ComputedAtMillisUTC)
+
+	_, err = dsvc.ComputeDialecticMetrics(&models.ComputeDialecticMetricsInput{DialecticID: "missing", SelfModelID: selfModelID})
+	assert.ErrorIs(t, err, svc.ErrDialecticNotFound)
+}
+
+func TestComputeDialecticMetricsBatchesRelevanceChecks(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	client := relevanceClient()
+	dsvc := svc.NewDialecticService(kv, ai.NewAIHelperWithClient(client), nil, nil)
+
+	const selfModelID = "self-model-1"
+	dialectic := models.Dialectic{ID: "di_long", SelfModelID: selfModelID}
+	for i := 0; i < 25; i++ {
+		dialectic.UserInteractions = append(dialectic.UserInteractions,
+			answeredQuestion(fmt.Sprintf("q%d", i), "How do you sleep?", "Eight hours"))
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	output, err := dsvc.ComputeDialecticMetrics(&models.ComputeDialecticMetricsInput{DialecticID: dialectic.ID, SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, client.Calls())
+	assert.Equal(t, int32(25), output.Metrics.RelevantAnswerCount)
+	assert.InDelta(t, 1.0, output.Metrics.AnswerRelevance, 0.001)
+	assert.Empty(t, output.Metrics.TopicCoverageProgression)
+	assert.Zero(t, output.Metrics.RatedBeliefCount)
+}
@@ -0,0 +1,164 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// answerEchoClient extracts a single belief quoting the answer it was given.
+func answerEchoClient() *fakeai.Client {
+	return fakeai.NewFunc(func(ctx context.Context, request openai.ChatCompletionRequest) (string, error) {
+		user := request.Messages[len(request.Messages)-1].Content
+		answer := user[strings.Index(user, `"answer":"`)+len(`"answer":"`):]
+		answer = answer[:strings.Index(answer, `"`)]
+		return `{"beliefs": [{"content": "I believe ` + answer + `", "type": "statement"}]}`, nil
+	})
+}
+
+func TestEditAnswerReplacesExtractedBeliefs(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	dsvc := svc.NewDialecticService(kv, ai.NewAIHelperWithClient(answerEchoClient()), nil, nil)
+
+	const selfModelID = "self-model-1"
+	oldBelief := &models.Belief{ID: "old-belief", Content: []models.Content{{RawStr: "I believe five hours is plenty"}}}
+	otherBelief := &models.Belief{ID: "other-belief", Content: []models.Content{{RawStr: "I believe exercise matters"}}}
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", models.BeliefSystem{
+		Beliefs: []*models.Belief{oldBelief, otherBelief},
+	}, 1))
+
+	dialectic := models.Dialectic{
+		ID:          "di_edit",
+		SelfModelID: selfModelID,
+		UserInteractions: []models.DialecticalInteraction{
+			{
+				ID:     "answered",
+				Status: models.StatusAnswered,
+				Type:   models.InteractionTypeQuestionAnswer,
+				Interaction: &models.InteractionData{
+					QuestionAnswer: &models.QuestionAnswerInteraction{
+						Question:         models.Question{Question: "How much do you sleep?"},
+						Answer:           models.UserAnswer{UserAnswer: "Five hours is plenty"},
+						ExtractedBeliefs: []*models.Belief{oldBelief},
+					},
+				},
+			},
+			{
+				ID:     "pending",
+				Status: models.StatusPendingAnswer,
+				Type:   models.InteractionTypeQuestionAnswer,
+				Interaction: &models.InteractionData{
+					QuestionAnswer: &models.QuestionAnswerInteraction{
+						Question: models.Question{Question: "Do you exercise?"},
+					},
+				},
+			},
+		},
+		Version: 1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	output, err := dsvc.EditAnswer(&models.EditAnswerInput{
+		DialecticID:   dialectic.ID,
+		SelfModelID:   selfModelID,
+		InteractionID: "answered",
+		Answer:        "eight hours keeps me sharp",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old-belief"}, output.RemovedBeliefIDs)
+	require.Len(t, output.ExtractedBeliefs, 1)
+
+	stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+	require.NoError(t, err)
+	var contents []string
+	for _, belief := range stored.(*models.BeliefSystem).Beliefs {
+		contents = append(contents, belief.GetContentAsString())
+	}
+	assert.ElementsMatch(t, []string{"I believe exercise matters", "I believe eight hours keeps me sharp"}, contents)
+
+	qa := output.Dialectic.UserInteractions[0].Interaction.QuestionAnswer
+	assert.Equal(t, "eight hours keeps me sharp", qa.Answer.UserAnswer)
+	assert.Equal(t, output.ExtractedBeliefs, qa.ExtractedBeliefs)
+	assert.Equal(t, int32(2), output.Dialectic.Version)
+
+	_, err = dsvc.EditAnswer(&models.EditAnswerInput{
+		DialecticID:   dialectic.ID,
+		SelfModelID:   selfModelID,
+		InteractionID: "pending",
+		Answer:        "Every day",
+	})
+	assert.ErrorIs(t, err, svc.ErrInteractionNotAnswered)
+
+	_, err = dsvc.EditAnswer(&models.EditAnswerInput{
+		DialecticID:   dialectic.ID,
+		SelfModelID:   selfModelID,
+		InteractionID: "missing",
+		Answer:        "Every day",
+	})
+	assert.ErrorIs(t, err, svc.ErrInteractionNotFound)
+}
+
+func TestEditAnswerConflictLeavesBeliefSystem(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+
+	const selfModelID = "self-model-1"
+	oldBelief := &models.Belief{ID: "old-belief", Content: []models.Content{{RawStr: "I believe five hours is plenty"}}}
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", models.BeliefSystem{Beliefs: []*models.Belief{oldBelief}}, 1))
+	dialectic := models.Dialectic{
+		ID:          "di_conflict",
+		SelfModelID: selfModelID,
+		UserInteractions: []models.DialecticalInteraction{{
+			ID:     "answered",
+			Status: models.StatusAnswered,
+			Type:   models.InteractionTypeQuestionAnswer,
+			Interaction: &models.InteractionData{
+				QuestionAnswer: &models.QuestionAnswerInteraction{
+					Question:         models.Question{Question: "How much do you sleep?"},
+					Answer:           models.UserAnswer{UserAnswer: "Five hours is plenty"},
+					ExtractedBeliefs: []*models.Belief{oldBelief},
+				},
+			},
+		}},
+		Version: 1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	// A newer version of the dialectic is stored while the edited answer's beliefs are extracted, as
+	// a concurrent edit would
+	var edited bool
+	client := answerEchoClient().Before(func(ctx context.Context, request openai.ChatCompletionRequest) error {
+		if !edited {
+			edited = true
+			concurrent := dialectic
+			concurrent.Version = 2
+			require.NoError(t, kv.Store(selfModelID, dialectic.ID, concurrent, 2))
+		}
+		return nil
+	})
+	dsvc := svc.NewDialecticService(kv, ai.NewAIHelperWithClient(client), nil, nil)
+	_, err = dsvc.EditAnswer(&models.EditAnswerInput{
+		DialecticID:   dialectic.ID,
+		SelfModelID:   selfModelID,
+		InteractionID: "answered",
+		Answer:        "eight hours keeps me sharp",
+	})
+	assert.ErrorIs(t, err, db.ErrVersionConflict)
+
+	stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+	require.NoError(t, err)
+	beliefs := stored.(*models.BeliefSystem).Beliefs
+	require.Len(t, beliefs, 1)
+	assert.Equal(t, "old-belief", beliefs[0].ID)
+}
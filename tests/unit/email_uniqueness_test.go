@@ -0,0 +1,75 @@
+package unit
+
+import (
+	"reflect"
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDeveloperRejectsDuplicateEmail(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	developerSvc := svc.NewDeveloperService(kv, nil)
+
+	_, err = developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "First", Email: "dev@example.com"})
+	require.NoError(t, err)
+
+	_, err = developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Second", Email: " Dev@Example.com"})
+	assert.ErrorIs(t, err, svc.ErrEmailTaken)
+
+	developers, err := kv.ListAllByType(reflect.TypeOf(models.Developer{}))
+	require.NoError(t, err)
+	assert.Len(t, developers, 1)
+}
+
+func TestCreateUserEmailIsUniquePerDeveloper(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	usvc := svc.NewUserService(kv, nil)
+
+	_, err = usvc.CreateUser(&models.CreateUserInput{DeveloperID: "dev_one", Email: "user@example.com"})
+	require.NoError(t, err)
+
+	_, err = usvc.CreateUser(&models.CreateUserInput{DeveloperID: "dev_one", Email: "USER@example.com"})
+	assert.ErrorIs(t, err, svc.ErrEmailTaken)
+
+	_, err = usvc.CreateUser(&models.CreateUserInput{DeveloperID: "dev_two", Email: "user@example.com"})
+	assert.NoError(t, err)
+
+	// Users without an email never conflict
+	for i := 0; i < 2; i++ {
+		_, err = usvc.CreateUser(&models.CreateUserInput{DeveloperID: "dev_one"})
+		assert.NoError(t, err)
+	}
+}
+
+func TestIndexEmailsClaimsExistingEmails(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	developerSvc := svc.NewDeveloperService(kv, nil)
+	usvc := svc.NewUserService(kv, nil)
+
+	// A developer and a user stored before emails were claimed
+	developer := models.Developer{ID: "dev_legacy", Name: "Legacy", Email: "dev@example.com", APIKeys: []string{"legacy-key"}}
+	require.NoError(t, kv.Store(developer.ID, "developer", developer, 1))
+	user := models.User{ID: "user_legacy", DeveloperID: developer.ID, Email: "user@example.com"}
+	require.NoError(t, kv.Store(developer.ID, "user_"+user.ID, user, 1))
+
+	_, err = developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Before", Email: "other@example.com"})
+	require.NoError(t, err)
+	require.NoError(t, developerSvc.IndexEmails())
+	require.NoError(t, developerSvc.IndexEmails(), "indexing again keeps the claims")
+
+	_, err = developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Second", Email: "DEV@example.com"})
+	assert.ErrorIs(t, err, svc.ErrEmailTaken)
+	_, err = usvc.CreateUser(&models.CreateUserInput{DeveloperID: developer.ID, Email: "user@example.com"})
+	assert.ErrorIs(t, err, svc.ErrEmailTaken)
+	_, err = developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Third", Email: "other@example.com"})
+	assert.ErrorIs(t, err, svc.ErrEmailTaken, "emails claimed at registration are left as they are")
+}
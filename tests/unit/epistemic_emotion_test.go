@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// predictingOptHelper extracts the answer itself as the only belief and predicts that people sleep
+// eight hours. Answers mentioning eight hours are placed as positive, all others as negative.
+type predictingOptHelper struct{}
+
+func (h *predictingOptHelper) GetInteractionEventAsBelief(event svc.InteractionEvent) ([]string, error) {
+	return []string{event.Answer}, nil
+}
+
+func (h *predictingOptHelper) GenerateQuestion(beliefSystem string, previousEvents []svc.InteractionEvent) (string, error) {
+	return "What do you believe about sleep?", nil
+}
+
+func (h *predictingOptHelper) ExtractQuestionsFromText(text string) ([]string, error) {
+	return nil, nil
+}
+
+func (h *predictingOptHelper) PredictAnswer(question string) (string, error) {
+	return "I sleep eight hours a night", nil
+}
+
+func (h *predictingOptHelper) GetAnswerStateDistributions(question string, answers []string, states []string) ([]map[string]float32, error) {
+	distributions := make([]map[string]float32, len(answers))
+	for i, answer := range answers {
+		if strings.Contains(answer, "eight hours") {
+			distributions[i] = map[string]float32{"Positive": 0.8, "Negative": 0.1, "Neutral": 0.1}
+		} else {
+			distributions[i] = map[string]float32{"Positive": 0.05, "Negative": 0.9, "Neutral": 0.05}
+		}
+	}
+	return distributions, nil
+}
+
+func TestEpistemicEmotionFromPrediction(t *testing.T) {
+	tests := []struct {
+		name            string
+		answer          string
+		computeSurprise bool
+		wantEmotion     models.EpistemicEmotion
+		wantIntensity   func(t *testing.T, intensity float32)
+	}{
+		{
+			name:            "matching answer confirms the prediction",
+			answer:          "I always sleep eight hours",
+			computeSurprise: true,
+			wantEmotion:     models.Confirmation,
+			wantIntensity:   func(t *testing.T, intensity float32) { assert.Greater(t, intensity, float32(0.9)) },
+		},
+		{
+			name:            "contradicting answer is a surprise",
+			answer:          "I barely sleep at all",
+			computeSurprise: true,
+			wantEmotion:     models.Surprise,
+			wantIntensity:   func(t *testing.T, intensity float32) { assert.Greater(t, intensity, float32(0.5)) },
+		},
+		{
+			name:          "without a prediction the defaults are kept",
+			answer:        "I barely sleep at all",
+			wantEmotion:   models.Confirmation,
+			wantIntensity: func(t *testing.T, intensity float32) { assert.Equal(t, float32(0.5), intensity) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kv, err := db.NewKeyValueStore("")
+			require.NoError(t, err)
+			aih := ai.NewAIHelperWithClient(scriptedClient())
+			epistemology := svc.NewDialecticEpistemology(svc.NewBeliefService(kv, aih), aih)
+			osvc := svc.NewOptimizedDialecticService(kv, &predictingOptHelper{}, epistemology)
+
+			const selfModelID = "self-model-1"
+			dialectic := models.Dialectic{
+				ID:          "di_emotion",
+				SelfModelID: selfModelID,
+				UserInteractions: []models.DialecticalInteraction{{
+					ID:     "question-1",
+					Status: models.StatusPendingAnswer,
+					Type:   models.InteractionTypeQuestionAnswer,
+					Interaction: &models.InteractionData{
+						QuestionAnswer: &models.QuestionAnswerInteraction{
+							Question: models.Question{Question: "How much do you sleep?"},
+						},
+					},
+				}},
+			}
+			require.NoError(t, kv.Store(selfModelID, "Dialectic:"+dialectic.ID, dialectic, 1))
+
+			output, err := osvc.OptimizedUpdateDialectic(&models.UpdateDialecticInput{
+				ID:              dialectic.ID,
+				SelfModelID:     selfModelID,
+				Answer:          models.UserAnswer{UserAnswer: tt.answer},
+				ComputeSurprise: tt.computeSurprise,
+			})
+			require.NoError(t, err)
+			_, predicted := output.Dialectic.UserInteractions[0].SurpriseScore()
+			assert.Equal(t, tt.computeSurprise, predicted)
+
+			stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+			require.NoError(t, err)
+			bs := stored.(*models.BeliefSystem)
+			var beliefID string
+			for _, belief := range bs.Beliefs {
+				if belief.GetContentAsString() == tt.answer {
+					beliefID = belief.ID
+				}
+			}
+			require.NotEmpty(t, beliefID)
+
+			var found bool
+			for _, beliefContext := range bs.EpistemicContexts[0].PredictiveProcessingContext.BeliefContexts {
+				if beliefContext.BeliefID != beliefID {
+					continue
+				}
+				found = true
+				assert.Equal(t, tt.wantEmotion, beliefContext.EpistemicEmotion)
+				tt.wantIntensity(t, beliefContext.EmotionIntensity)
+			}
+			assert.True(t, found, "the extracted belief should have a belief context")
+		})
+	}
+}
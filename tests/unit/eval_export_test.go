@@ -0,0 +1,75 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportDialecticForEval(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	dsvc := svc.NewDialecticService(kv, nil, nil, nil)
+
+	const selfModelID = "self-model-1"
+	interaction := func(id string, status models.DialecticalInteractionStatus, question, answer string, beliefs ...string) models.DialecticalInteraction {
+		extracted := make([]*models.Belief, 0, len(beliefs))
+		for _, content := range beliefs {
+			extracted = append(extracted, &models.Belief{ID: content, Content: []models.Content{{RawStr: content}}})
+		}
+		return models.DialecticalInteraction{
+			ID:     id,
+			Status: status,
+			Type:   models.InteractionTypeQuestionAnswer,
+			Interaction: &models.InteractionData{
+				QuestionAnswer: &models.QuestionAnswerInteraction{
+					Question:         models.Question{Question: question},
+					Answer:           models.UserAnswer{UserAnswer: answer},
+					ExtractedBeliefs: extracted,
+				},
+			},
+		}
+	}
+	dialectic := models.Dialectic{
+		ID:          "di_eval",
+		SelfModelID: selfModelID,
+		UserInteractions: []models.DialecticalInteraction{
+			interaction("sleep", models.StatusAnswered, "How much do you sleep?", "Eight hours", "I believe sleep matters"),
+			interaction("exercise", models.StatusAnswered, "Do you exercise?", "Not really"),
+			interaction("diet", models.StatusPendingAnswer, "What do you eat?", ""),
+		},
+		Version: 1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	output, err := dsvc.ExportDialecticForEval(&models.ExportDialecticForEvalInput{DialecticID: dialectic.ID, SelfModelID: selfModelID})
+	require.NoError(t, err)
+	document, err := json.Marshal(output.Export)
+	require.NoError(t, err)
+
+	var exported struct {
+		TestCases []map[string]any `json:"test_cases"`
+	}
+	require.NoError(t, json.Unmarshal(document, &exported))
+	require.Len(t, exported.TestCases, 2, "only answered interactions are exported")
+	for _, testCase := range exported.TestCases {
+		for _, key := range []string{"name", "input", "actual_output", "context", "additional_metadata"} {
+			assert.Contains(t, testCase, key)
+		}
+	}
+	first := exported.TestCases[0]
+	assert.Equal(t, "How much do you sleep?", first["input"])
+	assert.Equal(t, "Eight hours", first["actual_output"])
+	assert.Equal(t, []any{"I believe sleep matters"}, first["context"])
+	assert.Equal(t, "sleep", first["additional_metadata"].(map[string]any)["interaction_id"])
+	assert.Equal(t, []any{}, exported.TestCases[1]["context"])
+
+	_, err = dsvc.ExportDialecticForEval(&models.ExportDialecticForEvalInput{DialecticID: "di_missing", SelfModelID: selfModelID})
+	assert.ErrorIs(t, err, svc.ErrDialecticNotFound)
+}
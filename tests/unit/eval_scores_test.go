@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestEvalScores(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	dsvc := svc.NewDialecticService(kv, nil, nil, nil)
+
+	const selfModelID = "self-model-1"
+	answered := func(id string) models.DialecticalInteraction {
+		return models.DialecticalInteraction{
+			ID:     id,
+			Status: models.StatusAnswered,
+			Type:   models.InteractionTypeQuestionAnswer,
+			Interaction: &models.InteractionData{
+				QuestionAnswer: &models.QuestionAnswerInteraction{
+					Question: models.Question{Question: "Question " + id},
+					Answer:   models.UserAnswer{UserAnswer: "Answer " + id},
+				},
+			},
+		}
+	}
+	dialectic := models.Dialectic{
+		ID:               "di_scored",
+		SelfModelID:      selfModelID,
+		UserInteractions: []models.DialecticalInteraction{answered("sleep"), answered("exercise")},
+		Version:          1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	ingest := func(scores ...models.InteractionEvalScore) error {
+		_, err := dsvc.IngestEvalScores(&models.IngestEvalScoresInput{DialecticID: dialectic.ID, SelfModelID: selfModelID, Scores: scores})
+		return err
+	}
+	require.NoError(t, ingest(
+		models.InteractionEvalScore{InteractionID: "sleep", Metric: "answer_relevancy", Score: 0.4, Passed: false},
+		models.InteractionEvalScore{InteractionID: "sleep", Metric: "faithfulness", Score: 0.9, Passed: true},
+		models.InteractionEvalScore{InteractionID: "exercise", Metric: "answer_relevancy", Score: 0.7, Passed: true},
+	))
+	// Scoring a metric again replaces its earlier score
+	require.NoError(t, ingest(models.InteractionEvalScore{InteractionID: "sleep", Metric: "answer_relevancy", Score: 0.8, Passed: true}))
+
+	got, err := dsvc.GetDialectic(&models.GetDialecticInput{ID: dialectic.ID, SelfModelID: selfModelID})
+	require.NoError(t, err)
+	metrics := func(interaction models.DialecticalInteraction) map[string]float64 {
+		scores := make(map[string]float64)
+		for _, score := range interaction.EvalScores {
+			assert.NotZero(t, score.IngestedAtMillisUTC)
+			scores[score.Metric] = score.Score
+		}
+		return scores
+	}
+	assert.Equal(t, map[string]float64{"answer_relevancy": 0.8, "faithfulness": 0.9}, metrics(got.Dialectic.UserInteractions[0]))
+	assert.Equal(t, map[string]float64{"answer_relevancy": 0.7}, metrics(got.Dialectic.UserInteractions[1]))
+	assert.True(t, got.Dialectic.UserInteractions[1].EvalScores[0].Passed)
+	assert.Len(t, got.Dialectic.UserInteractions[0].ToProto().EvalScores, 2)
+
+	// A score for an unknown interaction rejects the whole request
+	err = ingest(
+		models.InteractionEvalScore{InteractionID: "exercise", Metric: "faithfulness", Score: 1, Passed: true},
+		models.InteractionEvalScore{InteractionID: "missing", Metric: "faithfulness", Score: 1, Passed: true},
+	)
+	assert.ErrorIs(t, err, svc.ErrInteractionNotFound)
+	got, err = dsvc.GetDialectic(&models.GetDialecticInput{ID: dialectic.ID, SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Len(t, got.Dialectic.UserInteractions[1].EvalScores, 1)
+
+	assert.ErrorIs(t, ingest(models.InteractionEvalScore{InteractionID: "sleep", Score: 1}), svc.ErrInvalidEvalScore)
+}
@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddEvidenceMovesConfidence(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	const selfModelID = "self-model-1"
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "Cold showers help me wake up",
+		BeliefType:    models.Statement,
+		BeliefEvidence: &models.BeliefEvidence{
+			Type:    models.EvidenceTypeHypothesis,
+			Content: "Cold water raises alertness",
+		},
+	})
+	require.NoError(t, err)
+	initial := created.Belief.Confidence
+	assert.Greater(t, initial, 0.5)
+
+	supported, err := bsvc.AddEvidence(&models.AddEvidenceInput{
+		SelfModelID: selfModelID,
+		BeliefID:    created.Belief.ID,
+		Evidence: &models.BeliefEvidence{
+			Type:    models.EvidenceTypeAction,
+			Action:  "Took a cold shower",
+			Outcome: "Felt awake within minutes",
+		},
+	})
+	require.NoError(t, err)
+	assert.Greater(t, supported.Belief.Confidence, initial, "supporting evidence raises confidence")
+
+	countered, err := bsvc.AddEvidence(&models.AddEvidenceInput{
+		SelfModelID: selfModelID,
+		BeliefID:    created.Belief.ID,
+		Evidence: &models.BeliefEvidence{
+			Type:      models.EvidenceTypeAction,
+			Action:    "Took a cold shower",
+			Outcome:   "Still groggy an hour later",
+			IsCounter: true,
+		},
+	})
+	require.NoError(t, err)
+	assert.Less(t, countered.Belief.Confidence, supported.Belief.Confidence, "counterfactual evidence lowers confidence")
+	assert.Greater(t, countered.Belief.Confidence, 0.0)
+	assert.Len(t, countered.Evidence, 3)
+
+	stored, err := bsvc.GetBelief(&models.GetBeliefInput{SelfModelID: selfModelID, BeliefID: created.Belief.ID})
+	require.NoError(t, err)
+	assert.Equal(t, countered.Belief.Confidence, stored.Belief.Confidence)
+	assert.Equal(t, int32(3), stored.Belief.Version)
+}
+
+func TestAddEvidenceRatesBeliefContexts(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	const selfModelID = "self-model-1"
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "Naps make me productive",
+		BeliefType:    models.Statement,
+	})
+	require.NoError(t, err)
+
+	beliefSystem := models.BeliefSystem{
+		EpistemicContexts: []*models.EpistemicContext{{
+			PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				BeliefContexts: []*models.BeliefContext{{BeliefID: created.Belief.ID, ObservationContextID: "oc_afternoon"}},
+			},
+		}},
+	}
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", beliefSystem, 1))
+
+	added, err := bsvc.AddEvidence(&models.AddEvidenceInput{
+		SelfModelID: selfModelID,
+		BeliefID:    created.Belief.ID,
+		Evidence:    &models.BeliefEvidence{Type: models.EvidenceTypeAction, Action: "Napped", Outcome: "Finished my work"},
+	})
+	require.NoError(t, err)
+
+	got, err := bsvc.GetBelief(&models.GetBeliefInput{SelfModelID: selfModelID, BeliefID: created.Belief.ID})
+	require.NoError(t, err)
+	require.Len(t, got.BeliefContexts, 1)
+	ratings := got.BeliefContexts[0].ConfidenceRatings
+	require.Len(t, ratings, 1)
+	assert.Equal(t, added.Belief.Confidence, ratings[0].ConfidenceScore)
+}
+
+func TestConcurrentEvidenceIsAllRecorded(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	const selfModelID = "self-model-1"
+	created, err := svc.NewBeliefService(kv, nil).CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "Cold showers help me wake up",
+		BeliefType:    models.Statement,
+	})
+	require.NoError(t, err)
+
+	// The first evidence's log append waits for the second one's, so both read the same log
+	bsvc := svc.NewBeliefService(newPairedReadStore(kv, ":evidence"), nil)
+	addEvidence := func(outcome string) error {
+		_, err := bsvc.AddEvidence(&models.AddEvidenceInput{
+			SelfModelID: selfModelID,
+			BeliefID:    created.Belief.ID,
+			Evidence:    &models.BeliefEvidence{Type: models.EvidenceTypeAction, Action: "Took a cold shower", Outcome: outcome},
+		})
+		return err
+	}
+	first := make(chan error, 1)
+	go func() { first <- addEvidence("Felt awake within minutes") }()
+	time.Sleep(50 * time.Millisecond)
+	second := addEvidence("Felt awake after breakfast")
+
+	// Both append to the log; the belief itself takes one of the two confidence updates
+	for _, err := range []error{<-first, second} {
+		if err != nil {
+			assert.ErrorIs(t, err, db.ErrVersionConflict)
+		}
+	}
+	listed, err := bsvc.ListBeliefEvidence(&models.ListBeliefEvidenceInput{SelfModelID: selfModelID, BeliefID: created.Belief.ID})
+	require.NoError(t, err)
+	var outcomes []string
+	for _, evidence := range listed.Evidence {
+		outcomes = append(outcomes, evidence.Outcome)
+	}
+	assert.ElementsMatch(t, []string{"Felt awake within minutes", "Felt awake after breakfast"}, outcomes)
+}
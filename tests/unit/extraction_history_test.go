@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// referenceResolvingClient extracts "coffee" as the subject of an answer only when coffee was
+// mentioned somewhere in the extraction request. Other prompts are scripted.
+func referenceResolvingClient() *fakeai.Client {
+	return scriptedClient().OnFunc("Extract all beliefs", func(ctx context.Context, request openai.ChatCompletionRequest) (string, error) {
+		if strings.Contains(request.Messages[1].Content, "coffee") {
+			return `{"beliefs": ["I believe that coffee keeps me up at night"]}`, nil
+		}
+		return `{"beliefs": ["I believe that it keeps me up at night"]}`, nil
+	})
+}
+
+// extractFollowUp answers a dialectic twice, the second answer referring back to the first, and
+// returns the beliefs extracted from the second.
+func extractFollowUp(t *testing.T, window int) []*models.Belief {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(referenceResolvingClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+	dsvc.SetExtractionHistoryWindow(window)
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+
+	var updated *models.UpdateDialecticOutput
+	for _, answer := range []string{"I drink coffee every afternoon", "It keeps me up at night"} {
+		updated, err = dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+			ID:          created.DialecticID,
+			SelfModelID: selfModelID,
+			Answer:      models.UserAnswer{UserAnswer: answer},
+		})
+		require.NoError(t, err)
+	}
+
+	interactions := updated.Dialectic.UserInteractions
+	require.Len(t, interactions, 3)
+	return interactions[1].Interaction.QuestionAnswer.ExtractedBeliefs
+}
+
+func TestBeliefExtractionResolvesEarlierAnswers(t *testing.T) {
+	beliefs := extractFollowUp(t, svc.DefaultExtractionHistoryWindow)
+	require.Len(t, beliefs, 1)
+	assert.Equal(t, "I believe that coffee keeps me up at night", beliefs[0].GetContentAsString())
+}
+
+func TestBeliefExtractionWithoutHistory(t *testing.T) {
+	beliefs := extractFollowUp(t, 0)
+	require.Len(t, beliefs, 1)
+	assert.Equal(t, "I believe that it keeps me up at night", beliefs[0].GetContentAsString())
+}
@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cannedAnswerGenerator returns a fixed answer and records what it was asked with.
+type cannedAnswerGenerator struct {
+	question     string
+	beliefs      []string
+	philosophies []string
+}
+
+func (g *cannedAnswerGenerator) GenerateAnswerFromBeliefSystem(question string, beliefSystem *models.BeliefSystem, philosophies []string) (string, error) {
+	g.question = question
+	g.beliefs = nil
+	for _, belief := range beliefSystem.Beliefs {
+		g.beliefs = append(g.beliefs, belief.GetContentAsString())
+	}
+	g.philosophies = philosophies
+	return "I believe eight hours of sleep keeps me sharp", nil
+}
+
+func TestGenerateAnswer(t *testing.T) {
+	ctx := context.Background()
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+
+	bsvc := svc.NewBeliefService(kv, nil)
+	sms := svc.NewSelfModelService(kv, svc.NewDialecticService(kv, nil, nil, nil), bsvc)
+	generator := &cannedAnswerGenerator{}
+	sms.SetAnswerGenerator(generator)
+
+	const selfModelID = "synthetic-user"
+	_, err = sms.CreateSelfModel(ctx, &models.CreateSelfModelInput{ID: selfModelID})
+	require.NoError(t, err)
+	_, err = bsvc.CreateBelief(&models.CreateBeliefInput{SelfModelID: selfModelID, BeliefContent: "I believe sleep matters"})
+	require.NoError(t, err)
+	philosophy, err := sms.CreatePhilosophy(ctx, &models.CreatePhilosophyInput{Description: "Rest before effort"})
+	require.NoError(t, err)
+	_, err = sms.AddPhilosophy(ctx, &models.AddPhilosophyInput{SelfModelID: selfModelID, PhilosophyID: philosophy.Philosophy.ID})
+	require.NoError(t, err)
+
+	output, err := sms.GenerateAnswer(ctx, &models.GenerateAnswerInput{
+		SelfModelID:            selfModelID,
+		Question:               "How much do you sleep?",
+		AdditionalPhilosophies: []string{"Measure what matters"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "I believe eight hours of sleep keeps me sharp", output.Answer)
+	assert.Equal(t, "How much do you sleep?", generator.question)
+	assert.Equal(t, []string{"I believe sleep matters"}, generator.beliefs)
+	assert.Equal(t, []string{"Rest before effort", "Measure what matters"}, generator.philosophies)
+
+	_, err = sms.GenerateAnswer(ctx, &models.GenerateAnswerInput{
+		SelfModelID:            selfModelID,
+		Question:               "How much do you sleep?",
+		AdditionalPhilosophies: []string{"Measure what matters"},
+		OverridePhilosophies:   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Measure what matters"}, generator.philosophies)
+
+	_, err = sms.GenerateAnswer(ctx, &models.GenerateAnswerInput{SelfModelID: "missing", Question: "How much do you sleep?"})
+	assert.ErrorIs(t, err, svc.ErrSelfModelNotFound)
+}
@@ -0,0 +1,55 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBelief(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	const selfModelID = "self-model-1"
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "I believe eight hours of sleep is enough",
+		BeliefType:    models.Statement,
+	})
+	require.NoError(t, err)
+	beliefID := created.Belief.ID
+
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", models.BeliefSystem{
+		EpistemicContexts: []*models.EpistemicContext{{
+			PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				BeliefContexts: []*models.BeliefContext{
+					{BeliefID: beliefID, ObservationContextID: "sleep"},
+					{BeliefID: "other-belief", ObservationContextID: "sleep"},
+					{BeliefID: beliefID, ObservationContextID: "exercise"},
+				},
+			},
+		}},
+	}, 1))
+
+	output, err := bsvc.GetBelief(&models.GetBeliefInput{SelfModelID: selfModelID, BeliefID: beliefID})
+	require.NoError(t, err)
+	assert.Equal(t, beliefID, output.Belief.ID)
+	assert.Equal(t, "I believe eight hours of sleep is enough", output.Belief.GetContentAsString())
+	require.Len(t, output.BeliefContexts, 2)
+	assert.Equal(t, "sleep", output.BeliefContexts[0].ObservationContextID)
+	assert.Equal(t, "exercise", output.BeliefContexts[1].ObservationContextID)
+
+	_, err = bsvc.GetBelief(&models.GetBeliefInput{SelfModelID: selfModelID, BeliefID: "unknown"})
+	assert.ErrorIs(t, err, svc.ErrBeliefNotFound)
+
+	_, err = bsvc.DeleteBelief(&models.DeleteBeliefInput{SelfModelID: selfModelID, ID: beliefID})
+	require.NoError(t, err)
+	_, err = bsvc.GetBelief(&models.GetBeliefInput{SelfModelID: selfModelID, BeliefID: beliefID})
+	assert.ErrorIs(t, err, svc.ErrBeliefNotFound)
+}
@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	fixture_models "epistemic-me-core/db/fixtures"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEpistemicContextMatchesStoredFixture(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	const selfModelID = "fixture-user"
+	require.NoError(t, fixture_models.ImportFixtures(kv, selfModelID))
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+	require.NoError(t, err)
+	storedContexts := stored.(*models.BeliefSystem).EpistemicContexts
+
+	output, err := bsvc.GetEpistemicContext(&models.GetEpistemicContextInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Equal(t, storedContexts, output.EpistemicContexts)
+
+	// Every belief context appears in the linkage of its belief
+	linked := make(map[string][]string)
+	for _, link := range output.BeliefLinks {
+		linked[link.BeliefID] = link.ObservationContextIDs
+	}
+	var beliefContexts int
+	for _, ec := range storedContexts {
+		for _, bc := range ec.PredictiveProcessingContext.BeliefContexts {
+			beliefContexts++
+			assert.Contains(t, linked[bc.BeliefID], bc.ObservationContextID)
+		}
+	}
+	require.NotZero(t, beliefContexts, "the fixture declares belief contexts")
+}
+
+func TestGetEpistemicContextLinksBeliefsOnce(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	const selfModelID = "self-model-1"
+	bs := models.BeliefSystem{
+		EpistemicContexts: []*models.EpistemicContext{
+			{PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{{ID: "sleep"}, {ID: "energy"}},
+				BeliefContexts: []*models.BeliefContext{
+					{BeliefID: "b1", ObservationContextID: "sleep"},
+					{BeliefID: "b2", ObservationContextID: "energy"},
+					{BeliefID: "b1", ObservationContextID: "energy"},
+					{BeliefID: "b1", ObservationContextID: "sleep"},
+				},
+			}},
+		},
+	}
+	require.NoError(t, kv.Store(selfModelID, "BeliefSystem", bs, 1))
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	output, err := bsvc.GetEpistemicContext(&models.GetEpistemicContextInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Equal(t, []*models.BeliefContextLink{
+		{BeliefID: "b1", ObservationContextIDs: []string{"sleep", "energy"}},
+		{BeliefID: "b2", ObservationContextIDs: []string{"energy"}},
+	}, output.BeliefLinks)
+
+	_, err = bsvc.GetEpistemicContext(&models.GetEpistemicContextInput{SelfModelID: "missing"})
+	assert.ErrorIs(t, err, svc.ErrBeliefSystemNotFound)
+}
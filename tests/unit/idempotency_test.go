@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBeliefWithIdempotencyKeyCreatesOnce(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, ai.NewAIHelperWithClient(scriptedClient()))
+
+	const selfModelID = "self-model-1"
+	input := &models.CreateBeliefInput{
+		SelfModelID:    selfModelID,
+		BeliefContent:  "I believe that sleep is important",
+		BeliefType:     models.Statement,
+		IdempotencyKey: "retry-1",
+		RequesterID:    "dev_one",
+	}
+	first, err := bsvc.CreateBelief(input)
+	require.NoError(t, err)
+	second, err := bsvc.CreateBelief(input)
+	require.NoError(t, err)
+	assert.Equal(t, first.Belief.ID, second.Belief.ID)
+
+	listed, err := bsvc.ListBeliefs(&models.ListBeliefsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Len(t, listed.Beliefs, 1)
+
+	// Keys are scoped to the developer sending them
+	other := *input
+	other.RequesterID = "dev_two"
+	third, err := bsvc.CreateBelief(&other)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Belief.ID, third.Belief.ID)
+
+	reused := *input
+	reused.SelfModelID = "self-model-2"
+	_, err = bsvc.CreateBelief(&reused)
+	assert.ErrorIs(t, err, svc.ErrIdempotencyKeyReused)
+}
+
+func TestCreateDialecticWithIdempotencyKeyCreatesOnce(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(scriptedClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+	input := &models.CreateDialecticInput{SelfModelID: selfModelID, IdempotencyKey: "retry-1", RequesterID: "dev_one"}
+	first, err := dsvc.CreateDialectic(input)
+	require.NoError(t, err)
+	second, err := dsvc.CreateDialectic(input)
+	require.NoError(t, err)
+	assert.Equal(t, first.DialecticID, second.DialecticID)
+
+	listed, err := dsvc.ListDialectics(&models.ListDialecticsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Len(t, listed.Dialectics, 1)
+
+	// Without a key every request creates a dialectic
+	_, err = dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	listed, err = dsvc.ListDialectics(&models.ListDialecticsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Len(t, listed.Dialectics, 2)
+}
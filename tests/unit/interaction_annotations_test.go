@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotateInteraction(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	dsvc := svc.NewDialecticService(kv, nil, nil, nil)
+
+	const selfModelID = "self-model-1"
+	extracted := []*models.Belief{{ID: "sleep-belief", Content: []models.Content{{RawStr: "I believe sleep matters"}}}}
+	dialectic := models.Dialectic{
+		ID:          "di_annotated",
+		SelfModelID: selfModelID,
+		UserInteractions: []models.DialecticalInteraction{{
+			ID:     "answered",
+			Status: models.StatusAnswered,
+			Type:   models.InteractionTypeQuestionAnswer,
+			Interaction: &models.InteractionData{
+				QuestionAnswer: &models.QuestionAnswerInteraction{
+					Question:         models.Question{Question: "How much do you sleep?"},
+					Answer:           models.UserAnswer{UserAnswer: "Eight hours"},
+					ExtractedBeliefs: extracted,
+				},
+			},
+		}},
+		Version: 1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	annotate := func(annotatorID, note string, score float32) (*models.AnnotateInteractionOutput, error) {
+		return dsvc.AnnotateInteraction(&models.AnnotateInteractionInput{
+			DialecticID:   dialectic.ID,
+			SelfModelID:   selfModelID,
+			InteractionID: "answered",
+			AnnotatorID:   annotatorID,
+			Note:          note,
+			QualityScore:  score,
+		})
+	}
+	first, err := annotate("expert-1", " Clear, specific answer ", 0.9)
+	require.NoError(t, err)
+	assert.Equal(t, "Clear, specific answer", first.Annotation.Note)
+	assert.NotZero(t, first.Annotation.CreatedAtMillisUTC)
+	second, err := annotate("expert-2", "", 0.4)
+	require.NoError(t, err)
+	assert.Len(t, second.Annotations, 2)
+
+	listed, err := dsvc.ListInteractionAnnotations(&models.ListInteractionAnnotationsInput{
+		DialecticID:   dialectic.ID,
+		SelfModelID:   selfModelID,
+		InteractionID: "answered",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []models.InteractionAnnotation{first.Annotation, second.Annotation}, listed.Annotations)
+
+	// Annotations leave the interaction's answer and extracted beliefs as they were
+	stored, err := kv.Retrieve(selfModelID, dialectic.ID)
+	require.NoError(t, err)
+	var interaction models.DialecticalInteraction
+	switch d := stored.(type) {
+	case *models.Dialectic:
+		interaction = d.UserInteractions[0]
+	case models.Dialectic:
+		interaction = d.UserInteractions[0]
+	}
+	assert.Equal(t, "Eight hours", interaction.Interaction.QuestionAnswer.Answer.UserAnswer)
+	assert.Equal(t, extracted[0].ID, interaction.Interaction.QuestionAnswer.ExtractedBeliefs[0].ID)
+	assert.Len(t, interaction.ToProto().Annotations, 2)
+
+	_, err = annotate("", "No annotator", 0.5)
+	assert.ErrorIs(t, err, svc.ErrInvalidAnnotation)
+	_, err = annotate("expert-1", "Out of range", 1.5)
+	assert.ErrorIs(t, err, svc.ErrInvalidAnnotation)
+	_, err = dsvc.AnnotateInteraction(&models.AnnotateInteractionInput{
+		DialecticID:   dialectic.ID,
+		SelfModelID:   selfModelID,
+		InteractionID: "missing",
+		AnnotatorID:   "expert-1",
+		QualityScore:  0.5,
+	})
+	assert.ErrorIs(t, err, svc.ErrInteractionNotFound)
+}
@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rollingSummaryClient numbers the summaries it writes, recording the conversation each was given
+// in summarized, and answers every other prompt with a question.
+func rollingSummaryClient(summarized *[]string) *fakeai.Client {
+	return fakeai.New("How do you sleep?").OnFunc("Summarize the questions and answers", func(ctx context.Context, request openai.ChatCompletionRequest) (string, error) {
+		*summarized = append(*summarized, request.Messages[1].Content)
+		return fmt.Sprintf("Summary number %d.", len(*summarized)), nil
+	})
+}
+
+func answeredInteractions(n int) []models.DialecticalInteraction {
+	var interactions []models.DialecticalInteraction
+	for i := 0; i < n; i++ {
+		interactions = append(interactions, models.DialecticalInteraction{
+			ID:     fmt.Sprintf("interaction-%d", i),
+			Status: models.StatusAnswered,
+			Type:   models.InteractionTypeQuestionAnswer,
+			Interaction: &models.InteractionData{
+				QuestionAnswer: &models.QuestionAnswerInteraction{
+					Question: models.Question{Question: fmt.Sprintf("Question %d?", i)},
+					Answer:   models.UserAnswer{UserAnswer: fmt.Sprintf("Answer %d.", i)},
+				},
+			},
+		})
+	}
+	return interactions
+}
+
+func TestInteractionSummaryRollsForward(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	var summarized []string
+	aih := ai.NewAIHelperWithClient(rollingSummaryClient(&summarized), ai.WithQuestionHistoryWindow(5))
+	de := svc.NewDialecticEpistemology(svc.NewBeliefService(kv, aih), aih)
+
+	respond := func(answered int, keepSummary bool) {
+		_, err := de.Respond(&models.BeliefSystem{}, &models.DialecticEvent{
+			SelfModelID:          "self-model-1",
+			DialecticID:          "di_rolling",
+			PreviousInteractions: answeredInteractions(answered),
+			KeepSummary:          keepSummary,
+		}, "")
+		require.NoError(t, err)
+	}
+
+	respond(8, false)
+	require.Len(t, summarized, 1)
+	assert.Contains(t, summarized[0], "Question 2?")
+	assert.NotContains(t, summarized[0], "Question 3?")
+
+	// Only the interaction that aged out since is folded into the stored summary
+	respond(9, false)
+	require.Len(t, summarized, 2)
+	assert.Contains(t, summarized[1], "Summary number 1.")
+	assert.Contains(t, summarized[1], "Question 3?")
+	assert.NotContains(t, summarized[1], "Question 2?")
+
+	// Nothing aged out, so nothing is summarized
+	respond(9, false)
+	assert.Len(t, summarized, 2)
+
+	// A kept summary is rolled forward for the question but not stored
+	respond(10, true)
+	respond(10, false)
+	require.Len(t, summarized, 4)
+	assert.Contains(t, summarized[3], "Summary number 2.")
+	assert.Contains(t, summarized[3], "Question 4?")
+}
@@ -0,0 +1,68 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLearningObjectiveProgress_UsesCachedProgress(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	// No AI helper is configured, so any attempt to recompute the progress would panic
+	dsvc := svc.NewDialecticService(kv, nil, nil, nil)
+
+	const selfModelID = "self-model-1"
+	progress := &models.LearningObjectiveProgress{
+		CompletionPercentage: 40,
+		TopicCoverage: map[string]*models.TopicCoverage{
+			"sleep": {
+				Percentage:        40,
+				CoveredCategories: []string{"foundational"},
+				MissingCategories: []string{"practice-based", "cause-effect", "experience-based"},
+				BeliefQuality:     map[string]float32{"foundational": 0.8},
+			},
+		},
+		Explanation: "Only foundational sleep beliefs have been collected",
+	}
+	dialectic := models.Dialectic{
+		ID:          "di_progress",
+		SelfModelID: selfModelID,
+		LearningObjective: &models.LearningObjective{
+			Description:          "Understand sleep habits",
+			Topics:               []string{"sleep"},
+			CompletionPercentage: 40,
+		},
+		LearningObjectiveProgress: progress,
+		Version:                   1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	output, err := dsvc.GetLearningObjectiveProgress(&models.GetLearningObjectiveProgressInput{
+		DialecticID: dialectic.ID,
+		SelfModelID: selfModelID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, progress, output.Progress)
+	assert.Equal(t, "Understand sleep habits", output.LearningObjective.Description)
+}
+
+func TestGetLearningObjectiveProgress_RequiresLearningObjective(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	dsvc := svc.NewDialecticService(kv, nil, nil, nil)
+
+	dialectic := models.Dialectic{ID: "di_no_objective", SelfModelID: "self-model-1", Version: 1}
+	require.NoError(t, kv.Store(dialectic.SelfModelID, dialectic.ID, dialectic, 1))
+
+	_, err = dsvc.GetLearningObjectiveProgress(&models.GetLearningObjectiveProgressInput{
+		DialecticID: dialectic.ID,
+		SelfModelID: dialectic.SelfModelID,
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sleepQuestion is the question scriptedClient asks.
+const sleepQuestion = "What do you believe about sleep?"
+
+// scriptedBeliefs are the beliefs scriptedClient extracts from every answer.
+const scriptedBeliefs = `{"beliefs": ["I believe that sleep is important"]}`
+
+// scriptedClient answers each kind of AI request made during a learning objective dialectic
+// with a canned response, reporting the objective as 40% complete.
+func scriptedClient() *fakeai.Client {
+	return fakeai.New(sleepQuestion).
+		On("Curtly respond with 'yes' or 'no'", "no").
+		On("Extract all beliefs", scriptedBeliefs).
+		On("completion percentage of a learning objective", `{"completion_percentage": 40, "topic_coverage": {"sleep": {"percentage": 40}}, "explanation": "Partial coverage"}`)
+}
+
+func TestLearningObjectiveCompletionThreshold(t *testing.T) {
+	tests := []struct {
+		name              string
+		objective         models.LearningObjective
+		wantInteractions  int
+		wantPendingAnswer bool
+	}{
+		{
+			name:              "default threshold keeps asking",
+			objective:         models.LearningObjective{},
+			wantInteractions:  2,
+			wantPendingAnswer: true,
+		},
+		{
+			name:             "low threshold ends after one answer",
+			objective:        models.LearningObjective{CompletionThreshold: 30},
+			wantInteractions: 1,
+		},
+		{
+			name:             "question cap ends after one answer",
+			objective:        models.LearningObjective{MaxQuestions: 1},
+			wantInteractions: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kv, err := db.NewKeyValueStore("")
+			require.NoError(t, err)
+
+			aih := ai.NewAIHelperWithClient(scriptedClient())
+			bsvc := svc.NewBeliefService(kv, aih)
+			dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+			const selfModelID = "self-model-1"
+			require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+
+			objective := tt.objective
+			objective.Description = "Understand sleep habits"
+			objective.Topics = []string{"sleep"}
+
+			created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{
+				SelfModelID:       selfModelID,
+				LearningObjective: &objective,
+			})
+			require.NoError(t, err)
+			require.Len(t, created.Dialectic.UserInteractions, 1)
+
+			updated, err := dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+				ID:          created.DialecticID,
+				SelfModelID: selfModelID,
+				Answer:      models.UserAnswer{UserAnswer: "I always sleep eight hours"},
+			})
+			require.NoError(t, err)
+
+			interactions := updated.Dialectic.UserInteractions
+			assert.Len(t, interactions, tt.wantInteractions)
+			last := interactions[len(interactions)-1]
+			assert.Equal(t, tt.wantPendingAnswer, last.Status == models.StatusPendingAnswer)
+		})
+	}
+}
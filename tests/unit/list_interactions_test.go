@@ -0,0 +1,86 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func questionAnswer(id string, status models.DialecticalInteractionStatus, updatedAt int64, question, answer string) models.DialecticalInteraction {
+	return models.DialecticalInteraction{
+		ID:     id,
+		Status: status,
+		Type:   models.InteractionTypeQuestionAnswer,
+		Interaction: &models.InteractionData{
+			QuestionAnswer: &models.QuestionAnswerInteraction{
+				Question:         models.Question{Question: question},
+				Answer:           models.UserAnswer{UserAnswer: answer},
+				ExtractedBeliefs: []*models.Belief{{ID: "belief-" + id}},
+			},
+		},
+		UpdatedAtMillisUTC: updatedAt,
+	}
+}
+
+func TestListInteractions(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	dsvc := svc.NewDialecticService(kv, nil, nil, nil)
+
+	const selfModelID = "self-model-1"
+	dialectic := models.Dialectic{
+		ID:          "di_interactions",
+		SelfModelID: selfModelID,
+		UserInteractions: []models.DialecticalInteraction{
+			questionAnswer("answered-late", models.StatusAnswered, 300, "How do you eat?", "Plants"),
+			questionAnswer("pending", models.StatusPendingAnswer, 400, "Do you exercise?", ""),
+			questionAnswer("answered-early", models.StatusAnswered, 100, "How do you sleep?", "Eight hours"),
+			{
+				ID:                 "action",
+				Status:             models.StatusAnswered,
+				Type:               models.InteractionTypeActionOutcome,
+				UpdatedAtMillisUTC: 200,
+			},
+		},
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	ids := func(input *models.ListInteractionsInput) []string {
+		input.DialecticID = dialectic.ID
+		input.SelfModelID = selfModelID
+		output, err := dsvc.ListInteractions(input)
+		require.NoError(t, err)
+		var ids []string
+		for _, interaction := range output.Interactions {
+			ids = append(ids, interaction.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"answered-early", "action", "answered-late", "pending"}, ids(&models.ListInteractionsInput{}))
+	assert.Equal(t, []string{"answered-early", "action", "answered-late"}, ids(&models.ListInteractionsInput{Status: models.StatusAnswered}))
+	assert.Equal(t, []string{"pending"}, ids(&models.ListInteractionsInput{Status: models.StatusPendingAnswer}))
+	assert.Equal(t, []string{"answered-early", "answered-late"}, ids(&models.ListInteractionsInput{
+		Status: models.StatusAnswered,
+		Type:   models.InteractionTypeQuestionAnswer,
+	}))
+
+	output, err := dsvc.ListInteractions(&models.ListInteractionsInput{DialecticID: dialectic.ID, SelfModelID: selfModelID, Status: models.StatusAnswered})
+	require.NoError(t, err)
+	assert.Equal(t, models.InteractionSummary{
+		ID:                 "answered-early",
+		Status:             models.StatusAnswered,
+		Type:               models.InteractionTypeQuestionAnswer,
+		Question:           "How do you sleep?",
+		Answer:             "Eight hours",
+		UpdatedAtMillisUTC: 100,
+	}, output.Interactions[0])
+
+	_, err = dsvc.ListInteractions(&models.ListInteractionsInput{DialecticID: "missing", SelfModelID: selfModelID})
+	assert.ErrorIs(t, err, svc.ErrDialecticNotFound)
+}
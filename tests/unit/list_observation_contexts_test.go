@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	fixture_models "epistemic-me-core/db/fixtures"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListObservationContextsFixture(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	const selfModelID = "fixture-user"
+	require.NoError(t, fixture_models.ImportFixtures(kv, selfModelID))
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+	require.NoError(t, err)
+	var nested int
+	for _, epistemicContext := range stored.(*models.BeliefSystem).EpistemicContexts {
+		nested += len(epistemicContext.PredictiveProcessingContext.ObservationContexts)
+	}
+	require.Equal(t, 16, nested, "the fixture declares 16 observation contexts")
+
+	output, err := bsvc.ListObservationContexts(&models.ListObservationContextsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+
+	// Contexts named alike in several examples share an ID and are listed once
+	ids := make(map[string]bool)
+	for _, oc := range output.ObservationContexts {
+		assert.False(t, ids[oc.ID], "duplicate context %s", oc.ID)
+		ids[oc.ID] = true
+	}
+	assert.Len(t, output.ObservationContexts, 12)
+	assert.True(t, ids["context-Metabolic state"])
+	assert.Equal(t, "context-Sleep", output.ObservationContexts[0].ID)
+	assert.Equal(t, "primary", output.ObservationContexts[0].ParentID)
+	assert.Equal(t, "Sleep", output.ObservationContexts[1].ParentID)
+
+	// Fixture roots are nested within "primary" rather than having no parent
+	roots, err := bsvc.ListObservationContexts(&models.ListObservationContextsInput{SelfModelID: selfModelID, RootsOnly: true})
+	require.NoError(t, err)
+	assert.Empty(t, roots.ObservationContexts)
+}
+
+func TestListObservationContextsRootsOnly(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	const selfModelID = "self-model-1"
+	bs := models.BeliefSystem{
+		EpistemicContexts: []*models.EpistemicContext{
+			{PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{
+					{ID: "sleep", Name: "Sleep"},
+					{ID: "rem", Name: "REM", ParentID: "sleep"},
+				},
+			}},
+			{PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{
+					{ID: "sleep", Name: "Sleep"},
+					{ID: "diet", Name: "Diet"},
+				},
+			}},
+			{},
+		},
+	}
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", bs, 1))
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	names := func(input *models.ListObservationContextsInput) []string {
+		input.SelfModelID = selfModelID
+		output, err := bsvc.ListObservationContexts(input)
+		require.NoError(t, err)
+		var names []string
+		for _, oc := range output.ObservationContexts {
+			names = append(names, oc.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Sleep", "REM", "Diet"}, names(&models.ListObservationContextsInput{}))
+	assert.Equal(t, []string{"Sleep", "Diet"}, names(&models.ListObservationContextsInput{RootsOnly: true}))
+
+	_, err = bsvc.ListObservationContexts(&models.ListObservationContextsInput{SelfModelID: "missing"})
+	assert.ErrorIs(t, err, svc.ErrBeliefSystemNotFound)
+}
@@ -0,0 +1,48 @@
+package unit
+
+import (
+	"sort"
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOfTypeReturnsTypedDialectics(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+
+	const selfModelID = "self-model-1"
+	for _, id := range []string{"di_sleep", "di_diet"} {
+		require.NoError(t, kv.Store(selfModelID, id, models.Dialectic{ID: id, SelfModelID: selfModelID, Version: 1}, 1))
+	}
+	// Values of other types, or of other owners, are left out
+	require.NoError(t, kv.Store(selfModelID, "bi_sleep", models.Belief{ID: "bi_sleep"}, 1))
+	require.NoError(t, kv.Store("self-model-2", "di_other", models.Dialectic{ID: "di_other", SelfModelID: "self-model-2"}, 1))
+
+	dialectics, err := db.ListOfType[models.Dialectic](kv, selfModelID)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(dialectics))
+	for _, dialectic := range dialectics {
+		ids = append(ids, dialectic.ID)
+	}
+	sort.Strings(ids)
+	assert.Equal(t, []string{"di_diet", "di_sleep"}, ids)
+
+	all, err := db.ListAllOfType[models.Dialectic](kv)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	none, err := db.ListOfType[models.Dialectic](kv, "self-model-3")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	// ListDialectics lists through the helper
+	listed, err := svc.NewDialecticService(kv, nil, nil, nil).ListDialectics(&models.ListDialecticsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Len(t, listed.Dialectics, 2)
+}
@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListUsersIsScopedToDeveloper(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	usvc := svc.NewUserService(kv, nil)
+
+	var created []string
+	for _, name := range []string{"Ada", "Grace"} {
+		out, err := usvc.CreateUser(&models.CreateUserInput{DeveloperID: "dev_one", Name: name})
+		require.NoError(t, err)
+		created = append(created, out.User.ID)
+	}
+	_, err = usvc.CreateUser(&models.CreateUserInput{DeveloperID: "dev_two", Name: "Alan"})
+	require.NoError(t, err)
+
+	listed, err := usvc.ListUsers(&models.ListUsersInput{DeveloperID: "dev_one"})
+	require.NoError(t, err)
+	var listedIDs []string
+	for _, user := range listed.Users {
+		assert.Equal(t, "dev_one", user.DeveloperID)
+		listedIDs = append(listedIDs, user.ID)
+	}
+	assert.ElementsMatch(t, created, listedIDs)
+	assert.Empty(t, listed.NextPageToken)
+
+	other, err := usvc.ListUsers(&models.ListUsersInput{DeveloperID: "dev_two"})
+	require.NoError(t, err)
+	require.Len(t, other.Users, 1)
+	assert.Equal(t, "Alan", other.Users[0].Name)
+
+	none, err := usvc.ListUsers(&models.ListUsersInput{DeveloperID: "dev_three"})
+	require.NoError(t, err)
+	assert.Empty(t, none.Users)
+}
+
+func TestListUsersPaginates(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	usvc := svc.NewUserService(kv, nil)
+	for _, name := range []string{"Ada", "Grace", "Barbara"} {
+		_, err := usvc.CreateUser(&models.CreateUserInput{DeveloperID: "dev_one", Name: name})
+		require.NoError(t, err)
+	}
+
+	first, err := usvc.ListUsers(&models.ListUsersInput{DeveloperID: "dev_one", PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, first.Users, 2)
+	require.NotEmpty(t, first.NextPageToken)
+
+	second, err := usvc.ListUsers(&models.ListUsersInput{DeveloperID: "dev_one", PageSize: 2, PageToken: first.NextPageToken})
+	require.NoError(t, err)
+	require.Len(t, second.Users, 1)
+	assert.Empty(t, second.NextPageToken)
+	assert.NotContains(t, []string{first.Users[0].ID, first.Users[1].ID}, second.Users[0].ID)
+
+	_, err = usvc.ListUsers(&models.ListUsersInput{DeveloperID: "dev_one", PageToken: "not-a-token"})
+	assert.ErrorIs(t, err, svc.ErrInvalidPageToken)
+}
@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeBeliefsRepointsBeliefContexts(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(fakeai.New("I believe regular, sufficient sleep keeps me healthy"))
+	bsvc := svc.NewBeliefService(kv, aih)
+
+	const selfModelID = "self-model-1"
+	var beliefIDs []string
+	for _, content := range []string{
+		"I believe sleep is important",
+		"I believe I need eight hours of sleep",
+		"I believe exercise improves my mood",
+	} {
+		output, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+			SelfModelID:   selfModelID,
+			BeliefContent: content,
+			BeliefType:    models.Statement,
+		})
+		require.NoError(t, err)
+		beliefIDs = append(beliefIDs, output.Belief.ID)
+	}
+
+	// Both sleep beliefs were observed in the same context, the exercise belief in another
+	bs, err := bsvc.GetBeliefSystem(selfModelID)
+	require.NoError(t, err)
+	bs.EpistemicContexts = []*models.EpistemicContext{
+		{
+			AssociatedBeleifs: []string{beliefIDs[0], beliefIDs[1], beliefIDs[2]},
+			PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{{ID: "oc_sleep"}, {ID: "oc_exercise"}},
+				BeliefContexts: []*models.BeliefContext{
+					{BeliefID: beliefIDs[0], ObservationContextID: "oc_sleep", DialecticInteractionIDs: []string{"di_1"}},
+					{BeliefID: beliefIDs[1], ObservationContextID: "oc_sleep", DialecticInteractionIDs: []string{"di_2"}},
+					{BeliefID: beliefIDs[2], ObservationContextID: "oc_exercise"},
+				},
+			},
+		},
+	}
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", *bs, 1))
+
+	output, err := bsvc.MergeBeliefs(&models.MergeBeliefsInput{
+		SelfModelID: selfModelID,
+		BeliefIDs:   beliefIDs[:2],
+	})
+	require.NoError(t, err)
+	merged := output.Belief
+	assert.Equal(t, "I believe regular, sufficient sleep keeps me healthy", merged.GetContentAsString())
+	assert.True(t, merged.Active)
+
+	// The stored belief system must no longer reference the merged beliefs
+	stored, err := bsvc.GetBeliefSystem(selfModelID)
+	require.NoError(t, err)
+	require.Len(t, stored.EpistemicContexts, 1)
+	ec := stored.EpistemicContexts[0]
+	assert.Equal(t, []string{merged.ID, beliefIDs[2]}, ec.AssociatedBeleifs)
+
+	beliefContexts := ec.PredictiveProcessingContext.BeliefContexts
+	require.Len(t, beliefContexts, 2)
+	for _, bc := range beliefContexts {
+		assert.NotContains(t, beliefIDs[:2], bc.BeliefID, "belief context still references a merged belief")
+	}
+	assert.Equal(t, merged.ID, beliefContexts[0].BeliefID)
+	assert.Equal(t, "oc_sleep", beliefContexts[0].ObservationContextID)
+	assert.Equal(t, []string{"di_1", "di_2"}, beliefContexts[0].DialecticInteractionIDs)
+	assert.Equal(t, beliefIDs[2], beliefContexts[1].BeliefID)
+
+	// Only the merged belief and the untouched belief remain active
+	var activeIDs []string
+	for _, belief := range stored.Beliefs {
+		activeIDs = append(activeIDs, belief.ID)
+	}
+	assert.ElementsMatch(t, []string{merged.ID, beliefIDs[2]}, activeIDs)
+}
+
+func TestMergeBeliefsRequiresTwoBeliefs(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	_, err = bsvc.MergeBeliefs(&models.MergeBeliefsInput{
+		SelfModelID: "self-model-1",
+		BeliefIDs:   []string{"bi_only"},
+	})
+	assert.Error(t, err)
+}
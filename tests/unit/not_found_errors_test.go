@@ -0,0 +1,57 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingRetrieveStore fails every read with an error whose wording matches the store's
+// not-found error without being one.
+type failingRetrieveStore struct {
+	db.KeyValueStore
+}
+
+func (s *failingRetrieveStore) Retrieve(developerId string, key string) (interface{}, error) {
+	return nil, errors.New("key not found")
+}
+
+func TestBeliefSystemNotFoundReturnsEmptyBeliefSystem(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+
+	beliefSystem, err := svc.NewBeliefService(kv, nil).GetBeliefSystem("unknown-self-model")
+	require.NoError(t, err)
+	assert.Empty(t, beliefSystem.Beliefs)
+
+	// Only a real not-found error is treated as a missing belief system
+	_, err = svc.NewBeliefService(&failingRetrieveStore{KeyValueStore: kv}, nil).GetBeliefSystem("unknown-self-model")
+	assert.Error(t, err)
+}
+
+func TestNotFoundErrorsAreSentinels(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	dsvc := svc.NewDialecticService(kv, nil, nil, nil)
+	smsvc := svc.NewSelfModelService(kv, dsvc, svc.NewBeliefService(kv, nil))
+
+	_, err = smsvc.GetSelfModel(context.Background(), &models.GetSelfModelInput{SelfModelID: "unknown-self-model"})
+	assert.ErrorIs(t, err, svc.ErrSelfModelNotFound)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	_, err = dsvc.UpdateDialectic(&models.UpdateDialecticInput{ID: "di_unknown", SelfModelID: "unknown-self-model"})
+	assert.ErrorIs(t, err, svc.ErrDialecticNotFound)
+
+	_, err = dsvc.GetLearningObjectiveProgress(&models.GetLearningObjectiveProgressInput{
+		DialecticID: "di_unknown",
+		SelfModelID: "unknown-self-model",
+	})
+	assert.ErrorIs(t, err, svc.ErrDialecticNotFound)
+}
@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newObservationContextService(t *testing.T) (*svc.BeliefService, string) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	const selfModelID = "self-model-1"
+	bs := models.BeliefSystem{
+		EpistemicContexts: []*models.EpistemicContext{
+			{PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{
+					{ID: "sleep", Name: "Sleep"},
+					{ID: "rem", Name: "REM", ParentID: "sleep"},
+					{ID: "dreams", Name: "Dreams", ParentID: "rem"},
+				},
+				BeliefContexts: []*models.BeliefContext{
+					{BeliefID: "belief-1", ObservationContextID: "rem"},
+				},
+			}},
+		},
+	}
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", bs, 1))
+	return svc.NewBeliefService(kv, nil), selfModelID
+}
+
+func contextsByID(t *testing.T, bsvc *svc.BeliefService, selfModelID string) map[string]*models.ObservationContext {
+	output, err := bsvc.ListObservationContexts(&models.ListObservationContextsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	byID := make(map[string]*models.ObservationContext)
+	for _, oc := range output.ObservationContexts {
+		byID[oc.ID] = oc
+	}
+	return byID
+}
+
+func TestCreateAndUpdateObservationContext(t *testing.T) {
+	bsvc, selfModelID := newObservationContextService(t)
+
+	created, err := bsvc.CreateObservationContext(&models.CreateObservationContextInput{
+		SelfModelID:    selfModelID,
+		Name:           "Deep Sleep",
+		ParentID:       "sleep",
+		PossibleStates: []string{"slow-wave"},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.ObservationContext.ID)
+
+	_, err = bsvc.CreateObservationContext(&models.CreateObservationContextInput{
+		SelfModelID: selfModelID, Name: "Naps", ParentID: "missing",
+	})
+	assert.ErrorIs(t, err, svc.ErrObservationContextNotFound)
+
+	updated, err := bsvc.UpdateObservationContext(&models.UpdateObservationContextInput{
+		SelfModelID: selfModelID,
+		ID:          created.ObservationContext.ID,
+		Name:        "Slow-Wave Sleep",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Slow-Wave Sleep", updated.ObservationContext.Name)
+
+	stored := contextsByID(t, bsvc, selfModelID)[created.ObservationContext.ID]
+	require.NotNil(t, stored)
+	assert.Equal(t, "Slow-Wave Sleep", stored.Name)
+	assert.Equal(t, "sleep", stored.ParentID, "a rename keeps the parent")
+	assert.Equal(t, []string{"slow-wave"}, stored.PossibleStates)
+
+	// A context can't move beneath its own descendant
+	parent := "dreams"
+	_, err = bsvc.UpdateObservationContext(&models.UpdateObservationContextInput{
+		SelfModelID: selfModelID, ID: "sleep", ParentID: &parent,
+	})
+	assert.Error(t, err)
+
+	_, err = bsvc.UpdateObservationContext(&models.UpdateObservationContextInput{
+		SelfModelID: selfModelID, ID: "missing", Name: "Missing",
+	})
+	assert.ErrorIs(t, err, svc.ErrObservationContextNotFound)
+}
+
+func TestDeleteObservationContext(t *testing.T) {
+	t.Run("referenced context is kept unless forced", func(t *testing.T) {
+		bsvc, selfModelID := newObservationContextService(t)
+
+		_, err := bsvc.DeleteObservationContext(&models.DeleteObservationContextInput{
+			SelfModelID: selfModelID, ID: "rem",
+		})
+		assert.ErrorIs(t, err, svc.ErrObservationContextInUse)
+		assert.Contains(t, contextsByID(t, bsvc, selfModelID), "rem")
+
+		output, err := bsvc.DeleteObservationContext(&models.DeleteObservationContextInput{
+			SelfModelID: selfModelID, ID: "rem", Force: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), output.RemovedBeliefContextsCount)
+		assert.Equal(t, []string{"dreams"}, output.ChildIDs)
+
+		remaining := contextsByID(t, bsvc, selfModelID)
+		assert.NotContains(t, remaining, "rem")
+		assert.Equal(t, "", remaining["dreams"].ParentID, "children are orphaned by default")
+	})
+
+	t.Run("children move to the deleted context's parent", func(t *testing.T) {
+		bsvc, selfModelID := newObservationContextService(t)
+
+		_, err := bsvc.DeleteObservationContext(&models.DeleteObservationContextInput{
+			SelfModelID: selfModelID, ID: "rem", Force: true, ReparentChildren: true,
+		})
+		require.NoError(t, err)
+
+		remaining := contextsByID(t, bsvc, selfModelID)
+		assert.Len(t, remaining, 2)
+		assert.Equal(t, "sleep", remaining["dreams"].ParentID)
+	})
+
+	t.Run("unknown context", func(t *testing.T) {
+		bsvc, selfModelID := newObservationContextService(t)
+
+		_, err := bsvc.DeleteObservationContext(&models.DeleteObservationContextInput{
+			SelfModelID: selfModelID, ID: "missing",
+		})
+		assert.ErrorIs(t, err, svc.ErrObservationContextNotFound)
+	})
+}
@@ -0,0 +1,154 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nodeNames(nodes []*models.ObservationContextNode) []string {
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Context.Name)
+	}
+	return names
+}
+
+func TestBuildObservationContextTree_TreeStructureMarkdown(t *testing.T) {
+	markdown := `## Experiential Narrative
+06:30 — [[C: Morning Routine]]
+  [[C: Circadian Rhythm]] [[S: asleep]] → [[S: awake]]
+  [[C: Zeitgeber Exposure]] [[S: bright-light-day]]
+    [[C: Hormonal Pulse]] [[S: cortisol-peak]]
+  [[C: HRV Band]] [[S: balanced-lfhf]]
+[[C: Evening Routine]]
+  [[C: Sleep Architecture]] [[S: light-n1]] → [[S: slow-wave]]
+    [[C: GH Pulse]] [[S: gh-pulse]]
+`
+
+	tree := models.BuildObservationContextTree(models.ExtrapolateObservationContexts(markdown))
+
+	assert.Empty(t, tree.Warnings)
+	assert.Equal(t, 3, tree.Depth())
+	require.Equal(t, []string{"Morning Routine", "Evening Routine"}, nodeNames(tree.Roots))
+
+	morning := tree.Roots[0]
+	require.Equal(t, []string{"Circadian Rhythm", "Zeitgeber Exposure", "HRV Band"}, nodeNames(morning.Children))
+	assert.Equal(t, []string{"Hormonal Pulse"}, nodeNames(morning.Children[1].Children))
+	assert.Empty(t, morning.Children[0].Children)
+
+	evening := tree.Roots[1]
+	require.Equal(t, []string{"Sleep Architecture"}, nodeNames(evening.Children))
+	assert.Equal(t, []string{"GH Pulse"}, nodeNames(evening.Children[0].Children))
+}
+
+func TestDescendantContextIDs_TreeStructureMarkdown(t *testing.T) {
+	markdown := `## Experiential Narrative
+06:30 — [[C: Morning Routine]]
+  [[C: Circadian Rhythm]] [[S: asleep]] → [[S: awake]]
+  [[C: Zeitgeber Exposure]] [[S: bright-light-day]]
+    [[C: Hormonal Pulse]] [[S: cortisol-peak]]
+  [[C: HRV Band]] [[S: balanced-lfhf]]
+[[C: Evening Routine]]
+  [[C: Sleep Architecture]] [[S: light-n1]] → [[S: slow-wave]]
+    [[C: GH Pulse]] [[S: gh-pulse]]
+`
+	contexts := models.ExtrapolateObservationContexts(markdown)
+	names := make(map[string]string)
+	idsByName := make(map[string]string)
+	for _, oc := range contexts {
+		names[oc.ID] = oc.Name
+		idsByName[oc.Name] = oc.ID
+	}
+	descendantNames := func(rootNames ...string) []string {
+		var rootIDs []string
+		for _, name := range rootNames {
+			rootIDs = append(rootIDs, idsByName[name])
+		}
+		var result []string
+		for _, id := range models.DescendantContextIDs(contexts, rootIDs) {
+			result = append(result, names[id])
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"Morning Routine", "Circadian Rhythm", "Zeitgeber Exposure", "HRV Band", "Hormonal Pulse"},
+		descendantNames("Morning Routine"))
+	assert.Equal(t, []string{"Zeitgeber Exposure", "Hormonal Pulse"}, descendantNames("Zeitgeber Exposure"))
+	assert.Equal(t, []string{"GH Pulse"}, descendantNames("GH Pulse"))
+	assert.Equal(t, []string{"Sleep Architecture", "Evening Routine", "GH Pulse"},
+		descendantNames("Sleep Architecture", "Evening Routine"), "a root nested under another root is listed once")
+}
+
+func TestDescendantContextIDs_Cycle(t *testing.T) {
+	contexts := []*models.ObservationContext{
+		{ID: "a", ParentID: "b"},
+		{ID: "b", ParentID: "a"},
+		{ID: "c", ParentID: "a"},
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, models.DescendantContextIDs(contexts, []string{"a"}))
+}
+
+func TestBuildObservationContextTree_OrphansAndCycles(t *testing.T) {
+	tree := models.BuildObservationContextTree([]*models.ObservationContext{
+		{ID: "sleep", Name: "Sleep"},
+		{ID: "rem", Name: "REM", ParentID: "sleep"},
+		{ID: "lost", Name: "Lost", ParentID: "missing"},
+		{ID: "a", Name: "A", ParentID: "b"},
+		{ID: "b", Name: "B", ParentID: "a"},
+		{ID: "c", Name: "C", ParentID: "a"},
+		{ID: "self", Name: "Self", ParentID: "self"},
+	})
+
+	require.Equal(t, []string{"Sleep", "Unattached contexts"}, nodeNames(tree.Roots))
+	unattached := tree.Roots[1]
+	assert.Equal(t, models.UnattachedContextsID, unattached.Context.ID)
+	require.Equal(t, []string{"Lost", "A", "Self"}, nodeNames(unattached.Children))
+
+	// The cycle is cut at A, keeping B and C beneath it
+	cycle := unattached.Children[1]
+	assert.Equal(t, []string{"B", "C"}, nodeNames(cycle.Children))
+	assert.Empty(t, cycle.Children[0].Children)
+	assert.Empty(t, unattached.Children[2].Children)
+	assert.Equal(t, 3, tree.Depth())
+
+	assert.Equal(t, []string{
+		`observation context "lost" has unknown parent "missing"`,
+		"observation contexts form a parent cycle: a -> b -> a",
+		"observation contexts form a parent cycle: self -> self",
+	}, tree.Warnings)
+}
+
+func TestListObservationContextsAsTree(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	const selfModelID = "self-model-1"
+	bs := models.BeliefSystem{
+		EpistemicContexts: []*models.EpistemicContext{
+			{PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{
+					{ID: "sleep", Name: "Sleep"},
+					{ID: "rem", Name: "REM", ParentID: "sleep"},
+				},
+			}},
+		},
+	}
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", bs, 1))
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	output, err := bsvc.ListObservationContexts(&models.ListObservationContextsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Nil(t, output.Tree)
+
+	output, err = bsvc.ListObservationContexts(&models.ListObservationContextsInput{SelfModelID: selfModelID, AsTree: true, RootsOnly: true})
+	require.NoError(t, err)
+	assert.Len(t, output.ObservationContexts, 1)
+	require.NotNil(t, output.Tree)
+	assert.Equal(t, 2, output.Tree.Depth())
+	assert.Empty(t, output.Tree.Warnings)
+}
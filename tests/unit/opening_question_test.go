@@ -0,0 +1,65 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFastOpeningQuestion(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	client := fakeai.New(sleepQuestion)
+	aih := ai.NewAIHelperWithClient(client)
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+	dsvc.SetOpeningQuestions(svc.DefaultOpeningQuestions)
+
+	const selfModelID = "self-model-1"
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{
+		SelfModelID:   selfModelID,
+		DialecticType: models.DialecticTypeDefault,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, client.Calls(), "a fresh self model should not need an AI call")
+	require.Len(t, created.Dialectic.UserInteractions, 1)
+	opening := created.Dialectic.UserInteractions[0]
+	assert.Equal(t, models.StatusPendingAnswer, opening.Status)
+	assert.Equal(t, svc.DefaultOpeningQuestions[models.DialecticTypeDefault], opening.Interaction.QuestionAnswer.Question.Question)
+
+	// Once the self model has beliefs the question is generated again
+	_, err = bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "I believe sleep matters",
+		BeliefType:    models.Statement,
+	})
+	require.NoError(t, err)
+	created, err = dsvc.CreateDialectic(&models.CreateDialecticInput{
+		SelfModelID:   selfModelID,
+		DialecticType: models.DialecticTypeDefault,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.Calls())
+	assert.Equal(t, "What do you believe about sleep?", created.Dialectic.UserInteractions[0].Interaction.QuestionAnswer.Question.Question)
+}
+
+func TestOpeningQuestionGeneratedWithoutFastMode(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	client := fakeai.New(sleepQuestion)
+	aih := ai.NewAIHelperWithClient(client)
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	_, err = dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: "self-model-1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.Calls())
+}
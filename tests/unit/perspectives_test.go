@@ -0,0 +1,158 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePerspectiveResponder answers with a canned response per self model, or an error when none is set.
+type fakePerspectiveResponder struct {
+	responses map[string]string
+}
+
+func (f *fakePerspectiveResponder) Respond(bs *models.BeliefSystem, request models.EpistemicRequest) (*string, error) {
+	response, ok := f.responses[request.SelfModelID]
+	if !ok {
+		return nil, errors.New("self model has no beliefs")
+	}
+	response = response + " / " + request.Content["question"].(string)
+	return &response, nil
+}
+
+func TestGetPerspectives(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	responder := &fakePerspectiveResponder{responses: map[string]string{
+		"stoic":    "Sleep is a discipline",
+		"hedonist": "Sleep whenever it feels good",
+	}}
+	dsvc := svc.NewDialecticService(kv, nil, responder, nil)
+
+	output, err := dsvc.GetPerspectives(&models.GetPerspectivesInput{
+		SelfModelIDs: []string{"stoic", "hedonist", "unknown"},
+		Question:     "How much do you sleep?",
+		Answer:       "About six hours",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []models.Perspective{
+		{SelfModelID: "stoic", Response: "Sleep is a discipline / How much do you sleep?"},
+		{SelfModelID: "hedonist", Response: "Sleep whenever it feels good / How much do you sleep?"},
+	}, output.Perspectives)
+	require.Contains(t, output.Errors, "unknown")
+	assert.Contains(t, output.Errors["unknown"], "self model has no beliefs")
+
+	_, err = dsvc.GetPerspectives(&models.GetPerspectivesInput{Question: "q", Answer: "a"})
+	assert.Error(t, err)
+}
+
+// orderedPerspectiveResponder makes each self model wait until the one after it in the dialectic's
+// list has responded, so responses complete in reverse order of the request.
+type orderedPerspectiveResponder struct {
+	after  map[string]chan struct{}
+	done   map[string]chan struct{}
+	failed map[string]bool
+}
+
+func newOrderedPerspectiveResponder(selfModelIDs []string) *orderedPerspectiveResponder {
+	r := &orderedPerspectiveResponder{
+		after:  make(map[string]chan struct{}),
+		done:   make(map[string]chan struct{}),
+		failed: make(map[string]bool),
+	}
+	for _, id := range selfModelIDs {
+		r.done[id] = make(chan struct{})
+	}
+	for i := 0; i < len(selfModelIDs)-1; i++ {
+		r.after[selfModelIDs[i]] = r.done[selfModelIDs[i+1]]
+	}
+	return r
+}
+
+func (r *orderedPerspectiveResponder) Respond(bs *models.BeliefSystem, request models.EpistemicRequest) (*string, error) {
+	defer close(r.done[request.SelfModelID])
+	if wait, ok := r.after[request.SelfModelID]; ok {
+		select {
+		case <-wait:
+		case <-time.After(5 * time.Second):
+			return nil, errors.New("perspectives were not requested concurrently")
+		}
+	}
+	if r.failed[request.SelfModelID] {
+		return nil, errors.New("perspective model unavailable")
+	}
+	response := fmt.Sprintf("%s on %q", request.SelfModelID, request.Content["answer"])
+	return &response, nil
+}
+
+func TestUpdateDialecticPerspectives(t *testing.T) {
+	perspectiveModelIDs := []string{"first", "second", "third"}
+
+	update := func(t *testing.T, responder svc.PerspectiveResponder, strict bool) (*models.UpdateDialecticOutput, error) {
+		kv, err := db.NewKeyValueStore("")
+		require.NoError(t, err)
+		aih := ai.NewAIHelperWithClient(scriptedClient())
+		dsvc := svc.NewDialecticService(kv, aih, responder, svc.NewDialecticEpistemology(svc.NewBeliefService(kv, aih), aih))
+
+		const selfModelID = "self-model-1"
+		require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+		created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{
+			SelfModelID:         selfModelID,
+			PerspectiveModelIDs: perspectiveModelIDs,
+			LearningObjective: &models.LearningObjective{
+				Description:  "Understand sleep habits",
+				Topics:       []string{"sleep"},
+				MaxQuestions: 1,
+			},
+		})
+		require.NoError(t, err)
+
+		return dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+			ID:                 created.DialecticID,
+			SelfModelID:        selfModelID,
+			Answer:             models.UserAnswer{UserAnswer: "I sleep eight hours"},
+			StrictPerspectives: strict,
+		})
+	}
+
+	t.Run("order follows the perspective model IDs", func(t *testing.T) {
+		updated, err := update(t, newOrderedPerspectiveResponder(perspectiveModelIDs), false)
+		require.NoError(t, err)
+		assert.Empty(t, updated.PerspectiveErrors)
+
+		perspectives := updated.Dialectic.UserInteractions[0].Perspectives
+		require.Len(t, perspectives, 3)
+		for i, id := range perspectiveModelIDs {
+			assert.Equal(t, id, perspectives[i].SelfModelID)
+			assert.Equal(t, id+` on "I sleep eight hours"`, perspectives[i].Response)
+		}
+	})
+
+	t.Run("failures are reported unless strict", func(t *testing.T) {
+		responder := newOrderedPerspectiveResponder(perspectiveModelIDs)
+		responder.failed["second"] = true
+		updated, err := update(t, responder, false)
+		require.NoError(t, err)
+		require.Contains(t, updated.PerspectiveErrors, "second")
+
+		perspectives := updated.Dialectic.UserInteractions[0].Perspectives
+		require.Len(t, perspectives, 2)
+		assert.Equal(t, "first", perspectives[0].SelfModelID)
+		assert.Equal(t, "third", perspectives[1].SelfModelID)
+
+		responder = newOrderedPerspectiveResponder(perspectiveModelIDs)
+		responder.failed["second"] = true
+		_, err = update(t, responder, true)
+		assert.Error(t, err)
+	})
+}
@@ -197,3 +197,124 @@ func TestPhilosophyExtrapolation_UpdateDisablesCaching(t *testing.T) {
 	svc.CacheMu().RUnlock()
 	require.False(t, ok)
 }
+
+func TestPhilosophyValidation_ReportsMalformedTags(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	svc := svc.NewSelfModelService(kv, nil, nil)
+	ctx := context.Background()
+
+	description := "# Broken Philosophy\n\n" +
+		"## Experiential Narrative\n" +
+		"[[S: groggy]] before anything else\n" +
+		"[[C: Circadian Rhythm]] [[S: asleep]] → [[S: awake\n" +
+		"[[C: ]] and [[X: unknown]]\n"
+
+	createOut, err := svc.CreatePhilosophy(ctx, &models.CreatePhilosophyInput{
+		Description:         description,
+		ExtrapolateContexts: true,
+	})
+	require.NoError(t, err, "validation warnings must not block creation")
+	require.NotNil(t, createOut.Philosophy)
+	require.Equal(t, []string{
+		`line 4: state "groggy" has no parent context`,
+		`line 5: unclosed tag "[[S: awake"`,
+		"line 6: empty C tag name",
+		`line 6: unrecognized tag "[[X: unknown]]"`,
+	}, createOut.Warnings)
+
+	updateOut, err := svc.UpdatePhilosophy(ctx, &models.UpdatePhilosophyInput{
+		PhilosophyID:        createOut.Philosophy.ID,
+		Description:         "[[C: Circadian Rhythm]] [[S: asleep]]",
+		ExtrapolateContexts: true,
+	})
+	require.NoError(t, err)
+	require.Empty(t, updateOut.Warnings)
+}
+
+func TestGetPhilosophy(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	creator := svc.NewSelfModelService(kv, nil, nil)
+	createOut, err := creator.CreatePhilosophy(ctx, &models.CreatePhilosophyInput{
+		Description:         "# Metabolic Health Philosophy\n\n## Experiential Narrative\n[[C: Circadian Rhythm]] [[S: asleep]] → [[S: awake]]\n[[C: Sleep Architecture]] [[S: slow-wave]]\n",
+		ExtrapolateContexts: true,
+	})
+	require.NoError(t, err)
+	philosophyID := createOut.Philosophy.ID
+
+	expected := []string{"Circadian Rhythm", "asleep", "awake", "Sleep Architecture", "slow-wave"}
+	contextNames := func(out *models.GetPhilosophyOutput) []string {
+		var names []string
+		for _, oc := range out.ExtrapolatedObservationContexts {
+			names = append(names, oc.Name)
+		}
+		return names
+	}
+
+	// The creating service serves the cached contexts
+	getOut, err := creator.GetPhilosophy(ctx, &models.GetPhilosophyInput{PhilosophyID: philosophyID})
+	require.NoError(t, err)
+	require.Equal(t, createOut.Philosophy.Description, getOut.Philosophy.Description)
+	require.Equal(t, createOut.ExtrapolatedObservationContexts, getOut.ExtrapolatedObservationContexts)
+
+	// A fresh service has an empty in-memory cache, so the contexts come from the stored record
+	fresh := svc.NewSelfModelService(kv, nil, nil)
+	getOut, err = fresh.GetPhilosophy(ctx, &models.GetPhilosophyInput{PhilosophyID: philosophyID})
+	require.NoError(t, err)
+	require.ElementsMatch(t, expected, contextNames(getOut))
+	fresh.CacheMu().RLock()
+	_, ok := fresh.Cache()[philosophyID]
+	fresh.CacheMu().RUnlock()
+	require.True(t, ok)
+
+	_, err = fresh.GetPhilosophy(ctx, &models.GetPhilosophyInput{PhilosophyID: "non-existent-id"})
+	require.Error(t, err)
+}
+
+func TestPhilosophyContextsPersistedUntilDescriptionChanges(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	ctx := context.Background()
+	s := svc.NewSelfModelService(kv, nil, nil)
+
+	desc := "Test [[C: Circadian Rhythm]] [[S: asleep]]"
+	createOut, err := s.CreatePhilosophy(ctx, &models.CreatePhilosophyInput{Description: desc, ExtrapolateContexts: true})
+	require.NoError(t, err)
+	philosophyID := createOut.Philosophy.ID
+	contextIDs := func(contexts []*models.ObservationContext) []string {
+		var ids []string
+		for _, oc := range contexts {
+			ids = append(ids, oc.ID)
+		}
+		return ids
+	}
+	originalIDs := contextIDs(createOut.ExtrapolatedObservationContexts)
+	require.Len(t, originalIDs, 2)
+
+	// Context IDs are generated on every parse, so matching IDs mean the markdown was not re-parsed
+	getOut, err := svc.NewSelfModelService(kv, nil, nil).GetPhilosophy(ctx, &models.GetPhilosophyInput{PhilosophyID: philosophyID})
+	require.NoError(t, err)
+	require.Equal(t, originalIDs, contextIDs(getOut.ExtrapolatedObservationContexts))
+
+	unchanged, err := s.UpdatePhilosophy(ctx, &models.UpdatePhilosophyInput{PhilosophyID: philosophyID, Description: desc, ExtrapolateContexts: true})
+	require.NoError(t, err)
+	require.Equal(t, originalIDs, contextIDs(unchanged.ExtrapolatedObservationContexts))
+
+	changed, err := s.UpdatePhilosophy(ctx, &models.UpdatePhilosophyInput{
+		PhilosophyID:        philosophyID,
+		Description:         "Test [[C: Sleep Architecture]]",
+		ExtrapolateContexts: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, changed.ExtrapolatedObservationContexts, 1)
+	require.Equal(t, "Sleep Architecture", changed.ExtrapolatedObservationContexts[0].Name)
+
+	stored, err := kv.Retrieve(philosophyID, "Philosophy")
+	require.NoError(t, err)
+	storedPhilosophy := stored.(*models.Philosophy)
+	require.Equal(t, contextIDs(changed.ExtrapolatedObservationContexts), contextIDs(storedPhilosophy.ExtrapolatedContexts))
+	require.NotEmpty(t, storedPhilosophy.DescriptionHash)
+}
@@ -0,0 +1,67 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inferredStates are the possible states the AI infers for every observation context.
+const inferredStates = `{"states": ["Rested", "Tired", " rested ", ""]}`
+
+func stateInferenceBeliefSystem() *models.BeliefSystem {
+	belief := &models.Belief{ID: "belief-1", Content: []models.Content{{RawStr: "I believe that I feel rested after eight hours of sleep"}}}
+	return &models.BeliefSystem{
+		Beliefs: []*models.Belief{belief},
+		EpistemicContexts: []*models.EpistemicContext{{
+			PredictiveProcessingContext: &models.PredictiveProcessingContext{
+				ObservationContexts: []*models.ObservationContext{
+					{ID: "oc-sleep", Name: "Morning energy", PossibleStates: []string{}},
+					{ID: "oc-unlinked", Name: "Diet", PossibleStates: []string{}},
+					{ID: "oc-defined", Name: "Mood", PossibleStates: []string{"Happy", "Sad"}},
+				},
+				BeliefContexts: []*models.BeliefContext{
+					{BeliefID: belief.ID, ObservationContextID: "oc-sleep"},
+				},
+			},
+		}},
+	}
+}
+
+func TestConceptualizeInfersPossibleStates(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	client := fakeai.New(inferredStates)
+	bsvc := svc.NewBeliefService(kv, ai.NewAIHelperWithClient(client))
+	bsvc.SetInferPossibleStates(true)
+
+	beliefSystem := stateInferenceBeliefSystem()
+	require.NoError(t, bsvc.ConceptualizeBeliefSystem(beliefSystem))
+
+	ppc := beliefSystem.EpistemicContexts[0].PredictiveProcessingContext
+	assert.Equal(t, []string{"Rested", "Tired"}, ppc.ObservationContexts[0].PossibleStates)
+	assert.Empty(t, ppc.ObservationContexts[1].PossibleStates, "a context without beliefs is not inferred")
+	assert.Equal(t, []string{"Happy", "Sad"}, ppc.ObservationContexts[2].PossibleStates)
+	assert.Equal(t, 1, client.Calls())
+	assert.Equal(t, map[string]float32{"Rested": 0.5, "Tired": 0.5}, ppc.BeliefContexts[0].ConditionalProbs)
+}
+
+func TestConceptualizeSkipsStateInferenceByDefault(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	client := fakeai.New(inferredStates)
+	bsvc := svc.NewBeliefService(kv, ai.NewAIHelperWithClient(client))
+
+	beliefSystem := stateInferenceBeliefSystem()
+	require.NoError(t, bsvc.ConceptualizeBeliefSystem(beliefSystem))
+
+	assert.Empty(t, beliefSystem.EpistemicContexts[0].PredictiveProcessingContext.ObservationContexts[0].PossibleStates)
+	assert.Zero(t, client.Calls())
+}
@@ -784,3 +784,102 @@ func TestExtrapolateObservationContexts_TreeStructure(t *testing.T) {
 		}
 	}
 }
+
+func TestExtrapolateObservationContexts_MetabolicHealthHeadings(t *testing.T) {
+	markdown := `# Metabolic Health Philosophy
+
+## Experiential Narrative ✦
+### Morning
+06 : 30 — I surface from dreams; [[C: Circadian Rhythm]] slides from [[S: asleep]]
+to [[S: awake]].
+A coral‑pink dawn floods the room: [[C: Zeitgeber Exposure]]
+sees [[S: bright-light-day]]; cortisol surges — [[C: Hormonal Pulse]]
+hits [[S: cortisol-peak]].
+
+### Afternoon
+12 : 30 — Lunch (quinoa‑bowl) moves [[C: Nutrient Flux]] [[S: fasted]] → [[S: fed-light]];
+[[C: Energy Expenditure]] upgrades to [[S: neat]] during a walk.
+
+### Night
+#### [[C: Sleep Architecture]] [[S: light-n1]]
+- [[C: GH Pulse]] [[S: gh-pulse]]
+  - [[C: Repair]] [[S: anabolic-dominant]]
+
+## Raison d'être
+To model how a person's day‑to‑day sensations reshape energy.
+`
+
+	contexts := models.ExtrapolateObservationContexts(markdown)
+	ctxByName := make(map[string]*models.ObservationContext)
+	nonRoot := 0
+	for _, ctx := range contexts {
+		ctxByName[ctx.Name] = ctx
+		if ctx.ParentID != "" {
+			nonRoot++
+		}
+	}
+	if nonRoot == 0 {
+		t.Fatalf("Expected at least one context with a ParentID, got none")
+	}
+
+	for name, parent := range map[string]string{
+		"Circadian Rhythm":   "Morning",
+		"Hormonal Pulse":     "Morning",
+		"Nutrient Flux":      "Afternoon",
+		"Sleep Architecture": "Night",
+		"GH Pulse":           "Sleep Architecture",
+		"Repair":             "GH Pulse",
+	} {
+		ctx, ok := ctxByName[name]
+		if !ok {
+			t.Errorf("Expected context %q to be found", name)
+			continue
+		}
+		if ctxByName[parent] == nil || ctx.ParentID != ctxByName[parent].ID {
+			t.Errorf("%s should have parent %s", name, parent)
+		}
+	}
+
+	// States keep attaching to the contexts they follow
+	if ctx, ok := ctxByName["Circadian Rhythm"]; ok && (!contains(ctx.PossibleStates, "asleep") || !contains(ctx.PossibleStates, "awake")) {
+		t.Errorf("Circadian Rhythm should have states 'asleep' and 'awake', got %v", ctx.PossibleStates)
+	}
+	if ctx, ok := ctxByName["Sleep Architecture"]; ok && !contains(ctx.PossibleStates, "light-n1") {
+		t.Errorf("Sleep Architecture should have state 'light-n1', got %v", ctx.PossibleStates)
+	}
+
+	// The section ends at the next level-two heading
+	if _, ok := ctxByName["Raison d'être"]; ok {
+		t.Errorf("Headings after the Experiential Narrative section should not become contexts")
+	}
+}
+
+func TestExtrapolateObservationContexts_MergesRepeatedContexts(t *testing.T) {
+	markdown := `## Experiential Narrative
+08 : 00 — Keyboard clicks keep [[C: Energy Expenditure]] at [[S: sedentary]];
+espresso triggers an [[S: insulin-spike]].
+12 : 30 — [[C: energy  expenditure]] upgrades to [[S: neat]] during a walk;
+dinner brings a second [[S: insulin-spike]].
+16 : 00 — HIIT pushes [[C: Energy Expenditure]] to [[S: exercise-high]], back to [[S: sedentary]] after.
+`
+
+	contexts := models.ExtrapolateObservationContexts(markdown)
+	if len(contexts) != 1 {
+		t.Fatalf("Expected repeated mentions to merge into 1 ObservationContext, got %d", len(contexts))
+	}
+
+	ctx := contexts[0]
+	if ctx.Name != "Energy Expenditure" {
+		t.Errorf("Expected the first-seen name 'Energy Expenditure', got %q", ctx.Name)
+	}
+	expected := []string{"sedentary", "insulin-spike", "neat", "exercise-high"}
+	if len(ctx.PossibleStates) != len(expected) {
+		t.Fatalf("Expected states %v, got %v", expected, ctx.PossibleStates)
+	}
+	for i, state := range expected {
+		if ctx.PossibleStates[i] != state {
+			t.Errorf("Expected states %v in first-seen order, got %v", expected, ctx.PossibleStates)
+			break
+		}
+	}
+}
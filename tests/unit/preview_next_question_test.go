@@ -0,0 +1,58 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewNextQuestionLeavesDialecticUnchanged(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(scriptedClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	updated, err := dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+		ID:          created.DialecticID,
+		SelfModelID: selfModelID,
+		Answer:      models.UserAnswer{UserAnswer: "Sleep is important to me"},
+	})
+	require.NoError(t, err)
+	before := updated.Dialectic
+
+	preview, err := dsvc.PreviewNextQuestion(&models.PreviewNextQuestionInput{
+		DialecticID: created.DialecticID,
+		SelfModelID: selfModelID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "What do you believe about sleep?", preview.Question)
+
+	dialectics, err := dsvc.ListDialectics(&models.ListDialecticsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	require.Len(t, dialectics.Dialectics, 1)
+	after := dialectics.Dialectics[0]
+	assert.Len(t, after.UserInteractions, len(before.UserInteractions))
+	assert.Equal(t, before.Version, after.Version)
+	assert.Equal(t, models.StatusPendingAnswer, after.UserInteractions[len(after.UserInteractions)-1].Status)
+}
+
+func TestPreviewNextQuestionUnknownDialectic(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(scriptedClient())
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(svc.NewBeliefService(kv, aih), aih))
+
+	_, err = dsvc.PreviewNextQuestion(&models.PreviewNextQuestionInput{DialecticID: "di_missing", SelfModelID: "self-model-1"})
+	assert.ErrorIs(t, err, svc.ErrDialecticNotFound)
+}
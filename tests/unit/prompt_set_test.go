@@ -0,0 +1,57 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuestionPromptOverride(t *testing.T) {
+	client := fakeai.New(sleepQuestion)
+	aih := ai.NewAIHelperWithClient(client, ai.WithPromptSet(ai.PromptSet{
+		QuestionGeneration: "Ask about this belief system: {{.BeliefSystem}}",
+	}))
+
+	_, err := aih.GenerateQuestion("I believe sleep matters", nil)
+	require.NoError(t, err)
+
+	requests := client.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "Ask about this belief system: I believe sleep matters", requests[0].Messages[0].Content)
+}
+
+func TestDefaultQuestionPrompt(t *testing.T) {
+	client := fakeai.New(sleepQuestion)
+	aih := ai.NewAIHelperWithClient(client)
+
+	_, err := aih.GenerateQuestion("I believe sleep matters", nil)
+	require.NoError(t, err)
+
+	requests := client.Requests()
+	require.Len(t, requests, 1)
+	systemPrompt := requests[0].Messages[0].Content
+	assert.Contains(t, systemPrompt, "Generate a single question to further understand the user's belief system.")
+	assert.Contains(t, systemPrompt, "The user's current belief system is I believe sleep matters")
+	assert.NotContains(t, systemPrompt, "existing questions asked")
+}
+
+func TestPromptSetValidate(t *testing.T) {
+	assert.NoError(t, ai.DefaultPromptSet().Validate())
+	assert.NoError(t, ai.PromptSet{}.Validate(), "empty templates fall back to the defaults")
+	assert.Error(t, ai.PromptSet{Analysis: "{{.BeliefSystem"}.Validate())
+}
+
+func TestPromptReferencingUnknownFieldFails(t *testing.T) {
+	client := fakeai.New(sleepQuestion)
+	aih := ai.NewAIHelperWithClient(client, ai.WithPromptSet(ai.PromptSet{
+		QuestionGeneration: "{{.Missing}}",
+	}))
+
+	_, err := aih.GenerateQuestion("", nil)
+	assert.Error(t, err)
+	assert.Empty(t, client.Requests())
+}
@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReprocessDialecticReplacesExtractedBeliefs(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	dsvc := svc.NewDialecticService(kv, ai.NewAIHelperWithClient(answerEchoClient()), nil, nil)
+	// answerEchoClient quotes the first answer in the prompt, which must be the one extracted from
+	dsvc.SetExtractionHistoryWindow(0)
+
+	const selfModelID = "self-model-1"
+	staleBelief := &models.Belief{ID: "stale-belief", Content: []models.Content{{RawStr: "Sleep"}}, SourceInteractionID: "sleep"}
+	unsourcedBelief := &models.Belief{ID: "unsourced-belief", Content: []models.Content{{RawStr: "Exercise"}}}
+	otherBelief := &models.Belief{ID: "other-belief", Content: []models.Content{{RawStr: "I believe breakfast matters"}}}
+	require.NoError(t, kv.ForceStore(selfModelID, "BeliefSystem", models.BeliefSystem{
+		Beliefs: []*models.Belief{staleBelief, unsourcedBelief, otherBelief},
+	}, 1))
+
+	answered := func(id, question, answer string, extracted ...*models.Belief) models.DialecticalInteraction {
+		return models.DialecticalInteraction{
+			ID:     id,
+			Status: models.StatusAnswered,
+			Type:   models.InteractionTypeQuestionAnswer,
+			Interaction: &models.InteractionData{
+				QuestionAnswer: &models.QuestionAnswerInteraction{
+					Question:         models.Question{Question: question},
+					Answer:           models.UserAnswer{UserAnswer: answer},
+					ExtractedBeliefs: extracted,
+				},
+			},
+		}
+	}
+	dialectic := models.Dialectic{
+		ID:          "di_reprocess",
+		SelfModelID: selfModelID,
+		UserInteractions: []models.DialecticalInteraction{
+			answered("sleep", "How much do you sleep?", "eight hours keeps me sharp"),
+			answered("exercise", "Do you exercise?", "daily runs clear my head", unsourcedBelief),
+		},
+		Version: 1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+
+	storedContents := func() []string {
+		stored, err := kv.Retrieve(selfModelID, "BeliefSystem")
+		require.NoError(t, err)
+		var contents []string
+		for _, belief := range stored.(*models.BeliefSystem).Beliefs {
+			contents = append(contents, belief.GetContentAsString())
+		}
+		return contents
+	}
+
+	// A dry run previews the changes without storing them
+	preview, err := dsvc.ReprocessDialectic(&models.ReprocessDialecticInput{DialecticID: dialectic.ID, SelfModelID: selfModelID, DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, preview.DryRun)
+	assert.Equal(t, []string{"stale-belief", "unsourced-belief"}, preview.RemovedBeliefIDs)
+	assert.Len(t, preview.ExtractedBeliefs, 2)
+	assert.ElementsMatch(t, []string{"Sleep", "Exercise", "I believe breakfast matters"}, storedContents())
+
+	output, err := dsvc.ReprocessDialectic(&models.ReprocessDialecticInput{DialecticID: dialectic.ID, SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stale-belief", "unsourced-belief"}, output.RemovedBeliefIDs)
+	require.Len(t, output.ExtractedBeliefs, 2)
+	assert.Equal(t, "sleep", output.ExtractedBeliefs[0].SourceInteractionID)
+	assert.Equal(t, "exercise", output.ExtractedBeliefs[1].SourceInteractionID)
+	assert.ElementsMatch(t, []string{
+		"I believe breakfast matters",
+		"I believe eight hours keeps me sharp",
+		"I believe daily runs clear my head",
+	}, storedContents())
+
+	qa := output.Dialectic.UserInteractions[1].Interaction.QuestionAnswer
+	assert.Equal(t, output.ExtractedBeliefs[1:], qa.ExtractedBeliefs)
+	assert.Equal(t, int32(2), output.Dialectic.Version)
+
+	// Reprocessing again replaces the beliefs it extracted itself
+	again, err := dsvc.ReprocessDialectic(&models.ReprocessDialecticInput{DialecticID: dialectic.ID, SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{output.ExtractedBeliefs[0].ID, output.ExtractedBeliefs[1].ID}, again.RemovedBeliefIDs)
+	assert.Len(t, storedContents(), 3)
+
+	// A dialectic updated while it was reprocessed keeps its beliefs
+	before := storedContents()
+	conflicting := &conflictingStore{KeyValueStore: kv, key: dialectic.ID}
+	conflictingSvc := svc.NewDialecticService(conflicting, ai.NewAIHelperWithClient(answerEchoClient()), nil, nil)
+	conflictingSvc.SetExtractionHistoryWindow(0)
+	_, err = conflictingSvc.ReprocessDialectic(&models.ReprocessDialecticInput{DialecticID: dialectic.ID, SelfModelID: selfModelID})
+	require.ErrorIs(t, err, db.ErrVersionConflict)
+	assert.Equal(t, before, storedContents())
+}
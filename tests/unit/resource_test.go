@@ -0,0 +1,146 @@
+package unit
+
+import (
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateResourceAsBeliefEvidence(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	const selfModelID = "self-model-1"
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "Sleeping eight hours improves memory",
+		BeliefType:    models.Statement,
+	})
+	require.NoError(t, err)
+
+	paper, err := bsvc.CreateResource(&models.CreateResourceInput{
+		SelfModelID: selfModelID,
+		Type:        models.ResourceTypeScientificPaper,
+		Content:     "Sleep deprivation impairs memory consolidation",
+		Metadata:    map[string]string{"doi": "10.1000/sleep"},
+		BeliefID:    created.Belief.ID,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, paper.Resource.ID)
+	assert.Equal(t, models.ResourceTypeScientificPaper, paper.Resource.Type)
+	require.NotNil(t, paper.Belief)
+	assert.Equal(t, []string{paper.Resource.ID}, paper.Belief.EvidenceResourceIDs)
+
+	stored, err := bsvc.GetBelief(&models.GetBeliefInput{SelfModelID: selfModelID, BeliefID: created.Belief.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{paper.Resource.ID}, stored.Belief.EvidenceResourceIDs)
+	assert.Equal(t, int32(2), stored.Belief.Version)
+
+	measurement, err := bsvc.CreateResource(&models.CreateResourceInput{
+		SelfModelID: selfModelID,
+		Type:        models.ResourceTypeMeasurementData,
+		Content:     "Recall score 0.82 after 8h sleep",
+	})
+	require.NoError(t, err)
+	assert.Nil(t, measurement.Belief)
+
+	linked, err := bsvc.AddEvidence(&models.AddEvidenceInput{
+		SelfModelID: selfModelID,
+		BeliefID:    created.Belief.ID,
+		ResourceID:  measurement.Resource.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{paper.Resource.ID, measurement.Resource.ID}, linked.Belief.EvidenceResourceIDs)
+	require.Len(t, linked.Evidence, 2)
+	assert.Equal(t, models.EvidenceTypeResource, linked.Evidence[1].Type)
+	assert.Equal(t, measurement.Resource.ID, linked.Evidence[1].ResourceID)
+
+	again, err := bsvc.AddEvidence(&models.AddEvidenceInput{
+		SelfModelID: selfModelID,
+		BeliefID:    created.Belief.ID,
+		ResourceID:  measurement.Resource.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, linked.Belief.Version, again.Belief.Version, "citing a resource twice changes nothing")
+
+	listed, err := bsvc.ListBeliefs(&models.ListBeliefsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	assert.Len(t, listed.Beliefs, 1, "resources are not listed as beliefs")
+}
+
+func TestCreateResourceRejectsInvalidInput(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	_, err = bsvc.CreateResource(&models.CreateResourceInput{SelfModelID: "self-model-1", Content: "Untyped"})
+	assert.ErrorIs(t, err, svc.ErrInvalidResource)
+
+	_, err = bsvc.CreateResource(&models.CreateResourceInput{SelfModelID: "self-model-1", Type: models.ResourceTypeChatLog})
+	assert.ErrorIs(t, err, svc.ErrInvalidResource)
+
+	_, err = bsvc.CreateResource(&models.CreateResourceInput{
+		SelfModelID: "self-model-1",
+		Type:        models.ResourceTypeChatLog,
+		Content:     "Transcript",
+		BeliefID:    "missing",
+	})
+	assert.ErrorIs(t, err, svc.ErrBeliefNotFound)
+
+	_, err = bsvc.AddEvidence(&models.AddEvidenceInput{SelfModelID: "self-model-1", BeliefID: "missing", ResourceID: "res_missing"})
+	assert.ErrorIs(t, err, svc.ErrResourceNotFound)
+}
+
+func TestListBeliefEvidenceAccumulates(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+
+	const selfModelID = "self-model-1"
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "Morning runs improve my focus",
+		BeliefType:    models.Statement,
+		BeliefEvidence: &models.BeliefEvidence{
+			Type:      models.EvidenceTypeHypothesis,
+			Content:   "Skipping a run would leave me distracted",
+			IsCounter: true,
+		},
+	})
+	require.NoError(t, err)
+
+	added, err := bsvc.AddEvidence(&models.AddEvidenceInput{
+		SelfModelID: selfModelID,
+		BeliefID:    created.Belief.ID,
+		Evidence: &models.BeliefEvidence{
+			Type:    models.EvidenceTypeAction,
+			Action:  "Ran before work",
+			Outcome: "Finished the report by noon",
+		},
+	})
+	require.NoError(t, err)
+	assert.Len(t, added.Evidence, 2)
+
+	listed, err := bsvc.ListBeliefEvidence(&models.ListBeliefEvidenceInput{SelfModelID: selfModelID, BeliefID: created.Belief.ID})
+	require.NoError(t, err)
+	require.Len(t, listed.Evidence, 2)
+	assert.Equal(t, models.EvidenceTypeHypothesis, listed.Evidence[0].Type)
+	assert.True(t, listed.Evidence[0].IsCounter)
+	assert.Equal(t, "Skipping a run would leave me distracted", listed.Evidence[0].Content)
+	assert.Equal(t, models.EvidenceTypeAction, listed.Evidence[1].Type)
+	assert.False(t, listed.Evidence[1].IsCounter)
+	assert.Equal(t, "Finished the report by noon", listed.Evidence[1].Outcome)
+	assert.NotZero(t, listed.Evidence[1].CreatedAtMillisUTC)
+
+	_, err = bsvc.AddEvidence(&models.AddEvidenceInput{SelfModelID: selfModelID, BeliefID: created.Belief.ID})
+	assert.ErrorIs(t, err, svc.ErrNoEvidence)
+
+	_, err = bsvc.ListBeliefEvidence(&models.ListBeliefEvidenceInput{SelfModelID: selfModelID, BeliefID: "missing"})
+	assert.ErrorIs(t, err, svc.ErrBeliefNotFound)
+}
@@ -0,0 +1,146 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedSelfModel stores a self model with a philosophy, two beliefs and a dialectic with a learning objective
+func seedSelfModel(t *testing.T, kv db.KeyValueStore, sms *svc.SelfModelService, bsvc *svc.BeliefService, selfModelID string) {
+	ctx := context.Background()
+
+	_, err := sms.CreateSelfModel(ctx, &models.CreateSelfModelInput{ID: selfModelID})
+	require.NoError(t, err)
+
+	philosophy, err := sms.CreatePhilosophy(ctx, &models.CreatePhilosophyInput{Description: "Stoicism [[C: adversity]]"})
+	require.NoError(t, err)
+	_, err = sms.AddPhilosophy(ctx, &models.AddPhilosophyInput{SelfModelID: selfModelID, PhilosophyID: philosophy.Philosophy.ID})
+	require.NoError(t, err)
+
+	for _, content := range []string{"I believe sleep is important", "I believe exercise improves my mood"} {
+		_, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+			SelfModelID:   selfModelID,
+			BeliefContent: content,
+			BeliefType:    models.Statement,
+		})
+		require.NoError(t, err)
+	}
+
+	dialectic := models.Dialectic{
+		ID:          "di_export",
+		SelfModelID: selfModelID,
+		LearningObjective: &models.LearningObjective{
+			Description: "Understand sleep habits",
+			Topics:      []string{"sleep"},
+		},
+		UserInteractions: []models.DialecticalInteraction{},
+		Version:          1,
+	}
+	require.NoError(t, kv.Store(selfModelID, dialectic.ID, dialectic, 1))
+}
+
+func TestExportSelfModelCapturesAllRecords(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+	sms := svc.NewSelfModelService(kv, nil, bsvc)
+
+	const selfModelID = "self-model-export"
+	seedSelfModel(t, kv, sms, bsvc, selfModelID)
+
+	output, err := sms.ExportSelfModel(context.Background(), &models.ExportSelfModelInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	document, err := json.Marshal(output.Export)
+	require.NoError(t, err)
+
+	// Once the store is gone, the document alone must hold every record
+	kv.ClearStore()
+	_, err = kv.Retrieve(selfModelID, "SelfModel")
+	require.Error(t, err)
+
+	var export models.SelfModelExport
+	require.NoError(t, json.Unmarshal(document, &export))
+
+	assert.Equal(t, models.SelfModelExportSchemaVersion, export.SchemaVersion)
+	require.NotNil(t, export.SelfModel)
+	assert.Equal(t, selfModelID, export.SelfModel.ID)
+	require.NotNil(t, export.BeliefSystem)
+	assert.Len(t, export.BeliefSystem.Beliefs, 2)
+
+	var beliefContents []string
+	for _, belief := range export.Beliefs {
+		beliefContents = append(beliefContents, belief.GetContentAsString())
+	}
+	assert.ElementsMatch(t, []string{"I believe sleep is important", "I believe exercise improves my mood"}, beliefContents)
+
+	require.Len(t, export.Dialectics, 1)
+	assert.Equal(t, "di_export", export.Dialectics[0].ID)
+	require.NotNil(t, export.Dialectics[0].LearningObjective)
+	assert.Equal(t, "Understand sleep habits", export.Dialectics[0].LearningObjective.Description)
+
+	require.Len(t, export.Philosophies, 1)
+	assert.Equal(t, export.SelfModel.Philosophies, []string{export.Philosophies[0].ID})
+	assert.Equal(t, "Stoicism [[C: adversity]]", export.Philosophies[0].Description)
+}
+
+func TestImportSelfModelRemapsReferences(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	bsvc := svc.NewBeliefService(kv, nil)
+	sms := svc.NewSelfModelService(kv, nil, bsvc)
+	ctx := context.Background()
+
+	const sourceID = "self-model-source"
+	seedSelfModel(t, kv, sms, bsvc, sourceID)
+
+	// Link the first belief to an observation context nested under another
+	stored, err := kv.Retrieve(sourceID, "BeliefSystem")
+	require.NoError(t, err)
+	bs := stored.(*models.BeliefSystem)
+	beliefID := bs.Beliefs[0].ID
+	ppc := bs.EpistemicContexts[0].PredictiveProcessingContext
+	ppc.ObservationContexts = []*models.ObservationContext{{ID: "oc_parent"}, {ID: "oc_child", ParentID: "oc_parent"}}
+	ppc.BeliefContexts = []*models.BeliefContext{{BeliefID: beliefID, ObservationContextID: "oc_child"}}
+	require.NoError(t, kv.ForceStore(sourceID, "BeliefSystem", *bs, 1))
+
+	exported, err := sms.ExportSelfModel(ctx, &models.ExportSelfModelInput{SelfModelID: sourceID})
+	require.NoError(t, err)
+	document, err := json.Marshal(exported.Export)
+	require.NoError(t, err)
+
+	const targetID = "self-model-target"
+	_, err = sms.ImportSelfModel(ctx, &models.ImportSelfModelInput{Document: document, SelfModelID: targetID})
+	require.NoError(t, err)
+
+	stored, err = kv.Retrieve(targetID, "BeliefSystem")
+	require.NoError(t, err)
+	imported := stored.(*models.BeliefSystem)
+	importedPPC := imported.EpistemicContexts[0].PredictiveProcessingContext
+	require.Len(t, importedPPC.ObservationContexts, 2)
+	require.Len(t, importedPPC.BeliefContexts, 1)
+
+	parent, child := importedPPC.ObservationContexts[0], importedPPC.ObservationContexts[1]
+	assert.NotEqual(t, "oc_parent", parent.ID)
+	assert.Equal(t, parent.ID, child.ParentID)
+
+	bc := importedPPC.BeliefContexts[0]
+	assert.Equal(t, child.ID, bc.ObservationContextID)
+	assert.NotEqual(t, beliefID, bc.BeliefID)
+	importedBelief, err := kv.Retrieve(targetID, bc.BeliefID)
+	require.NoError(t, err, "belief context should point at an imported belief")
+	assert.Equal(t, targetID, importedBelief.(*models.Belief).SelfModelID)
+
+	_, err = sms.ImportSelfModel(ctx, &models.ImportSelfModelInput{
+		Document:    []byte(`{"schema_version": 99}`),
+		SelfModelID: "self-model-future",
+	})
+	assert.ErrorIs(t, err, svc.ErrIncompatibleExport)
+}
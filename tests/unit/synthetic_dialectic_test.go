@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roleplayPrompt marks the prompts asking the AI to answer as the self model.
+const roleplayPrompt = "You are roleplaying as a user"
+
+// syntheticUserClient answers roleplay prompts as the self model and scripts everything else.
+func syntheticUserClient() *fakeai.Client {
+	return scriptedClient().On(roleplayPrompt, "I sleep eight hours a night")
+}
+
+func TestRunSyntheticDialectic(t *testing.T) {
+	tests := []struct {
+		name             string
+		objective        models.LearningObjective
+		maxTurns         int32
+		wantTurns        int
+		wantInteractions int
+		wantCompleted    bool
+	}{
+		{
+			name:             "stops at max turns with a question pending",
+			objective:        models.LearningObjective{Description: "Learn about sleep", Topics: []string{"sleep"}},
+			maxTurns:         3,
+			wantTurns:        3,
+			wantInteractions: 4,
+		},
+		{
+			name:             "stops when the completion threshold is reached",
+			objective:        models.LearningObjective{Description: "Learn about sleep", Topics: []string{"sleep"}, CompletionThreshold: 30},
+			maxTurns:         3,
+			wantTurns:        1,
+			wantInteractions: 1,
+			wantCompleted:    true,
+		},
+		{
+			name:             "max turns defaults to the question cap",
+			objective:        models.LearningObjective{Description: "Learn about sleep", Topics: []string{"sleep"}, MaxQuestions: 2},
+			wantTurns:        2,
+			wantInteractions: 2,
+			wantCompleted:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kv, err := db.NewKeyValueStore("")
+			require.NoError(t, err)
+
+			client := syntheticUserClient()
+			aih := ai.NewAIHelperWithClient(client)
+			bsvc := svc.NewBeliefService(kv, aih)
+			dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+			sms := svc.NewSelfModelService(kv, dsvc, bsvc)
+
+			const selfModelID = "synthetic-user"
+			_, err = sms.CreateSelfModel(context.Background(), &models.CreateSelfModelInput{ID: selfModelID})
+			require.NoError(t, err)
+
+			objective := tt.objective
+			output, err := dsvc.RunSyntheticDialectic(&models.RunSyntheticDialecticInput{
+				SelfModelID:       selfModelID,
+				LearningObjective: &objective,
+				MaxTurns:          tt.maxTurns,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantTurns, output.Turns)
+			assert.Equal(t, tt.wantTurns, client.CallsMatching(roleplayPrompt))
+			assert.Equal(t, tt.wantCompleted, output.Completed)
+			require.Len(t, output.Dialectic.UserInteractions, tt.wantInteractions)
+			for _, interaction := range output.Dialectic.UserInteractions[:tt.wantTurns] {
+				assert.Equal(t, models.StatusAnswered, interaction.Status)
+				assert.Equal(t, "I sleep eight hours a night", interaction.Interaction.QuestionAnswer.Answer.UserAnswer)
+			}
+
+			// The returned conversation is the stored one
+			listed, err := dsvc.ListDialectics(&models.ListDialecticsInput{SelfModelID: selfModelID})
+			require.NoError(t, err)
+			require.Len(t, listed.Dialectics, 1)
+			assert.Len(t, listed.Dialectics[0].UserInteractions, tt.wantInteractions)
+		})
+	}
+}
+
+func TestRunSyntheticDialecticErrors(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(syntheticUserClient())
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(svc.NewBeliefService(kv, aih), aih))
+
+	_, err = dsvc.RunSyntheticDialectic(&models.RunSyntheticDialecticInput{SelfModelID: "synthetic-user"})
+	assert.Error(t, err)
+
+	_, err = dsvc.RunSyntheticDialectic(&models.RunSyntheticDialecticInput{
+		SelfModelID:       "missing",
+		LearningObjective: &models.LearningObjective{Description: "Learn about sleep"},
+	})
+	assert.ErrorIs(t, err, svc.ErrSelfModelNotFound)
+}
@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ai "epistemic-me-core/ai"
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+	"epistemic-me-core/tests/fakeai"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingClient answers like scriptedClient until hang is set, then sleeps well past any budget
+// unless its context ends first. It sends the time each hanging call was given to allowed.
+func hangingClient(hang *atomic.Bool, allowed chan time.Duration) *fakeai.Client {
+	return scriptedClient().Before(func(ctx context.Context, request openai.ChatCompletionRequest) error {
+		if !hang.Load() {
+			return nil
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			select {
+			case allowed <- time.Until(deadline):
+			default:
+			}
+		}
+		return outlastBudget(ctx)
+	})
+}
+
+// outlastBudget sleeps well past any budget unless ctx ends first.
+func outlastBudget(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(10 * time.Second):
+		return nil
+	}
+}
+
+func TestUpdateDialecticBudget(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	var hang atomic.Bool
+	allowed := make(chan time.Duration, 1)
+	aih := ai.NewAIHelperWithClient(hangingClient(&hang, allowed))
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, nil, svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+
+	const budget = 200 * time.Millisecond
+	dsvc.SetUpdateBudget(budget)
+	hang.Store(true)
+
+	start := time.Now()
+	_, err = dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+		ID:          created.DialecticID,
+		SelfModelID: selfModelID,
+		Answer:      models.UserAnswer{UserAnswer: "Sleep is important to me"},
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second, "the update should fail once its budget is spent")
+
+	// The hanging call only got its share of the budget
+	require.Len(t, allowed, 1)
+	assert.LessOrEqual(t, <-allowed, budget/2)
+
+	// The dialectic is left as it was
+	dialectics, err := dsvc.ListDialectics(&models.ListDialecticsInput{SelfModelID: selfModelID})
+	require.NoError(t, err)
+	require.Len(t, dialectics.Dialectics, 1)
+	assert.Equal(t, models.StatusPendingAnswer, dialectics.Dialectics[0].UserInteractions[0].Status)
+}
+
+// slowPerspectiveClient answers like scriptedClient, except that it takes well past any budget to
+// give a perspective unless its context ends first.
+func slowPerspectiveClient() *fakeai.Client {
+	return scriptedClient().Before(func(ctx context.Context, request openai.ChatCompletionRequest) error {
+		prompt := request.Messages[0].Content
+		if !strings.Contains(prompt, "Construct a belief system") && !strings.Contains(prompt, "concise perspective") {
+			return nil
+		}
+		return outlastBudget(ctx)
+	})
+}
+
+func TestUpdateDialecticBudgetCoversPerspectives(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	aih := ai.NewAIHelperWithClient(slowPerspectiveClient())
+	bsvc := svc.NewBeliefService(kv, aih)
+	dsvc := svc.NewDialecticService(kv, aih, svc.NewPerspectiveTakingEpistemology(bsvc, aih), svc.NewDialecticEpistemology(bsvc, aih))
+
+	const selfModelID = "self-model-1"
+	require.NoError(t, kv.ForceStore(selfModelID, "SelfModel", models.SelfModel{ID: selfModelID}, 1))
+	created, err := dsvc.CreateDialectic(&models.CreateDialecticInput{
+		SelfModelID:         selfModelID,
+		PerspectiveModelIDs: []string{"perspective-model-1"},
+	})
+	require.NoError(t, err)
+
+	dsvc.SetUpdateBudget(200 * time.Millisecond)
+	start := time.Now()
+	updated, err := dsvc.UpdateDialectic(&models.UpdateDialecticInput{
+		ID:          created.DialecticID,
+		SelfModelID: selfModelID,
+		Answer:      models.UserAnswer{UserAnswer: "Sleep is important to me"},
+	})
+	assert.Less(t, time.Since(start), 2*time.Second, "perspective calls should be bound by the budget")
+
+	// The perspective ran out of its share of the budget, which a lenient update reports
+	require.NoError(t, err)
+	assert.Contains(t, updated.PerspectiveErrors, "perspective-model-1")
+}
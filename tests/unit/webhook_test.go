@@ -0,0 +1,294 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"epistemic-me-core/db"
+	"epistemic-me-core/svc"
+	"epistemic-me-core/svc/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookRequest struct {
+	body      []byte
+	signature string
+}
+
+// webhookTestSetup registers a webhook at url for a new developer and returns a belief service for
+// one of the developer's users, along with the user's ID and the webhook secret.
+func webhookTestSetup(t *testing.T, url string, retry svc.WebhookRetryPolicy) (*svc.BeliefService, *svc.WebhookDispatcher, string, string) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+
+	developerSvc := svc.NewDeveloperService(kv, nil)
+	// The test servers listen on loopback
+	developerSvc.SetAllowPrivateWebhookHosts(true)
+	developer, err := developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Integrator"})
+	require.NoError(t, err)
+	user, err := svc.NewUserService(kv, nil).CreateUser(&models.CreateUserInput{DeveloperID: developer.Developer.ID})
+	require.NoError(t, err)
+	registered, err := developerSvc.RegisterWebhook(&models.RegisterWebhookInput{
+		DeveloperID: developer.Developer.ID,
+		URL:         url,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, registered.Webhook.Secret)
+
+	dispatcher := svc.StartWebhookDispatcher(kv, retry)
+	dispatcher.SetAllowPrivateHosts(true)
+	t.Cleanup(dispatcher.Stop)
+	bsvc := svc.NewBeliefService(kv, nil)
+	bsvc.SetBeliefSystemObserver(dispatcher)
+	return bsvc, dispatcher, user.User.ID, registered.Webhook.Secret
+}
+
+func TestWebhookDeliveredOnBeliefChange(t *testing.T) {
+	received := make(chan webhookRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- webhookRequest{body: body, signature: r.Header.Get(svc.WebhookSignatureHeader)}
+	}))
+	defer server.Close()
+
+	bsvc, _, selfModelID, secret := webhookTestSetup(t, server.URL, svc.DefaultWebhookRetryPolicy)
+
+	created, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "I believe morning runs give me energy",
+		BeliefType:    models.Causal,
+	})
+	require.NoError(t, err)
+
+	var request webhookRequest
+	select {
+	case request = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	assert.Equal(t, svc.SignWebhookPayload(secret, request.body), request.signature)
+
+	var event models.WebhookEvent
+	require.NoError(t, json.Unmarshal(request.body, &event))
+	assert.Equal(t, models.WebhookEventBeliefSystemChanged, event.Type)
+	assert.Equal(t, selfModelID, event.SelfModelID)
+	require.Len(t, event.Diff.AddedBeliefs, 1)
+	assert.Equal(t, created.Belief.ID, event.Diff.AddedBeliefs[0].ID)
+	assert.Empty(t, event.Diff.UpdatedBeliefs)
+	assert.Empty(t, event.Diff.RemovedBeliefIDs)
+}
+
+func TestWebhookDeliveredForUserStoredBeforeOwners(t *testing.T) {
+	received := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer server.Close()
+
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	developerSvc := svc.NewDeveloperService(kv, nil)
+	developerSvc.SetAllowPrivateWebhookHosts(true)
+	developer, err := developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Integrator"})
+	require.NoError(t, err)
+	_, err = developerSvc.RegisterWebhook(&models.RegisterWebhookInput{DeveloperID: developer.Developer.ID, URL: server.URL})
+	require.NoError(t, err)
+
+	// A user stored without its self model owner, as users created by earlier versions were
+	user := models.User{ID: "user_legacy", DeveloperID: developer.Developer.ID}
+	require.NoError(t, kv.Store(developer.Developer.ID, "user_"+user.ID, user, 1))
+
+	dispatcher := svc.StartWebhookDispatcher(kv, svc.DefaultWebhookRetryPolicy)
+	dispatcher.SetAllowPrivateHosts(true)
+	t.Cleanup(dispatcher.Stop)
+	bsvc := svc.NewBeliefService(kv, nil)
+	bsvc.SetBeliefSystemObserver(dispatcher)
+
+	_, err = bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   user.ID,
+		BeliefContent: "I believe stretching helps my back",
+		BeliefType:    models.Causal,
+	})
+	require.NoError(t, err)
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+}
+
+func TestWebhookDeadLetteredAfterRetries(t *testing.T) {
+	attempts := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts <- struct{}{}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	bsvc, dispatcher, selfModelID, _ := webhookTestSetup(t, server.URL, svc.WebhookRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	_, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "I believe coffee after noon ruins my sleep",
+		BeliefType:    models.Causal,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(dispatcher.DeadLetters()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	letter := dispatcher.DeadLetters()[0]
+	assert.Equal(t, 3, letter.Attempts)
+	assert.Equal(t, server.URL, letter.URL)
+	assert.Equal(t, selfModelID, letter.Event.SelfModelID)
+	assert.Len(t, attempts, 3)
+}
+
+func TestWebhookRedirectsAreNotFollowed(t *testing.T) {
+	reached := make(chan struct{}, 10)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached <- struct{}{}
+	}))
+	defer target.Close()
+	redirecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer redirecting.Close()
+
+	bsvc, dispatcher, selfModelID, _ := webhookTestSetup(t, redirecting.URL, svc.WebhookRetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond})
+
+	_, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "I believe naps make me groggy",
+		BeliefType:    models.Causal,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(dispatcher.DeadLetters()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, dispatcher.DeadLetters()[0].Error, "307")
+	assert.Empty(t, reached, "the redirect target is never reached")
+}
+
+func TestWebhookDeliveryRefusesPrivateAddresses(t *testing.T) {
+	reached := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached <- struct{}{}
+	}))
+	defer server.Close()
+
+	// The webhook was accepted at registration, as when its host resolved to a public address then
+	bsvc, dispatcher, selfModelID, _ := webhookTestSetup(t, server.URL, svc.WebhookRetryPolicy{MaxAttempts: 1})
+	dispatcher.SetAllowPrivateHosts(false)
+
+	_, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "I believe cold showers wake me up",
+		BeliefType:    models.Causal,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(dispatcher.DeadLetters()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, dispatcher.DeadLetters()[0].Error, svc.ErrWebhookHostNotAllowed.Error())
+	assert.Empty(t, reached)
+}
+
+func TestRegisterWebhookRejectsInvalidURL(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	developerSvc := svc.NewDeveloperService(kv, nil)
+	developer, err := developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Integrator"})
+	require.NoError(t, err)
+
+	_, err = developerSvc.RegisterWebhook(&models.RegisterWebhookInput{
+		DeveloperID: developer.Developer.ID,
+		URL:         "ftp://example.com/hook",
+	})
+	assert.ErrorIs(t, err, svc.ErrInvalidWebhookURL)
+}
+
+func TestRegisterWebhookRejectsPrivateHosts(t *testing.T) {
+	kv, err := db.NewKeyValueStore("")
+	require.NoError(t, err)
+	developerSvc := svc.NewDeveloperService(kv, nil)
+	developer, err := developerSvc.CreateDeveloper(&models.CreateDeveloperInput{Name: "Integrator"})
+	require.NoError(t, err)
+
+	for _, url := range []string{
+		"http://localhost:8080/hook",
+		"http://127.0.0.1/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"https://10.0.0.5/hook",
+		"https://192.168.1.20/hook",
+	} {
+		_, err := developerSvc.RegisterWebhook(&models.RegisterWebhookInput{
+			DeveloperID: developer.Developer.ID,
+			URL:         url,
+		})
+		assert.ErrorIs(t, err, svc.ErrWebhookHostNotAllowed, url)
+	}
+
+	_, err = developerSvc.RegisterWebhook(&models.RegisterWebhookInput{
+		DeveloperID: developer.Developer.ID,
+		URL:         "https://93.184.215.14/hook",
+	})
+	assert.NoError(t, err)
+}
+
+func TestDiffBeliefSystems(t *testing.T) {
+	kept := &models.Belief{ID: "kept", Content: []models.Content{{RawStr: "I believe sleep matters"}}, Version: 1}
+	changed := &models.Belief{ID: "changed", Content: []models.Content{{RawStr: "I believe naps help"}}, Version: 1}
+	removed := &models.Belief{ID: "removed", Version: 1}
+	before := &models.BeliefSystem{Beliefs: []*models.Belief{kept, changed, removed}}
+
+	updated := *changed
+	updated.Version = 2
+	added := &models.Belief{ID: "added", Version: 1}
+	after := &models.BeliefSystem{Beliefs: []*models.Belief{kept, &updated, added}}
+
+	diff := models.DiffBeliefSystems(before, after)
+	assert.Equal(t, []*models.Belief{added}, diff.AddedBeliefs)
+	assert.Equal(t, []*models.Belief{&updated}, diff.UpdatedBeliefs)
+	assert.Equal(t, []string{"removed"}, diff.RemovedBeliefIDs)
+	assert.False(t, diff.EpistemicContextsChanged)
+
+	unchanged := models.DiffBeliefSystems(before, before)
+	assert.True(t, unchanged.Empty())
+}
+
+func TestWebhookStopAttemptsQueuedEvents(t *testing.T) {
+	received := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer server.Close()
+
+	bsvc, dispatcher, selfModelID, _ := webhookTestSetup(t, server.URL, svc.DefaultWebhookRetryPolicy)
+	for i := 0; i < 3; i++ {
+		_, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+			SelfModelID:   selfModelID,
+			BeliefContent: fmt.Sprintf("I believe habit %d is worth keeping", i),
+			BeliefType:    models.Causal,
+		})
+		require.NoError(t, err)
+	}
+	dispatcher.Stop()
+	assert.Len(t, received, 3, "events queued before stopping are still delivered")
+	assert.Empty(t, dispatcher.DeadLetters())
+
+	_, err := bsvc.CreateBelief(&models.CreateBeliefInput{
+		SelfModelID:   selfModelID,
+		BeliefContent: "I believe late dinners hurt my sleep",
+		BeliefType:    models.Causal,
+	})
+	require.NoError(t, err)
+	require.Len(t, dispatcher.DeadLetters(), 1, "events after stopping are dead-lettered")
+	assert.Len(t, received, 3)
+}